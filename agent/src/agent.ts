@@ -1,4 +1,4 @@
-import { generateText, stepCountIs, ModelMessage } from 'ai';
+import { generateText, stepCountIs, ModelMessage, LanguageModelUsage } from 'ai';
 import { openai } from '@ai-sdk/openai';
 import {
   createListFilesTool,
@@ -9,6 +9,7 @@ import {
   createRunCommandTool,
   createRunCypherTool,
   handleCypherResult,
+  cancelPendingCypherRequests,
   createWebSearchTool,
   createUrlExtractTool
 } from './tools';
@@ -16,17 +17,30 @@ import path from 'path';
 import fs from 'fs';
 // Message protocol between TUI and agent
 interface Message {
-  type: 'init' | 'chat' | 'error' | 'response' | 'tool_call' | 'stream_chunk' | 'cypher_result';
+  type: 'init' | 'chat' | 'error' | 'response' | 'tool_call' | 'stream_chunk' | 'cypher_result' | 'cancel' | 'context';
   data?: any;
 }
 
 //read system prompt from file agent/SYSTEM_MAIN.md
 const systemPrompt = fs.readFileSync(path.join(__dirname, '..', 'SYSTEM_MAIN.md'), 'utf8');
 
+// Tokens of two generations together, for a wrap-up made after the steps
+function addUsage(a: LanguageModelUsage, b: LanguageModelUsage): LanguageModelUsage {
+  return {
+    inputTokens: (a.inputTokens ?? 0) + (b.inputTokens ?? 0),
+    outputTokens: (a.outputTokens ?? 0) + (b.outputTokens ?? 0),
+    totalTokens: (a.totalTokens ?? 0) + (b.totalTokens ?? 0)
+  };
+}
+
 class CodingAgent {
   private model: any = null;
   private conversationHistory: ModelMessage[] = [];
   private workDir: string;
+  private abortController: AbortController | null = null;
+  private turnCount = 0;
+  // Overview of the repository from the TUI, added to the system prompt
+  private repoMap = '';
 
   constructor() {
     this.workDir = process.env.ONYX_WORK_DIR || process.cwd();
@@ -38,28 +52,36 @@ class CodingAgent {
     this.conversationHistory = [];
   }
 
+  setRepoMap(repoMap: string) {
+    this.repoMap = repoMap;
+  }
+
   //TODO take this out. I learned I can handle this with prepareStep.
   private async ensureFinalResponse(
     result: any,
     model: any,
-    history: ModelMessage[]
+    history: ModelMessage[],
+    messageId: string,
+    abortSignal?: AbortSignal
   ): Promise<any> {
     if (result.finishReason === "stop" && result.stopReason === "other") {
       console.error("[Agent] Step limit reached, generating wrap-up response");
   
       this.sendMessage({
         type: "stream_chunk",
-        data: { content: "", status: "wrapping_up" }
+        data: { content: "", status: "wrapping_up", message_id: messageId }
       });
   
       const wrapUp = await generateText({
         model,
         system: `You reached the tool call limit. Summarize progress for the user. Be detailed in explaining what you have done or learned so far. Then state what your intentded next steps are. Call the user Master Wayne.`,
-        messages: [...history]
+        messages: [...history],
+        abortSignal
       });
   
       return {
         ...wrapUp,
+        totalUsage: addUsage(result.totalUsage, wrapUp.usage),
         finishReason: "stop",
         stopReason: "other", // stays valid
         meta: { wrapUp: true } // <-- explicit flag for your TUI/frontend
@@ -79,6 +101,15 @@ class CodingAgent {
       return;
     }
 
+    const abortController = new AbortController();
+    this.abortController = abortController;
+
+    // Chunks and the final response of this turn carry its id, and each
+    // chunk the number of the step whose full text it holds, so the TUI can
+    // replace a chunk it receives again instead of appending it twice
+    const messageId = `turn-${++this.turnCount}`;
+    let step = 0;
+
     try {
       // Add user message to history
       this.conversationHistory.push({
@@ -89,7 +120,7 @@ class CodingAgent {
       // Send initial acknowledgment
       this.sendMessage({
         type: 'stream_chunk',
-        data: { content: '', status: 'thinking' }
+        data: { content: '', status: 'thinking', message_id: messageId }
       });
 
       // Store reference for closures
@@ -99,9 +130,11 @@ class CodingAgent {
       const MAX_STEPS = 25;
       const result = await generateText({
         model: this.model,
-        system: systemPrompt + ` \n\n Current working directory: ${this.workDir}`,
+        system: systemPrompt + ` \n\n Current working directory: ${this.workDir}` +
+          (this.repoMap ? `\n\n${this.repoMap}` : ''),
         messages: this.conversationHistory,
         stopWhen: stepCountIs(MAX_STEPS),
+        abortSignal: abortController.signal,
         prepareStep: ({ stepNumber, messages }) => {
           if (stepNumber === MAX_STEPS-1) {
             return {
@@ -131,13 +164,18 @@ class CodingAgent {
           web_search: createWebSearchTool(agent.sendMessage.bind(agent)),
           url_extract: createUrlExtractTool(agent.sendMessage.bind(agent))
         },
-        onStepFinish: async ({ text, finishReason }) => {
-          if (text) {
+        // Every step reports its tokens, including steps that only call
+        // tools, so the TUI can count a turn that is cancelled midway
+        onStepFinish: async ({ text, finishReason, usage }) => {
+          if (!abortController.signal.aborted) {
             this.sendMessage({
               type: 'stream_chunk',
               data: {
                 content: text,
-                status: finishReason === 'stop' ? 'done' : 'generating'
+                status: finishReason === 'stop' ? 'done' : 'generating',
+                message_id: messageId,
+                step: text ? step++ : step,
+                usage
               }
             });
           }
@@ -145,7 +183,10 @@ class CodingAgent {
       });
 
       // Ensure we have a final response, handling step limits
-      const finalResult = await this.ensureFinalResponse(result, this.model, this.conversationHistory);
+      const finalResult = await this.ensureFinalResponse(result, this.model, this.conversationHistory, messageId, abortController.signal);
+      if (abortController.signal.aborted) {
+        return;
+      }
 
       // Add assistant response to history
       this.conversationHistory.push({
@@ -158,8 +199,9 @@ class CodingAgent {
         type: 'response',
         data: {
           content: finalResult.text,
+          message_id: messageId,
           toolCalls: finalResult.toolCalls,
-          usage: finalResult.usage,
+          usage: finalResult.totalUsage,
           finishReason: finalResult.finishReason,
           stopReason: finalResult.stopReason,
           messageCount: this.conversationHistory.length
@@ -167,6 +209,11 @@ class CodingAgent {
       });
 
     } catch (error: any) {
+      if (abortController.signal.aborted) {
+        // The TUI already reported the cancellation
+        console.error('[Agent] Request cancelled');
+        return;
+      }
       console.error('[Agent] Error:', error);
       this.sendMessage({
         type: 'error',
@@ -175,7 +222,28 @@ class CodingAgent {
           details: error.toString()
         }
       });
+    } finally {
+      if (this.abortController === abortController) {
+        this.abortController = null;
+      }
+    }
+  }
+
+  // Abort the request currently being processed, if any. The request's user
+  // message is already in the history and its reply never will be, so a
+  // marker takes the reply's place: otherwise the next request would follow
+  // an unanswered user message. It is recorded here rather than when the
+  // aborted request settles, which may be after the next request has begun.
+  cancel() {
+    if (this.abortController) {
+      this.abortController.abort();
+      this.abortController = null;
+      this.conversationHistory.push({
+        role: 'assistant',
+        content: '[The user cancelled this request before it was answered.]'
+      });
     }
+    cancelPendingCypherRequests();
   }
 
   private sendMessage(message: Message) {
@@ -229,6 +297,17 @@ async function main() {
               }
               break;
               
+            case 'cancel':
+              agent.cancel();
+              break;
+
+            case 'context':
+              // Repository map sent once the TUI has built the graph
+              if (typeof message.data?.repo_map === 'string') {
+                agent.setRepoMap(message.data.repo_map);
+              }
+              break;
+
             case 'cypher_result':
               // Handle cypher query results from the Go TUI
               handleCypherResult(message.data);
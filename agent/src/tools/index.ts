@@ -4,6 +4,6 @@ export { createWriteFileTool } from './write_file';
 export { createEditFileTool } from './edit_file';
 export { createSearchFilesTool } from './search_files';
 export { createRunCommandTool } from './run_command';
-export { createRunCypherTool, handleCypherResult } from './run_cypher';
+export { createRunCypherTool, handleCypherResult, cancelPendingCypherRequests } from './run_cypher';
 export { createWebSearchTool } from './web_search';
 export { createUrlExtractTool } from './url_extract';
\ No newline at end of file
@@ -25,6 +25,14 @@ export function handleCypherResult(data: any) {
   }
 }
 
+// Resolve every outstanding query with an error when the user cancels a request
+export function cancelPendingCypherRequests() {
+  for (const [requestId, resolver] of pendingRequests) {
+    pendingRequests.delete(requestId);
+    resolver({ error: 'Query cancelled by user' });
+  }
+}
+
 export function createRunCypherTool(sendMessage: (message: any) => void) {
   return tool({
     description: 'Execute a Cypher query against the code graph database to find relationships between code entities',
@@ -75,10 +83,18 @@ export function createRunCypherTool(sendMessage: (message: any) => void) {
         };
       }
       
+      // The TUI returns the rows as a JSON array of objects keyed by column name
+      let rows: unknown = response.result;
+      try {
+        rows = JSON.parse(response.result);
+      } catch {
+        // Keep the raw text if it is not JSON
+      }
+
       return {
         query,
-        result: response.result,
-        resultCount: response.result ? response.result.split('\n').filter((line: string) => line.trim()).length : 0
+        result: rows,
+        resultCount: Array.isArray(rows) ? rows.length : 0
       };
     }
   });
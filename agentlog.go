@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Size of the agent log panel, and how many stderr lines are kept for it
+const (
+	agentLogPanelHeight = 8 // rows of the panel, border included
+	agentLogKeep        = 200
+)
+
+var agentLogStyle = lipgloss.NewStyle().
+	BorderStyle(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("#EF4444")).
+	Padding(0, 1)
+
+// agentLogMsg reports that the agent wrote to stderr
+type agentLogMsg struct{}
+
+// agentLog keeps the last lines the agent wrote to stderr. The agent's
+// stderr is copied both to agent-error.log and here, from the goroutine
+// exec runs for it, so the TUI can show it without reading the file back.
+type agentLog struct {
+	mu      sync.Mutex
+	lines   []string
+	partial string
+	notify  chan struct{}
+}
+
+func newAgentLog() *agentLog {
+	return &agentLog{notify: make(chan struct{}, 1)}
+}
+
+// Write records the complete lines of p; a trailing partial line is held
+// until the rest of it arrives
+func (l *agentLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	text := l.partial + strings.ReplaceAll(string(p), "\r\n", "\n")
+	parts := strings.Split(text, "\n")
+	l.partial = parts[len(parts)-1]
+	for _, line := range parts[:len(parts)-1] {
+		if strings.TrimSpace(line) != "" {
+			l.lines = append(l.lines, line)
+		}
+	}
+	if len(l.lines) > agentLogKeep {
+		l.lines = append([]string(nil), l.lines[len(l.lines)-agentLogKeep:]...)
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.notify <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Tail returns the last n lines written
+func (l *agentLog) Tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n > len(l.lines) {
+		n = len(l.lines)
+	}
+	return append([]string(nil), l.lines[len(l.lines)-n:]...)
+}
+
+// wait returns a command that delivers an agentLogMsg the next time the
+// agent writes to stderr. Update calls it again to keep following the log.
+func (l *agentLog) wait() tea.Cmd {
+	return func() tea.Msg {
+		<-l.notify
+		return agentLogMsg{}
+	}
+}
+
+// agentLogView renders the panel tailing the agent's stderr, below the chat
+func (m Model) agentLogView() string {
+	inner := m.width - 10 // margins, border and padding
+	rows := agentLogPanelHeight - 3
+	lines := []string{}
+	if m.agentLog != nil {
+		lines = m.agentLog.Tail(rows)
+	}
+	for i, line := range lines {
+		lines[i] = truncate(strings.ReplaceAll(line, "\t", "    "), inner)
+	}
+	if len(lines) == 0 {
+		lines = []string{statusStyle.Render("no output yet")}
+	}
+
+	heading := "Agent log"
+	path := truncate(agentErrorLogPath(), inner-len(heading)-1)
+	return agentLogStyle.Width(m.width - 6).Height(agentLogPanelHeight - 2).Render(
+		statsHeadingStyle.Render(heading) + " " + statusStyle.Render(path) + "\n" + strings.Join(lines, "\n"))
+}
+
+// agentErrorSummary returns the last lines of the agent's stderr for an
+// error message, or "" when there are none
+func (m Model) agentErrorSummary() string {
+	if m.agentLog == nil {
+		return ""
+	}
+	lines := m.agentLog.Tail(3)
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\nAgent stderr (Ctrl+L for more):\n%s", strings.Join(lines, "\n"))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	graph "github.com/onyx/onyx-tui/graph_service"
+)
+
+// Rows of a Cypher result shown in the chat. The collapsed view is enough to
+// see what the agent learned; the expanded view (Ctrl+O) is capped so a
+// large result cannot flood the viewport.
+const (
+	collapsedResultRows = 3
+	expandedResultRows  = 50
+	resultCellWidth     = 40 // characters shown of each value
+)
+
+// cypherResultMessage returns the chat message showing the result of a query
+// the agent ran: the row count and first rows, with up to expandedResultRows
+// rows as its expanded form
+func cypherResultMessage(rows *graph.QueryRows, err error) ChatMessage {
+	if err != nil {
+		return ChatMessage{Role: "result", Content: fmt.Sprintf("↳ query failed: %v", err), IsError: true}
+	}
+
+	count := fmt.Sprintf("↳ %d rows", len(rows.Rows))
+	if len(rows.Rows) == 1 {
+		count = "↳ 1 row"
+	}
+	if len(rows.Rows) == 0 {
+		return ChatMessage{Role: "result", Content: count}
+	}
+
+	msg := ChatMessage{
+		Role:    "result",
+		Content: count + "\n" + resultTable(rows, collapsedResultRows),
+	}
+	if len(rows.Rows) > collapsedResultRows {
+		msg.Content += fmt.Sprintf("\n… %d more (Ctrl+O to expand)", len(rows.Rows)-collapsedResultRows)
+		msg.Detail = count + "\n" + resultTable(rows, expandedResultRows)
+		if len(rows.Rows) > expandedResultRows {
+			msg.Detail += fmt.Sprintf("\n… %d more", len(rows.Rows)-expandedResultRows)
+		}
+	}
+	return msg
+}
+
+// resultTable renders the first limit rows as a table, shortening long
+// values such as function bodies
+func resultTable(rows *graph.QueryRows, limit int) string {
+	shown := &graph.QueryRows{Columns: rows.Columns, Rows: rows.Rows[:min(limit, len(rows.Rows))]}
+	shortened := make([][]any, len(shown.Rows))
+	for i, row := range shown.Rows {
+		shortened[i] = make([]any, len(row))
+		for j, value := range row {
+			if s, ok := value.(string); ok && len([]rune(s)) > resultCellWidth {
+				value = string([]rune(s)[:resultCellWidth-1]) + "…"
+			}
+			shortened[i][j] = value
+		}
+	}
+	shown.Rows = shortened
+
+	table, err := shown.Format(graph.QueryFormatTable)
+	if err != nil {
+		return ""
+	}
+	// The "(N rows)" footer counts only the rows shown; the full count heads
+	// the message instead
+	table = strings.TrimRight(table, "\n")
+	if i := strings.LastIndex(table, "\n"); i >= 0 {
+		table = table[:i]
+	}
+	return table
+}
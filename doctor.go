@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	graph "github.com/onyx/onyx-tui/graph_service"
+)
+
+// agentDir is where the TypeScript agent is started from, relative to the
+// directory the TUI is launched in
+const agentDir = "./agent"
+
+// doctorCheck is one environment check run by `onyx-tui doctor`
+type doctorCheck struct {
+	name string
+	run  func() (detail string, err error)
+
+	// warnOnly marks checks whose failure degrades the TUI without stopping it
+	warnOnly bool
+}
+
+// runDoctor checks that the environment can run the TUI and prints one line
+// per check. It returns the process exit code: 1 when a required check fails.
+func runDoctor(out io.Writer, workDir string) int {
+	checks := []doctorCheck{
+		{name: "Agent directory", run: checkAgentDir},
+		{name: "Agent dependencies", run: checkAgentDependencies, warnOnly: true},
+		{name: "npm on PATH", run: checkNpm},
+		{name: "Work directory writable", run: func() (string, error) { return checkWritable(workDir) }},
+		{name: "Log directory writable", run: checkLogDir, warnOnly: true},
+		{name: "KuzuDB", run: checkKuzu},
+	}
+
+	fmt.Fprintln(out, "Onyx TUI environment check")
+	fmt.Fprintln(out)
+
+	failed := 0
+	for _, check := range checks {
+		detail, err := check.run()
+		switch {
+		case err == nil:
+			fmt.Fprintf(out, "  ✓ %-24s %s\n", check.name, detail)
+		case check.warnOnly:
+			fmt.Fprintf(out, "  ! %-24s %v\n", check.name, err)
+		default:
+			fmt.Fprintf(out, "  ✗ %-24s %v\n", check.name, err)
+			failed++
+		}
+	}
+
+	fmt.Fprintln(out)
+	if failed > 0 {
+		fmt.Fprintf(out, "%d check(s) failed.\n", failed)
+		return 1
+	}
+	fmt.Fprintln(out, "All required checks passed.")
+	return 0
+}
+
+// checkAgentDir verifies the agent directory the TUI starts `npm start` in
+func checkAgentDir() (string, error) {
+	info, err := os.Stat(agentDir)
+	if err != nil {
+		return "", fmt.Errorf("%s not found; run onyx-tui from the onyx-tui directory", agentDir)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", agentDir)
+	}
+	if _, err := os.Stat(filepath.Join(agentDir, "package.json")); err != nil {
+		return "", fmt.Errorf("%s has no package.json", agentDir)
+	}
+	abs, _ := filepath.Abs(agentDir)
+	return abs, nil
+}
+
+// checkAgentDependencies verifies that `npm install` has been run for the agent
+func checkAgentDependencies() (string, error) {
+	if _, err := os.Stat(filepath.Join(agentDir, "node_modules")); err != nil {
+		return "", fmt.Errorf("node_modules missing; run `npm install` in %s", agentDir)
+	}
+	return "node_modules present", nil
+}
+
+// checkNpm verifies that npm can be found and run
+func checkNpm() (string, error) {
+	path, err := exec.LookPath("npm")
+	if err != nil {
+		return "", fmt.Errorf("npm not found; install Node.js from https://nodejs.org/")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	version, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s --version failed: %w", path, err)
+	}
+	return fmt.Sprintf("%s (%s)", path, strings.TrimSpace(string(version))), nil
+}
+
+// checkWritable verifies that files can be created in dir, where the graph
+// database is stored
+func checkWritable(dir string) (string, error) {
+	f, err := os.CreateTemp(dir, ".onyx-doctor-*")
+	if err != nil {
+		return "", fmt.Errorf("cannot write to %s: %w", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return dir, nil
+}
+
+// checkLogDir verifies the directory of onyx-tui.log; without it the TUI logs
+// to stderr, which corrupts the screen
+func checkLogDir() (string, error) {
+	if _, err := os.UserHomeDir(); err != nil {
+		return "", fmt.Errorf("cannot determine home directory: %w", err)
+	}
+	return checkWritable(onyxDir())
+}
+
+// checkKuzu creates a throwaway graph database with the full schema
+func checkKuzu() (string, error) {
+	dir, err := os.MkdirTemp("", "onyx-doctor-")
+	if err != nil {
+		return "", fmt.Errorf("cannot create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The database layer reports progress on stdout
+	origStdout := os.Stdout
+	devNull, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if devNull != nil {
+		os.Stdout = devNull
+		defer func() {
+			os.Stdout = origStdout
+			devNull.Close()
+		}()
+	}
+
+	if err := graph.CheckDatabase(filepath.Join(dir, "graphdb")); err != nil {
+		return "", err
+	}
+	return "database created and schema initialized", nil
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetPublicAPIFingerprint returns a hash of the public API of a package, so
+// two builds can be checked for API changes by comparing two strings: CI can
+// tell "the public interface of this package changed" without diffing
+// anything, and caches keyed by it survive edits to bodies and comments.
+//
+// packageOrPath is a package as DetectBreakingChanges reports it (a Go
+// package directory, or a Python or TypeScript module path such as
+// "pkg/util" for pkg/util.py or pkg/util/index.ts), a file, or a directory
+// whose files all count; "" or "." is the whole repository. The fingerprint
+// combines the "api_signature" property of the exported declarations in it,
+// which BuildGraph sets on every declaration of the public API: a hash of
+// its kind, its name qualified by its type, its visibility, its normalized
+// parameter and result types and, for Python and TypeScript, how many
+// parameters are required. Go interfaces include their methods, type
+// aliases their definition and variables and fields their type. Bodies,
+// comments, parameter names and positions are left out, as are the files
+// DetectBreakingChanges ignores: tests, and Go internal and main packages.
+// The result is "" when nothing public matches.
+//
+// Example:
+//
+//	before := oldResult.GetPublicAPIFingerprint("pkg/client")
+//	after := newResult.GetPublicAPIFingerprint("pkg/client")
+//	if before != after {
+//		fmt.Println("the public API of pkg/client changed")
+//	}
+func (r *BuildGraphResult) GetPublicAPIFingerprint(packageOrPath string) string {
+	if r.Builder == nil {
+		return ""
+	}
+	scope := path.Clean(strings.ReplaceAll(packageOrPath, "\\", "/"))
+
+	lines := make([]string, 0)
+	for key, symbol := range publicAPISymbols(r.GetAnalysisResult()) {
+		if !symbol.exported {
+			continue
+		}
+		filePath := path.Clean(strings.ReplaceAll(symbol.entity.FilePath, "\\", "/"))
+		if scope != "." && symbol.pkg != scope && filePath != scope && !strings.HasPrefix(filePath, scope+"/") {
+			continue
+		}
+		lines = append(lines, key+" "+apiFingerprint(symbol))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	sort.Strings(lines)
+	hash := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(hash[:16])
+}
+
+// markAPISignatures sets the "api_signature" property of every exported
+// declaration of the public API
+func (r *BuildGraphResult) markAPISignatures() {
+	for _, symbol := range publicAPISymbols(r.GetAnalysisResult()) {
+		// Go interface methods are stand-ins, fingerprinted with their
+		// interface
+		if symbol.exported && r.Builder.GetEntity(symbol.entity.ID) == symbol.entity {
+			symbol.entity.SetProperty("api_signature", apiFingerprint(symbol))
+		}
+	}
+}
+
+// apiFingerprint hashes what callers of a declaration depend on
+func apiFingerprint(symbol *apiSymbol) string {
+	entity := symbol.entity
+	visibility := "unexported"
+	if symbol.exported {
+		visibility = "exported"
+	}
+	parts := []string{string(entity.Type), symbol.name, visibility}
+
+	switch entity.Type {
+	case entities.EntityTypeFunction, entities.EntityTypeMethod:
+		params, typed := entity.GetProperty("parameter_types").([]string)
+		if !typed {
+			// Go interface method stand-ins and TypeScript interface members
+			// only carry their signature text
+			returnType, _ := entity.GetProperty("return_type").(string)
+			parts = append(parts, normalizeSignatureType(entity.Signature+returnType, symbol.aliases))
+			break
+		}
+		results, _ := entity.GetProperty("result_types").([]string)
+		parts = append(parts,
+			"("+strings.Join(normalizeAPITypes(params, symbol.aliases), ",")+")",
+			"("+strings.Join(normalizeAPITypes(results, symbol.aliases), ",")+")")
+		if symbol.language != "go" {
+			parts = append(parts, "required="+strconv.Itoa(requiredParameters(symbol)))
+		}
+	case entities.EntityTypeInterface:
+		methods, _ := entity.GetProperty("methods").([]string)
+		normalized := normalizeAPITypes(methods, symbol.aliases)
+		sort.Strings(normalized)
+		parts = append(parts, normalized...)
+	case entities.EntityTypeType:
+		if definition, ok := entity.GetProperty("type_definition").(string); ok {
+			parts = append(parts, normalizeSignatureType(definition, symbol.aliases))
+		}
+	case entities.EntityTypeVariable, entities.EntityTypeProperty:
+		for _, key := range []string{"type", "type_annotation"} {
+			if t, ok := entity.GetProperty(key).(string); ok && t != "" {
+				parts = append(parts, normalizeSignatureType(t, symbol.aliases))
+			}
+		}
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:8])
+}
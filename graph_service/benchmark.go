@@ -0,0 +1,293 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BenchmarkReport describes the performance of a single graph build. It is
+// produced by Benchmark and is intended both for users measuring analysis cost
+// on their own repositories and for catching performance regressions between
+// analyzer versions.
+type BenchmarkReport struct {
+	// RepoPath is the repository that was analyzed
+	RepoPath string `json:"repo_path"`
+
+	// TotalDuration is the wall-clock time of the whole build, including
+	// database setup and schema creation
+	TotalDuration time.Duration `json:"total_duration"`
+
+	// Phases lists the pipeline phases in execution order
+	Phases []PhaseTiming `json:"phases"`
+
+	// Languages maps language name to its parse throughput
+	Languages map[string]*LanguageThroughput `json:"languages"`
+
+	// PeakHeapBytes is the highest Go heap allocation observed during the build.
+	// Memory allocated natively by KuzuDB or Tree-sitter is not included.
+	PeakHeapBytes uint64 `json:"peak_heap_bytes"`
+
+	// PeakSysBytes is the highest amount of memory obtained from the OS by the Go runtime
+	PeakSysBytes uint64 `json:"peak_sys_bytes"`
+
+	// Stats are the regular build statistics for the same run
+	Stats BuildGraphStats `json:"stats"`
+}
+
+// PhaseTiming is the duration of one build phase
+type PhaseTiming struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// LanguageThroughput reports how fast files of one language were parsed
+type LanguageThroughput struct {
+	Files          int           `json:"files"`
+	Bytes          int64         `json:"bytes"`
+	ParseDuration  time.Duration `json:"parse_duration"`
+	FilesPerSecond float64       `json:"files_per_second"`
+	BytesPerSecond float64       `json:"bytes_per_second"`
+}
+
+// Benchmark phase names, in pipeline order
+const (
+	PhaseWalk    = "walk"
+	PhaseParse   = "parse"
+	PhaseDetect  = "detect"
+	PhaseResolve = "resolve"
+	PhaseStore   = "store"
+	PhasePost    = "post"
+)
+
+// memorySampleInterval controls how often heap usage is sampled during Benchmark
+const memorySampleInterval = 50 * time.Millisecond
+
+// Benchmark builds the graph for opts exactly like BuildGraph while timing each
+// phase of the pipeline (walk, parse, detect, resolve, store and the post-analysis
+// passes of WithChurn, PostPasses and ValidateAfterBuild), per-language parse
+// throughput and peak memory usage. The database is closed before returning;
+// a temporary database created because opts.DBPath was empty is removed.
+//
+// Parameters:
+//   - opts: The same options accepted by BuildGraph
+//
+// Returns:
+//   - *BenchmarkReport: Timing, throughput and memory figures for the build
+//   - error: Non-nil if the build itself fails
+//
+// Example:
+//
+//	report, err := graph.Benchmark(graph.BuildGraphOptions{RepoPath: "./my-project"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Print(report.String())
+func Benchmark(opts BuildGraphOptions) (*BenchmarkReport, error) {
+	runtime.GC()
+
+	sampler := newMemorySampler(memorySampleInterval)
+	start := time.Now()
+	result, err := BuildGraph(opts)
+	total := time.Since(start)
+	peakHeap, peakSys := sampler.stop()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		result.Close()
+		if opts.DBPath == "" {
+			os.RemoveAll(result.DBPath)
+		}
+	}()
+
+	stats := result.Builder.GetBuildStats()
+	report := &BenchmarkReport{
+		RepoPath:      opts.RepoPath,
+		TotalDuration: total,
+		Phases: []PhaseTiming{
+			{Name: PhaseWalk, Duration: stats.WalkTime},
+			{Name: PhaseParse, Duration: stats.ParseTime},
+			{Name: PhaseDetect, Duration: stats.DetectTime},
+			{Name: PhaseResolve, Duration: stats.RegistrationTime + stats.RelationshipResolutionTime},
+			{Name: PhaseStore, Duration: stats.DatabaseStorageTime},
+			{Name: PhasePost, Duration: result.postPassTime},
+		},
+		Languages:     make(map[string]*LanguageThroughput),
+		PeakHeapBytes: peakHeap,
+		PeakSysBytes:  peakSys,
+		Stats:         result.Stats,
+	}
+	if report.RepoPath == "" {
+		report.RepoPath = opts.RepoURL
+	}
+
+	for language, langStats := range stats.LanguageStats {
+		throughput := &LanguageThroughput{
+			Files:         langStats.Files,
+			Bytes:         langStats.Bytes,
+			ParseDuration: langStats.ParseTime,
+		}
+		if seconds := langStats.ParseTime.Seconds(); seconds > 0 {
+			throughput.FilesPerSecond = float64(langStats.Files) / seconds
+			throughput.BytesPerSecond = float64(langStats.Bytes) / seconds
+		}
+		report.Languages[language] = throughput
+	}
+
+	return report, nil
+}
+
+// PhaseDuration returns the duration of the named phase, or 0 if it is unknown
+func (br *BenchmarkReport) PhaseDuration(name string) time.Duration {
+	for _, phase := range br.Phases {
+		if phase.Name == name {
+			return phase.Duration
+		}
+	}
+	return 0
+}
+
+// Regressions compares this report against a baseline and describes every
+// phase or language whose cost grew by more than tolerance (0.2 means 20%
+// slower). Peak heap usage is compared with the same tolerance. An empty
+// result means no regression was detected.
+//
+// Example:
+//
+//	for _, r := range current.Regressions(baseline, 0.2) {
+//		fmt.Println("regression:", r)
+//	}
+func (br *BenchmarkReport) Regressions(baseline *BenchmarkReport, tolerance float64) []string {
+	regressions := make([]string, 0)
+	if baseline == nil {
+		return regressions
+	}
+
+	exceeds := func(current, previous float64) bool {
+		return previous > 0 && current > previous*(1+tolerance)
+	}
+
+	for _, phase := range br.Phases {
+		previous := baseline.PhaseDuration(phase.Name)
+		if exceeds(float64(phase.Duration), float64(previous)) {
+			regressions = append(regressions, fmt.Sprintf("phase %s: %v -> %v", phase.Name, previous, phase.Duration))
+		}
+	}
+
+	for _, language := range sortedLanguages(br.Languages) {
+		previous, ok := baseline.Languages[language]
+		if !ok {
+			continue
+		}
+		current := br.Languages[language]
+		// Lower throughput is worse, so compare the inverse
+		if current.BytesPerSecond > 0 && exceeds(previous.BytesPerSecond, current.BytesPerSecond) {
+			regressions = append(regressions, fmt.Sprintf("%s parse throughput: %.0f -> %.0f bytes/sec",
+				language, previous.BytesPerSecond, current.BytesPerSecond))
+		}
+	}
+
+	if exceeds(float64(br.PeakHeapBytes), float64(baseline.PeakHeapBytes)) {
+		regressions = append(regressions, fmt.Sprintf("peak heap: %d -> %d bytes", baseline.PeakHeapBytes, br.PeakHeapBytes))
+	}
+
+	return regressions
+}
+
+// String renders the report as a human-readable table
+func (br *BenchmarkReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Benchmark: %s\n", br.RepoPath)
+	fmt.Fprintf(&sb, "Total: %v\n", br.TotalDuration)
+
+	sb.WriteString("\nPhases:\n")
+	for _, phase := range br.Phases {
+		fmt.Fprintf(&sb, "  %-8s %v\n", phase.Name, phase.Duration)
+	}
+
+	sb.WriteString("\nParse throughput:\n")
+	for _, language := range sortedLanguages(br.Languages) {
+		t := br.Languages[language]
+		fmt.Fprintf(&sb, "  %-10s %5d files %10d bytes  %8.1f files/sec %12.0f bytes/sec\n",
+			language, t.Files, t.Bytes, t.FilesPerSecond, t.BytesPerSecond)
+	}
+
+	fmt.Fprintf(&sb, "\nPeak memory: heap %.1f MiB, sys %.1f MiB\n",
+		float64(br.PeakHeapBytes)/(1<<20), float64(br.PeakSysBytes)/(1<<20))
+	return sb.String()
+}
+
+// sortedLanguages returns the language keys in alphabetical order
+func sortedLanguages(languages map[string]*LanguageThroughput) []string {
+	keys := make([]string, 0, len(languages))
+	for language := range languages {
+		keys = append(keys, language)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// memorySampler periodically records peak Go memory usage in the background.
+// It reads runtime/metrics, which unlike runtime.ReadMemStats does not stop
+// the world, so sampling does not slow down the build it measures.
+type memorySampler struct {
+	done     chan struct{}
+	finished chan struct{}
+	samples  []metrics.Sample
+	peakHeap uint64
+	peakSys  uint64
+}
+
+// newMemorySampler starts sampling memory usage at the given interval
+func newMemorySampler(interval time.Duration) *memorySampler {
+	ms := &memorySampler{
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+		samples: []metrics.Sample{
+			{Name: "/memory/classes/heap/objects:bytes"},
+			{Name: "/memory/classes/total:bytes"},
+		},
+	}
+
+	go func() {
+		defer close(ms.finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		ms.sample()
+		for {
+			select {
+			case <-ticker.C:
+				ms.sample()
+			case <-ms.done:
+				ms.sample()
+				return
+			}
+		}
+	}()
+
+	return ms
+}
+
+// sample records the current memory usage if it exceeds the peak so far
+func (ms *memorySampler) sample() {
+	metrics.Read(ms.samples)
+	if heap := ms.samples[0].Value.Uint64(); heap > ms.peakHeap {
+		ms.peakHeap = heap
+	}
+	if sys := ms.samples[1].Value.Uint64(); sys > ms.peakSys {
+		ms.peakSys = sys
+	}
+}
+
+// stop ends sampling and returns the peak heap and system memory observed
+func (ms *memorySampler) stop() (uint64, uint64) {
+	close(ms.done)
+	<-ms.finished
+	return ms.peakHeap, ms.peakSys
+}
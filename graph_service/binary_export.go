@@ -0,0 +1,596 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// binaryMagic starts every binary export; binaryVersion changes whenever
+// the layout does, so that an old snapshot is rejected rather than misread
+const (
+	binaryMagic   = "ONYXGRPH"
+	binaryVersion = 1
+)
+
+// maxBinaryLength bounds the length of a string or list read from a binary
+// export, so a corrupt one fails instead of allocating without limit
+const maxBinaryLength = 1 << 30
+
+// Tags of the property values of a binary export
+const (
+	valueNil byte = iota
+	valueString
+	valueInt
+	valueInt64
+	valueUint32
+	valueBool
+	valueFloat64
+	valueStrings
+	valueInts
+	valueList
+	valueMap
+	valueJSON // Any other value, as JSON
+)
+
+// ExportBinary writes a snapshot of the graph to w in a compact binary
+// format that LoadBinary reads back: files with their content, entities
+// with their fields, properties, positions and children, and the resolved
+// and unresolved relationships with their properties, locations and
+// provenance, along with Stats, Partial and UnanalyzedFiles. Strings such
+// as names, types and paths are written once and referred to after that.
+// Syntax trees are not written, as they are rebuilt by parsing.
+//
+// Property values that are strings, numbers, booleans, or slices and maps of
+// them keep their Go types; any other value is written as JSON and reads
+// back as json.Unmarshal decodes it into an interface{}. Entities and files
+// are ordered by path and position, so snapshots of the same code are
+// identical.
+//
+// Example, caching the analysis of a CI run:
+//
+//	f, err := os.Create("graph.bin")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer f.Close()
+//	if err := result.ExportBinary(f); err != nil {
+//		log.Fatal(err)
+//	}
+func (r *BuildGraphResult) ExportBinary(w io.Writer) error {
+	if r.Builder == nil {
+		return fmt.Errorf("builder not available")
+	}
+
+	bw := &binaryWriter{w: bufio.NewWriter(w), strings: make(map[string]uint64)}
+	bw.w.WriteString(binaryMagic)
+	bw.uint(binaryVersion)
+
+	for _, n := range []int{r.Stats.FunctionsCount, r.Stats.ClassesCount, r.Stats.CallsCount,
+		r.Stats.MethodsCount, r.Stats.FilesCount, r.Stats.ErrorsCount} {
+		bw.int(int64(n))
+	}
+	bw.bool(r.Partial)
+	bw.strs(r.UnanalyzedFiles)
+
+	all := make([]*entities.Entity, 0, len(r.Builder.GetAllEntities()))
+	for _, entity := range r.Builder.GetAllEntities() {
+		all = append(all, entity)
+	}
+	sortEntities(all)
+	bw.uint(uint64(len(all)))
+	for _, e := range all {
+		bw.str(e.ID)
+		bw.str(e.Name)
+		bw.str(string(e.Type))
+		bw.str(e.FilePath)
+		bw.uint(uint64(e.StartByte))
+		bw.uint(uint64(e.EndByte))
+		bw.uint(uint64(e.StartLine()))
+		bw.uint(uint64(e.EndLine()))
+		bw.str(e.Signature)
+		bw.str(e.Body)
+		bw.str(e.DocString)
+		bw.properties(e.Properties)
+		bw.uint(uint64(len(e.Children)))
+		for _, child := range e.Children {
+			bw.str(child.ID)
+		}
+	}
+
+	files := r.Builder.GetFiles()
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	bw.uint(uint64(len(paths)))
+	for _, path := range paths {
+		file := files[path]
+		bw.str(path)
+		bw.str(file.Path)
+		bw.str(file.Language)
+		bw.bytes(file.Content)
+		members := file.GetAllEntities()
+		sortEntities(members)
+		bw.uint(uint64(len(members)))
+		for _, entity := range members {
+			bw.str(entity.ID)
+		}
+	}
+
+	for _, relationships := range [][]*entities.Relationship{r.Builder.GetAllRelationships(), r.Builder.GetUnresolvedRelationships()} {
+		bw.uint(uint64(len(relationships)))
+		for _, rel := range relationships {
+			bw.relationship(rel)
+		}
+	}
+
+	if bw.err == nil {
+		bw.err = bw.w.Flush()
+	}
+	if bw.err != nil {
+		return fmt.Errorf("failed to write binary export: %w", bw.err)
+	}
+	return nil
+}
+
+// LoadBinary reads a graph written by ExportBinary, without parsing the
+// repository again or opening a database. The result serves the graph
+// through Builder and the methods built on it, but has no Database, so
+// Cypher queries are not available; the files and entities have no syntax
+// trees, but entities still report their lines through StartLine and
+// EndLine, and their properties are the ones that were exported.
+//
+// Example:
+//
+//	f, err := os.Open("graph.bin")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer f.Close()
+//	result, err := graph.LoadBinary(f)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(len(result.GetAllEntities()), "entities")
+func LoadBinary(r io.Reader) (*BuildGraphResult, error) {
+	br := &binaryReader{r: bufio.NewReader(r)}
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(br.r, magic); err != nil || string(magic) != binaryMagic {
+		return nil, fmt.Errorf("not a binary graph export")
+	}
+	if version := br.uint(); br.err == nil && version != binaryVersion {
+		return nil, fmt.Errorf("unsupported binary graph export version %d", version)
+	}
+
+	result := &BuildGraphResult{}
+	for _, n := range []*int{&result.Stats.FunctionsCount, &result.Stats.ClassesCount, &result.Stats.CallsCount,
+		&result.Stats.MethodsCount, &result.Stats.FilesCount, &result.Stats.ErrorsCount} {
+		*n = int(br.int())
+	}
+	result.Partial = br.bool()
+	result.UnanalyzedFiles = br.strs()
+
+	all := make(map[string]*entities.Entity)
+	children := make(map[*entities.Entity][]string)
+	for i, n := 0, br.length(); i < n && br.err == nil; i++ {
+		id, name, entityType, filePath := br.str(), br.str(), entities.EntityType(br.str()), br.str()
+		startByte, endByte := uint32(br.uint()), uint32(br.uint())
+		startLine, endLine := int(br.uint()), int(br.uint())
+		entity := entities.NewRestoredEntity(id, name, entityType, filePath, startByte, endByte, startLine, endLine)
+		entity.Signature, entity.Body, entity.DocString = br.str(), br.str(), br.str()
+		for key, value := range br.properties() {
+			entity.SetProperty(key, value)
+		}
+		for j, m := 0, br.length(); j < m && br.err == nil; j++ {
+			children[entity] = append(children[entity], br.str())
+		}
+		all[id] = entity
+	}
+	for parent, ids := range children {
+		for _, id := range ids {
+			if child := all[id]; child != nil {
+				parent.AddChild(child)
+			}
+		}
+	}
+
+	files := make(map[string]*entities.File)
+	for i, n := 0, br.length(); i < n && br.err == nil; i++ {
+		key, path, language := br.str(), br.str(), br.str()
+		file := entities.NewFile(path, language, nil, br.bytes())
+		for j, m := 0, br.length(); j < m && br.err == nil; j++ {
+			if entity := all[br.str()]; entity != nil {
+				file.AddEntity(entity)
+			}
+		}
+		files[key] = file
+	}
+
+	var relationships [2][]*entities.Relationship
+	for k := range relationships {
+		n := br.length()
+		relationships[k] = make([]*entities.Relationship, 0, min(n, 1<<16))
+		for i := 0; i < n && br.err == nil; i++ {
+			rel := br.relationship()
+			rel.Source, rel.Target = all[rel.SourceID], all[rel.TargetID]
+			relationships[k] = append(relationships[k], rel)
+		}
+	}
+	if br.err != nil {
+		return nil, fmt.Errorf("failed to read binary export: %w", br.err)
+	}
+
+	builder, err := analyzer.RestoreGraphBuilder(files, all, relationships[0], relationships[1])
+	if err != nil {
+		return nil, err
+	}
+	result.Builder = builder
+	return result, nil
+}
+
+// sortEntities orders entities by file, position and ID
+func sortEntities(list []*entities.Entity) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].FilePath != list[j].FilePath {
+			return list[i].FilePath < list[j].FilePath
+		}
+		if list[i].StartByte != list[j].StartByte {
+			return list[i].StartByte < list[j].StartByte
+		}
+		return list[i].ID < list[j].ID
+	})
+}
+
+// binaryWriter writes the values of a binary export, keeping the first
+// error. A string is written in full the first time, after a zero, and as
+// one plus its index in the order of first use after that.
+type binaryWriter struct {
+	w       *bufio.Writer
+	strings map[string]uint64
+	err     error
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (bw *binaryWriter) write(p []byte) {
+	if bw.err == nil {
+		_, bw.err = bw.w.Write(p)
+	}
+}
+
+func (bw *binaryWriter) uint(v uint64) {
+	bw.write(bw.scratch[:binary.PutUvarint(bw.scratch[:], v)])
+}
+
+func (bw *binaryWriter) int(v int64) {
+	bw.write(bw.scratch[:binary.PutVarint(bw.scratch[:], v)])
+}
+
+func (bw *binaryWriter) bool(v bool) {
+	if v {
+		bw.write([]byte{1})
+	} else {
+		bw.write([]byte{0})
+	}
+}
+
+func (bw *binaryWriter) bytes(p []byte) {
+	bw.uint(uint64(len(p)))
+	bw.write(p)
+}
+
+func (bw *binaryWriter) str(s string) {
+	if index, ok := bw.strings[s]; ok {
+		bw.uint(index + 1)
+		return
+	}
+	bw.strings[s] = uint64(len(bw.strings))
+	bw.uint(0)
+	bw.bytes([]byte(s))
+}
+
+func (bw *binaryWriter) strs(list []string) {
+	bw.uint(uint64(len(list)))
+	for _, s := range list {
+		bw.str(s)
+	}
+}
+
+// properties writes a property map with its keys in order
+func (bw *binaryWriter) properties(properties map[string]interface{}) {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	bw.uint(uint64(len(keys)))
+	for _, key := range keys {
+		bw.str(key)
+		bw.value(properties[key])
+	}
+}
+
+// value writes a property value after the tag of its type
+func (bw *binaryWriter) value(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		bw.write([]byte{valueNil})
+	case string:
+		bw.write([]byte{valueString})
+		bw.str(v)
+	case int:
+		bw.write([]byte{valueInt})
+		bw.int(int64(v))
+	case int64:
+		bw.write([]byte{valueInt64})
+		bw.int(v)
+	case uint32:
+		bw.write([]byte{valueUint32})
+		bw.uint(uint64(v))
+	case bool:
+		bw.write([]byte{valueBool})
+		bw.bool(v)
+	case float64:
+		bw.write([]byte{valueFloat64})
+		bw.uint(math.Float64bits(v))
+	case []string:
+		bw.write([]byte{valueStrings})
+		bw.strs(v)
+	case []int:
+		bw.write([]byte{valueInts})
+		bw.uint(uint64(len(v)))
+		for _, n := range v {
+			bw.int(int64(n))
+		}
+	case []interface{}:
+		bw.write([]byte{valueList})
+		bw.uint(uint64(len(v)))
+		for _, item := range v {
+			bw.value(item)
+		}
+	case map[string]interface{}:
+		bw.write([]byte{valueMap})
+		bw.properties(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			if bw.err == nil {
+				bw.err = fmt.Errorf("failed to encode property value %T: %w", v, err)
+			}
+			return
+		}
+		bw.write([]byte{valueJSON})
+		bw.bytes(data)
+	}
+}
+
+// relationship writes a relationship; its resolution context, only kept for
+// debugging the build, is left out
+func (bw *binaryWriter) relationship(rel *entities.Relationship) {
+	bw.str(rel.ID)
+	bw.str(string(rel.Type))
+	bw.str(rel.SourceID)
+	bw.str(rel.TargetID)
+	bw.str(string(rel.SourceType))
+	bw.str(string(rel.TargetType))
+	bw.bool(rel.IsResolved)
+	bw.strs(rel.ResolutionErrors)
+	bw.properties(rel.Properties)
+
+	bw.bool(rel.Location != nil)
+	if loc := rel.Location; loc != nil {
+		bw.str(loc.FilePath)
+		for _, n := range []uint32{loc.StartByte, loc.EndByte, loc.Line, loc.Column} {
+			bw.uint(uint64(n))
+		}
+	}
+	bw.bool(rel.Provenance != nil)
+	if p := rel.Provenance; p != nil {
+		bw.str(p.NodeKind)
+		bw.str(p.MatchedText)
+		bw.str(p.FilePath)
+		bw.uint(uint64(p.Line))
+		bw.uint(uint64(p.Column))
+	}
+}
+
+// binaryReader reads what binaryWriter writes. After the first error every
+// read returns a zero value and the error is kept.
+type binaryReader struct {
+	r       *bufio.Reader
+	strings []string
+	err     error
+}
+
+func (br *binaryReader) fail(err error) {
+	if br.err == nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		br.err = err
+	}
+}
+
+func (br *binaryReader) uint() uint64 {
+	if br.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		br.fail(err)
+	}
+	return v
+}
+
+func (br *binaryReader) int() int64 {
+	if br.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(br.r)
+	if err != nil {
+		br.fail(err)
+	}
+	return v
+}
+
+func (br *binaryReader) byte() byte {
+	if br.err != nil {
+		return 0
+	}
+	b, err := br.r.ReadByte()
+	if err != nil {
+		br.fail(err)
+	}
+	return b
+}
+
+func (br *binaryReader) bool() bool {
+	return br.byte() != 0
+}
+
+// length reads the length of a string or list
+func (br *binaryReader) length() int {
+	n := br.uint()
+	if n > maxBinaryLength {
+		br.fail(fmt.Errorf("corrupt length %d", n))
+		return 0
+	}
+	return int(n)
+}
+
+func (br *binaryReader) bytes() []byte {
+	n := br.length()
+	if br.err != nil {
+		return nil
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(br.r, p); err != nil {
+		br.fail(err)
+		return nil
+	}
+	return p
+}
+
+func (br *binaryReader) str() string {
+	index := br.uint()
+	if br.err != nil {
+		return ""
+	}
+	if index > 0 {
+		if index > uint64(len(br.strings)) {
+			br.fail(fmt.Errorf("corrupt string reference %d", index))
+			return ""
+		}
+		return br.strings[index-1]
+	}
+	s := string(br.bytes())
+	br.strings = append(br.strings, s)
+	return s
+}
+
+func (br *binaryReader) strs() []string {
+	n := br.length()
+	if n == 0 {
+		return nil
+	}
+	list := make([]string, 0, min(n, 1<<16))
+	for i := 0; i < n && br.err == nil; i++ {
+		list = append(list, br.str())
+	}
+	return list
+}
+
+func (br *binaryReader) properties() map[string]interface{} {
+	n := br.length()
+	properties := make(map[string]interface{}, min(n, 1<<10))
+	for i := 0; i < n && br.err == nil; i++ {
+		key := br.str()
+		properties[key] = br.value()
+	}
+	return properties
+}
+
+func (br *binaryReader) value() interface{} {
+	switch tag := br.byte(); tag {
+	case valueNil:
+		return nil
+	case valueString:
+		return br.str()
+	case valueInt:
+		return int(br.int())
+	case valueInt64:
+		return br.int()
+	case valueUint32:
+		return uint32(br.uint())
+	case valueBool:
+		return br.bool()
+	case valueFloat64:
+		return math.Float64frombits(br.uint())
+	case valueStrings:
+		list := br.strs()
+		if list == nil {
+			list = []string{}
+		}
+		return list
+	case valueInts:
+		n := br.length()
+		list := make([]int, 0, min(n, 1<<16))
+		for i := 0; i < n && br.err == nil; i++ {
+			list = append(list, int(br.int()))
+		}
+		return list
+	case valueList:
+		n := br.length()
+		list := make([]interface{}, 0, min(n, 1<<16))
+		for i := 0; i < n && br.err == nil; i++ {
+			list = append(list, br.value())
+		}
+		return list
+	case valueMap:
+		return br.properties()
+	case valueJSON:
+		var v interface{}
+		if data := br.bytes(); br.err == nil {
+			if err := json.Unmarshal(data, &v); err != nil {
+				br.fail(fmt.Errorf("corrupt property value: %w", err))
+			}
+		}
+		return v
+	default:
+		br.fail(fmt.Errorf("unknown property value tag %d", tag))
+		return nil
+	}
+}
+
+func (br *binaryReader) relationship() *entities.Relationship {
+	rel := &entities.Relationship{
+		ID:         br.str(),
+		Type:       entities.RelationshipType(br.str()),
+		SourceID:   br.str(),
+		TargetID:   br.str(),
+		SourceType: entities.EntityType(br.str()),
+		TargetType: entities.EntityType(br.str()),
+		IsResolved: br.bool(),
+	}
+	rel.ResolutionErrors = br.strs()
+	rel.Properties = br.properties()
+	if br.bool() {
+		rel.Location = &entities.Location{FilePath: br.str()}
+		rel.Location.StartByte, rel.Location.EndByte = uint32(br.uint()), uint32(br.uint())
+		rel.Location.Line, rel.Location.Column = uint32(br.uint()), uint32(br.uint())
+	}
+	if br.bool() {
+		rel.Provenance = &entities.Provenance{NodeKind: br.str(), MatchedText: br.str(), FilePath: br.str()}
+		rel.Provenance.Line, rel.Provenance.Column = uint32(br.uint()), uint32(br.uint())
+	}
+	return rel
+}
@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// binaryFixture is a small repository covering the analyzers whose entities
+// have syntax nodes and those that only have spans
+var binaryFixture = map[string]string{
+	"go.mod": "module example.com/shop\n\ngo 1.22\n",
+	"store/store.go": `package store
+
+import "errors"
+
+// ErrMissing is returned for unknown keys
+var ErrMissing = errors.New("missing key")
+
+// Store keeps values by key
+type Store struct {
+	values map[string]int ` + "`json:\"values\"`" + `
+}
+
+// Get returns the value stored under key
+func (s *Store) Get(key string) (int, error) {
+	v, ok := s.values[key]
+	if !ok {
+		return 0, ErrMissing
+	}
+	return v, nil
+}
+`,
+	"main.go": `package main
+
+import "example.com/shop/store"
+
+func main() {
+	s := &store.Store{}
+	_, _ = s.Get("a")
+}
+`,
+	"web/server.ts": `import express from 'express';
+
+const app = express();
+
+export function listUsers(req: any, res: any) {
+  res.json([]);
+}
+
+app.get('/api/users', listUsers);
+`,
+	"infra/main.tf": `resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+`,
+}
+
+func TestExportBinaryRoundTrip(t *testing.T) {
+	repo := t.TempDir()
+	for path, content := range binaryFixture {
+		full := filepath.Join(repo, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := BuildGraph(BuildGraphOptions{RepoPath: repo, DBPath: filepath.Join(t.TempDir(), "graph.db"), CleanupDB: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Close()
+
+	var snapshot bytes.Buffer
+	if err := result.ExportBinary(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBinary(bytes.NewReader(snapshot.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Stats != result.Stats || loaded.Partial != result.Partial {
+		t.Errorf("stats = %+v partial %v, want %+v partial %v", loaded.Stats, loaded.Partial, result.Stats, result.Partial)
+	}
+
+	before, after := result.Builder.GetAllEntities(), loaded.Builder.GetAllEntities()
+	if len(before) == 0 || len(after) != len(before) {
+		t.Fatalf("loaded %d entities, want %d", len(after), len(before))
+	}
+	for id, want := range before {
+		got := after[id]
+		if got == nil {
+			t.Errorf("entity %s %s missing after loading", want.Type, want.Name)
+			continue
+		}
+		if diff := entityDifference(want, got); diff != "" {
+			t.Errorf("entity %s %s differs after loading: %s", want.Type, want.Name, diff)
+		}
+	}
+
+	for _, pair := range []struct {
+		name          string
+		before, after []*entities.Relationship
+	}{
+		{"resolved", result.Builder.GetAllRelationships(), loaded.Builder.GetAllRelationships()},
+		{"unresolved", result.Builder.GetUnresolvedRelationships(), loaded.Builder.GetUnresolvedRelationships()},
+	} {
+		if len(pair.before) == 0 || len(pair.after) != len(pair.before) {
+			t.Fatalf("loaded %d %s relationships, want %d", len(pair.after), pair.name, len(pair.before))
+		}
+		for i, want := range pair.before {
+			if diff := relationshipDifference(want, pair.after[i]); diff != "" {
+				t.Errorf("%s relationship %s %s differs after loading: %s", pair.name, want.Type, want.ID, diff)
+			}
+		}
+	}
+
+	// A snapshot of the loaded graph is the snapshot it was loaded from
+	var again bytes.Buffer
+	if err := loaded.ExportBinary(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), snapshot.Bytes()) {
+		t.Error("exporting the loaded graph gives a different snapshot")
+	}
+}
+
+// entityDifference names the first field of an entity that a snapshot did
+// not preserve, or returns "" when there is none
+func entityDifference(want, got *entities.Entity) string {
+	switch {
+	case got.Name != want.Name || got.Type != want.Type || got.FilePath != want.FilePath:
+		return "identity"
+	case got.StartByte != want.StartByte || got.EndByte != want.EndByte:
+		return "byte span"
+	case got.StartLine() != want.StartLine() || got.EndLine() != want.EndLine():
+		return "lines"
+	case got.Signature != want.Signature || got.Body != want.Body || got.DocString != want.DocString:
+		return "source text"
+	case !reflect.DeepEqual(got.Properties, want.Properties):
+		return "properties"
+	case len(got.Children) != len(want.Children):
+		return "children"
+	case (got.Parent == nil) != (want.Parent == nil) || got.Parent != nil && got.Parent.ID != want.Parent.ID:
+		return "parent"
+	}
+	for i := range want.Children {
+		if got.Children[i].ID != want.Children[i].ID {
+			return "children"
+		}
+	}
+	return ""
+}
+
+// relationshipDifference names the first field of a relationship that a
+// snapshot did not preserve, or returns "" when there is none
+func relationshipDifference(want, got *entities.Relationship) string {
+	switch {
+	case got.ID != want.ID || got.Type != want.Type || got.SourceID != want.SourceID || got.TargetID != want.TargetID:
+		return "identity"
+	case got.SourceType != want.SourceType || got.TargetType != want.TargetType || got.IsResolved != want.IsResolved:
+		return "resolution"
+	case !reflect.DeepEqual(got.Properties, want.Properties):
+		return "properties"
+	case !reflect.DeepEqual(got.Location, want.Location):
+		return "location"
+	case !reflect.DeepEqual(got.Provenance, want.Provenance):
+		return "provenance"
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetBindings returns the dependency-injection bindings of the repository:
+// the places where an interface, or an injection token, is bound to the
+// concrete type used for it at runtime. This answers which implementation an
+// injected interface actually gets, which IMPLEMENTS alone does not.
+// With an empty interfaceName every binding is returned; otherwise only the
+// bindings of that interface, matched by name.
+//
+// Bindings are recognized in:
+//   - Go: wire.Bind(new(Iface), new(*Impl)), fx.Annotate(NewImpl,
+//     fx.As(new(Iface))), constructors returning an interface with a
+//     composite literal (func NewStore() Store { return &pgStore{} }) and
+//     interface-typed variables initialized with one
+//   - TypeScript: Angular and NestJS providers ({provide: Logger, useClass:
+//     ConsoleLogger}) and InversifyJS container.bind<Iface>(...).to(Impl)
+//   - Python: injector bindings, binder.bind(Iface, to=Impl)
+//
+// Each Binding entity is named "Iface -> Impl" and carries the "interface",
+// "implementation" and "framework" ("wire", "fx", "constructor", "variable",
+// "provider", "inversify" or "injector") and, inside a function, the
+// "enclosing_function" ID and "enclosing_function_name". In the graph it has
+// a PROVIDES relationship to the interface and a BOUND_TO relationship to the
+// implementation when they are declared in the repository; an fx constructor
+// from another file is bound as the Function itself. Results are ordered by
+// file and position.
+//
+// Example:
+//
+//	bindings, err := result.GetBindings("Store")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, b := range bindings {
+//		fmt.Printf("%s:%d %v (%v)\n", b.FilePath, b.StartLine(), b.GetProperty("implementation"), b.GetProperty("framework"))
+//	}
+func (r *BuildGraphResult) GetBindings(interfaceName string) ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	bindings := r.entitiesOfType(entities.EntityTypeBinding)
+	if interfaceName == "" {
+		return bindings, nil
+	}
+	matching := make([]*entities.Entity, 0)
+	for _, binding := range bindings {
+		if binding.GetProperty("interface") == interfaceName {
+			matching = append(matching, binding)
+		}
+	}
+	return matching, nil
+}
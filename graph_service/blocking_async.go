@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetBlockingInAsync returns the synchronous calls made inside async
+// functions that block the event loop, and with it every other request the
+// process is serving, until they return: a common performance bug of Node.js
+// and Python asyncio services.
+//
+// Python async def functions are checked for time.sleep, requests and
+// urllib.request.urlopen, subprocess and os.system, and for psycopg2,
+// psycopg, sqlite3, pymysql, MySQLdb, mysql.connector, pymongo and redis
+// connections; TypeScript and JavaScript async functions and arrow functions
+// for the functions of fs, child_process, crypto and zlib ending in Sync and
+// for better-sqlite3. A method called on a connection of a synchronous
+// driver, or on a cursor or statement obtained from one, is a blocking
+// database call wherever the connection was opened. Imports are followed, so
+// an aliased or destructured import is recognized, but calls made by a
+// nested function or lambda, which may run in a thread, and functions the
+// async function calls are not checked. Test files and functions marked
+// with an onyx:ignore comment are skipped.
+//
+// Each BlockingInAsync entity spans the call and carries the "callee" as
+// written, the "qualified_callee" with imports resolved (time.sleep,
+// fs.readFileSync), the "kind" of work ("sleep", "file", "process",
+// "network", "database" or "cpu"), a non-blocking "suggestion", a
+// "message", its "line", the "async_function" name and its
+// "async_function_line", and the "enclosing_function". The enclosing
+// function links to it with a HAS_ISSUE relationship. Results are ordered by
+// file and position.
+//
+// Example:
+//
+//	calls, err := result.GetBlockingInAsync()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, call := range calls {
+//		fmt.Printf("%s:%v %v\n", call.FilePath, call.GetProperty("line"), call.GetProperty("message"))
+//	}
+func (r *BuildGraphResult) GetBlockingInAsync() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeBlockingInAsync), nil
+}
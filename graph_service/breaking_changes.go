@@ -0,0 +1,540 @@
+package graph
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of BreakingChange
+const (
+	BreakingRemoved                = "removed"
+	BreakingRenamed                = "renamed"
+	BreakingSignatureChanged       = "signature_changed"
+	BreakingInterfaceMethodRemoved = "interface_method_removed"
+	BreakingVisibilityNarrowed     = "visibility_narrowed"
+)
+
+// BreakingChange is a change to the public API between two versions of a
+// repository that can break its callers
+type BreakingChange struct {
+	// Kind is one of "removed", "renamed", "signature_changed",
+	// "interface_method_removed" or "visibility_narrowed"
+	Kind string `json:"kind"`
+
+	// Symbol is the name in the old version, qualified by its type for
+	// members, e.g. "Server.Start"
+	Symbol     string `json:"symbol"`
+	EntityType string `json:"entity_type"`
+
+	// Package is the Go package directory or the Python/TypeScript module
+	// path that declares the symbol
+	Package  string `json:"package"`
+	FilePath string `json:"file_path"` // in the old version
+	Line     int    `json:"line"`
+
+	// Old and New are the signatures before and after the change. New is
+	// empty for removals and is the new name for renames.
+	Old string `json:"old"`
+	New string `json:"new,omitempty"`
+}
+
+var goPackageMain = regexp.MustCompile(`(?m)^package\s+main\b`)
+
+// apiSymbol is a public declaration keyed by its package and qualified name
+type apiSymbol struct {
+	key      string
+	pkg      string
+	name     string // qualified name
+	language string
+	entity   *entities.Entity
+	exported bool
+	aliases  map[string]string
+	content  []byte // source of the declaring file
+}
+
+// DetectBreakingChanges compares the public API of two analyses of the same
+// repository, typically taken before and after a release, and reports the
+// changes that require a major version bump:
+//   - exported symbols that were removed, or renamed when a new exported
+//     symbol of the same kind, owner and signature replaced them
+//   - exported functions and methods whose signature changed incompatibly
+//   - methods removed from exported interfaces
+//   - exported symbols that still exist but are no longer exported
+//
+// The public API is what the "exported" entity property marks as visible
+// outside its module. Go test files and internal and main packages, and test
+// files in Python and TypeScript, are not part of it. Symbols may move
+// between files of the same Go package or module without being reported.
+//
+// Signatures are compared on their normalized parameter and result types. Go
+// signatures must match exactly; in Python and TypeScript, parameters may be
+// added when they are optional, and unannotated types match any type.
+//
+// Results are ordered by file and line in the old version.
+//
+// Example:
+//
+//	before, _ := graph.BuildGraph(graph.BuildGraphOptions{RepoPath: oldCheckout, CleanupDB: true})
+//	after, _ := graph.BuildGraph(graph.BuildGraphOptions{RepoPath: newCheckout, CleanupDB: true})
+//	for _, change := range graph.DetectBreakingChanges(before.GetAnalysisResult(), after.GetAnalysisResult()) {
+//		fmt.Printf("%s:%d %s %s\n", change.FilePath, change.Line, change.Kind, change.Symbol)
+//	}
+func DetectBreakingChanges(old, new *AnalysisResult) []BreakingChange {
+	oldSymbols := publicAPISymbols(old)
+	newSymbols := publicAPISymbols(new)
+
+	changes := make([]BreakingChange, 0)
+	reported := make(map[string]bool)
+	report := func(kind string, before *apiSymbol, newValue string) {
+		changes = append(changes, newBreakingChange(kind, before, newValue))
+		reported[before.key] = true
+	}
+	removed := make([]*apiSymbol, 0)
+	for key, before := range oldSymbols {
+		if !before.exported {
+			continue
+		}
+		after, ok := newSymbols[key]
+		switch {
+		case ok && after.exported:
+			if signatureChanged(before, after) {
+				report(BreakingSignatureChanged, before, apiSignature(after.entity))
+			}
+		case ok:
+			report(BreakingVisibilityNarrowed, before, "")
+		default:
+			if narrowed := findNarrowed(before, newSymbols); narrowed != nil {
+				report(BreakingVisibilityNarrowed, before, narrowed.name)
+			} else {
+				removed = append(removed, before)
+			}
+		}
+	}
+
+	added := make([]*apiSymbol, 0)
+	for key, after := range newSymbols {
+		if _, ok := oldSymbols[key]; !ok && after.exported {
+			added = append(added, after)
+		}
+	}
+	renamedTo := matchRenames(removed, added)
+
+	for _, before := range removed {
+		kind := BreakingRemoved
+		if owner := apiOwner(before.entity); owner != nil && owner.Type == entities.EntityTypeInterface {
+			kind = BreakingInterfaceMethodRemoved
+		}
+		if after, ok := renamedTo[before]; ok {
+			report(BreakingRenamed, before, after.name)
+			continue
+		}
+		report(kind, before, "")
+	}
+
+	// Members of a type that was itself removed, renamed or unexported are
+	// covered by the change to the type
+	kept := changes[:0]
+	for _, change := range changes {
+		if i := strings.LastIndex(change.Symbol, "."); i < 0 || !reported[change.Package+"#"+change.Symbol[:i]] {
+			kept = append(kept, change)
+		}
+	}
+	changes = kept
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].FilePath != changes[j].FilePath {
+			return changes[i].FilePath < changes[j].FilePath
+		}
+		if changes[i].Line != changes[j].Line {
+			return changes[i].Line < changes[j].Line
+		}
+		return changes[i].Symbol < changes[j].Symbol
+	})
+	return changes
+}
+
+// RecommendVersionBump returns the semantic version component to increment
+// for the changes between two analyses: "major" when DetectBreakingChanges
+// finds anything, "minor" when exported symbols were added, otherwise "patch"
+func RecommendVersionBump(old, new *AnalysisResult) string {
+	if len(DetectBreakingChanges(old, new)) > 0 {
+		return "major"
+	}
+
+	oldSymbols := publicAPISymbols(old)
+	for key, after := range publicAPISymbols(new) {
+		if before, ok := oldSymbols[key]; after.exported && (!ok || !before.exported) {
+			return "minor"
+		}
+	}
+	return "patch"
+}
+
+// publicAPISymbols collects the declarations of an analysis that can be part
+// of the public API, exported or not, keyed by package and qualified name.
+// Go interface methods, which are not entities, are added from the methods
+// property of their interface.
+func publicAPISymbols(analysis *AnalysisResult) map[string]*apiSymbol {
+	symbols := make(map[string]*apiSymbol)
+	if analysis == nil {
+		return symbols
+	}
+
+	for filePath, file := range analysis.Files {
+		pkg, ok := apiPackage(filePath, file)
+		if !ok {
+			continue
+		}
+		aliases := importAliases(file)
+
+		fileEntities := file.GetAllEntities()
+		sortEntitiesBySource(fileEntities)
+		for _, entity := range fileEntities {
+			exported, ok := entity.GetProperty("exported").(bool)
+			if !ok {
+				continue
+			}
+			symbol := &apiSymbol{
+				pkg:      pkg,
+				name:     apiName(entity),
+				language: file.Language,
+				entity:   entity,
+				exported: exported,
+				aliases:  aliases,
+				content:  file.Content,
+			}
+			symbol.key = pkg + "#" + symbol.name
+			if _, exists := symbols[symbol.key]; !exists {
+				symbols[symbol.key] = symbol
+			}
+
+			methods, _ := entity.GetProperty("methods").([]string)
+			for _, method := range methods {
+				name := method[:strings.Index(method, "(")]
+				member := &apiSymbol{
+					pkg:      pkg,
+					name:     entity.Name + "." + name,
+					language: file.Language,
+					entity:   goInterfaceMethod(entity, name, method),
+					exported: exported && startsWithUpper(name),
+					aliases:  aliases,
+					content:  file.Content,
+				}
+				member.key = pkg + "#" + member.name
+				symbols[member.key] = member
+			}
+		}
+	}
+	return symbols
+}
+
+// goInterfaceMethod builds a stand-in entity for a Go interface method so that
+// it can be compared like the methods of other languages
+func goInterfaceMethod(iface *entities.Entity, name, signature string) *entities.Entity {
+	method := entities.NewSpanEntity(iface.ID+"."+name, name, entities.EntityTypeMethod, iface.FilePath,
+		iface.StartByte, iface.EndByte, iface.StartLine(), iface.EndLine())
+	method.Signature = signature
+	method.Parent = iface
+	return method
+}
+
+// apiPackage returns the package or module path of a file, and false for files
+// that cannot contribute to the public API
+func apiPackage(filePath string, file *entities.File) (string, bool) {
+	filePath = path.Clean(strings.ReplaceAll(filePath, "\\", "/"))
+	dir, base := path.Split(filePath)
+	dir = strings.TrimSuffix(dir, "/")
+	segments := strings.Split(dir, "/")
+
+	switch file.Language {
+	case "go":
+		if strings.HasSuffix(base, "_test.go") || goPackageMain.Match(file.Content) {
+			return "", false
+		}
+		for _, segment := range segments {
+			if segment == "internal" || segment == "testdata" {
+				return "", false
+			}
+		}
+		return dir, true
+
+	case "python":
+		if strings.HasPrefix(base, "test_") || strings.HasSuffix(base, "_test.py") || base == "conftest.py" {
+			return "", false
+		}
+		for _, segment := range segments {
+			if segment == "tests" || segment == "test" {
+				return "", false
+			}
+		}
+		module := strings.TrimSuffix(filePath, ".py")
+		return strings.TrimSuffix(module, "/__init__"), true
+
+	case "typescript", "javascript":
+		if strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") {
+			return "", false
+		}
+		for _, segment := range segments {
+			if segment == "__tests__" {
+				return "", false
+			}
+		}
+		module := strings.TrimSuffix(filePath, ".d.ts")
+		module = strings.TrimSuffix(module, path.Ext(module))
+		return strings.TrimSuffix(module, "/index"), true
+	}
+	return "", false
+}
+
+// apiName qualifies members by the type that declares them
+func apiName(entity *entities.Entity) string {
+	if owner := apiOwner(entity); owner != nil {
+		return owner.Name + "." + entity.Name
+	}
+	if receiver, ok := entity.GetProperty("receiver").(string); ok {
+		return analyzer.ReceiverTypeName(receiver) + "." + entity.Name
+	}
+	return entity.Name
+}
+
+// apiOwner returns the class, struct or interface declaring a member
+func apiOwner(entity *entities.Entity) *entities.Entity {
+	if entity.Parent == nil {
+		return nil
+	}
+	switch entity.Parent.Type {
+	case entities.EntityTypeClass, entities.EntityTypeStruct, entities.EntityTypeInterface:
+		return entity.Parent
+	}
+	return nil
+}
+
+// findNarrowed finds the unexported spelling of a symbol that lost its export:
+// a lower-cased Go name or an underscore-prefixed Python name
+func findNarrowed(before *apiSymbol, symbols map[string]*apiSymbol) *apiSymbol {
+	qualifier, name := "", before.name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qualifier, name = name[:i+1], name[i+1:]
+	}
+
+	var candidate string
+	switch before.language {
+	case "go":
+		r, size := utf8.DecodeRuneInString(name)
+		candidate = string(unicode.ToLower(r)) + name[size:]
+	case "python":
+		candidate = "_" + name
+	default:
+		return nil
+	}
+
+	if after, ok := symbols[before.pkg+"#"+qualifier+candidate]; ok && !after.exported && after.entity.Type == before.entity.Type {
+		return after
+	}
+	return nil
+}
+
+// matchRenames pairs removed symbols with added ones of the same package,
+// owner and kind whose signature is identical. Declarations without
+// parameters carry little signal, so their bodies must match as well. Only
+// unambiguous one-to-one matches are treated as renames.
+func matchRenames(removed, added []*apiSymbol) map[*apiSymbol]*apiSymbol {
+	fingerprint := func(symbol *apiSymbol) string {
+		entity := symbol.entity
+		owner := ""
+		if i := strings.LastIndex(symbol.name, "."); i >= 0 {
+			owner = symbol.name[:i]
+		}
+
+		shape := ""
+		params, hasParams := entity.GetProperty("parameter_types").([]string)
+		if hasParams && len(params) > 0 {
+			results, _ := entity.GetProperty("result_types").([]string)
+			shape = strings.Join(normalizeAPITypes(params, symbol.aliases), ",") + "->" + strings.Join(normalizeAPITypes(results, symbol.aliases), ",")
+		} else if body := strings.Join(strings.Fields(entity.Body), " "); body != "" {
+			shape = "body:" + body
+		} else if def, ok := entity.GetProperty("type_definition").(string); ok {
+			shape = "type:" + strings.Join(strings.Fields(def), " ")
+		} else {
+			return ""
+		}
+		return strings.Join([]string{symbol.pkg, owner, string(entity.Type), shape}, "|")
+	}
+
+	removedBy := make(map[string][]*apiSymbol)
+	for _, symbol := range removed {
+		if fp := fingerprint(symbol); fp != "" {
+			removedBy[fp] = append(removedBy[fp], symbol)
+		}
+	}
+	addedBy := make(map[string][]*apiSymbol)
+	for _, symbol := range added {
+		if fp := fingerprint(symbol); fp != "" {
+			addedBy[fp] = append(addedBy[fp], symbol)
+		}
+	}
+
+	renames := make(map[*apiSymbol]*apiSymbol)
+	for fp, candidates := range removedBy {
+		if len(candidates) == 1 && len(addedBy[fp]) == 1 {
+			renames[candidates[0]] = addedBy[fp][0]
+		}
+	}
+	return renames
+}
+
+// signatureChanged reports whether an exported function or method present in
+// both versions can no longer be called the way it was
+func signatureChanged(before, after *apiSymbol) bool {
+	if before.entity.Type != entities.EntityTypeFunction && before.entity.Type != entities.EntityTypeMethod {
+		return false
+	}
+
+	oldParams, oldTyped := before.entity.GetProperty("parameter_types").([]string)
+	newParams, newTyped := after.entity.GetProperty("parameter_types").([]string)
+	if !oldTyped || !newTyped {
+		// Go interface method stand-ins and TypeScript interface members
+		// only carry their signature text
+		oldReturn, _ := before.entity.GetProperty("return_type").(string)
+		newReturn, _ := after.entity.GetProperty("return_type").(string)
+		return normalizeSignatureType(before.entity.Signature+oldReturn, before.aliases) !=
+			normalizeSignatureType(after.entity.Signature+newReturn, after.aliases)
+	}
+	oldResults, _ := before.entity.GetProperty("result_types").([]string)
+	newResults, _ := after.entity.GetProperty("result_types").([]string)
+
+	oldTypes := normalizeAPITypes(oldParams, before.aliases)
+	newTypes := normalizeAPITypes(newParams, after.aliases)
+	if before.language == "go" {
+		return strings.Join(oldTypes, ",") != strings.Join(newTypes, ",") ||
+			strings.Join(normalizeAPITypes(oldResults, before.aliases), ",") != strings.Join(normalizeAPITypes(newResults, after.aliases), ",")
+	}
+	return !parametersCompatible(oldTypes, newTypes, requiredParameters(before), requiredParameters(after)) ||
+		!resultsCompatible(normalizeAPITypes(oldResults, before.aliases), normalizeAPITypes(newResults, after.aliases))
+}
+
+// parametersCompatible reports whether every call valid against the old
+// parameters is still valid: existing parameters keep their types, no optional
+// parameter became required, and new parameters are optional
+func parametersCompatible(oldTypes, newTypes []string, oldRequired, newRequired int) bool {
+	if newRequired > oldRequired {
+		return false
+	}
+	if len(newTypes) < len(oldTypes) && !hasRestParameter(newTypes) {
+		return false
+	}
+	for i, oldType := range oldTypes {
+		if i >= len(newTypes) {
+			break
+		}
+		if oldType != "" && newTypes[i] != "" && oldType != newTypes[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// resultsCompatible treats a missing annotation as matching any type
+func resultsCompatible(oldTypes, newTypes []string) bool {
+	if len(oldTypes) == 0 || len(newTypes) == 0 {
+		return true
+	}
+	return strings.Join(oldTypes, ",") == strings.Join(newTypes, ",")
+}
+
+// hasRestParameter reports whether the last parameter is variadic
+func hasRestParameter(types []string) bool {
+	return len(types) > 0 && strings.HasPrefix(types[len(types)-1], "...")
+}
+
+// requiredParameters counts the leading Python or TypeScript parameters that
+// have no default value and are not optional or variadic
+func requiredParameters(symbol *apiSymbol) int {
+	entity := symbol.entity
+	if entity.Node == nil {
+		params, _ := entity.GetProperty("parameter_types").([]string)
+		return len(params)
+	}
+	paramsNode := entity.Node.ChildByFieldName("parameters")
+	if paramsNode == nil {
+		return 0
+	}
+
+	required := 0
+	for i := uint(0); i < paramsNode.NamedChildCount(); i++ {
+		param := paramsNode.NamedChild(i)
+		switch param.Kind() {
+		case "identifier", "typed_parameter":
+			if text := param.Utf8Text(symbol.content); text == "self" || text == "cls" {
+				continue
+			}
+			required++
+		case "required_parameter":
+			if param.ChildByFieldName("value") != nil {
+				return required
+			}
+			if pattern := param.ChildByFieldName("pattern"); pattern != nil && pattern.Kind() == "rest_pattern" {
+				return required
+			}
+			required++
+		case "comment", "this":
+			continue
+		default:
+			// default_parameter, optional_parameter, *args, **kwargs, ...
+			return required
+		}
+	}
+	return required
+}
+
+// normalizeAPITypes normalizes a list of declared types for comparison
+func normalizeAPITypes(types []string, aliases map[string]string) []string {
+	normalized := make([]string, len(types))
+	for i, t := range types {
+		normalized[i] = normalizeSignatureType(t, aliases)
+	}
+	return normalized
+}
+
+// newBreakingChange describes a change to a symbol of the old version
+func newBreakingChange(kind string, before *apiSymbol, newValue string) BreakingChange {
+	return BreakingChange{
+		Kind:       kind,
+		Symbol:     before.name,
+		EntityType: string(before.entity.Type),
+		Package:    before.pkg,
+		FilePath:   before.entity.FilePath,
+		Line:       before.entity.StartLine(),
+		Old:        apiSignature(before.entity),
+		New:        newValue,
+	}
+}
+
+// apiSignature renders a declaration for display. Python and TypeScript
+// signatures only hold the parameter list, so the name is prepended.
+func apiSignature(entity *entities.Entity) string {
+	signature := strings.TrimSpace(entity.Signature)
+	if !strings.Contains(signature, entity.Name) {
+		signature = entity.Name + signature
+	}
+	if returnType, ok := entity.GetProperty("return_type").(string); ok && returnType != "" {
+		if !strings.HasPrefix(returnType, ":") {
+			returnType = " -> " + returnType
+		}
+		signature += returnType
+	}
+	return signature
+}
+
+// startsWithUpper reports whether a Go identifier is exported
+func startsWithUpper(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
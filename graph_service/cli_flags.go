@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetCLIFlags returns the command-line flags and positional arguments the
+// repository's programs define, so a CLI can be documented from its source
+// or checked for flags nobody reads.
+//
+// Definitions are recognized in:
+//   - Go: the flag and pflag packages and their flag sets (flag.Int,
+//     fs.StringVar, pflag.BoolP) and cobra commands, through cmd.Flags() and
+//     cmd.PersistentFlags()
+//   - Python: argparse parser.add_argument and the @click.option and
+//     @click.argument decorators of click commands
+//   - TypeScript: commander's .option, .requiredOption and .argument, and
+//     yargs' .option and .positional
+//
+// Each CLIFlag entity is named after the flag's long name, or the
+// argument's name, and carries the "flag" as typed ("--port", or "-port" for
+// the Go flag package), its "short" form, "flag_type", "default" when one is
+// given, "help", "library" and "command": the subcommand it belongs to, from
+// a cobra Use, an argparse add_parser, a click command or a commander or
+// yargs .command, and empty for the root command of the Go flag package and
+// argparse. "positional" marks arguments and "persistent" cobra flags
+// inherited by subcommands. When the variable the value is read through is
+// known it is the "variable", and "read" is false if nothing in the file
+// uses it, or in the command function for click. The function defining the
+// flag, the command function for click, has a DEFINES_FLAG relationship to
+// it. Results are ordered by file and position.
+//
+// Example:
+//
+//	flags, err := result.GetCLIFlags()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, f := range flags {
+//		if read, ok := f.GetProperty("read").(bool); ok && !read {
+//			fmt.Printf("%s:%d %v is defined but never read\n", f.FilePath, f.StartLine(), f.GetProperty("flag"))
+//		}
+//	}
+func (r *BuildGraphResult) GetCLIFlags() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeCLIFlag), nil
+}
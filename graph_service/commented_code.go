@@ -0,0 +1,36 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetCommentedCode returns the blocks of commented-out code in Go, Python and
+// TypeScript files: runs of at least two comment lines that read as code and
+// parse without errors once the comment markers are removed. Prose, doc
+// comments, tool directives such as //go:build or // eslint-disable and
+// single commented lines are not reported, nor are blocks inside functions
+// marked with an onyx:ignore comment.
+//
+// Each entity is named after the first line of code and carries the
+// uncommented "text" (also its Body), the "start_line", "end_line" and
+// "line_count" of the block and, for blocks inside a function, the
+// "enclosing_function" ID and "enclosing_function_name". Results are ordered
+// by file and position.
+//
+// Example:
+//
+//	blocks, err := result.GetCommentedCode()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, block := range blocks {
+//		fmt.Printf("%s:%d-%d %s\n", block.FilePath, block.StartLine(), block.EndLine(), block.Name)
+//	}
+func (r *BuildGraphResult) GetCommentedCode() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeCommentedCode), nil
+}
@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetConditionalImports returns the imports made only under a condition, so
+// an optional or platform-specific dependency is not mistaken for one the
+// code always needs, and a fallback is not mistaken for a second dependency.
+//
+// Python imports in a try body or an except clause, as in
+// try: import fast_json / except ImportError: import json, and in the
+// branches of if, elif and else count, as do TypeScript and JavaScript
+// dynamic import() and require() calls in the branches of if, ternaries,
+// try and catch, or right of && and ||, which get an Import entity of their
+// own with the "dynamic" property set for import(). Go imports count when
+// their file has a //go:build or // +build constraint or a _GOOS or
+// _GOARCH name suffix.
+//
+// Each import carries "conditional" set to true and the "condition" it is
+// made under: the tests of the enclosing statements, outermost first, joined
+// with "and" ("not (...)" for else branches, "try" or the except or catch
+// header for the branches of a try), or the Go build constraint, such as
+// "!purego && linux". Imports in different branches of the same statement
+// are linked with ALTERNATIVE_TO relationships from the earlier branch to
+// the later, carrying the condition of the later one. The same goes for Go
+// declarations of one package name in two constrained files of a package,
+// such as a function in file_linux.go and file_windows.go, which are marked
+// conditional too. Results are ordered by file and position.
+//
+// Example:
+//
+//	imports, err := result.GetConditionalImports()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, imp := range imports {
+//		fmt.Printf("%s: %s if %v\n", imp.FilePath, imp.Name, imp.GetProperty("condition"))
+//	}
+func (r *BuildGraphResult) GetConditionalImports() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	imports := make([]*entities.Entity, 0)
+	for _, entity := range r.entitiesOfType(entities.EntityTypeImport) {
+		if conditional, _ := entity.GetProperty("conditional").(bool); conditional {
+			imports = append(imports, entity)
+		}
+	}
+	return imports, nil
+}
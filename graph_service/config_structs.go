@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// configTypeMarkers are name fragments that identify configuration-like types
+var configTypeMarkers = []string{"config", "options", "settings"}
+
+// ConfigStruct is a configuration-like type (a Go struct, Python dataclass or
+// pydantic model, or TypeScript interface or class) together with its fields.
+type ConfigStruct struct {
+	Name     string         `json:"name"`
+	Kind     string         `json:"kind"`
+	FilePath string         `json:"file_path"`
+	Line     int            `json:"line"`
+	Fields   []*ConfigField `json:"fields"`
+
+	// Entity is the underlying type entity
+	Entity *entities.Entity `json:"-"`
+}
+
+// ConfigField is a single field of a ConfigStruct
+type ConfigField struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+
+	// JSONName is the serialized key: the json/yaml struct tag, pydantic alias
+	// or member name. Empty for Go fields tagged json:"-".
+	JSONName string `json:"json_name,omitempty"`
+
+	// EnvName is the environment variable bound through a Go env struct tag
+	EnvName string `json:"env_name,omitempty"`
+
+	Required   bool   `json:"required"`
+	Default    string `json:"default,omitempty"`
+	HasDefault bool   `json:"has_default"`
+	Line       int    `json:"line"`
+}
+
+// GetConfigStructs returns the configuration-like types in the repository,
+// identified heuristically by names containing Config, Options or Settings,
+// with each field's serialized name, default value and whether it is required.
+// This is enough to generate configuration documentation or a .env.example.
+//
+// Field metadata is derived per language:
+//   - Go: json/yaml/env struct tags, default/envDefault tags, and
+//     validate:"required", binding:"required" or env:",required"
+//   - Python: dataclass and pydantic field defaults, Field(...)/field(...)
+//     arguments and aliases
+//   - TypeScript: optional ("?") members, initializers and JSDoc @default tags
+//
+// Results are ordered by file and position.
+//
+// Example:
+//
+//	configs, err := result.GetConfigStructs()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, cfg := range configs {
+//		for _, f := range cfg.Fields {
+//			fmt.Printf("%s.%s required=%v default=%q\n", cfg.Name, f.JSONName, f.Required, f.Default)
+//		}
+//	}
+func (r *BuildGraphResult) GetConfigStructs() ([]*ConfigStruct, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	configs := make([]*ConfigStruct, 0)
+	types := r.entitiesOfType(entities.EntityTypeStruct, entities.EntityTypeClass, entities.EntityTypeInterface)
+	for _, entity := range types {
+		if !isConfigTypeName(entity.Name) {
+			continue
+		}
+
+		config := &ConfigStruct{
+			Name:     entity.Name,
+			Kind:     string(entity.Type),
+			FilePath: entity.FilePath,
+			Line:     entity.StartLine(),
+			Fields:   make([]*ConfigField, 0),
+			Entity:   entity,
+		}
+		for _, child := range entity.Children {
+			if field := newConfigField(child); field != nil {
+				config.Fields = append(config.Fields, field)
+			}
+		}
+		sort.SliceStable(config.Fields, func(i, j int) bool {
+			return config.Fields[i].Line < config.Fields[j].Line
+		})
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// isConfigTypeName reports whether a type name looks like a configuration type
+func isConfigTypeName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, marker := range configTypeMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// newConfigField converts a field entity into a ConfigField, returning nil for
+// children that carry no field metadata (methods, nested types)
+func newConfigField(entity *entities.Entity) *ConfigField {
+	required, ok := entity.GetProperty("required").(bool)
+	if !ok {
+		return nil
+	}
+
+	field := &ConfigField{
+		Name:     entity.Name,
+		Required: required,
+		Line:     entity.StartLine(),
+	}
+	for _, key := range []string{"type", "type_annotation"} {
+		if t, ok := entity.GetProperty(key).(string); ok && t != "" {
+			field.Type = strings.TrimSpace(strings.TrimPrefix(t, ":"))
+			break
+		}
+	}
+	field.JSONName, _ = entity.GetProperty("json_name").(string)
+	field.EnvName, _ = entity.GetProperty("env_name").(string)
+	field.Default, field.HasDefault = entity.GetProperty("default").(string)
+	return field
+}
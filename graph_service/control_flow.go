@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetControlFlowIssues returns the control-flow mistakes found inside
+// functions. Two kinds are reported, in the "issue" property:
+//
+//   - "missing_return": a TypeScript or Python function declares a return
+//     type other than void, undefined or None (or an Optional), yet some
+//     path reaches the end of its body. Neither language enforces this
+//     without strict compiler or type-checker settings. Go is not checked,
+//     since its compiler already rejects such functions, and generators and
+//     stubs made of a docstring, pass or ... are skipped.
+//   - "unreachable_code": a Go, TypeScript or Python statement follows a
+//     return, throw, raise, panic, exit call (os.Exit, process.exit,
+//     sys.exit), break or continue, or a statement such as an if/else or a
+//     switch with a default whose every branch ends in one. Only the first
+//     dead statement of each block is reported.
+//
+// Each entity carries the offending "line" (the unreachable statement, or
+// the end of the function body for a missing return), a "message", the
+// "language", for unreachable code the statement it comes "after" (return,
+// panic, os.Exit, if...), and the "enclosing_function" ID and
+// "enclosing_function_name". Functions marked with an onyx:ignore comment
+// are skipped. Results are ordered by file and position.
+//
+// Example:
+//
+//	issues, err := result.GetControlFlowIssues()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, issue := range issues {
+//		fmt.Printf("%s:%v %v\n", issue.FilePath, issue.GetProperty("line"), issue.GetProperty("message"))
+//	}
+func (r *BuildGraphResult) GetControlFlowIssues() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeControlFlowIssue), nil
+}
@@ -0,0 +1,277 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Formats accepted by ImportCoverageReport
+const (
+	CoverageFormatLCOV      = "lcov"
+	CoverageFormatGo        = "go"
+	CoverageFormatCobertura = "cobertura"
+)
+
+// lineHits maps a file of a coverage report, as the report names it, to the
+// execution count of each of its instrumented lines
+type lineHits map[string]map[int]int
+
+// ImportCoverageReport reads a coverage report produced by a test run and
+// records on each entity whether its code actually ran. Every entity with an
+// instrumented line in its range gets "runtime_covered", true when any of
+// those lines ran, and "runtime_hits", the highest count of any of them;
+// entities without instrumented lines, such as most type declarations, are
+// left alone. GetUncoveredEntities then prefers these properties to the
+// tests found by static analysis.
+//
+// format is "lcov" (lcov.info), "go" (go test -coverprofile) or "cobertura"
+// (Cobertura XML, as written by coverage.py and most CI tools); empty detects
+// it from the content. Files of the report are matched to analyzed files by
+// path suffix, so absolute paths and Go import paths work. Entities of files
+// the report does not mention keep what an earlier import recorded, so
+// reports of several test suites can be imported one after the other. Only
+// the builder is updated, not Database.
+//
+// Example:
+//
+//	if err := result.ImportCoverageReport("coverage.out", graph.CoverageFormatGo); err != nil {
+//		log.Fatal(err)
+//	}
+//	uncovered, _ := result.GetUncoveredEntities()
+func (r *BuildGraphResult) ImportCoverageReport(path, format string) error {
+	if r.Builder == nil {
+		return fmt.Errorf("builder not available")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read coverage report: %w", err)
+	}
+	if format == "" {
+		format = detectCoverageFormat(data)
+	}
+
+	var hits lineHits
+	switch strings.ToLower(format) {
+	case CoverageFormatLCOV:
+		hits, err = parseLCOV(data)
+	case CoverageFormatGo:
+		hits, err = parseGoCoverProfile(data)
+	case CoverageFormatCobertura:
+		hits, err = parseCobertura(data)
+	default:
+		return fmt.Errorf("unknown coverage format %q, expected lcov, go or cobertura", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse coverage report %s: %w", path, err)
+	}
+
+	// Line counts by analyzed file
+	byFile := make(map[string]map[int]int)
+	for reportPath, lines := range hits {
+		file := r.matchReportPath(reportPath)
+		if file == "" {
+			continue
+		}
+		if byFile[file] == nil {
+			byFile[file] = make(map[int]int)
+		}
+		for line, count := range lines {
+			byFile[file][line] += count
+		}
+	}
+	if len(byFile) == 0 {
+		return fmt.Errorf("coverage report %s matches no analyzed file", path)
+	}
+
+	for _, entity := range r.Builder.GetAllEntities() {
+		lines := byFile[entity.FilePath]
+		start, end := entity.StartLine(), entity.EndLine()
+		if lines == nil || start == 0 {
+			continue
+		}
+		instrumented := false
+		most := 0
+		for line := start; line <= end; line++ {
+			if count, ok := lines[line]; ok {
+				instrumented = true
+				if count > most {
+					most = count
+				}
+			}
+		}
+		if instrumented {
+			entity.SetProperty("runtime_covered", most > 0)
+			entity.SetProperty("runtime_hits", most)
+		}
+	}
+	return nil
+}
+
+// matchReportPath returns the analyzed file a path of a coverage report
+// refers to, the longest one it ends with, or "" if there is none
+func (r *BuildGraphResult) matchReportPath(reportPath string) string {
+	reportPath = filepath.ToSlash(reportPath)
+	best := ""
+	for file := range r.Builder.GetFiles() {
+		slashed := filepath.ToSlash(file)
+		if reportPath != slashed && !strings.HasSuffix(reportPath, "/"+slashed) {
+			continue
+		}
+		if len(file) > len(best) {
+			best = file
+		}
+	}
+	return best
+}
+
+// detectCoverageFormat guesses the format of a report from its first line
+func detectCoverageFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return CoverageFormatGo
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return CoverageFormatCobertura
+	default:
+		return CoverageFormatLCOV
+	}
+}
+
+// parseLCOV reads the SF (source file) and DA (line, count) records of an
+// lcov tracefile
+func parseLCOV(data []byte) (lineHits, error) {
+	hits := make(lineHits)
+	var current map[int]int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file := strings.TrimPrefix(line, "SF:")
+			if hits[file] == nil {
+				hits[file] = make(map[int]int)
+			}
+			current = hits[file]
+		case strings.HasPrefix(line, "DA:"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: DA record outside of a source file", n)
+			}
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: malformed DA record %q", n, line)
+			}
+			number, err1 := strconv.Atoi(fields[0])
+			count, err2 := strconv.Atoi(fields[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("line %d: malformed DA record %q", n, line)
+			}
+			current[number] += count
+		case line == "end_of_record":
+			current = nil
+		}
+	}
+	return hits, scanner.Err()
+}
+
+// parseGoCoverProfile reads the blocks of a Go coverage profile, lines such
+// as "example.com/pkg/file.go:12.34,15.2 3 1", giving every line of a block
+// the highest count of the blocks covering it. Blocks repeated by merged
+// profiles add up.
+func parseGoCoverProfile(data []byte) (lineHits, error) {
+	type block struct {
+		file                         string
+		start, startCol, end, endCol int
+	}
+	counts := make(map[block]int)
+	order := make([]block, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("line %d: malformed block %q", n, line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: malformed block %q", n, line)
+		}
+		var startLine, startCol, endLine, endCol int
+		if _, err := fmt.Sscanf(fields[0], "%d.%d,%d.%d", &startLine, &startCol, &endLine, &endCol); err != nil {
+			return nil, fmt.Errorf("line %d: malformed range %q", n, fields[0])
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: malformed count %q", n, fields[2])
+		}
+		b := block{file: line[:colon], start: startLine, startCol: startCol, end: endLine, endCol: endCol}
+		if _, ok := counts[b]; !ok {
+			order = append(order, b)
+		}
+		counts[b] += count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	hits := make(lineHits)
+	for _, b := range order {
+		if hits[b.file] == nil {
+			hits[b.file] = make(map[int]int)
+		}
+		for line := b.start; line <= b.end; line++ {
+			if current, ok := hits[b.file][line]; !ok || counts[b] > current {
+				hits[b.file][line] = counts[b]
+			}
+		}
+	}
+	return hits, nil
+}
+
+// coberturaReport is the part of a Cobertura XML report that maps lines to
+// execution counts
+type coberturaReport struct {
+	Sources []string `xml:"sources>source"`
+	Classes []struct {
+		Filename string `xml:"filename,attr"`
+		Lines    []struct {
+			Number int `xml:"number,attr"`
+			Hits   int `xml:"hits,attr"`
+		} `xml:"lines>line"`
+	} `xml:"packages>package>classes>class"`
+}
+
+// parseCobertura reads the line counts of the classes of a Cobertura report.
+// File names are relative to one of the sources, which is prepended when
+// there is only one.
+func parseCobertura(data []byte) (lineHits, error) {
+	var report coberturaReport
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+
+	hits := make(lineHits)
+	for _, class := range report.Classes {
+		file := class.Filename
+		if len(report.Sources) == 1 && !filepath.IsAbs(file) {
+			file = filepath.Join(strings.TrimSpace(report.Sources[0]), file)
+		}
+		if hits[file] == nil {
+			hits[file] = make(map[int]int)
+		}
+		for _, line := range class.Lines {
+			hits[file][line.Number] += line.Hits
+		}
+	}
+	return hits, nil
+}
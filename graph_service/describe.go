@@ -0,0 +1,309 @@
+package graph
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Warnings of an EntityDescription
+const (
+	WarningDead         = "dead"         // Unexported and nothing in the repository calls or references it
+	WarningDeprecated   = "deprecated"   // Marked deprecated in its documentation or by a decorator
+	WarningUndocumented = "undocumented" // Exported without a doc comment or docstring
+)
+
+// EntityDescription is everything the graph knows about one entity, gathered
+// by DescribeEntity
+type EntityDescription struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	FullName  string `json:"full_name"`
+	Type      string `json:"type"`
+	FilePath  string `json:"file_path"`
+	Line      int    `json:"line"`
+	EndLine   int    `json:"end_line"`
+	Signature string `json:"signature,omitempty"`
+	DocString string `json:"docstring,omitempty"`
+
+	// Size of functions and methods
+	Complexity int `json:"complexity,omitempty"` // Cyclomatic complexity
+	LineCount  int `json:"line_count,omitempty"`
+
+	Callers    []*EntityReference `json:"callers"`
+	Callees    []*EntityReference `json:"callees"`
+	Implements []*EntityReference `json:"implements,omitempty"` // Interfaces it, or the type of a method, implements
+	Tests      []*EntityReference `json:"tests"`
+
+	// History, when the graph was built with WithChurn
+	ChangeFrequency int    `json:"change_frequency,omitempty"`
+	LastAuthor      string `json:"last_author,omitempty"`
+	LastChanged     string `json:"last_changed,omitempty"` // RFC 3339
+
+	Warnings []string `json:"warnings,omitempty"`
+
+	Entity *entities.Entity `json:"-"`
+}
+
+// EntityReference points at an entity related to the one described
+type EntityReference struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+}
+
+// DescribeEntity gathers everything known about an entity into one
+// description, for a deep dive that would otherwise take a query for each:
+// its signature, docstring and line range; the complexity and line count of
+// a function or method; the functions calling it and those it calls; the
+// interfaces it implements, or for a method those its type implements; the
+// tests that test, cover or call it; who changed it last and how often, when
+// the graph was built with WithChurn; and warnings that it is dead,
+// deprecated or undocumented.
+//
+// A declaration is dead when it is not exported and nothing outside it uses
+// it, as GetUnusedTypes and GetUnusedVariables find for types and variables;
+// functions named main or init, Python special methods and test code are
+// never dead. It is deprecated when its documentation has a "Deprecated:"
+// paragraph, a @deprecated tag or a ".. deprecated::" directive, or it has a
+// deprecated decorator, and undocumented when GetUndocumentedAPI reports it.
+// Related entities are ordered by file and position.
+//
+// Example:
+//
+//	d, err := result.DescribeEntity(id)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("%s %s (%s:%d-%d), %d callers, %d tests %v\n",
+//		d.Type, d.Signature, d.FilePath, d.Line, d.EndLine, len(d.Callers), len(d.Tests), d.Warnings)
+func (r *BuildGraphResult) DescribeEntity(id string) (*EntityDescription, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	entity := r.Builder.GetEntity(id)
+	if entity == nil {
+		return nil, fmt.Errorf("entity not found: %s", id)
+	}
+
+	d := &EntityDescription{
+		ID:        entity.ID,
+		Name:      entity.Name,
+		FullName:  entity.GetFullName(),
+		Type:      string(entity.Type),
+		FilePath:  entity.FilePath,
+		Line:      entity.StartLine(),
+		EndLine:   entity.EndLine(),
+		Signature: strings.TrimSpace(entity.Signature),
+		DocString: strings.TrimSpace(entity.DocString),
+		Callers:   make([]*EntityReference, 0),
+		Callees:   make([]*EntityReference, 0),
+		Tests:     make([]*EntityReference, 0),
+		Entity:    entity,
+	}
+	d.Complexity, _ = entity.GetProperty("complexity").(int)
+	d.LineCount, _ = entity.GetProperty("line_count").(int)
+	d.ChangeFrequency, _ = entity.GetProperty("change_frequency").(int)
+	d.LastAuthor, _ = entity.GetProperty("last_author").(string)
+	d.LastChanged, _ = entity.GetProperty("last_changed").(string)
+
+	// The types whose interfaces count for the entity: itself, and the type
+	// declaring a method
+	implementers := map[string]bool{entity.ID: true}
+	if owner := r.methodOwner(entity); owner != nil {
+		implementers[owner.ID] = true
+	}
+
+	var callers, callees, implements, tests []*entities.Entity
+	for _, rel := range r.Builder.GetAllRelationships() {
+		source, target := r.Builder.GetEntity(rel.SourceID), r.Builder.GetEntity(rel.TargetID)
+		if source == nil || target == nil {
+			continue
+		}
+		switch rel.Type {
+		case entities.RelationshipTypeImplements:
+			if implementers[source.ID] {
+				implements = append(implements, target)
+			}
+		case entities.RelationshipTypeTests, entities.RelationshipTypeCovers:
+			if target == entity {
+				tests = append(tests, source)
+			}
+		case entities.RelationshipTypeCalls:
+			switch {
+			case source == target:
+			case target == entity && (source.IsTest() || source.IsTestFile()):
+				tests = append(tests, source)
+				callers = append(callers, source)
+			case target == entity:
+				callers = append(callers, source)
+			case source == entity:
+				callees = append(callees, target)
+			}
+		}
+	}
+	d.Callers = appendReferences(d.Callers, callers)
+	d.Callees = appendReferences(d.Callees, callees)
+	d.Implements = appendReferences(d.Implements, implements)
+	d.Tests = appendReferences(d.Tests, tests)
+
+	dead, err := r.isDead(entity)
+	if err != nil {
+		return nil, err
+	}
+	if dead {
+		d.Warnings = append(d.Warnings, WarningDead)
+	}
+	if isDeprecated(entity) {
+		d.Warnings = append(d.Warnings, WarningDeprecated)
+	}
+	if documented, ok := entity.GetProperty("documented").(bool); ok && !documented {
+		d.Warnings = append(d.Warnings, WarningUndocumented)
+	}
+	return d, nil
+}
+
+// methodOwner returns the type declaring a method: its parent class, or the
+// type of a Go receiver in the same package; nil for other entities
+func (r *BuildGraphResult) methodOwner(entity *entities.Entity) *entities.Entity {
+	if entity.Type != entities.EntityTypeMethod {
+		return nil
+	}
+	if entity.Parent != nil {
+		return entity.Parent
+	}
+	receiver, ok := entity.GetProperty("receiver").(string)
+	if !ok {
+		return nil
+	}
+	name := analyzer.ReceiverTypeName(receiver)
+	for _, candidate := range r.Builder.GetEntitiesByName(name) {
+		if candidate.Type == entities.EntityTypeStruct || candidate.Type == entities.EntityTypeType {
+			if path.Dir(candidate.FilePath) == path.Dir(entity.FilePath) {
+				return candidate
+			}
+		}
+	}
+	return nil
+}
+
+// isDead reports whether an unexported declaration is used nowhere outside
+// itself. Types and variables are checked as by GetUnusedTypes and
+// GetUnusedVariables; functions and methods by the resolved relationships
+// pointing at them.
+func (r *BuildGraphResult) isDead(entity *entities.Entity) (bool, error) {
+	var unused []*entities.Entity
+	var err error
+	switch entity.Type {
+	case entities.EntityTypeStruct, entities.EntityTypeInterface, entities.EntityTypeClass,
+		entities.EntityTypeType, entities.EntityTypeEnum:
+		unused, err = r.GetUnusedTypes(false)
+	case entities.EntityTypeVariable:
+		unused, err = r.GetUnusedVariables(false)
+	case entities.EntityTypeFunction, entities.EntityTypeMethod:
+		return r.isDeadFunction(entity), nil
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, e := range unused {
+		if e == entity {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isDeadFunction reports whether an unexported function or method is never
+// called or referenced from outside its own body. Entry points, Python
+// special methods, test code and methods of types implementing an interface,
+// which may be called through it, are never dead.
+func (r *BuildGraphResult) isDeadFunction(function *entities.Entity) bool {
+	exported, known := function.GetProperty("exported").(bool)
+	if !known || exported || function.IsIgnored() || function.IsTest() || function.IsTestFile() {
+		return false
+	}
+	switch function.Name {
+	case "main", "init", "constructor":
+		return false
+	}
+	if strings.HasPrefix(function.Name, "__") && strings.HasSuffix(function.Name, "__") {
+		return false
+	}
+	owner := r.methodOwner(function)
+	for _, rel := range r.Builder.GetAllRelationships() {
+		if !rel.IsResolved || rel.Type == entities.RelationshipTypeContains || rel.Type == entities.RelationshipTypeDefines {
+			continue
+		}
+		if owner != nil && rel.SourceID == owner.ID &&
+			(rel.Type == entities.RelationshipTypeImplements || rel.Type == entities.RelationshipTypeInherits) {
+			return false
+		}
+		if rel.TargetID != function.ID {
+			continue
+		}
+		if source := r.Builder.GetEntity(rel.SourceID); source != nil && !withinAny(source, []*entities.Entity{function}) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDeprecated reports whether a declaration is marked deprecated: a Go
+// "Deprecated:" paragraph, a JSDoc @deprecated tag or a Sphinx
+// ".. deprecated::" directive in its documentation, or a Python or
+// TypeScript decorator named deprecated
+func isDeprecated(entity *entities.Entity) bool {
+	for _, line := range strings.Split(entity.DocString, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "/*# ")
+		if strings.HasPrefix(line, "Deprecated:") || strings.HasPrefix(line, "@deprecated") ||
+			strings.HasPrefix(line, ".. deprecated::") {
+			return true
+		}
+	}
+	decorators, _ := entity.GetProperty("decorators").([]string)
+	for _, decorator := range decorators {
+		name := strings.TrimPrefix(decorator, "@")
+		if i := strings.Index(name, "("); i >= 0 {
+			name = name[:i]
+		}
+		if name == "deprecated" || strings.HasSuffix(name, ".deprecated") {
+			return true
+		}
+	}
+	return false
+}
+
+// appendReferences adds a reference to each of the entities once, ordered by
+// file and position
+func appendReferences(refs []*EntityReference, list []*entities.Entity) []*EntityReference {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].FilePath != list[j].FilePath {
+			return list[i].FilePath < list[j].FilePath
+		}
+		return list[i].StartByte < list[j].StartByte
+	})
+	seen := make(map[string]bool, len(list))
+	for _, e := range list {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		refs = append(refs, &EntityReference{
+			ID:       e.ID,
+			Name:     e.GetFullName(),
+			Type:     string(e.Type),
+			FilePath: e.FilePath,
+			Line:     e.StartLine(),
+		})
+	}
+	return refs
+}
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// BrokenDocLink is a link in a doc comment to a declaration that does not
+// exist, typically one renamed or removed since the comment was written
+type BrokenDocLink struct {
+	Name     string `json:"name"`   // The documented declaration
+	Target   string `json:"target"` // The name the link points to
+	Text     string `json:"text"`   // The link as written, e.g. "[Server.Start]"
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+
+	Entity *entities.Entity `json:"-"` // The documented declaration
+}
+
+// GetBrokenDocLinks returns the links of doc comments that point to no
+// declaration of the repository. Links are Go doc links ([Name],
+// [Type.Method], [pkg.Name]), JSDoc {@link X} tags and the Sphinx roles of
+// Python docstrings, such as :func:`helper` or :meth:`Client.close`. Go links
+// must resolve in their package; Python and TypeScript links anywhere in the
+// repository. A link to a member only resolves to a member of the named type.
+//
+// Links into Go packages of other modules and links to names the file
+// imports, which may come from other modules, are not reported, nor are links
+// to packages, URLs and language builtins. The links that resolve are stored
+// in the graph as DOC_REFERENCES relationships from the documented
+// declaration to its target. Results are ordered by file and line.
+//
+// Example:
+//
+//	broken, err := result.GetBrokenDocLinks()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, link := range broken {
+//		fmt.Printf("%s:%d %s\n", link.FilePath, link.Line, link.Message)
+//	}
+func (r *BuildGraphResult) GetBrokenDocLinks() ([]*BrokenDocLink, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	resolved := make(map[string]bool)
+	for _, rel := range r.Builder.GetAllRelationships() {
+		if rel.Type == entities.RelationshipTypeDocReferences && rel.IsResolved {
+			resolved[rel.ID] = true
+		}
+	}
+
+	broken := make([]*BrokenDocLink, 0)
+	for _, rel := range r.Builder.GetUnresolvedRelationships() {
+		if rel.Type != entities.RelationshipTypeDocReferences || resolved[rel.ID] {
+			continue
+		}
+		if external, _ := rel.GetProperty("external").(bool); external {
+			continue
+		}
+		entity := r.Builder.GetEntity(rel.SourceID)
+		if entity == nil {
+			continue
+		}
+		link := &BrokenDocLink{
+			Name:     entity.Name,
+			Target:   rel.TargetID,
+			FilePath: entity.FilePath,
+			Entity:   entity,
+		}
+		link.Text, _ = rel.GetProperty("text").(string)
+		link.Line, _ = rel.GetProperty("line").(int)
+		link.Message = fmt.Sprintf("doc comment of %s links to %s, which does not exist", entity.Name, link.Text)
+		broken = append(broken, link)
+	}
+
+	sort.SliceStable(broken, func(i, j int) bool {
+		if broken[i].FilePath != broken[j].FilePath {
+			return broken[i].FilePath < broken[j].FilePath
+		}
+		return broken[i].Line < broken[j].Line
+	})
+	return broken, nil
+}
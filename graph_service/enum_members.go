@@ -0,0 +1,213 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of EnumIssue
+const (
+	EnumIssueDuplicateValue = "duplicate_value"
+	EnumIssueValueGap       = "value_gap"
+)
+
+// EnumIssue is a value shared by several members of an enum, or a range of
+// values an enum skips
+type EnumIssue struct {
+	// Kind is "duplicate_value" or "value_gap"
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+
+	Enum string `json:"enum"`
+	// Members are the members sharing the value, or those either side of
+	// the gap
+	Members  []string `json:"members"`
+	Values   []int64  `json:"values"`
+	FilePath string   `json:"file_path"`
+	Line     int      `json:"line"`
+
+	// Entity is the EnumMember the issue is reported at
+	Entity *entities.Entity `json:"-"`
+}
+
+// GetEnumMembers returns the members of TypeScript enums, Python Enum
+// subclasses and Go constant blocks that use iota or declare several
+// constants of one named type, one EnumMember entity per member. Each carries
+// the "enum" it belongs to, its "value" as text, "int_value" when the value
+// is a known integer, whether the value is "implicit" (an iota repetition, a
+// TypeScript member without initializer or a Python auto()) and its
+// "position". In the graph, the Enum, the Python class or the Go named type
+// DEFINES its members. Results are ordered by file and position.
+//
+// Example:
+//
+//	members, err := result.GetEnumMembers()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, m := range members {
+//		fmt.Printf("%v.%s = %v\n", m.GetProperty("enum"), m.Name, m.GetProperty("value"))
+//	}
+func (r *BuildGraphResult) GetEnumMembers() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeEnumMember), nil
+}
+
+// GetEnumIssues checks the integer values of enums for two mistakes:
+//   - several members have the same value, as when an explicit value in an
+//     iota block repeats an earlier one
+//   - the values skip a range, as when a member was removed from the middle
+//     of an explicitly numbered enum. Values skipped on purpose with _ in a
+//     Go block are not gaps, and enums whose values are all powers of two,
+//     flags meant to be combined, are not checked for gaps.
+//
+// Members with a value that is not a known integer, such as strings, are
+// ignored, as are members marked with an onyx:ignore comment. Results are
+// ordered by file and line.
+//
+// Example:
+//
+//	issues, err := result.GetEnumIssues()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, issue := range issues {
+//		fmt.Printf("%s:%d %s: %s\n", issue.FilePath, issue.Line, issue.Enum, issue.Message)
+//	}
+func (r *BuildGraphResult) GetEnumIssues() ([]*EnumIssue, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	// Members of one enum: its entity, or the const block of an untyped or
+	// foreign Go type
+	groups := make(map[string][]*entities.Entity)
+	for _, member := range r.entitiesOfType(entities.EntityTypeEnumMember) {
+		if member.IsIgnored() {
+			continue
+		}
+		if _, ok := member.GetProperty("int_value").(int64); !ok {
+			continue
+		}
+		key, _ := member.GetProperty("enum_id").(string)
+		if key == "" {
+			enum, _ := member.GetProperty("enum").(string)
+			key = member.FilePath + ":" + enum
+		}
+		groups[key] = append(groups[key], member)
+	}
+
+	issues := make([]*EnumIssue, 0)
+	for _, members := range groups {
+		issues = append(issues, enumIssues(members)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].FilePath != issues[j].FilePath {
+			return issues[i].FilePath < issues[j].FilePath
+		}
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Kind < issues[j].Kind
+	})
+	return issues, nil
+}
+
+// enumIssues checks the members of one enum
+func enumIssues(members []*entities.Entity) []*EnumIssue {
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].FilePath != members[j].FilePath {
+			return members[i].FilePath < members[j].FilePath
+		}
+		return members[i].StartLine() < members[j].StartLine()
+	})
+	enum, _ := members[0].GetProperty("enum").(string)
+	value := func(member *entities.Entity) int64 {
+		n, _ := member.GetProperty("int_value").(int64)
+		return n
+	}
+
+	issues := make([]*EnumIssue, 0)
+	report := func(kind string, at *entities.Entity, names []string, values []int64, message string) {
+		issues = append(issues, &EnumIssue{
+			Kind:     kind,
+			Message:  message,
+			Enum:     enum,
+			Members:  names,
+			Values:   values,
+			FilePath: at.FilePath,
+			Line:     at.StartLine(),
+			Entity:   at,
+		})
+	}
+
+	byValue := make(map[int64][]*entities.Entity)
+	present := make(map[int64]bool)
+	for _, member := range members {
+		byValue[value(member)] = append(byValue[value(member)], member)
+		present[value(member)] = true
+		if reserved, ok := member.GetProperty("reserved").([]int64); ok {
+			for _, n := range reserved {
+				present[n] = true
+			}
+		}
+	}
+
+	for n, same := range byValue {
+		if len(same) < 2 {
+			continue
+		}
+		names := make([]string, len(same))
+		for i, member := range same {
+			names[i] = member.Name
+		}
+		report(EnumIssueDuplicateValue, same[1], names, []int64{n},
+			fmt.Sprintf("members %s of %s all have the value %d", strings.Join(names, ", "), enum, n))
+	}
+
+	if isFlagEnum(byValue) {
+		return issues
+	}
+	values := make([]int64, 0, len(present))
+	for n := range present {
+		values = append(values, n)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for i := 1; i < len(values); i++ {
+		low, high := values[i-1], values[i]
+		if high-low < 2 || len(byValue[low]) == 0 || len(byValue[high]) == 0 {
+			continue
+		}
+		before, after := byValue[low][0], byValue[high][0]
+		missing := strconv.FormatInt(low+1, 10)
+		if high-low > 2 {
+			missing += ".." + strconv.FormatInt(high-1, 10)
+		}
+		report(EnumIssueValueGap, after, []string{before.Name, after.Name}, []int64{low, high},
+			fmt.Sprintf("%s skips the values %s between %s and %s", enum, missing, before.Name, after.Name))
+	}
+	return issues
+}
+
+// isFlagEnum reports whether the values of an enum are bit flags: at least
+// three, all zero or powers of two
+func isFlagEnum(byValue map[int64][]*entities.Entity) bool {
+	flags := 0
+	for n := range byValue {
+		switch {
+		case n == 0:
+		case n > 0 && n&(n-1) == 0:
+			flags++
+		default:
+			return false
+		}
+	}
+	return flags >= 3
+}
@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// ErrorMessageRules are the conventions the error messages of a language are
+// checked against, given by language with BuildGraphOptions.ErrorMessageRules
+type ErrorMessageRules = analyzer.ErrorMessageRules
+
+// GetErrorMessageIssues returns the error messages that break the
+// conventions of their language, so messages can be made consistent before
+// they reach users or get wrapped into each other.
+//
+// Messages are taken from where errors are created with a literal message:
+// Go fmt.Errorf and errors.New (and the github.com/pkg/errors and
+// cockroachdb/errors constructors), Python raise X("...") and TypeScript or
+// JavaScript new X("...") of a class whose name ends in Error or Exception.
+// By default Go messages must start lower-case and not end with punctuation
+// or a newline, following the Go code review convention, while Python,
+// TypeScript and JavaScript messages must follow what most messages of the
+// language in the repository do; BuildGraphOptions.ErrorMessageRules changes
+// the rules. A first word that is an acronym or identifier, such as HTTP or
+// ReadFile, is never reported.
+//
+// Each ErrorMessageIssue entity is named after the message and carries the
+// "kind" of violation ("capitalized", "lowercase", "trailing_punctuation" or
+// "missing_period"), the rule value "expected", the "error_message", the
+// "constructor" (fmt.Errorf, ValueError), a "message" describing the issue,
+// a "suggestion": the error message with every rule applied, its "line" and
+// the "enclosing_function". A message breaking two rules has an issue for
+// each. Results are ordered by file and position.
+//
+// Example:
+//
+//	issues, err := result.GetErrorMessageIssues()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, issue := range issues {
+//		fmt.Printf("%s:%v %v: %q -> %q\n", issue.FilePath, issue.GetProperty("line"),
+//			issue.GetProperty("message"), issue.Name, issue.GetProperty("suggestion"))
+//	}
+func (r *BuildGraphResult) GetErrorMessageIssues() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeErrorMessageIssue), nil
+}
@@ -0,0 +1,591 @@
+package graph
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// redacted replaces text that an export removes but whose presence matters,
+// such as a string property
+const redacted = "<redacted>"
+
+// ExportOptions controls what ExportJSON and ExportGraphML write. The zero
+// value exports the graph as analyzed, source text included. The redaction
+// options remove source while keeping the structure, names, types,
+// positions and relationships, so a graph can be shared without the code.
+//
+// Example, sharing the shape of a codebase without any of its text:
+//
+//	err := result.ExportJSON(w, graph.ExportOptions{
+//		RedactBodies:   true,
+//		RedactStrings:  true,
+//		RedactComments: true,
+//		HashNames:      true,
+//		HashKey:        os.Getenv("EXPORT_HASH_KEY"),
+//	})
+type ExportOptions struct {
+	// RedactBodies leaves out the source of function, method and class
+	// bodies, and the source text relationships were inferred from.
+	RedactBodies bool
+
+	// RedactStrings blanks string literals: in bodies that are kept, in
+	// signatures (default values) and in properties holding literal values
+	// such as URLs, log messages, assertion values or feature flag keys.
+	RedactStrings bool
+
+	// RedactComments leaves out doc comments and blocks of commented-out
+	// code, and removes comments from bodies that are kept.
+	RedactComments bool
+
+	// HashNames replaces identifiers with hashes: entity names, IDs and file
+	// path elements (extensions are kept), along with the same identifiers
+	// wherever they appear in signatures, bodies and properties. A name
+	// always hashes to the same value, so relationships and references
+	// between entities stay intact. Identifiers that name no entity, such as
+	// local variables and library functions, are kept.
+	HashNames bool
+
+	// HashKey keys the name hashes. Without a key, common names can be
+	// recovered by hashing candidates, so a secret key should be used for
+	// graphs that leave the team. Exports made with the same key hash names
+	// the same way and can be compared.
+	HashKey string
+}
+
+// ExportedGraph is the graph written by ExportJSON
+type ExportedGraph struct {
+	Entities      []*ExportedEntity       `json:"entities"`
+	Relationships []*ExportedRelationship `json:"relationships"`
+}
+
+// ExportedEntity is an entity as written by ExportJSON and ExportGraphML
+type ExportedEntity struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	FilePath   string                 `json:"file_path,omitempty"`
+	StartLine  int                    `json:"start_line,omitempty"`
+	EndLine    int                    `json:"end_line,omitempty"`
+	Signature  string                 `json:"signature,omitempty"`
+	Body       string                 `json:"body,omitempty"`
+	DocString  string                 `json:"doc_string,omitempty"`
+	Parent     string                 `json:"parent,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// ExportedRelationship is a relationship as written by ExportJSON and
+// ExportGraphML
+type ExportedRelationship struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Source     string                 `json:"source"`
+	Target     string                 `json:"target"`
+	FilePath   string                 `json:"file_path,omitempty"`
+	Line       uint32                 `json:"line,omitempty"`
+	Column     uint32                 `json:"column,omitempty"`
+	NodeKind   string                 `json:"node_kind,omitempty"` // Syntax node the relationship was inferred from
+	Text       string                 `json:"text,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// ExportJSON writes the entities and relationships of the graph to w as an
+// indented JSON document (see ExportedGraph), redacted as the options ask.
+// Entities are ordered by file and position, relationships by type, source
+// and target, so exports of the same code are identical.
+//
+// Example:
+//
+//	f, err := os.Create("graph.json")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer f.Close()
+//	if err := result.ExportJSON(f, graph.ExportOptions{RedactBodies: true}); err != nil {
+//		log.Fatal(err)
+//	}
+func (r *BuildGraphResult) ExportJSON(w io.Writer, opts ExportOptions) error {
+	if r.Builder == nil {
+		return fmt.Errorf("builder not available")
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newExporter(r, opts).graph()); err != nil {
+		return fmt.Errorf("failed to write JSON export: %w", err)
+	}
+	return nil
+}
+
+// ExportGraphML writes the graph to w in GraphML, the XML format read by
+// graph tools such as Gephi, yEd and NetworkX, redacted as the options ask.
+// Entity fields and relationship types become GraphML attributes; the
+// remaining properties of each are written as one JSON-encoded attribute.
+func (r *BuildGraphResult) ExportGraphML(w io.Writer, opts ExportOptions) error {
+	if r.Builder == nil {
+		return fmt.Errorf("builder not available")
+	}
+	g := newExporter(r, opts).graph()
+	out := &graphMLWriter{w: w}
+
+	out.printf("%s<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n", xml.Header)
+	for _, key := range [][3]string{
+		{"name", "node", "string"}, {"type", "node", "string"}, {"file_path", "node", "string"},
+		{"start_line", "node", "int"}, {"end_line", "node", "int"}, {"signature", "node", "string"},
+		{"body", "node", "string"}, {"doc_string", "node", "string"}, {"parent", "node", "string"},
+		{"properties", "node", "string"},
+		{"rel_type", "edge", "string"}, {"rel_file_path", "edge", "string"}, {"rel_line", "edge", "int"},
+		{"rel_column", "edge", "int"}, {"rel_node_kind", "edge", "string"}, {"rel_text", "edge", "string"},
+		{"rel_properties", "edge", "string"},
+	} {
+		name := strings.TrimPrefix(key[0], "rel_")
+		out.printf("  <key id=%q for=%q attr.name=%q attr.type=%q/>\n", key[0], key[1], name, key[2])
+	}
+	out.printf("  <graph id=\"G\" edgedefault=\"directed\">\n")
+
+	for _, e := range g.Entities {
+		out.printf("    <node id=\"%s\">\n", escapeXML(e.ID))
+		out.data("name", e.Name)
+		out.data("type", e.Type)
+		out.data("file_path", e.FilePath)
+		if e.StartLine > 0 {
+			out.data("start_line", fmt.Sprint(e.StartLine))
+			out.data("end_line", fmt.Sprint(e.EndLine))
+		}
+		out.data("signature", e.Signature)
+		out.data("body", e.Body)
+		out.data("doc_string", e.DocString)
+		out.data("parent", e.Parent)
+		out.jsonData("properties", e.Properties)
+		out.printf("    </node>\n")
+	}
+	for _, rel := range g.Relationships {
+		out.printf("    <edge id=\"%s\" source=\"%s\" target=\"%s\">\n", escapeXML(rel.ID), escapeXML(rel.Source), escapeXML(rel.Target))
+		out.data("rel_type", rel.Type)
+		out.data("rel_file_path", rel.FilePath)
+		if rel.Line > 0 {
+			out.data("rel_line", fmt.Sprint(rel.Line))
+		}
+		if rel.Column > 0 {
+			out.data("rel_column", fmt.Sprint(rel.Column))
+		}
+		out.data("rel_node_kind", rel.NodeKind)
+		out.data("rel_text", rel.Text)
+		out.jsonData("rel_properties", rel.Properties)
+		out.printf("    </edge>\n")
+	}
+	out.printf("  </graph>\n</graphml>\n")
+
+	if out.err != nil {
+		return fmt.Errorf("failed to write GraphML export: %w", out.err)
+	}
+	return nil
+}
+
+// graphMLWriter writes GraphML elements, keeping the first write error
+type graphMLWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (g *graphMLWriter) printf(format string, args ...interface{}) {
+	if g.err == nil {
+		_, g.err = fmt.Fprintf(g.w, format, args...)
+	}
+}
+
+// data writes a data element unless its value is empty
+func (g *graphMLWriter) data(key, value string) {
+	if value != "" {
+		g.printf("      <data key=%q>%s</data>\n", key, escapeXML(value))
+	}
+}
+
+// jsonData writes properties as a JSON-encoded data element
+func (g *graphMLWriter) jsonData(key string, properties map[string]interface{}) {
+	if len(properties) == 0 {
+		return
+	}
+	encoded, err := json.Marshal(properties)
+	if err != nil {
+		if g.err == nil {
+			g.err = err
+		}
+		return
+	}
+	g.data(key, string(encoded))
+}
+
+// escapeXML escapes text for use in XML content and attribute values
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// literalProperties are the properties that hold literal values from the
+// source, removed by RedactStrings
+var literalProperties = map[string]bool{
+	"value":          true,
+	"default":        true,
+	"initializer":    true,
+	"expression":     true,
+	"arguments":      true,
+	"message":        true,
+	"text":           true,
+	"url":            true,
+	"url_pattern":    true,
+	"base_url":       true,
+	"api_path":       true,
+	"query":          true,
+	"key":            true,
+	"flag":           true,
+	"tag":            true,
+	"expected_value": true,
+	"actual_value":   true,
+	"error_message":  true,
+	"suggestion":     true,
+	"help":           true,
+	"description":    true,
+}
+
+// quotedLiteral matches double-, single- and back-quoted string literals
+var quotedLiteral = regexp.MustCompile("\"(?:[^\"\\\\\\n]|\\\\.)*\"|'(?:[^'\\\\\\n]|\\\\.)*'|`[^`]*`")
+
+// identifier matches the identifiers HashNames replaces
+var identifier = regexp.MustCompile(`[A-Za-z_$][A-Za-z0-9_$]*`)
+
+// stringNodes and commentNodes are the syntax nodes that RedactStrings and
+// RedactComments remove from bodies
+var (
+	stringNodes = map[string]bool{
+		"interpreted_string_literal": true, // Go
+		"raw_string_literal":         true,
+		"rune_literal":               true,
+		"string":                     true, // Python, TypeScript
+		"template_string":            true,
+	}
+	commentNodes = map[string]bool{
+		"comment": true,
+	}
+)
+
+// exporter converts the entities and relationships of a build into their
+// exported form
+type exporter struct {
+	opts  ExportOptions
+	r     *BuildGraphResult
+	ids   map[string]bool // every entity ID, to recognize references to entities
+	names map[string]bool // every identifier that appears in an entity name
+}
+
+func newExporter(r *BuildGraphResult, opts ExportOptions) *exporter {
+	x := &exporter{
+		opts:  opts,
+		r:     r,
+		ids:   make(map[string]bool),
+		names: make(map[string]bool),
+	}
+	for id, entity := range r.Builder.GetAllEntities() {
+		x.ids[id] = true
+		for _, name := range identifier.FindAllString(entity.Name, -1) {
+			x.names[name] = true
+		}
+	}
+	return x
+}
+
+// graph returns the exported graph in a stable order
+func (x *exporter) graph() *ExportedGraph {
+	all := x.r.Builder.GetAllEntities()
+	list := make([]*entities.Entity, 0, len(all))
+	for _, entity := range all {
+		list = append(list, entity)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].FilePath != list[j].FilePath {
+			return list[i].FilePath < list[j].FilePath
+		}
+		if list[i].StartByte != list[j].StartByte {
+			return list[i].StartByte < list[j].StartByte
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	relationships := append([]*entities.Relationship(nil), x.r.Builder.GetAllRelationships()...)
+	sort.SliceStable(relationships, func(i, j int) bool {
+		a, b := relationships[i], relationships[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.SourceID != b.SourceID {
+			return a.SourceID < b.SourceID
+		}
+		if a.TargetID != b.TargetID {
+			return a.TargetID < b.TargetID
+		}
+		return a.ID < b.ID
+	})
+
+	g := &ExportedGraph{
+		Entities:      make([]*ExportedEntity, 0, len(list)),
+		Relationships: make([]*ExportedRelationship, 0, len(relationships)),
+	}
+	for _, entity := range list {
+		g.Entities = append(g.Entities, x.entity(entity))
+	}
+	for _, rel := range relationships {
+		g.Relationships = append(g.Relationships, x.relationship(rel))
+	}
+	return g
+}
+
+func (x *exporter) entity(e *entities.Entity) *ExportedEntity {
+	commentedCode := e.Type == entities.EntityTypeCommentedCode
+	out := &ExportedEntity{
+		ID:         x.id(e.ID),
+		Name:       x.name(e.Name),
+		Type:       string(e.Type),
+		FilePath:   x.path(e.FilePath),
+		StartLine:  e.StartLine(),
+		EndLine:    e.EndLine(),
+		Signature:  x.text(e.Signature),
+		Body:       x.body(e),
+		Properties: x.properties(e.Properties),
+	}
+	if !x.opts.RedactComments {
+		out.DocString = x.text(e.DocString)
+	}
+	if e.Parent != nil {
+		out.Parent = x.id(e.Parent.ID)
+	}
+	switch {
+	case commentedCode && x.opts.RedactComments:
+		// The name and text of commented-out code are the comment itself
+		out.Name = redacted
+		out.Body = ""
+		delete(out.Properties, "text")
+	case (e.Type == entities.EntityTypeFeatureFlag || e.Type == entities.EntityTypeErrorMessageIssue) &&
+		x.opts.RedactStrings && !x.opts.HashNames:
+		// The name is the flag key or the error message itself
+		out.Name = redacted
+	}
+	return out
+}
+
+func (x *exporter) relationship(rel *entities.Relationship) *ExportedRelationship {
+	out := &ExportedRelationship{
+		ID:         x.id(rel.ID),
+		Type:       string(rel.Type),
+		Source:     x.id(rel.SourceID),
+		Target:     x.id(rel.TargetID),
+		Properties: x.properties(rel.Properties),
+	}
+	switch {
+	case rel.Provenance != nil:
+		out.FilePath = x.path(rel.Provenance.FilePath)
+		out.Line = rel.Provenance.Line
+		out.Column = rel.Provenance.Column
+		out.NodeKind = rel.Provenance.NodeKind
+		if !x.opts.RedactBodies {
+			out.Text = x.text(rel.Provenance.MatchedText)
+		}
+	case rel.Location != nil:
+		out.FilePath = x.path(rel.Location.FilePath)
+		out.Line = rel.Location.Line
+	}
+	return out
+}
+
+// body returns the body of an entity with the redactions that apply to it.
+// When strings or comments must be removed from a body that cannot be
+// matched to its syntax tree, the body is left out rather than leaked.
+func (x *exporter) body(e *entities.Entity) string {
+	if x.opts.RedactBodies || e.Body == "" {
+		return ""
+	}
+	if !x.opts.RedactStrings && !x.opts.RedactComments {
+		return x.text(e.Body)
+	}
+	file := x.r.Builder.GetFile(e.FilePath)
+	if e.Node == nil || file == nil || int(e.Node.EndByte()) > len(file.Content) {
+		return ""
+	}
+	start := e.Node.StartByte()
+	offset := strings.Index(string(file.Content[start:e.Node.EndByte()]), e.Body)
+	if offset < 0 {
+		return ""
+	}
+	start += uint(offset)
+	return x.text(x.redactSource(file.Content, e.Node, start, start+uint(len(e.Body))))
+}
+
+// redactSource returns the source between start and end with the string
+// literals and comments inside node removed as the options ask. Removed text
+// keeps its line breaks, so line numbers within the body still hold.
+func (x *exporter) redactSource(content []byte, node *ts.Node, start, end uint) string {
+	var b strings.Builder
+	next := start
+	var visit func(n *ts.Node)
+	visit = func(n *ts.Node) {
+		if n.EndByte() <= next || n.StartByte() >= end {
+			return
+		}
+		kind := n.Kind()
+		if (x.opts.RedactStrings && stringNodes[kind]) || (x.opts.RedactComments && commentNodes[kind]) {
+			from, to := max(n.StartByte(), next), min(n.EndByte(), end)
+			b.Write(content[next:from])
+			if stringNodes[kind] {
+				b.WriteString(`""`)
+			}
+			b.WriteString(strings.Repeat("\n", strings.Count(string(content[from:to]), "\n")))
+			next = to
+			return
+		}
+		for i := uint(0); i < n.ChildCount(); i++ {
+			visit(n.Child(i))
+		}
+	}
+	visit(node)
+	b.Write(content[next:end])
+	return b.String()
+}
+
+// properties returns a copy of entity or relationship properties with the
+// redactions that apply to them
+func (x *exporter) properties(properties map[string]interface{}) map[string]interface{} {
+	if len(properties) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		if x.opts.RedactStrings && literalProperties[key] {
+			if s, ok := value.(string); !ok || s != "" {
+				value = redacted
+			}
+		}
+		out[key] = x.value(value)
+	}
+	return out
+}
+
+// value applies text redactions to a property value and to the strings in
+// lists and maps
+func (x *exporter) value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if x.ids[v] {
+			return x.id(v)
+		}
+		return x.text(v)
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i], _ = x.value(s).(string)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = x.value(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = x.value(item)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for key, item := range v {
+			out[key], _ = x.value(item).(string)
+		}
+		return out
+	case nil, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	}
+	return x.text(fmt.Sprint(value))
+}
+
+// text applies the string and name redactions to source-derived text
+func (x *exporter) text(text string) string {
+	text = x.literals(text)
+	if x.opts.HashNames {
+		text = identifier.ReplaceAllStringFunc(text, func(word string) string {
+			if x.names[word] {
+				return x.hash("n", word)
+			}
+			return word
+		})
+	}
+	return text
+}
+
+// literals blanks the quoted string literals in text when strings are
+// redacted, keeping the quotes
+func (x *exporter) literals(text string) string {
+	if !x.opts.RedactStrings || text == "" {
+		return text
+	}
+	return quotedLiteral.ReplaceAllStringFunc(text, func(literal string) string {
+		return literal[:1] + literal[len(literal)-1:]
+	})
+}
+
+// name returns an entity name, with every identifier in it hashed when
+// names are hashed
+func (x *exporter) name(name string) string {
+	name = x.literals(name)
+	if !x.opts.HashNames {
+		return name
+	}
+	return identifier.ReplaceAllStringFunc(name, func(word string) string {
+		return x.hash("n", word)
+	})
+}
+
+// id returns an entity or relationship ID, hashed when names are hashed
+// since IDs are built from them
+func (x *exporter) id(id string) string {
+	if !x.opts.HashNames || id == "" {
+		return id
+	}
+	return x.hash("id", id)
+}
+
+// path returns a file path with each element hashed when names are hashed.
+// Extensions are kept so the language of each file stays known.
+func (x *exporter) path(filePath string) string {
+	if !x.opts.HashNames || filePath == "" {
+		return filePath
+	}
+	elements := strings.Split(strings.ReplaceAll(filePath, "\\", "/"), "/")
+	for i, element := range elements {
+		ext := path.Ext(element)
+		if element == "" || element == "." || element == ".." {
+			continue
+		}
+		elements[i] = x.hash("p", strings.TrimSuffix(element, ext)) + ext
+	}
+	return strings.Join(elements, "/")
+}
+
+// hash returns a short keyed hash of value, prefixed so that it remains a
+// valid identifier
+func (x *exporter) hash(prefix, value string) string {
+	mac := hmac.New(sha256.New, []byte(x.opts.HashKey))
+	mac.Write([]byte(value))
+	return prefix + hex.EncodeToString(mac.Sum(nil))[:12]
+}
@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// FeatureFlag is a feature flag key together with every place that evaluates it
+type FeatureFlag struct {
+	Name string `json:"name"`
+
+	// Provider is the flag library: "launchdarkly", "unleash", "openfeature",
+	// or "custom" for FeatureFlagFunctions and helpers like featureEnabled
+	Provider string       `json:"provider"`
+	Checks   []*FlagCheck `json:"checks"`
+
+	// Entity is the underlying FeatureFlag entity
+	Entity *entities.Entity `json:"-"`
+}
+
+// FlagCheck is a single call site that evaluates a feature flag
+type FlagCheck struct {
+	Function   string               `json:"function"`
+	FunctionID string               `json:"function_id"`
+	Callee     string               `json:"callee"` // the evaluation call, e.g. "ldClient.BoolVariation"
+	FilePath   string               `json:"file_path"`
+	Line       int                  `json:"line"`
+	Provenance *entities.Provenance `json:"provenance,omitempty"`
+}
+
+// GetFeatureFlags returns every feature flag evaluated in the repository with
+// its check sites, which is what an agent needs to remove a stale flag.
+//
+// Calls to LaunchDarkly (BoolVariation, variation, ...), Unleash (IsEnabled,
+// is_enabled, getVariant), OpenFeature (getBooleanValue, ...) and helpers named
+// featureEnabled or isFeatureEnabled are recognized in Go, Python and
+// TypeScript, as are calls matching BuildGraphOptions.FeatureFlagFunctions.
+// Only literal flag keys are detected. Each check is also stored in the graph
+// as a CHECKS_FLAG relationship from the enclosing function to the flag.
+//
+// Flags are ordered by name and checks by file and line.
+//
+// Example:
+//
+//	flags, err := result.GetFeatureFlags()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, flag := range flags {
+//		fmt.Printf("%s (%s): %d checks\n", flag.Name, flag.Provider, len(flag.Checks))
+//	}
+func (r *BuildGraphResult) GetFeatureFlags() ([]*FeatureFlag, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	byID := make(map[string]*FeatureFlag)
+	for _, entity := range r.entitiesOfType(entities.EntityTypeFeatureFlag) {
+		provider, _ := entity.GetProperty("provider").(string)
+		byID[entity.ID] = &FeatureFlag{
+			Name:     entity.Name,
+			Provider: provider,
+			Checks:   make([]*FlagCheck, 0),
+			Entity:   entity,
+		}
+	}
+
+	for _, rel := range r.Builder.GetUnresolvedRelationships() {
+		flag := byID[rel.TargetID]
+		if rel.Type != entities.RelationshipTypeChecksFlag || flag == nil {
+			continue
+		}
+
+		check := &FlagCheck{FunctionID: rel.SourceID, Provenance: rel.Provenance}
+		check.Callee, _ = rel.GetProperty("callee").(string)
+		if caller := r.Builder.GetEntity(rel.SourceID); caller != nil {
+			check.Function = caller.Name
+			check.FilePath = caller.FilePath
+		}
+		if rel.Provenance != nil {
+			check.FilePath = rel.Provenance.FilePath
+			check.Line = int(rel.Provenance.Line)
+		}
+		flag.Checks = append(flag.Checks, check)
+	}
+
+	flags := make([]*FeatureFlag, 0, len(byID))
+	for _, flag := range byID {
+		sort.SliceStable(flag.Checks, func(i, j int) bool {
+			if flag.Checks[i].FilePath != flag.Checks[j].FilePath {
+				return flag.Checks[i].FilePath < flag.Checks[j].FilePath
+			}
+			return flag.Checks[i].Line < flag.Checks[j].Line
+		})
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags, nil
+}
@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetGenerics returns the type parameters declared by generic Go and
+// TypeScript functions and types, one Generic entity per parameter. Each
+// carries its "constraint" as written, without a TypeScript "extends", its
+// "position" in the parameter list, its "language" and the "owner" ID and
+// "owner_name" of the declaring function or type. Go owners also list the
+// whole parameter list in their "type_parameters" property.
+//
+// In the graph, each Generic node has a CONSTRAINS relationship to every
+// interface, struct, class or named type of the repository its constraint
+// mentions, with constraint_type "exact", "approximate" for Go ~T terms, or
+// "extends" for TypeScript. Results are ordered by file and position.
+//
+// Example:
+//
+//	generics, err := result.GetGenerics()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, g := range generics {
+//		fmt.Printf("%v[%s %v]\n", g.GetProperty("owner_name"), g.Name, g.GetProperty("constraint"))
+//	}
+func (r *BuildGraphResult) GetGenerics() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeGeneric), nil
+}
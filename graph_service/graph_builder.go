@@ -79,7 +79,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
 	"github.com/onyx/onyx-tui/graph_service/internal/db"
@@ -146,8 +148,136 @@ type BuildGraphOptions struct {
 
 	// IgnorePatterns specifies paths/patterns to be excluded from static analysis.
 	// Defaults include common build and VCS directories, plus ".goru". Patterns
-	// match on substring within full path or basename glob.
+	// match on substring within the path relative to RepoPath, or basename
+	// glob, so "tmp" does not skip a repository under /tmp.
 	IgnorePatterns []string
+
+	// Languages restricts analysis to files of the listed languages; files of
+	// other languages are skipped during the walk. Accepted names are "go",
+	// "python", "typescript" (.ts, .tsx), "javascript" (.js, .jsx), "hcl"
+	// (Terraform .tf) and "sql" (migrations), plus aliases such as "golang",
+	// "ts" or "terraform".
+	// Applies on top of IgnorePatterns. Empty analyzes all supported languages.
+	//
+	// Example: []string{"go"} to ignore the frontend of a Go/TypeScript monorepo
+	Languages []string
+
+	// FeatureFlagFunctions adds function-name patterns whose calls evaluate a
+	// feature flag, for in-house flag helpers such as featureEnabled("x").
+	// Patterns use path.Match syntax and match either the full callee
+	// ("flags.IsOn") or its last segment ("featureEnabled"). LaunchDarkly,
+	// Unleash and OpenFeature SDK calls are always detected.
+	FeatureFlagFunctions []string
+
+	// ErrorMessageRules overrides the conventions error messages are checked
+	// against, by language name (see Languages for the accepted names); see
+	// GetErrorMessageIssues for the defaults. A language given here replaces
+	// its default rules, and an empty ErrorMessageRules turns its checks off.
+	//
+	// Example: map[string]graph.ErrorMessageRules{"python": {Capitalization: "upper", TrailingPeriod: "require"}}
+	ErrorMessageRules map[string]ErrorMessageRules
+
+	// EntityTypes restricts the entities stored in the database to the listed
+	// node types, such as "Function" or "Struct"; File nodes are always
+	// stored. Relationships to or from entities that are not stored are
+	// dropped too. Names match the node tables of the schema in any case.
+	// Analysis is unaffected, so methods such as GetTestCoverage still see
+	// every entity. Empty stores all types.
+	EntityTypes []string
+
+	// RelationshipTypes restricts the relationships stored in the database to
+	// the listed types, such as "CALLS" or "IMPORTS", matched in any case.
+	// Keeps the database small when only a few relationship types are
+	// queried. Empty stores all types.
+	//
+	// Example: []string{"CALLS", "IMPORTS"} for a call graph
+	RelationshipTypes []string
+
+	// ExtractNested makes functions defined inside other functions entities
+	// of their own: Go func literals, Python nested defs and lambdas, and
+	// TypeScript nested functions, arrow functions and function expressions.
+	// Each is a Function with a "nested" property, named after the variable it
+	// is assigned to or "func<N>" when anonymous (full name
+	// "<enclosing>.func<N>"), and linked from its enclosing function by a
+	// CONTAINS relationship; calls made inside it are attributed to it rather
+	// than to the enclosing function.
+	//
+	// When false, no nested function is an entity in any language and their
+	// calls are attributed to the enclosing function. Functions nested only
+	// in anonymous top-level callbacks are unaffected either way.
+	ExtractNested bool
+
+	// ReuseExisting resumes the storage of an interrupted build of the same
+	// repository into DBPath instead of starting over. Builds with a DBPath
+	// and without CleanupDB store the graph in batches and record their
+	// progress in a manifest next to the database ("<DBPath>.manifest.json");
+	// a resumed build only stores the files the interrupted build had not
+	// finished. When the previous build completed, nothing is stored again.
+	//
+	// Only storage is checkpointed. Discovery and resolution are held in
+	// memory, so a resumed build always parses and resolves the whole
+	// repository again, and a build interrupted before storage began
+	// resumes nothing.
+	//
+	// When opening DBPath migrated its schema to relationship types that
+	// connect more node tables, the relationships of every file are stored
+	// again, even if the previous build completed.
+	//
+	// Resuming fails if any source file was added, removed or modified since
+	// the checkpoint; remove DBPath and its manifest to rebuild from scratch.
+	// Requires RepoPath and DBPath, since a fresh clone never matches.
+	ReuseExisting bool
+
+	// AuditLog records every change made to the stored graph in an append-only
+	// JSONL file next to the database ("<DBPath>.audit.jsonl"): what changed,
+	// when, and which file or build triggered it. The log is kept across
+	// builds into the same DBPath; read it with GetMutationHistory. Requires
+	// DBPath without CleanupDB.
+	AuditLog bool
+
+	// ValidateAfterBuild runs Validate once the graph is built, after every
+	// PostPasses, and stores what it finds in
+	// BuildGraphResult.ConsistencyIssues. Inconsistencies do not fail the
+	// build.
+	ValidateAfterBuild bool
+
+	// MaxDuration bounds the time spent analyzing, counted from the call to
+	// BuildGraph. Once exceeded, no further file is parsed: the graph is
+	// resolved and stored from the files analyzed so far, and the result is
+	// marked Partial with the others in UnanalyzedFiles. The limit is checked
+	// between files, so one large file can overrun it. Zero means no limit.
+	//
+	// The checkpoint of a partial build only covers the analyzed files, so
+	// ReuseExisting fails once the build is run without a limit; remove
+	// DBPath and its manifest to complete it.
+	//
+	// Example: 10 * time.Second for a quick first look at a large repository
+	MaxDuration time.Duration
+
+	// WithChurn walks the git history of the repository once the graph is
+	// built and records on every entity its "change_frequency": the number
+	// of commits that changed a line in its range, with the lines of older
+	// commits followed through later edits to where they are at HEAD, and,
+	// when any did, the "last_author" and "last_changed" time (RFC 3339) of
+	// the latest. Read the functions that change often and are complex with
+	// GetHotspots.
+	// Walking the history diffs every commit, so it can take longer than the
+	// analysis itself on a large repository. The build fails if RepoPath is
+	// not in a git repository.
+	WithChurn bool
+
+	// ChurnWindow limits WithChurn to the commits of that long before the
+	// build. Zero walks the whole history.
+	//
+	// Example: 90 * 24 * time.Hour for the last three months
+	ChurnWindow time.Duration
+
+	// PostPasses are analyses run in order over the built graph before
+	// BuildGraph returns, after WithChurn and before ValidateAfterBuild. A
+	// pass that fails fails the build. See PostPass for the passes built in.
+	//
+	// Example: []PostPass{CoverageReportPass("coverage.out", CoverageFormatGo)}
+	PostPasses []PostPass
 }
 
 // BuildGraphResult contains the complete results of code graph analysis.
@@ -193,6 +323,27 @@ type BuildGraphResult struct {
 	// entity and relationship data. This is the preferred way to access
 	// detailed analysis results programmatically.
 	Builder *analyzer.GraphBuilder
+
+	// ReadOnly makes QueryRows, QueryStream, RunQuery and QueryGraph reject
+	// queries that could modify the graph, such as CREATE, SET, DELETE or
+	// CALL of a function other than read-only ones like show_tables, with an
+	// error wrapping ErrWriteQuery. Set it before running queries from an untrusted
+	// source like an LLM agent. Database is not affected.
+	ReadOnly bool
+
+	// ConsistencyIssues holds what Validate found after the build when
+	// BuildGraphOptions.ValidateAfterBuild is set
+	ConsistencyIssues []ConsistencyIssue
+
+	// Partial is set when BuildGraphOptions.MaxDuration was exceeded and
+	// the graph holds only some of the files. UnanalyzedFiles lists the
+	// others, relative to the repository.
+	Partial         bool
+	UnanalyzedFiles []string
+
+	// postPassTime is the time spent running post-analysis passes, reported
+	// by Benchmark
+	postPassTime time.Duration
 }
 
 // BuildGraphStats provides quantitative metrics about the code analysis.
@@ -345,11 +496,40 @@ type AnalysisResult struct {
 //   - Memory usage peaks during relationship resolution phase
 //   - Consider using temporary databases for large one-time analyses
 func BuildGraph(opts BuildGraphOptions) (*BuildGraphResult, error) {
+	start := time.Now()
+
 	// Load environment variables if requested
 	if opts.LoadEnvFile {
 		_ = godotenv.Load() // Silently continue if .env doesn't exist
 	}
 
+	if opts.ReuseExisting && (opts.RepoPath == "" || opts.DBPath == "") {
+		return nil, fmt.Errorf("ReuseExisting requires RepoPath and DBPath")
+	}
+	if opts.AuditLog && (opts.DBPath == "" || opts.CleanupDB) {
+		return nil, fmt.Errorf("AuditLog requires DBPath without CleanupDB")
+	}
+
+	languages := make([]string, 0, len(opts.Languages))
+	for _, name := range opts.Languages {
+		lang, err := analyzer.NormalizeLanguage(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Languages option: %w", err)
+		}
+		languages = append(languages, lang)
+	}
+	errorMessageRules := make(map[string]ErrorMessageRules, len(opts.ErrorMessageRules))
+	for name, rules := range opts.ErrorMessageRules {
+		lang, err := analyzer.NormalizeLanguage(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ErrorMessageRules option: %w", err)
+		}
+		if err := rules.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid ErrorMessageRules option for %s: %w", lang, err)
+		}
+		errorMessageRules[lang] = rules
+	}
+
 	// Determine repository path
 	repoPath := opts.RepoPath
 	if repoPath == "" && opts.RepoURL != "" {
@@ -394,6 +574,12 @@ func BuildGraph(opts BuildGraphOptions) (*BuildGraphResult, error) {
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	entityTypes, relationshipTypes, err := storedTypes(kdb, opts.EntityTypes, opts.RelationshipTypes)
+	if err != nil {
+		kdb.Close()
+		return nil, err
+	}
+
 	// Create graph builder with the new entity system and configure ignore patterns
 	config := analyzer.DefaultGraphBuilderConfig()
 	if len(opts.IgnorePatterns) > 0 {
@@ -412,6 +598,25 @@ func BuildGraph(opts BuildGraphOptions) (*BuildGraphResult, error) {
 			config.IgnorePatterns = append(config.IgnorePatterns, filepath.Base(dbPath))
 		}
 	}
+	config.Languages = languages
+	config.FeatureFlagFunctions = opts.FeatureFlagFunctions
+	for lang, rules := range errorMessageRules {
+		config.ErrorMessageRules[lang] = rules
+	}
+	config.EntityTypes = entityTypes
+	config.RelationshipTypes = relationshipTypes
+	config.ExtractNested = opts.ExtractNested
+	if opts.DBPath != "" && !opts.CleanupDB {
+		config.ManifestPath = opts.DBPath + ".manifest.json"
+		config.ResumeFromCheckpoint = opts.ReuseExisting
+		config.RelationshipsMigrated = kdb.RelationshipsMigrated
+	}
+	if opts.AuditLog {
+		config.MutationLogPath = opts.DBPath + ".audit.jsonl"
+	}
+	if opts.MaxDuration > 0 {
+		config.Deadline = start.Add(opts.MaxDuration)
+	}
 	builder := analyzer.NewGraphBuilderWithConfig(kdb, config)
 
 	// Build the graph using the sophisticated analyzer
@@ -432,12 +637,85 @@ func BuildGraph(opts BuildGraphOptions) (*BuildGraphResult, error) {
 	}
 
 	// Return result - note: caller is responsible for closing the database
-	return &BuildGraphResult{
+	result := &BuildGraphResult{
 		DBPath:   dbPath,
 		Database: kdb,
 		Stats:    extStats,
 		Builder:  builder,
-	}, nil
+	}
+	if unanalyzed := builder.UnanalyzedFiles(); len(unanalyzed) > 0 {
+		result.Partial = true
+		result.UnanalyzedFiles = unanalyzed
+	}
+
+	result.markAPISignatures()
+
+	// Built-in passes run around those of the caller: churn first, so they
+	// can use it, and validation last, so it checks what they left
+	passes := make([]PostPass, 0, len(opts.PostPasses)+2)
+	if opts.WithChurn {
+		var since time.Time
+		if opts.ChurnWindow > 0 {
+			since = start.Add(-opts.ChurnWindow)
+		}
+		passes = append(passes, PostPassFunc(func(r *BuildGraphResult) error {
+			if err := r.attachChurn(repoPath, since); err != nil {
+				return fmt.Errorf("failed to analyze git history: %w", err)
+			}
+			return nil
+		}))
+	}
+	passes = append(passes, opts.PostPasses...)
+	if opts.ValidateAfterBuild {
+		passes = append(passes, ValidatePass())
+	}
+	if err := result.runPostPasses(passes); err != nil {
+		kdb.Close()
+		return nil, err
+	}
+	return result, nil
+}
+
+// storedTypes resolves the EntityTypes and RelationshipTypes options against
+// the node and relationship tables of the schema, so a misspelled type fails
+// the build instead of silently storing nothing
+func storedTypes(kdb *db.KuzuDatabase, entityNames, relationshipNames []string) ([]entities.EntityType, []entities.RelationshipType, error) {
+	if len(entityNames) == 0 && len(relationshipNames) == 0 {
+		return nil, nil, nil
+	}
+	nodeTables, relTables, err := kdb.TableNames()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entityTypes := make([]entities.EntityType, 0, len(entityNames))
+	for _, name := range entityNames {
+		table := matchTable(nodeTables, name)
+		if table == "" {
+			return nil, nil, fmt.Errorf("invalid EntityTypes option: unknown entity type %q", name)
+		}
+		entityTypes = append(entityTypes, entities.EntityType(table))
+	}
+
+	relationshipTypes := make([]entities.RelationshipType, 0, len(relationshipNames))
+	for _, name := range relationshipNames {
+		if matchTable(relTables, name) == "" {
+			return nil, nil, fmt.Errorf("invalid RelationshipTypes option: unknown relationship type %q", name)
+		}
+		relationshipTypes = append(relationshipTypes, entities.RelationshipType(strings.ToUpper(strings.TrimSpace(name))))
+	}
+	return entityTypes, relationshipTypes, nil
+}
+
+// matchTable returns the table whose name equals name in any case, or "" if
+// there is none
+func matchTable(tables []string, name string) string {
+	for _, table := range tables {
+		if strings.EqualFold(table, strings.TrimSpace(name)) {
+			return table
+		}
+	}
+	return ""
 }
 
 // GetAnalysisResult returns detailed analysis results with full entity access
@@ -457,8 +735,26 @@ func (r *BuildGraphResult) GetAnalysisResult() *AnalysisResult {
 	}
 }
 
+// CheckDatabase verifies that a KuzuDB database can be created at dbPath with
+// the graph schema and queried. It is meant for environment checks: the
+// database is closed afterwards and left on disk for the caller to remove.
+func CheckDatabase(dbPath string) error {
+	database, err := db.NewKuzuDatabase(dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := database.CreateSchema(); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	_, err = database.ExecuteQuery("MATCH (f:File) RETURN count(f)")
+	return err
+}
+
 // QueryGraph uses LLM to generate and execute a Cypher query against the code graph
-// based on a natural language question
+// based on a natural language question. Generated queries that would modify the
+// graph are rejected with an error wrapping ErrWriteQuery.
 func QueryGraph(db *db.KuzuDatabase, question string) (string, error) {
 	// Initialize LLM client
 	client, err := llm.NewLLMClient()
@@ -478,6 +774,10 @@ func QueryGraph(db *db.KuzuDatabase, question string) (string, error) {
 		return "", fmt.Errorf("failed to generate query: %w", err)
 	}
 
+	if err := checkWriteQuery(query); err != nil {
+		return "", err
+	}
+
 	// Execute query
 	result, err := db.ExecuteQuery(query)
 	if err != nil {
@@ -492,6 +792,9 @@ func (r *BuildGraphResult) QueryGraph(question string) (string, error) {
 	if r.Builder == nil {
 		return QueryGraph(r.Database, question)
 	}
+	if err := r.checkReadOnly(question); err != nil {
+		return "", err
+	}
 	return r.Builder.QueryGraph(question)
 }
 
@@ -503,6 +806,17 @@ func (r *BuildGraphResult) GetEntityByName(name string) []*entities.Entity {
 	return r.Builder.GetEntitiesByName(name)
 }
 
+// GetEntitiesInPath returns the entities of the files under a directory, such
+// as "internal/db", ordered by file path and position. The prefix matches
+// whole path elements and may also name a single file. It is answered from a
+// path index built with the graph rather than by scanning every entity.
+func (r *BuildGraphResult) GetEntitiesInPath(prefix string) []*entities.Entity {
+	if r.Builder == nil {
+		return nil
+	}
+	return r.Builder.GetEntitiesInPath(prefix)
+}
+
 // GetFile retrieves a file by path
 func (r *BuildGraphResult) GetFile(filePath string) *entities.File {
 	if r.Builder == nil {
@@ -819,7 +1133,10 @@ func (r *BuildGraphResult) GetTestCoverage(entityID string) (*TestCoverageResult
 	return result, nil
 }
 
-// GetUncoveredEntities finds all entities without tests
+// GetUncoveredEntities finds all entities without tests. Declarations marked
+// with an onyx:ignore or onyx:ignore-coverage comment are not reported. For
+// entities a coverage report imported with ImportCoverageReport has data on,
+// whether their code ran decides, rather than the tests found statically.
 func (r *BuildGraphResult) GetUncoveredEntities() ([]*entities.Entity, error) {
 	if r.Builder == nil {
 		return nil, fmt.Errorf("builder not available")
@@ -831,6 +1148,14 @@ func (r *BuildGraphResult) GetUncoveredEntities() ([]*entities.Entity, error) {
 	allEntities := r.Builder.GetAllEntities()
 	for _, entity := range allEntities {
 		if !entity.IsTest() && r.isProductionEntity(entity) {
+			// Measured coverage beats the static mapping
+			if covered, ok := entity.GetProperty("runtime_covered").(bool); ok {
+				if !covered {
+					uncovered = append(uncovered, entity)
+				}
+				continue
+			}
+
 			// Check if this entity has any test coverage
 			coverage, err := r.GetTestCoverage(entity.ID)
 			if err != nil {
@@ -932,6 +1257,10 @@ func (r *BuildGraphResult) GetCoverageMetrics() (*CoverageMetrics, error) {
 	testEntities := make([]*entities.Entity, 0)
 
 	for _, entity := range allEntities {
+		if entity.Type == entities.EntityTypeAssertion {
+			// Assertions are counted through the assertion count of their test
+			continue
+		}
 		if entity.IsTest() {
 			testEntities = append(testEntities, entity)
 
@@ -1146,6 +1475,11 @@ func (r *BuildGraphResult) isProductionEntity(entity *entities.Entity) bool {
 		return false
 	}
 
+	// Skip entities excluded with onyx:ignore or onyx:ignore-coverage
+	if entity.IgnoresCoverage() {
+		return false
+	}
+
 	// Include functions, methods, classes, structs, interfaces
 	productionTypes := map[entities.EntityType]bool{
 		entities.EntityTypeFunction:  true,
@@ -1301,3 +1635,27 @@ func minFloat(a, b float64) float64 {
 	}
 	return b
 }
+
+// entitiesOfType returns all analyzed entities of the given types, ordered by
+// file path and then by position within the file
+func (r *BuildGraphResult) entitiesOfType(types ...entities.EntityType) []*entities.Entity {
+	wanted := make(map[entities.EntityType]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	result := make([]*entities.Entity, 0)
+	for _, entity := range r.Builder.GetAllEntities() {
+		if wanted[entity.Type] {
+			result = append(result, entity)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].FilePath != result[j].FilePath {
+			return result[i].FilePath < result[j].FilePath
+		}
+		return result[i].StartByte < result[j].StartByte
+	})
+	return result
+}
@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+	"github.com/onyx/onyx-tui/graph_service/internal/git"
+)
+
+// Hotspot is a function that changes often and is complex, where bugs are
+// most likely and where review and refactoring pay off most
+type Hotspot struct {
+	Name            string `json:"name"`
+	FilePath        string `json:"file_path"`
+	Line            int    `json:"line"`
+	ChangeFrequency int    `json:"change_frequency"` // Commits that changed it
+	Complexity      int    `json:"complexity"`       // Cyclomatic complexity
+	Score           int    `json:"score"`            // ChangeFrequency times Complexity
+
+	Entity *entities.Entity `json:"-"`
+}
+
+// GetHotspots returns the functions and methods that changed in the history
+// walked by BuildGraphOptions.WithChurn, ranked by their change frequency
+// times their cyclomatic complexity, highest first. A function that changes
+// often but is simple, or is complex but stable, ranks below one that is
+// both. Ties are ordered by change frequency, then by file and position.
+// Functions marked with an onyx:ignore comment are not reported.
+//
+// It fails unless the graph was built with WithChurn.
+//
+// Example:
+//
+//	hotspots, err := result.GetHotspots()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, h := range hotspots[:min(10, len(hotspots))] {
+//		fmt.Printf("%s:%d %s changed %d times, complexity %d\n",
+//			h.FilePath, h.Line, h.Name, h.ChangeFrequency, h.Complexity)
+//	}
+func (r *BuildGraphResult) GetHotspots() ([]*Hotspot, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	computed := false
+	hotspots := make([]*Hotspot, 0)
+	for _, function := range r.entitiesOfType(entities.EntityTypeFunction, entities.EntityTypeMethod) {
+		changes, ok := function.GetProperty("change_frequency").(int)
+		computed = computed || ok
+		if changes == 0 || function.IsIgnored() {
+			continue
+		}
+		complexity, _ := function.GetProperty("complexity").(int)
+		complexity = max(complexity, 1)
+		hotspots = append(hotspots, &Hotspot{
+			Name:            function.Name,
+			FilePath:        function.FilePath,
+			Line:            function.StartLine(),
+			ChangeFrequency: changes,
+			Complexity:      complexity,
+			Score:           changes * complexity,
+			Entity:          function,
+		})
+	}
+	if !computed {
+		return nil, fmt.Errorf("change frequency not computed; build with WithChurn set")
+	}
+
+	sort.SliceStable(hotspots, func(i, j int) bool {
+		if hotspots[i].Score != hotspots[j].Score {
+			return hotspots[i].Score > hotspots[j].Score
+		}
+		return hotspots[i].ChangeFrequency > hotspots[j].ChangeFrequency
+	})
+	return hotspots, nil
+}
+
+// attachChurn records the "change_frequency" of every entity, and the
+// "last_author" and "last_changed" time of those that changed, from the
+// history of the repository at repoPath since the given time, or all of it
+// when since is zero
+func (r *BuildGraphResult) attachChurn(repoPath string, since time.Time) error {
+	histories, err := git.ChangeFrequency(repoPath, since)
+	if err != nil {
+		return err
+	}
+	for _, entity := range r.Builder.GetAllEntities() {
+		start, end := entity.StartLine(), entity.EndLine()
+		if start == 0 {
+			continue
+		}
+		changes := 0
+		if history := histories[filepath.ToSlash(entity.FilePath)]; history != nil {
+			changes = history.ChangesBetween(start, end)
+			if author, when, ok := history.LastChange(start, end); ok {
+				entity.SetProperty("last_author", author)
+				entity.SetProperty("last_changed", when.UTC().Format(time.RFC3339))
+			}
+		}
+		entity.SetProperty("change_frequency", changes)
+	}
+	return nil
+}
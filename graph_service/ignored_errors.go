@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// errorReturningMethods are well-known standard library and third-party calls
+// that return an error. A Go call statement or `_ = f()` whose callee is
+// outside the repository is only reported as ignoring an error when its method
+// name is listed here.
+var errorReturningMethods = map[string]bool{
+	"Close": true, "Decode": true, "Encode": true, "Flush": true, "Kill": true,
+	"Mkdir": true, "MkdirAll": true, "Remove": true, "RemoveAll": true, "Rename": true,
+	"Run": true, "Setenv": true, "Shutdown": true, "Start": true, "Sync": true,
+	"Chdir": true, "Chmod": true, "Truncate": true, "Unmarshal": true, "Wait": true,
+	"WriteFile": true,
+}
+
+// IgnoredError is a call site whose error is dropped: assigned to _, never
+// checked, discarded by calling the function as a statement, or caught by an
+// empty except/catch block.
+type IgnoredError struct {
+	Caller   string `json:"caller"`
+	CallerID string `json:"caller_id"`
+
+	// Callee is the called function as written at the call site, e.g. "os.Remove"
+	Callee   string `json:"callee"`
+	CalleeID string `json:"callee_id,omitempty"` // empty when the callee is outside the repository
+
+	// Reason is one of "blank_identifier", "never_checked", "discarded_result"
+	// or "empty_handler"
+	Reason string `json:"reason"`
+
+	// ExceptionTypes lists the exception types caught by an empty Python or
+	// TypeScript handler, comma-separated; empty for a bare except or catch
+	ExceptionTypes string `json:"exception_types,omitempty"`
+
+	FilePath   string               `json:"file_path"`
+	Line       int                  `json:"line"`
+	Provenance *entities.Provenance `json:"provenance,omitempty"`
+}
+
+// GetIgnoredErrors returns the call sites whose errors are silently dropped.
+// Calls into the repository are checked against the callee's declared result
+// types, so `_ = f()` is only reported when f actually returns an error. Calls
+// to external packages cannot be checked that way: multi-value assignments
+// ending in _ and unchecked variables declared with type error are always
+// reported, other blank assignments, unchecked variables and call statements
+// only for well-known error-returning methods such as Close or os.Remove.
+//
+// Sites whose callee is in the repository are also stored in the graph as
+// IGNORES_ERROR relationships, alongside the PROPAGATES_ERROR and
+// HANDLES_ERROR relationships of errors that are returned or checked; calls
+// to external packages are only reported here. Functions marked with an
+// onyx:ignore comment are skipped. Results are ordered by file and line.
+//
+// Example:
+//
+//	ignored, err := result.GetIgnoredErrors()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, ie := range ignored {
+//		fmt.Printf("%s:%d %s ignores the error from %s (%s)\n", ie.FilePath, ie.Line, ie.Caller, ie.Callee, ie.Reason)
+//	}
+func (r *BuildGraphResult) GetIgnoredErrors() ([]*IgnoredError, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	resolved := make(map[string]*entities.Relationship)
+	for _, rel := range r.Builder.GetAllRelationships() {
+		if rel.Type == entities.RelationshipTypeIgnoresError && rel.IsResolved {
+			resolved[rel.ID] = rel
+		}
+	}
+
+	ignored := make([]*IgnoredError, 0)
+	for _, rel := range r.Builder.GetUnresolvedRelationships() {
+		if rel.Type != entities.RelationshipTypeIgnoresError {
+			continue
+		}
+
+		caller := r.Builder.GetEntity(rel.SourceID)
+		if caller != nil && caller.IsIgnored() {
+			continue
+		}
+
+		reason, _ := rel.GetProperty("reason").(string)
+		calleeID := ""
+		if resolvedRel, ok := resolved[rel.ID]; ok {
+			calleeID = resolvedRel.TargetID
+		} else if !r.externalCallMayFail(rel, reason) {
+			continue
+		}
+
+		entry := &IgnoredError{
+			CallerID:   rel.SourceID,
+			Callee:     rel.TargetID,
+			CalleeID:   calleeID,
+			Reason:     reason,
+			Provenance: rel.Provenance,
+		}
+		entry.ExceptionTypes, _ = rel.GetProperty("exception_types").(string)
+		if caller != nil {
+			entry.Caller = caller.Name
+			entry.FilePath = caller.FilePath
+		}
+		if rel.Provenance != nil {
+			entry.FilePath = rel.Provenance.FilePath
+			entry.Line = int(rel.Provenance.Line)
+		}
+		ignored = append(ignored, entry)
+	}
+
+	sort.SliceStable(ignored, func(i, j int) bool {
+		if ignored[i].FilePath != ignored[j].FilePath {
+			return ignored[i].FilePath < ignored[j].FilePath
+		}
+		return ignored[i].Line < ignored[j].Line
+	})
+	return ignored, nil
+}
+
+// externalCallMayFail decides whether an IGNORES_ERROR relationship that did not
+// resolve should still be reported. Callees defined in the repository were
+// rejected during resolution because they do not return an error; a method
+// name shared with a repository function that returns an error is reported.
+// An unchecked variable is only known to be an error without the callee's
+// signature when it was declared with type error.
+func (r *BuildGraphResult) externalCallMayFail(rel *entities.Relationship, reason string) bool {
+	callee := rel.TargetID
+	name := callee[strings.LastIndex(callee, ".")+1:]
+	switch reason {
+	case analyzer.ErrorIgnoredBlank, analyzer.ErrorIgnoredDiscarded:
+	case analyzer.ErrorIgnoredUnchecked:
+		if variable, _ := rel.GetProperty("error_variable").(string); variable == "declared" {
+			return true
+		}
+	default:
+		return true
+	}
+
+	candidates := r.Builder.GetEntitiesByName(name)
+	if !strings.Contains(callee, ".") && len(candidates) > 0 {
+		return false
+	}
+	for _, candidate := range candidates {
+		if results, ok := candidate.GetProperty("result_types").([]string); ok && results[len(results)-1] == "error" {
+			return true
+		}
+	}
+	if count, _ := rel.GetProperty("result_count").(int); count > 1 {
+		return true
+	}
+	return errorReturningMethods[name]
+}
@@ -48,6 +48,7 @@ type GoroutineInfo struct {
 	LaunchSite   *entities.Entity
 	ChannelUsage []string
 	Context      string
+	Node         *ts.Node // go statement the goroutine is launched by
 }
 
 // GenericInfo represents generic type information
@@ -56,6 +57,7 @@ type GenericInfo struct {
 	Constraints []string
 	TypeParams  []string
 	Usage       []*entities.Entity
+	Node        *ts.Node // type parameter list
 }
 
 // ModuleInfo represents Go module information
@@ -89,6 +91,7 @@ type ChannelOperation struct {
 	Operation string // "send", "receive", "close", "select"
 	Location  *entities.Entity
 	Value     string
+	Node      *ts.Node // send or receive expression
 }
 
 // NewAdvancedGoAnalyzer creates a new advanced Go analyzer
@@ -124,6 +127,11 @@ func (aga *AdvancedGoAnalyzer) AnalyzeFile(filePath string, content []byte) (*en
 	aga.currentFile = file
 	aga.relationships = make([]*entities.Relationship, 0)
 
+	// Operations hold nodes of this file's tree, so they do not carry over
+	aga.channelOperations = make([]*ChannelOperation, 0)
+	aga.goroutines = make(map[string]*GoroutineInfo)
+	aga.generics = make(map[string]*GenericInfo)
+
 	rootNode := tree.RootNode()
 
 	// Phase 1: Basic entity extraction
@@ -295,6 +303,7 @@ func (aga *AdvancedGoAnalyzer) extractChannelSend(node *ts.Node) {
 		Channel:   channelName,
 		Operation: "send",
 		Value:     value,
+		Node:      node,
 	}
 
 	aga.channelOperations = append(aga.channelOperations, operation)
@@ -312,6 +321,7 @@ func (aga *AdvancedGoAnalyzer) extractChannelReceive(node *ts.Node) {
 	operation := &ChannelOperation{
 		Channel:   channelName,
 		Operation: "receive",
+		Node:      node,
 	}
 
 	aga.channelOperations = append(aga.channelOperations, operation)
@@ -364,6 +374,7 @@ func (aga *AdvancedGoAnalyzer) extractGoroutine(node *ts.Node) {
 	goroutineInfo := &GoroutineInfo{
 		Function: functionName,
 		Context:  "async",
+		Node:     node,
 	}
 
 	key := fmt.Sprintf("goroutine_%d_%d", node.StartPosition().Row, node.StartPosition().Column)
@@ -379,6 +390,7 @@ func (aga *AdvancedGoAnalyzer) extractGoroutine(node *ts.Node) {
 		entities.EntityTypeFunction, // Source is a function that launches the goroutine
 		entities.EntityTypeFunction, // Target is the function being launched as a goroutine
 	)
+	relationship.SetProvenance(aga.currentFile.Path, node, aga.currentFile.Content)
 	relationship.SetProperty("concurrency", "goroutine")
 	relationship.SetProperty("async", true)
 
@@ -426,6 +438,7 @@ func (aga *AdvancedGoAnalyzer) extractTypeParameters(node *ts.Node) {
 		genericInfo := &GenericInfo{
 			TypeParams:  params,
 			Constraints: constraints,
+			Node:        node,
 		}
 
 		key := fmt.Sprintf("generic_%d_%d", node.StartPosition().Row, node.StartPosition().Column)
@@ -459,6 +472,7 @@ func (aga *AdvancedGoAnalyzer) extractGenericInstantiation(node *ts.Node) {
 		entities.EntityTypeFunction, // Source is a function using the generic type
 		entities.EntityTypeInterface, // Target is likely a generic type or interface
 	)
+	relationship.SetProvenance(aga.currentFile.Path, node, aga.currentFile.Content)
 	relationship.SetProperty("generic_instantiation", true)
 
 	aga.relationships = append(aga.relationships, relationship)
@@ -621,6 +635,7 @@ func (aga *AdvancedGoAnalyzer) buildChannelRelationships() {
 		if op.Value != "" {
 			relationship.SetProperty("value", op.Value)
 		}
+		relationship.SetProvenance(aga.currentFile.Path, op.Node, aga.currentFile.Content)
 
 		aga.relationships = append(aga.relationships, relationship)
 	}
@@ -640,6 +655,7 @@ func (aga *AdvancedGoAnalyzer) buildGoroutineRelationships() {
 		)
 		relationship.SetProperty("concurrency", "goroutine")
 		relationship.SetProperty("async_execution", true)
+		relationship.SetProvenance(aga.currentFile.Path, goroutine.Node, aga.currentFile.Content)
 
 		aga.relationships = append(aga.relationships, relationship)
 	}
@@ -660,6 +676,7 @@ func (aga *AdvancedGoAnalyzer) buildGenericRelationships() {
 			)
 			relationship.SetProperty("generic_parameter", true)
 			relationship.SetProperty("constraints", strings.Join(generic.Constraints, ", "))
+			relationship.SetProvenance(aga.currentFile.Path, generic.Node, aga.currentFile.Content)
 
 			aga.relationships = append(aga.relationships, relationship)
 		}
@@ -0,0 +1,229 @@
+package analyzer
+
+import (
+	"strings"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// maxURLConstantDepth bounds how many constants referring to each other are
+// followed when resolving an API URL
+const maxURLConstantDepth = 8
+
+// httpMethods are the HTTP methods an API call can be named after, as in
+// axios.post or api.delete
+var httpMethods = map[string]bool{
+	"get": true, "post": true, "put": true, "patch": true,
+	"delete": true, "head": true, "options": true,
+}
+
+// apiURLScope holds what a file declares at the top level that API URLs are
+// built from
+type apiURLScope struct {
+	constants map[string]*ts.Node // constant name -> value
+	clients   map[string]*ts.Node // HTTP client from axios.create -> its baseURL
+}
+
+// apiScope returns the constants and HTTP clients of the current file,
+// collecting them on first use
+func (ta *TypeScriptAnalyzer) apiScope() *apiURLScope {
+	if ta.apiURLs != nil {
+		return ta.apiURLs
+	}
+	scope := &apiURLScope{
+		constants: make(map[string]*ts.Node),
+		clients:   make(map[string]*ts.Node),
+	}
+	root := ta.currentFile.Tree.RootNode()
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		declaration := root.NamedChild(i)
+		if declaration.Kind() == "export_statement" {
+			declaration = declaration.ChildByFieldName("declaration")
+		}
+		if declaration == nil || declaration.Kind() != "lexical_declaration" {
+			continue
+		}
+		for j := uint(0); j < declaration.NamedChildCount(); j++ {
+			declarator := declaration.NamedChild(j)
+			name := declarator.ChildByFieldName("name")
+			value := declarator.ChildByFieldName("value")
+			if declarator.Kind() != "variable_declarator" || name == nil || value == nil || name.Kind() != "identifier" {
+				continue
+			}
+			if ta.getNodeText(value.ChildByFieldName("function")) == "axios.create" {
+				if config := nthArgument(value, 0); config != nil && config.Kind() == "object" {
+					if base := objectProperty(config, "baseURL", ta.currentFile.Content); base != nil {
+						scope.clients[ta.getNodeText(name)] = base
+					}
+				}
+				continue
+			}
+			scope.constants[ta.getNodeText(name)] = value
+		}
+	}
+	ta.apiURLs = scope
+	return scope
+}
+
+// extractAPICallArguments extracts the URL, URL pattern, base URL and HTTP
+// method of an API call from its arguments. The URL is resolved through string constants, template
+// literals and concatenation, with what cannot be resolved, such as
+// `${id}`, becoming a path parameter (:id). The method comes from the called
+// function (axios.post), then from the options (method: 'POST'), and is GET
+// otherwise, the default of fetch and axios.
+func (ta *TypeScriptAnalyzer) extractAPICallArguments(call *ts.Node, apiCallInfo *TypeScriptAPICallInfo) {
+	scope := ta.apiScope()
+	urlNode := nthArgument(call, 0)
+	if urlNode == nil {
+		return
+	}
+
+	var options []*ts.Node
+	if urlNode.Kind() == "object" {
+		// axios({ url, method }) takes a single config object
+		options = append(options, urlNode)
+		urlNode = objectProperty(urlNode, "url", ta.currentFile.Content)
+	}
+	if arguments := call.ChildByFieldName("arguments"); arguments != nil {
+		for i := uint(1); i < arguments.NamedChildCount(); i++ {
+			if argument := arguments.NamedChild(i); argument.Kind() == "object" {
+				options = append(options, argument)
+			}
+		}
+	}
+
+	if urlNode != nil {
+		if url, literal := ta.resolveURL(urlNode, scope, 0); literal {
+			apiCallInfo.URL = url
+		}
+	}
+	function := call.ChildByFieldName("function")
+	if function != nil && function.Kind() == "member_expression" {
+		if object := function.ChildByFieldName("object"); object != nil {
+			if base := scope.clients[ta.getNodeText(object)]; base != nil {
+				apiCallInfo.BaseURL, _ = ta.resolveURL(base, scope, 0)
+				if apiCallInfo.URL != "" && !strings.Contains(apiCallInfo.URL, "://") {
+					apiCallInfo.URL = strings.TrimSuffix(apiCallInfo.BaseURL, "/") + "/" + strings.TrimPrefix(apiCallInfo.URL, "/")
+				}
+			}
+		}
+		if property := function.ChildByFieldName("property"); property != nil && httpMethods[ta.getNodeText(property)] {
+			apiCallInfo.Method = strings.ToUpper(ta.getNodeText(property))
+		}
+	}
+	if apiCallInfo.URL == "" {
+		return
+	}
+	apiCallInfo.Pattern = normalizeURLPattern(apiCallInfo.URL)
+
+	for _, object := range options {
+		if apiCallInfo.Method != "" {
+			break
+		}
+		if method := objectProperty(object, "method", ta.currentFile.Content); method != nil {
+			if value, literal := ta.resolveURL(method, scope, 0); literal && !strings.HasPrefix(value, ":") {
+				apiCallInfo.Method = strings.ToUpper(value)
+			}
+		}
+	}
+	if apiCallInfo.Method == "" {
+		apiCallInfo.Method = "GET"
+	}
+}
+
+// resolveURL returns the string an expression builds, with the parts that
+// cannot be resolved written as path parameters, and whether any part of it
+// is literal text
+func (ta *TypeScriptAnalyzer) resolveURL(node *ts.Node, scope *apiURLScope, depth int) (string, bool) {
+	switch node.Kind() {
+	case "string":
+		return strings.Trim(ta.getNodeText(node), "\"'"), true
+	case "template_string":
+		var b strings.Builder
+		literal := false
+		for i := uint(0); i < node.NamedChildCount(); i++ {
+			part := node.NamedChild(i)
+			if part.Kind() == "template_substitution" && part.NamedChildCount() > 0 {
+				text, ok := ta.resolveURL(part.NamedChild(0), scope, depth)
+				b.WriteString(text)
+				literal = literal || ok
+			} else {
+				b.WriteString(ta.getNodeText(part))
+				literal = true
+			}
+		}
+		return b.String(), literal
+	case "binary_expression":
+		operator := node.ChildByFieldName("operator")
+		left, right := node.ChildByFieldName("left"), node.ChildByFieldName("right")
+		if operator != nil && ta.getNodeText(operator) == "+" && left != nil && right != nil {
+			leftText, leftLiteral := ta.resolveURL(left, scope, depth)
+			rightText, rightLiteral := ta.resolveURL(right, scope, depth)
+			return leftText + rightText, leftLiteral || rightLiteral
+		}
+	case "parenthesized_expression":
+		if node.NamedChildCount() > 0 {
+			return ta.resolveURL(node.NamedChild(0), scope, depth)
+		}
+	case "identifier", "shorthand_property_identifier":
+		if value := scope.constants[ta.getNodeText(node)]; value != nil && depth < maxURLConstantDepth {
+			return ta.resolveURL(value, scope, depth+1)
+		}
+		return ":" + ta.getNodeText(node), false
+	case "member_expression":
+		if property := node.ChildByFieldName("property"); property != nil {
+			return ":" + ta.getNodeText(property), false
+		}
+	}
+	return ":param", false
+}
+
+// normalizeURLPattern reduces a resolved URL to the path endpoints are
+// declared with: without scheme, host, query string or fragment, with single
+// slashes and without a trailing slash, so fetch(`${HOST}/api/users/${id}/`)
+// becomes /api/users/:id
+func normalizeURLPattern(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		if slash := strings.Index(url, "/"); slash >= 0 {
+			url = url[slash:]
+		} else {
+			url = "/"
+		}
+	}
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+
+	segments := strings.FieldsFunc(url, func(r rune) bool { return r == '/' })
+	return "/" + strings.Join(segments, "/")
+}
+
+// nthArgument returns the nth argument of a call, or nil
+func nthArgument(call *ts.Node, n uint) *ts.Node {
+	arguments := call.ChildByFieldName("arguments")
+	if arguments == nil || arguments.NamedChildCount() <= n {
+		return nil
+	}
+	return arguments.NamedChild(n)
+}
+
+// objectProperty returns the value of a property of an object literal, or
+// nil. A shorthand property ({ url }) is returned as its identifier.
+func objectProperty(object *ts.Node, name string, content []byte) *ts.Node {
+	for i := uint(0); i < object.NamedChildCount(); i++ {
+		member := object.NamedChild(i)
+		switch member.Kind() {
+		case "pair":
+			key := member.ChildByFieldName("key")
+			if key != nil && strings.Trim(key.Utf8Text(content), "\"'") == name {
+				return member.ChildByFieldName("value")
+			}
+		case "shorthand_property_identifier":
+			if member.Utf8Text(content) == name {
+				return member
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Operations recorded in the mutation log
+const (
+	MutationBuild      = "build"       // a build stored the graph of a repository
+	MutationClearFile  = "clear_file"  // a resumed build removed a file's partially stored nodes or relationships
+	MutationAddFile    = "add_file"    // a file was analyzed and stored for the first time
+	MutationUpdateFile = "update_file" // a changed file was re-analyzed and stored again
+	MutationRemoveFile = "remove_file" // a deleted or renamed file was dropped from the graph
+)
+
+// What triggered a mutation
+const (
+	TriggerBuild  = "build"  // BuildGraph
+	TriggerResume = "resume" // BuildGraph resuming an interrupted build
+	TriggerScan   = "scan"   // LiveAnalyzer's initial scan
+	TriggerWatch  = "watch"  // a file system event seen by LiveAnalyzer
+	TriggerManual = "manual" // LiveAnalyzer.UpdateFile
+)
+
+// Mutation is one entry of the mutation log
+type Mutation struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Trigger   string    `json:"trigger"`
+
+	// FilePath is the file the mutation applies to; empty for builds
+	FilePath string `json:"file_path,omitempty"`
+
+	EntitiesAdded        int `json:"entities_added"`
+	EntitiesRemoved      int `json:"entities_removed"`
+	RelationshipsAdded   int `json:"relationships_added,omitempty"`
+	RelationshipsRemoved int `json:"relationships_removed,omitempty"`
+
+	// Added and Removed list the entities that appeared in or disappeared from
+	// a file as "Type Name", for file-level mutations
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+
+	// Errors counts the entities and relationships that failed to store
+	Errors int `json:"errors,omitempty"`
+}
+
+// MutationLog appends mutations to a JSONL file. Entries are never rewritten,
+// so the file is a history of every change made to the graph through the
+// builder or live analyzer that own the log. A nil *MutationLog records
+// nothing, which lets callers log unconditionally.
+type MutationLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewMutationLog returns a log appending to path. The file is created on the
+// first recorded mutation.
+func NewMutationLog(path string) *MutationLog {
+	return &MutationLog{path: path}
+}
+
+// Path returns the file the log appends to
+func (l *MutationLog) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Record appends a mutation, stamping it with the current time if unset
+func (l *MutationLog) Record(m Mutation) error {
+	if l == nil {
+		return nil
+	}
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+	line, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode mutation: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open mutation log: %w", err)
+	}
+	if err := trimPartialLine(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to repair mutation log: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write mutation log: %w", err)
+	}
+	return f.Close()
+}
+
+// trimPartialLine truncates a log after its last complete line. A crash while
+// writing leaves a line without its newline, which the next entry would
+// otherwise be appended to.
+func trimPartialLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	end := info.Size()
+	buf := make([]byte, 4096)
+	for offset := end; offset > 0; {
+		n := int64(len(buf))
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+		if _, err := f.ReadAt(buf[:n], offset); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			if complete := offset + int64(i) + 1; complete < end {
+				return f.Truncate(complete)
+			}
+			return nil
+		}
+	}
+	return f.Truncate(0)
+}
+
+// ReadMutationLog reads every mutation recorded in a log file, oldest first.
+// A missing file has no mutations. A truncated last line, left by a crash
+// while writing, is skipped.
+func ReadMutationLog(path string) ([]Mutation, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Mutation{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mutation log: %w", err)
+	}
+	defer f.Close()
+
+	mutations := make([]Mutation, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var pending error
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if pending != nil {
+			return nil, pending
+		}
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var m Mutation
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			pending = fmt.Errorf("failed to parse mutation log %s line %d: %w", path, lineNo, err)
+			continue
+		}
+		mutations = append(mutations, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mutation log: %w", err)
+	}
+	return mutations, nil
+}
+
+// diffEntities lists the entities that appear in after but not in before and
+// those that disappeared, as "Type Name". Entities are compared by type and
+// name rather than ID, since IDs change whenever a declaration moves.
+func diffEntities(before, after map[string]*entities.Entity) (added, removed []string) {
+	counts := make(map[string]int)
+	for _, entity := range after {
+		counts[string(entity.Type)+" "+entity.Name]++
+	}
+	for _, entity := range before {
+		counts[string(entity.Type)+" "+entity.Name]--
+	}
+	for key, count := range counts {
+		for ; count > 0; count-- {
+			added = append(added, key)
+		}
+		for ; count < 0; count++ {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,165 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/db"
+)
+
+func TestReadMutationLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mutations.jsonl")
+
+	if mutations, err := ReadMutationLog(path); err != nil || len(mutations) != 0 {
+		t.Fatalf("ReadMutationLog of a missing file = %v, %v; want no mutations", mutations, err)
+	}
+
+	var disabled *MutationLog
+	if err := disabled.Record(Mutation{Operation: MutationBuild}); err != nil {
+		t.Errorf("Record on a nil log = %v", err)
+	}
+
+	log := NewMutationLog(path)
+	stamped := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	recorded := []Mutation{
+		{Time: stamped, Operation: MutationBuild, Trigger: TriggerBuild, EntitiesAdded: 3, Errors: 1},
+		{Operation: MutationUpdateFile, Trigger: TriggerWatch, FilePath: "a.go", Added: []string{"Function b"}, Removed: []string{"Function a"}},
+	}
+	for _, m := range recorded {
+		if err := log.Record(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A crash while writing leaves a truncated last line
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"operation":"remove_fi`)
+	f.Close()
+
+	mutations, err := ReadMutationLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mutations) != len(recorded) {
+		t.Fatalf("ReadMutationLog = %d mutations, want %d", len(mutations), len(recorded))
+	}
+	if !mutations[0].Time.Equal(stamped) {
+		t.Errorf("mutation 0 time = %v, want %v", mutations[0].Time, stamped)
+	}
+	if mutations[1].Time.IsZero() {
+		t.Error("mutation 1 was not stamped with the time it was recorded")
+	}
+	for i := range mutations {
+		mutations[i].Time, recorded[i].Time = time.Time{}, time.Time{}
+	}
+	if !reflect.DeepEqual(mutations, recorded) {
+		t.Errorf("ReadMutationLog =\n%+v\nwant\n%+v", mutations, recorded)
+	}
+
+	// The next entry replaces the truncated line rather than being appended
+	// to it
+	if err := log.Record(Mutation{Operation: MutationRemoveFile}); err != nil {
+		t.Fatal(err)
+	}
+	mutations, err = ReadMutationLog(path)
+	if err != nil || len(mutations) != 3 || mutations[2].Operation != MutationRemoveFile {
+		t.Fatalf("ReadMutationLog after recovering = %+v, %v; want the remove_file entry last", mutations, err)
+	}
+
+	// A damaged line anywhere else is an error
+	f, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+	if err := log.Record(Mutation{Operation: MutationRemoveFile}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadMutationLog(path); err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("ReadMutationLog with a damaged line = %v, want an error for line 4", err)
+	}
+}
+
+func TestLiveAnalyzerMutationLog(t *testing.T) {
+	repo := t.TempDir()
+	path := filepath.Join(repo, "a.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc a() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	kdb, err := db.NewKuzuDatabase(filepath.Join(t.TempDir(), "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kdb.Close()
+	if err := kdb.CreateSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	options := DefaultWatchOptions()
+	options.DebounceInterval = time.Hour // only the manual updates below change the graph
+	options.MutationLogPath = filepath.Join(t.TempDir(), "mutations.jsonl")
+	la, err := NewLiveAnalyzer(kdb, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer la.StopWatching()
+
+	if err := la.StartWatching(repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("package main\n\nfunc b() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := la.UpdateFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := la.UpdateFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	mutations, err := ReadMutationLog(options.MutationLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		operation, trigger string
+	}{
+		{MutationAddFile, TriggerScan},
+		{MutationUpdateFile, TriggerManual},
+		{MutationRemoveFile, TriggerManual},
+	}
+	if len(mutations) != len(want) {
+		t.Fatalf("recorded %d mutations, want %d: %+v", len(mutations), len(want), mutations)
+	}
+	for i, m := range mutations {
+		if m.Operation != want[i].operation || m.Trigger != want[i].trigger || m.FilePath != path {
+			t.Errorf("mutation %d = %s %s %s, want %s %s %s", i, m.Operation, m.Trigger, m.FilePath,
+				want[i].operation, want[i].trigger, path)
+		}
+	}
+
+	update := mutations[1]
+	if !slices.Contains(update.Added, "Function b") || slices.Contains(update.Added, "Function a") {
+		t.Errorf("update added %v, want Function b only among the functions", update.Added)
+	}
+	if !slices.Contains(update.Removed, "Function a") || slices.Contains(update.Removed, "Function b") {
+		t.Errorf("update removed %v, want Function a only among the functions", update.Removed)
+	}
+	remove := mutations[2]
+	if !slices.Contains(remove.Removed, "Function b") || remove.EntitiesRemoved != len(remove.Removed) {
+		t.Errorf("remove = %d entities removed, %v; want Function b among them", remove.EntitiesRemoved, remove.Removed)
+	}
+}
@@ -0,0 +1,529 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Kinds of blocking call found in an async function
+const (
+	BlockingSleep    = "sleep"    // time.sleep
+	BlockingFile     = "file"     // synchronous file system access: fs.readFileSync
+	BlockingProcess  = "process"  // waiting for a child process: subprocess.run, execSync
+	BlockingNetwork  = "network"  // synchronous HTTP client: requests.get
+	BlockingDatabase = "database" // synchronous database driver: psycopg2, better-sqlite3
+	BlockingCPU      = "cpu"      // CPU-bound synchronous crypto or compression: crypto.pbkdf2Sync
+)
+
+// blockingCall is a synchronous call that stalls the event loop, with the
+// kind of work it blocks on and what to call instead
+type blockingCall struct {
+	kind       string
+	suggestion string
+}
+
+// pythonBlockingCalls are the blocking Python calls by qualified name, after
+// import aliases are resolved. The database calls open connections whose
+// later use blocks too.
+var pythonBlockingCalls = map[string]blockingCall{
+	"time.sleep":              {BlockingSleep, "await asyncio.sleep()"},
+	"requests.get":            {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"requests.post":           {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"requests.put":            {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"requests.patch":          {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"requests.delete":         {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"requests.head":           {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"requests.options":        {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"requests.request":        {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"urllib.request.urlopen":  {BlockingNetwork, "an async client such as httpx.AsyncClient or aiohttp"},
+	"subprocess.run":          {BlockingProcess, "await asyncio.create_subprocess_exec()"},
+	"subprocess.call":         {BlockingProcess, "await asyncio.create_subprocess_exec()"},
+	"subprocess.check_call":   {BlockingProcess, "await asyncio.create_subprocess_exec()"},
+	"subprocess.check_output": {BlockingProcess, "await asyncio.create_subprocess_exec()"},
+	"os.system":               {BlockingProcess, "await asyncio.create_subprocess_shell()"},
+	"psycopg2.connect":        {BlockingDatabase, "an async driver such as asyncpg"},
+	"psycopg.connect":         {BlockingDatabase, "await psycopg.AsyncConnection.connect()"},
+	"sqlite3.connect":         {BlockingDatabase, "aiosqlite"},
+	"pymysql.connect":         {BlockingDatabase, "aiomysql"},
+	"MySQLdb.connect":         {BlockingDatabase, "aiomysql"},
+	"mysql.connector.connect": {BlockingDatabase, "aiomysql"},
+	"pymongo.MongoClient":     {BlockingDatabase, "motor"},
+	"redis.Redis":             {BlockingDatabase, "redis.asyncio"},
+	"redis.StrictRedis":       {BlockingDatabase, "redis.asyncio"},
+	"redis.from_url":          {BlockingDatabase, "redis.asyncio"},
+}
+
+// nodeSyncModules are the Node.js modules whose functions ending in Sync
+// block, with the kind of work they do
+var nodeSyncModules = map[string]string{
+	"fs":            BlockingFile,
+	"child_process": BlockingProcess,
+	"crypto":        BlockingCPU,
+	"zlib":          BlockingCPU,
+}
+
+// tsSyncDatabaseModules are the TypeScript and JavaScript database drivers
+// whose queries run synchronously
+var tsSyncDatabaseModules = map[string]bool{
+	"better-sqlite3": true,
+}
+
+// blockingSite is a blocking call made directly in an async function
+type blockingSite struct {
+	call          *ts.Node
+	asyncFunction *ts.Node
+	callee        string // As written
+	qualified     string // With import aliases resolved
+	blocking      blockingCall
+}
+
+// detectBlockingInAsync adds a BlockingInAsync entity for every synchronous
+// call that blocks the event loop made in a Python async def or a TypeScript
+// or JavaScript async function: time.sleep, requests, subprocess and
+// synchronous database drivers in Python, and the *Sync functions of fs,
+// child_process, crypto and zlib and better-sqlite3 in Node.js. Calls on a
+// connection, cursor or statement obtained from a synchronous driver count
+// as database calls.
+//
+// Only calls made by the async function itself are checked: a function or
+// lambda nested in it may run elsewhere, such as in a thread, and functions
+// it calls are not followed. Names are resolved through the file's imports,
+// so from time import sleep and import { readFileSync } from "node:fs" are
+// recognized. Each warning is linked to its enclosing function by a
+// HAS_ISSUE relationship. Test files and functions marked with an
+// onyx:ignore comment are skipped.
+func detectBlockingInAsync(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil || entities.IsTestFilePath(file.Path) {
+		return nil
+	}
+
+	var sites []blockingSite
+	switch file.Language {
+	case "python":
+		sites = pythonBlockingSites(file)
+	case "typescript", "javascript":
+		sites = tsBlockingSites(file)
+	default:
+		return nil
+	}
+
+	functions := append(append([]*entities.Entity{}, file.Functions...), file.Methods...)
+	relationships := make([]*entities.Relationship, 0)
+	for _, site := range sites {
+		enclosing := innermostEntity(functions, site.asyncFunction)
+		if enclosing != nil && enclosing.IsIgnored() {
+			continue
+		}
+		entity := newBlockingInAsync(file, site, enclosing)
+		file.AddEntity(entity)
+		if enclosing == nil {
+			continue
+		}
+		rel := entities.NewRelationship(entity.ID+":has_issue", entities.RelationshipTypeHasIssue, enclosing, entity)
+		rel.SetProperty("issue", site.blocking.kind)
+		rel.SetProvenance(file.Path, site.call, file.Content)
+		relationships = append(relationships, rel)
+	}
+	return relationships
+}
+
+// walkAsyncCalls calls visit for every call made directly in an async
+// function, with the function. Calls in nested functions and lambdas belong
+// to them; calls in classes and at the top level are in no async function.
+func walkAsyncCalls(node, asyncFunction *ts.Node, callKinds map[string]bool, visit func(call, asyncFunction *ts.Node)) {
+	if nestedScopes[node.Kind()] {
+		asyncFunction = nil
+		if isAsyncFunction(node) {
+			asyncFunction = node
+		}
+	}
+	if asyncFunction != nil && callKinds[node.Kind()] {
+		visit(node, asyncFunction)
+	}
+	for i := uint(0); i < node.ChildCount(); i++ {
+		walkAsyncCalls(node.Child(i), asyncFunction, callKinds, visit)
+	}
+}
+
+// isAsyncFunction reports whether a function node is declared async
+func isAsyncFunction(node *ts.Node) bool {
+	for i := uint(0); i < node.ChildCount(); i++ {
+		if node.Child(i).Kind() == "async" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveCallee replaces the first name of a dotted callee by what the
+// file's imports bind it to
+func resolveCallee(callee string, imports map[string]string) string {
+	first, rest, dotted := strings.Cut(callee, ".")
+	bound, ok := imports[first]
+	if !ok {
+		return callee
+	}
+	if !dotted {
+		return bound
+	}
+	return bound + "." + rest
+}
+
+// rootedAt reports whether an expression such as conn.cursor().execute
+// starts with one of the names
+func rootedAt(expression string, names map[string]bool) bool {
+	for name := range names {
+		if expression == name || strings.HasPrefix(expression, name+".") || strings.HasPrefix(expression, name+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// chainedReceiver reports whether a call is the receiver of a method call,
+// as db.prepare(sql) is in db.prepare(sql).get(), which is reported instead
+func chainedReceiver(call *ts.Node) bool {
+	parent := call.Parent()
+	if parent == nil || (parent.Kind() != "member_expression" && parent.Kind() != "attribute") {
+		return false
+	}
+	object := parent.ChildByFieldName("object")
+	grandparent := parent.Parent()
+	return object != nil && object.StartByte() == call.StartByte() && object.EndByte() == call.EndByte() &&
+		grandparent != nil && (grandparent.Kind() == "call_expression" || grandparent.Kind() == "call")
+}
+
+// pythonBlockingSites finds the blocking calls of a Python file's async
+// functions
+func pythonBlockingSites(file *entities.File) []blockingSite {
+	root := file.Tree.RootNode()
+	imports := pythonImportBindings(root, file.Content)
+
+	// Connections from synchronous drivers, and what is obtained from them,
+	// such as cursors, by assignment anywhere in the file
+	handles := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		walkTree(root, func(node *ts.Node) {
+			if node.Kind() != "assignment" {
+				return
+			}
+			left, right := node.ChildByFieldName("left"), node.ChildByFieldName("right")
+			if left == nil || right == nil || left.Kind() == "pattern_list" || left.Kind() == "tuple_pattern" {
+				return
+			}
+			name := compactText(left, file.Content)
+			if handles[name] {
+				return
+			}
+			value := compactText(right, file.Content)
+			if right.Kind() == "call" {
+				if function := right.ChildByFieldName("function"); function != nil {
+					qualified := resolveCallee(compactText(function, file.Content), imports)
+					if blocking, ok := pythonBlockingCalls[qualified]; ok && blocking.kind == BlockingDatabase {
+						handles[name], changed = true, true
+						return
+					}
+				}
+			}
+			if (right.Kind() == "call" || right.Kind() == "attribute") && rootedAt(value, handles) {
+				handles[name], changed = true, true
+			}
+		})
+	}
+
+	sites := make([]blockingSite, 0)
+	walkAsyncCalls(root, nil, map[string]bool{"call": true}, func(call, asyncFunction *ts.Node) {
+		function := call.ChildByFieldName("function")
+		if function == nil {
+			return
+		}
+		callee := compactText(function, file.Content)
+		qualified := resolveCallee(callee, imports)
+		blocking, ok := pythonBlockingCalls[qualified]
+		if !ok && function.Kind() == "attribute" && rootedAt(callee, handles) && !chainedReceiver(call) {
+			blocking, ok = blockingCall{BlockingDatabase, "an async driver such as asyncpg, aiosqlite, aiomysql or motor"}, true
+		}
+		if ok {
+			sites = append(sites, blockingSite{call, asyncFunction, callee, qualified, blocking})
+		}
+	})
+	return sites
+}
+
+// pythonImportBindings maps the names a Python file's imports bind to what
+// they stand for: import os.path binds os to os, import numpy as np binds np
+// to numpy and from time import sleep binds sleep to time.sleep
+func pythonImportBindings(root *ts.Node, content []byte) map[string]string {
+	bindings := make(map[string]string)
+	walkTree(root, func(node *ts.Node) {
+		module := ""
+		switch node.Kind() {
+		case "import_statement":
+		case "import_from_statement":
+			name := node.ChildByFieldName("module_name")
+			if name == nil || name.Kind() != "dotted_name" {
+				return
+			}
+			module = name.Utf8Text(content) + "."
+		default:
+			return
+		}
+		for i := uint(0); i < node.ChildCount(); i++ {
+			if node.FieldNameForChild(uint32(i)) != "name" {
+				continue
+			}
+			child := node.Child(i)
+			switch child.Kind() {
+			case "dotted_name":
+				name := child.Utf8Text(content)
+				if module == "" {
+					first, _, _ := strings.Cut(name, ".")
+					bindings[first] = first
+				} else {
+					bindings[name] = module + name
+				}
+			case "aliased_import":
+				name, alias := child.ChildByFieldName("name"), child.ChildByFieldName("alias")
+				if name != nil && alias != nil {
+					bindings[alias.Utf8Text(content)] = module + name.Utf8Text(content)
+				}
+			}
+		}
+	})
+	return bindings
+}
+
+// tsBlockingSites finds the blocking calls of a TypeScript or JavaScript
+// file's async functions
+func tsBlockingSites(file *entities.File) []blockingSite {
+	root := file.Tree.RootNode()
+	imports := tsImportBindings(root, file.Content)
+
+	isDatabase := func(qualified string) bool {
+		module, _, _ := strings.Cut(qualified, ".")
+		return tsSyncDatabaseModules[module]
+	}
+
+	// Databases opened with a synchronous driver, and statements prepared
+	// from them
+	handles := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		walkTree(root, func(node *ts.Node) {
+			var left, right *ts.Node
+			switch node.Kind() {
+			case "variable_declarator":
+				left, right = node.ChildByFieldName("name"), node.ChildByFieldName("value")
+			case "assignment_expression":
+				left, right = node.ChildByFieldName("left"), node.ChildByFieldName("right")
+			}
+			if left == nil || right == nil || (left.Kind() != "identifier" && left.Kind() != "member_expression") {
+				return
+			}
+			name := compactText(left, file.Content)
+			if handles[name] {
+				return
+			}
+			if right.Kind() == "await_expression" && right.NamedChildCount() > 0 {
+				right = right.NamedChild(0)
+			}
+			opened := false
+			switch right.Kind() {
+			case "new_expression":
+				if constructor := right.ChildByFieldName("constructor"); constructor != nil {
+					opened = isDatabase(resolveCallee(compactText(constructor, file.Content), imports))
+				}
+			case "call_expression":
+				if function := right.ChildByFieldName("function"); function != nil {
+					callee := compactText(function, file.Content)
+					opened = isDatabase(resolveCallee(callee, imports)) ||
+						(function.Kind() == "member_expression" && rootedAt(callee, handles))
+				}
+			}
+			if opened {
+				handles[name], changed = true, true
+			}
+		})
+	}
+
+	sites := make([]blockingSite, 0)
+	callKinds := map[string]bool{"call_expression": true, "new_expression": true}
+	walkAsyncCalls(root, nil, callKinds, func(call, asyncFunction *ts.Node) {
+		function := call.ChildByFieldName("function")
+		if call.Kind() == "new_expression" {
+			function = call.ChildByFieldName("constructor")
+		}
+		if function == nil {
+			return
+		}
+		callee := compactText(function, file.Content)
+		qualified := resolveCallee(callee, imports)
+		blocking, ok := tsBlockingCall(qualified)
+		if !ok && call.Kind() == "call_expression" && function.Kind() == "member_expression" &&
+			rootedAt(callee, handles) && !chainedReceiver(call) {
+			blocking, ok = blockingCall{BlockingDatabase, "an async driver, or a worker thread for the queries"}, true
+		}
+		if !ok && call.Kind() == "new_expression" && isDatabase(qualified) {
+			blocking, ok = blockingCall{BlockingDatabase, "an async driver, or a worker thread for the queries"}, true
+		}
+		if ok {
+			sites = append(sites, blockingSite{call, asyncFunction, callee, qualified, blocking})
+		}
+	})
+	return sites
+}
+
+// tsBlockingCall classifies a qualified Node.js call such as fs.readFileSync
+func tsBlockingCall(qualified string) (blockingCall, bool) {
+	module, name, ok := strings.Cut(qualified, ".")
+	kind, known := nodeSyncModules[module]
+	if !ok || !known || strings.Contains(name, ".") || !strings.HasSuffix(name, "Sync") {
+		return blockingCall{}, false
+	}
+	async := strings.TrimSuffix(name, "Sync")
+	switch {
+	case qualified == "fs.existsSync":
+		return blockingCall{kind, "await fs.promises.access()"}, true
+	case module == "fs":
+		return blockingCall{kind, fmt.Sprintf("await fs.promises.%s()", async)}, true
+	case module == "child_process":
+		return blockingCall{kind, fmt.Sprintf("util.promisify(child_process.%s)", async)}, true
+	default:
+		return blockingCall{kind, fmt.Sprintf("%s.%s with a callback, or util.promisify", module, async)}, true
+	}
+}
+
+// tsImportBindings maps the names a TypeScript or JavaScript file's imports
+// and require calls bind to what they stand for: import fs from "fs" and
+// const fs = require("node:fs") bind fs to fs, and import { readFileSync }
+// from "fs" binds readFileSync to fs.readFileSync. The node: prefix is
+// dropped from module names.
+func tsImportBindings(root *ts.Node, content []byte) map[string]string {
+	bindings := make(map[string]string)
+	module := func(source *ts.Node) (string, bool) {
+		value, ok := stringLiteralValue(source, content)
+		return strings.TrimPrefix(value, "node:"), ok && value != ""
+	}
+	walkTree(root, func(node *ts.Node) {
+		switch node.Kind() {
+		case "import_statement":
+			source, ok := module(node.ChildByFieldName("source"))
+			if !ok {
+				return
+			}
+			walkTree(node, func(n *ts.Node) {
+				switch n.Kind() {
+				case "import_clause":
+					for i := uint(0); i < n.NamedChildCount(); i++ {
+						if child := n.NamedChild(i); child.Kind() == "identifier" {
+							bindings[child.Utf8Text(content)] = source
+						}
+					}
+				case "namespace_import":
+					for i := uint(0); i < n.NamedChildCount(); i++ {
+						if child := n.NamedChild(i); child.Kind() == "identifier" {
+							bindings[child.Utf8Text(content)] = source
+						}
+					}
+				case "import_specifier":
+					name, alias := n.ChildByFieldName("name"), n.ChildByFieldName("alias")
+					if name == nil {
+						return
+					}
+					if alias == nil {
+						alias = name
+					}
+					bindings[alias.Utf8Text(content)] = source + "." + name.Utf8Text(content)
+				}
+			})
+		case "variable_declarator":
+			name, value := node.ChildByFieldName("name"), node.ChildByFieldName("value")
+			if name == nil || value == nil || value.Kind() != "call_expression" {
+				return
+			}
+			function, args := value.ChildByFieldName("function"), value.ChildByFieldName("arguments")
+			if function == nil || function.Utf8Text(content) != "require" || args == nil || args.NamedChildCount() == 0 {
+				return
+			}
+			source, ok := module(args.NamedChild(0))
+			if !ok {
+				return
+			}
+			switch name.Kind() {
+			case "identifier":
+				bindings[name.Utf8Text(content)] = source
+			case "object_pattern":
+				for i := uint(0); i < name.NamedChildCount(); i++ {
+					switch property := name.NamedChild(i); property.Kind() {
+					case "shorthand_property_identifier_pattern":
+						bindings[property.Utf8Text(content)] = source + "." + property.Utf8Text(content)
+					case "pair_pattern":
+						key, local := property.ChildByFieldName("key"), property.ChildByFieldName("value")
+						if key != nil && local != nil && local.Kind() == "identifier" {
+							bindings[local.Utf8Text(content)] = source + "." + key.Utf8Text(content)
+						}
+					}
+				}
+			}
+		}
+	})
+	return bindings
+}
+
+// compactText returns the text of a node without whitespace, so a callee
+// split over lines reads as one expression
+func compactText(node *ts.Node, content []byte) string {
+	return strings.Join(strings.Fields(node.Utf8Text(content)), "")
+}
+
+// asyncFunctionName names an async function after its declaration, or the
+// variable or property an anonymous function is assigned to
+func asyncFunctionName(function *ts.Node, content []byte) string {
+	if name := function.ChildByFieldName("name"); name != nil {
+		return name.Utf8Text(content)
+	}
+	if parent := function.Parent(); parent != nil {
+		switch parent.Kind() {
+		case "variable_declarator", "public_field_definition":
+			if name := parent.ChildByFieldName("name"); name != nil {
+				return name.Utf8Text(content)
+			}
+		case "pair":
+			if key := parent.ChildByFieldName("key"); key != nil {
+				return key.Utf8Text(content)
+			}
+		}
+	}
+	return "anonymous async function"
+}
+
+// newBlockingInAsync builds the BlockingInAsync entity for a call site
+func newBlockingInAsync(file *entities.File, site blockingSite, enclosing *entities.Entity) *entities.Entity {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("blocking_in_async:%s:%d", file.Path, site.call.StartByte())))
+	line := int(site.call.StartPosition().Row) + 1
+
+	asyncName := asyncFunctionName(site.asyncFunction, file.Content)
+
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), site.callee, entities.EntityTypeBlockingInAsync, file.Path, site.call)
+	entity.SetProperty("callee", site.callee)
+	entity.SetProperty("qualified_callee", site.qualified)
+	entity.SetProperty("kind", site.blocking.kind)
+	entity.SetProperty("suggestion", site.blocking.suggestion)
+	entity.SetProperty("message", fmt.Sprintf("%s blocks the event loop in %s; use %s", site.callee, asyncName, site.blocking.suggestion))
+	entity.SetProperty("line", line)
+	entity.SetProperty("language", file.Language)
+	entity.SetProperty("async_function", asyncName)
+	entity.SetProperty("async_function_line", int(site.asyncFunction.StartPosition().Row)+1)
+	if enclosing != nil {
+		entity.SetProperty("enclosing_function", enclosing.ID)
+		entity.SetProperty("enclosing_function_name", enclosing.GetFullName())
+	}
+	return entity
+}
@@ -0,0 +1,292 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// manifestVersion is bumped whenever the manifest layout or the way entities
+// are keyed changes, so that old checkpoints are not resumed
+const manifestVersion = 1
+
+// defaultCheckpointEvery is the number of files stored between checkpoints
+const defaultCheckpointEvery = 500
+
+// FileFingerprint identifies the version of a file that was analyzed
+type FileFingerprint struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"mod_time"` // Unix nanoseconds
+}
+
+// BuildManifest records the storage progress of a checkpointed build; the
+// analysis itself is not recorded and is redone on resume. Storage happens
+// in two passes so that relationships never reference nodes that are not
+// stored yet: first the nodes of every file, then their outgoing
+// relationships. Each pass records the files it has finished.
+type BuildManifest struct {
+	Version   int                        `json:"version"`
+	RepoPath  string                     `json:"repo_path"`
+	Files     map[string]FileFingerprint `json:"files"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+
+	// NodesStored and RelationshipsStored list the files whose nodes, and
+	// whose outgoing relationships, are fully stored
+	NodesStored         []string `json:"nodes_stored"`
+	RelationshipsStored []string `json:"relationships_stored"`
+
+	// Complete is set once both passes have finished
+	Complete bool `json:"complete"`
+}
+
+// LoadBuildManifest reads a manifest written by a checkpointed build. It
+// returns nil without an error when no manifest exists.
+func LoadBuildManifest(path string) (*BuildManifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build manifest: %w", err)
+	}
+
+	var manifest BuildManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse build manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// Save writes the manifest atomically, so a crash never leaves a truncated file
+func (m *BuildManifest) Save(path string) error {
+	m.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode build manifest: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write build manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace build manifest: %w", err)
+	}
+	return nil
+}
+
+// checkResumable reports why a manifest cannot be resumed by the current build:
+// entity IDs are derived from file paths and positions, so stored nodes are only
+// valid while the analyzed files are unchanged
+func (m *BuildManifest) checkResumable(repoPath string, files map[string]FileFingerprint) error {
+	if m.Version != manifestVersion {
+		return fmt.Errorf("manifest version %d, expected %d", m.Version, manifestVersion)
+	}
+	if m.RepoPath != repoPath {
+		return fmt.Errorf("manifest is for %s, not %s", m.RepoPath, repoPath)
+	}
+
+	changed := 0
+	for path, fp := range files {
+		if previous, ok := m.Files[path]; !ok || previous != fp {
+			changed++
+		}
+	}
+	for path := range m.Files {
+		if _, ok := files[path]; !ok {
+			changed++
+		}
+	}
+	if changed > 0 {
+		return fmt.Errorf("%d files were added, removed or modified since the checkpoint", changed)
+	}
+	return nil
+}
+
+// storeWithCheckpoints is the checkpointed variant of storeInDatabase. Files
+// are stored in batches of CheckpointEvery and the manifest is saved after each
+// batch. When resuming, finished files are skipped and the first unfinished
+// batch is cleared before it is stored again, so no node or relationship is
+// stored twice.
+func (gb *GraphBuilder) storeWithCheckpoints() error {
+	manifest := &BuildManifest{
+		Version:  manifestVersion,
+		RepoPath: gb.rootPath,
+		Files:    gb.fingerprints,
+	}
+	resuming := false
+	if gb.config.ResumeFromCheckpoint {
+		previous, err := LoadBuildManifest(gb.config.ManifestPath)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			if err := previous.checkResumable(gb.rootPath, gb.fingerprints); err != nil {
+				return fmt.Errorf("cannot resume from %s: %w", gb.config.ManifestPath, err)
+			}
+			manifest = previous
+			resuming = true
+			if gb.config.RelationshipsMigrated {
+				manifest.RelationshipsStored = nil
+				manifest.Complete = false
+			}
+		}
+	}
+	if manifest.Complete {
+		return nil
+	}
+
+	batchSize := gb.config.CheckpointEvery
+	if batchSize <= 0 {
+		batchSize = defaultCheckpointEvery
+	}
+
+	// Group nodes and relationships by the file they belong to
+	nodesByFile := make(map[string][]*entities.Entity)
+	for _, entity := range gb.allEntities {
+		nodesByFile[entity.FilePath] = append(nodesByFile[entity.FilePath], entity)
+	}
+	for path := range gb.files {
+		if _, ok := nodesByFile[path]; !ok {
+			nodesByFile[path] = nil
+		}
+	}
+	relsByFile := make(map[string][]*entities.Relationship)
+	for _, rel := range gb.resolvedRelationships {
+		path := rel.SourceID // Contains relationships start at the file path
+		if source := gb.allEntities[rel.SourceID]; source != nil {
+			path = source.FilePath
+		} else if rel.Location != nil {
+			path = rel.Location.FilePath
+		}
+		relsByFile[path] = append(relsByFile[path], rel)
+	}
+
+	// Totals for the mutation log
+	build := Mutation{Operation: MutationBuild, Trigger: TriggerBuild}
+	if resuming {
+		build.Trigger = TriggerResume
+	}
+
+	storeNodes := func(path string) error {
+		if resuming {
+			if err := gb.database.DeleteFileNodes(path); err != nil {
+				return err
+			}
+			if err := gb.mutationLog.Record(Mutation{Operation: MutationClearFile, Trigger: TriggerResume, FilePath: path}); err != nil {
+				return err
+			}
+		}
+		if file := gb.files[path]; file != nil {
+			if err := gb.database.AddFileNode(path, file.Name, file.Language); err != nil {
+				gb.stats.ErrorsEncountered++
+				build.Errors++
+			}
+		}
+		for _, entity := range nodesByFile[path] {
+			if !gb.storesEntity(entity) {
+				continue
+			}
+			if err := gb.database.StoreEntity(entity); err != nil {
+				gb.stats.ErrorsEncountered++
+				build.Errors++
+			} else {
+				build.EntitiesAdded++
+			}
+		}
+		return nil
+	}
+	storeRelationships := func(path string) error {
+		if resuming {
+			if err := gb.database.DeleteFileRelationships(path); err != nil {
+				return err
+			}
+			if err := gb.mutationLog.Record(Mutation{Operation: MutationClearFile, Trigger: TriggerResume, FilePath: path}); err != nil {
+				return err
+			}
+		}
+		for _, rel := range relsByFile[path] {
+			if !gb.storesRelationship(rel) {
+				continue
+			}
+			if err := gb.database.StoreRelationship(rel); err != nil {
+				gb.stats.ErrorsEncountered++
+				build.Errors++
+			} else {
+				build.RelationshipsAdded++
+			}
+		}
+		return nil
+	}
+
+	if err := gb.storeInBatches(manifest, sortedKeys(nodesByFile), &manifest.NodesStored, batchSize, storeNodes); err != nil {
+		return err
+	}
+	if err := gb.storeInBatches(manifest, sortedKeys(relsByFile), &manifest.RelationshipsStored, batchSize, storeRelationships); err != nil {
+		return err
+	}
+
+	manifest.Complete = true
+	if err := manifest.Save(gb.config.ManifestPath); err != nil {
+		return err
+	}
+	return gb.mutationLog.Record(build)
+}
+
+// storeInBatches stores the files not yet listed in done, batchSize at a time,
+// appending each finished batch to done and saving the manifest
+func (gb *GraphBuilder) storeInBatches(manifest *BuildManifest, paths []string, done *[]string, batchSize int, store func(path string) error) error {
+	finished := make(map[string]bool, len(*done))
+	for _, path := range *done {
+		finished[path] = true
+	}
+
+	batch := make([]string, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		*done = append(*done, batch...)
+		batch = batch[:0]
+		return manifest.Save(gb.config.ManifestPath)
+	}
+
+	for _, path := range paths {
+		if finished[path] {
+			continue
+		}
+		if err := store(path); err != nil {
+			return err
+		}
+		batch = append(batch, path)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// sortedKeys returns the keys of a map in lexical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// recordFingerprint remembers the version of a file analyzed in phase 1
+func (gb *GraphBuilder) recordFingerprint(relPath string, info os.FileInfo) {
+	gb.fingerprints[relPath] = FileFingerprint{
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+	}
+}
@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/db"
+)
+
+func TestCheckResumable(t *testing.T) {
+	files := map[string]FileFingerprint{
+		"a.go": {Size: 10, ModTime: 1},
+		"b.go": {Size: 20, ModTime: 2},
+	}
+	manifest := &BuildManifest{Version: manifestVersion, RepoPath: "/repo", Files: files}
+
+	tests := []struct {
+		name     string
+		version  int
+		repoPath string
+		files    map[string]FileFingerprint
+		wantErr  string
+	}{
+		{"unchanged", manifestVersion, "/repo", files, ""},
+		{"other version", manifestVersion + 1, "/repo", files, "manifest version"},
+		{"other repository", manifestVersion, "/other", files, "manifest is for /repo"},
+		{"modified", manifestVersion, "/repo", map[string]FileFingerprint{"a.go": {Size: 11, ModTime: 1}, "b.go": files["b.go"]}, "1 files"},
+		{"added", manifestVersion, "/repo", map[string]FileFingerprint{"a.go": files["a.go"], "b.go": files["b.go"], "c.go": {}}, "1 files"},
+		{"removed", manifestVersion, "/repo", map[string]FileFingerprint{"a.go": files["a.go"]}, "1 files"},
+	}
+	for _, tt := range tests {
+		manifest.Version = tt.version
+		err := manifest.checkResumable(tt.repoPath, tt.files)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: checkResumable = %v, want nil", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: checkResumable = %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// checkpointRepo is a repository whose files call each other, so that
+// relationships cross the batches of a checkpointed build
+var checkpointRepo = map[string]string{
+	"a.go": "package main\n\nfunc main() {\n\tb()\n}\n",
+	"b.go": "package main\n\nfunc b() {\n\tc()\n}\n",
+	"c.go": "package main\n\ntype C struct{ N int }\n\nfunc c() {\n\t_ = C{}\n}\n",
+}
+
+// storedGraph is what a build stored, and how many entities and
+// relationships it failed to store
+type storedGraph struct {
+	nodes, relationships int64
+	errors               int
+}
+
+// buildCheckpointed builds repo into the database at dbPath with the given
+// configuration
+func buildCheckpointed(t *testing.T, repo, dbPath string, config *GraphBuilderConfig) (storedGraph, error) {
+	t.Helper()
+	kdb, err := db.NewKuzuDatabase(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kdb.Close()
+	if err := kdb.CreateSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := NewGraphBuilderWithConfig(kdb, config).BuildGraph(repo)
+	if err != nil {
+		return storedGraph{}, err
+	}
+	stored := storedGraph{errors: stats.ErrorsEncountered}
+	count := func(query string) int64 {
+		rows, err := kdb.QueryRows(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rows.Rows[0][0].(int64)
+	}
+	nodeTables, relTables, err := kdb.TableNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range nodeTables {
+		stored.nodes += count("MATCH (n:" + table + ") RETURN count(n)")
+	}
+	// Node tables are named, as unlabeled nodes bind properties of the same
+	// name but different types
+	for _, table := range relTables {
+		rows, err := kdb.QueryRows("CALL show_connection('" + table + "') RETURN *")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, pair := range rows.Rows {
+			stored.relationships += count(fmt.Sprintf("MATCH (:%s)-[r:%s]->(:%s) RETURN count(r)", pair[0], table, pair[1]))
+		}
+	}
+	return stored, nil
+}
+
+func TestResumeAfterPartialStore(t *testing.T) {
+	repo := t.TempDir()
+	for path, source := range checkpointRepo {
+		if err := os.WriteFile(filepath.Join(repo, path), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	work := t.TempDir()
+
+	want, err := buildCheckpointed(t, repo, filepath.Join(work, "reference.db"), DefaultGraphBuilderConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultGraphBuilderConfig()
+	config.ManifestPath = filepath.Join(work, "manifest.json")
+	config.CheckpointEvery = 1
+	config.MutationLogPath = filepath.Join(work, "mutations.jsonl")
+	dbPath := filepath.Join(work, "graph.db")
+	if _, err := buildCheckpointed(t, repo, dbPath, config); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend the build stopped after the nodes of the first batch were
+	// recorded, with the other files stored but not recorded yet: resuming
+	// must clear them rather than store them twice
+	manifest, err := LoadBuildManifest(config.ManifestPath)
+	if err != nil || manifest == nil || !manifest.Complete {
+		t.Fatalf("manifest after the build = %+v, %v; want a complete one", manifest, err)
+	}
+	manifest.NodesStored = manifest.NodesStored[:1]
+	manifest.RelationshipsStored = nil
+	manifest.Complete = false
+	if err := manifest.Save(config.ManifestPath); err != nil {
+		t.Fatal(err)
+	}
+
+	config.ResumeFromCheckpoint = true
+	got, err := buildCheckpointed(t, repo, dbPath, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("resumed build stored %+v, want %+v", got, want)
+	}
+	if manifest, _ := LoadBuildManifest(config.ManifestPath); manifest == nil || !manifest.Complete {
+		t.Errorf("manifest after resuming = %+v, want a complete one", manifest)
+	}
+
+	// A file changed since the checkpoint makes the stored nodes unusable
+	manifest.Complete = false
+	if err := manifest.Save(config.ManifestPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "b.go"), []byte(checkpointRepo["b.go"]+"\nfunc d() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = buildCheckpointed(t, repo, dbPath, config)
+	if err == nil || !strings.Contains(err.Error(), "cannot resume") || !strings.Contains(err.Error(), "1 files were added, removed or modified") {
+		t.Errorf("resuming after a change = %v, want a cannot resume error", err)
+	}
+}
@@ -0,0 +1,877 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Libraries through which command-line flags are defined
+const (
+	CLILibraryFlag      = "flag"      // flag.Int("port", 8080, "port to listen on")
+	CLILibraryPflag     = "pflag"     // pflag.IntP("port", "p", 8080, "port to listen on")
+	CLILibraryCobra     = "cobra"     // cmd.Flags().IntVarP(&port, "port", "p", 8080, "...")
+	CLILibraryArgparse  = "argparse"  // parser.add_argument("--port", type=int, default=8080)
+	CLILibraryClick     = "click"     // @click.option("--port", default=8080)
+	CLILibraryCommander = "commander" // program.option("-p, --port <number>", "...", "8080")
+	CLILibraryYargs     = "yargs"     // yargs.option("port", { type: "number", default: 8080 })
+)
+
+// goFlagTypes are the value types of the Go flag and pflag definition
+// methods: String defines a string flag, StringVar one stored in a variable
+// and, in pflag, StringP and StringVarP add a shorthand
+var goFlagTypes = map[string]bool{
+	"Bool": true, "String": true, "Int": true, "Int8": true, "Int16": true, "Int32": true, "Int64": true,
+	"Uint": true, "Uint8": true, "Uint16": true, "Uint32": true, "Uint64": true, "Float32": true,
+	"Float64": true, "Duration": true, "Count": true, "Text": true, "Func": true, "BoolFunc": true,
+	"StringSlice": true, "StringArray": true, "IntSlice": true, "Int32Slice": true, "Int64Slice": true,
+	"UintSlice": true, "Float32Slice": true, "Float64Slice": true, "BoolSlice": true,
+	"DurationSlice": true, "StringToString": true, "StringToInt": true, "StringToInt64": true,
+	"IP": true, "IPSlice": true, "IPMask": true, "IPNet": true, "BytesHex": true, "BytesBase64": true,
+}
+
+// Import paths of the Go flag libraries
+const (
+	goPflagPath = "github.com/spf13/pflag"
+	goCobraPath = "github.com/spf13/cobra"
+)
+
+// cliFlag is the definition of a command-line flag or positional argument
+type cliFlag struct {
+	node         *ts.Node // Call or decorator defining the flag
+	definition   *ts.Node // Statement defining it, left out when looking for reads
+	name         string   // Long name without dashes, or the name of the argument
+	flag         string   // As typed on the command line, e.g. --port; empty for arguments
+	short        string   // Shorthand as typed, e.g. -p
+	valueType    string
+	defaultValue string
+	hasDefault   bool
+	help         string
+	library      string
+	command      string   // Subcommand the flag belongs to; empty for the root command
+	persistent   bool     // Inherited by the subcommands of its command
+	positional   bool     // A positional argument rather than a flag
+	variable     string   // Where the program reads the value from
+	readName     string   // Name looked for to find reads of the value
+	readScope    *ts.Node // Where reads are looked for
+	owner        *ts.Node // Node of the function the flag belongs to
+}
+
+// detectCLIFlags records the command-line flags and positional arguments a
+// file defines as CLIFlag entities, with their name, shorthand, type,
+// default and help text, and returns a DEFINES_FLAG relationship to each
+// from the function that defines it: the command function for click, the
+// function making the definition call otherwise. Supported are Go flag,
+// pflag and cobra, Python argparse and click, and commander and yargs.
+//
+// A flag whose value is never read in the file that defines it, or in its
+// function for click, has "read" set to false. Values stored in struct fields
+// count as read when the field is used anywhere in the file. Definitions
+// inside functions marked with an onyx:ignore comment are skipped.
+func detectCLIFlags(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+
+	var flags []cliFlag
+	switch file.Language {
+	case "go":
+		flags = goCLIFlags(file)
+	case "python":
+		flags = pythonCLIFlags(file)
+	case "typescript", "javascript":
+		flags = tsCLIFlags(file)
+	}
+
+	functions := append(append([]*entities.Entity{}, file.Functions...), file.Methods...)
+	relationships := make([]*entities.Relationship, 0, len(flags))
+	for _, f := range flags {
+		if f.name == "" {
+			continue
+		}
+		owner := innermostEntity(functions, f.owner)
+		if owner != nil && owner.IsIgnored() {
+			continue
+		}
+		entity := newCLIFlag(file, f, owner)
+		file.AddEntity(entity)
+		if owner == nil {
+			continue
+		}
+		hash := sha256.Sum256([]byte(fmt.Sprintf("defines_flag:%s:%s", owner.ID, entity.ID)))
+		rel := entities.NewRelationship(hex.EncodeToString(hash[:8]), entities.RelationshipTypeDefinesFlag, owner, entity)
+		rel.SetProperty("library", f.library)
+		rel.SetProvenance(file.Path, f.node, file.Content)
+		relationships = append(relationships, rel)
+	}
+	return relationships
+}
+
+// newCLIFlag creates the CLIFlag entity of a flag definition
+func newCLIFlag(file *entities.File, f cliFlag, owner *entities.Entity) *entities.Entity {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("cli_flag:%s:%d:%s", file.Path, f.node.StartByte(), f.name)))
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), f.name, entities.EntityTypeCLIFlag, file.Path, f.node)
+	entity.Signature = strings.Join(strings.Fields(f.node.Utf8Text(file.Content)), " ")
+	entity.SetProperty("flag", f.flag)
+	entity.SetProperty("short", f.short)
+	entity.SetProperty("flag_type", f.valueType)
+	if f.hasDefault {
+		entity.SetProperty("default", f.defaultValue)
+	}
+	entity.SetProperty("help", f.help)
+	entity.SetProperty("library", f.library)
+	entity.SetProperty("command", f.command)
+	entity.SetProperty("persistent", f.persistent)
+	entity.SetProperty("positional", f.positional)
+	entity.SetProperty("language", file.Language)
+	if f.variable != "" {
+		entity.SetProperty("variable", f.variable)
+	}
+	if f.readName != "" && f.readScope != nil {
+		entity.SetProperty("read", cliFlagRead(f.readScope, f.readName, f.definition, file.Content))
+	}
+	if owner != nil {
+		entity.SetProperty("enclosing_function", owner.ID)
+		entity.SetProperty("enclosing_function_name", owner.GetFullName())
+	}
+	return entity
+}
+
+// cliFlagRead reports whether name is used in scope outside the definition of
+// a flag, as an identifier, a field or property name, or a string such as
+// the one getattr(args, "port") takes. Declarations of the name do not count.
+func cliFlagRead(scope *ts.Node, name string, definition *ts.Node, content []byte) bool {
+	read := false
+	walkTree(scope, func(n *ts.Node) {
+		if read || (definition != nil && n.StartByte() >= definition.StartByte() && n.EndByte() <= definition.EndByte()) {
+			return
+		}
+		switch n.Kind() {
+		case "identifier", "field_identifier", "property_identifier", "shorthand_property_identifier",
+			"shorthand_property_identifier_pattern", "string_content", "string_fragment":
+		default:
+			return
+		}
+		if n.Utf8Text(content) != name {
+			return
+		}
+		if parent := n.Parent(); parent != nil {
+			switch parent.Kind() {
+			case "var_spec", "field_declaration", "parameter_declaration", "parameters", "typed_parameter",
+				"default_parameter", "typed_default_parameter":
+				return
+			}
+		}
+		read = true
+	})
+	return read
+}
+
+// stringLiteralValue returns the value of a string literal without interpolation
+func stringLiteralValue(node *ts.Node, content []byte) (string, bool) {
+	if node == nil {
+		return "", false
+	}
+	text := node.Utf8Text(content)
+	switch node.Kind() {
+	case "interpreted_string_literal", "raw_string_literal":
+		value, err := strconv.Unquote(text)
+		return value, err == nil
+	case "string", "template_string":
+		if containsKind(node, "interpolation") || containsKind(node, "template_substitution") {
+			return "", false
+		}
+		body := strings.TrimLeft(text, "rRuU")
+		raw := strings.ContainsAny(text[:len(text)-len(body)], "rR")
+		for _, quote := range []string{`"""`, `'''`, `"`, `'`, "`"} {
+			if len(body) >= 2*len(quote) && strings.HasPrefix(body, quote) && strings.HasSuffix(body, quote) {
+				value := body[len(quote) : len(body)-len(quote)]
+				if !raw {
+					value = unescapeStringLiteral(value)
+				}
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// unescapeStringLiteral replaces the escape sequences of a Python or
+// JavaScript string body by the characters they stand for. Sequences the
+// languages do not define, such as \d, are kept as written, as Python does.
+func unescapeStringLiteral(body string) string {
+	if !strings.Contains(body, "\\") {
+		return body
+	}
+	var sb strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' || i+1 == len(body) {
+			sb.WriteByte(body[i])
+			continue
+		}
+		i++
+		switch c := body[i]; c {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case '\\', '\'', '"', '`':
+			sb.WriteByte(c)
+		case '\n':
+			// A line continuation
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// cliArguments returns the arguments of a call, without comments
+func cliArguments(call *ts.Node) []*ts.Node {
+	args := call.ChildByFieldName("arguments")
+	if args == nil {
+		return nil
+	}
+	result := make([]*ts.Node, 0, args.NamedChildCount())
+	for i := uint(0); i < args.NamedChildCount(); i++ {
+		if arg := args.NamedChild(i); arg.Kind() != "comment" {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// flagNameVariable turns a flag name into the identifier libraries store its
+// value under: dry-run becomes dry_run in Python, or dryRun in camel case
+func flagNameVariable(name string, camel bool) string {
+	if !camel {
+		return strings.ReplaceAll(name, "-", "_")
+	}
+	parts := strings.Split(name, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// goFlagMethod splits a Go flag definition method into the type of its value
+// and whether it stores it through a pointer and takes a shorthand: Int,
+// IntVar, IntP and IntVarP. Var and VarP define flags of a custom Value.
+func goFlagMethod(method string) (base string, pointer, shorthand, ok bool) {
+	split := func(m string) (string, bool, bool) {
+		if goFlagTypes[m] {
+			return m, false, true
+		}
+		if b, found := strings.CutSuffix(m, "Var"); found && (b == "" || goFlagTypes[b]) {
+			return b, true, true
+		}
+		return "", false, false
+	}
+	if base, pointer, ok = split(method); ok {
+		return base, pointer, false, true
+	}
+	if m, found := strings.CutSuffix(method, "P"); found {
+		base, pointer, ok = split(m)
+		return base, pointer, true, ok
+	}
+	return "", false, false, false
+}
+
+// goAssignedName returns the variable a value is assigned or declared to, as
+// in port := flag.Int(...), with the statement doing so
+func goAssignedName(node *ts.Node, content []byte) (string, *ts.Node) {
+	value := node
+	for value.Parent() != nil {
+		parent := value.Parent()
+		switch parent.Kind() {
+		case "unary_expression", "parenthesized_expression", "expression_list":
+			value = parent
+			continue
+		case "var_spec":
+			if name := parent.ChildByFieldName("name"); name != nil {
+				return name.Utf8Text(content), parent
+			}
+		case "short_var_declaration", "assignment_statement":
+			right := parent.ChildByFieldName("right")
+			if left := parent.ChildByFieldName("left"); left != nil && left.NamedChildCount() == 1 &&
+				right != nil && right.StartByte() == value.StartByte() {
+				return left.NamedChild(0).Utf8Text(content), parent
+			}
+		}
+		break
+	}
+	return "", nil
+}
+
+// goCLIFlags finds the flags defined with the flag and pflag packages, on
+// their flag sets, and on cobra commands through Flags() and
+// PersistentFlags()
+func goCLIFlags(file *entities.File) []cliFlag {
+	content := file.Content
+	root := file.Tree.RootNode()
+	goImports := goImportNames(file)
+	text := func(n *ts.Node) string { return n.Utf8Text(content) }
+
+	// Flag sets by variable, with their library and command name, and the
+	// command names of cobra commands by variable
+	type flagSet struct{ library, command string }
+	flagSets := make(map[string]flagSet)
+	commands := make(map[string]string)
+	walkTree(root, func(node *ts.Node) {
+		switch node.Kind() {
+		case "call_expression":
+			function := node.ChildByFieldName("function")
+			if function == nil || function.Kind() != "selector_expression" ||
+				text(function.ChildByFieldName("field")) != "NewFlagSet" {
+				return
+			}
+			library := CLILibraryFlag
+			switch goImports[text(function.ChildByFieldName("operand"))] {
+			case "flag":
+			case goPflagPath:
+				library = CLILibraryPflag
+			default:
+				return
+			}
+			args := cliArguments(node)
+			if name, _ := goAssignedName(node, content); name != "" && len(args) > 0 {
+				command, _ := stringLiteralValue(args[0], content)
+				flagSets[name] = flagSet{library, command}
+			}
+		case "composite_literal":
+			typ := node.ChildByFieldName("type")
+			if typ == nil || typ.Kind() != "qualified_type" || text(typ.ChildByFieldName("name")) != "Command" ||
+				goImports[text(typ.ChildByFieldName("package"))] != goCobraPath {
+				return
+			}
+			name, _ := goAssignedName(node, content)
+			body := node.ChildByFieldName("body")
+			if name == "" || body == nil {
+				return
+			}
+			for i := uint(0); i < body.NamedChildCount(); i++ {
+				element := body.NamedChild(i)
+				if element.Kind() != "keyed_element" || element.NamedChildCount() < 2 ||
+					text(element.NamedChild(0)) != "Use" {
+					continue
+				}
+				value := element.NamedChild(1)
+				if value.Kind() == "literal_element" && value.NamedChildCount() > 0 {
+					value = value.NamedChild(0)
+				}
+				if use, ok := stringLiteralValue(value, content); ok {
+					if fields := strings.Fields(use); len(fields) > 0 {
+						commands[name] = fields[0]
+					}
+				}
+			}
+		}
+	})
+
+	flags := make([]cliFlag, 0)
+	walkTree(root, func(node *ts.Node) {
+		if node.Kind() != "call_expression" {
+			return
+		}
+		function := node.ChildByFieldName("function")
+		if function == nil || function.Kind() != "selector_expression" {
+			return
+		}
+		base, pointer, shorthand, ok := goFlagMethod(text(function.ChildByFieldName("field")))
+		if !ok {
+			return
+		}
+
+		f := cliFlag{node: node, definition: node, owner: node, readScope: root}
+		operand := function.ChildByFieldName("operand")
+		switch operand.Kind() {
+		case "identifier":
+			if set, ok := flagSets[text(operand)]; ok {
+				f.library, f.command = set.library, set.command
+			} else if path := goImports[text(operand)]; path == "flag" {
+				f.library = CLILibraryFlag
+			} else if path == goPflagPath {
+				f.library = CLILibraryPflag
+			} else {
+				return
+			}
+		case "call_expression":
+			// cmd.Flags() and cmd.PersistentFlags() of a cobra command
+			getter := operand.ChildByFieldName("function")
+			if getter == nil || getter.Kind() != "selector_expression" || len(cliArguments(operand)) > 0 {
+				return
+			}
+			switch text(getter.ChildByFieldName("field")) {
+			case "Flags":
+			case "PersistentFlags":
+				f.persistent = true
+			default:
+				return
+			}
+			f.library = CLILibraryCobra
+			command := text(getter.ChildByFieldName("operand"))
+			f.command = command
+			if use, ok := commands[command]; ok {
+				f.command = use
+			}
+		default:
+			return
+		}
+
+		// [pointer] name [shorthand] [default] usage
+		args := cliArguments(node)
+		hasDefault := base != "" && base != "Count" && base != "Func" && base != "BoolFunc"
+		i := 0
+		var dest *ts.Node
+		if pointer {
+			if len(args) == 0 {
+				return
+			}
+			dest, i = args[0], 1
+		}
+		if len(args) <= i {
+			return
+		}
+		if f.name, ok = stringLiteralValue(args[i], content); !ok {
+			return
+		}
+		i++
+		if shorthand && len(args) > i {
+			if short, _ := stringLiteralValue(args[i], content); short != "" {
+				f.short = "-" + short
+			}
+			i++
+		}
+		if hasDefault && len(args) > i {
+			f.defaultValue, f.hasDefault = text(args[i]), true
+			i++
+		}
+		if len(args) > i {
+			f.help, _ = stringLiteralValue(args[i], content)
+		}
+
+		f.flag = "--" + f.name
+		if f.library == CLILibraryFlag {
+			f.flag = "-" + f.name
+		}
+		switch base {
+		case "":
+			f.valueType = "value"
+		case "BoolFunc":
+			f.valueType = "bool"
+		default:
+			f.valueType = strings.ToLower(base[:1]) + base[1:]
+		}
+
+		if dest != nil {
+			f.variable = strings.TrimPrefix(text(dest), "&")
+			f.readName = f.variable[strings.LastIndex(f.variable, ".")+1:]
+		} else if name, statement := goAssignedName(node, content); name != "" && name != "_" {
+			f.variable, f.readName, f.definition = name, name, statement
+		}
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// pythonKeywordArguments returns the keyword arguments of a call by name
+func pythonKeywordArguments(args []*ts.Node, content []byte) map[string]*ts.Node {
+	keywords := make(map[string]*ts.Node)
+	for _, arg := range args {
+		if arg.Kind() != "keyword_argument" {
+			continue
+		}
+		name, value := arg.ChildByFieldName("name"), arg.ChildByFieldName("value")
+		if name != nil && value != nil {
+			keywords[name.Utf8Text(content)] = value
+		}
+	}
+	return keywords
+}
+
+// pythonFlagNames reads the leading string arguments of add_argument or a
+// click decorator: the option strings, and for click the name of the
+// parameter too
+func pythonFlagNames(f *cliFlag, args []*ts.Node, content []byte) (parameter string) {
+	for _, arg := range args {
+		name, ok := stringLiteralValue(arg, content)
+		if !ok {
+			break
+		}
+		switch {
+		case strings.HasPrefix(name, "--"):
+			if f.flag == "" {
+				f.flag = name
+			}
+		case strings.HasPrefix(name, "-"):
+			if f.short == "" {
+				f.short = name
+			}
+		default:
+			parameter = name
+		}
+	}
+	return parameter
+}
+
+// pythonFlagOptions reads the keyword arguments shared by add_argument and
+// the click decorators
+func pythonFlagOptions(f *cliFlag, keywords map[string]*ts.Node, content []byte) {
+	f.valueType = "str"
+	if typ := keywords["type"]; typ != nil {
+		f.valueType = typ.Utf8Text(content)
+	}
+	if value := keywords["default"]; value != nil {
+		f.defaultValue, f.hasDefault = value.Utf8Text(content), true
+	}
+	if help := keywords["help"]; help != nil {
+		f.help, _ = stringLiteralValue(help, content)
+	}
+	if nargs := keywords["nargs"]; nargs != nil {
+		if n := strings.Trim(nargs.Utf8Text(content), "\"'"); n != "?" && n != "1" {
+			f.valueType = "list"
+		}
+	}
+}
+
+// pythonCLIFlags finds the arguments added to argparse parsers and the
+// options and arguments of click commands
+func pythonCLIFlags(file *entities.File) []cliFlag {
+	content := file.Content
+	root := file.Tree.RootNode()
+	_, imported := collectNameUses(file)
+	text := func(n *ts.Node) string { return n.Utf8Text(content) }
+
+	// Commands of the argparse parsers and argument groups, by variable
+	parsers := make(map[string]string)
+	walkTree(root, func(node *ts.Node) {
+		if node.Kind() != "assignment" {
+			return
+		}
+		left, right := node.ChildByFieldName("left"), node.ChildByFieldName("right")
+		if left == nil || left.Kind() != "identifier" || right == nil || right.Kind() != "call" {
+			return
+		}
+		function := right.ChildByFieldName("function")
+		if function == nil || function.Kind() != "attribute" {
+			return
+		}
+		switch text(function.ChildByFieldName("attribute")) {
+		case "add_parser":
+			if args := cliArguments(right); len(args) > 0 {
+				parsers[text(left)], _ = stringLiteralValue(args[0], content)
+			}
+		case "add_argument_group", "add_mutually_exclusive_group":
+			parsers[text(left)] = parsers[text(function.ChildByFieldName("object"))]
+		}
+	})
+
+	flags := make([]cliFlag, 0)
+	walkTree(root, func(node *ts.Node) {
+		switch node.Kind() {
+		case "call":
+			function := node.ChildByFieldName("function")
+			if function == nil || function.Kind() != "attribute" || text(function.ChildByFieldName("attribute")) != "add_argument" {
+				return
+			}
+			args := cliArguments(node)
+			f := cliFlag{node: node, definition: node, owner: node, readScope: root, library: CLILibraryArgparse}
+			f.command = parsers[text(function.ChildByFieldName("object"))]
+			positional := pythonFlagNames(&f, args, content)
+			if f.flag == "" && f.short == "" {
+				f.name, f.positional = positional, true
+			} else {
+				f.name = strings.TrimLeft(f.flag, "-")
+				if f.name == "" {
+					f.name = strings.TrimLeft(f.short, "-")
+				}
+			}
+			keywords := pythonKeywordArguments(args, content)
+			pythonFlagOptions(&f, keywords, content)
+			if action := keywords["action"]; action != nil {
+				switch value, _ := stringLiteralValue(action, content); value {
+				case "store_true", "store_false":
+					f.valueType = "bool"
+				case "count":
+					f.valueType = "count"
+				case "append", "extend":
+					f.valueType = "list"
+				case "help", "version":
+					return
+				default:
+					if strings.HasSuffix(text(action), "BooleanOptionalAction") {
+						f.valueType = "bool"
+					}
+				}
+			}
+			f.variable = flagNameVariable(f.name, false)
+			if dest := keywords["dest"]; dest != nil {
+				f.variable, _ = stringLiteralValue(dest, content)
+			}
+			f.readName = f.variable
+			flags = append(flags, f)
+
+		case "decorated_definition":
+			definition := node.ChildByFieldName("definition")
+			if definition == nil || definition.Kind() != "function_definition" {
+				return
+			}
+			body := definition.ChildByFieldName("body")
+			functionName := text(definition.ChildByFieldName("name"))
+
+			command, isCommand := "", false
+			options := make([]cliFlag, 0)
+			for i := uint(0); i < node.NamedChildCount(); i++ {
+				decorator := node.NamedChild(i)
+				if decorator.Kind() != "decorator" || decorator.NamedChildCount() == 0 || decorator.NamedChild(0).Kind() != "call" {
+					continue
+				}
+				call := decorator.NamedChild(0)
+				callee := text(call.ChildByFieldName("function"))
+				args := cliArguments(call)
+				last := callee[strings.LastIndex(callee, ".")+1:]
+				if (last == "command" || last == "group") && (strings.Contains(callee, ".") || imported[callee]) {
+					isCommand = true
+					command = strings.ReplaceAll(functionName, "_", "-")
+					if len(args) > 0 {
+						if name, ok := stringLiteralValue(args[0], content); ok {
+							command = name
+						}
+					}
+					continue
+				}
+				if callee != "click.option" && callee != "click.argument" &&
+					!((callee == "option" || callee == "argument") && imported[callee]) {
+					continue
+				}
+
+				f := cliFlag{node: decorator, owner: definition, readScope: body, library: CLILibraryClick}
+				parameter := pythonFlagNames(&f, args, content)
+				f.positional = last == "argument"
+				f.name = parameter
+				if !f.positional {
+					f.name = strings.TrimLeft(f.flag, "-")
+					if f.name == "" {
+						f.name = strings.TrimLeft(f.short, "-")
+					}
+				}
+				keywords := pythonKeywordArguments(args, content)
+				pythonFlagOptions(&f, keywords, content)
+				for keyword, valueType := range map[string]string{"is_flag": "bool", "count": "count", "multiple": "list"} {
+					if value := keywords[keyword]; value != nil && text(value) == "True" {
+						f.valueType = valueType
+					}
+				}
+				f.variable = parameter
+				if f.variable == "" || f.positional {
+					f.variable = strings.ToLower(flagNameVariable(f.name, false))
+				}
+				f.readName = f.variable
+				options = append(options, f)
+			}
+			if !isCommand {
+				return
+			}
+			for i := range options {
+				options[i].command = command
+			}
+			flags = append(flags, options...)
+		}
+	})
+	return flags
+}
+
+// tsCLIFlags finds the options and arguments of commander programs and the
+// options and positionals of yargs
+func tsCLIFlags(file *entities.File) []cliFlag {
+	content := file.Content
+	root := file.Tree.RootNode()
+	text := func(n *ts.Node) string { return n.Utf8Text(content) }
+
+	commander, yargs := false, false
+	for _, imp := range file.Imports {
+		commander = commander || strings.Contains(imp.Name, "commander")
+		yargs = yargs || strings.Contains(imp.Name, "yargs")
+	}
+	if !commander && !yargs {
+		return nil
+	}
+
+	flags := make([]cliFlag, 0)
+	walkTree(root, func(node *ts.Node) {
+		if node.Kind() != "call_expression" {
+			return
+		}
+		function := node.ChildByFieldName("function")
+		if function == nil || function.Kind() != "member_expression" {
+			return
+		}
+		method := text(function.ChildByFieldName("property"))
+		args := cliArguments(node)
+		if len(args) == 0 {
+			return
+		}
+		first, ok := stringLiteralValue(args[0], content)
+		if !ok || first == "" {
+			return
+		}
+
+		f := cliFlag{node: node, definition: node.ChildByFieldName("arguments"), owner: node, readScope: root}
+		switch {
+		case commander && (method == "option" || method == "requiredOption") && strings.HasPrefix(first, "-"):
+			f.library = CLILibraryCommander
+			f.valueType = "bool"
+			for _, token := range strings.FieldsFunc(first, func(r rune) bool { return r == ' ' || r == ',' || r == '|' }) {
+				switch {
+				case strings.HasPrefix(token, "--"):
+					f.flag = token
+				case strings.HasPrefix(token, "-"):
+					f.short = token
+				case strings.HasPrefix(token, "<"), strings.HasPrefix(token, "["):
+					f.valueType = "string"
+					if strings.Contains(token, "...") {
+						f.valueType = "list"
+					}
+				}
+			}
+			f.name = strings.TrimPrefix(f.flag, "--")
+			if negated, ok := strings.CutPrefix(f.name, "no-"); ok {
+				f.name = negated
+			}
+			if f.name == "" {
+				f.name = strings.TrimPrefix(f.short, "-")
+			}
+			commanderHelpDefault(&f, args, content)
+			f.variable = flagNameVariable(f.name, true)
+
+		case commander && method == "argument" && strings.ContainsAny(first[:1], "<["):
+			f.library = CLILibraryCommander
+			f.positional = true
+			f.valueType = "string"
+			if strings.Contains(first, "...") {
+				f.valueType = "list"
+			}
+			f.name = strings.Trim(first, "<>[]. ")
+			commanderHelpDefault(&f, args, content)
+
+		case yargs && (method == "option" || method == "positional") && len(args) > 1 && args[1].Kind() == "object":
+			f.library = CLILibraryYargs
+			f.name = first
+			f.positional = method == "positional"
+			if !f.positional {
+				f.flag = "--" + first
+			}
+			f.valueType = "string"
+			for i := uint(0); i < args[1].NamedChildCount(); i++ {
+				pair := args[1].NamedChild(i)
+				key, value := pair.ChildByFieldName("key"), pair.ChildByFieldName("value")
+				if pair.Kind() != "pair" || key == nil || value == nil {
+					continue
+				}
+				name := strings.Trim(text(key), "\"'")
+				switch name {
+				case "alias":
+					if alias, ok := stringLiteralValue(value, content); ok {
+						f.short = "--" + alias
+						if len(alias) == 1 {
+							f.short = "-" + alias
+						}
+					}
+				case "type":
+					f.valueType, _ = stringLiteralValue(value, content)
+				case "boolean", "array", "count":
+					if text(value) == "true" {
+						f.valueType = map[string]string{"boolean": "bool", "array": "list", "count": "count"}[name]
+					}
+				case "default":
+					f.defaultValue, f.hasDefault = text(value), true
+				case "describe", "description", "desc":
+					f.help, _ = stringLiteralValue(value, content)
+				}
+			}
+			f.variable = flagNameVariable(f.name, true)
+
+		default:
+			return
+		}
+		if f.variable == "" {
+			f.variable = flagNameVariable(f.name, true)
+		}
+		f.readName = f.variable
+		f.command = tsCLICommand(node, content)
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// commanderHelpDefault reads the description and default value commander's
+// option and argument take after their flags, skipping a parser function
+// given between the two
+func commanderHelpDefault(f *cliFlag, args []*ts.Node, content []byte) {
+	if len(args) > 1 {
+		f.help, _ = stringLiteralValue(args[1], content)
+	}
+	rest := args[min(2, len(args)):]
+	if len(rest) > 0 && (rest[0].Kind() == "arrow_function" || rest[0].Kind() == "function_expression" ||
+		rest[0].Kind() == "function") {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		f.defaultValue, f.hasDefault = rest[0].Utf8Text(content), true
+	}
+}
+
+// tsCLICommand returns the subcommand a commander or yargs definition
+// belongs to: the first word of the nearest .command("name ...") call in its
+// chain, as in program.command("serve").option(...), or around it, as in the
+// builder of yargs.command("serve", "...", (y) => y.option(...))
+func tsCLICommand(call *ts.Node, content []byte) string {
+	commandName := func(node *ts.Node) (string, bool) {
+		function := node.ChildByFieldName("function")
+		if node.Kind() != "call_expression" || function == nil || function.Kind() != "member_expression" ||
+			function.ChildByFieldName("property").Utf8Text(content) != "command" {
+			return "", false
+		}
+		args := cliArguments(node)
+		if len(args) == 0 {
+			return "", false
+		}
+		name, ok := stringLiteralValue(args[0], content)
+		if fields := strings.Fields(name); ok && len(fields) > 0 {
+			return fields[0], true
+		}
+		return "", false
+	}
+
+	for node := call; node != nil; {
+		if name, ok := commandName(node); ok && node != call {
+			return name
+		}
+		function := node.ChildByFieldName("function")
+		if node.Kind() == "call_expression" && function != nil && function.Kind() == "member_expression" {
+			node = function.ChildByFieldName("object")
+		} else if node.Kind() == "member_expression" {
+			node = node.ChildByFieldName("object")
+		} else {
+			break
+		}
+	}
+	for parent := call.Parent(); parent != nil; parent = parent.Parent() {
+		args := parent.ChildByFieldName("arguments")
+		if name, ok := commandName(parent); ok && call.StartByte() >= args.StartByte() {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+func TestDetectCLIFlags(t *testing.T) {
+	type flag struct {
+		name, flag, short, flagType, def, help, library string
+	}
+	tests := []struct {
+		name   string
+		path   string
+		source string
+		want   []flag
+	}{
+		{
+			name: "go flag",
+			path: "main.go",
+			source: `package main
+
+import "flag"
+
+func main() {
+	port := flag.Int("port", 8080, "port to listen on")
+	flag.StringVar(&name, ` + "`name`" + `, "world", "say \"hello\" to name")
+	flag.Parse()
+	_ = *port
+}
+`,
+			want: []flag{
+				{"name", "-name", "", "string", `"world"`, `say "hello" to name`, CLILibraryFlag},
+				{"port", "-port", "", "int", "8080", "port to listen on", CLILibraryFlag},
+			},
+		},
+		{
+			name: "cobra",
+			path: "cmd/root.go",
+			source: `package cmd
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file")
+}
+`,
+			want: []flag{{"config", "--config", "-c", "string", `""`, "config file", CLILibraryCobra}},
+		},
+		{
+			name: "argparse",
+			path: "cli.py",
+			source: `import argparse
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument('-v', "--verbose", action="store_true", help='don\'t be "quiet"')
+    parser.add_argument("--dry-run", default='no', help=r"path like C:\tmp")
+    args = parser.parse_args()
+    print(args.verbose, args.dry_run)
+`,
+			want: []flag{
+				{"dry-run", "--dry-run", "", "str", "'no'", `path like C:\tmp`, CLILibraryArgparse},
+				{"verbose", "--verbose", "-v", "bool", "", `don't be "quiet"`, CLILibraryArgparse},
+			},
+		},
+		{
+			name: "commander",
+			path: "cli.ts",
+			source: `import { program } from 'commander';
+
+function main() {
+  program.option('-p, --port <number>', "port to \"bind\"", '8080');
+  program.parse();
+}
+`,
+			want: []flag{{"port", "--port", "-p", "string", "'8080'", `port to "bind"`, CLILibraryCommander}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := analyzeSource(t, tt.path, tt.source)
+			detectCLIFlags(file)
+
+			got := make([]flag, 0)
+			for _, entity := range file.GetEntitiesByType(entities.EntityTypeCLIFlag) {
+				property := func(name string) string {
+					value, _ := entity.GetProperty(name).(string)
+					return value
+				}
+				got = append(got, flag{entity.Name, property("flag"), property("short"), property("flag_type"),
+					property("default"), property("help"), property("library")})
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].name < got[j].name })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flags =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+// analyzeSource parses a file with the analyzer of its language
+func analyzeSource(t *testing.T, path, source string) *entities.File {
+	t.Helper()
+	var file *entities.File
+	var err error
+	switch fileLanguages[filepath.Ext(path)] {
+	case "go":
+		file, _, err = NewGoAnalyzer().AnalyzeFile(path, []byte(source))
+	case "python":
+		file, _, err = NewPythonAnalyzer().AnalyzeFile(path, []byte(source))
+	default:
+		file, _, err = NewTypeScriptAnalyzer().AnalyzeFile(path, []byte(source))
+	}
+	if err != nil {
+		t.Fatalf("analyze %s: %v", path, err)
+	}
+	return file
+}
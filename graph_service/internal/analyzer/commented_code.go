@@ -0,0 +1,284 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+	golang "github.com/tree-sitter/tree-sitter-go/bindings/go"
+	python "github.com/tree-sitter/tree-sitter-python/bindings/go"
+	tree_sitter_typescript "github.com/tree-sitter/tree-sitter-typescript/bindings/go"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// minCommentedCodeLines is the number of code lines a comment block needs
+// before it is reported; a single commented line is as often an example as
+// dead code
+const minCommentedCodeLines = 2
+
+// commentDirective matches comments that are instructions to tools rather
+// than text, such as //go:build, // +build, //nolint, # type: ignore,
+// // eslint-disable-next-line or // @ts-ignore
+var commentDirective = regexp.MustCompile(`^(//go:|//\s*\+build|//\s*nolint|#\s*type:|#\s*-\*-|#!|#\s*(noqa|pragma|pylint|fmt:)|//\s*(eslint|prettier|@ts-|istanbul|tslint)|/\*\s*(eslint|istanbul|prettier))`)
+
+// codeKeywords start lines that are code in at least one supported language
+var codeKeywords = regexp.MustCompile(`^(if|else|for|while|switch|case|default|return|func|def|class|import|from|package|const|let|var|type|go|defer|try|except|catch|finally|with|async|await|export|raise|throw|break|continue|elif|yield|interface|struct|select)\b`)
+
+// codeLine matches text that reads as code rather than prose: a line ending
+// in a brace, bracket, semicolon or comma, or containing a call, assignment or
+// operator. A trailing colon alone is not enough, since prose such as
+// "Example:" introduces examples.
+var codeLine = regexp.MustCompile(`([{}\[\]();,]$|^[})\]]|\w\(.*\)|:=|[^=!<>]=[^=]|==|!=|=>|->|&&|\|\||\+\+|--$)`)
+
+// commentBlock is a run of comment lines with nothing but whitespace between
+// them and no code on the same lines
+type commentBlock struct {
+	comments []*ts.Node
+	lines    []string // the comment text, markers removed
+}
+
+// detectCommentedCode records comment blocks that parse as code in the file's
+// language as CommentedCode entities: dead code that was commented out rather
+// than deleted. A block needs at least two lines, every non-blank line must
+// look like code (keywords, braces, calls, assignments) and the block must
+// parse without errors, which keeps prose, doc comments and examples out.
+// Blocks inside functions marked with an onyx:ignore comment are skipped.
+func detectCommentedCode(file *entities.File) {
+	if file.Tree == nil {
+		return
+	}
+
+	type candidate struct {
+		block     *commentBlock
+		code      string
+		skip      int
+		enclosing *entities.Entity
+	}
+	functions := append(append([]*entities.Entity{}, file.Functions...), file.Methods...)
+	candidates := make([]candidate, 0)
+	for _, block := range commentBlocks(file) {
+		enclosing := innermostEntity(functions, block.comments[0])
+		if enclosing != nil && enclosing.IsIgnored() {
+			continue
+		}
+		// Doc comments, whose examples read as code, only precede
+		// declarations, so a lead-in such as "// old version:" is only
+		// skipped inside function bodies
+		if code, skip, ok := commentedCode(block.lines, enclosing != nil); ok {
+			candidates = append(candidates, candidate{block, code, skip, enclosing})
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	parser := commentParser(file)
+	if parser == nil {
+		return
+	}
+	defer parser.Close()
+
+	for _, c := range candidates {
+		if parsesAsCode(parser, file.Language, c.code) {
+			file.AddEntity(newCommentedCode(file, c.block, c.skip, c.code, c.enclosing))
+		}
+	}
+}
+
+// commentBlocks groups the comments of a file into blocks of consecutive
+// lines. Trailing comments after code, doc comments (/** and ///) and tool
+// directives end a block and are never part of one.
+func commentBlocks(file *entities.File) []*commentBlock {
+	blocks := make([]*commentBlock, 0)
+	var current *commentBlock
+	lastRow := uint(0)
+
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		if node.Kind() != "comment" {
+			return
+		}
+		text := node.Utf8Text(file.Content)
+		standalone := onlyWhitespaceBefore(file.Content, node.StartByte())
+		if !standalone || commentDirective.MatchString(text) ||
+			strings.HasPrefix(text, "/**") || strings.HasPrefix(text, "///") {
+			current = nil
+			return
+		}
+
+		if current == nil || node.StartPosition().Row != lastRow+1 {
+			current = &commentBlock{}
+			blocks = append(blocks, current)
+		}
+		current.comments = append(current.comments, node)
+		current.lines = append(current.lines, uncomment(text)...)
+		lastRow = node.EndPosition().Row
+	})
+	return blocks
+}
+
+// onlyWhitespaceBefore reports whether the line holding offset has nothing
+// but whitespace before it
+func onlyWhitespaceBefore(content []byte, offset uint) bool {
+	for i := int(offset) - 1; i >= 0 && content[i] != '\n'; i-- {
+		if content[i] != ' ' && content[i] != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// uncomment strips the comment markers from a line or block comment,
+// returning one string per line
+func uncomment(text string) []string {
+	switch {
+	case strings.HasPrefix(text, "//"):
+		return []string{strings.TrimPrefix(strings.TrimPrefix(text, "//"), " ")}
+	case strings.HasPrefix(text, "#"):
+		return []string{strings.TrimPrefix(strings.TrimPrefix(text, "#"), " ")}
+	}
+
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "* ") || trimmed == "*" {
+			line = strings.TrimPrefix(strings.TrimPrefix(trimmed, "*"), " ")
+		}
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return lines
+}
+
+// commentedCode joins the lines of a block into the code they would be,
+// reporting false when the block is too short or any line reads as prose.
+// With skipLeadingProse, prose lines before the first line of code are
+// dropped instead, and skip is the number of lines dropped.
+func commentedCode(lines []string, skipLeadingProse bool) (code string, skip int, ok bool) {
+	for skip < len(lines) {
+		trimmed := strings.TrimSpace(lines[skip])
+		if trimmed != "" && (!skipLeadingProse || looksLikeCode(trimmed)) {
+			break
+		}
+		skip++
+	}
+	lines = lines[skip:]
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	codeLines := 0
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !looksLikeCode(trimmed) {
+			return "", 0, false
+		}
+		codeLines++
+	}
+	if codeLines < minCommentedCodeLines {
+		return "", 0, false
+	}
+	return dedent(lines), skip, true
+}
+
+// looksLikeCode reports whether a trimmed line starts with a keyword or
+// contains code punctuation
+func looksLikeCode(line string) bool {
+	return codeKeywords.MatchString(line) || codeLine.MatchString(line)
+}
+
+// dedent removes the indentation shared by every non-blank line
+func dedent(lines []string) string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent {
+			out[i] = line[indent:]
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// commentParser returns a parser for the language of a file, or nil for
+// languages without commented-code detection
+func commentParser(file *entities.File) *ts.Parser {
+	var language *ts.Language
+	switch file.Language {
+	case "go":
+		language = ts.NewLanguage(golang.Language())
+	case "python":
+		language = ts.NewLanguage(python.Language())
+	case "typescript":
+		language = ts.NewLanguage(tree_sitter_typescript.LanguageTypescript())
+	default:
+		return nil
+	}
+	parser := ts.NewParser()
+	parser.SetLanguage(language)
+	return parser
+}
+
+// parsesAsCode reports whether code parses without errors. Go code is tried
+// both as top-level declarations and as the statements of a function body.
+func parsesAsCode(parser *ts.Parser, language, code string) bool {
+	candidates := []string{code}
+	if language == "go" {
+		candidates = []string{"package p\n" + code, "package p\nfunc _() {\n" + code + "\n}"}
+	}
+	for _, candidate := range candidates {
+		tree := parser.Parse([]byte(candidate), nil)
+		if tree == nil {
+			continue
+		}
+		ok := !tree.RootNode().HasError()
+		tree.Close()
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newCommentedCode creates the CommentedCode entity for a block, starting
+// skip lines into it
+func newCommentedCode(file *entities.File, block *commentBlock, skip int, code string, enclosing *entities.Entity) *entities.Entity {
+	first, last := block.comments[0], block.comments[len(block.comments)-1]
+	startRow := first.StartPosition().Row + uint(skip)
+	startByte := first.StartByte()
+	for _, comment := range block.comments {
+		if comment.StartPosition().Row <= startRow {
+			startByte = comment.StartByte()
+		}
+	}
+
+	name := strings.TrimSpace(strings.SplitN(code, "\n", 2)[0])
+	hash := sha256.Sum256([]byte(fmt.Sprintf("commented_code:%s:%d", file.Path, startByte)))
+	startLine := int(startRow) + 1
+	endLine := int(last.EndPosition().Row) + 1
+
+	entity := entities.NewSpanEntity(hex.EncodeToString(hash[:8]), name, entities.EntityTypeCommentedCode, file.Path,
+		uint32(startByte), uint32(last.EndByte()), startLine, endLine)
+	entity.Body = code
+	entity.SetProperty("text", code)
+	entity.SetProperty("line_count", endLine-startLine+1)
+	if enclosing != nil {
+		entity.SetProperty("enclosing_function", enclosing.ID)
+		entity.SetProperty("enclosing_function_name", enclosing.GetFullName())
+	}
+	return entity
+}
@@ -0,0 +1,470 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/build/constraint"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// goOSes and goArches are the operating systems and architectures a Go file
+// name can be constrained to with a _GOOS, _GOARCH or _GOOS_GOARCH suffix
+var (
+	goOSes = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+		"illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true,
+		"openbsd": true, "plan9": true, "solaris": true, "wasip1": true, "windows": true, "zos": true,
+	}
+	goArches = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true,
+		"mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true,
+		"riscv64": true, "s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// importBranch is the branch of a conditional statement an import is in
+type importBranch struct {
+	statement *ts.Node
+	index     int    // Branches of a statement are numbered in source order
+	condition string // Under which the branch runs, "" when it always does
+}
+
+// conditionalImport is an import made in a branch of one or more
+// conditional statements
+type conditionalImport struct {
+	entity   *entities.Entity
+	branches []importBranch // Innermost first
+}
+
+// detectConditionalImports marks the imports a Python, TypeScript or
+// JavaScript file makes only under a condition with the "conditional" and
+// "condition" properties, and links the imports made in different branches
+// of the same statement with ALTERNATIVE_TO relationships, so a fallback
+// does not pass for a dependency of its own:
+//   - Python: imports in a try body and its except clauses, and in the
+//     branches of if, elif and else, such as if TYPE_CHECKING
+//   - TypeScript and JavaScript: dynamic import() and require() calls in the
+//     branches of if, ternaries, try and catch, or right of && and ||. They
+//     get an Import entity of their own, with the "dynamic" property set for
+//     import().
+//
+// The condition of a branch is that of each enclosing statement, outermost
+// first, joined with "and": the test of an if, "not (...)" of the earlier
+// tests for elif and else, and "try" or the header of the except or catch
+// clause for the branches of a try. Go files are handled across files by
+// linkBuildAlternatives.
+func detectConditionalImports(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+
+	imports := make([]conditionalImport, 0)
+	switch file.Language {
+	case "python":
+		for _, entity := range file.GetEntitiesByType(entities.EntityTypeImport) {
+			if entity.Node == nil {
+				continue
+			}
+			if branches := importBranches(entity.Node, file); len(branches) > 0 {
+				imports = append(imports, conditionalImport{entity, branches})
+			}
+		}
+	case "typescript", "javascript":
+		walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+			module, dynamic, ok := tsDynamicImport(node, file.Content)
+			if !ok {
+				return
+			}
+			branches := importBranches(node, file)
+			if len(branches) == 0 {
+				return
+			}
+			hash := sha256.Sum256([]byte(fmt.Sprintf("conditional_import:%s:%d", file.Path, node.StartByte())))
+			entity := entities.NewEntity(hex.EncodeToString(hash[:8]), module, entities.EntityTypeImport, file.Path, node)
+			entity.Signature = compactText(node, file.Content)
+			entity.SetProperty("path", module)
+			entity.SetProperty("dynamic", dynamic)
+			file.AddEntity(entity)
+			imports = append(imports, conditionalImport{entity, branches})
+		})
+	default:
+		return nil
+	}
+
+	// Imports by statement and branch, to link those of different branches
+	type statementKey struct{ start, end uint }
+	byStatement := make(map[statementKey]map[int][]*entities.Entity)
+	statements := make([]statementKey, 0)
+	for _, imp := range imports {
+		conditions := make([]string, 0, len(imp.branches))
+		for i := len(imp.branches) - 1; i >= 0; i-- {
+			if c := imp.branches[i].condition; c != "" {
+				conditions = append(conditions, c)
+			}
+		}
+		imp.entity.SetProperty("conditional", true)
+		imp.entity.SetProperty("condition", strings.Join(conditions, " and "))
+
+		for _, branch := range imp.branches {
+			key := statementKey{branch.statement.StartByte(), branch.statement.EndByte()}
+			if byStatement[key] == nil {
+				byStatement[key] = make(map[int][]*entities.Entity)
+				statements = append(statements, key)
+			}
+			byStatement[key][branch.index] = append(byStatement[key][branch.index], imp.entity)
+		}
+	}
+
+	relationships := make([]*entities.Relationship, 0)
+	linked := make(map[[2]string]bool)
+	for _, key := range statements {
+		branches := byStatement[key]
+		indexes := make([]int, 0, len(branches))
+		for index := range branches {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		for i, from := range indexes {
+			for _, to := range indexes[i+1:] {
+				for _, source := range branches[from] {
+					for _, target := range branches[to] {
+						if linked[[2]string{source.ID, target.ID}] {
+							continue
+						}
+						linked[[2]string{source.ID, target.ID}] = true
+						relationships = append(relationships, newAlternative(file, source, target))
+					}
+				}
+			}
+		}
+	}
+	return relationships
+}
+
+// importBranches returns the branches of conditional statements a node is
+// in, innermost first
+func importBranches(node *ts.Node, file *entities.File) []importBranch {
+	branches := make([]importBranch, 0)
+	child := node
+	for parent := node.Parent(); parent != nil; child, parent = parent, parent.Parent() {
+		var index int
+		var condition string
+		var ok bool
+		if file.Language == "python" {
+			index, condition, ok = pythonBranch(parent, child, file.Content)
+		} else {
+			index, condition, ok = tsBranch(parent, child, file.Content)
+		}
+		if ok {
+			branches = append(branches, importBranch{parent, index, condition})
+		}
+	}
+	return branches
+}
+
+// pythonBranch returns the branch of a Python if or try statement that child
+// is, and the condition under which it runs
+func pythonBranch(statement, child *ts.Node, content []byte) (int, string, bool) {
+	switch statement.Kind() {
+	case "try_statement":
+		index := 0
+		for i := uint(0); i < statement.NamedChildCount(); i++ {
+			clause := statement.NamedChild(i)
+			switch {
+			case !clause.Equals(*child):
+				if clause.Kind() == "except_clause" {
+					index++
+				}
+				continue
+			case clause.Kind() == "except_clause":
+				header, _, _ := strings.Cut(clause.Utf8Text(content), ":")
+				return index + 1, strings.Join(strings.Fields(header), " "), true
+			case clause.Kind() == "finally_clause":
+				return 0, "", false
+			default:
+				// The body, and the else clause run after it succeeded
+				return 0, "try", true
+			}
+		}
+
+	case "if_statement":
+		previous := make([]string, 0)
+		if condition := statement.ChildByFieldName("condition"); condition != nil {
+			if consequence := statement.ChildByFieldName("consequence"); consequence != nil && consequence.Equals(*child) {
+				return 0, compactCondition(condition, content), true
+			}
+			previous = append(previous, "not ("+compactCondition(condition, content)+")")
+		}
+		index := 0
+		for i := uint(0); i < statement.NamedChildCount(); i++ {
+			clause := statement.NamedChild(i)
+			if clause.Kind() != "elif_clause" && clause.Kind() != "else_clause" {
+				continue
+			}
+			index++
+			condition := clause.ChildByFieldName("condition")
+			if clause.Equals(*child) {
+				conditions := previous
+				if condition != nil {
+					conditions = append(conditions, compactCondition(condition, content))
+				}
+				return index, strings.Join(conditions, " and "), true
+			}
+			if condition != nil {
+				previous = append(previous, "not ("+compactCondition(condition, content)+")")
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// tsBranch returns the branch of a TypeScript or JavaScript if, ternary, try
+// or && and || expression that child is, and the condition under which it
+// runs
+func tsBranch(statement, child *ts.Node, content []byte) (int, string, bool) {
+	field := func(name string) bool {
+		node := statement.ChildByFieldName(name)
+		return node != nil && node.Equals(*child)
+	}
+	condition := func() string {
+		if node := statement.ChildByFieldName("condition"); node != nil {
+			return compactCondition(node, content)
+		}
+		return ""
+	}
+
+	switch statement.Kind() {
+	case "if_statement", "ternary_expression":
+		if field("consequence") {
+			return 0, condition(), true
+		}
+		if field("alternative") {
+			return 1, "not (" + condition() + ")", true
+		}
+	case "try_statement":
+		if field("body") {
+			return 0, "try", true
+		}
+		if field("handler") {
+			return 1, "catch", true
+		}
+	case "binary_expression":
+		operator := statement.ChildByFieldName("operator")
+		left := statement.ChildByFieldName("left")
+		if operator == nil || left == nil || !field("right") {
+			break
+		}
+		switch operator.Utf8Text(content) {
+		case "&&":
+			return 1, compactCondition(left, content), true
+		case "||":
+			return 1, "not (" + compactCondition(left, content) + ")", true
+		case "??":
+			return 1, "(" + compactCondition(left, content) + ") == null", true
+		}
+	}
+	return 0, "", false
+}
+
+// tsDynamicImport reports whether a node is an import() or require() call of
+// a literal module, and which
+func tsDynamicImport(node *ts.Node, content []byte) (module string, dynamic bool, ok bool) {
+	if node.Kind() != "call_expression" {
+		return "", false, false
+	}
+	function := node.ChildByFieldName("function")
+	if function == nil {
+		return "", false, false
+	}
+	switch {
+	case function.Kind() == "import":
+		dynamic = true
+	case function.Kind() == "identifier" && function.Utf8Text(content) == "require":
+	default:
+		return "", false, false
+	}
+	args := cliArguments(node)
+	if len(args) == 0 {
+		return "", false, false
+	}
+	module, ok = stringLiteralValue(args[0], content)
+	return module, dynamic, ok && module != ""
+}
+
+// compactCondition returns the text of a condition on one line, without
+// the parentheses around the test of a TypeScript if
+func compactCondition(node *ts.Node, content []byte) string {
+	text := strings.Join(strings.Fields(node.Utf8Text(content)), " ")
+	if node.Kind() == "parenthesized_expression" {
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(text, "("), ")"))
+	}
+	return text
+}
+
+// goBuildConstraint returns the build constraint of a Go file, from its
+// //go:build line, or its // +build lines in older code, and its _GOOS,
+// _GOARCH or _GOOS_GOARCH file name suffix. It is "" for files built
+// everywhere.
+func goBuildConstraint(file *entities.File) string {
+	var expr constraint.Expr
+	plusBuild := false
+	for _, line := range strings.Split(string(file.Content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		switch {
+		case constraint.IsGoBuild(line):
+			if parsed, err := constraint.Parse(line); err == nil {
+				expr, plusBuild = parsed, false
+			}
+		case constraint.IsPlusBuild(line) && (expr == nil || plusBuild):
+			if parsed, err := constraint.Parse(line); err == nil {
+				if expr == nil {
+					expr = parsed
+				} else {
+					expr = &constraint.AndExpr{X: expr, Y: parsed}
+				}
+				plusBuild = true
+			}
+		}
+		if constraint.IsGoBuild(line) {
+			break
+		}
+	}
+
+	tags := ""
+	if expr != nil {
+		tags = expr.String()
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file.Path), ".go"), "_test")
+	parts := strings.Split(name, "_")
+	suffix := make([]string, 0, 2)
+	if n := len(parts); n >= 3 && goOSes[parts[n-2]] && goArches[parts[n-1]] {
+		suffix = append(suffix, parts[n-2], parts[n-1])
+	} else if n >= 2 && (goOSes[parts[n-1]] || goArches[parts[n-1]]) {
+		suffix = append(suffix, parts[n-1])
+	}
+
+	terms := make([]string, 0)
+	if tags != "" {
+		if strings.Contains(tags, "||") && len(suffix) > 0 {
+			terms = append(terms, "("+tags+")")
+		} else {
+			terms = append(terms, strings.Split(tags, " && ")...)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		seen[term] = true
+	}
+	for _, part := range suffix {
+		// A // +build windows line in file_windows.go says it twice
+		if !seen[part] {
+			terms = append(terms, part)
+		}
+	}
+	return strings.Join(terms, " && ")
+}
+
+// linkBuildAlternatives handles the Go files built only under a build
+// constraint: their imports are marked conditional on it, and declarations
+// of the same package-level name in two constrained files of a package, such
+// as the same function in file_linux.go and file_windows.go, are marked too
+// and linked with ALTERNATIVE_TO relationships, since only one of them is
+// compiled for a platform.
+func (gb *GraphBuilder) linkBuildAlternatives() {
+	type alternative struct {
+		entity    *entities.Entity
+		file      *entities.File
+		condition string
+	}
+	groups := make(map[string][]alternative)
+	keys := make([]string, 0)
+
+	paths := make([]string, 0, len(gb.files))
+	for filePath, file := range gb.files {
+		if file.Language == "go" {
+			paths = append(paths, filePath)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, filePath := range paths {
+		file := gb.files[filePath]
+		condition := goBuildConstraint(file)
+		if condition == "" {
+			continue
+		}
+		dir := path.Dir(filepath.ToSlash(filePath))
+		for _, entity := range file.GetAllEntities() {
+			switch entity.Type {
+			case entities.EntityTypeImport:
+				entity.SetProperty("conditional", true)
+				entity.SetProperty("condition", condition)
+				continue
+			case entities.EntityTypeFunction, entities.EntityTypeStruct, entities.EntityTypeInterface,
+				entities.EntityTypeType, entities.EntityTypeVariable:
+				if entity.Parent != nil {
+					continue
+				}
+			case entities.EntityTypeMethod:
+				// Interface methods go with their interface
+				if _, ok := entity.GetProperty("receiver").(string); !ok {
+					continue
+				}
+			default:
+				continue
+			}
+			name := entity.Name
+			if receiver, ok := entity.GetProperty("receiver").(string); ok {
+				name = ReceiverTypeName(receiver) + "." + name
+			}
+			key := dir + "\x00" + string(entity.Type) + "\x00" + name
+			if groups[key] == nil {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], alternative{entity, file, condition})
+		}
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		for _, alt := range group {
+			alt.entity.SetProperty("conditional", true)
+			alt.entity.SetProperty("condition", alt.condition)
+		}
+		for i, source := range group {
+			for _, target := range group[i+1:] {
+				if source.file != target.file {
+					gb.unresolvedRelationships = append(gb.unresolvedRelationships, newAlternative(target.file, source.entity, target.entity))
+				}
+			}
+		}
+	}
+}
+
+// newAlternative links a declaration or import to another used instead of
+// it under a different condition
+func newAlternative(file *entities.File, source, target *entities.Entity) *entities.Relationship {
+	rel := entities.NewRelationship(source.ID+":alternative:"+target.ID, entities.RelationshipTypeAlternativeTo, source, target)
+	condition, _ := target.GetProperty("condition").(string)
+	rel.SetProperty("condition", condition)
+	if target.Node != nil {
+		rel.SetProvenance(file.Path, target.Node, file.Content)
+	}
+	return rel
+}
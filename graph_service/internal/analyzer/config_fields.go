@@ -0,0 +1,226 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Field entities (Go struct fields, Python class attributes, TypeScript class and
+// interface members) carry these properties so that configuration types can be
+// documented without re-reading the source:
+//   - json_name: the serialized key (struct tag, pydantic alias or member name)
+//   - required: whether a value must be supplied
+//   - default: the default value as written in source, when there is one
+
+// pythonDataClassMarkers identify Python classes whose class-level annotations
+// declare fields: dataclasses, attrs classes, pydantic models and typed dicts
+var pythonDataClassMarkers = []string{
+	"dataclass", "attr.s", "attr.define", "attrs.define", "@define", "@frozen",
+	"BaseModel", "BaseSettings", "TypedDict", "NamedTuple", "Struct",
+}
+
+// setFieldMetadata records the serialized name, required flag and default of a field
+func setFieldMetadata(entity *entities.Entity, jsonName string, required bool, defaultValue string, hasDefault bool) {
+	if jsonName != "" {
+		entity.SetProperty("json_name", jsonName)
+	}
+	entity.SetProperty("required", required)
+	if hasDefault {
+		entity.SetProperty("default", defaultValue)
+	}
+}
+
+// newFieldRelationship links a field to the struct, class or interface that
+// declares it with a Contains relationship
+func newFieldRelationship(file *entities.File, owner, field *entities.Entity) *entities.Relationship {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:contains:%s:%s", file.Path, owner.ID, field.ID)))
+	rel := entities.NewRelationship(hex.EncodeToString(hash[:8]), entities.RelationshipTypeContains, owner, field)
+	rel.SetProvenance(file.Path, field.Node, file.Content)
+	return rel
+}
+
+// goTagMetadata derives field metadata from a Go struct tag such as
+// `json:"port,omitempty" env:"PORT" envDefault:"8080" validate:"required"`.
+// Fields are only considered required when a tag says so explicitly.
+func goTagMetadata(fieldName, rawTag string) (jsonName, envName, defaultValue string, hasDefault, required bool) {
+	jsonName = fieldName
+	if unquoted, err := strconv.Unquote(rawTag); err == nil {
+		rawTag = unquoted
+	}
+	tag := reflect.StructTag(rawTag)
+
+	nameFrom := func(key string) (string, []string, bool) {
+		value, ok := tag.Lookup(key)
+		if !ok {
+			return "", nil, false
+		}
+		parts := strings.Split(value, ",")
+		return parts[0], parts[1:], true
+	}
+
+	if name, _, ok := nameFrom("json"); ok {
+		if name == "-" {
+			jsonName = ""
+		} else if name != "" {
+			jsonName = name
+		}
+	} else if name, _, ok := nameFrom("yaml"); ok && name != "" && name != "-" {
+		jsonName = name
+	}
+
+	if name, options, ok := nameFrom("env"); ok {
+		envName = name
+		for _, option := range options {
+			if option == "required" || option == "notEmpty" {
+				required = true
+			}
+		}
+	}
+
+	for _, key := range []string{"default", "envDefault"} {
+		if value, ok := tag.Lookup(key); ok {
+			defaultValue, hasDefault = value, true
+			break
+		}
+	}
+
+	for _, key := range []string{"validate", "binding"} {
+		if value, ok := tag.Lookup(key); ok {
+			for _, rule := range strings.Split(value, ",") {
+				if rule == "required" {
+					required = true
+				}
+			}
+		}
+	}
+	if value, ok := tag.Lookup("required"); ok && value == "true" {
+		required = true
+	}
+
+	return jsonName, envName, defaultValue, hasDefault, required
+}
+
+// isPythonDataClass reports whether a class declares its fields through
+// class-level annotations
+func isPythonDataClass(class *entities.Entity) bool {
+	var markers []string
+	if decorators, ok := class.GetProperty("decorators").([]string); ok {
+		markers = append(markers, decorators...)
+	}
+	if superclasses, ok := class.GetProperty("superclasses").(string); ok {
+		markers = append(markers, superclasses)
+	}
+
+	for _, text := range markers {
+		for _, marker := range pythonDataClassMarkers {
+			if strings.Contains(text, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pythonFieldMetadata derives field metadata from a class-level assignment in a
+// dataclass or pydantic model. Field(...) and field(...) calls are inspected for
+// default, default_factory and alias arguments; Field(...) with an Ellipsis
+// marks a required field.
+func pythonFieldMetadata(name, annotation string, valueNode *ts.Node, content []byte) (jsonName, defaultValue string, hasDefault, required bool) {
+	jsonName = name
+	if valueNode == nil {
+		// Bare annotation: required unless explicitly marked otherwise
+		required = !strings.HasPrefix(annotation, "NotRequired[")
+		return jsonName, "", false, required
+	}
+
+	if valueNode.Kind() == "call" {
+		funcName := ""
+		if fn := valueNode.ChildByFieldName("function"); fn != nil {
+			funcName = fn.Utf8Text(content)
+		}
+		if base := funcName[strings.LastIndex(funcName, ".")+1:]; base == "Field" || base == "field" || base == "attrib" || base == "ib" {
+			return pythonFieldCallMetadata(name, valueNode.ChildByFieldName("arguments"), content)
+		}
+	}
+
+	return jsonName, valueNode.Utf8Text(content), true, false
+}
+
+// pythonFieldCallMetadata reads the arguments of a Field()/field() call
+func pythonFieldCallMetadata(name string, argsNode *ts.Node, content []byte) (jsonName, defaultValue string, hasDefault, required bool) {
+	jsonName = name
+	required = true
+	if argsNode == nil {
+		return jsonName, "", false, required
+	}
+
+	for i := uint(0); i < argsNode.NamedChildCount(); i++ {
+		arg := argsNode.NamedChild(i)
+		switch arg.Kind() {
+		case "ellipsis":
+			required = true
+		case "keyword_argument":
+			keyNode := arg.ChildByFieldName("name")
+			valueNode := arg.ChildByFieldName("value")
+			if keyNode == nil || valueNode == nil {
+				continue
+			}
+			value := valueNode.Utf8Text(content)
+			switch keyNode.Utf8Text(content) {
+			case "default":
+				defaultValue, hasDefault, required = value, true, false
+			case "default_factory", "factory":
+				defaultValue, hasDefault, required = value+"()", true, false
+			case "alias", "validation_alias", "serialization_alias":
+				if valueNode.Kind() == "string" {
+					jsonName = strings.Trim(value, "\"'")
+				}
+			}
+		case "comment":
+		default:
+			// First positional argument of pydantic's Field is the default
+			if i == 0 {
+				defaultValue, hasDefault, required = arg.Utf8Text(content), true, false
+			}
+		}
+	}
+	return jsonName, defaultValue, hasDefault, required
+}
+
+// tsMemberMetadata derives field metadata from a TypeScript interface property
+// signature or class field. Members are required unless marked optional with
+// "?" or given an initializer; a JSDoc "@default" tag supplies the default for
+// interface members.
+func tsMemberMetadata(name string, memberNode *ts.Node, content []byte) (jsonName, defaultValue string, hasDefault, required bool) {
+	jsonName = strings.Trim(name, "\"'")
+	optional := false
+	for i := uint(0); i < memberNode.ChildCount(); i++ {
+		if memberNode.Child(i).Kind() == "?" {
+			optional = true
+			break
+		}
+	}
+
+	if valueNode := memberNode.ChildByFieldName("value"); valueNode != nil {
+		defaultValue, hasDefault = valueNode.Utf8Text(content), true
+	} else if prev := memberNode.PrevNamedSibling(); prev != nil && prev.Kind() == "comment" {
+		comment := prev.Utf8Text(content)
+		if idx := strings.Index(comment, "@default"); idx >= 0 {
+			value := strings.TrimSpace(comment[idx+len("@default"):])
+			value = strings.TrimSpace(strings.TrimSuffix(value, "*/"))
+			if line := strings.SplitN(value, "\n", 2); len(line) > 0 {
+				defaultValue, hasDefault = strings.TrimSpace(line[0]), true
+			}
+		}
+	}
+
+	return jsonName, defaultValue, hasDefault, !optional && !hasDefault
+}
@@ -0,0 +1,581 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of control-flow issue
+const (
+	ControlFlowMissingReturn = "missing_return"   // a path reaches the end of a function that must return a value
+	ControlFlowUnreachable   = "unreachable_code" // statement after one that never completes normally
+)
+
+// exitCalls are calls that never return: they end the process or unwind the
+// stack like a throw
+var exitCalls = map[string]bool{
+	"panic":        true,
+	"os.Exit":      true,
+	"log.Fatal":    true,
+	"log.Fatalf":   true,
+	"log.Fatalln":  true,
+	"log.Panic":    true,
+	"log.Panicf":   true,
+	"log.Panicln":  true,
+	"process.exit": true,
+	"sys.exit":     true,
+	"exit":         true,
+	"quit":         true,
+	"os._exit":     true,
+}
+
+// statementContainers are the nodes whose statements run in sequence, the
+// only place where code can follow a statement that never completes
+var statementContainers = map[string]bool{
+	"block":              true, // Go, Python
+	"statement_block":    true, // TypeScript
+	"expression_case":    true, // Go switch
+	"type_case":          true,
+	"communication_case": true, // Go select
+	"default_case":       true,
+	"switch_case":        true, // TypeScript switch
+	"switch_default":     true,
+}
+
+// nestedScopes are the nodes a break or return inside a function body cannot
+// leave
+var nestedScopes = map[string]bool{
+	"func_literal":                   true,
+	"function_declaration":           true,
+	"function_expression":            true,
+	"arrow_function":                 true,
+	"method_definition":              true,
+	"generator_function_declaration": true,
+	"generator_function":             true,
+	"class_declaration":              true,
+	"class":                          true,
+	"function_definition":            true,
+	"lambda":                         true,
+	"class_definition":               true,
+}
+
+// loopStatements are the loops an unlabeled break leaves
+var loopStatements = map[string]bool{
+	"for_statement":       true,
+	"for_in_statement":    true,
+	"while_statement":     true,
+	"do_statement":        true,
+	"for_range_statement": true,
+}
+
+// switchStatements are the statements an unlabeled break leaves besides loops
+var switchStatements = map[string]bool{
+	"expression_switch_statement": true,
+	"type_switch_statement":       true,
+	"select_statement":            true,
+	"switch_statement":            true,
+}
+
+// detectControlFlowIssues records ControlFlowIssue entities for two
+// correctness problems inside functions:
+//
+//   - missing returns: a TypeScript or Python function declares a return
+//     type other than void or None, yet a path reaches the end of its body.
+//     Go is not checked, the compiler rejects such functions.
+//   - unreachable code: a statement follows a return, throw, raise, panic,
+//     exit call, break, continue, or a statement such as an if/else whose
+//     every branch ends in one. Only the first unreachable statement of a
+//     block is reported.
+//
+// Each issue is linked to its function by a HAS_ISSUE relationship.
+// Functions marked with an onyx:ignore comment are skipped.
+func detectControlFlowIssues(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+	switch file.Language {
+	case "go", "python", "typescript", "javascript":
+	default:
+		return nil
+	}
+
+	functions := append(append([]*entities.Entity{}, file.Functions...), file.Methods...)
+	relationships := make([]*entities.Relationship, 0)
+	add := func(node *ts.Node, issue string, line int, after, message string) {
+		enclosing := innermostEntity(functions, node)
+		if enclosing == nil || enclosing.IsIgnored() {
+			return
+		}
+		entity := newControlFlowIssue(file, node, issue, line, after, message, enclosing)
+		file.AddEntity(entity)
+
+		rel := entities.NewRelationship(entity.ID+":has_issue", entities.RelationshipTypeHasIssue, enclosing, entity)
+		rel.SetProperty("issue", issue)
+		rel.SetProvenance(file.Path, node, file.Content)
+		relationships = append(relationships, rel)
+	}
+
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		if statementContainers[node.Kind()] {
+			if terminator, dead := unreachableStatement(node, file.Content); dead != nil {
+				after := statementKeyword(terminator, file.Content)
+				add(dead, ControlFlowUnreachable, int(dead.StartPosition().Row)+1, after,
+					fmt.Sprintf("unreachable code after %s", after))
+			}
+			return
+		}
+		if body, returnType := valueReturningBody(node, file); body != nil && !terminates(body, file.Content) {
+			line := int(body.EndPosition().Row) + 1
+			add(body, ControlFlowMissingReturn, line, "",
+				fmt.Sprintf("missing return: end of function reached without returning %s", returnType))
+		}
+	})
+	return relationships
+}
+
+// statements returns the statements of a container in order, leaving out
+// comments and the values a case matches
+func statements(container *ts.Node) []*ts.Node {
+	list := make([]*ts.Node, 0, container.NamedChildCount())
+	for i := uint(0); i < container.NamedChildCount(); i++ {
+		child := container.NamedChild(i)
+		field := container.FieldNameForNamedChild(uint32(i))
+		if child.Kind() == "comment" || (field != "" && field != "body") {
+			continue
+		}
+		list = append(list, child)
+	}
+	return list
+}
+
+// unreachableStatement returns the first statement of a container that
+// follows one that never completes normally, together with that statement
+func unreachableStatement(container *ts.Node, content []byte) (*ts.Node, *ts.Node) {
+	var terminator *ts.Node
+	for _, statement := range statements(container) {
+		if terminator != nil {
+			switch statement.Kind() {
+			case "empty_statement", "labeled_statement", "function_declaration",
+				"type_alias_declaration", "interface_declaration":
+				// Empty statements do nothing, labels can be jumped to and
+				// declarations are hoisted
+				continue
+			}
+			return terminator, statement
+		}
+		switch statement.Kind() {
+		case "break_statement", "continue_statement":
+			terminator = statement
+		default:
+			if terminates(statement, content) {
+				terminator = statement
+			}
+		}
+	}
+	return nil, nil
+}
+
+// terminates reports whether a statement never completes normally: it
+// returns, throws, exits or loops forever on every path
+func terminates(node *ts.Node, content []byte) bool {
+	switch node.Kind() {
+	case "return_statement", "throw_statement", "raise_statement", "goto_statement":
+		return true
+	case "expression_statement":
+		call := node.NamedChild(0)
+		if call == nil || (call.Kind() != "call_expression" && call.Kind() != "call") {
+			return false
+		}
+		function := call.ChildByFieldName("function")
+		return function != nil && exitCalls[strings.Join(strings.Fields(function.Utf8Text(content)), "")]
+	case "block", "statement_block", "expression_case", "type_case", "communication_case",
+		"default_case", "switch_case", "switch_default":
+		for _, statement := range statements(node) {
+			if terminates(statement, content) {
+				return true
+			}
+			if kind := statement.Kind(); kind == "break_statement" || kind == "continue_statement" {
+				return false
+			}
+		}
+		return false
+	case "labeled_statement":
+		for i := node.NamedChildCount(); i > 0; i-- {
+			if statement := node.NamedChild(i - 1); statement.Kind() != "label_name" && statement.Kind() != "statement_identifier" {
+				return terminates(statement, content) && !breaksOut(statement)
+			}
+		}
+		return false
+	case "else_clause":
+		// TypeScript wraps the else branch, Python names it body
+		if body := node.ChildByFieldName("body"); body != nil {
+			return terminates(body, content)
+		}
+		return node.NamedChildCount() > 0 && terminates(node.NamedChild(node.NamedChildCount()-1), content)
+	case "elif_clause":
+		consequence := node.ChildByFieldName("consequence")
+		return consequence != nil && terminates(consequence, content)
+	case "if_statement":
+		return ifTerminates(node, content)
+	case "expression_switch_statement", "type_switch_statement", "select_statement", "switch_statement":
+		return switchTerminates(node, content)
+	case "match_statement":
+		return matchTerminates(node, content)
+	case "try_statement":
+		return tryTerminates(node, content)
+	case "with_statement":
+		body := node.ChildByFieldName("body")
+		return body != nil && terminates(body, content)
+	case "for_statement", "while_statement", "do_statement":
+		return infiniteLoop(node, content) && !breaksOut(node.ChildByFieldName("body"))
+	}
+	return false
+}
+
+// ifTerminates reports whether an if statement has an else branch and every
+// branch terminates
+func ifTerminates(node *ts.Node, content []byte) bool {
+	consequence := node.ChildByFieldName("consequence")
+	if consequence == nil || !terminates(consequence, content) {
+		return false
+	}
+	hasElse := false
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		if node.FieldNameForNamedChild(uint32(i)) != "alternative" {
+			continue
+		}
+		alternative := node.NamedChild(i)
+		if !terminates(alternative, content) {
+			return false
+		}
+		// An else if, or a Python elif, still needs a final else
+		if alternative.Kind() != "elif_clause" {
+			hasElse = true
+		}
+	}
+	return hasElse
+}
+
+// switchTerminates reports whether a switch or select cannot complete: every
+// case terminates or falls through to one that does, none breaks out of it
+// and, except for a Go select, a default case catches the remaining values
+func switchTerminates(node *ts.Node, content []byte) bool {
+	cases := make([]*ts.Node, 0)
+	collect := func(parent *ts.Node) {
+		for i := uint(0); i < parent.NamedChildCount(); i++ {
+			switch child := parent.NamedChild(i); child.Kind() {
+			case "expression_case", "type_case", "communication_case", "default_case", "switch_case", "switch_default":
+				cases = append(cases, child)
+			}
+		}
+	}
+	collect(node)
+	if body := node.ChildByFieldName("body"); body != nil {
+		collect(body) // TypeScript wraps its cases in a switch_body
+	}
+
+	hasDefault := node.Kind() == "select_statement"
+	for i, c := range cases {
+		if c.Kind() == "default_case" || c.Kind() == "switch_default" {
+			hasDefault = true
+		}
+		list := statements(c)
+		if len(list) == 0 && (c.Kind() == "switch_case" || c.Kind() == "switch_default") && i < len(cases)-1 {
+			continue // an empty TypeScript case falls through to the next
+		}
+		fallsThrough := len(list) > 0 && list[len(list)-1].Kind() == "fallthrough_statement"
+		if !fallsThrough && !terminates(c, content) {
+			return false
+		}
+		if breaksOut(c) {
+			return false
+		}
+	}
+	return hasDefault && len(cases) > 0
+}
+
+// matchTerminates reports whether a Python match statement has a catch-all
+// case _ and every case terminates
+func matchTerminates(node *ts.Node, content []byte) bool {
+	body := node.ChildByFieldName("body")
+	if body == nil {
+		return false
+	}
+	catchAll := false
+	for i := uint(0); i < body.NamedChildCount(); i++ {
+		clause := body.NamedChild(i)
+		if clause.Kind() != "case_clause" {
+			continue
+		}
+		consequence := clause.ChildByFieldName("consequence")
+		if consequence == nil || !terminates(consequence, content) {
+			return false
+		}
+		if clause.ChildByFieldName("guard") == nil {
+			for j := uint(0); j < clause.NamedChildCount(); j++ {
+				if pattern := clause.NamedChild(j); pattern.Kind() == "case_pattern" && pattern.Utf8Text(content) == "_" {
+					catchAll = true
+				}
+			}
+		}
+	}
+	return catchAll
+}
+
+// tryTerminates reports whether a try statement cannot complete: its finally
+// block terminates, or its body (or Python else block) and every handler do
+func tryTerminates(node *ts.Node, content []byte) bool {
+	body := node.ChildByFieldName("body")
+	if body == nil {
+		return false
+	}
+	completes := !terminates(body, content)
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		child := node.NamedChild(i)
+		switch child.Kind() {
+		case "finally_clause":
+			if block := clauseBlock(child); block != nil && terminates(block, content) {
+				return true
+			}
+		case "else_clause":
+			if block := clauseBlock(child); block != nil && terminates(block, content) {
+				completes = false
+			}
+		case "catch_clause", "except_clause", "except_group_clause":
+			if block := clauseBlock(child); block == nil || !terminates(block, content) {
+				return false
+			}
+		}
+	}
+	return !completes
+}
+
+// clauseBlock returns the block of a catch, except, else or finally clause
+func clauseBlock(clause *ts.Node) *ts.Node {
+	if body := clause.ChildByFieldName("body"); body != nil {
+		return body
+	}
+	for i := clause.NamedChildCount(); i > 0; i-- {
+		if child := clause.NamedChild(i - 1); child.Kind() == "block" || child.Kind() == "statement_block" {
+			return child
+		}
+	}
+	return nil
+}
+
+// infiniteLoop reports whether a loop has no condition, or a condition that
+// is literally true
+func infiniteLoop(node *ts.Node, content []byte) bool {
+	condition := node.ChildByFieldName("condition")
+	if node.Kind() == "for_statement" && condition == nil {
+		// Go leaves its loop condition unnamed: for cond {}, for init; cond; post {}.
+		// A Python for loop names its target and iterable instead.
+		for i := uint(0); i < node.NamedChildCount(); i++ {
+			child := node.NamedChild(i)
+			field := node.FieldNameForNamedChild(uint32(i))
+			switch {
+			case child.Kind() == "for_clause":
+				return child.ChildByFieldName("condition") == nil
+			case child.Kind() != "comment" && field != "body":
+				return false
+			}
+		}
+		return true
+	}
+	if node.Kind() == "for_statement" && condition.Kind() == "empty_statement" {
+		return true
+	}
+	if condition == nil {
+		return false
+	}
+	text := strings.Trim(strings.TrimSpace(condition.Utf8Text(content)), "();")
+	return text == "true" || text == "True" || text == "1"
+}
+
+// breaksOut reports whether a statement contains a break that leaves it: an
+// unlabeled break outside any nested loop or switch, or any labeled break,
+// which may target an enclosing statement
+func breaksOut(node *ts.Node) bool {
+	if node == nil {
+		return false
+	}
+	found := false
+	var visit func(n *ts.Node, nested bool)
+	visit = func(n *ts.Node, nested bool) {
+		if found || nestedScopes[n.Kind()] {
+			return
+		}
+		if n.Kind() == "break_statement" {
+			if !nested || n.NamedChildCount() > 0 {
+				found = true
+			}
+			return
+		}
+		inner := nested || loopStatements[n.Kind()] || switchStatements[n.Kind()]
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			visit(n.NamedChild(i), inner && n != node)
+		}
+	}
+	visit(node, false)
+	return found
+}
+
+// valueReturningBody returns the body of a TypeScript or Python function
+// declared to return a value, with the declared type. Functions returning
+// void, undefined, None or an Optional, generators and Python stubs whose
+// body is only a docstring, pass or ... return nil.
+func valueReturningBody(node *ts.Node, file *entities.File) (*ts.Node, string) {
+	switch node.Kind() {
+	case "function_declaration", "function_expression", "method_definition", "arrow_function":
+		annotation := node.ChildByFieldName("return_type")
+		body := node.ChildByFieldName("body")
+		if annotation == nil || body == nil || body.Kind() != "statement_block" {
+			return nil, ""
+		}
+		returnType := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(annotation.Utf8Text(file.Content)), ":"))
+		if !typeScriptReturnsValue(returnType) {
+			return nil, ""
+		}
+		return body, returnType
+	case "function_definition":
+		annotation := node.ChildByFieldName("return_type")
+		body := node.ChildByFieldName("body")
+		if annotation == nil || body == nil {
+			return nil, ""
+		}
+		returnType := strings.TrimSpace(annotation.Utf8Text(file.Content))
+		if !pythonReturnsValue(returnType) || isPythonStub(body, file.Content) || yields(body) {
+			return nil, ""
+		}
+		return body, returnType
+	}
+	return nil, ""
+}
+
+// typeScriptReturnsValue reports whether a TypeScript return type requires
+// an explicit return
+func typeScriptReturnsValue(returnType string) bool {
+	compact := strings.Join(strings.Fields(returnType), "")
+	switch compact {
+	case "void", "undefined", "never", "any", "unknown", "Promise<void>", "Promise<undefined>", "Promise<any>":
+		return false
+	}
+	if strings.HasPrefix(compact, "asserts") || strings.HasPrefix(compact, "Generator") ||
+		strings.HasPrefix(compact, "AsyncGenerator") || strings.HasPrefix(compact, "Iterable") {
+		return false
+	}
+	for _, member := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(compact, "Promise<"), ">"), "|") {
+		if member == "void" || member == "undefined" {
+			return false
+		}
+	}
+	return true
+}
+
+// pythonReturnsValue reports whether a Python return annotation requires an
+// explicit return
+func pythonReturnsValue(returnType string) bool {
+	compact := strings.Trim(strings.Join(strings.Fields(returnType), ""), "\"'")
+	compact = strings.ReplaceAll(compact, "typing.", "")
+	for _, prefix := range []string{"Optional[", "Iterator[", "Generator[", "Iterable[",
+		"AsyncIterator[", "AsyncGenerator[", "AsyncIterable["} {
+		if strings.HasPrefix(compact, prefix) {
+			return false
+		}
+	}
+	switch compact {
+	case "None", "NoReturn", "Never", "Any", "object":
+		return false
+	}
+	for _, member := range strings.Split(compact, "|") {
+		if member == "None" {
+			return false
+		}
+	}
+	return true
+}
+
+// isPythonStub reports whether a function body is only a docstring, pass or
+// ..., as in protocols, abstract methods and overloads
+func isPythonStub(body *ts.Node, content []byte) bool {
+	for _, statement := range statements(body) {
+		switch statement.Kind() {
+		case "pass_statement":
+			continue
+		case "expression_statement":
+			if value := statement.NamedChild(0); value != nil && (value.Kind() == "string" || value.Kind() == "ellipsis") {
+				continue
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// yields reports whether a function body contains a yield of its own, which
+// makes it a generator
+func yields(body *ts.Node) bool {
+	found := false
+	var visit func(n *ts.Node)
+	visit = func(n *ts.Node) {
+		if found || nestedScopes[n.Kind()] {
+			return
+		}
+		if n.Kind() == "yield" {
+			found = true
+			return
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			visit(n.NamedChild(i))
+		}
+	}
+	visit(body)
+	return found
+}
+
+// statementKeyword names the statement that ends a path: its keyword, such
+// as return or if, or the function an exit call calls, such as os.Exit
+func statementKeyword(statement *ts.Node, content []byte) string {
+	if statement.Kind() == "expression_statement" {
+		if call := statement.NamedChild(0); call != nil {
+			if function := call.ChildByFieldName("function"); function != nil {
+				return strings.Join(strings.Fields(function.Utf8Text(content)), "")
+			}
+		}
+	}
+	fields := strings.FieldsFunc(statement.Utf8Text(content), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '(' || r == '{' || r == ':' || r == ';'
+	})
+	if len(fields) == 0 {
+		return statement.Kind()
+	}
+	return fields[0]
+}
+
+// newControlFlowIssue builds the ControlFlowIssue entity for a node: the
+// unreachable statement or the body that lacks a return
+func newControlFlowIssue(file *entities.File, node *ts.Node, issue string, line int, after, message string, enclosing *entities.Entity) *entities.Entity {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("control_flow:%s:%s:%d", issue, file.Path, node.StartByte())))
+	name := enclosing.GetFullName()
+	if issue == ControlFlowUnreachable {
+		name = strings.TrimSpace(strings.SplitN(node.Utf8Text(file.Content), "\n", 2)[0])
+	}
+
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), name, entities.EntityTypeControlFlowIssue, file.Path, node)
+	entity.SetProperty("issue", issue)
+	entity.SetProperty("line", line)
+	entity.SetProperty("message", message)
+	entity.SetProperty("language", file.Language)
+	if after != "" {
+		entity.SetProperty("after", after)
+	}
+	entity.SetProperty("enclosing_function", enclosing.ID)
+	entity.SetProperty("enclosing_function_name", enclosing.GetFullName())
+	return entity
+}
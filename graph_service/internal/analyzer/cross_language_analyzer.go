@@ -339,6 +339,10 @@ func (cla *CrossLanguageAnalyzer) detectAPICallPatterns() {
 	fmt.Println("Detecting API call patterns...")
 
 	for filePath, file := range cla.allFiles {
+		if file.Language == "typescript" {
+			cla.detectTypeScriptAPICalls(filePath, file)
+			continue
+		}
 		content := string(file.Content)
 
 		patterns := []*regexp.Regexp{
@@ -372,6 +376,39 @@ func (cla *CrossLanguageAnalyzer) detectAPICallPatterns() {
 	}
 }
 
+// detectTypeScriptAPICalls records the API calls the TypeScript analyzer
+// found, whose URLs are resolved through constants, template literals and
+// HTTP client base URLs
+func (cla *CrossLanguageAnalyzer) detectTypeScriptAPICalls(filePath string, file *entities.File) {
+	for _, entity := range file.GetEntitiesByType(entities.EntityTypeAPICall) {
+		pattern, _ := entity.GetProperty("url_pattern").(string)
+		method, _ := entity.GetProperty("method").(string)
+		if pattern == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s:%s", file.Language, method, pattern)
+		cla.apiCalls[key] = &APICallInfo{
+			Method:   method,
+			Target:   pattern,
+			Language: file.Language,
+			File:     filePath,
+		}
+	}
+}
+
+// matchProvenance records why a cross-language relationship was inferred.
+// These relationships come from matching names or paths across files rather
+// than from one syntax node, so the line is 0 when it is not known.
+func matchProvenance(kind, text, filePath string, line int) *entities.Provenance {
+	return &entities.Provenance{
+		NodeKind:    kind,
+		MatchedText: text,
+		FilePath:    filePath,
+		Line:        uint32(line),
+	}
+}
+
 // buildCrossLanguageRelationships creates relationships between languages
 func (cla *CrossLanguageAnalyzer) buildCrossLanguageRelationships() {
 	fmt.Println("Building cross-language relationships...")
@@ -379,29 +416,41 @@ func (cla *CrossLanguageAnalyzer) buildCrossLanguageRelationships() {
 	// Match API calls with endpoints
 	for callKey, apiCall := range cla.apiCalls {
 		for endpointKey, endpoint := range cla.httpEndpoints {
-			if cla.pathsMatch(apiCall.Target, endpoint.Path) && apiCall.Language != endpoint.Language {
-				// Create cross-language relationship
-				relID := fmt.Sprintf("cross_api_%s_%s", callKey, endpointKey)
-				relationship := entities.NewRelationshipByID(
-					relID,
-					entities.RelationshipTypeCalls,
-					callKey,     // Using call key as source ID
-					endpointKey, // Using endpoint key as target ID
-					entities.EntityTypeAPICall, // Source is an API call
-					entities.EntityTypeEndpoint, // Target is an API endpoint
-				)
-				relationship.SetProperty("cross_language", true)
-				relationship.SetProperty("api_method", apiCall.Method)
-				relationship.SetProperty("api_path", apiCall.Target)
-				relationship.SetProperty("source_language", apiCall.Language)
-				relationship.SetProperty("target_language", endpoint.Language)
-
-				cla.crossReferences = append(cla.crossReferences, relationship)
+			if apiCall.Language == endpoint.Language || !methodsMatch(apiCall.Method, endpoint.Method) ||
+				!cla.pathsMatch(apiCall.Target, endpoint.Path) {
+				continue
 			}
+			// Create cross-language relationship
+			relID := fmt.Sprintf("cross_api_%s_%s", callKey, endpointKey)
+			relationship := entities.NewRelationshipByID(
+				relID,
+				entities.RelationshipTypeCalls,
+				callKey,     // Using call key as source ID
+				endpointKey, // Using endpoint key as target ID
+				entities.EntityTypeAPICall, // Source is an API call
+				entities.EntityTypeEndpoint, // Target is an API endpoint
+			)
+			relationship.SetProperty("cross_language", true)
+			relationship.SetProperty("api_method", apiCall.Method)
+			relationship.SetProperty("api_path", apiCall.Target)
+			relationship.SetProperty("source_language", apiCall.Language)
+			relationship.SetProperty("target_language", endpoint.Language)
+			relationship.Provenance = matchProvenance("api_path",
+				strings.TrimSpace(apiCall.Method+" "+apiCall.Target), apiCall.File, 0)
+
+			cla.crossReferences = append(cla.crossReferences, relationship)
 		}
 	}
 }
 
+// methodsMatch reports whether an API call can reach an endpoint by its HTTP
+// method. A method that is not known, such as that of an http.HandleFunc
+// endpoint serving all of them, matches any other.
+func methodsMatch(callMethod, endpointMethod string) bool {
+	known := func(method string) bool { return method != "" && method != "ANY" }
+	return !known(callMethod) || !known(endpointMethod) || strings.EqualFold(callMethod, endpointMethod)
+}
+
 // pathsMatch checks if API call path matches endpoint path
 func (cla *CrossLanguageAnalyzer) pathsMatch(callPath, endpointPath string) bool {
 	// Extract path from full URL
@@ -416,9 +465,38 @@ func (cla *CrossLanguageAnalyzer) pathsMatch(callPath, endpointPath string) bool
 		callPath = callPath[:idx]
 	}
 
-	return callPath == endpointPath ||
-		strings.HasSuffix(callPath, endpointPath) ||
-		strings.Contains(callPath, endpointPath)
+	return callPath == endpointPath || segmentsMatch(callPath, endpointPath)
+}
+
+// segmentsMatch reports whether the end of a call path matches an endpoint
+// path segment by segment, so /api/v1/users/42 matches /users/{id}. Only the
+// path parameters of the endpoint (:id, {id}, <int:id>) match any segment;
+// those of the call are values the analyzer could not resolve and match
+// only a parameter. At least one literal segment must match, so that
+// /{id} does not match every call.
+func segmentsMatch(callPath, endpointPath string) bool {
+	isParam := func(segment string) bool {
+		return strings.HasPrefix(segment, ":") ||
+			strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") ||
+			strings.HasPrefix(segment, "<") && strings.HasSuffix(segment, ">")
+	}
+	calls := strings.FieldsFunc(callPath, func(r rune) bool { return r == '/' })
+	endpoints := strings.FieldsFunc(endpointPath, func(r rune) bool { return r == '/' })
+	if len(endpoints) == 0 || len(endpoints) > len(calls) {
+		return false
+	}
+	calls = calls[len(calls)-len(endpoints):]
+	literal := false
+	for i, segment := range endpoints {
+		switch {
+		case isParam(segment):
+		case segment == calls[i]:
+			literal = true
+		default:
+			return false
+		}
+	}
+	return literal
 }
 
 // buildTestCoverageRelationships builds relationships between tests and code across languages
@@ -452,6 +530,7 @@ func (cla *CrossLanguageAnalyzer) buildTestCoverageRelationships() {
 						entity.Type,
 					)
 					rel.SetProperty("cross_language", true)
+					rel.Provenance = matchProvenance("test_name", test.Name, test.FilePath, test.StartLine())
 					cla.crossReferences = append(cla.crossReferences, rel)
 					testedEntities[entity.ID] = true
 				}
@@ -465,7 +544,7 @@ func (cla *CrossLanguageAnalyzer) buildTestCoverageRelationships() {
 				// Match API call to endpoint
 				for _, endpoint := range cla.httpEndpoints {
 					if cla.pathsMatch(apiCall.Target, endpoint.Path) && 
-					   strings.EqualFold(apiCall.Method, endpoint.Method) {
+					   methodsMatch(apiCall.Method, endpoint.Method) {
 						// Create cross-language test relationship
 						for _, entity := range cla.allEntities {
 							if entity.FilePath == endpoint.File {
@@ -480,6 +559,8 @@ func (cla *CrossLanguageAnalyzer) buildTestCoverageRelationships() {
 								rel.SetProperty("endpoint", endpoint.Path)
 								rel.SetProperty("method", endpoint.Method)
 								rel.SetProperty("cross_language", true)
+								rel.Provenance = matchProvenance("api_path",
+									strings.TrimSpace(apiCall.Method+" "+apiCall.Target), apiCall.File, 0)
 								cla.crossReferences = append(cla.crossReferences, rel)
 								testedEntities[entity.ID] = true
 							}
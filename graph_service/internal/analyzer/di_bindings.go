@@ -0,0 +1,401 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Frameworks, or idioms, through which a binding is declared
+const (
+	BindingWire        = "wire"        // wire.Bind(new(Iface), new(*Impl))
+	BindingFx          = "fx"          // fx.Annotate(NewImpl, fx.As(new(Iface)))
+	BindingConstructor = "constructor" // func NewX() Iface { return &Impl{} }
+	BindingVariable    = "variable"    // var x Iface = &Impl{}
+	BindingProvider    = "provider"    // Angular/NestJS { provide: Iface, useClass: Impl }
+	BindingInversify   = "inversify"   // container.bind<Iface>(TYPES.X).to(Impl)
+	BindingInjector    = "injector"    // Python injector: binder.bind(Iface, to=Impl)
+)
+
+// bindingName matches the type and token names a binding can be resolved by;
+// factories, values and other expressions do not
+var bindingName = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+// diBinding is a place where an interface, or an injection token, is bound
+// to the concrete type that implements it at runtime
+type diBinding struct {
+	node           *ts.Node
+	iface, impl    string
+	framework      string
+	implIsFunction bool // impl names a constructor rather than a type
+}
+
+// detectBindings records the dependency-injection bindings of a file as
+// Binding entities and returns their relationships: PROVIDES to the bound
+// interface and BOUND_TO to the implementation, both by name, to be resolved
+// with the other relationships of the file. Bindings inside functions marked
+// with an onyx:ignore comment are skipped.
+func detectBindings(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+
+	var bindings []diBinding
+	switch file.Language {
+	case "go":
+		bindings = goBindings(file)
+	case "typescript":
+		bindings = tsBindings(file)
+	case "python":
+		bindings = pythonBindings(file)
+	}
+
+	functions := append(append([]*entities.Entity{}, file.Functions...), file.Methods...)
+	relationships := make([]*entities.Relationship, 0, 2*len(bindings))
+	for _, b := range bindings {
+		if !bindingName.MatchString(b.iface) || !bindingName.MatchString(b.impl) || b.iface == b.impl {
+			continue
+		}
+		enclosing := innermostEntity(functions, b.node)
+		if enclosing != nil && enclosing.IsIgnored() {
+			continue
+		}
+		entity := newBinding(file, b, enclosing)
+		file.AddEntity(entity)
+
+		provides := entities.NewRelationshipByID(entity.ID+":provides", entities.RelationshipTypeProvides,
+			entity.ID, b.iface, entities.EntityTypeBinding, entities.EntityTypeInterface)
+		implType := entities.EntityTypeStruct
+		if b.implIsFunction {
+			implType = entities.EntityTypeFunction
+		} else if file.Language != "go" {
+			implType = entities.EntityTypeClass
+		}
+		boundTo := entities.NewRelationshipByID(entity.ID+":bound_to", entities.RelationshipTypeBoundTo,
+			entity.ID, b.impl, entities.EntityTypeBinding, implType)
+		for _, rel := range []*entities.Relationship{provides, boundTo} {
+			rel.SetProperty("framework", b.framework)
+			rel.SetProvenance(file.Path, b.node, file.Content)
+			relationships = append(relationships, rel)
+		}
+	}
+	return relationships
+}
+
+// newBinding creates the Binding entity of a binding site. A site can bind
+// several pairs, as a wire.NewSet or fx.Provide call does, so the ID covers
+// the interface and implementation as well as the position.
+func newBinding(file *entities.File, b diBinding, enclosing *entities.Entity) *entities.Entity {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("binding:%s:%d:%s:%s", file.Path, b.node.StartByte(), b.iface, b.impl)))
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), b.iface+" -> "+b.impl,
+		entities.EntityTypeBinding, file.Path, b.node)
+	entity.Signature = strings.Join(strings.Fields(b.node.Utf8Text(file.Content)), " ")
+	entity.SetProperty("interface", b.iface)
+	entity.SetProperty("implementation", b.impl)
+	entity.SetProperty("framework", b.framework)
+	entity.SetProperty("language", file.Language)
+	if enclosing != nil {
+		entity.SetProperty("enclosing_function", enclosing.ID)
+		entity.SetProperty("enclosing_function_name", enclosing.GetFullName())
+	}
+	return entity
+}
+
+// bindingTypeName reduces a type or token expression to the name it is
+// declared under: *pkg.Impl, &Impl, TYPES.Logger and Repo<User> become Impl,
+// Impl, Logger and Repo
+func bindingTypeName(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexAny(text, "[<("); i > 0 {
+		text = text[:i]
+	}
+	text = strings.TrimLeft(text, "*&")
+	if i := strings.LastIndex(text, "."); i >= 0 {
+		text = text[i+1:]
+	}
+	return strings.TrimSpace(text)
+}
+
+// goBindings finds wire and fx bindings, constructors returning an interface
+// they implement with a concrete value, and interface-typed variables
+// initialized with one
+func goBindings(file *entities.File) []diBinding {
+	content := file.Content
+	bindings := make([]diBinding, 0)
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		switch node.Kind() {
+		case "call_expression":
+			function := node.ChildByFieldName("function")
+			args := node.ChildByFieldName("arguments")
+			if function == nil || args == nil {
+				return
+			}
+			switch function.Utf8Text(content) {
+			case "wire.Bind":
+				if args.NamedChildCount() == 2 {
+					bindings = append(bindings, diBinding{
+						node:      node,
+						iface:     bindingTypeName(goNewType(args.NamedChild(0), content)),
+						impl:      bindingTypeName(goNewType(args.NamedChild(1), content)),
+						framework: BindingWire,
+					})
+				}
+			case "fx.Annotate":
+				if args.NamedChildCount() < 2 {
+					return
+				}
+				constructor := bindingTypeName(args.NamedChild(0).Utf8Text(content))
+				impl, isFunction := goConstructorResult(file, constructor), false
+				if impl == "" {
+					impl, isFunction = constructor, true
+				}
+				for i := uint(1); i < args.NamedChildCount(); i++ {
+					as := args.NamedChild(i)
+					asFunction := as.ChildByFieldName("function")
+					asArgs := as.ChildByFieldName("arguments")
+					if as.Kind() != "call_expression" || asFunction == nil || asArgs == nil ||
+						asFunction.Utf8Text(content) != "fx.As" {
+						continue
+					}
+					for j := uint(0); j < asArgs.NamedChildCount(); j++ {
+						bindings = append(bindings, diBinding{
+							node:           node,
+							iface:          bindingTypeName(goNewType(asArgs.NamedChild(j), content)),
+							impl:           impl,
+							framework:      BindingFx,
+							implIsFunction: isFunction,
+						})
+					}
+				}
+			}
+
+		case "function_declaration", "method_declaration":
+			iface := goInterfaceResult(node, content)
+			body := node.ChildByFieldName("body")
+			if iface == "" || body == nil {
+				return
+			}
+			seen := make(map[string]bool)
+			walkTree(body, func(n *ts.Node) {
+				if n.Kind() != "return_statement" || hasAncestor(n, "func_literal") || n.NamedChildCount() == 0 {
+					return
+				}
+				values := n.NamedChild(0)
+				if values.Kind() == "expression_list" && values.NamedChildCount() > 0 {
+					values = values.NamedChild(0)
+				}
+				impl := bindingTypeName(goCompositeType(values, content))
+				if impl != "" && impl != iface && !seen[impl] {
+					seen[impl] = true
+					bindings = append(bindings, diBinding{node: n, iface: iface, impl: impl, framework: BindingConstructor})
+				}
+			})
+
+		case "var_spec":
+			name := node.ChildByFieldName("name")
+			typ := node.ChildByFieldName("type")
+			value := node.ChildByFieldName("value")
+			if name == nil || name.Utf8Text(content) == "_" || typ == nil || value == nil ||
+				(typ.Kind() != "type_identifier" && typ.Kind() != "qualified_type") || value.NamedChildCount() != 1 {
+				return
+			}
+			iface := bindingTypeName(typ.Utf8Text(content))
+			impl := bindingTypeName(goCompositeType(value.NamedChild(0), content))
+			if impl != "" && !goPredeclaredTypes[iface] {
+				bindings = append(bindings, diBinding{node: node, iface: iface, impl: impl, framework: BindingVariable})
+			}
+		}
+	})
+	return bindings
+}
+
+// goNewType returns the type T of a new(T) expression
+func goNewType(node *ts.Node, content []byte) string {
+	if node.Kind() != "call_expression" {
+		return ""
+	}
+	function := node.ChildByFieldName("function")
+	args := node.ChildByFieldName("arguments")
+	if function == nil || args == nil || function.Utf8Text(content) != "new" || args.NamedChildCount() != 1 {
+		return ""
+	}
+	return args.NamedChild(0).Utf8Text(content)
+}
+
+// goCompositeType returns the type of a composite literal value such as
+// &Impl{} or pkg.Impl{}
+func goCompositeType(node *ts.Node, content []byte) string {
+	if node.Kind() == "unary_expression" {
+		if operand := node.ChildByFieldName("operand"); operand != nil {
+			node = operand
+		}
+	}
+	if node.Kind() != "composite_literal" {
+		return ""
+	}
+	if typ := node.ChildByFieldName("type"); typ != nil {
+		return typ.Utf8Text(content)
+	}
+	return ""
+}
+
+// goInterfaceResult returns the first result type of a function when it may
+// be an interface: a named, non-pointer type other than a predeclared one.
+// Returning a composite literal of another type from such a function is only
+// valid Go when the result is an interface.
+func goInterfaceResult(function *ts.Node, content []byte) string {
+	result := function.ChildByFieldName("result")
+	if result == nil {
+		return ""
+	}
+	if result.Kind() == "parameter_list" {
+		if result.NamedChildCount() == 0 {
+			return ""
+		}
+		result = result.NamedChild(0).ChildByFieldName("type")
+		if result == nil {
+			return ""
+		}
+	}
+	if result.Kind() != "type_identifier" && result.Kind() != "qualified_type" {
+		return ""
+	}
+	name := bindingTypeName(result.Utf8Text(content))
+	if goPredeclaredTypes[name] {
+		return ""
+	}
+	return name
+}
+
+// goConstructorResult returns the concrete type built by a constructor
+// declared in the file, such as Impl for func NewImpl() *Impl
+func goConstructorResult(file *entities.File, constructor string) string {
+	for _, function := range file.Functions {
+		if function.Name != constructor || function.Node == nil {
+			continue
+		}
+		result := function.Node.ChildByFieldName("result")
+		if result == nil {
+			return ""
+		}
+		if result.Kind() == "parameter_list" {
+			if result.NamedChildCount() == 0 {
+				return ""
+			}
+			if result = result.NamedChild(0).ChildByFieldName("type"); result == nil {
+				return ""
+			}
+		}
+		return bindingTypeName(result.Utf8Text(file.Content))
+	}
+	return ""
+}
+
+// tsBindings finds Angular and NestJS provider objects binding a token to a
+// class, and InversifyJS container.bind(...).to(...) chains
+func tsBindings(file *entities.File) []diBinding {
+	content := file.Content
+	bindings := make([]diBinding, 0)
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		switch node.Kind() {
+		case "object":
+			var iface, impl string
+			for i := uint(0); i < node.NamedChildCount(); i++ {
+				pair := node.NamedChild(i)
+				key := pair.ChildByFieldName("key")
+				value := pair.ChildByFieldName("value")
+				if pair.Kind() != "pair" || key == nil || value == nil {
+					continue
+				}
+				switch key.Utf8Text(content) {
+				case "provide":
+					iface = bindingTypeName(value.Utf8Text(content))
+				case "useClass", "useExisting":
+					impl = bindingTypeName(value.Utf8Text(content))
+				}
+			}
+			if iface != "" && impl != "" {
+				bindings = append(bindings, diBinding{node: node, iface: iface, impl: impl, framework: BindingProvider})
+			}
+
+		case "call_expression":
+			function := node.ChildByFieldName("function")
+			args := node.ChildByFieldName("arguments")
+			if function == nil || args == nil || function.Kind() != "member_expression" || args.NamedChildCount() == 0 {
+				return
+			}
+			property := function.ChildByFieldName("property")
+			bind := function.ChildByFieldName("object")
+			if property == nil || property.Utf8Text(content) != "to" || bind == nil || bind.Kind() != "call_expression" {
+				return
+			}
+			bindFunction := bind.ChildByFieldName("function")
+			if bindFunction == nil || bindFunction.Kind() != "member_expression" ||
+				!strings.HasSuffix(bindFunction.Utf8Text(content), ".bind") {
+				return
+			}
+			// The type argument names the interface; without one, the
+			// service identifier is the best name available
+			iface := ""
+			if typeArgs := bind.ChildByFieldName("type_arguments"); typeArgs != nil && typeArgs.NamedChildCount() > 0 {
+				iface = typeArgs.NamedChild(0).Utf8Text(content)
+			} else if bindArgs := bind.ChildByFieldName("arguments"); bindArgs != nil && bindArgs.NamedChildCount() > 0 {
+				iface = bindArgs.NamedChild(0).Utf8Text(content)
+			}
+			bindings = append(bindings, diBinding{
+				node:      node,
+				iface:     bindingTypeName(iface),
+				impl:      bindingTypeName(args.NamedChild(0).Utf8Text(content)),
+				framework: BindingInversify,
+			})
+		}
+	})
+	return bindings
+}
+
+// pythonBindings finds injector bindings: binder.bind(Iface, to=Impl)
+func pythonBindings(file *entities.File) []diBinding {
+	content := file.Content
+	bindings := make([]diBinding, 0)
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		if node.Kind() != "call" {
+			return
+		}
+		function := node.ChildByFieldName("function")
+		args := node.ChildByFieldName("arguments")
+		if function == nil || args == nil || function.Kind() != "attribute" {
+			return
+		}
+		if attribute := function.ChildByFieldName("attribute"); attribute == nil || attribute.Utf8Text(content) != "bind" {
+			return
+		}
+		var iface, impl string
+		for i := uint(0); i < args.NamedChildCount(); i++ {
+			arg := args.NamedChild(i)
+			if arg.Kind() != "keyword_argument" {
+				if iface == "" {
+					iface = arg.Utf8Text(content)
+				}
+				continue
+			}
+			if name := arg.ChildByFieldName("name"); name != nil && name.Utf8Text(content) == "to" {
+				if value := arg.ChildByFieldName("value"); value != nil {
+					impl = value.Utf8Text(content)
+				}
+			}
+		}
+		bindings = append(bindings, diBinding{
+			node:      node,
+			iface:     bindingTypeName(iface),
+			impl:      bindingTypeName(impl),
+			framework: BindingInjector,
+		})
+	})
+	return bindings
+}
@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Links to other declarations in doc comments: Go doc links such as
+// [pkg.Func] or [Type.Method], JSDoc {@link X} tags, and Sphinx roles such
+// as :func:`module.func` in Python docstrings
+var (
+	goDocLink     = regexp.MustCompile(`\[\*?([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*){0,2})\]`)
+	jsDocLink     = regexp.MustCompile(`\{@link(?:code|plain)?\s+([^\s|}]+)[^}]*\}`)
+	pythonDocRole = regexp.MustCompile("(?::py)?:(?:func|meth|class|attr|exc|data|obj|const):`([^`]+)`")
+)
+
+// docLinkBuiltins are names doc comments link to that are part of the
+// language rather than the repository
+var docLinkBuiltins = map[string]map[string]bool{
+	"python": {
+		"str": true, "int": true, "float": true, "bool": true, "bytes": true, "list": true,
+		"dict": true, "set": true, "tuple": true, "object": true, "None": true, "True": true,
+		"False": true, "type": true, "Exception": true, "ValueError": true, "TypeError": true,
+		"KeyError": true, "IndexError": true, "RuntimeError": true, "NotImplementedError": true,
+	},
+	"typescript": {
+		"Array": true, "Promise": true, "Map": true, "Set": true, "Error": true, "Date": true,
+		"Object": true, "String": true, "Number": true, "Boolean": true, "RegExp": true,
+		"JSON": true, "Math": true, "Record": true, "Partial": true, "undefined": true, "null": true,
+	},
+}
+
+// docLinkTargetTypes are the declarations a doc comment can link to
+var docLinkTargetTypes = []entities.EntityType{
+	entities.EntityTypeFunction,
+	entities.EntityTypeMethod,
+	entities.EntityTypeClass,
+	entities.EntityTypeStruct,
+	entities.EntityTypeInterface,
+	entities.EntityTypeType,
+	entities.EntityTypeEnum,
+	entities.EntityTypeVariable,
+	entities.EntityTypeProperty,
+	entities.EntityTypeEnumMember,
+}
+
+// docLink is a declaration a doc comment links to
+type docLink struct {
+	text       string // The link as written
+	name       string // Name of the declaration
+	receiver   string // Type the declaration is a member of, if any
+	importPath string // Go package qualifying the link, if any
+	qualifier  string // First name of a qualified Python or TypeScript link
+	offset     int    // Byte offset of the link in the comment
+}
+
+// detectDocReferences records a DOC_REFERENCES relationship from each
+// documented declaration to every declaration its documentation links to:
+// Go doc links ([Name], [Type.Method], [pkg.Name], [pkg.Type.Method]),
+// JSDoc {@link X}, {@linkcode X} and {@linkplain X} tags, and the :func:,
+// :meth:, :class:, :attr:, :exc:, :data:, :obj: and :const: roles of Python
+// docstrings. Targets are names here, resolved in phase 2; links that stay
+// unresolved are broken unless they point outside the repository. Links to
+// packages, URLs and language builtins are skipped.
+func detectDocReferences(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+	switch file.Language {
+	case "go", "python", "typescript", "javascript":
+	default:
+		return nil
+	}
+
+	var goImports map[string]string
+	var imported map[string]bool
+	relationships := make([]*entities.Relationship, 0)
+	for _, entity := range file.GetAllEntities() {
+		if _, ok := documentableTypes[entity.Type]; !ok || entity.Node == nil || entity.IsIgnored() {
+			continue
+		}
+		for _, doc := range docNodes(entity.Node, file) {
+			if goImports == nil {
+				goImports = goImportNames(file)
+				_, imported = collectNameUses(file)
+			}
+			text := doc.Utf8Text(file.Content)
+			for _, link := range parseDocLinks(text, file.Language, goImports) {
+				start := doc.StartByte() + uint(link.offset)
+				hash := sha256.Sum256([]byte(fmt.Sprintf("doc_references:%s:%d", entity.ID, start)))
+				rel := entities.NewRelationshipByID(hex.EncodeToString(hash[:8]), entities.RelationshipTypeDocReferences,
+					entity.ID, link.name, entity.Type, entities.EntityTypeFunction)
+				rel.SetProperty("text", link.text)
+				rel.SetProperty("line", int(doc.StartPosition().Row)+1+strings.Count(text[:link.offset], "\n"))
+				if link.receiver != "" {
+					rel.SetProperty("receiver", link.receiver)
+				}
+				if link.importPath != "" {
+					rel.SetProperty("import_path", link.importPath)
+				}
+				if imported[link.qualifier] || (link.qualifier == "" && link.receiver == "" && imported[link.name]) {
+					rel.SetProperty("imported", true)
+				}
+				rel.SetProvenance(file.Path, doc, file.Content)
+				relationships = append(relationships, rel)
+			}
+		}
+	}
+	return relationships
+}
+
+// docNodes returns the comments documenting a declaration in Go and
+// TypeScript, a run of comments directly above it or its /** JSDoc */
+// comment, or its docstring in Python
+func docNodes(node *ts.Node, file *entities.File) []*ts.Node {
+	if file.Language == "python" {
+		if node.Kind() == "decorated_definition" {
+			node = node.ChildByFieldName("definition")
+		}
+		if node == nil || !hasDocstring(node) {
+			return nil
+		}
+		return []*ts.Node{statements(node.ChildByFieldName("body"))[0].NamedChild(0)}
+	}
+
+	for n := node; n != nil; n = n.Parent() {
+		docs := make([]*ts.Node, 0)
+		next := n
+		for previous := next.PrevSibling(); previous != nil && previous.Kind() == "comment" &&
+			previous.EndPosition().Row+1 >= next.StartPosition().Row &&
+			onlyWhitespaceBefore(file.Content, previous.StartByte()); previous = previous.PrevSibling() {
+			if file.Language != "go" {
+				if strings.HasPrefix(previous.Utf8Text(file.Content), "/**") {
+					docs = append(docs, previous)
+				}
+				break
+			}
+			docs = append([]*ts.Node{previous}, docs...)
+			next = previous
+		}
+		if len(docs) > 0 {
+			return docs
+		}
+		parent := n.Parent()
+		if parent == nil || !docWrappers[parent.Kind()] {
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseDocLinks returns the links of a doc comment in the syntax of its
+// language
+func parseDocLinks(text, language string, goImports map[string]string) []docLink {
+	links := make([]docLink, 0)
+	switch language {
+	case "go":
+		for _, m := range goDocLink.FindAllStringSubmatchIndex(text, -1) {
+			// [text]: url defines a link and [text](url) is Markdown
+			if m[1] < len(text) && (text[m[1]] == ':' || text[m[1]] == '(') {
+				continue
+			}
+			link := docLink{text: text[m[0]:m[1]], offset: m[0]}
+			parts := strings.Split(text[m[2]:m[3]], ".")
+			if importPath := goImports[parts[0]]; importPath != "" && len(parts) > 1 {
+				link.importPath = importPath
+				parts = parts[1:]
+			} else if !startsUpper(parts[0]) || len(parts) > 2 {
+				continue // A package link, or not a doc link at all
+			}
+			link.name = parts[len(parts)-1]
+			if len(parts) == 2 {
+				link.receiver = parts[0]
+			}
+			links = append(links, link)
+		}
+
+	case "python", "typescript", "javascript":
+		pattern, builtins := jsDocLink, docLinkBuiltins["typescript"]
+		if language == "python" {
+			pattern, builtins = pythonDocRole, docLinkBuiltins["python"]
+		}
+		for _, m := range pattern.FindAllStringSubmatchIndex(text, -1) {
+			target := text[m[2]:m[3]]
+			if strings.Contains(target, "://") {
+				continue
+			}
+			// :func:`title <target>` names its target in angle brackets
+			if open := strings.LastIndexByte(target, '<'); open >= 0 && strings.HasSuffix(target, ">") {
+				target = target[open+1 : len(target)-1]
+			}
+			target = strings.TrimLeft(strings.TrimSpace(target), "~!.")
+			target = strings.TrimSuffix(strings.NewReplacer("#", ".", "~", ".").Replace(target), "()")
+			parts := strings.Split(target, ".")
+			link := docLink{text: text[m[0]:m[1]], name: parts[len(parts)-1], offset: m[0]}
+			if link.name == "" || (len(parts) == 1 && builtins[link.name]) {
+				continue
+			}
+			if len(parts) > 1 {
+				link.qualifier = parts[0]
+				// A class qualifies its members; lower-case names are modules
+				if owner := parts[len(parts)-2]; startsUpper(owner) {
+					link.receiver = owner
+				}
+			}
+			links = append(links, link)
+		}
+	}
+	return links
+}
@@ -0,0 +1,508 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// pythonEnumBases are the base classes of the Python enum module; Flag and
+// IntFlag members are powers of two
+var pythonEnumBases = map[string]bool{
+	"Enum":    true,
+	"IntEnum": true,
+	"StrEnum": true,
+	"Flag":    true,
+	"IntFlag": true,
+}
+
+// enumMember is a member found in an enum declaration, before it becomes an
+// entity
+type enumMember struct {
+	node     *ts.Node
+	name     string
+	value    string
+	number   int64
+	numeric  bool
+	implicit bool
+}
+
+// detectEnumMembers records an EnumMember entity for each member of an
+// enumeration, with its value whether written or implied:
+//
+//   - TypeScript enums: members without an initializer follow the previous
+//     numeric member, starting at 0
+//   - Python Enum, IntEnum, StrEnum, Flag and IntFlag subclasses: auto()
+//     gives the next integer from 1, the next power of two for flags, or the
+//     lower-cased name for StrEnum
+//   - Go constant blocks using iota, or declaring several constants of one
+//     named type: iota is evaluated through the usual constant expressions,
+//     and a constant without a value repeats the previous expression
+//
+// Members get the "enum" name, the "value" as text, "int_value" when it is a
+// known integer, whether it is "implicit", and their "position". The enum, a
+// TypeScript Enum, a Python class or the Go named type when declared in the
+// same file, DEFINES each of its members. Go values skipped with _ are listed
+// as "reserved" on the members of their block.
+func detectEnumMembers(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+
+	relationships := make([]*entities.Relationship, 0)
+	add := func(owner *entities.Entity, enum string, members []enumMember, reserved []int64) {
+		for position, member := range members {
+			hash := sha256.Sum256([]byte(fmt.Sprintf("enum_member:%s:%s:%d", file.Path, member.name, member.node.StartByte())))
+			entity := entities.NewEntity(hex.EncodeToString(hash[:8]), member.name, entities.EntityTypeEnumMember, file.Path, member.node)
+			entity.Signature = strings.Join(strings.Fields(member.node.Utf8Text(file.Content)), " ")
+			entity.SetProperty("enum", enum)
+			entity.SetProperty("value", member.value)
+			if member.numeric {
+				entity.SetProperty("int_value", member.number)
+			}
+			entity.SetProperty("implicit", member.implicit)
+			entity.SetProperty("position", position)
+			entity.SetProperty("language", file.Language)
+			if len(reserved) > 0 {
+				entity.SetProperty("reserved", reserved)
+			}
+			file.AddEntity(entity)
+			if owner == nil {
+				continue
+			}
+			entity.SetProperty("enum_id", owner.ID)
+			owner.AddChild(entity)
+
+			rel := entities.NewRelationship(entity.ID+":defines", entities.RelationshipTypeDefines, owner, entity)
+			rel.SetProvenance(file.Path, member.node, file.Content)
+			relationships = append(relationships, rel)
+		}
+	}
+
+	switch file.Language {
+	case "go":
+		root := file.Tree.RootNode()
+		for i := uint(0); i < root.NamedChildCount(); i++ {
+			if declaration := root.NamedChild(i); declaration.Kind() == "const_declaration" {
+				typeName, members, reserved := goEnumMembers(declaration, file.Content)
+				if len(members) == 0 {
+					continue
+				}
+				owner := namedEntity(file, typeName, entities.EntityTypeClass)
+				enum := typeName
+				if enum == "" {
+					enum = members[0].name
+				}
+				add(owner, enum, members, reserved)
+			}
+		}
+	case "typescript", "javascript":
+		for _, enum := range file.GetEntitiesByType(entities.EntityTypeEnum) {
+			if enum.Node == nil {
+				continue
+			}
+			if body := enum.Node.ChildByFieldName("body"); body != nil {
+				add(enum, enum.Name, typeScriptEnumMembers(body, file.Content), nil)
+			}
+		}
+	case "python":
+		for _, class := range file.GetEntitiesByType(entities.EntityTypeClass) {
+			base := pythonEnumBase(class)
+			if base == "" || class.Node == nil {
+				continue
+			}
+			if body := class.Node.ChildByFieldName("body"); body != nil {
+				add(class, class.Name, pythonEnumMembers(body, base, file.Content), nil)
+			}
+		}
+	}
+	return relationships
+}
+
+// namedEntity returns the entity of a type declared in a file under a name,
+// or nil
+func namedEntity(file *entities.File, name string, entityType entities.EntityType) *entities.Entity {
+	if name == "" {
+		return nil
+	}
+	for _, entity := range file.GetEntitiesByType(entityType) {
+		if entity.Name == name {
+			return entity
+		}
+	}
+	return nil
+}
+
+// goEnumMembers returns the constants of a const block that enumerates
+// values, with the named type they are declared with, or none when the block
+// neither uses iota nor declares several constants of one named type. The
+// values skipped with _ are returned as reserved.
+func goEnumMembers(declaration *ts.Node, content []byte) (string, []enumMember, []int64) {
+	members := make([]enumMember, 0)
+	reserved := make([]int64, 0)
+	known := make(map[string]int64)
+	typeName, typed := "", 0
+	usesIota := false
+
+	var previousValues *ts.Node
+	var previousType string
+	iota := int64(0)
+	for i := uint(0); i < declaration.NamedChildCount(); i++ {
+		spec := declaration.NamedChild(i)
+		if spec.Kind() != "const_spec" {
+			continue
+		}
+
+		values := spec.ChildByFieldName("value")
+		specType := ""
+		if typeNode := spec.ChildByFieldName("type"); typeNode != nil {
+			specType = typeNode.Utf8Text(content)
+		}
+		implicit := values == nil
+		if implicit {
+			values, specType = previousValues, previousType
+		} else {
+			previousValues, previousType = values, specType
+		}
+		if values != nil && containsIota(values, content) {
+			usesIota = true
+		}
+		if specType != "" && !goPredeclaredTypes[specType] {
+			if typeName == "" {
+				typeName = specType
+			}
+			if specType == typeName {
+				typed++
+			}
+		}
+
+		names := make([]*ts.Node, 0)
+		for j := uint(0); j < spec.NamedChildCount(); j++ {
+			if spec.FieldNameForNamedChild(uint32(j)) == "name" {
+				names = append(names, spec.NamedChild(j))
+			}
+		}
+		for j, nameNode := range names {
+			member := enumMember{node: spec, name: nameNode.Utf8Text(content), implicit: implicit}
+			if values != nil {
+				value := values
+				if values.NamedChildCount() == uint(len(names)) {
+					value = values.NamedChild(uint(j))
+				}
+				member.number, member.numeric = evalGoConstant(value, iota, known, content)
+				member.value = strings.Join(strings.Fields(value.Utf8Text(content)), " ")
+			}
+			if member.numeric {
+				member.value = strconv.FormatInt(member.number, 10)
+			}
+			if member.name == "_" {
+				if member.numeric {
+					reserved = append(reserved, member.number)
+				}
+				continue
+			}
+			if member.numeric {
+				known[member.name] = member.number
+			}
+			members = append(members, member)
+		}
+		iota++
+	}
+
+	if !usesIota && typed < 2 {
+		return "", nil, nil
+	}
+	return typeName, members, reserved
+}
+
+// containsIota reports whether an expression uses iota
+func containsIota(node *ts.Node, content []byte) bool {
+	found := false
+	walkTree(node, func(n *ts.Node) {
+		if n.Kind() == "iota" || (n.Kind() == "identifier" && n.Utf8Text(content) == "iota") {
+			found = true
+		}
+	})
+	return found
+}
+
+// evalGoConstant evaluates an integer constant expression at a given iota,
+// with the constants of the block declared before it. Conversions such as
+// Status(iota) evaluate their argument.
+func evalGoConstant(node *ts.Node, iota int64, known map[string]int64, content []byte) (int64, bool) {
+	text := node.Utf8Text(content)
+	switch node.Kind() {
+	case "int_literal":
+		n, err := strconv.ParseInt(strings.ReplaceAll(text, "_", ""), 0, 64)
+		return n, err == nil
+	case "iota":
+		return iota, true
+	case "identifier":
+		if text == "iota" {
+			return iota, true
+		}
+		n, ok := known[text]
+		return n, ok
+	case "parenthesized_expression":
+		if node.NamedChildCount() == 1 {
+			return evalGoConstant(node.NamedChild(0), iota, known, content)
+		}
+	case "call_expression":
+		if arguments := node.ChildByFieldName("arguments"); arguments != nil && arguments.NamedChildCount() == 1 {
+			return evalGoConstant(arguments.NamedChild(0), iota, known, content)
+		}
+	case "unary_expression":
+		operand := node.ChildByFieldName("operand")
+		operator := node.ChildByFieldName("operator")
+		if operand == nil || operator == nil {
+			return 0, false
+		}
+		n, ok := evalGoConstant(operand, iota, known, content)
+		switch operator.Utf8Text(content) {
+		case "-":
+			return -n, ok
+		case "+":
+			return n, ok
+		case "^":
+			return ^n, ok
+		}
+	case "binary_expression":
+		return evalBinary(node, content, func(operand *ts.Node) (int64, bool) {
+			return evalGoConstant(operand, iota, known, content)
+		})
+	}
+	return 0, false
+}
+
+// evalBinary evaluates an integer binary expression whose operands eval
+// evaluates, for the operators Go, TypeScript and Python share
+func evalBinary(node *ts.Node, content []byte, eval func(*ts.Node) (int64, bool)) (int64, bool) {
+	left, right := node.ChildByFieldName("left"), node.ChildByFieldName("right")
+	operator := node.ChildByFieldName("operator")
+	if left == nil || right == nil || operator == nil {
+		return 0, false
+	}
+	a, ok := eval(left)
+	if !ok {
+		return 0, false
+	}
+	b, ok := eval(right)
+	if !ok {
+		return 0, false
+	}
+	switch operator.Utf8Text(content) {
+	case "+":
+		return a + b, true
+	case "-":
+		return a - b, true
+	case "*":
+		return a * b, true
+	case "/", "//":
+		return a / b, b != 0
+	case "%":
+		return a % b, b != 0
+	case "<<":
+		return a << uint64(b), b >= 0 && b < 64
+	case ">>":
+		return a >> uint64(b), b >= 0 && b < 64
+	case "|":
+		return a | b, true
+	case "&":
+		return a & b, true
+	case "^":
+		return a ^ b, true
+	case "&^":
+		return a &^ b, true
+	}
+	return 0, false
+}
+
+// typeScriptEnumMembers returns the members of a TypeScript enum body. A
+// member without an initializer is the previous numeric member plus one, or
+// 0 when first; after a string member its value is not known.
+func typeScriptEnumMembers(body *ts.Node, content []byte) []enumMember {
+	members := make([]enumMember, 0)
+	known := make(map[string]int64)
+	next, nextKnown := int64(0), true
+	for i := uint(0); i < body.NamedChildCount(); i++ {
+		child := body.NamedChild(i)
+		member := enumMember{node: child}
+		switch child.Kind() {
+		case "property_identifier", "string":
+			member.name = strings.Trim(child.Utf8Text(content), "\"'")
+			member.implicit = true
+			member.number, member.numeric = next, nextKnown
+		case "enum_assignment":
+			nameNode, value := child.ChildByFieldName("name"), child.ChildByFieldName("value")
+			if nameNode == nil || value == nil {
+				continue
+			}
+			member.name = strings.Trim(nameNode.Utf8Text(content), "\"'")
+			member.value = value.Utf8Text(content)
+			member.number, member.numeric = evalTypeScriptConstant(value, known, content)
+		default:
+			continue
+		}
+		if member.numeric {
+			member.value = strconv.FormatInt(member.number, 10)
+			known[member.name] = member.number
+		}
+		next, nextKnown = member.number+1, member.numeric
+		members = append(members, member)
+	}
+	return members
+}
+
+// evalTypeScriptConstant evaluates an integer enum initializer, with the
+// members declared before it
+func evalTypeScriptConstant(node *ts.Node, known map[string]int64, content []byte) (int64, bool) {
+	text := node.Utf8Text(content)
+	switch node.Kind() {
+	case "number":
+		n, err := strconv.ParseInt(strings.ReplaceAll(text, "_", ""), 0, 64)
+		return n, err == nil
+	case "identifier":
+		n, ok := known[text]
+		return n, ok
+	case "parenthesized_expression":
+		if node.NamedChildCount() == 1 {
+			return evalTypeScriptConstant(node.NamedChild(0), known, content)
+		}
+	case "unary_expression":
+		argument := node.ChildByFieldName("argument")
+		operator := node.ChildByFieldName("operator")
+		if argument == nil || operator == nil {
+			return 0, false
+		}
+		n, ok := evalTypeScriptConstant(argument, known, content)
+		switch operator.Utf8Text(content) {
+		case "-":
+			return -n, ok
+		case "+":
+			return n, ok
+		case "~":
+			return ^n, ok
+		}
+	case "binary_expression":
+		return evalBinary(node, content, func(operand *ts.Node) (int64, bool) {
+			return evalTypeScriptConstant(operand, known, content)
+		})
+	}
+	return 0, false
+}
+
+// pythonEnumBase returns the enum module base class a Python class derives
+// from directly, such as IntEnum for class Color(enum.IntEnum), or ""
+func pythonEnumBase(class *entities.Entity) string {
+	superclasses, _ := class.GetProperty("superclasses").(string)
+	for _, base := range strings.Split(strings.Trim(superclasses, "()"), ",") {
+		base = strings.TrimSpace(base)
+		base = base[strings.LastIndex(base, ".")+1:]
+		if pythonEnumBases[base] {
+			return base
+		}
+	}
+	return ""
+}
+
+// pythonEnumMembers returns the members of the body of a Python enum class:
+// its class-level assignments, leaving out private names and annotated
+// fields. auto() counts from 1, or gives the next power of two for Flag and
+// IntFlag and the lower-cased name for StrEnum.
+func pythonEnumMembers(body *ts.Node, base string, content []byte) []enumMember {
+	flag := base == "Flag" || base == "IntFlag"
+	members := make([]enumMember, 0)
+	known := make(map[string]int64)
+	last, lastKnown := int64(0), true
+	for i := uint(0); i < body.NamedChildCount(); i++ {
+		statement := body.NamedChild(i)
+		if statement.Kind() != "expression_statement" || statement.NamedChildCount() == 0 {
+			continue
+		}
+		assignment := statement.NamedChild(0)
+		if assignment.Kind() != "assignment" || assignment.ChildByFieldName("type") != nil {
+			continue
+		}
+		left, right := assignment.ChildByFieldName("left"), assignment.ChildByFieldName("right")
+		if left == nil || right == nil || left.Kind() != "identifier" {
+			continue
+		}
+		name := left.Utf8Text(content)
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		member := enumMember{node: statement, name: name, value: right.Utf8Text(content)}
+		if function := right.ChildByFieldName("function"); right.Kind() == "call" && function != nil && strings.HasSuffix(function.Utf8Text(content), "auto") {
+			member.implicit = true
+			switch {
+			case base == "StrEnum":
+				member.value = strings.ToLower(name)
+			case !lastKnown:
+			case flag:
+				member.number, member.numeric = 1, true
+				for member.number <= last {
+					member.number <<= 1
+				}
+			default:
+				member.number, member.numeric = last+1, true
+			}
+		} else {
+			member.number, member.numeric = evalPythonConstant(right, known, content)
+		}
+		if member.numeric {
+			member.value = strconv.FormatInt(member.number, 10)
+			known[name] = member.number
+			if member.number > last || !flag {
+				last = member.number
+			}
+		}
+		lastKnown = member.numeric || base == "StrEnum"
+		members = append(members, member)
+	}
+	return members
+}
+
+// evalPythonConstant evaluates an integer member value, with the members
+// declared before it
+func evalPythonConstant(node *ts.Node, known map[string]int64, content []byte) (int64, bool) {
+	text := node.Utf8Text(content)
+	switch node.Kind() {
+	case "integer":
+		n, err := strconv.ParseInt(strings.ReplaceAll(text, "_", ""), 0, 64)
+		return n, err == nil
+	case "identifier":
+		n, ok := known[text]
+		return n, ok
+	case "parenthesized_expression":
+		if node.NamedChildCount() == 1 {
+			return evalPythonConstant(node.NamedChild(0), known, content)
+		}
+	case "unary_operator":
+		argument := node.ChildByFieldName("argument")
+		operator := node.ChildByFieldName("operator")
+		if argument == nil || operator == nil {
+			return 0, false
+		}
+		n, ok := evalPythonConstant(argument, known, content)
+		switch operator.Utf8Text(content) {
+		case "-":
+			return -n, ok
+		case "+":
+			return n, ok
+		case "~":
+			return ^n, ok
+		}
+	case "binary_operator":
+		return evalBinary(node, content, func(operand *ts.Node) (int64, bool) {
+			return evalPythonConstant(operand, known, content)
+		})
+	}
+	return 0, false
+}
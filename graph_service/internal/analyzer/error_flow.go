@@ -0,0 +1,418 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Reasons recorded on IGNORES_ERROR relationships
+const (
+	ErrorIgnoredBlank     = "blank_identifier" // Go: _ = f() or v, _ := f()
+	ErrorIgnoredUnchecked = "never_checked"    // Go: err := f() with err never read afterwards
+	ErrorIgnoredDiscarded = "discarded_result" // Go: f() called as a statement
+	ErrorIgnoredSwallowed = "empty_handler"    // Python/TypeScript: except: pass, catch {}
+)
+
+// newErrorFlowRelationship builds a PROPAGATES_ERROR, HANDLES_ERROR or
+// IGNORES_ERROR relationship from a function to the callee whose error it
+// deals with. The callee is recorded by name and resolved in phase 2.
+func newErrorFlowRelationship(relType entities.RelationshipType, caller *entities.Entity, callee, filePath string, callNode *ts.Node, content []byte) *entities.Relationship {
+	base := fmt.Sprintf("%s:%s:%s@%d", relType, caller.ID, callee, callNode.StartByte())
+	hash := sha256.Sum256([]byte(base))
+	rel := entities.NewRelationshipByID(
+		hex.EncodeToString(hash[:])[:16],
+		relType,
+		caller.ID,
+		callee,
+		caller.Type,
+		entities.EntityTypeFunction,
+	)
+	rel.SetProvenance(filePath, callNode, content)
+	return rel
+}
+
+// goErrorConstructors create a new error rather than returning one from a callee
+var goErrorConstructors = map[string]bool{
+	"errors.New": true, "errors.Join": true, "fmt.Errorf": true,
+	"errors.Wrap": true, "errors.Wrapf": true, "errors.WithMessage": true, "errors.WithStack": true,
+}
+
+// How the error variable of an assignment was recognized, recorded as the
+// error_variable property of the relationship
+const (
+	errorVariableDeclared = "declared" // declared with type error, e.g. var err error or a named result
+	errorVariableResult   = "result"   // receives the last result of the call; kept only if the callee returns an error
+)
+
+// goErrorVariable decides whether the identifier in the last position of an
+// assignment holds an error. A variable declared in the caller with a type is
+// an error exactly when that type is error; one introduced by the assignment
+// itself receives the callee's last result, which is an error only if the
+// callee returns one, so that is left to resolution.
+func (ga *GoAnalyzer) goErrorVariable(caller *entities.Entity, stmt *ts.Node, name string) string {
+	switch ga.declaredType(caller.Node, name, stmt.StartByte()) {
+	case "error":
+		return errorVariableDeclared
+	case "":
+		return errorVariableResult
+	}
+	return ""
+}
+
+// declaredType returns the type of the last parameter, result or var
+// declaration of name in a function before the byte offset before, or "" if
+// the name is not declared with a type
+func (ga *GoAnalyzer) declaredType(function *ts.Node, name string, before uint) string {
+	declared := ""
+	ga.walkNode(function, func(n *ts.Node) {
+		if n.StartByte() >= before || (n.Kind() != "parameter_declaration" && n.Kind() != "var_spec") {
+			return
+		}
+		typeNode := n.ChildByFieldName("type")
+		if typeNode == nil {
+			return
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			child := n.NamedChild(i)
+			if child.Kind() == "identifier" && ga.getNodeText(child) == name {
+				declared = ga.getNodeText(typeNode)
+			}
+		}
+	})
+	return declared
+}
+
+// goReturnsError reports whether a function entity's last result is an error
+func goReturnsError(entity *entities.Entity) bool {
+	results, _ := entity.GetProperty("result_types").([]string)
+	return len(results) > 0 && results[len(results)-1] == "error"
+}
+
+// mayReturnError decides whether an error-flow relationship survives
+// resolution. Go blank assignments, statement calls and variables receiving a
+// call's last result are recorded for every call; once the callee is known
+// they are kept only if it returns an error.
+func mayReturnError(rel *entities.Relationship, callee *entities.Entity) bool {
+	reason, _ := rel.GetProperty("reason").(string)
+	variable, _ := rel.GetProperty("error_variable").(string)
+	if reason != ErrorIgnoredBlank && reason != ErrorIgnoredDiscarded && variable != errorVariableResult {
+		return true
+	}
+	if _, known := callee.GetProperty("parameter_types").([]string); !known {
+		return reason == ErrorIgnoredBlank
+	}
+	return goReturnsError(callee)
+}
+
+// extractErrorFlow classifies what a Go function does with the error returned
+// by a call:
+//   - err := f(); if err != nil { return ..., err }  -> PROPAGATES_ERROR
+//   - return f() in a function returning error       -> PROPAGATES_ERROR
+//   - err := f(); if err != nil { log(err) }         -> HANDLES_ERROR
+//   - _ = f(), v, _ := f(), err := f() never read    -> IGNORES_ERROR
+//
+// The error variable is the last one assigned; unless it is declared with
+// type error, the relationship is kept only when f is found to return an error.
+//   - f() as a statement                             -> IGNORES_ERROR (kept only when f returns an error)
+func (ga *GoAnalyzer) extractErrorFlow(callNode *ts.Node, caller *entities.Entity, callee string) {
+	parent := callNode.Parent()
+	if parent == nil || caller.Node == nil {
+		return
+	}
+
+	var rel *entities.Relationship
+	switch parent.Kind() {
+	case "expression_statement":
+		rel = ga.newErrorFlow(entities.RelationshipTypeIgnoresError, caller, callee, callNode)
+		rel.SetProperty("reason", ErrorIgnoredDiscarded)
+
+	case "expression_list":
+		stmt := parent.Parent()
+		if stmt == nil {
+			return
+		}
+		switch stmt.Kind() {
+		case "assignment_statement", "short_var_declaration":
+			right := stmt.ChildByFieldName("right")
+			left := stmt.ChildByFieldName("left")
+			if right == nil || left == nil || right.Id() != parent.Id() || parent.NamedChildCount() != 1 || left.NamedChildCount() == 0 {
+				return
+			}
+			last := left.NamedChild(left.NamedChildCount() - 1)
+			name := ga.getNodeText(last)
+			if name == "_" {
+				rel = ga.newErrorFlow(entities.RelationshipTypeIgnoresError, caller, callee, callNode)
+				rel.SetProperty("reason", ErrorIgnoredBlank)
+				rel.SetProperty("result_count", int(left.NamedChildCount()))
+			} else if last.Kind() == "identifier" {
+				if variable := ga.goErrorVariable(caller, stmt, name); variable != "" {
+					rel = ga.classifyErrorCheck(stmt, name, caller, callee, callNode)
+					rel.SetProperty("error_variable", variable)
+				}
+			}
+		case "return_statement":
+			if goReturnsError(caller) && !goErrorConstructors[callee] && parent.NamedChild(parent.NamedChildCount()-1).Id() == callNode.Id() {
+				rel = ga.newErrorFlow(entities.RelationshipTypePropagatesError, caller, callee, callNode)
+				rel.SetProperty("wrapped", false)
+			}
+		}
+	}
+
+	if rel != nil {
+		ga.relationships = append(ga.relationships, rel)
+	}
+}
+
+// classifyErrorCheck follows an error variable assigned by stmt to the if
+// statement that checks it, or to any later use
+func (ga *GoAnalyzer) classifyErrorCheck(stmt *ts.Node, errName string, caller *entities.Entity, callee string, callNode *ts.Node) *entities.Relationship {
+	// The check is either the enclosing if (if err := f(); err != nil) or the next statement
+	var check *ts.Node
+	if p := stmt.Parent(); p != nil && p.Kind() == "if_statement" {
+		check = p
+	} else {
+		next := stmt.NextNamedSibling()
+		for next != nil && next.Kind() == "comment" {
+			next = next.NextNamedSibling()
+		}
+		check = next
+	}
+
+	if check != nil && check.Kind() == "if_statement" && ga.referencesIdentifier(check.ChildByFieldName("condition"), errName, 0) {
+		consequence := check.ChildByFieldName("consequence")
+		if returned, wrapped := ga.returnsIdentifier(consequence, errName); returned {
+			rel := ga.newErrorFlow(entities.RelationshipTypePropagatesError, caller, callee, callNode)
+			rel.SetProperty("wrapped", wrapped)
+			return rel
+		}
+		return ga.newErrorFlow(entities.RelationshipTypeHandlesError, caller, callee, callNode)
+	}
+
+	if check != nil && check.Kind() == "return_statement" {
+		if returned, wrapped := ga.returnsIdentifier(check, errName); returned {
+			rel := ga.newErrorFlow(entities.RelationshipTypePropagatesError, caller, callee, callNode)
+			rel.SetProperty("wrapped", wrapped)
+			return rel
+		}
+	}
+
+	if ga.referencesIdentifier(caller.Node.ChildByFieldName("body"), errName, stmt.EndByte()) {
+		return ga.newErrorFlow(entities.RelationshipTypeHandlesError, caller, callee, callNode)
+	}
+
+	rel := ga.newErrorFlow(entities.RelationshipTypeIgnoresError, caller, callee, callNode)
+	rel.SetProperty("reason", ErrorIgnoredUnchecked)
+	return rel
+}
+
+// referencesIdentifier reports whether node contains the identifier name at or
+// after the byte offset after
+func (ga *GoAnalyzer) referencesIdentifier(node *ts.Node, name string, after uint) bool {
+	if node == nil {
+		return false
+	}
+	found := false
+	ga.walkNode(node, func(n *ts.Node) {
+		if !found && n.Kind() == "identifier" && n.StartByte() >= after && ga.getNodeText(n) == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// returnsIdentifier reports whether node contains a return statement that
+// returns the error variable, and whether it is wrapped in a call such as
+// fmt.Errorf("...: %w", err)
+func (ga *GoAnalyzer) returnsIdentifier(node *ts.Node, name string) (returned, wrapped bool) {
+	if node == nil {
+		return false, false
+	}
+	ga.walkNode(node, func(n *ts.Node) {
+		if returned || n.Kind() != "return_statement" || n.NamedChildCount() == 0 {
+			return
+		}
+		last := n.NamedChild(0)
+		if last.Kind() == "expression_list" && last.NamedChildCount() > 0 {
+			last = last.NamedChild(last.NamedChildCount() - 1)
+		}
+		if !ga.referencesIdentifier(last, name, 0) {
+			return
+		}
+		returned = true
+		wrapped = last.Kind() != "identifier"
+	})
+	return returned, wrapped
+}
+
+// newErrorFlow builds an error-flow relationship for a call in the current Go file
+func (ga *GoAnalyzer) newErrorFlow(relType entities.RelationshipType, caller *entities.Entity, callee string, callNode *ts.Node) *entities.Relationship {
+	return newErrorFlowRelationship(relType, caller, callee, ga.currentFile.Path, callNode, ga.currentFile.Content)
+}
+
+// extractTryStatement links the calls guarded by a Python try block to the
+// except clauses that handle them. A handler that re-raises propagates the
+// error; handlers consisting only of pass or ... swallow it.
+func (pa *PythonAnalyzer) extractTryStatement(tryNode *ts.Node) {
+	body := tryNode.ChildByFieldName("body")
+	if body == nil {
+		return
+	}
+
+	var exceptionTypes []string
+	handled, propagated, swallowed := false, false, false
+	for i := uint(0); i < tryNode.NamedChildCount(); i++ {
+		clause := tryNode.NamedChild(i)
+		if clause.Kind() != "except_clause" && clause.Kind() != "except_group_clause" {
+			continue
+		}
+
+		exceptionType := "BaseException"
+		if value := clause.ChildByFieldName("value"); value != nil {
+			if value.Kind() == "as_pattern" && value.NamedChildCount() > 0 {
+				value = value.NamedChild(0)
+			}
+			exceptionType = strings.Trim(pa.getNodeText(value), "()")
+		}
+		exceptionTypes = append(exceptionTypes, exceptionType)
+
+		var block *ts.Node
+		for j := uint(0); j < clause.NamedChildCount(); j++ {
+			if clause.NamedChild(j).Kind() == "block" {
+				block = clause.NamedChild(j)
+			}
+		}
+		switch {
+		case block == nil || pa.isEmptyHandler(block):
+			swallowed = true
+		case containsKind(block, "raise_statement"):
+			propagated = true
+		default:
+			handled = true
+		}
+	}
+	if len(exceptionTypes) == 0 {
+		return // try/finally without handlers
+	}
+
+	relType := entities.RelationshipTypeHandlesError
+	switch {
+	case propagated:
+		relType = entities.RelationshipTypePropagatesError
+	case swallowed && !handled:
+		relType = entities.RelationshipTypeIgnoresError
+	}
+
+	for _, call := range guardedCalls(body, "call") {
+		functionNode := call.ChildByFieldName("function")
+		caller := pa.findContainingFunction(call)
+		if functionNode == nil || caller == nil {
+			continue
+		}
+		rel := newErrorFlowRelationship(relType, caller, pa.getNodeText(functionNode), pa.currentFile.Path, call, pa.currentFile.Content)
+		rel.SetProperty("exception_types", strings.Join(exceptionTypes, ", "))
+		if relType == entities.RelationshipTypeIgnoresError {
+			rel.SetProperty("reason", ErrorIgnoredSwallowed)
+		}
+		pa.relationships = append(pa.relationships, rel)
+	}
+}
+
+// isEmptyHandler reports whether an except block only contains pass, ... or comments
+func (pa *PythonAnalyzer) isEmptyHandler(block *ts.Node) bool {
+	for i := uint(0); i < block.NamedChildCount(); i++ {
+		stmt := block.NamedChild(i)
+		switch stmt.Kind() {
+		case "pass_statement", "comment":
+		case "expression_statement":
+			if stmt.NamedChildCount() != 1 || stmt.NamedChild(0).Kind() != "ellipsis" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// extractTryStatement links the calls guarded by a TypeScript try block to its
+// catch clause. A catch that throws propagates the error; an empty catch
+// swallows it.
+func (ta *TypeScriptAnalyzer) extractTryStatement(tryNode *ts.Node) {
+	body := tryNode.ChildByFieldName("body")
+	handler := tryNode.ChildByFieldName("handler")
+	if body == nil || handler == nil {
+		return
+	}
+
+	relType := entities.RelationshipTypeHandlesError
+	handlerBody := handler.ChildByFieldName("body")
+	switch {
+	case handlerBody == nil || handlerBody.NamedChildCount() == 0 || onlyComments(handlerBody):
+		relType = entities.RelationshipTypeIgnoresError
+	case containsKind(handlerBody, "throw_statement"):
+		relType = entities.RelationshipTypePropagatesError
+	}
+
+	for _, call := range guardedCalls(body, "call_expression") {
+		functionNode := call.ChildByFieldName("function")
+		caller := ta.findContainingFunction(call)
+		if functionNode == nil || caller == nil {
+			continue
+		}
+		rel := newErrorFlowRelationship(relType, caller, ta.getNodeText(functionNode), ta.currentFile.Path, call, ta.currentFile.Content)
+		if relType == entities.RelationshipTypeIgnoresError {
+			rel.SetProperty("reason", ErrorIgnoredSwallowed)
+		}
+		ta.relationships = append(ta.relationships, rel)
+	}
+}
+
+// guardedCalls returns the calls of the given node kind inside a try body,
+// excluding calls in nested function definitions, which run later and are not
+// guarded by the try
+func guardedCalls(body *ts.Node, callKind string) []*ts.Node {
+	var calls []*ts.Node
+	var visit func(n *ts.Node)
+	visit = func(n *ts.Node) {
+		switch n.Kind() {
+		case "function_definition", "lambda", "function_declaration", "function_expression", "arrow_function", "class_definition", "class_declaration":
+			return
+		case callKind:
+			calls = append(calls, n)
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			visit(n.NamedChild(i))
+		}
+	}
+	visit(body)
+	return calls
+}
+
+// containsKind reports whether any node under root has the given kind
+func containsKind(root *ts.Node, kind string) bool {
+	if root.Kind() == kind {
+		return true
+	}
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		if containsKind(root.NamedChild(i), kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// onlyComments reports whether a block contains nothing but comments
+func onlyComments(block *ts.Node) bool {
+	for i := uint(0); i < block.NamedChildCount(); i++ {
+		if block.NamedChild(i).Kind() != "comment" {
+			return false
+		}
+	}
+	return true
+}
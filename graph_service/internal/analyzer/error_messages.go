@@ -0,0 +1,372 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Values of the ErrorMessageRules
+const (
+	ErrorMessageLower      = "lower"      // Capitalization: "failed to open config"
+	ErrorMessageUpper      = "upper"      // Capitalization: "Failed to open config"
+	ErrorMessageForbid     = "forbid"     // TrailingPeriod: no period, colon, ! or ? or newline at the end
+	ErrorMessageRequire    = "require"    // TrailingPeriod: a period, ! or ? at the end
+	ErrorMessageConsistent = "consistent" // Either rule: what most messages of the language do
+)
+
+// Kinds of ErrorMessageIssue
+const (
+	ErrorMessageCapitalized   = "capitalized"          // Starts upper-case where lower-case is expected
+	ErrorMessageLowercase     = "lowercase"            // Starts lower-case where upper-case is expected
+	ErrorMessagePunctuated    = "trailing_punctuation" // Ends in punctuation or a newline where none is expected
+	ErrorMessageMissingPeriod = "missing_period"       // Ends without a period where one is expected
+)
+
+// ErrorMessageRules are the conventions the error messages of a language are
+// checked against. A rule left empty is not checked.
+type ErrorMessageRules struct {
+	// Capitalization of the first word: "lower", "upper" or "consistent".
+	// Words with an upper-case letter after the first, such as acronyms and
+	// identifiers (HTTP, ReadFile), and words that are not letters, such as
+	// format verbs, are left as they are.
+	Capitalization string
+
+	// TrailingPeriod is "forbid", which also rejects a trailing colon,
+	// exclamation or question mark or newline, "require", which accepts a
+	// trailing exclamation or question mark too, or "consistent"
+	TrailingPeriod string
+}
+
+// Validate reports a rule set to a value it does not accept
+func (r ErrorMessageRules) Validate() error {
+	switch r.Capitalization {
+	case "", ErrorMessageLower, ErrorMessageUpper, ErrorMessageConsistent:
+	default:
+		return fmt.Errorf("unknown Capitalization %q", r.Capitalization)
+	}
+	switch r.TrailingPeriod {
+	case "", ErrorMessageForbid, ErrorMessageRequire, ErrorMessageConsistent:
+	default:
+		return fmt.Errorf("unknown TrailingPeriod %q", r.TrailingPeriod)
+	}
+	return nil
+}
+
+// DefaultErrorMessageRules returns the rules each language is checked
+// against unless configured otherwise: Go follows its code review
+// convention that error strings are neither capitalized nor end with
+// punctuation, since they are usually wrapped in other messages; Python,
+// TypeScript and JavaScript have no such convention, so their messages are
+// held to what most of them do in the repository
+func DefaultErrorMessageRules() map[string]ErrorMessageRules {
+	consistent := ErrorMessageRules{Capitalization: ErrorMessageConsistent, TrailingPeriod: ErrorMessageConsistent}
+	return map[string]ErrorMessageRules{
+		"go":         {Capitalization: ErrorMessageLower, TrailingPeriod: ErrorMessageForbid},
+		"python":     consistent,
+		"typescript": consistent,
+		"javascript": consistent,
+	}
+}
+
+// goErrorMessageConstructors are the Go functions that create an error from a
+// message, by import path, with the position of the message argument
+var goErrorMessageConstructors = map[string]map[string]int{
+	"fmt":                           {"Errorf": 0},
+	"errors":                        {"New": 0},
+	"github.com/pkg/errors":         {"New": 0, "Errorf": 0, "Wrap": 1, "Wrapf": 1, "WithMessage": 1, "WithMessagef": 1},
+	"github.com/cockroachdb/errors": {"New": 0, "Newf": 0, "Errorf": 0, "Wrap": 1, "Wrapf": 1},
+}
+
+// errorMessage is a place where an error is created with a literal message
+type errorMessage struct {
+	file        *entities.File
+	node        *ts.Node // The message literal
+	text        string   // The message, without quotes
+	constructor string   // The function or exception class creating the error
+	enclosing   *entities.Entity
+}
+
+// collectErrorMessages returns the error construction sites of a file with a
+// literal message: Go fmt.Errorf and errors.New (and the pkg/errors and
+// cockroachdb/errors variants), Python raise X("...") and TypeScript new
+// X("...") of a class whose name ends in Error or Exception. Messages in
+// functions marked with an onyx:ignore comment are left out.
+func collectErrorMessages(file *entities.File) []errorMessage {
+	if file.Tree == nil {
+		return nil
+	}
+	content := file.Content
+	text := func(n *ts.Node) string { return n.Utf8Text(content) }
+	goImports := goImportNames(file)
+	candidates := file.GetAllEntities()
+
+	messages := make([]errorMessage, 0)
+	add := func(constructor string, message *ts.Node) {
+		value, ok := errorMessageText(message, content)
+		if !ok || strings.TrimSpace(value) == "" {
+			return
+		}
+		enclosing := innermostEntity(candidates, message)
+		for e := enclosing; e != nil; e = e.Parent {
+			if e.IsIgnored() {
+				return
+			}
+		}
+		messages = append(messages, errorMessage{
+			file:        file,
+			node:        message,
+			text:        value,
+			constructor: strings.Join(strings.Fields(constructor), ""),
+			enclosing:   enclosing,
+		})
+	}
+
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		switch file.Language {
+		case "go":
+			if node.Kind() != "call_expression" {
+				return
+			}
+			function := node.ChildByFieldName("function")
+			if function == nil || function.Kind() != "selector_expression" {
+				return
+			}
+			operand, field := function.ChildByFieldName("operand"), function.ChildByFieldName("field")
+			position, ok := goErrorMessageConstructors[goImports[text(operand)]][text(field)]
+			if args := cliArguments(node); ok && len(args) > position {
+				add(text(function), args[position])
+			}
+
+		case "python":
+			if node.Kind() != "raise_statement" || node.NamedChildCount() == 0 {
+				return
+			}
+			call := node.NamedChild(0)
+			if call.Kind() != "call" {
+				return
+			}
+			if args := cliArguments(call); len(args) > 0 {
+				add(text(call.ChildByFieldName("function")), args[0])
+			}
+
+		case "typescript", "javascript":
+			if node.Kind() != "new_expression" {
+				return
+			}
+			constructor := node.ChildByFieldName("constructor")
+			if constructor == nil {
+				return
+			}
+			name := text(constructor)
+			if !strings.HasSuffix(name, "Error") && !strings.HasSuffix(name, "Exception") {
+				return
+			}
+			if args := cliArguments(node); len(args) > 0 {
+				add(name, args[0])
+			}
+		}
+	})
+	return messages
+}
+
+// errorMessageText returns the text of a message literal as written, without
+// its quotes and prefixes, keeping interpolations such as {name} and ${id}.
+// A Go interpreted string is unquoted.
+func errorMessageText(node *ts.Node, content []byte) (string, bool) {
+	raw := node.Utf8Text(content)
+	switch node.Kind() {
+	case "interpreted_string_literal":
+		value, err := strconv.Unquote(raw)
+		return value, err == nil
+	case "raw_string_literal", "string", "template_string":
+		raw = strings.TrimLeft(raw, "rRuUfFbB")
+		for _, quote := range []string{`"""`, `'''`, `"`, `'`, "`"} {
+			if len(raw) >= 2*len(quote) && strings.HasPrefix(raw, quote) && strings.HasSuffix(raw, quote) {
+				return raw[len(quote) : len(raw)-len(quote)], true
+			}
+		}
+	}
+	return "", false
+}
+
+// messageCase returns "upper" or "lower" for the first word of a message,
+// or "" when its case says nothing: it is an acronym or identifier, or does
+// not start with a letter
+func messageCase(message string) string {
+	first, size := utf8.DecodeRuneInString(message)
+	if !unicode.IsLetter(first) {
+		return ""
+	}
+	end := strings.IndexFunc(message, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' })
+	if end < 0 {
+		end = len(message)
+	}
+	for _, r := range message[size:end] {
+		if unicode.IsUpper(r) || r == '_' {
+			return ""
+		}
+	}
+	if end < len(message) && (message[end] == '.' || message[end] == '(') {
+		return "" // A qualified name or call, such as os.Open or Parse()
+	}
+	if unicode.IsUpper(first) {
+		return ErrorMessageUpper
+	}
+	return ErrorMessageLower
+}
+
+// messageEnding returns the punctuation a message ends with: a period, or
+// one of : ! ? or a newline, or "" when it ends otherwise. An escaped \n
+// counts as a newline.
+func messageEnding(message string) string {
+	if strings.HasSuffix(message, "\n") || strings.HasSuffix(message, `\n`) {
+		return "\n"
+	}
+	if message == "" {
+		return ""
+	}
+	switch last := message[len(message)-1]; last {
+	case '.', ':', '!', '?':
+		if strings.HasSuffix(message, "...") {
+			return "..."
+		}
+		return string(last)
+	}
+	return ""
+}
+
+// checkErrorMessages checks the error messages collected from every file
+// against the rules of their language and records each violation as an
+// ErrorMessageIssue entity of the file. Rules asking for consistency are
+// settled by what most messages of the language do; a tie is not reported.
+func (gb *GraphBuilder) checkErrorMessages() {
+	rules := DefaultErrorMessageRules()
+	if gb.config.ErrorMessageRules != nil {
+		rules = gb.config.ErrorMessageRules
+	}
+
+	// How many messages of each language start in each case and end with a
+	// period or not
+	type tally struct{ upper, lower, period, bare int }
+	tallies := make(map[string]*tally)
+	for _, m := range gb.errorMessages {
+		t := tallies[m.file.Language]
+		if t == nil {
+			t = &tally{}
+			tallies[m.file.Language] = t
+		}
+		switch messageCase(m.text) {
+		case ErrorMessageUpper:
+			t.upper++
+		case ErrorMessageLower:
+			t.lower++
+		}
+		if ending := messageEnding(m.text); ending == "." || ending == "!" || ending == "?" {
+			t.period++
+		} else if ending == "" {
+			t.bare++
+		}
+	}
+	majority := func(a, b int, ifA, ifB string) string {
+		switch {
+		case a > b:
+			return ifA
+		case b > a:
+			return ifB
+		}
+		return ""
+	}
+
+	for _, m := range gb.errorMessages {
+		rule, ok := rules[m.file.Language]
+		if !ok {
+			continue
+		}
+		t := tallies[m.file.Language]
+		capitalization, trailing := rule.Capitalization, rule.TrailingPeriod
+		if capitalization == ErrorMessageConsistent {
+			capitalization = majority(t.upper, t.lower, ErrorMessageUpper, ErrorMessageLower)
+		}
+		if trailing == ErrorMessageConsistent {
+			trailing = majority(t.period, t.bare, ErrorMessageRequire, ErrorMessageForbid)
+		}
+
+		kinds := make([]string, 0, 2)
+		fixed := m.text
+		switch messageCase(m.text) {
+		case ErrorMessageUpper:
+			if capitalization == ErrorMessageLower {
+				kinds = append(kinds, ErrorMessageCapitalized)
+				first, size := utf8.DecodeRuneInString(fixed)
+				fixed = string(unicode.ToLower(first)) + fixed[size:]
+			}
+		case ErrorMessageLower:
+			if capitalization == ErrorMessageUpper {
+				kinds = append(kinds, ErrorMessageLowercase)
+				first, size := utf8.DecodeRuneInString(fixed)
+				fixed = string(unicode.ToUpper(first)) + fixed[size:]
+			}
+		}
+		switch ending := messageEnding(m.text); {
+		case ending != "" && trailing == ErrorMessageForbid:
+			kinds = append(kinds, ErrorMessagePunctuated)
+			fixed = strings.TrimRight(strings.TrimSuffix(fixed, `\n`), ".:!?\n")
+		case ending == "" && trailing == ErrorMessageRequire:
+			kinds = append(kinds, ErrorMessageMissingPeriod)
+			fixed += "."
+		}
+
+		for _, kind := range kinds {
+			issue := newErrorMessageIssue(m, kind, fixed, capitalization, trailing)
+			m.file.AddEntity(issue)
+			gb.allEntities[issue.ID] = issue
+			gb.stats.EntitiesFound++
+		}
+	}
+	gb.errorMessages = nil
+}
+
+// newErrorMessageIssue builds the ErrorMessageIssue entity of a message that
+// breaks a rule
+func newErrorMessageIssue(m errorMessage, kind, fixed, capitalization, trailing string) *entities.Entity {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("error_message_issue:%s:%d:%s", m.file.Path, m.node.StartByte(), kind)))
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), m.text, entities.EntityTypeErrorMessageIssue, m.file.Path, m.node)
+
+	var message string
+	switch kind {
+	case ErrorMessageCapitalized:
+		message = "error message should not be capitalized"
+	case ErrorMessageLowercase:
+		message = "error message should start with an upper-case letter"
+	case ErrorMessagePunctuated:
+		message = "error message should not end with punctuation or a newline"
+	case ErrorMessageMissingPeriod:
+		message = "error message should end with a period"
+	}
+	if (kind == ErrorMessageCapitalized || kind == ErrorMessageLowercase) && capitalization != "" {
+		entity.SetProperty("expected", capitalization)
+	} else {
+		entity.SetProperty("expected", trailing)
+	}
+	entity.SetProperty("kind", kind)
+	entity.SetProperty("error_message", m.text)
+	entity.SetProperty("constructor", m.constructor)
+	entity.SetProperty("message", message)
+	entity.SetProperty("suggestion", fixed)
+	entity.SetProperty("line", int(m.node.StartPosition().Row)+1)
+	entity.SetProperty("language", m.file.Language)
+	if m.enclosing != nil {
+		entity.SetProperty("enclosing_function", m.enclosing.ID)
+		entity.SetProperty("enclosing_function_name", m.enclosing.GetFullName())
+	}
+	return entity
+}
@@ -0,0 +1,215 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// exportableTypes are the declarations that carry an "exported" property. It
+// is true when the declaration is visible outside its module under the rules
+// of its language:
+//   - Go: the name starts with an upper-case letter; methods and fields also
+//     need an exported receiver or struct
+//   - Python: the name has no leading underscore (dunder methods are public);
+//     members need an exported class, variables must be at module level
+//   - TypeScript: the declaration is exported with `export` or an export
+//     clause; class members must also not be private, protected or #private
+var exportableTypes = map[entities.EntityType]bool{
+	entities.EntityTypeFunction:  true,
+	entities.EntityTypeMethod:    true,
+	entities.EntityTypeClass:     true,
+	entities.EntityTypeStruct:    true,
+	entities.EntityTypeInterface: true,
+	entities.EntityTypeType:      true,
+	entities.EntityTypeEnum:      true,
+	entities.EntityTypeProperty:  true,
+	entities.EntityTypeVariable:  true,
+}
+
+// markExported sets the "exported" property on the declarations of a file.
+// Parents are decided before their members, which depend on them.
+func markExported(file *entities.File) {
+	var isExported func(*entities.Entity) bool
+	switch file.Language {
+	case "go":
+		isExported = goExported
+	case "python":
+		isExported = pythonExported
+	case "typescript", "javascript":
+		isExported = tsExportedFunc(file)
+	default:
+		return
+	}
+
+	for _, entity := range file.GetAllEntities() {
+		if exportableTypes[entity.Type] {
+			// Functions nested in other functions are local in every language
+			nested, _ := entity.GetProperty("nested").(bool)
+			entity.SetProperty("exported", !nested && isExported(entity))
+		}
+	}
+}
+
+// entityExported reads the "exported" property, computing it for parents that
+// have not been visited yet
+func entityExported(entity *entities.Entity, isExported func(*entities.Entity) bool) bool {
+	if exported, ok := entity.GetProperty("exported").(bool); ok {
+		return exported
+	}
+	return isExported(entity)
+}
+
+// memberOwner returns the type declaring a method, field or property, or nil
+// for top-level declarations
+func memberOwner(entity *entities.Entity) *entities.Entity {
+	if entity.Parent == nil {
+		return nil
+	}
+	switch entity.Parent.Type {
+	case entities.EntityTypeClass, entities.EntityTypeStruct, entities.EntityTypeInterface:
+		return entity.Parent
+	}
+	return nil
+}
+
+// goExported applies Go's upper-case rule to the name, the receiver type of a
+// method and the struct of a field
+func goExported(entity *entities.Entity) bool {
+	if !startsUpper(entity.Name) {
+		return false
+	}
+	if entity.Type == entities.EntityTypeMethod {
+		receiver, _ := entity.GetProperty("receiver").(string)
+		return startsUpper(ReceiverTypeName(receiver))
+	}
+	if owner := memberOwner(entity); owner != nil {
+		return entityExported(owner, goExported)
+	}
+	return true
+}
+
+// ReceiverTypeName extracts the type name from a Go receiver such as
+// "(c *Cache[K, V])", returning "Cache"
+func ReceiverTypeName(receiver string) string {
+	receiver = strings.Trim(strings.TrimSpace(receiver), "()")
+	if i := strings.IndexByte(receiver, '['); i >= 0 {
+		receiver = receiver[:i]
+	}
+	fields := strings.Fields(receiver)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimLeft(fields[len(fields)-1], "*")
+}
+
+// startsUpper reports whether name begins with an upper-case letter
+func startsUpper(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// pythonExported treats names without a leading underscore as public. Nested
+// functions and variables inside functions are never part of the module's API.
+func pythonExported(entity *entities.Entity) bool {
+	name := entity.Name
+	if strings.HasPrefix(name, "_") && !(strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__")) {
+		return false
+	}
+	if entity.Node != nil && hasAncestor(entity.Node, "function_definition", "lambda") {
+		return false
+	}
+	if owner := memberOwner(entity); owner != nil {
+		return entityExported(owner, pythonExported)
+	}
+	if entity.Type == entities.EntityTypeVariable && entity.Node != nil && hasAncestor(entity.Node, "class_definition") {
+		// Class attributes that are not dataclass fields have no parent entity
+		return false
+	}
+	return true
+}
+
+// tsExportedFunc returns the export rule for a TypeScript or JavaScript file,
+// which also needs the names listed in `export { a, b }` clauses
+func tsExportedFunc(file *entities.File) func(*entities.Entity) bool {
+	clauseNames := make(map[string]bool)
+	if file.Tree != nil {
+		walkTree(file.Tree.RootNode(), func(n *ts.Node) {
+			if n.Kind() != "export_specifier" {
+				return
+			}
+			statement := n.Parent()
+			if statement != nil {
+				statement = statement.Parent()
+			}
+			if statement == nil || statement.ChildByFieldName("source") != nil {
+				// Re-exports name declarations of another module
+				return
+			}
+			if nameNode := n.ChildByFieldName("name"); nameNode != nil {
+				clauseNames[nameNode.Utf8Text(file.Content)] = true
+			}
+		})
+	}
+
+	var isExported func(*entities.Entity) bool
+	isExported = func(entity *entities.Entity) bool {
+		if entity.Node == nil {
+			return false
+		}
+		if owner := memberOwner(entity); owner != nil {
+			return tsMemberPublic(entity.Node, entity.Name) && entityExported(owner, isExported)
+		}
+		if hasAncestor(entity.Node, "function_declaration", "function_expression", "arrow_function", "method_definition") {
+			return false
+		}
+		declaration := entity.Node
+		if declaration.Kind() == "variable_declarator" && declaration.Parent() != nil {
+			declaration = declaration.Parent()
+		}
+		if parent := declaration.Parent(); parent != nil && parent.Kind() == "export_statement" {
+			return true
+		}
+		return declaration.Parent() != nil && declaration.Parent().Kind() == "program" && clauseNames[entity.Name]
+	}
+	return isExported
+}
+
+// tsMemberPublic reports whether a class or interface member is public
+func tsMemberPublic(node *ts.Node, name string) bool {
+	if strings.HasPrefix(name, "#") {
+		return false
+	}
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		child := node.NamedChild(i)
+		if child.Kind() == "accessibility_modifier" {
+			return child.Child(0) != nil && child.Child(0).Kind() == "public"
+		}
+	}
+	return true
+}
+
+// hasAncestor reports whether any ancestor of node has one of the given kinds
+func hasAncestor(node *ts.Node, kinds ...string) bool {
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		for _, kind := range kinds {
+			if parent.Kind() == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// walkTree visits node and all of its descendants
+func walkTree(node *ts.Node, visitor func(*ts.Node)) {
+	visitor(node)
+	for i := uint(0); i < node.ChildCount(); i++ {
+		walkTree(node.Child(i), visitor)
+	}
+}
@@ -0,0 +1,194 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Providers recorded on CHECKS_FLAG relationships and FeatureFlag entities
+const (
+	FlagProviderLaunchDarkly = "launchdarkly"
+	FlagProviderUnleash      = "unleash"
+	FlagProviderOpenFeature  = "openfeature"
+	FlagProviderCustom       = "custom"
+)
+
+// flagMethodProviders maps flag evaluation methods, lower-cased with
+// underscores removed, to the library they belong to. Go, Python and
+// TypeScript SDKs spell the same method differently (BoolVariation,
+// bool_variation, boolVariation).
+var flagMethodProviders = map[string]string{
+	"variation":              FlagProviderLaunchDarkly,
+	"variationdetail":        FlagProviderLaunchDarkly,
+	"boolvariation":          FlagProviderLaunchDarkly,
+	"boolvariationdetail":    FlagProviderLaunchDarkly,
+	"stringvariation":        FlagProviderLaunchDarkly,
+	"stringvariationdetail":  FlagProviderLaunchDarkly,
+	"intvariation":           FlagProviderLaunchDarkly,
+	"intvariationdetail":     FlagProviderLaunchDarkly,
+	"float64variation":       FlagProviderLaunchDarkly,
+	"float64variationdetail": FlagProviderLaunchDarkly,
+	"numbervariation":        FlagProviderLaunchDarkly,
+	"numbervariationdetail":  FlagProviderLaunchDarkly,
+	"jsonvariation":          FlagProviderLaunchDarkly,
+	"jsonvaluevariation":     FlagProviderLaunchDarkly,
+	"isenabled":              FlagProviderUnleash,
+	"getvariant":             FlagProviderUnleash,
+	"getbooleanvalue":        FlagProviderOpenFeature,
+	"getbooleandetails":      FlagProviderOpenFeature,
+	"getstringvalue":         FlagProviderOpenFeature,
+	"getnumbervalue":         FlagProviderOpenFeature,
+	"getobjectvalue":         FlagProviderOpenFeature,
+	"featureenabled":         FlagProviderCustom,
+	"isfeatureenabled":       FlagProviderCustom,
+	"flagenabled":            FlagProviderCustom,
+	"isflagenabled":          FlagProviderCustom,
+}
+
+// featureFlagID returns the ID of the FeatureFlag entity for a flag key. Flags
+// are keyed by name alone so that every check site links to the same entity.
+func featureFlagID(key string) string {
+	hash := sha256.Sum256([]byte("feature_flag:" + key))
+	return "flag_" + hex.EncodeToString(hash[:])[:16]
+}
+
+// classifyFlagCall decides whether a callee such as "ldClient.BoolVariation",
+// "unleash.is_enabled" or "featureEnabled" evaluates a feature flag. Extra
+// patterns are matched with path.Match against the whole callee and against
+// its last segment, so "featureOn" and "flags.*" both work.
+func classifyFlagCall(callee string, patterns []string) (provider string, ok bool) {
+	callee = stripCallArguments(callee)
+	method := callee[strings.LastIndex(callee, ".")+1:]
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, callee); matched {
+			return FlagProviderCustom, true
+		}
+		if matched, _ := path.Match(pattern, method); matched {
+			return FlagProviderCustom, true
+		}
+	}
+
+	provider, ok = flagMethodProviders[strings.ToLower(strings.ReplaceAll(method, "_", ""))]
+	return provider, ok
+}
+
+// flagKeyArgument returns the flag key passed to an evaluation call: the first
+// string literal among the first two arguments, which also covers APIs that
+// take a context first. Keys built at runtime are not recognized.
+func flagKeyArgument(argsNode *ts.Node, content []byte) (string, bool) {
+	if argsNode == nil {
+		return "", false
+	}
+
+	seen := 0
+	for i := uint(0); i < argsNode.NamedChildCount() && seen < 2; i++ {
+		arg := argsNode.NamedChild(i)
+		if arg.Kind() == "comment" {
+			continue
+		}
+		seen++
+
+		switch arg.Kind() {
+		case "interpreted_string_literal", "raw_string_literal", "string", "template_string":
+			if containsKind(arg, "interpolation") || containsKind(arg, "template_substitution") {
+				return "", false
+			}
+			key := strings.TrimLeft(arg.Utf8Text(content), "rRbBuUfF")
+			key = strings.Trim(key, "\"'`")
+			return key, key != ""
+		}
+	}
+	return "", false
+}
+
+// newFlagCheck builds a CHECKS_FLAG relationship from the function evaluating a
+// flag to the FeatureFlag entity for its key. The graph builder creates the
+// entity when it first sees the key.
+func newFlagCheck(caller *entities.Entity, key, provider, callee, filePath string, callNode *ts.Node, content []byte) *entities.Relationship {
+	base := fmt.Sprintf("checks_flag:%s:%s@%d", caller.ID, key, callNode.StartByte())
+	hash := sha256.Sum256([]byte(base))
+	rel := entities.NewRelationshipByID(
+		hex.EncodeToString(hash[:])[:16],
+		entities.RelationshipTypeChecksFlag,
+		caller.ID,
+		featureFlagID(key),
+		caller.Type,
+		entities.EntityTypeFeatureFlag,
+	)
+	rel.SetProperty("flag", key)
+	rel.SetProperty("provider", provider)
+	rel.SetProperty("callee", callee)
+	rel.SetProvenance(filePath, callNode, content)
+	return rel
+}
+
+// newFeatureFlag builds the FeatureFlag entity for the first check site of a key
+func newFeatureFlag(check *entities.Relationship) *entities.Entity {
+	key, _ := check.GetProperty("flag").(string)
+	provider, _ := check.GetProperty("provider").(string)
+
+	var entity *entities.Entity
+	if loc := check.Location; loc != nil {
+		entity = entities.NewSpanEntity(check.TargetID, key, entities.EntityTypeFeatureFlag, loc.FilePath,
+			loc.StartByte, loc.EndByte, int(loc.Line), int(loc.Line))
+	} else {
+		entity = entities.NewSpanEntity(check.TargetID, key, entities.EntityTypeFeatureFlag, "", 0, 0, 0, 0)
+	}
+	entity.SetProperty("key", key)
+	entity.SetProperty("provider", provider)
+	return entity
+}
+
+// extractFlagCheck records a Go call that evaluates a feature flag
+func (ga *GoAnalyzer) extractFlagCheck(callNode *ts.Node) {
+	if rel := flagCheckForCall(callNode, ga.flagPatterns, ga.findContainingFunction, ga.currentFile); rel != nil {
+		ga.relationships = append(ga.relationships, rel)
+	}
+}
+
+// extractFlagCheck records a Python call that evaluates a feature flag
+func (pa *PythonAnalyzer) extractFlagCheck(callNode *ts.Node) {
+	if rel := flagCheckForCall(callNode, pa.flagPatterns, pa.findContainingFunction, pa.currentFile); rel != nil {
+		pa.relationships = append(pa.relationships, rel)
+	}
+}
+
+// extractFlagCheck records a TypeScript or JavaScript call that evaluates a feature flag
+func (ta *TypeScriptAnalyzer) extractFlagCheck(callNode *ts.Node) {
+	if rel := flagCheckForCall(callNode, ta.flagPatterns, ta.findContainingFunction, ta.currentFile); rel != nil {
+		ta.relationships = append(ta.relationships, rel)
+	}
+}
+
+// flagCheckForCall classifies a call node, which has the same function and
+// arguments fields in all three grammars. Checks outside any function are not
+// recorded, matching CALLS relationships.
+func flagCheckForCall(callNode *ts.Node, patterns []string, containing func(*ts.Node) *entities.Entity, file *entities.File) *entities.Relationship {
+	functionNode := callNode.ChildByFieldName("function")
+	if functionNode == nil {
+		return nil
+	}
+
+	callee := functionNode.Utf8Text(file.Content)
+	provider, ok := classifyFlagCall(callee, patterns)
+	if !ok {
+		return nil
+	}
+	key, ok := flagKeyArgument(callNode.ChildByFieldName("arguments"), file.Content)
+	if !ok {
+		return nil
+	}
+	caller := containing(callNode)
+	if caller == nil {
+		return nil
+	}
+	return newFlagCheck(caller, key, provider, callee, file.Path, callNode, file.Content)
+}
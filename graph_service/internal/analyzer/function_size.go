@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// decisionPoints are the nodes that add a path through a function: branches,
+// loops, the cases of a switch or match, exception handlers and conditional
+// expressions. && and || are counted separately.
+var decisionPoints = map[string]bool{
+	"if_statement":           true,
+	"elif_clause":            true, // Python
+	"for_statement":          true,
+	"for_in_statement":       true, // TypeScript
+	"for_range_statement":    true,
+	"while_statement":        true,
+	"do_statement":           true,
+	"expression_case":        true, // Go switch
+	"type_case":              true,
+	"communication_case":     true, // Go select
+	"switch_case":            true, // TypeScript switch
+	"case_clause":            true, // Python match
+	"catch_clause":           true,
+	"except_clause":          true,
+	"except_group_clause":    true,
+	"conditional_expression": true, // Python a if c else b
+	"ternary_expression":     true, // TypeScript c ? a : b
+	"for_in_clause":          true, // Python comprehensions
+	"if_clause":              true,
+	"boolean_operator":       true, // Python and, or
+}
+
+// measureFunctions records the size of every function and method of a Go,
+// Python or TypeScript file: "line_count", the lines it spans, declaration
+// included; "statement_count", the statements of its blocks; and
+// "complexity", its cyclomatic complexity, one more than its decision
+// points. Closures and nested functions count toward the function they are
+// written in.
+func measureFunctions(file *entities.File) {
+	if file.Tree == nil {
+		return
+	}
+	switch file.Language {
+	case "go", "python", "typescript", "javascript":
+	default:
+		return
+	}
+
+	for _, function := range append(append([]*entities.Entity{}, file.Functions...), file.Methods...) {
+		if function.Node == nil {
+			continue
+		}
+		statementCount := 0
+		complexity := 1
+		walkTree(function.Node, func(node *ts.Node) {
+			if statementContainers[node.Kind()] {
+				statementCount += len(statements(node))
+			}
+			switch {
+			case decisionPoints[node.Kind()]:
+				complexity++
+			case node.Kind() == "binary_expression":
+				if operator := node.ChildByFieldName("operator"); operator != nil {
+					switch operator.Kind() {
+					case "&&", "||", "??":
+						complexity++
+					}
+				}
+			}
+		})
+		function.SetProperty("line_count", function.EndLine()-function.StartLine()+1)
+		function.SetProperty("statement_count", statementCount)
+		function.SetProperty("complexity", complexity)
+	}
+}
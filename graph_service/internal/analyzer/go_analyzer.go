@@ -18,6 +18,9 @@ type GoAnalyzer struct {
 	language      *ts.Language
 	currentFile   *entities.File
 	relationships []*entities.Relationship
+
+	// flagPatterns are extra function-name patterns that evaluate feature flags
+	flagPatterns []string
 }
 
 // NewGoAnalyzer creates a new Go analyzer
@@ -65,6 +68,7 @@ func (ga *GoAnalyzer) extractEntities(node *ts.Node, parent *entities.Entity) {
 		entity := ga.extractFunction(node, parent)
 		if entity != nil {
 			ga.currentFile.AddEntity(entity)
+			ga.extractTypeParameters(node, entity)
 			if parent != nil {
 				parent.AddChild(entity)
 			}
@@ -82,6 +86,11 @@ func (ga *GoAnalyzer) extractEntities(node *ts.Node, parent *entities.Entity) {
 	case "type_declaration":
 		ga.extractTypeDeclarations(node)
 
+	case "var_declaration", "const_declaration":
+		if node.Parent() != nil && node.Parent().Kind() == "source_file" {
+			ga.extractPackageVariables(node)
+		}
+
 	case "import_declaration":
 		ga.extractImports(node)
 	}
@@ -126,6 +135,8 @@ func (ga *GoAnalyzer) extractFunction(node *ts.Node, parent *entities.Entity) *e
 	}
 
 	entity.Signature = signature
+	params, results := goSignatureTypes(parametersNode, resultNode, ga.currentFile.Content)
+	setSignatureTypes(entity, params, results)
 
 	// Extract body
 	bodyNode := node.ChildByFieldName("body")
@@ -184,6 +195,8 @@ func (ga *GoAnalyzer) extractMethod(node *ts.Node, parent *entities.Entity) *ent
 	}
 
 	entity.Signature = signature
+	params, results := goSignatureTypes(parametersNode, resultNode, ga.currentFile.Content)
+	setSignatureTypes(entity, params, results)
 
 	// Extract body
 	bodyNode := node.ChildByFieldName("body")
@@ -197,10 +210,11 @@ func (ga *GoAnalyzer) extractMethod(node *ts.Node, parent *entities.Entity) *ent
 	return entity
 }
 
-// extractTypeDeclarations extracts type declarations (structs, interfaces, etc.)
+// extractTypeDeclarations extracts type declarations (structs, interfaces, etc.).
+// Aliases (type X = Y) are told from defined types (type X Y) by "alias".
 func (ga *GoAnalyzer) extractTypeDeclarations(node *ts.Node) {
 	ga.walkNode(node, func(n *ts.Node) {
-		if n.Kind() == "type_spec" {
+		if n.Kind() == "type_spec" || n.Kind() == "type_alias" {
 			nameNode := n.ChildByFieldName("name")
 			typeNode := n.ChildByFieldName("type")
 
@@ -221,13 +235,154 @@ func (ga *GoAnalyzer) extractTypeDeclarations(node *ts.Node) {
 				id := ga.generateEntityID(string(entityType), name, n)
 				entity := entities.NewEntity(id, name, entityType, ga.currentFile.Path, n)
 				entity.SetProperty("type_definition", typeText)
+				entity.SetProperty("alias", n.Kind() == "type_alias")
 
 				ga.currentFile.AddEntity(entity)
+				ga.extractTypeParameters(n, entity)
+
+				if typeNode.Kind() == "struct_type" {
+					ga.extractStructFields(typeNode, entity)
+				}
+				if typeNode.Kind() == "interface_type" {
+					ga.extractInterfaceMethods(typeNode, entity)
+				}
+			}
+		}
+	})
+}
+
+// extractPackageVariables extracts package-level vars and consts as Variable
+// entities, one per declared name. Local variables are not extracted.
+func (ga *GoAnalyzer) extractPackageVariables(node *ts.Node) {
+	ga.walkNode(node, func(spec *ts.Node) {
+		if spec.Kind() != "var_spec" && spec.Kind() != "const_spec" {
+			return
+		}
+
+		names := make([]*ts.Node, 0)
+		for i := uint(0); i < spec.NamedChildCount(); i++ {
+			if spec.FieldNameForNamedChild(uint32(i)) == "name" {
+				names = append(names, spec.NamedChild(i))
+			}
+		}
+		valueNode := spec.ChildByFieldName("value")
+
+		for i, nameNode := range names {
+			name := ga.getNodeText(nameNode)
+			if name == "" || name == "_" {
+				continue
+			}
+
+			id := ga.generateEntityID("variable", name, spec)
+			entity := entities.NewEntity(id, name, entities.EntityTypeVariable, ga.currentFile.Path, spec)
+			entity.SetProperty("kind", strings.TrimSuffix(spec.Kind(), "_spec"))
+			if typeNode := spec.ChildByFieldName("type"); typeNode != nil {
+				entity.SetProperty("type", ga.getNodeText(typeNode))
+			}
+			if valueNode != nil {
+				value := valueNode
+				if valueNode.NamedChildCount() == uint(len(names)) {
+					value = valueNode.NamedChild(uint(i))
+				}
+				entity.SetProperty("value", ga.getNodeText(value))
 			}
+			ga.currentFile.AddEntity(entity)
 		}
 	})
 }
 
+// extractInterfaceMethods records the method set of an interface as the
+// "methods" property, one "Name(param types) results" entry per method.
+// Embedded interfaces are listed under "embeds".
+func (ga *GoAnalyzer) extractInterfaceMethods(interfaceNode *ts.Node, interfaceEntity *entities.Entity) {
+	methods := make([]string, 0)
+	embeds := make([]string, 0)
+	for i := uint(0); i < interfaceNode.NamedChildCount(); i++ {
+		elem := interfaceNode.NamedChild(i)
+		switch elem.Kind() {
+		case "method_elem":
+			nameNode := elem.ChildByFieldName("name")
+			if nameNode == nil {
+				continue
+			}
+			params, results := goSignatureTypes(elem.ChildByFieldName("parameters"), elem.ChildByFieldName("result"), ga.currentFile.Content)
+			method := ga.getNodeText(nameNode) + "(" + strings.Join(params, ", ") + ")"
+			if len(results) == 1 {
+				method += " " + results[0]
+			} else if len(results) > 1 {
+				method += " (" + strings.Join(results, ", ") + ")"
+			}
+			methods = append(methods, method)
+		case "type_elem":
+			embeds = append(embeds, ga.getNodeText(elem))
+		}
+	}
+	interfaceEntity.SetProperty("methods", methods)
+	if len(embeds) > 0 {
+		interfaceEntity.SetProperty("embeds", embeds)
+	}
+}
+
+// extractStructFields extracts the fields of a struct as Property entities,
+// including their serialized names, defaults and required flags from struct tags
+func (ga *GoAnalyzer) extractStructFields(structNode *ts.Node, structEntity *entities.Entity) {
+	for i := uint(0); i < structNode.NamedChildCount(); i++ {
+		list := structNode.NamedChild(i)
+		if list.Kind() != "field_declaration_list" {
+			continue
+		}
+		for j := uint(0); j < list.NamedChildCount(); j++ {
+			field := list.NamedChild(j)
+			if field.Kind() != "field_declaration" {
+				continue
+			}
+
+			typeText := ""
+			if typeNode := field.ChildByFieldName("type"); typeNode != nil {
+				typeText = ga.getNodeText(typeNode)
+			}
+			tag := ""
+			if tagNode := field.ChildByFieldName("tag"); tagNode != nil {
+				tag = ga.getNodeText(tagNode)
+			}
+
+			names := make([]string, 0)
+			for k := uint(0); k < field.NamedChildCount(); k++ {
+				if field.FieldNameForNamedChild(uint32(k)) == "name" {
+					names = append(names, ga.getNodeText(field.NamedChild(k)))
+				}
+			}
+			embedded := len(names) == 0
+			if embedded {
+				names = append(names, strings.TrimLeft(typeText, "*"))
+			}
+
+			for _, name := range names {
+				id := ga.generateEntityID("property", structEntity.Name+"."+name, field)
+				prop := entities.NewEntity(id, name, entities.EntityTypeProperty, ga.currentFile.Path, field)
+				prop.Signature = ga.getNodeText(field)
+				prop.SetProperty("type", typeText)
+				if embedded {
+					prop.SetProperty("embedded", true)
+				}
+				if tag != "" {
+					prop.SetProperty("tag", strings.Trim(tag, "`"))
+				}
+
+				jsonName, envName, defaultValue, hasDefault, required := goTagMetadata(name, tag)
+				setFieldMetadata(prop, jsonName, required, defaultValue, hasDefault)
+				if envName != "" {
+					prop.SetProperty("env_name", envName)
+				}
+
+				ga.currentFile.AddEntity(prop)
+				structEntity.AddChild(prop)
+				ga.relationships = append(ga.relationships, newFieldRelationship(ga.currentFile, structEntity, prop))
+			}
+		}
+	}
+}
+
 // extractImports extracts import declarations
 func (ga *GoAnalyzer) extractImports(node *ts.Node) {
 	ga.walkNode(node, func(n *ts.Node) {
@@ -241,6 +396,9 @@ func (ga *GoAnalyzer) extractImports(node *ts.Node) {
 				id := ga.generateEntityID("import", importPath, n)
 				entity := entities.NewEntity(id, importPath, entities.EntityTypeImport, ga.currentFile.Path, n)
 				entity.SetProperty("path", importPath)
+				if nameNode := n.ChildByFieldName("name"); nameNode != nil {
+					entity.SetProperty("alias", ga.getNodeText(nameNode))
+				}
 
 				ga.currentFile.AddEntity(entity)
 			}
@@ -253,6 +411,8 @@ func (ga *GoAnalyzer) extractRelationships(node *ts.Node) {
 	ga.walkNode(node, func(n *ts.Node) {
 		if n.Kind() == "call_expression" {
 			ga.extractCallRelationship(n)
+			ga.extractLogStatement(n)
+			ga.extractFlagCheck(n)
 		}
 	})
 }
@@ -282,8 +442,30 @@ func (ga *GoAnalyzer) extractCallRelationship(callNode *ts.Node) {
 		containingFunction.Type, // Source entity type (function or method)
 		entities.EntityTypeFunction, // Target is assumed to be a function
 	)
+	relationship.SetProvenance(ga.currentFile.Path, callNode, ga.currentFile.Content)
 
 	ga.relationships = append(ga.relationships, relationship)
+	ga.extractErrorFlow(callNode, containingFunction, functionName)
+}
+
+// extractLogStatement records calls to log, slog, zap, logrus and similar loggers
+// as LogStatement entities
+func (ga *GoAnalyzer) extractLogStatement(callNode *ts.Node) {
+	functionNode := callNode.ChildByFieldName("function")
+	if functionNode == nil {
+		return
+	}
+
+	callee := ga.getNodeText(functionNode)
+	logger, method, level, ok := classifyLogCall(callee)
+	if !ok {
+		return
+	}
+
+	message := logMessageSnippet(callNode.ChildByFieldName("arguments"), ga.currentFile.Content)
+	id := ga.generateEntityID("log_statement", callee, callNode)
+	entity := newLogStatement(id, ga.currentFile.Path, callNode, callee, logger, method, level, message, ga.findContainingFunction(callNode))
+	ga.currentFile.AddEntity(entity)
 }
 
 // findContainingFunction finds the function or method that contains the given node
@@ -366,9 +548,9 @@ func (ga *GoAnalyzer) enhanceTestFunction(entity *entities.Entity, node *ts.Node
 		testType := ga.determineGoTestType(entity)
 		entity.SetTestType(testType)
 		
-		// Count assertions in the function body
-		assertionCount := ga.countGoTestAssertions(node)
-		entity.SetAssertionCount(assertionCount)
+		// Extract the assertions in the function body
+		assertions := ga.extractGoAssertions(entity, node)
+		entity.SetAssertionCount(len(assertions))
 		
 		// Try to determine what this test is testing
 		testTarget := ga.determineTestTarget(entity)
@@ -481,37 +663,6 @@ func (ga *GoAnalyzer) determineGoTestType(entity *entities.Entity) string {
 	return "unit"
 }
 
-// countGoTestAssertions counts assertions and test calls in the function body
-func (ga *GoAnalyzer) countGoTestAssertions(node *ts.Node) int {
-	if node == nil {
-		return 0
-	}
-	
-	count := 0
-	bodyNode := node.ChildByFieldName("body")
-	if bodyNode == nil {
-		return 0
-	}
-	
-	// Walk through the body and count assertion patterns
-	ga.walkNode(bodyNode, func(n *ts.Node) {
-		if n.Kind() == "call_expression" {
-			// Get the function being called
-			functionNode := n.ChildByFieldName("function")
-			if functionNode != nil {
-				callText := ga.getNodeText(functionNode)
-				
-				// Common Go test assertion patterns
-				if ga.isGoTestAssertion(callText) {
-					count++
-				}
-			}
-		}
-	})
-	
-	return count
-}
-
 // isGoTestAssertion checks if a function call is a test assertion
 func (ga *GoAnalyzer) isGoTestAssertion(callText string) bool {
 	if callText == "" {
@@ -625,12 +776,14 @@ func (ga *GoAnalyzer) extractTestRelationships(testEntity *entities.Entity, node
 						relID := ga.generateRelationshipID("TESTS", testEntity.ID, targetEntity.ID)
 						rel := entities.NewRelationship(relID, entities.RelationshipTypeTests, testEntity, targetEntity)
 						rel.SetConfidenceScore(0.8) // High confidence for direct function calls
+						rel.SetProvenance(ga.currentFile.Path, n, ga.currentFile.Content)
 						ga.relationships = append(ga.relationships, rel)
 						
 						// Also create COVERS relationship
 						coverRelID := ga.generateRelationshipID("COVERS", testEntity.ID, targetEntity.ID)
 						coverRel := entities.NewRelationship(coverRelID, entities.RelationshipTypeCovers, testEntity, targetEntity)
 						coverRel.SetCoverageType("direct")
+						coverRel.SetProvenance(ga.currentFile.Path, n, ga.currentFile.Content)
 						ga.relationships = append(ga.relationships, coverRel)
 					}
 				}
@@ -354,6 +354,7 @@ func (ega *EnhancedGoAnalyzer) detectStructEmbedding(typeNode *ts.Node, entity *
 					entities.EntityTypeStruct, // Source is a struct that embeds another struct
 					entities.EntityTypeStruct, // Target is the embedded struct
 				)
+				relationship.SetProvenance(ega.currentFile.Path, n, ega.currentFile.Content)
 				ega.relationships = append(ega.relationships, relationship)
 			}
 		}
@@ -399,6 +400,7 @@ func (ega *EnhancedGoAnalyzer) detectInterfaceImplementations() {
 						entities.EntityTypeStruct,    // Source is a struct
 						entities.EntityTypeInterface, // Target is an interface
 					)
+					relationship.SetProvenance(ega.currentFile.Path, structEntity.Node, ega.currentFile.Content)
 					ega.relationships = append(ega.relationships, relationship)
 				}
 			}
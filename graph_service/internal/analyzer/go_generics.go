@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	ts "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// goPredeclaredTypes are the predeclared Go types and constraints, which
+// never resolve to a declaration in the repository
+var goPredeclaredTypes = map[string]bool{
+	"any": true, "comparable": true, "error": true, "bool": true, "string": true,
+	"byte": true, "rune": true, "int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+// extractTypeParameters records the type parameters of a generic function or
+// type declaration as Generic entities, children of owner, and emits a
+// CONSTRAINS relationship from each parameter to every type named in its
+// constraint that may be declared in the repository: interfaces such as
+// Number in [T Number], or the terms of a type set such as ~MyInt.
+// Predeclared constraints like any and comparable, package-qualified ones like
+// cmp.Ordered and the other type parameters of the same list are only kept in
+// the "constraint" property.
+func (ga *GoAnalyzer) extractTypeParameters(node *ts.Node, owner *entities.Entity) {
+	list := node.ChildByFieldName("type_parameters")
+	if list == nil {
+		return
+	}
+
+	declarations := make([]*ts.Node, 0)
+	paramNames := make(map[string]bool)
+	for i := uint(0); i < list.NamedChildCount(); i++ {
+		decl := list.NamedChild(i)
+		if decl.Kind() != "type_parameter_declaration" {
+			continue
+		}
+		declarations = append(declarations, decl)
+		for j := uint(0); j < decl.NamedChildCount(); j++ {
+			if decl.FieldNameForNamedChild(uint32(j)) == "name" {
+				paramNames[ga.getNodeText(decl.NamedChild(j))] = true
+			}
+		}
+	}
+
+	params := make([]string, 0, len(declarations))
+	position := 0
+	for _, decl := range declarations {
+		constraintNode := decl.ChildByFieldName("type")
+		constraint := ""
+		if constraintNode != nil {
+			constraint = ga.getNodeText(constraintNode)
+		}
+		params = append(params, ga.getNodeText(decl))
+
+		for j := uint(0); j < decl.NamedChildCount(); j++ {
+			if decl.FieldNameForNamedChild(uint32(j)) != "name" {
+				continue
+			}
+			name := ga.getNodeText(decl.NamedChild(j))
+			id := ga.generateEntityID("generic", owner.Name+"."+name, decl.NamedChild(j))
+			generic := entities.NewEntity(id, name, entities.EntityTypeGeneric, ga.currentFile.Path, decl)
+			generic.Signature = name + " " + constraint
+			generic.SetProperty("constraint", constraint)
+			generic.SetProperty("position", position)
+			generic.SetProperty("owner", owner.ID)
+			generic.SetProperty("owner_name", owner.Name)
+			generic.SetProperty("language", "go")
+			position++
+
+			ga.currentFile.AddEntity(generic)
+			owner.AddChild(generic)
+
+			if constraintNode != nil {
+				ga.extractConstraintRelationships(generic, constraintNode, paramNames)
+			}
+		}
+	}
+	owner.SetProperty("type_parameters", params)
+}
+
+// extractConstraintRelationships emits CONSTRAINS relationships from a type
+// parameter to the types named in its constraint. Terms written as ~T are
+// marked "approximate", since any type whose underlying type is T satisfies
+// them.
+func (ga *GoAnalyzer) extractConstraintRelationships(generic *entities.Entity, constraint *ts.Node, paramNames map[string]bool) {
+	seen := make(map[string]bool)
+	walkTree(constraint, func(n *ts.Node) {
+		if n.Kind() != "type_identifier" || n.Parent().Kind() == "qualified_type" {
+			return
+		}
+		name := ga.getNodeText(n)
+		if goPredeclaredTypes[name] || paramNames[name] || seen[name] {
+			return
+		}
+		seen[name] = true
+
+		constraintType := "exact"
+		if hasAncestor(n, "negated_type") {
+			constraintType = "approximate"
+		}
+		relID := ga.generateRelationshipID("constrains", generic.ID, name)
+		rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeConstrains, generic.ID, name,
+			entities.EntityTypeGeneric, entities.EntityTypeInterface)
+		rel.SetProperty("constraint_type", constraintType)
+		rel.SetProvenance(ga.currentFile.Path, n, ga.currentFile.Content)
+		ga.relationships = append(ga.relationships, rel)
+	})
+}
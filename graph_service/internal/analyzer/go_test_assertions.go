@@ -0,0 +1,437 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Assertion styles of Go tests, stored as the assertion_type of their
+// Assertion entities
+const (
+	GoAssertionTestify     = "testify"
+	GoAssertionGotestTools = "gotest.tools"
+	GoAssertionComparison  = "comparison" // if got != want { t.Errorf(...) }
+)
+
+// goAssertionPackages maps the import paths of assertion libraries to their
+// assertion style
+var goAssertionPackages = map[string]string{
+	"github.com/stretchr/testify/assert":  GoAssertionTestify,
+	"github.com/stretchr/testify/require": GoAssertionTestify,
+	"gotest.tools/assert":                 GoAssertionGotestTools,
+	"gotest.tools/v3/assert":              GoAssertionGotestTools,
+}
+
+// goTestingTypes are the parameter types whose methods fail a test
+var goTestingTypes = map[string]bool{
+	"*testing.T": true,
+	"*testing.B": true,
+	"*testing.F": true,
+	"testing.TB": true,
+}
+
+// goAssertionConstructors are the functions of assertion libraries that make
+// no assertion but return an instance making them, as assert.New(t) does
+var goAssertionConstructors = map[string]bool{
+	"New": true,
+}
+
+// goFailureMethods are the testing.TB methods that mark a test as failed
+var goFailureMethods = map[string]bool{
+	"Error":   true,
+	"Errorf":  true,
+	"Fatal":   true,
+	"Fatalf":  true,
+	"Fail":    true,
+	"FailNow": true,
+}
+
+// testifyExpectedFirst are the testify assertions taking the expected value
+// before the actual one; the others, like gotest.tools, take the actual
+// value first
+var testifyExpectedFirst = map[string]bool{
+	"Equal":          true,
+	"NotEqual":       true,
+	"EqualValues":    true,
+	"NotEqualValues": true,
+	"Exactly":        true,
+	"Same":           true,
+	"NotSame":        true,
+	"ElementsMatch":  true,
+	"InDelta":        true,
+	"InDeltaSlice":   true,
+	"InEpsilon":      true,
+	"JSONEq":         true,
+	"YAMLEq":         true,
+	"IsType":         true,
+}
+
+// goImpliedExpected is the expected value of assertions that only take the
+// actual value
+var goImpliedExpected = map[string]string{
+	"True":     "true",
+	"False":    "false",
+	"Nil":      "nil",
+	"NotNil":   "not nil",
+	"NoError":  "nil",
+	"NilError": "nil",
+	"Error":    "error",
+	"Zero":     "zero value",
+	"NotZero":  "non-zero value",
+	"Empty":    "empty",
+	"NotEmpty": "not empty",
+	"Assert":   "true",
+	"Check":    "true",
+}
+
+// goAssertion is an assertion found in the body of a Go test
+type goAssertion struct {
+	name     string
+	style    string
+	method   string
+	expected string
+	actual   string
+	fatal    bool
+}
+
+// extractGoAssertions creates an Assertion entity, linked to the test by an
+// ASSERTS relationship, for each assertion in the body of a test function,
+// t.Run closures included. Assertions are testify and gotest.tools calls,
+// including the methods of an instance such as a := assert.New(t), and if
+// statements failing the test through t.Error and the like, which count as
+// one assertion however many failure calls they make.
+//
+// The ASSERTS relationships carry no location so that, with ExtractNested,
+// they stay with the test rather than move to the closure of a subtest.
+func (ga *GoAnalyzer) extractGoAssertions(test *entities.Entity, node *ts.Node) []*entities.Entity {
+	body := node.ChildByFieldName("body")
+	if body == nil {
+		return nil
+	}
+
+	content := ga.currentFile.Content
+	packages := goAssertionImports(ga.currentFile.Tree.RootNode(), content)
+	instances := goAssertionInstances(body, packages, content)
+	testing := goTestingParameters(node, content)
+	ga.walkNode(body, func(n *ts.Node) {
+		if n.Kind() == "func_literal" {
+			for name := range goTestingParameters(n, content) {
+				testing[name] = true
+			}
+		}
+	})
+
+	found := make([]*entities.Entity, 0)
+	ga.walkNode(body, func(n *ts.Node) {
+		var assertion *goAssertion
+		switch n.Kind() {
+		case "call_expression":
+			assertion = goLibraryAssertion(n, packages, instances, content)
+		case "if_statement":
+			assertion = goComparisonAssertion(n, testing, content)
+		}
+		if assertion == nil {
+			return
+		}
+
+		id := ga.generateEntityID("assertion", assertion.name, n)
+		entity := entities.NewEntity(id, assertion.name, entities.EntityTypeAssertion, ga.currentFile.Path, n)
+		entity.SetProperty("assertion_type", assertion.style)
+		entity.SetProperty("method", assertion.method)
+		entity.SetProperty("expected_value", assertion.expected)
+		entity.SetProperty("actual_value", assertion.actual)
+		entity.SetProperty("fatal", assertion.fatal)
+		entity.SetProperty("test_function", test.ID)
+		entity.SetProperty("test_function_name", test.GetFullName())
+		ga.currentFile.AddEntity(entity)
+
+		rel := entities.NewRelationship(ga.generateRelationshipID("ASSERTS", test.ID, id),
+			entities.RelationshipTypeAsserts, test, entity)
+		rel.SetProperty("assertion_type", assertion.style)
+		rel.SetProvenance(ga.currentFile.Path, n, ga.currentFile.Content)
+		ga.relationships = append(ga.relationships, rel)
+		found = append(found, entity)
+	})
+	return found
+}
+
+// goAssertionImports maps the names under which a file imports assertion
+// libraries to their import paths
+func goAssertionImports(root *ts.Node, content []byte) map[string]string {
+	packages := make(map[string]string)
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		declaration := root.NamedChild(i)
+		if declaration.Kind() != "import_declaration" {
+			continue
+		}
+		walkTree(declaration, func(spec *ts.Node) {
+			if spec.Kind() != "import_spec" {
+				return
+			}
+			pathNode := spec.ChildByFieldName("path")
+			if pathNode == nil {
+				return
+			}
+			path, err := strconv.Unquote(pathNode.Utf8Text(content))
+			if err != nil || goAssertionPackages[path] == "" {
+				return
+			}
+			name := path[strings.LastIndex(path, "/")+1:]
+			if alias := spec.ChildByFieldName("name"); alias != nil {
+				name = alias.Utf8Text(content)
+			}
+			packages[name] = path
+		})
+	}
+	return packages
+}
+
+// goAssertionInstances maps the variables of a test body holding an
+// assertion instance, like the a of a := assert.New(t), to the import path of
+// its library
+func goAssertionInstances(body *ts.Node, packages map[string]string, content []byte) map[string]string {
+	instances := make(map[string]string)
+	walkTree(body, func(n *ts.Node) {
+		var left, right *ts.Node
+		switch n.Kind() {
+		case "short_var_declaration", "assignment_statement":
+			left, right = n.ChildByFieldName("left"), n.ChildByFieldName("right")
+		case "var_spec":
+			left, right = n, n.ChildByFieldName("value")
+		}
+		if left == nil || right == nil || right.NamedChildCount() != 1 {
+			return
+		}
+		call := right.NamedChild(0)
+		if call.Kind() != "call_expression" {
+			return
+		}
+		function := call.ChildByFieldName("function")
+		if function == nil || function.Kind() != "selector_expression" {
+			return
+		}
+		operand := function.ChildByFieldName("operand")
+		field := function.ChildByFieldName("field")
+		if operand == nil || field == nil || !goAssertionConstructors[field.Utf8Text(content)] {
+			return
+		}
+		path := packages[operand.Utf8Text(content)]
+		if path == "" {
+			return
+		}
+		for i := uint(0); i < left.NamedChildCount(); i++ {
+			if name := left.NamedChild(i); name.Kind() == "identifier" {
+				instances[name.Utf8Text(content)] = path
+				break
+			}
+		}
+	})
+	return instances
+}
+
+// goTestingParameters returns the names of the parameters of a function
+// that can fail a test, like the t of func(t *testing.T)
+func goTestingParameters(function *ts.Node, content []byte) map[string]bool {
+	names := make(map[string]bool)
+	parameters := function.ChildByFieldName("parameters")
+	if parameters == nil {
+		return names
+	}
+	for i := uint(0); i < parameters.NamedChildCount(); i++ {
+		parameter := parameters.NamedChild(i)
+		typeNode := parameter.ChildByFieldName("type")
+		if parameter.Kind() != "parameter_declaration" || typeNode == nil || !goTestingTypes[typeNode.Utf8Text(content)] {
+			continue
+		}
+		for j := uint(0); j < parameter.NamedChildCount(); j++ {
+			if name := parameter.NamedChild(j); name.Kind() == "identifier" {
+				names[name.Utf8Text(content)] = true
+			}
+		}
+	}
+	return names
+}
+
+// goLibraryAssertion returns the assertion made by a call to a testify or
+// gotest.tools assertion, either a package function or a method of an
+// assertion instance, or nil. Constructors such as assert.New are not
+// assertions.
+func goLibraryAssertion(call *ts.Node, packages, instances map[string]string, content []byte) *goAssertion {
+	function := call.ChildByFieldName("function")
+	if function == nil || function.Kind() != "selector_expression" {
+		return nil
+	}
+	operand := function.ChildByFieldName("operand")
+	field := function.ChildByFieldName("field")
+	if operand == nil || field == nil || operand.Kind() != "identifier" {
+		return nil
+	}
+	// Package functions take the test as their first argument, instance
+	// methods do not
+	first := uint(1)
+	path, ok := packages[operand.Utf8Text(content)]
+	if ok && goAssertionConstructors[field.Utf8Text(content)] {
+		return nil
+	}
+	if !ok {
+		path = instances[operand.Utf8Text(content)]
+		first = 0
+	}
+	style := goAssertionPackages[path]
+	if style == "" {
+		return nil
+	}
+
+	assertion := &goAssertion{
+		name:   function.Utf8Text(content),
+		style:  style,
+		method: field.Utf8Text(content),
+	}
+	if style == GoAssertionTestify {
+		assertion.fatal = strings.HasSuffix(path, "/require")
+	} else {
+		assertion.fatal = assertion.method != "Check"
+	}
+
+	// testify's formatted variants such as Equalf take the same values as
+	// their plain counterpart
+	var values []*ts.Node
+	if arguments := call.ChildByFieldName("arguments"); arguments != nil {
+		for i := first; i < arguments.NamedChildCount(); i++ {
+			values = append(values, arguments.NamedChild(i))
+		}
+	}
+	method := assertion.method
+	if base := strings.TrimSuffix(method, "f"); style == GoAssertionTestify && base != method {
+		method = base
+	}
+
+	if style == GoAssertionGotestTools && (method == "Assert" || method == "Check") && len(values) > 0 {
+		// assert.Assert(t, is.Equal(got, want)) or assert.Assert(t, got == want)
+		if actual, expected, ok := goComparedValues(values[0], content); ok {
+			assertion.actual, assertion.expected = actual, expected
+			return assertion
+		}
+	}
+	switch {
+	case len(values) >= 2 && style == GoAssertionTestify && testifyExpectedFirst[method]:
+		assertion.expected = values[0].Utf8Text(content)
+		assertion.actual = values[1].Utf8Text(content)
+	case len(values) >= 2 && goImpliedExpected[method] == "":
+		assertion.actual = values[0].Utf8Text(content)
+		assertion.expected = values[1].Utf8Text(content)
+	case len(values) >= 1:
+		assertion.actual = values[0].Utf8Text(content)
+		assertion.expected = goImpliedExpected[method]
+	}
+	return assertion
+}
+
+// goComparisonAssertion returns the assertion made by an if statement whose
+// body fails the test, or nil. The compared values come from a comparison
+// such as got != want or from an equality check such as
+// !reflect.DeepEqual(got, want); otherwise the condition is the actual value.
+func goComparisonAssertion(statement *ts.Node, testing map[string]bool, content []byte) *goAssertion {
+	condition := statement.ChildByFieldName("condition")
+	consequence := statement.ChildByFieldName("consequence")
+	if condition == nil || consequence == nil {
+		return nil
+	}
+
+	method := ""
+	for i := uint(0); i < consequence.NamedChildCount() && method == ""; i++ {
+		child := consequence.NamedChild(i)
+		if child.Kind() == "expression_statement" && child.NamedChildCount() > 0 {
+			child = child.NamedChild(0)
+		}
+		if child.Kind() != "call_expression" {
+			continue
+		}
+		function := child.ChildByFieldName("function")
+		if function == nil || function.Kind() != "selector_expression" {
+			continue
+		}
+		operand := function.ChildByFieldName("operand")
+		field := function.ChildByFieldName("field")
+		if operand != nil && field != nil && testing[operand.Utf8Text(content)] && goFailureMethods[field.Utf8Text(content)] {
+			method = field.Utf8Text(content)
+		}
+	}
+	if method == "" {
+		return nil
+	}
+
+	assertion := &goAssertion{
+		name:   strings.Join(strings.Fields(condition.Utf8Text(content)), " "),
+		style:  GoAssertionComparison,
+		method: method,
+		fatal:  strings.HasPrefix(method, "Fatal") || method == "FailNow",
+	}
+	if actual, expected, ok := goComparedValues(condition, content); ok {
+		assertion.actual, assertion.expected = actual, expected
+	} else {
+		assertion.actual = assertion.name
+	}
+	return assertion
+}
+
+// goComparedValues returns the actual and expected values of a comparison
+// (got != want) or of a call comparing two values (reflect.DeepEqual(got,
+// want), is.Equal(got, want)), negated or not. The expected value is the
+// literal or the operand named like want or expected, or else the second.
+func goComparedValues(node *ts.Node, content []byte) (actual, expected string, ok bool) {
+	for node.Kind() == "parenthesized_expression" || node.Kind() == "unary_expression" {
+		if node.Kind() == "unary_expression" {
+			if operator := node.ChildByFieldName("operator"); operator == nil || operator.Utf8Text(content) != "!" {
+				return "", "", false
+			}
+			node = node.ChildByFieldName("operand")
+		} else {
+			node = node.NamedChild(0)
+		}
+		if node == nil {
+			return "", "", false
+		}
+	}
+
+	var first, second *ts.Node
+	switch node.Kind() {
+	case "binary_expression":
+		switch node.ChildByFieldName("operator").Utf8Text(content) {
+		case "==", "!=", "<", "<=", ">", ">=":
+			first, second = node.ChildByFieldName("left"), node.ChildByFieldName("right")
+		}
+	case "call_expression":
+		if arguments := node.ChildByFieldName("arguments"); arguments != nil && arguments.NamedChildCount() == 2 {
+			first, second = arguments.NamedChild(0), arguments.NamedChild(1)
+		}
+	}
+	if first == nil || second == nil {
+		return "", "", false
+	}
+	if isGoExpectedValue(first, content) && !isGoExpectedValue(second, content) {
+		first, second = second, first
+	}
+	return first.Utf8Text(content), second.Utf8Text(content), true
+}
+
+// isGoExpectedValue reports whether an operand of a comparison reads as the
+// expected value: a literal, or a name such as want, tt.expected or expErr
+func isGoExpectedValue(node *ts.Node, content []byte) bool {
+	switch node.Kind() {
+	case "int_literal", "float_literal", "imaginary_literal", "rune_literal",
+		"interpreted_string_literal", "raw_string_literal", "nil", "true", "false":
+		return true
+	case "selector_expression":
+		node = node.ChildByFieldName("field")
+	}
+	if node == nil || (node.Kind() != "identifier" && node.Kind() != "field_identifier") {
+		return false
+	}
+	name := strings.ToLower(node.Utf8Text(content))
+	return strings.HasPrefix(name, "want") || strings.HasPrefix(name, "exp")
+}
@@ -3,7 +3,9 @@ package analyzer
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -36,6 +38,8 @@ type GraphBuilder struct {
 	pythonAnalyzer     *PythonAnalyzer
 	goAnalyzer         *GoAnalyzer
 	typescriptAnalyzer *TypeScriptAnalyzer
+	hclAnalyzer        *HCLAnalyzer
+	sqlAnalyzer        *SQLAnalyzer
 
 	// Enhanced analyzers for better analysis
 	enhancedGoAnalyzer *EnhancedGoAnalyzer
@@ -46,10 +50,39 @@ type GraphBuilder struct {
 	allEntities             map[string]*entities.Entity
 	unresolvedRelationships []*entities.Relationship // Relationships with unresolved references
 	resolvedRelationships   []*entities.Relationship // Fully resolved relationships
+	pathIndex               []*entities.Entity       // Entities sorted by file path, see indexPaths
+
+	// Checkpointing state
+	rootPath     string                     // Absolute path of the repository being built
+	fingerprints map[string]FileFingerprint // Size and modification time of each analyzed file
+
+	// goModules maps the directory of each go.mod found by the walk to the
+	// module path it declares, for resolving Go imports to packages
+	goModules map[string]string
+
+	// unanalyzed lists the supported files the walk reached after
+	// GraphBuilderConfig.Deadline and did not parse
+	unanalyzed []string
+
+	// tables maps the table names of ORM models to the models, for
+	// resolving the AFFECTS relationships of migrations
+	tables map[string][]*entities.Entity
+
+	// errorMessages collects the error construction sites of every file,
+	// checked against the conventions of their language once all are known
+	errorMessages []errorMessage
+
+	// goAccesses and goLaunches collect the uses of shared state and the
+	// goroutine launches of every Go file, for checkRaceRisks
+	goAccesses []goAccess
+	goLaunches []goLaunch
 
 	// Analysis configuration
 	config *GraphBuilderConfig
 
+	// mutationLog records what each build stores; nil unless configured
+	mutationLog *MutationLog
+
 	// Performance tracking
 	stats      *BuildStats
 	phaseStats map[string]*PhaseStats
@@ -62,8 +95,53 @@ type GraphBuilderConfig struct {
 	EnableBuiltinResolution bool
 	MaxFileSize             int64 // Maximum file size to analyze (in bytes)
 	// Paths/patterns to ignore during static repository walk
-	// Matches if substring is present in the path or basename matches filepath.Match
+	// Matches if substring is present in the path (relative to the repository) or basename matches filepath.Match
 	IgnorePatterns []string
+	// Languages restricts the walk to files of these languages (see
+	// NormalizeLanguage); empty analyzes every supported language
+	Languages []string
+	// FeatureFlagFunctions are extra function-name patterns (path.Match syntax,
+	// e.g. "featureOn" or "flags.*") whose calls evaluate a feature flag, in
+	// addition to the LaunchDarkly, Unleash and OpenFeature SDK methods
+	FeatureFlagFunctions []string
+	// ErrorMessageRules are the error message conventions of each language,
+	// by normalized name; languages without rules are not checked
+	ErrorMessageRules map[string]ErrorMessageRules
+
+	// EntityTypes and RelationshipTypes restrict which entities and
+	// relationships are stored in the database; empty stores every type.
+	// Analysis is unaffected, so the builder still holds everything in memory.
+	EntityTypes       []entities.EntityType
+	RelationshipTypes []entities.RelationshipType
+
+	// ExtractNested keeps functions defined inside other functions as nested
+	// Function entities; when false they are removed (see applyNesting)
+	ExtractNested bool
+
+	// Checkpointing options. When ManifestPath is set, storage (Phase 3) is
+	// done in batches of CheckpointEvery files and progress is written to the
+	// manifest after each batch; ResumeFromCheckpoint skips the files an
+	// earlier, interrupted build already stored. Phases 1 and 2 are not
+	// checkpointed and always run in full.
+	ManifestPath         string
+	CheckpointEvery      int
+	ResumeFromCheckpoint bool
+
+	// RelationshipsMigrated makes a resumed build store the relationships of
+	// every file again, as after a schema migration that added relationship
+	// pairs the stored relationships are incomplete (see
+	// KuzuDatabase.RelationshipsMigrated)
+	RelationshipsMigrated bool
+
+	// MutationLogPath, when set, is a JSONL file to which every change the
+	// builder makes to the database is appended (see MutationLog)
+	MutationLogPath string
+
+	// Deadline, when set, stops the walk from parsing files once it has
+	// passed. The remaining supported files are listed by UnanalyzedFiles
+	// and the build completes with the files analyzed so far; a file being
+	// parsed when the deadline passes is finished first.
+	Deadline time.Time
 
 	// Performance options
 	EnableParallelAnalysis bool
@@ -86,6 +164,7 @@ func DefaultGraphBuilderConfig() *GraphBuilderConfig {
 		EnableDetailedLogging:       false,
 		SaveUnresolvedRelationships: true,
 		GenerateAnalysisReport:      true,
+		ErrorMessageRules:           DefaultErrorMessageRules(),
 		IgnorePatterns: []string{
 			".git",
 			"node_modules",
@@ -123,7 +202,7 @@ func NewGraphBuilder(database *db.KuzuDatabase) *GraphBuilder {
 
 // NewGraphBuilderWithConfig creates a new graph builder with custom configuration
 func NewGraphBuilderWithConfig(database *db.KuzuDatabase, config *GraphBuilderConfig) *GraphBuilder {
-	return &GraphBuilder{
+	gb := &GraphBuilder{
 		database: database,
 		registry: entities.NewEntityRegistry(),
 		config:   config,
@@ -132,6 +211,8 @@ func NewGraphBuilderWithConfig(database *db.KuzuDatabase, config *GraphBuilderCo
 		pythonAnalyzer:     NewPythonAnalyzer(),
 		goAnalyzer:         NewGoAnalyzer(),
 		typescriptAnalyzer: NewTypeScriptAnalyzer(),
+		hclAnalyzer:        NewHCLAnalyzer(),
+		sqlAnalyzer:        NewSQLAnalyzer(),
 		enhancedGoAnalyzer: NewEnhancedGoAnalyzer(),
 		advancedGoAnalyzer: NewAdvancedGoAnalyzer(),
 
@@ -140,11 +221,54 @@ func NewGraphBuilderWithConfig(database *db.KuzuDatabase, config *GraphBuilderCo
 		allEntities:             make(map[string]*entities.Entity),
 		unresolvedRelationships: make([]*entities.Relationship, 0),
 		resolvedRelationships:   make([]*entities.Relationship, 0),
+		fingerprints:            make(map[string]FileFingerprint),
+		goModules:               make(map[string]string),
 
 		// Initialize tracking
-		stats:      &BuildStats{},
+		stats:      &BuildStats{LanguageStats: make(map[string]*LanguageStats)},
 		phaseStats: make(map[string]*PhaseStats),
 	}
+
+	gb.goAnalyzer.flagPatterns = config.FeatureFlagFunctions
+	gb.pythonAnalyzer.flagPatterns = config.FeatureFlagFunctions
+	gb.typescriptAnalyzer.flagPatterns = config.FeatureFlagFunctions
+	if config.MutationLogPath != "" {
+		gb.mutationLog = NewMutationLog(config.MutationLogPath)
+	}
+	return gb
+}
+
+// RestoreGraphBuilder returns a builder holding a graph built earlier, such as
+// one read back from a snapshot: the files, every entity by ID, and the
+// resolved and unresolved relationships, which should point at those
+// entities. It has no database, and the files and entities have no syntax
+// trees, and it has no language analyzers, so it serves the graph as it is
+// but cannot build or store it.
+func RestoreGraphBuilder(files map[string]*entities.File, allEntities map[string]*entities.Entity,
+	resolved, unresolved []*entities.Relationship) (*GraphBuilder, error) {
+	gb := &GraphBuilder{
+		registry:                entities.NewEntityRegistry(),
+		config:                  DefaultGraphBuilderConfig(),
+		files:                   files,
+		allEntities:             allEntities,
+		unresolvedRelationships: unresolved,
+		resolvedRelationships:   resolved,
+		fingerprints:            make(map[string]FileFingerprint),
+		goModules:               make(map[string]string),
+		stats:                   &BuildStats{LanguageStats: make(map[string]*LanguageStats)},
+		phaseStats:              make(map[string]*PhaseStats),
+	}
+	if err := gb.registerAllEntities(); err != nil {
+		return nil, fmt.Errorf("failed to register entities: %w", err)
+	}
+	gb.indexPaths()
+	return gb, nil
+}
+
+// MutationLog returns the log of changes the builder made to the database, or
+// nil when GraphBuilderConfig.MutationLogPath is not set
+func (gb *GraphBuilder) MutationLog() *MutationLog {
+	return gb.mutationLog
 }
 
 // BuildStats contains comprehensive statistics about the graph building process
@@ -177,6 +301,17 @@ type BuildStats struct {
 	RelationshipResolutionTime time.Duration
 	DatabaseStorageTime        time.Duration
 
+	// Phase 1 breakdown: time spent walking the tree and reading files,
+	// parsing and extracting entities, running the detectors on the parsed
+	// files, and registering the entities in the registry
+	WalkTime         time.Duration
+	ParseTime        time.Duration
+	DetectTime       time.Duration
+	RegistrationTime time.Duration
+
+	// Per-language parse statistics keyed by language name ("go", "python", ...)
+	LanguageStats map[string]*LanguageStats
+
 	// Error tracking
 	ErrorsEncountered int
 	WarningsGenerated int
@@ -185,6 +320,13 @@ type BuildStats struct {
 	RegistryStats *entities.RegistryStats
 }
 
+// LanguageStats tracks parse throughput for a single language
+type LanguageStats struct {
+	Files     int
+	Bytes     int64
+	ParseTime time.Duration
+}
+
 // BuildGraph analyzes all files in a directory using comprehensive two-phase analysis
 func (gb *GraphBuilder) BuildGraph(rootPath string) (*BuildStats, error) {
 	startTime := time.Now()
@@ -193,12 +335,18 @@ func (gb *GraphBuilder) BuildGraph(rootPath string) (*BuildStats, error) {
 		fmt.Printf("Starting comprehensive graph analysis of: %s\n", rootPath)
 	}
 
+	gb.rootPath = rootPath
+	if abs, err := filepath.Abs(rootPath); err == nil {
+		gb.rootPath = abs
+	}
+
 	// Phase 1: Entity Discovery and Registration
 	phase1Stats, err := gb.executePhase1(rootPath)
 	if err != nil {
 		return gb.stats, fmt.Errorf("phase 1 failed: %w", err)
 	}
 	gb.phaseStats["phase1"] = phase1Stats
+	gb.indexPaths()
 
 	// Phase 2: Relationship Resolution
 	phase2Stats, err := gb.executePhase2()
@@ -244,6 +392,9 @@ func (gb *GraphBuilder) executePhase1(rootPath string) (*PhaseStats, error) {
 		fmt.Println("Phase 1: Discovering and registering entities...")
 	}
 
+	// A go.mod inside the repository, found by the walk, takes precedence
+	gb.recordEnclosingGoModule(rootPath)
+
 	// Walk through all files in the directory
 	err := filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -252,16 +403,24 @@ func (gb *GraphBuilder) executePhase1(rootPath string) (*PhaseStats, error) {
 			return nil // Continue walking
 		}
 
+		// Convert to relative path for storage and matching, so that the
+		// directories above the repository are not matched by IgnorePatterns
+		relPath, err := filepath.Rel(rootPath, path)
+		if err != nil {
+			// If we can't make it relative, use the full path
+			relPath = path
+		}
+
 		// Skip directories (and prevent descent) if ignored
 		if info.IsDir() {
-			if gb.shouldIgnorePath(path) {
+			if gb.shouldIgnorePath(relPath) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
 		// Skip ignored files
-		if gb.shouldIgnorePath(path) {
+		if gb.shouldIgnorePath(relPath) {
 			gb.stats.FilesSkipped++
 			return nil
 		}
@@ -275,15 +434,21 @@ func (gb *GraphBuilder) executePhase1(rootPath string) (*PhaseStats, error) {
 			return nil
 		}
 
+		if info.Name() == "go.mod" {
+			gb.recordGoModule(rootPath, path)
+		}
+
 		// Process supported file types
 		if gb.isSupported(path) {
-			// Convert to relative path for storage
-			relPath, err := filepath.Rel(rootPath, path)
-			if err != nil {
-				// If we can't make it relative, use the full path
-				relPath = path
+			// Out of time: list the file instead of parsing it. It gets
+			// no fingerprint, so a checkpoint covers the analyzed files only
+			if !gb.config.Deadline.IsZero() && time.Now().After(gb.config.Deadline) {
+				gb.unanalyzed = append(gb.unanalyzed, relPath)
+				gb.stats.FilesSkipped++
+				return nil
 			}
-			
+
+			gb.recordFingerprint(relPath, info)
 			err = gb.processFilePhase1WithPaths(path, relPath)
 			if err != nil {
 				// Silently track error without printing to console
@@ -305,16 +470,30 @@ func (gb *GraphBuilder) executePhase1(rootPath string) (*PhaseStats, error) {
 		return phaseStats, fmt.Errorf("failed to walk directory: %w", err)
 	}
 
+	gb.stats.WalkTime = time.Since(phaseStats.StartTime) - gb.stats.ParseTime - gb.stats.DetectTime
+
+	// Detectors that need every file
+	detectStart := time.Now()
+	orderMigrations(gb.files)
+	gb.indexTables()
+	gb.checkErrorMessages()
+	gb.checkRaceRisks()
+	gb.linkBuildAlternatives()
+	gb.stats.DetectTime += time.Since(detectStart)
+
 	// Register all entities in the registry
+	registrationStart := time.Now()
 	err = gb.registerAllEntities()
 	if err != nil {
 		return phaseStats, fmt.Errorf("failed to register entities: %w", err)
 	}
+	gb.stats.RegistrationTime = time.Since(registrationStart)
 
 	phaseStats.EndTime = time.Now()
 	phaseStats.Duration = phaseStats.EndTime.Sub(phaseStats.StartTime)
 	phaseStats.Details["entities_registered"] = len(gb.allEntities)
 	phaseStats.Details["unresolved_relationships"] = len(gb.unresolvedRelationships)
+	phaseStats.Details["files_unanalyzed"] = len(gb.unanalyzed)
 
 	if gb.config.EnableDetailedLogging {
 		fmt.Printf("Phase 1 completed: %d entities registered, %d unresolved relationships\n",
@@ -324,6 +503,18 @@ func (gb *GraphBuilder) executePhase1(rootPath string) (*PhaseStats, error) {
 	return phaseStats, nil
 }
 
+// unstoredWhenUnresolved are the relationship types that are not stored when
+// their target does not resolve, even with SaveUnresolvedRelationships. Their
+// unresolved targets are mostly locals, builtins and standard library names,
+// which would only fill the database with edges to nowhere. GetIgnoredErrors
+// reads the error-flow relationships from the builder, not the database.
+var unstoredWhenUnresolved = map[entities.RelationshipType]bool{
+	entities.RelationshipTypeReferences:      true,
+	entities.RelationshipTypeHandlesError:    true,
+	entities.RelationshipTypeIgnoresError:    true,
+	entities.RelationshipTypePropagatesError: true,
+}
+
 // executePhase2 performs relationship resolution using the EntityRegistry
 func (gb *GraphBuilder) executePhase2() (*PhaseStats, error) {
 	phaseStats := &PhaseStats{
@@ -339,6 +530,7 @@ func (gb *GraphBuilder) executePhase2() (*PhaseStats, error) {
 	resolvedCount := 0
 	failedCount := 0
 	crossFileCount := 0
+	imports := make(map[string]map[string]string)
 
 	for _, relationship := range gb.unresolvedRelationships {
 		resolvedRel, err := gb.resolveRelationship(relationship)
@@ -349,8 +541,16 @@ func (gb *GraphBuilder) executePhase2() (*PhaseStats, error) {
 			failedCount++
 			gb.stats.RelationshipsFailed++
 
+			// Keep Python calls the analyzer cannot follow visible, like
+			// the dynamic calls Phase 1 records
+			if call := gb.newPhase2UnresolvedCall(relationship, imports); call != nil && gb.allEntities[call.ID] == nil {
+				gb.files[call.FilePath].AddEntity(call)
+				gb.allEntities[call.ID] = call
+				gb.stats.EntitiesFound++
+			}
+
 			// Still store unresolved relationships if configured
-			if gb.config.SaveUnresolvedRelationships {
+			if gb.config.SaveUnresolvedRelationships && !unstoredWhenUnresolved[relationship.Type] {
 				gb.resolvedRelationships = append(gb.resolvedRelationships, relationship)
 			}
 		} else {
@@ -414,20 +614,65 @@ func (gb *GraphBuilder) executePhase3() (*PhaseStats, error) {
 
 // Helper methods for the two-phase analysis
 
-// isSupported checks if a file type is supported for analysis
+// fileLanguages maps supported file extensions to the language they are analyzed as
+var fileLanguages = map[string]string{
+	".py":  "python",
+	".go":  "go",
+	".ts":  "typescript",
+	".tsx": "typescript",
+	".js":  "javascript",
+	".jsx": "javascript",
+	".tf":  "hcl",
+	".sql": "sql",
+}
+
+// languageAliases maps alternative spellings accepted by NormalizeLanguage
+var languageAliases = map[string]string{
+	"golang":    "go",
+	"py":        "python",
+	"ts":        "typescript",
+	"js":        "javascript",
+	"terraform": "hcl",
+	"tf":        "hcl",
+}
+
+// NormalizeLanguage returns the canonical name of a supported language ("go",
+// "python", "typescript", "javascript", "hcl" or "sql"), accepting common aliases such
+// as "golang", "ts" or "terraform" in any case
+func NormalizeLanguage(name string) (string, error) {
+	lang := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := languageAliases[lang]; ok {
+		lang = alias
+	}
+	for _, supported := range fileLanguages {
+		if lang == supported {
+			return lang, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language: %q", name)
+}
+
+// isSupported checks if a file type is supported for analysis and belongs to
+// one of the configured languages
 func (gb *GraphBuilder) isSupported(filePath string) bool {
-	ext := strings.ToLower(filepath.Ext(filePath))
-	supportedExtensions := []string{".py", ".go", ".ts", ".tsx", ".js", ".jsx"}
+	lang, ok := fileLanguages[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return false
+	}
+	if gb.config == nil || len(gb.config.Languages) == 0 {
+		return true
+	}
 
-	for _, supportedExt := range supportedExtensions {
-		if ext == supportedExt {
+	for _, allowed := range gb.config.Languages {
+		if lang == allowed {
 			return true
 		}
 	}
 	return false
 }
 
-// shouldIgnorePath reports whether the given path should be ignored according to config patterns.
+// shouldIgnorePath reports whether the given path, relative to the repository
+// root, should be ignored according to config patterns
 func (gb *GraphBuilder) shouldIgnorePath(filePath string) bool {
 	if gb.config == nil || len(gb.config.IgnorePatterns) == 0 {
 		return false
@@ -468,6 +713,7 @@ func (gb *GraphBuilder) processFilePhase1WithPaths(fullPath, relPath string) err
 	// Analyze the file based on its extension, but use relative path for storage
 	var file *entities.File
 	var relationships []*entities.Relationship
+	parseStart := time.Now()
 
 	ext := strings.ToLower(filepath.Ext(relPath))
 	switch ext {
@@ -493,9 +739,46 @@ func (gb *GraphBuilder) processFilePhase1WithPaths(fullPath, relPath string) err
 		if err != nil {
 			return fmt.Errorf("failed to analyze JavaScript file: %w", err)
 		}
+	case ".tf":
+		file, relationships, err = gb.hclAnalyzer.AnalyzeFile(relPath, content)
+		if err != nil {
+			return fmt.Errorf("failed to analyze Terraform file: %w", err)
+		}
+	case ".sql":
+		file, relationships, err = gb.sqlAnalyzer.AnalyzeFile(relPath, content)
+		if err != nil {
+			return fmt.Errorf("failed to analyze SQL file: %w", err)
+		}
 	default:
 		return fmt.Errorf("unsupported file type: %s", ext)
 	}
+	gb.recordParse(file.Language, int64(len(content)), time.Since(parseStart))
+
+	detectStart := time.Now()
+	relationships = applyNesting(file, relationships, gb.config.ExtractNested)
+	relationships = append(relationships, detectEnumMembers(file)...)
+	markExported(file)
+	markPragmas(file)
+	detectNPlusOne(file)
+	detectCommentedCode(file)
+	relationships = append(relationships, detectControlFlowIssues(file)...)
+	relationships = append(relationships, detectBlockingInAsync(file)...)
+	relationships = append(relationships, detectStructTagIssues(file)...)
+	relationships = append(relationships, detectConditionalImports(file)...)
+	measureFunctions(file)
+	detectMissingDocs(file)
+	relationships = append(relationships, detectBindings(file)...)
+	relationships = append(relationships, detectReferences(file)...)
+	relationships = append(relationships, detectTypeAliases(file)...)
+	relationships = append(relationships, detectDocReferences(file)...)
+	relationships = append(relationships, detectCLIFlags(file)...)
+	relationships = append(relationships, detectAlembicMigration(file)...)
+	markModelTables(file)
+	gb.errorMessages = append(gb.errorMessages, collectErrorMessages(file)...)
+	concurrency := collectGoConcurrency(file)
+	gb.goAccesses = append(gb.goAccesses, concurrency.accesses...)
+	gb.goLaunches = append(gb.goLaunches, concurrency.launches...)
+	gb.stats.DetectTime += time.Since(detectStart)
 
 	// Store the file and its entities using relative path as key
 	gb.files[relPath] = file
@@ -532,6 +815,15 @@ func (gb *GraphBuilder) processFilePhase1WithPaths(fullPath, relPath string) err
 		}
 	}
 
+	// Feature flags are shared across files, so their entities are created
+	// here from the first check of each key rather than by the analyzers
+	for _, rel := range relationships {
+		if rel.Type == entities.RelationshipTypeChecksFlag && gb.allEntities[rel.TargetID] == nil {
+			gb.allEntities[rel.TargetID] = newFeatureFlag(rel)
+			gb.stats.EntitiesFound++
+		}
+	}
+
 	// Store unresolved relationships (Phase 1 only discovers them)
 	gb.unresolvedRelationships = append(gb.unresolvedRelationships, relationships...)
 	gb.stats.UnresolvedRelationshipsFound += len(relationships)
@@ -539,6 +831,20 @@ func (gb *GraphBuilder) processFilePhase1WithPaths(fullPath, relPath string) err
 	return nil
 }
 
+// recordParse accumulates parse timing for a successfully analyzed file
+func (gb *GraphBuilder) recordParse(language string, size int64, elapsed time.Duration) {
+	gb.stats.ParseTime += elapsed
+
+	langStats, exists := gb.stats.LanguageStats[language]
+	if !exists {
+		langStats = &LanguageStats{}
+		gb.stats.LanguageStats[language] = langStats
+	}
+	langStats.Files++
+	langStats.Bytes += size
+	langStats.ParseTime += elapsed
+}
+
 // registerAllEntities registers all discovered entities in the EntityRegistry
 func (gb *GraphBuilder) registerAllEntities() error {
 	entities := make([]*entities.Entity, 0, len(gb.allEntities))
@@ -580,21 +886,44 @@ func (gb *GraphBuilder) resolveRelationship(relationship *entities.Relationship)
 		targetEntity = gb.registry.GetEntityByID(relationship.TargetID)
 
 		// If not found, treat it as a name and resolve it
-		if targetEntity == nil {
+		if targetEntity == nil && relationship.Type == entities.RelationshipTypeDependsOn {
+			targetEntity = gb.resolveInfrastructureReference(relationship.TargetID, context)
+		} else if targetEntity == nil && (relationship.Type == entities.RelationshipTypeReferences ||
+			relationship.Type == entities.RelationshipTypeAliases) {
+			targetEntity = gb.resolveReference(relationship, context)
+		} else if targetEntity == nil && relationship.Type == entities.RelationshipTypeDocReferences {
+			targetEntity = gb.resolveDocReference(relationship, context)
+		} else if targetEntity == nil && relationship.Type == entities.RelationshipTypeAffects {
+			targetEntity = gb.resolveTable(relationship.TargetID)
+		} else if targetEntity == nil {
 			// Set expected types based on relationship type
 			switch relationship.Type {
-			case entities.RelationshipTypeCalls:
+			case entities.RelationshipTypeCalls, entities.RelationshipTypePropagatesError,
+				entities.RelationshipTypeHandlesError, entities.RelationshipTypeIgnoresError:
 				context.ExpectedTypes = []entities.EntityType{
 					entities.EntityTypeFunction,
 					entities.EntityTypeMethod,
 					entities.EntityTypeTestFunction,
 				}
-			case entities.RelationshipTypeUses, entities.RelationshipTypeEmbeds, entities.RelationshipTypeImplements:
+			case entities.RelationshipTypeUses, entities.RelationshipTypeEmbeds, entities.RelationshipTypeImplements,
+				entities.RelationshipTypeConstrains:
 				context.ExpectedTypes = []entities.EntityType{
 					entities.EntityTypeStruct,
 					entities.EntityTypeInterface,
 					entities.EntityTypeClass,
 				}
+			case entities.RelationshipTypeProvides:
+				context.ExpectedTypes = []entities.EntityType{
+					entities.EntityTypeInterface,
+					entities.EntityTypeClass,
+					entities.EntityTypeStruct,
+				}
+			case entities.RelationshipTypeBoundTo:
+				context.ExpectedTypes = []entities.EntityType{
+					entities.EntityTypeStruct,
+					entities.EntityTypeClass,
+					entities.EntityTypeFunction,
+				}
 			}
 
 			targetEntity = gb.registry.ResolveFunction(relationship.TargetID, context)
@@ -612,6 +941,12 @@ func (gb *GraphBuilder) resolveRelationship(relationship *entities.Relationship)
 	if targetEntity == nil {
 		return nil, fmt.Errorf("failed to resolve target entity: %s", relationship.TargetID)
 	}
+	switch relationship.Type {
+	case entities.RelationshipTypeIgnoresError, entities.RelationshipTypeHandlesError, entities.RelationshipTypePropagatesError:
+		if !mayReturnError(relationship, targetEntity) {
+			return nil, fmt.Errorf("result of %s is not an error", targetEntity.Name)
+		}
+	}
 
 	// Create resolution context for the relationship
 	resolutionContext := &entities.RelationshipResolutionContext{
@@ -651,11 +986,12 @@ func (gb *GraphBuilder) resolveRelationship(relationship *entities.Relationship)
 		resolutionContext,
 	)
 
-	// Copy properties and location from original relationship
+	// Copy properties, location and provenance from original relationship
 	for key, value := range relationship.Properties {
 		resolvedRel.SetProperty(key, value)
 	}
 	resolvedRel.Location = relationship.Location
+	resolvedRel.Provenance = relationship.Provenance
 
 	// Validate against schema constraints
 	if !resolvedRel.IsValidForSchema() {
@@ -666,6 +1002,160 @@ func (gb *GraphBuilder) resolveRelationship(relationship *entities.Relationship)
 	return resolvedRel, nil
 }
 
+// resolveInfrastructureReference resolves a Terraform address such as
+// "aws_s3_bucket.logs" or "var.region". Each directory is its own Terraform
+// module, so only blocks declared alongside the referencing file match.
+func (gb *GraphBuilder) resolveInfrastructureReference(address string, context *entities.EntityResolutionContext) *entities.Entity {
+	context.ExpectedTypes = []entities.EntityType{
+		entities.EntityTypeResource,
+		entities.EntityTypeDataSource,
+		entities.EntityTypeModuleCall,
+		entities.EntityTypeVariable,
+	}
+	context.CurrentPackage = filepath.Dir(context.CurrentFile)
+	return gb.registry.ResolveInPackage(address, context.CurrentPackage, context)
+}
+
+// resolveReference resolves the name a declaration uses. A Go name qualified
+// by an imported package is looked up in that package's directory, any other
+// Go name in the directory of the referencing file. Python and TypeScript
+// files are modules of their own, so their names are looked up in the
+// referencing file, and names brought in by an import anywhere in the
+// repository. The members of a type alias only resolve to types.
+func (gb *GraphBuilder) resolveReference(rel *entities.Relationship, context *entities.EntityResolutionContext) *entities.Entity {
+	context.ExpectedTypes = []entities.EntityType{
+		entities.EntityTypeStruct,
+		entities.EntityTypeInterface,
+		entities.EntityTypeClass,
+		entities.EntityTypeType,
+		entities.EntityTypeEnum,
+	}
+	if rel.Type == entities.RelationshipTypeReferences {
+		context.ExpectedTypes = append(context.ExpectedTypes, entities.EntityTypeVariable)
+	}
+	context.CurrentPackage = filepath.Dir(context.CurrentFile)
+	if importPath, ok := rel.GetProperty("import_path").(string); ok {
+		context.CurrentPackage = gb.goImportDir(importPath)
+		if context.CurrentPackage == "" {
+			return nil
+		}
+	}
+
+	if filepath.Ext(context.CurrentFile) != ".go" {
+		if target := gb.resolveInModule(rel.TargetID, context); target != nil {
+			return target
+		}
+	} else if target := gb.registry.ResolveInPackage(rel.TargetID, context.CurrentPackage, context); target != nil {
+		return target
+	}
+	if imported, _ := rel.GetProperty("imported").(bool); imported {
+		return gb.registry.ResolveEntity(rel.TargetID, context)
+	}
+	return nil
+}
+
+// resolveInModule returns the first declaration of a name of the expected
+// types in the referencing file
+func (gb *GraphBuilder) resolveInModule(name string, context *entities.EntityResolutionContext) *entities.Entity {
+	var first *entities.Entity
+	for _, candidate := range gb.registry.LookupName(name, context.ExpectedTypes...) {
+		if candidate.FilePath == context.CurrentFile && (first == nil || candidate.StartByte < first.StartByte) {
+			first = candidate
+		}
+	}
+	return first
+}
+
+// resolveDocReference resolves the declaration a doc comment links to. Go
+// links resolve in the package of the comment or in the package qualifying
+// them; Python and TypeScript links anywhere in the repository, preferring
+// the directory of the comment. A link to a member, such as [Server.Start],
+// only resolves to a member of that type. Links that cannot resolve because
+// they point into a Go package of another module, or to a name the file
+// imports, are marked "external".
+func (gb *GraphBuilder) resolveDocReference(rel *entities.Relationship, context *entities.EntityResolutionContext) *entities.Entity {
+	dir := filepath.Dir(context.CurrentFile)
+	goLink := filepath.Ext(context.CurrentFile) == ".go"
+	if importPath, ok := rel.GetProperty("import_path").(string); ok {
+		if dir = gb.goImportDir(importPath); dir == "" {
+			rel.SetProperty("external", true)
+			return nil
+		}
+	}
+
+	receiver, _ := rel.GetProperty("receiver").(string)
+	var best *entities.Entity
+	for _, candidate := range gb.registry.LookupName(rel.TargetID, docLinkTargetTypes...) {
+		owner := ""
+		if candidate.Parent != nil {
+			owner = candidate.Parent.Name
+		} else if r, ok := candidate.GetProperty("receiver").(string); ok {
+			owner = ReceiverTypeName(r)
+		}
+		inDir := filepath.Dir(candidate.FilePath) == dir
+		if (receiver != "" && owner != receiver) || (goLink && !inDir) {
+			continue
+		}
+		if best == nil {
+			best = candidate
+			continue
+		}
+		bestInDir := filepath.Dir(best.FilePath) == dir
+		if inDir != bestInDir {
+			if inDir {
+				best = candidate
+			}
+		} else if candidate.FilePath < best.FilePath ||
+			(candidate.FilePath == best.FilePath && candidate.StartByte < best.StartByte) {
+			best = candidate
+		}
+	}
+
+	if imported, _ := rel.GetProperty("imported").(bool); best == nil && imported {
+		rel.SetProperty("external", true)
+	}
+	return best
+}
+
+// indexTables records the models of the analyzed files by the table they
+// map to, the "table" set by markModelTables, for resolveTable
+func (gb *GraphBuilder) indexTables() {
+	gb.tables = make(map[string][]*entities.Entity)
+	for _, entity := range gb.allEntities {
+		if table, ok := entity.GetProperty("table").(string); ok && table != "" {
+			gb.tables[table] = append(gb.tables[table], entity)
+		}
+	}
+	for _, models := range gb.tables {
+		sort.Slice(models, func(i, j int) bool {
+			if models[i].FilePath != models[j].FilePath {
+				return models[i].FilePath < models[j].FilePath
+			}
+			return models[i].StartByte < models[j].StartByte
+		})
+	}
+}
+
+// resolveTable returns the model that maps to a table, the first by path
+// when several do, such as the models of two services sharing a database
+func (gb *GraphBuilder) resolveTable(table string) *entities.Entity {
+	if models := gb.tables[normalizeTableName(table)]; len(models) > 0 {
+		return models[0]
+	}
+	return nil
+}
+
+// goImportDir returns the directory of the repository a Go import path refers
+// to, or "" for packages of other modules
+func (gb *GraphBuilder) goImportDir(importPath string) string {
+	for moduleDir, modulePath := range gb.goModules {
+		if importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/") {
+			return path.Join(moduleDir, strings.TrimPrefix(importPath, modulePath))
+		}
+	}
+	return ""
+}
+
 // getRegistryStats retrieves current registry statistics
 func (gb *GraphBuilder) getRegistryStats() *entities.RegistryStats {
 	stats := gb.registry.GetStats()
@@ -715,7 +1205,7 @@ func (gb *GraphBuilder) printAnalysisSummary() {
 		fmt.Printf("  Failed Resolutions: %d\n", gb.stats.RegistryStats.UnresolvedCount)
 	}
 
-	fmt.Println("==============================\n")
+	fmt.Print("==============================\n\n")
 }
 
 // generateAnalysisReport generates a detailed analysis report (placeholder)
@@ -729,6 +1219,10 @@ func (gb *GraphBuilder) generateAnalysisReport() {
 
 // storeInDatabase stores all entities and relationships in the database
 func (gb *GraphBuilder) storeInDatabase() error {
+	if gb.config.ManifestPath != "" {
+		return gb.storeWithCheckpoints()
+	}
+
 	if gb.config.EnableDetailedLogging {
 		fmt.Printf("Storing %d entities and %d relationships in database...\n",
 			len(gb.allEntities), len(gb.resolvedRelationships))
@@ -747,7 +1241,12 @@ func (gb *GraphBuilder) storeInDatabase() error {
 
 	// Store all entities
 	entityErrors := 0
+	entitiesStored := 0
 	for _, entity := range gb.allEntities {
+		if !gb.storesEntity(entity) {
+			continue
+		}
+		entitiesStored++
 		err := gb.database.StoreEntity(entity)
 		if err != nil {
 			// Silently track error without printing to console
@@ -758,7 +1257,12 @@ func (gb *GraphBuilder) storeInDatabase() error {
 
 	// Store all resolved relationships using the enhanced StoreRelationship method
 	relationshipErrors := 0
+	relationshipsStored := 0
 	for _, rel := range gb.resolvedRelationships {
+		if !gb.storesRelationship(rel) {
+			continue
+		}
+		relationshipsStored++
 		err := gb.database.StoreRelationship(rel)
 		if err != nil {
 			// Silently track error without printing to console
@@ -772,7 +1276,50 @@ func (gb *GraphBuilder) storeInDatabase() error {
 			fileErrors, entityErrors, relationshipErrors)
 	}
 
-	return nil
+	return gb.mutationLog.Record(Mutation{
+		Operation:          MutationBuild,
+		Trigger:            TriggerBuild,
+		EntitiesAdded:      entitiesStored - entityErrors,
+		RelationshipsAdded: relationshipsStored - relationshipErrors,
+		Errors:             fileErrors + entityErrors + relationshipErrors,
+	})
+}
+
+// storesEntity reports whether an entity's type is one of the configured
+// EntityTypes
+func (gb *GraphBuilder) storesEntity(entity *entities.Entity) bool {
+	return gb.storesEntityType(entity.Type)
+}
+
+// storesEntityType reports whether entities of a type are stored. File nodes
+// are always stored.
+func (gb *GraphBuilder) storesEntityType(entityType entities.EntityType) bool {
+	if len(gb.config.EntityTypes) == 0 || entityType == entities.EntityTypeFile {
+		return true
+	}
+	for _, allowed := range gb.config.EntityTypes {
+		if entityType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// storesRelationship reports whether a relationship's type is one of the
+// configured RelationshipTypes and both of its ends are stored
+func (gb *GraphBuilder) storesRelationship(rel *entities.Relationship) bool {
+	if !gb.storesEntityType(rel.SourceType) || !gb.storesEntityType(rel.TargetType) {
+		return false
+	}
+	if len(gb.config.RelationshipTypes) == 0 {
+		return true
+	}
+	for _, allowed := range gb.config.RelationshipTypes {
+		if rel.Type == allowed {
+			return true
+		}
+	}
+	return false
 }
 
 // GetFile retrieves a file by path
@@ -801,6 +1348,13 @@ func (gb *GraphBuilder) GetFiles() map[string]*entities.File {
 	return gb.files
 }
 
+// UnanalyzedFiles returns the supported files, relative to the repository,
+// that were not parsed because GraphBuilderConfig.Deadline had passed. It is
+// empty when the build analyzed everything.
+func (gb *GraphBuilder) UnanalyzedFiles() []string {
+	return gb.unanalyzed
+}
+
 // GetAllEntities returns all entities
 func (gb *GraphBuilder) GetAllEntities() map[string]*entities.Entity {
 	return gb.allEntities
@@ -826,6 +1380,11 @@ func (gb *GraphBuilder) GetConfig() *GraphBuilderConfig {
 	return gb.config
 }
 
+// GetBuildStats returns the statistics collected during the last build
+func (gb *GraphBuilder) GetBuildStats() *BuildStats {
+	return gb.stats
+}
+
 // GetPhaseStats returns statistics for a specific phase
 func (gb *GraphBuilder) GetPhaseStats(phaseName string) *PhaseStats {
 	return gb.phaseStats[phaseName]
@@ -0,0 +1,29 @@
+package analyzer
+
+import "testing"
+
+func TestShouldIgnorePath(t *testing.T) {
+	config := DefaultGraphBuilderConfig()
+	config.IgnorePatterns = []string{"node_modules", "_test.go", ".pb.go", "internal/db/kuzudb.go", "*.gen.ts"}
+	gb := NewGraphBuilderWithConfig(nil, config)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"node_modules", true},
+		{"web/node_modules/react/index.js", true},
+		{"store/store_test.go", true},
+		{"api/service.pb.go", true},
+		{"internal/db/kuzudb.go", true},
+		{"web/api.gen.ts", true},
+		{"store/store.go", false},
+		{"internal/db/schema.go", false},
+		{"web/api.ts", false},
+	}
+	for _, tt := range tests {
+		if got := gb.shouldIgnorePath(tt.path); got != tt.want {
+			t.Errorf("shouldIgnorePath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
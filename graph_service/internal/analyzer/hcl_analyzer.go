@@ -0,0 +1,584 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// hclReferencePattern matches references to other blocks inside HCL
+// expressions: var.name, module.name, data.type.name and type.name for
+// resources. Resource types must contain an underscore, which rules out the
+// built-in objects (each, count, self, path, terraform, local). The trailing
+// attribute path is captured so the full reference can be recorded.
+var hclReferencePattern = regexp.MustCompile(
+	`(?:^|[^\w.\-])((?:var|module)\.[A-Za-z_][\w\-]*|data\.[a-z][a-z0-9]*_[\w\-]*\.[A-Za-z_][\w\-]*|[a-z][a-z0-9]*_[\w\-]*\.[A-Za-z_][\w\-]*)((?:\.[A-Za-z_][\w\-]*)*)`)
+
+// hclBlock is a block found by the HCL scanner, e.g. resource "aws_s3_bucket" "logs" { ... }
+type hclBlock struct {
+	kind   string
+	labels []string
+	start  int // offset of the block type keyword
+	open   int // offset of the opening brace
+	end    int // offset just past the closing brace
+}
+
+// hclAttribute is a name = expression assignment found by the HCL scanner
+type hclAttribute struct {
+	name  string
+	value string
+	start int // offset of the attribute name
+	end   int // offset just past the expression
+}
+
+// HCLAnalyzer analyzes Terraform (.tf) files and extracts resource, data,
+// module, variable and output blocks together with the DEPENDS_ON references
+// between them.
+//
+// No Tree-sitter grammar for HCL is bundled, so files are read by a small
+// scanner that understands just enough of the syntax to find blocks and
+// attributes: quoted strings with ${...} interpolation, heredocs, and #, //
+// and /* */ comments. Entities produced here have no syntax node and are
+// positioned with entities.NewSpanEntity.
+type HCLAnalyzer struct {
+	currentFile   *entities.File
+	masked        []byte // file content with comments and literal text blanked
+	lineStarts    []int
+	relationships []*entities.Relationship
+}
+
+// NewHCLAnalyzer creates a new HCL analyzer
+func NewHCLAnalyzer() *HCLAnalyzer {
+	return &HCLAnalyzer{
+		relationships: make([]*entities.Relationship, 0),
+	}
+}
+
+// AnalyzeFile analyzes a Terraform file and returns the File entity with all extracted entities
+func (ha *HCLAnalyzer) AnalyzeFile(filePath string, content []byte) (*entities.File, []*entities.Relationship, error) {
+	file := entities.NewFile(filePath, "hcl", nil, content)
+	ha.currentFile = file
+	ha.relationships = make([]*entities.Relationship, 0)
+	ha.masked = maskHCL(content)
+	ha.lineStarts = lineStartOffsets(content)
+
+	blocks, _ := scanHCLBody(content, ha.masked, 0, len(content))
+
+	// Extract block entities first so references can be attributed to them
+	blockEntities := make([]*entities.Entity, len(blocks))
+	for i, block := range blocks {
+		if entity := ha.extractBlock(block); entity != nil {
+			file.AddEntity(entity)
+			blockEntities[i] = entity
+		}
+	}
+
+	for i, entity := range blockEntities {
+		if entity != nil {
+			ha.extractReferences(entity, blocks[i])
+		}
+	}
+
+	// Extract file-entity containment relationships
+	for _, entity := range file.GetAllEntities() {
+		rel := entities.NewRelationshipByID(
+			ha.generateRelationshipID("contains", file.Path, entity.ID),
+			entities.RelationshipTypeContains,
+			file.Path,
+			entity.ID,
+			entities.EntityTypeFile,
+			entity.Type,
+		)
+		rel.SetLocation(file.Path, entity.StartByte, entity.EndByte)
+		offset := int(entity.StartByte)
+		rel.Provenance = &entities.Provenance{
+			NodeKind:    "block",
+			MatchedText: entity.Name,
+			FilePath:    file.Path,
+			Line:        uint32(ha.lineAt(offset)),
+			Column:      uint32(ha.columnAt(offset)),
+		}
+		ha.relationships = append(ha.relationships, rel)
+	}
+
+	return file, ha.relationships, nil
+}
+
+// extractBlock creates an entity for a top-level block. Blocks that do not
+// declare anything addressable (terraform, provider, locals) are skipped.
+func (ha *HCLAnalyzer) extractBlock(block hclBlock) *entities.Entity {
+	var entityType entities.EntityType
+	var name string
+
+	switch {
+	case block.kind == "resource" && len(block.labels) == 2:
+		entityType = entities.EntityTypeResource
+		name = block.labels[0] + "." + block.labels[1]
+	case block.kind == "data" && len(block.labels) == 2:
+		entityType = entities.EntityTypeDataSource
+		name = "data." + block.labels[0] + "." + block.labels[1]
+	case block.kind == "module" && len(block.labels) == 1:
+		entityType = entities.EntityTypeModuleCall
+		name = "module." + block.labels[0]
+	case block.kind == "variable" && len(block.labels) == 1:
+		entityType = entities.EntityTypeVariable
+		name = "var." + block.labels[0]
+	case block.kind == "output" && len(block.labels) == 1:
+		entityType = entities.EntityTypeOutput
+		name = "output." + block.labels[0]
+	default:
+		return nil
+	}
+
+	entity := entities.NewSpanEntity(
+		ha.generateEntityID(string(entityType), name, block.start, block.end),
+		name,
+		entityType,
+		ha.currentFile.Path,
+		uint32(block.start),
+		uint32(block.end),
+		ha.lineAt(block.start),
+		ha.lineAt(block.end-1),
+	)
+	entity.Signature = strings.TrimSpace(string(ha.currentFile.Content[block.start:block.open]))
+	entity.Body = string(ha.currentFile.Content[block.start:block.end])
+	entity.SetProperty("block_kind", block.kind)
+
+	_, attributes := scanHCLBody(ha.currentFile.Content, ha.masked, block.open+1, block.end-1)
+	attrs := make(map[string]string, len(attributes))
+	for _, attr := range attributes {
+		attrs[attr.name] = attr.value
+	}
+	if description, ok := attrs["description"]; ok {
+		entity.DocString = unquoteHCL(description)
+	}
+
+	switch entityType {
+	case entities.EntityTypeResource, entities.EntityTypeDataSource:
+		entity.SetProperty("block_type", block.labels[0])
+		entity.SetProperty("block_name", block.labels[1])
+		provider := block.labels[0]
+		if idx := strings.Index(provider, "_"); idx > 0 {
+			provider = provider[:idx]
+		}
+		entity.SetProperty("provider", provider)
+		if alias, ok := attrs["provider"]; ok {
+			entity.SetProperty("provider_alias", alias)
+		}
+	case entities.EntityTypeModuleCall:
+		if source, ok := attrs["source"]; ok {
+			entity.SetProperty("source", unquoteHCL(source))
+		}
+		if version, ok := attrs["version"]; ok {
+			entity.SetProperty("version", unquoteHCL(version))
+		}
+	case entities.EntityTypeVariable:
+		if varType, ok := attrs["type"]; ok {
+			entity.SetProperty("type", varType)
+		}
+		if defaultValue, ok := attrs["default"]; ok {
+			entity.SetProperty("value", defaultValue)
+		}
+		if sensitive, ok := attrs["sensitive"]; ok {
+			entity.SetProperty("sensitive", sensitive == "true")
+		}
+	case entities.EntityTypeOutput:
+		if value, ok := attrs["value"]; ok {
+			entity.SetProperty("value", value)
+		}
+		if sensitive, ok := attrs["sensitive"]; ok {
+			entity.SetProperty("sensitive", sensitive == "true")
+		}
+	}
+
+	return entity
+}
+
+// extractReferences creates DEPENDS_ON relationships for every block the
+// given block references, either through interpolation or an explicit
+// depends_on list. Targets are recorded by address and resolved against the
+// blocks of the same Terraform module during relationship resolution.
+func (ha *HCLAnalyzer) extractReferences(entity *entities.Entity, block hclBlock) {
+	_, attributes := scanHCLBody(ha.currentFile.Content, ha.masked, block.open+1, block.end-1)
+	var dependsOn *hclAttribute
+	for i := range attributes {
+		if attributes[i].name == "depends_on" {
+			dependsOn = &attributes[i]
+			break
+		}
+	}
+
+	seen := make(map[string]*entities.Relationship)
+	body := ha.masked[block.open:block.end]
+	for _, match := range hclReferencePattern.FindAllSubmatchIndex(body, -1) {
+		address := string(body[match[2]:match[3]])
+		if address == entity.Name {
+			continue
+		}
+
+		offset := block.open + match[2]
+		explicit := dependsOn != nil && offset >= dependsOn.start && offset < dependsOn.end
+		if rel, ok := seen[address]; ok {
+			if explicit {
+				rel.SetProperty("explicit", true)
+			}
+			continue
+		}
+
+		reference := string(body[match[2]:match[5]])
+		rel := entities.NewRelationshipByID(
+			ha.generateRelationshipID("depends_on", entity.ID, address),
+			entities.RelationshipTypeDependsOn,
+			entity.ID,
+			address,
+			entity.Type,
+			hclAddressType(address),
+		)
+		rel.SetProperty("reference", reference)
+		rel.SetProperty("explicit", explicit)
+
+		line, column := ha.lineAt(offset), ha.columnAt(offset)
+		rel.SetLocation(ha.currentFile.Path, uint32(offset), uint32(block.open+match[5]))
+		rel.Location.Line, rel.Location.Column = uint32(line), uint32(column)
+		rel.Provenance = &entities.Provenance{
+			NodeKind:    "traversal",
+			MatchedText: reference,
+			FilePath:    ha.currentFile.Path,
+			Line:        uint32(line),
+			Column:      uint32(column),
+		}
+
+		seen[address] = rel
+		ha.relationships = append(ha.relationships, rel)
+	}
+}
+
+// hclAddressType returns the entity type a reference address points at
+func hclAddressType(address string) entities.EntityType {
+	switch {
+	case strings.HasPrefix(address, "var."):
+		return entities.EntityTypeVariable
+	case strings.HasPrefix(address, "module."):
+		return entities.EntityTypeModuleCall
+	case strings.HasPrefix(address, "data."):
+		return entities.EntityTypeDataSource
+	default:
+		return entities.EntityTypeResource
+	}
+}
+
+// lineAt returns the 1-based line containing a byte offset
+func (ha *HCLAnalyzer) lineAt(offset int) int {
+	return sort.SearchInts(ha.lineStarts, offset+1)
+}
+
+// columnAt returns the 1-based column of a byte offset
+func (ha *HCLAnalyzer) columnAt(offset int) int {
+	return offset - ha.lineStarts[ha.lineAt(offset)-1] + 1
+}
+
+// generateEntityID generates a unique ID for an entity
+func (ha *HCLAnalyzer) generateEntityID(entityType, name string, startByte, endByte int) string {
+	base := fmt.Sprintf("%s:%s:%s:%d:%d",
+		entityType,
+		ha.currentFile.Path,
+		name,
+		startByte,
+		endByte)
+
+	hash := sha256.Sum256([]byte(base))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// generateRelationshipID generates a unique ID for a relationship
+func (ha *HCLAnalyzer) generateRelationshipID(relType, source, target string) string {
+	base := fmt.Sprintf("%s:%s:%s", relType, source, target)
+	hash := sha256.Sum256([]byte(base))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// lineStartOffsets returns the byte offset at which each line begins
+func lineStartOffsets(content []byte) []int {
+	starts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// unquoteHCL returns the value of a quoted string expression, or the
+// expression unchanged when it is not a plain string
+func unquoteHCL(expr string) string {
+	if unquoted, err := strconv.Unquote(expr); err == nil {
+		return unquoted
+	}
+	return expr
+}
+
+// scanHCLBody scans content[start:end] for blocks and attributes at the top
+// level of a body. Structure is read from masked, where comments and literal
+// string text have been blanked so that braces inside them are ignored;
+// labels and attribute values are taken from the original content.
+func scanHCLBody(content, masked []byte, start, end int) ([]hclBlock, []hclAttribute) {
+	var blocks []hclBlock
+	var attributes []hclAttribute
+
+	i := start
+	for i < end {
+		for i < end && isHCLSpace(masked[i]) {
+			i++
+		}
+		if i >= end {
+			break
+		}
+		if !isHCLIdentStart(masked[i]) {
+			i = skipHCLExpression(masked, i, end)
+			continue
+		}
+
+		identStart := i
+		for i < end && isHCLIdentChar(masked[i]) {
+			i++
+		}
+		ident := string(content[identStart:i])
+
+		// Labels follow the block type on the same line, up to the opening brace
+		var labels []string
+		j := i
+		for j < end {
+			for j < end && (masked[j] == ' ' || masked[j] == '\t') {
+				j++
+			}
+			if j >= end {
+				break
+			}
+			switch c := masked[j]; {
+			case c == '"':
+				closing := j + 1
+				for closing < end && masked[closing] != '"' && masked[closing] != '\n' {
+					closing++
+				}
+				labels = append(labels, string(content[j+1:closing]))
+				j = closing + 1
+				continue
+			case isHCLIdentStart(c):
+				labelStart := j
+				for j < end && isHCLIdentChar(masked[j]) {
+					j++
+				}
+				labels = append(labels, string(content[labelStart:j]))
+				continue
+			case c == '{':
+				blockEnd := matchHCLBrace(masked, j, end)
+				blocks = append(blocks, hclBlock{kind: ident, labels: labels, start: identStart, open: j, end: blockEnd})
+				i = blockEnd
+			case c == '=' && (j+1 >= end || masked[j+1] != '='):
+				exprEnd := skipHCLExpression(masked, j+1, end)
+				if len(labels) == 0 {
+					attributes = append(attributes, hclAttribute{
+						name:  ident,
+						value: strings.TrimSpace(string(content[j+1 : exprEnd])),
+						start: identStart,
+						end:   exprEnd,
+					})
+				}
+				i = exprEnd
+			default:
+				i = skipHCLExpression(masked, j, end)
+			}
+			break
+		}
+		if j >= end {
+			i = end
+		}
+	}
+
+	return blocks, attributes
+}
+
+// skipHCLExpression returns the offset of the newline that ends the
+// expression starting at i, treating newlines inside brackets as part of it
+func skipHCLExpression(masked []byte, i, end int) int {
+	depth := 0
+	for ; i < end; i++ {
+		switch masked[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		case '\n':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return end
+}
+
+// matchHCLBrace returns the offset just past the brace closing the one at open,
+// or end when the block is unterminated
+func matchHCLBrace(masked []byte, open, end int) int {
+	depth := 0
+	for i := open; i < end; i++ {
+		switch masked[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return end
+}
+
+// maskHCL returns a copy of content with comments and the literal text of
+// strings and heredocs replaced by spaces. Quote characters, newlines and
+// the contents of ${...} and %{...} template sequences are kept, so offsets
+// and line numbers are unchanged and references inside interpolations remain
+// visible.
+func maskHCL(content []byte) []byte {
+	masked := make([]byte, len(content))
+	copy(masked, content)
+	n := len(content)
+
+	for i := 0; i < n; {
+		switch c := content[i]; {
+		case c == '#' || (c == '/' && i+1 < n && content[i+1] == '/'):
+			for i < n && content[i] != '\n' {
+				masked[i] = ' '
+				i++
+			}
+		case c == '/' && i+1 < n && content[i+1] == '*':
+			closing := strings.Index(string(content[i+2:]), "*/")
+			stop := n
+			if closing >= 0 {
+				stop = i + 2 + closing + 2
+			}
+			blankHCL(masked, i, stop)
+			i = stop
+		case c == '"':
+			i = maskHCLLiteral(content, masked, i+1, n, true)
+		case c == '<' && i+1 < n && content[i+1] == '<':
+			i = maskHCLHeredoc(content, masked, i)
+		default:
+			i++
+		}
+	}
+	return masked
+}
+
+// maskHCLLiteral blanks template text from start, stopping at the closing
+// quote when quoted, and returns the offset just past the literal
+func maskHCLLiteral(content, masked []byte, start, end int, quoted bool) int {
+	i := start
+	for i < end {
+		c := content[i]
+		switch {
+		case quoted && c == '"':
+			return i + 1
+		case quoted && c == '\n':
+			return i
+		case quoted && c == '\\' && i+1 < end:
+			blankHCL(masked, i, i+2)
+			i += 2
+		case (c == '$' || c == '%') && i+1 < end && content[i+1] == c:
+			// $${ and %%{ are escapes for a literal ${ or %{
+			blankHCL(masked, i, i+2)
+			i += 2
+		case (c == '$' || c == '%') && i+1 < end && content[i+1] == '{':
+			i = maskHCLTemplate(content, masked, i+2, end)
+		default:
+			blankHCL(masked, i, i+1)
+			i++
+		}
+	}
+	return end
+}
+
+// maskHCLTemplate skips the expression inside a template sequence, masking any
+// nested strings, and returns the offset just past its closing brace
+func maskHCLTemplate(content, masked []byte, start, end int) int {
+	depth := 1
+	for i := start; i < end; {
+		switch content[i] {
+		case '"':
+			i = maskHCLLiteral(content, masked, i+1, end, true)
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return end
+}
+
+// maskHCLHeredoc masks a <<MARKER or <<-MARKER heredoc starting at i and
+// returns the offset after its closing marker. A "<<" that does not start a
+// heredoc is skipped.
+func maskHCLHeredoc(content, masked []byte, i int) int {
+	n := len(content)
+	j := i + 2
+	if j < n && content[j] == '-' {
+		j++
+	}
+	markerStart := j
+	for j < n && isHCLIdentChar(content[j]) {
+		j++
+	}
+	marker := string(content[markerStart:j])
+	if marker == "" || j >= n || content[j] != '\n' {
+		return i + 2
+	}
+
+	lineStart := j + 1
+	for lineStart < n {
+		lineEnd := lineStart
+		for lineEnd < n && content[lineEnd] != '\n' {
+			lineEnd++
+		}
+		if strings.TrimSpace(string(content[lineStart:lineEnd])) == marker {
+			return lineEnd
+		}
+		maskHCLLiteral(content, masked, lineStart, lineEnd, false)
+		lineStart = lineEnd + 1
+	}
+	return n
+}
+
+// blankHCL replaces masked[start:end] with spaces, preserving newlines
+func blankHCL(masked []byte, start, end int) {
+	for k := start; k < end && k < len(masked); k++ {
+		if masked[k] != '\n' {
+			masked[k] = ' '
+		}
+	}
+}
+
+func isHCLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isHCLIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isHCLIdentChar(c byte) bool {
+	return isHCLIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
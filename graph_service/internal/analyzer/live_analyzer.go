@@ -156,6 +156,10 @@ type LiveAnalyzer struct {
 
 	// Options
 	watchOptions *WatchOptions
+
+	// mutationLog records each file added, updated or removed; nil unless
+	// WatchOptions.MutationLogPath is set
+	mutationLog *MutationLog
 }
 
 // FileChangeType represents the type of file change
@@ -203,6 +207,7 @@ type WatchOptions struct {
 	DebounceInterval  time.Duration // How long to wait before processing changes
 	MaxDepth          int           // Maximum directory depth to watch
 	EnableCrossLang   bool          // Enable cross-language analysis
+	MutationLogPath   string        // JSONL file recording every graph update (see MutationLog); empty disables it
 }
 
 // DefaultWatchOptions returns sensible defaults for watching
@@ -241,6 +246,9 @@ func NewLiveAnalyzer(database *db.KuzuDatabase, options *WatchOptions) (*LiveAna
 		fileStates:   make(map[string]*FileState),
 		watchOptions: options,
 	}
+	if options.MutationLogPath != "" {
+		la.mutationLog = NewMutationLog(options.MutationLogPath)
+	}
 
 	// Start the file watcher goroutine
 	go la.watcherLoop()
@@ -303,12 +311,12 @@ func (la *LiveAnalyzer) UpdateFile(filePath string) error {
 	// Check if file exists
 	_, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
-		return la.processFileChange(filePath, FileDeleted)
+		return la.processFileChange(filePath, FileDeleted, TriggerManual)
 	} else if err != nil {
 		return fmt.Errorf("failed to check file: %w", err)
 	}
 
-	return la.processFileChange(filePath, FileModified)
+	return la.processFileChange(filePath, FileModified, TriggerManual)
 }
 
 // initialScan performs the initial analysis of all files in the directory
@@ -456,7 +464,7 @@ func (la *LiveAnalyzer) debounceChange(filePath string, changeType FileChangeTyp
 		delete(la.pendingChanges, filePath)
 		la.changesMutex.Unlock()
 
-		err := la.processFileChange(filePath, changeType)
+		err := la.processFileChange(filePath, changeType, TriggerWatch)
 		if err != nil && la.onError != nil {
 			la.onError(fmt.Errorf("failed to process file change %s: %w", filePath, err))
 		}
@@ -465,8 +473,9 @@ func (la *LiveAnalyzer) debounceChange(filePath string, changeType FileChangeTyp
 	la.pendingChanges[filePath] = pendingChange
 }
 
-// processFileChange processes a file change and updates the graph
-func (la *LiveAnalyzer) processFileChange(filePath string, changeType FileChangeType) error {
+// processFileChange processes a file change and updates the graph. trigger is
+// recorded in the mutation log.
+func (la *LiveAnalyzer) processFileChange(filePath string, changeType FileChangeType, trigger string) error {
 	startTime := time.Now()
 	stats := &UpdateStats{}
 
@@ -479,13 +488,13 @@ func (la *LiveAnalyzer) processFileChange(filePath string, changeType FileChange
 
 	switch changeType {
 	case FileDeleted, FileRenamed:
-		err := la.removeFileFromGraph(filePath, stats)
+		err := la.removeFileFromGraph(filePath, stats, trigger)
 		if err != nil {
 			return err
 		}
 
 	case FileAdded, FileModified:
-		err := la.updateFileInGraph(filePath, stats)
+		err := la.updateFileInGraph(filePath, stats, trigger)
 		if err != nil {
 			return err
 		}
@@ -503,7 +512,7 @@ func (la *LiveAnalyzer) processFileChange(filePath string, changeType FileChange
 }
 
 // removeFileFromGraph removes a file and all its entities from the graph
-func (la *LiveAnalyzer) removeFileFromGraph(filePath string, stats *UpdateStats) error {
+func (la *LiveAnalyzer) removeFileFromGraph(filePath string, stats *UpdateStats, trigger string) error {
 	la.statesMutex.Lock()
 	fileState, exists := la.fileStates[filePath]
 	if !exists {
@@ -511,23 +520,41 @@ func (la *LiveAnalyzer) removeFileFromGraph(filePath string, stats *UpdateStats)
 		return nil // File wasn't tracked
 	}
 
-	// Count entities that will be removed
-	stats.EntitiesRemoved = len(fileState.Entities)
-	delete(la.fileStates, filePath)
 	la.statesMutex.Unlock()
 
-	// Remove from database
-	// Note: This is a simplified version - in a real implementation,
-	// you'd want to remove specific entities and relationships
-	// For now, we'll just mark the file as deleted
-	log.Printf("Removing file from graph: %s (%d entities)", filePath, stats.EntitiesRemoved)
+	// Remove the file's nodes and their relationships from the database. The
+	// file stays tracked if that fails, so a later change can retry.
+	log.Printf("Removing file from graph: %s (%d entities)", filePath, len(fileState.Entities))
+	if err := la.database.DeleteFileNodes(filePath); err != nil {
+		if logErr := la.mutationLog.Record(Mutation{
+			Operation: MutationRemoveFile,
+			Trigger:   trigger,
+			FilePath:  filePath,
+			Errors:    1,
+		}); logErr != nil {
+			log.Printf("Warning: Failed to record mutation: %v", logErr)
+		}
+		return fmt.Errorf("failed to remove %s from graph: %w", filePath, err)
+	}
+
+	la.statesMutex.Lock()
+	delete(la.fileStates, filePath)
+	la.statesMutex.Unlock()
 
 	stats.FilesUpdated = 1
-	return nil
+	stats.EntitiesRemoved = len(fileState.Entities)
+	_, removed := diffEntities(fileState.Entities, nil)
+	return la.mutationLog.Record(Mutation{
+		Operation:       MutationRemoveFile,
+		Trigger:         trigger,
+		FilePath:        filePath,
+		EntitiesRemoved: stats.EntitiesRemoved,
+		Removed:         removed,
+	})
 }
 
 // updateFileInGraph analyzes a file and updates the graph
-func (la *LiveAnalyzer) updateFileInGraph(filePath string, stats *UpdateStats) error {
+func (la *LiveAnalyzer) updateFileInGraph(filePath string, stats *UpdateStats, trigger string) error {
 	// Read file content
 	content, err := os.ReadFile(filePath)
 	if err != nil {
@@ -552,9 +579,31 @@ func (la *LiveAnalyzer) updateFileInGraph(filePath string, stats *UpdateStats) e
 		return fmt.Errorf("failed to analyze file: %w", err)
 	}
 
-	// Update file state
 	la.statesMutex.Lock()
 	oldState := la.fileStates[filePath]
+	la.statesMutex.Unlock()
+
+	// Remove what the previous version of the file stored, so entities that
+	// are gone or renamed do not linger next to the new ones
+	if oldState != nil {
+		log.Printf("Updating existing file in graph: %s", filePath)
+		if err := la.database.DeleteFileNodes(filePath); err != nil {
+			if logErr := la.mutationLog.Record(Mutation{
+				Operation: MutationUpdateFile,
+				Trigger:   trigger,
+				FilePath:  filePath,
+				Errors:    1,
+			}); logErr != nil {
+				log.Printf("Warning: Failed to record mutation: %v", logErr)
+			}
+			return fmt.Errorf("failed to remove previous version of %s from graph: %w", filePath, err)
+		}
+	} else {
+		log.Printf("Adding new file to graph: %s", filePath)
+	}
+
+	// Update file state
+	la.statesMutex.Lock()
 
 	newState := &FileState{
 		FilePath:     filePath,
@@ -570,44 +619,63 @@ func (la *LiveAnalyzer) updateFileInGraph(filePath string, stats *UpdateStats) e
 	la.fileStates[filePath] = newState
 	la.statesMutex.Unlock()
 
-	// Calculate statistics
-	stats.FilesUpdated = 1
-	stats.EntitiesAdded = len(newState.Entities)
-	stats.RelationshipsAdded = len(relationships)
-
-	if oldState != nil {
-		// This is an update, not a new file
-		stats.EntitiesRemoved = len(oldState.Entities)
-		stats.EntitiesModified = stats.EntitiesAdded
-		stats.EntitiesAdded = 0
+	// Store the file node and new entities, keeping those that were stored
+	failed := 0
+	if err := la.database.AddFileNode(filePath, file.Name, file.Language); err != nil {
+		log.Printf("Warning: Failed to store file node %s: %v", filePath, err)
+		failed++
 	}
-
-	// Store in database
-	// First remove old entities if this is an update
-	if oldState != nil {
-		// In a real implementation, you'd remove old entities and relationships
-		log.Printf("Updating existing file in graph: %s", filePath)
-	} else {
-		log.Printf("Adding new file to graph: %s", filePath)
-	}
-
-	// Store new entities
+	stored := make(map[string]*entities.Entity)
 	for _, entity := range file.GetAllEntities() {
 		err := la.database.StoreEntity(entity)
 		if err != nil {
 			log.Printf("Warning: Failed to store entity %s: %v", entity.Name, err)
+			failed++
+			continue
 		}
+		stored[entity.ID] = entity
 	}
 
 	// Store new relationships
+	relationshipsStored := 0
 	for _, rel := range relationships {
 		err := la.database.StoreRelationship(rel)
 		if err != nil {
 			log.Printf("Warning: Failed to store relationship: %v", err)
+			failed++
+			continue
 		}
+		relationshipsStored++
 	}
 
-	return nil
+	// Calculate statistics
+	stats.FilesUpdated = 1
+	stats.EntitiesAdded = len(stored)
+	stats.RelationshipsAdded = relationshipsStored
+
+	if oldState != nil {
+		// This is an update, not a new file
+		stats.EntitiesRemoved = len(oldState.Entities)
+		stats.EntitiesModified = stats.EntitiesAdded
+		stats.EntitiesAdded = 0
+	}
+
+	mutation := Mutation{
+		Operation:          MutationAddFile,
+		Trigger:            trigger,
+		FilePath:           filePath,
+		EntitiesAdded:      len(stored),
+		RelationshipsAdded: relationshipsStored,
+		Errors:             failed,
+	}
+	var previous map[string]*entities.Entity
+	if oldState != nil {
+		mutation.Operation = MutationUpdateFile
+		mutation.EntitiesRemoved = len(oldState.Entities)
+		previous = oldState.Entities
+	}
+	mutation.Added, mutation.Removed = diffEntities(previous, stored)
+	return la.mutationLog.Record(mutation)
 }
 
 // analyzeFileInitial analyzes a file during the initial scan
@@ -651,10 +719,12 @@ func (la *LiveAnalyzer) analyzeFileInitial(filePath string, info os.FileInfo) er
 	la.statesMutex.Unlock()
 
 	// Store in database
+	failed := 0
 	for _, entity := range file.GetAllEntities() {
 		err := la.database.StoreEntity(entity)
 		if err != nil {
 			log.Printf("Warning: Failed to store entity %s: %v", entity.Name, err)
+			failed++
 		}
 	}
 
@@ -662,10 +732,18 @@ func (la *LiveAnalyzer) analyzeFileInitial(filePath string, info os.FileInfo) er
 		err := la.database.StoreRelationship(rel)
 		if err != nil {
 			log.Printf("Warning: Failed to store relationship: %v", err)
+			failed++
 		}
 	}
 
-	return nil
+	return la.mutationLog.Record(Mutation{
+		Operation:          MutationAddFile,
+		Trigger:            TriggerScan,
+		FilePath:           filePath,
+		EntitiesAdded:      len(fileState.Entities),
+		RelationshipsAdded: len(relationships),
+		Errors:             failed,
+	})
 }
 
 // shouldIgnoreFile checks if a file should be ignored based on patterns
@@ -0,0 +1,197 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Log levels in increasing order of severity. Every detected LogStatement is
+// normalized to one of these regardless of the logging library used.
+const (
+	LogLevelTrace = "trace"
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+	LogLevelFatal = "fatal"
+)
+
+// logLevelRanks orders the normalized log levels by severity
+var logLevelRanks = map[string]int{
+	LogLevelTrace: 0,
+	LogLevelDebug: 1,
+	LogLevelInfo:  2,
+	LogLevelWarn:  3,
+	LogLevelError: 4,
+	LogLevelFatal: 5,
+}
+
+// logMethodLevels maps lower-cased logging method names to normalized levels.
+// Formatting variants (Infof, Infow, Infoln, InfoContext) are reduced to their
+// base name before lookup.
+var logMethodLevels = map[string]string{
+	"trace":     LogLevelTrace,
+	"debug":     LogLevelDebug,
+	"info":      LogLevelInfo,
+	"log":       LogLevelInfo, // console.log, logger.log
+	"print":     LogLevelInfo, // Go standard library log.Print*
+	"notice":    LogLevelInfo,
+	"warn":      LogLevelWarn,
+	"warning":   LogLevelWarn,
+	"error":     LogLevelError,
+	"exception": LogLevelError, // Python logging.exception
+	"fatal":     LogLevelFatal,
+	"critical":  LogLevelFatal,
+	"panic":     LogLevelFatal,
+	"dpanic":    LogLevelFatal,
+}
+
+// loggerNames are receivers recognized as loggers across Go (log, slog, zap,
+// logrus), Python (logging) and TypeScript (console, winston, pino)
+var loggerNames = map[string]bool{
+	"log":     true,
+	"slog":    true,
+	"logger":  true,
+	"logging": true,
+	"logrus":  true,
+	"zap":     true,
+	"zerolog": true,
+	"klog":    true,
+	"glog":    true,
+	"console": true,
+	"winston": true,
+	"pino":    true,
+}
+
+// maxLogMessageLength bounds the message snippet stored on LogStatement entities
+const maxLogMessageLength = 80
+
+// LogLevelRank returns the severity rank of a normalized log level and whether
+// the level is known
+func LogLevelRank(level string) (int, bool) {
+	rank, ok := logLevelRanks[strings.ToLower(level)]
+	return rank, ok
+}
+
+// classifyLogCall decides whether a callee expression such as "log.Printf",
+// "self.logger.warning" or "logrus.WithField(k, v).Error" is a logging call.
+// It returns the logger receiver, the method name and the normalized level.
+func classifyLogCall(callee string) (logger, method, level string, ok bool) {
+	callee = stripCallArguments(callee)
+	dot := strings.LastIndex(callee, ".")
+	if dot <= 0 || dot == len(callee)-1 {
+		return "", "", "", false
+	}
+	logger, method = callee[:dot], callee[dot+1:]
+
+	level, ok = logLevelForMethod(method)
+	if !ok {
+		return "", "", "", false
+	}
+
+	for _, segment := range strings.Split(logger, ".") {
+		name := strings.Trim(strings.ToLower(segment), "_")
+		if loggerNames[name] || strings.HasSuffix(name, "logger") || strings.HasPrefix(name, "logger") {
+			return logger, method, level, true
+		}
+	}
+	return "", "", "", false
+}
+
+// logLevelForMethod normalizes a logging method name to a level
+func logLevelForMethod(method string) (string, bool) {
+	name := strings.ToLower(method)
+	name = strings.TrimSuffix(name, "context")
+	if level, ok := logMethodLevels[name]; ok {
+		return level, true
+	}
+
+	name = strings.TrimSuffix(name, "ln")
+	if level, ok := logMethodLevels[name]; ok {
+		return level, true
+	}
+	for _, suffix := range []string{"f", "w"} {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		if level, ok := logMethodLevels[strings.TrimSuffix(name, suffix)]; ok {
+			return level, true
+		}
+	}
+	return "", false
+}
+
+// stripCallArguments removes parenthesized argument lists from a call chain so
+// that "zap.L().With(field).Info" becomes "zap.L.With.Info"
+func stripCallArguments(expr string) string {
+	var sb strings.Builder
+	depth := 0
+	for _, r := range expr {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && r != ' ' && r != '\n' && r != '\t':
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// logMessageSnippet returns a short snippet of the first argument of a log call,
+// with string quotes removed
+func logMessageSnippet(argsNode *ts.Node, content []byte) string {
+	if argsNode == nil {
+		return ""
+	}
+
+	var first *ts.Node
+	for i := uint(0); i < argsNode.NamedChildCount(); i++ {
+		child := argsNode.NamedChild(i)
+		if child.Kind() != "comment" {
+			first = child
+			break
+		}
+	}
+	if first == nil {
+		return ""
+	}
+
+	message := strings.TrimSpace(first.Utf8Text(content))
+	// Drop Python string prefixes (f"...", r'...') before removing quotes
+	if i := strings.IndexAny(message, "\"'`"); i > 0 && i <= 2 && strings.Trim(message[:i], "fFrRbBuU") == "" {
+		message = message[i:]
+	}
+	message = strings.Trim(message, "\"'`")
+	message = strings.Join(strings.Fields(message), " ")
+	if len(message) > maxLogMessageLength {
+		// Cut on a rune boundary so multi-byte characters are not split
+		end := maxLogMessageLength
+		for end > 0 && !utf8.RuneStart(message[end]) {
+			end--
+		}
+		message = message[:end] + "..."
+	}
+	return message
+}
+
+// newLogStatement builds a LogStatement entity for a logging call site
+func newLogStatement(id, filePath string, callNode *ts.Node, callee, logger, method, level, message string, enclosing *entities.Entity) *entities.Entity {
+	entity := entities.NewEntity(id, callee, entities.EntityTypeLogStatement, filePath, callNode)
+	entity.SetProperty("level", level)
+	entity.SetProperty("message", message)
+	entity.SetProperty("logger", logger)
+	entity.SetProperty("log_method", method)
+	if enclosing != nil {
+		entity.SetProperty("enclosing_function", enclosing.ID)
+		entity.SetProperty("enclosing_function_name", enclosing.GetFullName())
+	}
+	return entity
+}
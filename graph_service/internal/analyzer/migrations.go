@@ -0,0 +1,1078 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Migration tools, the "tool" of a Migration
+const (
+	MigrationToolAlembic       = "alembic"        // versions/<revision>_<slug>.py
+	MigrationToolGolangMigrate = "golang-migrate" // <version>_<name>.up.sql and .down.sql
+	MigrationToolFlyway        = "flyway"         // V<version>__<name>.sql, U for undo, R for repeatable
+	MigrationToolPrisma        = "prisma"         // migrations/<timestamp>_<name>/migration.sql
+	MigrationToolSQL           = "sql"            // any other .sql file under a migrations directory
+)
+
+// Schema operations, the "operation" of a SchemaChange
+const (
+	SchemaCreateTable  = "create_table"
+	SchemaDropTable    = "drop_table"
+	SchemaRenameTable  = "rename_table"
+	SchemaAddColumn    = "add_column"
+	SchemaDropColumn   = "drop_column"
+	SchemaAlterColumn  = "alter_column"
+	SchemaRenameColumn = "rename_column"
+	SchemaCreateIndex  = "create_index"
+	SchemaDropIndex    = "drop_index"
+)
+
+var (
+	golangMigrateFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	flywayFile        = regexp.MustCompile(`^([VUR])(\d+(?:[._]\d+)*)?__(.+)\.sql$`)
+	prismaDir         = regexp.MustCompile(`^(\d{14})_(.+)$`)
+	numberedFile      = regexp.MustCompile(`^(\d+)[_\-.](.+)\.sql$`)
+
+	// sqlDirection matches the comments goose and sql-migrate use to put the
+	// up and down statements in one file
+	sqlDirection = regexp.MustCompile(`(?im)^\s*--\s*\+(?:goose|migrate)\s+(up|down)\b`)
+)
+
+// sqlName matches a possibly schema-qualified, possibly quoted identifier,
+// in which a doubled quote stands for a quote
+const sqlName = "(?:(?:\"(?:[^\"]|\"\")+\"|`(?:[^`]|``)+`|\\[[^\\]]+\\]|[\\w$]+)\\s*\\.\\s*)*(?:\"(?:[^\"]|\"\")+\"|`(?:[^`]|``)+`|\\[[^\\]]+\\]|[\\w$]+)"
+
+var (
+	sqlCreateTable = regexp.MustCompile(`(?is)^create\s+(?:or\s+replace\s+)?(?:(?:global\s+|local\s+)?(?:temporary|temp)\s+)?(?:unlogged\s+)?table\s+(?:if\s+not\s+exists\s+)?(` + sqlName + `)(?:\s*(\(.*)|\s+.*)?$`)
+	sqlDropTable   = regexp.MustCompile(`(?is)^drop\s+table\s+(?:if\s+exists\s+)?(.+?)(?:\s+(?:cascade|restrict))?$`)
+	sqlAlterTable  = regexp.MustCompile(`(?is)^alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?(` + sqlName + `)\s+(.+)$`)
+	sqlRenameTable = regexp.MustCompile(`(?is)^rename\s+table\s+(` + sqlName + `)\s+to\s+(` + sqlName + `)`)
+	sqlCreateIndex = regexp.MustCompile(`(?is)^create\s+(?:unique\s+)?index\s+(?:concurrently\s+)?(?:if\s+not\s+exists\s+)?(?:(` + sqlName + `)\s+)?on\s+(?:only\s+)?(` + sqlName + `)\s*(?:using\s+\w+\s*)?(\(.*)?`)
+	sqlDropIndex   = regexp.MustCompile(`(?is)^drop\s+index\s+(?:concurrently\s+)?(?:if\s+exists\s+)?(` + sqlName + `)(?:\s+on\s+(` + sqlName + `))?`)
+
+	sqlAddIndex     = regexp.MustCompile(`(?is)^add\s+(?:unique\s+|fulltext\s+)?(?:index|key)\s+(` + sqlName + `)`)
+	sqlAddColumn    = regexp.MustCompile(`(?is)^add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?(` + sqlName + `)`)
+	sqlDropColumn   = regexp.MustCompile(`(?is)^drop\s+(?:column\s+)?(?:if\s+exists\s+)?(` + sqlName + `)`)
+	sqlAlterColumn  = regexp.MustCompile(`(?is)^(?:alter|modify|change)\s+(?:column\s+)?(` + sqlName + `)`)
+	sqlRenameColumn = regexp.MustCompile(`(?is)^rename\s+(?:column\s+)?(` + sqlName + `)\s+to\s+(` + sqlName + `)`)
+	sqlRenameTo     = regexp.MustCompile(`(?is)^rename\s+to\s+(` + sqlName + `)`)
+	sqlLeadingName  = regexp.MustCompile(`^` + sqlName)
+
+	// sqlConstraintWords start the clauses of ALTER TABLE and CREATE TABLE
+	// that are not columns
+	sqlConstraintWords = map[string]bool{
+		"constraint": true, "primary": true, "unique": true, "foreign": true, "check": true,
+		"index": true, "key": true, "exclude": true, "like": true, "fulltext": true, "period": true,
+	}
+)
+
+var (
+	alembicRevision     = regexp.MustCompile(`(?m)^revision\s*(?::\s*[\w\[\], |]+)?=\s*['"]([^'"]+)['"]`)
+	alembicDownRevision = regexp.MustCompile(`(?m)^down_revision\s*(?::\s*[\w\[\], |]+)?=\s*(.+)$`)
+	alembicFunction     = regexp.MustCompile(`(?m)^def\s+(upgrade|downgrade)\s*\(`)
+	alembicBatch        = regexp.MustCompile(`(\w+)\.batch_alter_table\(\s*['"]([^'"]+)['"][^)]*\)\s+as\s+(\w+)`)
+	alembicCall         = regexp.MustCompile(`\b(\w+)\.(create_table|drop_table|rename_table|add_column|drop_column|alter_column|create_index|drop_index)\(`)
+	pythonString        = regexp.MustCompile(`(?:^|[^\w])(?:[rRuU])?(['"])((?:[^'"\\]|\\.)*?)['"]`)
+	pythonKeyword       = regexp.MustCompile(`^\s*(\w+)\s*=`)
+	alembicColumn       = regexp.MustCompile(`(?:sa\.|sqlalchemy\.)?Column\(\s*['"]([^'"]+)['"]`)
+)
+
+// sqlStatement is one statement of a SQL file, with comments and string
+// literals blanked out of text
+type sqlStatement struct {
+	text      string
+	start     int
+	direction string
+}
+
+// schemaChange is an operation of a migration on a table
+type schemaChange struct {
+	operation string
+	table     string
+	newTable  string
+	column    string
+	newColumn string
+	index     string
+	columns   []string
+	direction string
+	start     int
+	end       int
+	text      string
+}
+
+// migrationInfo describes a migration file as its tool names it
+type migrationInfo struct {
+	tool        string
+	name        string
+	version     string
+	description string
+	direction   string
+}
+
+// SQLAnalyzer analyzes .sql files. Files that a migration tool would run,
+// recognized by their name and directory, become a Migration entity
+// containing a SchemaChange for each table, column and index the file
+// creates, alters, renames or drops; other SQL files only get a File.
+//
+// Like HCL, SQL is read without a Tree-sitter grammar: comments and string
+// literals are blanked, the text is split into statements at semicolons and
+// each statement is matched against the DDL forms of PostgreSQL, MySQL and
+// SQLite. Data changes, views, functions and triggers are not recorded.
+type SQLAnalyzer struct{}
+
+// NewSQLAnalyzer creates a new SQL analyzer
+func NewSQLAnalyzer() *SQLAnalyzer {
+	return &SQLAnalyzer{}
+}
+
+// AnalyzeFile analyzes a SQL file and returns the File entity with the
+// migration it holds, if any
+func (sa *SQLAnalyzer) AnalyzeFile(filePath string, content []byte) (*entities.File, []*entities.Relationship, error) {
+	file := entities.NewFile(filePath, "sql", nil, content)
+	info, ok := sqlMigrationInfo(filePath)
+	if !ok {
+		return file, nil, nil
+	}
+
+	changes := make([]schemaChange, 0)
+	for _, statement := range splitSQL(content, info.direction) {
+		changes = append(changes, parseSQLStatement(statement)...)
+	}
+	return file, addMigration(file, info, changes, "", nil), nil
+}
+
+// sqlMigrationInfo recognizes the migration files of golang-migrate,
+// Flyway and Prisma by name, and any .sql file under a directory named
+// migrations, migration or migrate
+func sqlMigrationInfo(filePath string) (migrationInfo, bool) {
+	slashed := filepath.ToSlash(filePath)
+	base := path.Base(slashed)
+	dir := path.Base(path.Dir(slashed))
+
+	if m := golangMigrateFile.FindStringSubmatch(base); m != nil {
+		return migrationInfo{tool: MigrationToolGolangMigrate, name: m[1] + "_" + m[2], version: m[1],
+			description: m[2], direction: m[3]}, true
+	}
+	if m := flywayFile.FindStringSubmatch(base); m != nil {
+		direction := "up"
+		switch m[1] {
+		case "U":
+			direction = "down"
+		case "R":
+			direction = "repeatable"
+		}
+		return migrationInfo{tool: MigrationToolFlyway, name: strings.TrimSuffix(base, ".sql"), version: m[2],
+			description: m[3], direction: direction}, true
+	}
+	if m := prismaDir.FindStringSubmatch(dir); m != nil && base == "migration.sql" {
+		return migrationInfo{tool: MigrationToolPrisma, name: dir, version: m[1], description: m[2], direction: "up"}, true
+	}
+
+	inMigrations := false
+	for _, part := range strings.Split(path.Dir(slashed), "/") {
+		switch strings.ToLower(part) {
+		case "migrations", "migration", "migrate":
+			inMigrations = true
+		}
+	}
+	if !inMigrations {
+		return migrationInfo{}, false
+	}
+	info := migrationInfo{tool: MigrationToolSQL, name: strings.TrimSuffix(base, ".sql"), direction: "up"}
+	if m := numberedFile.FindStringSubmatch(base); m != nil {
+		info.version, info.description = m[1], m[2]
+	} else {
+		info.description = info.name
+	}
+	if strings.HasSuffix(info.name, ".down") || strings.HasSuffix(info.name, "_down") {
+		info.direction = "down"
+	}
+	return info, true
+}
+
+// splitSQL splits SQL at the semicolons outside comments, string literals,
+// dollar-quoted bodies and quoted identifiers. Each statement is the blanked text, trimmed,
+// with the direction set by the goose or sql-migrate comment before it, or
+// the direction of the file.
+func splitSQL(content []byte, direction string) []sqlStatement {
+	masked := maskSQL(content)
+	markers := sqlDirection.FindAllSubmatchIndex(content, -1)
+
+	statements := make([]sqlStatement, 0)
+	start := 0
+	var quote byte
+	for i := 0; i <= len(masked); i++ {
+		if i < len(masked) {
+			// Only quoted identifiers are left unmasked
+			switch c := masked[i]; {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '`':
+				quote = c
+				continue
+			case c == '[':
+				quote = ']'
+				continue
+			case c != ';':
+				continue
+			}
+		}
+		text := string(masked[start:i])
+		trimmed := strings.TrimSpace(text)
+		if trimmed != "" {
+			offset := start + strings.Index(text, trimmed)
+			dir := direction
+			for _, m := range markers {
+				if m[0] < offset {
+					dir = strings.ToLower(string(content[m[2]:m[3]]))
+				}
+			}
+			statements = append(statements, sqlStatement{text: trimmed, start: offset, direction: dir})
+		}
+		start = i + 1
+	}
+	return statements
+}
+
+// maskSQL returns a copy of content with comments, string literals and
+// dollar-quoted bodies replaced by spaces, keeping newlines and offsets.
+// Quoted identifiers are kept, and what they contain is not read as a
+// comment, string or semicolon.
+func maskSQL(content []byte) []byte {
+	masked := make([]byte, len(content))
+	copy(masked, content)
+	blank := func(from, to int) {
+		for j := from; j < to && j < len(masked); j++ {
+			if masked[j] != '\n' {
+				masked[j] = ' '
+			}
+		}
+	}
+
+	for i := 0; i < len(content); {
+		switch {
+		case content[i] == '-' && i+1 < len(content) && content[i+1] == '-':
+			end := i
+			for end < len(content) && content[end] != '\n' {
+				end++
+			}
+			blank(i, end)
+			i = end
+		case content[i] == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(string(content[i+2:]), "*/")
+			if end < 0 {
+				end = len(content)
+			} else {
+				end += i + 4
+			}
+			blank(i, end)
+			i = end
+		case content[i] == '"' || content[i] == '`' || content[i] == '[':
+			quote := content[i]
+			if quote == '[' {
+				quote = ']'
+			}
+			end := i + 1
+			for end < len(content) && content[end] != quote {
+				end++
+			}
+			i = end + 1
+		case content[i] == '\'':
+			end := i + 1
+			for end < len(content) {
+				if content[end] == '\\' {
+					end += 2
+					continue
+				}
+				if content[end] == '\'' {
+					if end+1 < len(content) && content[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			blank(i+1, end)
+			i = end + 1
+		case content[i] == '$':
+			// $$ or $tag$ opens a body that ends at the same tag; $1 is a
+			// parameter
+			end := i + 1
+			for end < len(content) && (content[end] == '_' || isHCLIdentStart(content[end]) || content[end] >= '0' && content[end] <= '9') {
+				end++
+			}
+			if end >= len(content) || content[end] != '$' || (end > i+1 && content[i+1] >= '0' && content[i+1] <= '9') {
+				i++
+				continue
+			}
+			tag := string(content[i : end+1])
+			close := strings.Index(string(content[end+1:]), tag)
+			if close < 0 {
+				close = len(content)
+			} else {
+				close += end + 1
+			}
+			blank(end+1, close)
+			i = close + len(tag)
+		default:
+			i++
+		}
+	}
+	return masked
+}
+
+// parseSQLStatement returns the schema changes a statement makes
+func parseSQLStatement(statement sqlStatement) []schemaChange {
+	text := statement.text
+	change := func(operation, table string) schemaChange {
+		return schemaChange{
+			operation: operation,
+			table:     normalizeTableName(table),
+			direction: statement.direction,
+			start:     statement.start,
+			end:       statement.start + len(text),
+			text:      strings.Join(strings.Fields(text), " "),
+		}
+	}
+
+	if m := sqlCreateTable.FindStringSubmatch(text); m != nil {
+		c := change(SchemaCreateTable, m[1])
+		if m[2] != "" {
+			c.columns = sqlColumnNames(m[2])
+		}
+		return []schemaChange{c}
+	}
+	if m := sqlDropTable.FindStringSubmatch(text); m != nil {
+		changes := make([]schemaChange, 0)
+		for _, table := range splitSQLList(m[1]) {
+			changes = append(changes, change(SchemaDropTable, table))
+		}
+		return changes
+	}
+	if m := sqlRenameTable.FindStringSubmatch(text); m != nil {
+		c := change(SchemaRenameTable, m[1])
+		c.newTable = normalizeTableName(m[2])
+		return []schemaChange{c}
+	}
+	if m := sqlCreateIndex.FindStringSubmatch(text); m != nil {
+		c := change(SchemaCreateIndex, m[2])
+		c.index = normalizeTableName(m[1])
+		if m[3] != "" {
+			c.columns = sqlColumnNames(m[3])
+		}
+		return []schemaChange{c}
+	}
+	if m := sqlDropIndex.FindStringSubmatch(text); m != nil {
+		c := change(SchemaDropIndex, m[2])
+		c.index = normalizeTableName(m[1])
+		return []schemaChange{c}
+	}
+
+	m := sqlAlterTable.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	changes := make([]schemaChange, 0)
+	for _, action := range splitSQLList(m[2]) {
+		c := change("", m[1])
+		fields := strings.Fields(action)
+		if len(fields) == 0 {
+			continue
+		}
+		first, second := strings.ToLower(fields[0]), ""
+		if len(fields) > 1 {
+			second = strings.ToLower(strings.Trim(fields[1], "("))
+		}
+		switch {
+		case first == "add" && sqlAddIndex.MatchString(action):
+			c.operation = SchemaCreateIndex
+			c.index = normalizeTableName(sqlAddIndex.FindStringSubmatch(action)[1])
+		case first == "add" && !sqlConstraintWords[second]:
+			c.operation = SchemaAddColumn
+			c.column = normalizeTableName(sqlAddColumn.FindStringSubmatch(action)[1])
+		case first == "drop" && (second == "index" || second == "key"):
+			c.operation = SchemaDropIndex
+			if len(fields) > 2 {
+				c.index = normalizeTableName(fields[2])
+			}
+		case first == "drop" && !sqlConstraintWords[second] && second != "default" && second != "not":
+			c.operation = SchemaDropColumn
+			c.column = normalizeTableName(sqlDropColumn.FindStringSubmatch(action)[1])
+		case (first == "alter" || first == "modify" || first == "change") && !sqlConstraintWords[second]:
+			c.operation = SchemaAlterColumn
+			c.column = normalizeTableName(sqlAlterColumn.FindStringSubmatch(action)[1])
+		case sqlRenameTo.MatchString(action):
+			c.operation = SchemaRenameTable
+			c.newTable = normalizeTableName(sqlRenameTo.FindStringSubmatch(action)[1])
+		case sqlRenameColumn.MatchString(action):
+			rename := sqlRenameColumn.FindStringSubmatch(action)
+			c.operation = SchemaRenameColumn
+			c.column = normalizeTableName(rename[1])
+			c.newColumn = normalizeTableName(rename[2])
+		default:
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// sqlColumnNames returns the columns of a parenthesized column or
+// definition list, skipping constraints
+func sqlColumnNames(list string) []string {
+	list = strings.TrimSpace(list)
+	if !strings.HasPrefix(list, "(") {
+		return nil
+	}
+	depth := 0
+	end := len(list)
+	for i, c := range list {
+		if c == '(' {
+			depth++
+		} else if c == ')' {
+			depth--
+			if depth == 0 {
+				end = i
+				break
+			}
+		}
+	}
+
+	columns := make([]string, 0)
+	for _, element := range splitSQLList(list[1:end]) {
+		fields := strings.Fields(element)
+		if len(fields) == 0 || sqlConstraintWords[strings.ToLower(fields[0])] {
+			continue
+		}
+		if name := sqlLeadingName.FindString(element); name != "" {
+			columns = append(columns, normalizeTableName(name))
+		}
+	}
+	return columns
+}
+
+// normalizeTableName reduces a table, column or index name as SQL or an ORM
+// spells it to the form the graph compares: without quotes or schema, in
+// lower case
+func normalizeTableName(name string) string {
+	var quote byte
+	last := -1
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '.':
+			last = i
+		}
+	}
+	name = strings.TrimSpace(name[last+1:])
+	if len(name) >= 2 && (name[0] == '"' || name[0] == '`') && name[len(name)-1] == name[0] {
+		quote := name[:1]
+		name = strings.ReplaceAll(name[1:len(name)-1], quote+quote, quote)
+	} else {
+		name = strings.Trim(name, "\"`[]")
+	}
+	return strings.ToLower(name)
+}
+
+// splitSQLList splits a list at the commas outside parentheses and quotes,
+// trimming each element
+func splitSQLList(list string) []string {
+	elements := make([]string, 0)
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(list); i++ {
+		switch c := list[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' {
+				i++
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			elements = append(elements, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(list[start:]); last != "" {
+		elements = append(elements, last)
+	}
+	return elements
+}
+
+// detectAlembicMigration records an Alembic revision file as a Migration
+// with a SchemaChange for each op call of its upgrade and downgrade
+// functions, including those made through the batch_op of
+// op.batch_alter_table. Columns of create_table are taken from the
+// sa.Column calls among its arguments.
+func detectAlembicMigration(file *entities.File) []*entities.Relationship {
+	if file.Language != "python" {
+		return nil
+	}
+	content := string(file.Content)
+	revision := alembicRevision.FindStringSubmatch(content)
+	down := alembicDownRevision.FindStringSubmatch(content)
+	functions := alembicFunction.FindAllStringSubmatchIndex(content, -1)
+	if revision == nil || down == nil || len(functions) == 0 {
+		return nil
+	}
+
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(file.Path)), ".py")
+	description := strings.TrimPrefix(name, revision[1])
+	description = strings.Trim(description, "_")
+	if description == "" {
+		description = name
+	}
+	info := migrationInfo{tool: MigrationToolAlembic, name: name, version: revision[1], description: description}
+
+	downRevisions := make([]string, 0)
+	for _, m := range pythonString.FindAllStringSubmatch(down[1], -1) {
+		downRevisions = append(downRevisions, m[2])
+	}
+
+	// batch_op aliases, each valid from its with statement on
+	type batch struct {
+		alias, table string
+		start        int
+	}
+	batches := make([]batch, 0)
+	for _, m := range alembicBatch.FindAllStringSubmatchIndex(content, -1) {
+		batches = append(batches, batch{alias: content[m[6]:m[7]], table: content[m[4]:m[5]], start: m[0]})
+	}
+
+	changes := make([]schemaChange, 0)
+	for _, m := range alembicCall.FindAllStringSubmatchIndex(content, -1) {
+		receiver, operation := content[m[2]:m[3]], content[m[4]:m[5]]
+		table := ""
+		if receiver != "op" {
+			for _, b := range batches {
+				if b.alias == receiver && b.start < m[0] {
+					table = b.table
+				}
+			}
+			if table == "" {
+				continue
+			}
+		}
+		end := matchPythonParen(content, m[1]-1)
+		args := splitSQLList(content[m[1]:end])
+		positional := make([]string, 0, len(args))
+		keywords := make(map[string]string)
+		for _, arg := range args {
+			value := ""
+			if s := pythonString.FindStringSubmatch(arg); s != nil {
+				value = s[2]
+			}
+			if k := pythonKeyword.FindStringSubmatch(arg); k != nil {
+				keywords[k[1]] = value
+				continue
+			}
+			positional = append(positional, value)
+		}
+		arg := func(i int, keyword string) string {
+			if v, ok := keywords[keyword]; ok {
+				return v
+			}
+			if i < len(positional) {
+				return positional[i]
+			}
+			return ""
+		}
+		// Calls on op name the table first; those on a batch do not
+		shift := 0
+		if table == "" {
+			shift = 1
+		}
+
+		direction := ""
+		for _, f := range functions {
+			if f[0] < m[0] {
+				direction = map[string]string{"upgrade": "up", "downgrade": "down"}[content[f[2]:f[3]]]
+			}
+		}
+		if direction == "" {
+			continue
+		}
+		c := schemaChange{
+			operation: operation,
+			table:     table,
+			direction: direction,
+			start:     m[0],
+			end:       end + 1,
+			text:      strings.Join(strings.Fields(content[m[0]:min(end+1, len(content))]), " "),
+		}
+		if shift == 1 {
+			c.table = arg(0, "table_name")
+		}
+		switch operation {
+		case "create_table":
+			for _, col := range alembicColumn.FindAllStringSubmatch(content[m[1]:end], -1) {
+				c.columns = append(c.columns, normalizeTableName(col[1]))
+			}
+		case "rename_table":
+			c.table = arg(0, "old_table_name")
+			c.newTable = normalizeTableName(arg(1, "new_table_name"))
+		case "add_column":
+			if col := alembicColumn.FindStringSubmatch(content[m[1]:end]); col != nil {
+				c.column = normalizeTableName(col[1])
+			}
+		case "drop_column":
+			c.column = normalizeTableName(arg(shift, "column_name"))
+		case "alter_column":
+			c.column = normalizeTableName(arg(shift, "column_name"))
+			if newName, ok := keywords["new_column_name"]; ok && newName != "" {
+				c.operation = SchemaRenameColumn
+				c.newColumn = normalizeTableName(newName)
+			}
+		case "create_index":
+			c.index = normalizeTableName(arg(0, "index_name"))
+			if shift == 1 {
+				c.table = arg(1, "table_name")
+			}
+			if cols := strings.Index(content[m[1]:end], "["); cols >= 0 {
+				for _, col := range pythonString.FindAllStringSubmatch(content[m[1]+cols:end], -1) {
+					c.columns = append(c.columns, normalizeTableName(col[2]))
+				}
+			}
+		case "drop_index":
+			c.index = normalizeTableName(arg(0, "index_name"))
+			c.table = keywords["table_name"]
+			if table != "" {
+				c.table = table
+			}
+		}
+		c.table = normalizeTableName(c.table)
+		changes = append(changes, c)
+	}
+
+	return addMigration(file, info, changes, revision[1], downRevisions)
+}
+
+// matchPythonParen returns the offset of the parenthesis closing the one at
+// open, skipping string literals, or the end of content
+func matchPythonParen(content string, open int) int {
+	depth := 0
+	for i := open; i < len(content); i++ {
+		switch content[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\'', '"':
+			quote := content[i]
+			for i++; i < len(content) && content[i] != quote; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+		case '#':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		}
+	}
+	return len(content)
+}
+
+// addMigration adds the Migration entity of a file, spanning all of it, and
+// a SchemaChange child for each change, numbered in file order. It returns
+// their CONTAINS relationships and an AFFECTS relationship from each change
+// to the table it changes, by table name, resolved against the "table" of
+// ORM models by resolveTable.
+func addMigration(file *entities.File, info migrationInfo, changes []schemaChange, revision string, downRevisions []string) []*entities.Relationship {
+	starts := lineStartOffsets(file.Content)
+	lineAt := func(offset int) int { return sort.SearchInts(starts, offset+1) }
+	hash := func(parts ...interface{}) string {
+		sum := sha256.Sum256([]byte(fmt.Sprint(parts...)))
+		return hex.EncodeToString(sum[:])[:16]
+	}
+	provenance := func(kind, text string, offset int) *entities.Provenance {
+		line := lineAt(offset)
+		return &entities.Provenance{
+			NodeKind:    kind,
+			MatchedText: text,
+			FilePath:    file.Path,
+			Line:        uint32(line),
+			Column:      uint32(offset - starts[line-1] + 1),
+		}
+	}
+
+	migration := entities.NewSpanEntity(hash("Migration:", file.Path, ":", info.name), info.name,
+		entities.EntityTypeMigration, file.Path, 0, uint32(len(file.Content)), 1, len(starts))
+	migration.SetProperty("tool", info.tool)
+	migration.SetProperty("version", info.version)
+	migration.SetProperty("description", info.description)
+	if info.direction != "" {
+		migration.SetProperty("direction", info.direction)
+	}
+	if revision != "" {
+		migration.SetProperty("revision", revision)
+		migration.SetProperty("down_revision", strings.Join(downRevisions, ","))
+	}
+	file.AddEntity(migration)
+
+	contains := entities.NewRelationshipByID(hash("contains:", file.Path, ":", migration.ID), entities.RelationshipTypeContains,
+		file.Path, migration.ID, entities.EntityTypeFile, entities.EntityTypeMigration)
+	contains.SetLocation(file.Path, 0, uint32(len(file.Content)))
+	contains.Provenance = provenance("migration", info.name, 0)
+	relationships := []*entities.Relationship{contains}
+
+	for position, c := range changes {
+		subject := c.table
+		switch {
+		case c.column != "":
+			subject += "." + c.column
+		case c.index != "" && c.table != "":
+			subject = c.index + " on " + c.table
+		case c.index != "":
+			subject = c.index
+		}
+		entity := entities.NewSpanEntity(hash("SchemaChange:", file.Path, ":", c.start, ":", position), c.operation+" "+subject,
+			entities.EntityTypeSchemaChange, file.Path, uint32(c.start), uint32(c.end), lineAt(c.start), lineAt(max(c.end-1, c.start)))
+		entity.Signature = c.text
+		entity.SetProperty("operation", c.operation)
+		entity.SetProperty("table", c.table)
+		entity.SetProperty("migration", migration.ID)
+		entity.SetProperty("direction", c.direction)
+		entity.SetProperty("position", position+1)
+		if c.newTable != "" {
+			entity.SetProperty("new_table", c.newTable)
+		}
+		if c.column != "" {
+			entity.SetProperty("column", c.column)
+		}
+		if c.newColumn != "" {
+			entity.SetProperty("new_column", c.newColumn)
+		}
+		if c.index != "" {
+			entity.SetProperty("index", c.index)
+		}
+		if len(c.columns) > 0 {
+			entity.SetProperty("columns", strings.Join(c.columns, ","))
+		}
+		migration.AddChild(entity)
+		file.AddEntity(entity)
+
+		rel := entities.NewRelationshipByID(hash("contains:", migration.ID, ":", entity.ID), entities.RelationshipTypeContains,
+			migration.ID, entity.ID, entities.EntityTypeMigration, entities.EntityTypeSchemaChange)
+		rel.SetLocation(file.Path, entity.StartByte, entity.EndByte)
+		rel.Provenance = provenance("schema_change", c.text, c.start)
+		relationships = append(relationships, rel)
+
+		for _, table := range []string{c.table, c.newTable} {
+			if table == "" {
+				continue
+			}
+			affects := entities.NewRelationshipByID(hash("affects:", entity.ID, ":", table), entities.RelationshipTypeAffects,
+				entity.ID, table, entities.EntityTypeSchemaChange, entities.EntityTypeClass)
+			affects.SetProperty("table", table)
+			affects.SetProperty("operation", c.operation)
+			affects.SetLocation(file.Path, entity.StartByte, entity.EndByte)
+			affects.Provenance = provenance("schema_change", c.text, c.start)
+			relationships = append(relationships, affects)
+		}
+	}
+	return relationships
+}
+
+var (
+	pythonTableName   = regexp.MustCompile(`(?m)^\s+(?:__tablename__|db_table)\s*(?::\s*\w+\s*)?=\s*['"]([^'"]+)['"]`)
+	tsEntityTable     = regexp.MustCompile(`@(?:Entity|Table)\(\s*['"]([^'"]+)['"]`)
+	tsEntityTableName = regexp.MustCompile(`@(?:Entity|Table)\(\s*\{[^}]*?\b(?:name|tableName)\s*:\s*['"]([^'"]+)['"]`)
+	goTableNameReturn = regexp.MustCompile(`return\s+"([^"]+)"`)
+	goBunTable        = regexp.MustCompile(`bun:"table:([\w.]+)`)
+	goGormModel       = regexp.MustCompile(`\bgorm\.Model\b|gorm:"`)
+)
+
+// markModelTables sets "table" on the classes and structs of a file that
+// map to a database table, so that schema changes can be linked to them:
+// SQLAlchemy classes with __tablename__ and Django models with db_table;
+// TypeORM and Sequelize classes decorated with @Entity or @Table; Go
+// structs with a TableName method returning a literal, a bun table tag, or
+// gorm tags, the last named as gorm does by default, in snake case and
+// plural.
+func markModelTables(file *entities.File) {
+	content := file.Content
+	text := func(entity *entities.Entity) string {
+		if int(entity.EndByte) > len(content) || entity.StartByte > entity.EndByte {
+			return ""
+		}
+		return string(content[entity.StartByte:entity.EndByte])
+	}
+
+	switch file.Language {
+	case "python":
+		for _, class := range file.Classes {
+			if class.Name == "Meta" {
+				continue
+			}
+			body := text(class)
+			for _, m := range pythonTableName.FindAllStringSubmatchIndex(body, -1) {
+				offset := int(class.StartByte) + m[0]
+				if ownedByNestedModel(class, offset) {
+					continue
+				}
+				class.SetProperty("table", normalizeTableName(body[m[2]:m[3]]))
+				break
+			}
+		}
+	case "typescript", "javascript":
+		for _, class := range file.Classes {
+			head := decoratorHead(content, int(class.StartByte)) + text(class)
+			if brace := strings.Index(head, "{\n"); brace >= 0 {
+				head = head[:brace]
+			}
+			if m := tsEntityTableName.FindStringSubmatch(head); m != nil {
+				class.SetProperty("table", normalizeTableName(m[1]))
+			} else if m := tsEntityTable.FindStringSubmatch(head); m != nil {
+				class.SetProperty("table", normalizeTableName(m[1]))
+			}
+		}
+	case "go":
+		structs := make(map[string]*entities.Entity)
+		for _, entity := range file.GetAllEntities() {
+			if entity.Type == entities.EntityTypeStruct {
+				structs[entity.Name] = entity
+			}
+		}
+		for _, method := range file.Methods {
+			receiver, _ := method.GetProperty("receiver").(string)
+			owner := structs[ReceiverTypeName(receiver)]
+			if method.Name != "TableName" || owner == nil {
+				continue
+			}
+			if m := goTableNameReturn.FindStringSubmatch(text(method)); m != nil {
+				owner.SetProperty("table", normalizeTableName(m[1]))
+			}
+		}
+		for _, s := range structs {
+			if s.GetProperty("table") != nil {
+				continue
+			}
+			body := text(s)
+			if m := goBunTable.FindStringSubmatch(body); m != nil {
+				s.SetProperty("table", normalizeTableName(m[1]))
+			} else if goGormModel.MatchString(body) {
+				s.SetProperty("table", pluralTableName(snakeCase(s.Name)))
+			}
+		}
+	}
+}
+
+// ownedByNestedModel reports whether offset falls inside a class nested in
+// class, other than a Django Meta
+func ownedByNestedModel(class *entities.Entity, offset int) bool {
+	for _, child := range class.Children {
+		if child.Type == entities.EntityTypeClass && child.Name != "Meta" &&
+			offset >= int(child.StartByte) && offset < int(child.EndByte) {
+			return true
+		}
+	}
+	return false
+}
+
+// decoratorHead returns the lines just before offset that belong to the
+// declaration starting there, such as multi-line decorators: up to ten
+// lines, stopping at a blank line or one that ends a statement or block
+func decoratorHead(content []byte, offset int) string {
+	lines := strings.Split(string(content[:offset]), "\n")
+	from := len(lines) - 1
+	for n := 0; n < 10 && from > 0; n++ {
+		previous := strings.TrimSpace(lines[from-1])
+		if previous == "" || previous == "}" || strings.HasSuffix(previous, ";") {
+			break
+		}
+		from--
+	}
+	return strings.Join(lines[from:], "\n")
+}
+
+// snakeCase converts a Go identifier to snake case, keeping initialisms
+// together: UserID becomes user_id, HTTPLog http_log
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z' || runes[i-1] >= '0' && runes[i-1] <= '9'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || (nextLower && runes[i-1] >= 'A' && runes[i-1] <= 'Z') {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteString(strings.ToLower(string(r)))
+	}
+	return b.String()
+}
+
+// pluralTableName pluralizes the last word of a table name the way simple
+// English inflection does
+func pluralTableName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s") || strings.HasSuffix(name, "x") || strings.HasSuffix(name, "ch") || strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
+
+// orderMigrations sets "order" on the migrations of each directory, from 1
+// for the first to run. Alembic revisions are ordered by following
+// down_revision from the base revision, the others by version, compared
+// number by number. The up and down migrations of a version share its order,
+// and repeatable Flyway migrations, which run after the versioned ones, come
+// last by name.
+func orderMigrations(files map[string]*entities.File) {
+	groups := make(map[string][]*entities.Entity)
+	for filePath, file := range files {
+		for _, entity := range file.GetAllEntities() {
+			if entity.Type != entities.EntityTypeMigration {
+				continue
+			}
+			dir := path.Dir(filepath.ToSlash(filePath))
+			tool, _ := entity.GetProperty("tool").(string)
+			if tool == MigrationToolPrisma {
+				dir = path.Dir(dir)
+			}
+			groups[tool+":"+dir] = append(groups[tool+":"+dir], entity)
+		}
+	}
+
+	for _, migrations := range groups {
+		sort.Slice(migrations, func(i, j int) bool { return migrations[i].FilePath < migrations[j].FilePath })
+		if tool, _ := migrations[0].GetProperty("tool").(string); tool == MigrationToolAlembic {
+			orderRevisions(migrations)
+			continue
+		}
+
+		versionOf := func(m *entities.Entity) string {
+			version, _ := m.GetProperty("version").(string)
+			return version
+		}
+		repeatable := func(m *entities.Entity) bool {
+			direction, _ := m.GetProperty("direction").(string)
+			return direction == "repeatable"
+		}
+		sort.SliceStable(migrations, func(i, j int) bool {
+			a, b := migrations[i], migrations[j]
+			if repeatable(a) != repeatable(b) {
+				return repeatable(b)
+			}
+			if c := compareVersions(versionOf(a), versionOf(b)); c != 0 {
+				return c < 0
+			}
+			return a.Name < b.Name
+		})
+		order := 0
+		previous := ""
+		for _, m := range migrations {
+			key := versionOf(m)
+			if key == "" || repeatable(m) {
+				key = m.Name
+			}
+			if order == 0 || key != previous {
+				order++
+			}
+			previous = key
+			m.SetProperty("order", order)
+		}
+	}
+}
+
+// orderRevisions orders Alembic revisions topologically, a revision after
+// all of its down revisions; ties and cycles fall back to file order
+func orderRevisions(migrations []*entities.Entity) {
+	byRevision := make(map[string]*entities.Entity)
+	for _, m := range migrations {
+		revision, _ := m.GetProperty("revision").(string)
+		byRevision[revision] = m
+	}
+	placed := make(map[*entities.Entity]bool)
+	order := 0
+	for len(placed) < len(migrations) {
+		progress := false
+		for _, m := range migrations {
+			if placed[m] {
+				continue
+			}
+			ready := true
+			down, _ := m.GetProperty("down_revision").(string)
+			for _, parent := range strings.Split(down, ",") {
+				if p := byRevision[parent]; parent != "" && p != nil && !placed[p] {
+					ready = false
+				}
+			}
+			if ready {
+				order++
+				m.SetProperty("order", order)
+				placed[m] = true
+				progress = true
+			}
+		}
+		if !progress {
+			for _, m := range migrations {
+				if !placed[m] {
+					order++
+					m.SetProperty("order", order)
+					placed[m] = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// compareVersions compares migration versions such as 2, 1.10 or 2_1 number
+// by number
+func compareVersions(a, b string) int {
+	split := func(v string) []string {
+		return strings.FieldsFunc(v, func(r rune) bool { return r == '.' || r == '_' })
+	}
+	as, bs := split(a), split(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.ParseUint(as[i], 10, 64)
+		y, errY := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case errX == nil && errY == nil && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		case (errX != nil || errY != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
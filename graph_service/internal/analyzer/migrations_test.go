@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+func TestSQLAnalyzerSchemaChanges(t *testing.T) {
+	type change struct {
+		operation, table, column, index, columns string
+	}
+	tests := []struct {
+		name string
+		sql  string
+		want []change
+	}{
+		{
+			name: "create table",
+			sql:  "CREATE TABLE IF NOT EXISTS users (id serial PRIMARY KEY, email text NOT NULL, UNIQUE (email));",
+			want: []change{{SchemaCreateTable, "users", "", "", "id,email"}},
+		},
+		{
+			name: "quoted and schema-qualified names",
+			sql:  `CREATE TABLE public."User Accounts" ("Id" int, "e;mail" text, "First Name" text);`,
+			want: []change{{SchemaCreateTable, "user accounts", "", "", "id,e;mail,first name"}},
+		},
+		{
+			name: "doubled quotes",
+			sql:  `ALTER TABLE "we""ird" ADD COLUMN "say ""hi""" text;`,
+			want: []change{{SchemaAddColumn, `we"ird`, `say "hi"`, "", ""}},
+		},
+		{
+			name: "MySQL backquotes",
+			sql:  "ALTER TABLE `order` ADD COLUMN `group` varchar(10), DROP COLUMN `note`;",
+			want: []change{
+				{SchemaAddColumn, "order", "group", "", ""},
+				{SchemaDropColumn, "order", "note", "", ""},
+			},
+		},
+		{
+			name: "SQL Server brackets",
+			sql:  "CREATE INDEX [ix items] ON [dbo].[Items] ([Name], [Price]);",
+			want: []change{{SchemaCreateIndex, "items", "", "ix items", "name,price"}},
+		},
+		{
+			name: "rename column and table",
+			sql:  "ALTER TABLE users RENAME COLUMN name TO full_name;\nALTER TABLE users RENAME TO accounts;",
+			want: []change{
+				{SchemaRenameColumn, "users", "name", "", ""},
+				{SchemaRenameTable, "users", "", "", ""},
+			},
+		},
+		{
+			name: "constraints are not columns",
+			sql:  "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email), ALTER COLUMN email SET NOT NULL;",
+			want: []change{{SchemaAlterColumn, "users", "email", "", ""}},
+		},
+		{
+			name: "drop several tables",
+			sql:  `DROP TABLE IF EXISTS sessions, "Audit Log" CASCADE;`,
+			want: []change{
+				{SchemaDropTable, "sessions", "", "", ""},
+				{SchemaDropTable, "audit log", "", "", ""},
+			},
+		},
+		{
+			name: "comments and strings are ignored",
+			sql:  "-- DROP TABLE users;\nINSERT INTO notes VALUES ('ALTER TABLE x DROP COLUMN y;');\n/* CREATE TABLE ghosts (id int); */",
+			want: []change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, _, err := NewSQLAnalyzer().AnalyzeFile("db/migrations/001_init.up.sql", []byte(tt.sql))
+			if err != nil {
+				t.Fatal(err)
+			}
+			schemaChanges := file.GetEntitiesByType(entities.EntityTypeSchemaChange)
+			sort.Slice(schemaChanges, func(i, j int) bool {
+				return schemaChanges[i].GetProperty("position").(int) < schemaChanges[j].GetProperty("position").(int)
+			})
+			got := make([]change, 0)
+			for _, entity := range schemaChanges {
+				property := func(name string) string {
+					value, _ := entity.GetProperty(name).(string)
+					return value
+				}
+				got = append(got, change{property("operation"), property("table"), property("column"), property("index"), property("columns")})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("schema changes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLMigrationInfo(t *testing.T) {
+	tests := []struct {
+		path                  string
+		tool, version, direct string
+		ok                    bool
+	}{
+		{"db/migrations/20240101120000_add_users.up.sql", MigrationToolGolangMigrate, "20240101120000", "up", true},
+		{"db/migrations/20240101120000_add_users.down.sql", MigrationToolGolangMigrate, "20240101120000", "down", true},
+		{"src/main/resources/db/migration/V1_2__add_users.sql", MigrationToolFlyway, "1_2", "up", true},
+		{"prisma/migrations/20240101120000_init/migration.sql", MigrationToolPrisma, "20240101120000", "up", true},
+		{"schema/seed.sql", "", "", "", false},
+	}
+	for _, tt := range tests {
+		info, ok := sqlMigrationInfo(tt.path)
+		if ok != tt.ok || info.tool != tt.tool || info.version != tt.version || info.direction != tt.direct {
+			t.Errorf("sqlMigrationInfo(%q) = %+v, %v; want tool %q, version %q, direction %q, %v",
+				tt.path, info, ok, tt.tool, tt.version, tt.direct, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// documentableTypes are the exported declarations expected to have
+// documentation. Fields are left out, and variables are only checked in Go,
+// where golint asks for them to be documented.
+var documentableTypes = map[entities.EntityType]string{
+	entities.EntityTypeFunction:  "function",
+	entities.EntityTypeMethod:    "method",
+	entities.EntityTypeClass:     "class",
+	entities.EntityTypeStruct:    "type",
+	entities.EntityTypeInterface: "interface",
+	entities.EntityTypeType:      "type",
+	entities.EntityTypeEnum:      "enum",
+	entities.EntityTypeVariable:  "var",
+}
+
+// docWrappers are the nodes a declaration sits in whose leading comment
+// documents it, such as the export statement of a TypeScript function or the
+// var block of a Go variable
+var docWrappers = map[string]bool{
+	"type_declaration":     true, // Go
+	"var_declaration":      true,
+	"const_declaration":    true,
+	"var_spec_list":        true,
+	"export_statement":     true, // TypeScript
+	"lexical_declaration":  true,
+	"variable_declaration": true,
+	"variable_declarator":  true,
+	"decorated_definition": true, // Python
+}
+
+// detectMissingDocs records a MissingDoc entity for every exported
+// declaration without documentation: a comment directly above it in Go, a
+// docstring in Python, a /** JSDoc */ comment in TypeScript. Every declaration
+// checked gets a "documented" property, from which the share of the public
+// API that is documented follows.
+//
+// Python magic methods and TypeScript constructors, which document
+// themselves, test files, generated Go files and declarations marked with an
+// onyx:ignore comment are skipped.
+func detectMissingDocs(file *entities.File) {
+	if file.Tree == nil || entities.IsTestFilePath(file.Path) || generatedGoFile(file) {
+		return
+	}
+	switch file.Language {
+	case "go", "python", "typescript", "javascript":
+	default:
+		return
+	}
+
+	for _, entity := range file.GetAllEntities() {
+		kind, ok := documentableTypes[entity.Type]
+		if !ok || entity.Node == nil || entity.IsIgnored() {
+			continue
+		}
+		if exported, _ := entity.GetProperty("exported").(bool); !exported {
+			continue
+		}
+		if entity.Type == entities.EntityTypeVariable && file.Language != "go" {
+			continue
+		}
+		if (file.Language == "python" && strings.HasPrefix(entity.Name, "__")) || entity.Name == "constructor" {
+			continue
+		}
+
+		documented := entity.DocString != "" || hasDocumentation(entity.Node, file)
+		entity.SetProperty("documented", documented)
+		if !documented {
+			file.AddEntity(newMissingDoc(file, entity, kind))
+		}
+	}
+}
+
+// generatedGoFile reports whether a Go file carries the standard
+// "Code generated ... DO NOT EDIT." header
+func generatedGoFile(file *entities.File) bool {
+	if file.Language != "go" {
+		return false
+	}
+	header := file.Content
+	if end := strings.Index(string(header), "\npackage "); end >= 0 {
+		header = header[:end]
+	}
+	text := string(header)
+	return strings.Contains(text, "// Code generated ") && strings.Contains(text, "DO NOT EDIT.")
+}
+
+// hasDocumentation reports whether a declaration is documented in the way of
+// its language
+func hasDocumentation(node *ts.Node, file *entities.File) bool {
+	if file.Language == "python" {
+		return hasDocstring(node)
+	}
+	jsDoc := file.Language != "go"
+	if next := node.NextSibling(); !jsDoc && next != nil && next.Kind() == "comment" &&
+		next.StartPosition().Row == node.EndPosition().Row {
+		// Constants and variables in a Go block are commonly described by a
+		// comment at the end of their line
+		return true
+	}
+	for n := node; n != nil; n = n.Parent() {
+		if previous := n.PrevSibling(); previous != nil && previous.Kind() == "comment" &&
+			previous.EndPosition().Row+1 >= n.StartPosition().Row &&
+			onlyWhitespaceBefore(file.Content, previous.StartByte()) &&
+			(!jsDoc || strings.HasPrefix(previous.Utf8Text(file.Content), "/**")) {
+			return true
+		}
+		parent := n.Parent()
+		if parent == nil || !docWrappers[parent.Kind()] {
+			return false
+		}
+	}
+	return false
+}
+
+// hasDocstring reports whether a Python function or class body starts with a
+// string
+func hasDocstring(node *ts.Node) bool {
+	if node.Kind() == "decorated_definition" {
+		node = node.ChildByFieldName("definition")
+	}
+	if node == nil {
+		return false
+	}
+	body := node.ChildByFieldName("body")
+	if body == nil {
+		return false
+	}
+	for _, statement := range statements(body) {
+		if statement.Kind() != "expression_statement" {
+			return false
+		}
+		value := statement.NamedChild(0)
+		return value != nil && value.Kind() == "string"
+	}
+	return false
+}
+
+// newMissingDoc builds the MissingDoc entity for an undocumented declaration,
+// with a message in the style of the language's linters
+func newMissingDoc(file *entities.File, entity *entities.Entity, kind string) *entities.Entity {
+	name := entity.GetFullName()
+	var message string
+	switch file.Language {
+	case "go":
+		if entity.Type == entities.EntityTypeMethod {
+			receiver, _ := entity.GetProperty("receiver").(string)
+			name = ReceiverTypeName(receiver) + "." + entity.Name
+		} else if kind != "function" && kind != "var" {
+			kind = "type" // golint names every type declaration a type
+		}
+		message = fmt.Sprintf("exported %s %s should have comment or be unexported", kind, name)
+	case "python":
+		message = fmt.Sprintf("public %s %s has no docstring", kind, name)
+	default:
+		message = fmt.Sprintf("exported %s %s has no JSDoc comment", kind, name)
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("missing_doc:%s", entity.ID)))
+	finding := entities.NewEntity(hex.EncodeToString(hash[:8]), name, entities.EntityTypeMissingDoc, file.Path, entity.Node)
+	finding.SetProperty("entity", entity.ID)
+	finding.SetProperty("entity_name", name)
+	finding.SetProperty("entity_type", string(entity.Type))
+	finding.SetProperty("line", entity.StartLine())
+	finding.SetProperty("language", file.Language)
+	finding.SetProperty("message", message)
+	return finding
+}
@@ -0,0 +1,462 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Kinds of data access made by an N+1 call site
+const (
+	DataAccessQuery = "query" // database or cache query
+	DataAccessAPI   = "api"   // HTTP request
+)
+
+// queryReceivers are receiver names recognized as database, ORM or cache
+// handles. Names ending in "db", "repo", "repository", "dao" or "store", such
+// as userRepo or s.orderStore, are recognized as well.
+var queryReceivers = map[string]bool{
+	"db":         true,
+	"database":   true,
+	"conn":       true,
+	"connection": true,
+	"tx":         true,
+	"txn":        true,
+	"cursor":     true,
+	"cur":        true,
+	"session":    true,
+	"pool":       true,
+	"objects":    true, // Django managers: User.objects.get
+	"prisma":     true,
+	"knex":       true,
+	"sequelize":  true,
+	"collection": true,
+	"em":         true, // TypeORM/MikroORM entity manager
+	"redis":      true,
+	"rdb":        true,
+}
+
+// queryMethods are lower-cased query method names with underscores and a
+// trailing "context" removed, so QueryRowContext and fetch_one both match
+var queryMethods = map[string]bool{
+	"query":      true,
+	"queryrow":   true,
+	"queryx":     true,
+	"queryrowx":  true,
+	"exec":       true,
+	"execute":    true,
+	"get":        true,
+	"select":     true,
+	"first":      true,
+	"take":       true,
+	"find":       true,
+	"findone":    true,
+	"findbyid":   true,
+	"findbypk":   true,
+	"findunique": true,
+	"findfirst":  true,
+	"findmany":   true,
+	"findall":    true,
+	"filter":     true,
+	"filterby":   true,
+	"fetchone":   true,
+	"fetchall":   true,
+	"fetchrow":   true,
+	"scalar":     true,
+	"scalars":    true,
+	"one":        true,
+	"count":      true,
+	"hget":       true,
+	"hgetall":    true,
+}
+
+// apiReceivers are receiver names recognized as HTTP clients
+var apiReceivers = map[string]bool{
+	"http":       true,
+	"httpclient": true,
+	"apiclient":  true,
+	"api":        true,
+	"axios":      true,
+	"requests":   true,
+	"httpx":      true,
+}
+
+// apiMethods are lower-cased HTTP client method names
+var apiMethods = map[string]bool{
+	"get":      true,
+	"post":     true,
+	"put":      true,
+	"patch":    true,
+	"delete":   true,
+	"head":     true,
+	"request":  true,
+	"do":       true,
+	"postform": true,
+}
+
+// iterationMethods are TypeScript array methods whose callback runs once per
+// element
+var iterationMethods = map[string]bool{
+	"forEach": true,
+	"map":     true,
+	"flatMap": true,
+	"filter":  true,
+	"some":    true,
+	"every":   true,
+}
+
+// loopScope is a loop found in a parse tree: the node spanning the whole loop,
+// the node whose calls run once per iteration and the names bound by each
+// iteration
+type loopScope struct {
+	node      *ts.Node
+	body      *ts.Node
+	kind      string
+	variables []string
+}
+
+// nPlusOneSite is a data access call inside a loop that depends on the loop
+type nPlusOneSite struct {
+	call       *ts.Node
+	loop       loopScope
+	access     string
+	dependsOn  []string
+	apiCallRef *entities.Entity
+}
+
+// detectNPlusOne adds an NPlusOne entity for every database query or HTTP
+// request made inside a loop with arguments derived from the loop variable,
+// the pattern that turns one query for a list into one query per element.
+// Values assigned from the loop variable inside the loop body count as
+// derived. When a call sits in nested loops it is attributed to the innermost
+// loop it depends on.
+//
+// Calls are recognized by their receiver and method names and, in TypeScript,
+// by the APICall entities the analyzer already created. Test files and
+// functions marked with an onyx:ignore comment are skipped.
+func detectNPlusOne(file *entities.File) {
+	if file.Tree == nil || entities.IsTestFilePath(file.Path) {
+		return
+	}
+
+	apiCalls := make(map[uint]*entities.Entity)
+	for _, entity := range file.GetEntitiesByType(entities.EntityTypeAPICall) {
+		if entity.Node != nil {
+			apiCalls[entity.Node.StartByte()] = entity
+		}
+	}
+
+	// Loops are visited outermost first, so inner loops overwrite the sites
+	// they also explain
+	sites := make(map[uint]*nPlusOneSite)
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		loop, ok := loopScopeFor(node, file.Content)
+		if !ok {
+			return
+		}
+		for _, site := range loopDataAccess(loop, file.Content, apiCalls) {
+			sites[site.call.StartByte()] = site
+		}
+	})
+
+	offsets := make([]uint, 0, len(sites))
+	for offset := range sites {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	functions := append(append([]*entities.Entity{}, file.Functions...), file.Methods...)
+	for _, offset := range offsets {
+		site := sites[offset]
+		enclosing := innermostEntity(functions, site.call)
+		if enclosing != nil && enclosing.IsIgnored() {
+			continue
+		}
+		file.AddEntity(newNPlusOne(file, site, enclosing))
+	}
+}
+
+// loopScopeFor returns the loop scope of node if node is a loop that binds a
+// variable per iteration
+func loopScopeFor(node *ts.Node, content []byte) (loopScope, bool) {
+	loop := loopScope{node: node, body: node.ChildByFieldName("body")}
+
+	switch node.Kind() {
+	case "for_statement":
+		for i := uint(0); i < node.NamedChildCount(); i++ {
+			switch clause := node.NamedChild(i); clause.Kind() {
+			case "range_clause": // Go
+				loop.kind = "for-range"
+				loop.variables = identifierNames(clause.ChildByFieldName("left"), content)
+			case "for_clause": // Go
+				loop.kind = "for"
+				if init := clause.ChildByFieldName("initializer"); init != nil {
+					loop.variables = assignedNames(init, content)
+				}
+			}
+		}
+		if left := node.ChildByFieldName("left"); left != nil { // Python
+			loop.kind = "for-in"
+			loop.variables = identifierNames(left, content)
+		}
+		if init := node.ChildByFieldName("initializer"); init != nil { // TypeScript
+			loop.kind = "for"
+			loop.variables = assignedNames(init, content)
+		}
+
+	case "for_in_statement": // TypeScript for...of and for...in
+		loop.kind = "for-in"
+		if operator := node.ChildByFieldName("operator"); operator != nil && operator.Kind() == "of" {
+			loop.kind = "for-of"
+		}
+		loop.variables = identifierNames(node.ChildByFieldName("left"), content)
+
+	case "list_comprehension", "set_comprehension", "dictionary_comprehension", "generator_expression":
+		loop.kind = "comprehension"
+		for i := uint(0); i < node.NamedChildCount(); i++ {
+			if clause := node.NamedChild(i); clause.Kind() == "for_in_clause" {
+				loop.variables = append(loop.variables, identifierNames(clause.ChildByFieldName("left"), content)...)
+			}
+		}
+
+	case "call_expression": // TypeScript items.forEach(item => ...)
+		function := node.ChildByFieldName("function")
+		if function == nil || function.Kind() != "member_expression" {
+			return loop, false
+		}
+		method := function.ChildByFieldName("property")
+		if method == nil || !iterationMethods[method.Utf8Text(content)] {
+			return loop, false
+		}
+		callback := firstArgument(node)
+		if callback == nil {
+			return loop, false
+		}
+		switch callback.Kind() {
+		case "arrow_function", "function_expression", "function":
+		default:
+			return loop, false
+		}
+		loop.kind = method.Utf8Text(content)
+		loop.body = callback.ChildByFieldName("body")
+		if param := callback.ChildByFieldName("parameter"); param != nil {
+			loop.variables = identifierNames(param, content)
+		} else {
+			loop.variables = identifierNames(callback.ChildByFieldName("parameters"), content)
+		}
+	}
+
+	if loop.kind == "" || loop.body == nil || len(loop.variables) == 0 {
+		return loop, false
+	}
+	return loop, true
+}
+
+// loopDataAccess finds the data access calls in a loop body whose arguments or
+// receiver depend on the loop variables
+func loopDataAccess(loop loopScope, content []byte, apiCalls map[uint]*entities.Entity) []*nPlusOneSite {
+	derived := make(map[string]bool, len(loop.variables))
+	for _, name := range loop.variables {
+		derived[name] = true
+	}
+
+	sites := make([]*nPlusOneSite, 0)
+	walkTree(loop.body, func(node *ts.Node) {
+		switch node.Kind() {
+		case "short_var_declaration", "assignment_statement", "assignment", "assignment_expression", "variable_declarator":
+			left, right := node.ChildByFieldName("left"), node.ChildByFieldName("right")
+			if node.Kind() == "variable_declarator" {
+				left, right = node.ChildByFieldName("name"), node.ChildByFieldName("value")
+			}
+			if left != nil && right != nil && len(referencedNames(right, derived, content)) > 0 {
+				for _, name := range identifierNames(left, content) {
+					derived[name] = true
+				}
+			}
+
+		case "call_expression", "call":
+			function := node.ChildByFieldName("function")
+			if function == nil {
+				return
+			}
+			access, ok := classifyDataAccessCall(function.Utf8Text(content))
+			apiCall := apiCalls[node.StartByte()]
+			if apiCall != nil {
+				access, ok = DataAccessAPI, true
+			}
+			if !ok {
+				return
+			}
+			dependsOn := referencedNames(node, derived, content)
+			if len(dependsOn) == 0 {
+				return
+			}
+			sites = append(sites, &nPlusOneSite{call: node, loop: loop, access: access, dependsOn: dependsOn, apiCallRef: apiCall})
+		}
+	})
+	return sites
+}
+
+// classifyDataAccessCall decides whether a callee such as "db.QueryRowContext",
+// "User.objects.get", "prisma.user.findUnique" or "axios.get" queries a
+// database or calls an HTTP API
+func classifyDataAccessCall(callee string) (string, bool) {
+	callee = stripCallArguments(callee)
+	switch callee {
+	case "fetch", "window.fetch", "globalThis.fetch":
+		return DataAccessAPI, true
+	}
+
+	dot := strings.LastIndex(callee, ".")
+	if dot <= 0 || dot == len(callee)-1 {
+		return "", false
+	}
+	method := strings.ToLower(strings.ReplaceAll(callee[dot+1:], "_", ""))
+	method = strings.TrimSuffix(method, "context")
+
+	for _, segment := range strings.Split(callee[:dot], ".") {
+		name := strings.ToLower(strings.Trim(segment, "_"))
+		if apiMethods[method] && apiReceivers[name] {
+			return DataAccessAPI, true
+		}
+		if queryMethods[method] && isQueryReceiver(name) {
+			return DataAccessQuery, true
+		}
+	}
+	return "", false
+}
+
+// isQueryReceiver reports whether a lower-cased receiver name looks like a
+// database, ORM or cache handle
+func isQueryReceiver(name string) bool {
+	if queryReceivers[name] {
+		return true
+	}
+	for _, suffix := range []string{"db", "repo", "repository", "dao", "store"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// identifierNames returns the names bound by a pattern, such as the left side
+// of a range clause or a destructured callback parameter. The blank
+// identifier is left out.
+func identifierNames(pattern *ts.Node, content []byte) []string {
+	if pattern == nil {
+		return nil
+	}
+	names := make([]string, 0)
+	walkTree(pattern, func(node *ts.Node) {
+		switch node.Kind() {
+		case "identifier", "shorthand_property_identifier_pattern":
+			// Type annotations of TypeScript parameters are not bindings
+			if hasAncestor(node, "type_annotation") {
+				return
+			}
+			if name := node.Utf8Text(content); name != "_" {
+				names = append(names, name)
+			}
+		}
+	})
+	return names
+}
+
+// assignedNames returns the names declared by a loop initializer such as
+// "i := 0" or "let i = 0, n = items.length"
+func assignedNames(init *ts.Node, content []byte) []string {
+	names := make([]string, 0)
+	walkTree(init, func(node *ts.Node) {
+		switch node.Kind() {
+		case "short_var_declaration", "assignment_statement", "assignment_expression":
+			names = append(names, identifierNames(node.ChildByFieldName("left"), content)...)
+		case "variable_declarator":
+			names = append(names, identifierNames(node.ChildByFieldName("name"), content)...)
+		}
+	})
+	return names
+}
+
+// referencedNames returns the names from derived that node references, in
+// order of first use
+func referencedNames(node *ts.Node, derived map[string]bool, content []byte) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	walkTree(node, func(n *ts.Node) {
+		switch n.Kind() {
+		case "identifier", "shorthand_property_identifier":
+		default:
+			return
+		}
+		// The attribute name in Python's obj.name is an identifier too
+		if parent := n.Parent(); parent != nil && parent.Kind() == "attribute" {
+			if attribute := parent.ChildByFieldName("attribute"); attribute != nil && attribute.StartByte() == n.StartByte() {
+				return
+			}
+		}
+		name := n.Utf8Text(content)
+		if derived[name] && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+// firstArgument returns the first argument of a call, skipping comments
+func firstArgument(call *ts.Node) *ts.Node {
+	args := call.ChildByFieldName("arguments")
+	if args == nil {
+		return nil
+	}
+	for i := uint(0); i < args.NamedChildCount(); i++ {
+		if arg := args.NamedChild(i); arg.Kind() != "comment" {
+			return arg
+		}
+	}
+	return nil
+}
+
+// innermostEntity returns the entity with the smallest span containing node
+func innermostEntity(candidates []*entities.Entity, node *ts.Node) *entities.Entity {
+	var innermost *entities.Entity
+	for _, candidate := range candidates {
+		if uint(candidate.StartByte) > node.StartByte() || uint(candidate.EndByte) < node.EndByte() {
+			continue
+		}
+		if innermost == nil || candidate.EndByte-candidate.StartByte < innermost.EndByte-innermost.StartByte {
+			innermost = candidate
+		}
+	}
+	return innermost
+}
+
+// newNPlusOne builds the NPlusOne entity for a call site
+func newNPlusOne(file *entities.File, site *nPlusOneSite, enclosing *entities.Entity) *entities.Entity {
+	callee := strings.Join(strings.Fields(site.call.ChildByFieldName("function").Utf8Text(file.Content)), "")
+	hash := sha256.Sum256([]byte(fmt.Sprintf("n_plus_one:%s:%d", file.Path, site.call.StartByte())))
+
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), callee, entities.EntityTypeNPlusOne, file.Path, site.call)
+	entity.SetProperty("callee", callee)
+	entity.SetProperty("access_kind", site.access)
+	entity.SetProperty("loop_kind", site.loop.kind)
+	entity.SetProperty("loop_variables", strings.Join(site.loop.variables, ", "))
+	entity.SetProperty("depends_on", strings.Join(site.dependsOn, ", "))
+	entity.SetProperty("loop_start_line", int(site.loop.node.StartPosition().Row)+1)
+	entity.SetProperty("loop_end_line", int(site.loop.node.EndPosition().Row)+1)
+	if site.apiCallRef != nil {
+		entity.SetProperty("api_call", site.apiCallRef.ID)
+	}
+	if enclosing != nil {
+		entity.SetProperty("enclosing_function", enclosing.ID)
+		entity.SetProperty("enclosing_function_name", enclosing.GetFullName())
+	}
+	return entity
+}
@@ -0,0 +1,263 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// nestedFunctionKinds are the function nodes of each language that can be
+// defined inside another function
+var nestedFunctionKinds = map[string]map[string]bool{
+	"go":         {"func_literal": true},
+	"python":     {"function_definition": true, "lambda": true},
+	"typescript": {"function_declaration": true, "function_expression": true, "arrow_function": true, "generator_function_declaration": true},
+}
+
+// functionScopeKinds are the function nodes that enclose nested functions
+// without being nested themselves
+var functionScopeKinds = map[string]bool{
+	"function_declaration": true,
+	"method_declaration":   true,
+	"method_definition":    true,
+}
+
+// classScopeKinds end the search for an enclosing function: a method of a
+// class declared inside a function is not a nested function
+var classScopeKinds = map[string]bool{
+	"class_definition":  true,
+	"class_declaration": true,
+	"class":             true,
+}
+
+// nestedFunction is a function node defined inside a function entity
+type nestedFunction struct {
+	node      *ts.Node
+	entity    *entities.Entity // the analyzer's entity for node, if any
+	enclosing *entities.Entity
+}
+
+// applyNesting makes the handling of functions defined inside other functions
+// the same in every language. The analyzers differ: Python extracts nested
+// defs, TypeScript nested function declarations but no arrow functions, and
+// Go no func literals at all.
+//
+// With extract, every nested function becomes a Function entity with the
+// "nested", "anonymous", "enclosing_function" and "enclosing_function_name"
+// properties and a CONTAINS relationship from its enclosing function, and the
+// relationships, symbols and entities located inside it are moved from the
+// enclosing function to it. Without, nested function entities are removed
+// and what referred to them as a source refers to the enclosing function
+// instead; relationships targeting them are dropped. The file's relationships
+// are returned updated.
+func applyNesting(file *entities.File, relationships []*entities.Relationship, extract bool) []*entities.Relationship {
+	kinds := nestedFunctionKinds[file.Language]
+	if file.Tree == nil || kinds == nil {
+		return relationships
+	}
+
+	// Function entities by the start of their node; nested functions are
+	// found outermost first, so an enclosing function is always registered
+	// (or removed) before the functions inside it are looked up
+	functions := make(map[uint]*entities.Entity)
+	for _, entity := range file.GetAllEntities() {
+		switch entity.Type {
+		case entities.EntityTypeFunction, entities.EntityTypeMethod, entities.EntityTypeTestFunction:
+			if entity.Node != nil {
+				functions[entity.Node.StartByte()] = entity
+			}
+		}
+	}
+
+	replaced := make(map[string]*entities.Entity)
+	anonymous := make(map[string]int)
+	walkTree(file.Tree.RootNode(), func(node *ts.Node) {
+		if !node.IsNamed() || !kinds[node.Kind()] {
+			return
+		}
+		nested := findNestedFunction(node, kinds, functions)
+		if nested == nil {
+			return
+		}
+		if !extract {
+			if nested.entity != nil {
+				delete(functions, node.StartByte())
+				file.RemoveEntity(nested.entity)
+				replaced[nested.entity.ID] = nested.enclosing
+			}
+			return
+		}
+
+		entity := nested.entity
+		if entity == nil {
+			entity = newNestedFunction(file, nested, anonymous)
+			file.AddEntity(entity)
+			functions[node.StartByte()] = entity
+		}
+		if entity.Parent == nil {
+			nested.enclosing.AddChild(entity)
+		}
+		entity.SetProperty("nested", true)
+		entity.SetProperty("enclosing_function", nested.enclosing.ID)
+		entity.SetProperty("enclosing_function_name", nested.enclosing.GetFullName())
+		if _, ok := entity.GetProperty("anonymous").(bool); !ok {
+			entity.SetProperty("anonymous", false)
+		}
+		moveIntoNested(file, relationships, nested.enclosing, entity)
+
+		rel := entities.NewRelationship(generateNestedID("contains", file.Path, node.StartByte()),
+			entities.RelationshipTypeContains, nested.enclosing, entity)
+		rel.SetProvenance(file.Path, node, file.Content)
+		relationships = append(relationships, rel)
+	})
+
+	if len(replaced) == 0 {
+		return relationships
+	}
+	kept := relationships[:0]
+	for _, rel := range relationships {
+		if replaced[rel.TargetID] != nil {
+			continue
+		}
+		if enclosing := replaced[rel.SourceID]; enclosing != nil {
+			rel.Source = enclosing
+			rel.SourceID = enclosing.ID
+			rel.SourceType = enclosing.Type
+		}
+		kept = append(kept, rel)
+	}
+	for _, entity := range file.Entities {
+		id, _ := entity.GetProperty("enclosing_function").(string)
+		if enclosing := replaced[id]; enclosing != nil {
+			entity.SetProperty("enclosing_function", enclosing.ID)
+			entity.SetProperty("enclosing_function_name", enclosing.GetFullName())
+		}
+	}
+	return kept
+}
+
+// findNestedFunction returns the nested function for a function node, or nil
+// when the node is not inside a function entity of the file
+func findNestedFunction(node *ts.Node, kinds map[string]bool, functions map[uint]*entities.Entity) *nestedFunction {
+	for current := node.Parent(); current != nil; current = current.Parent() {
+		kind := current.Kind()
+		if classScopeKinds[kind] {
+			return nil
+		}
+		if !kinds[kind] && !functionScopeKinds[kind] {
+			continue
+		}
+		if enclosing := functions[current.StartByte()]; enclosing != nil {
+			return &nestedFunction{node: node, entity: functions[node.StartByte()], enclosing: enclosing}
+		}
+	}
+	return nil
+}
+
+// newNestedFunction creates the Function entity of a nested function the
+// analyzer did not extract. It is named after the variable it is assigned
+// to, or numbered within its enclosing function like the Go compiler does, so
+// the first anonymous function of Handle has the full name "Handle.func1".
+func newNestedFunction(file *entities.File, nested *nestedFunction, anonymous map[string]int) *entities.Entity {
+	node := nested.node
+	name := boundName(node, file.Content)
+	bound := name != ""
+	if !bound {
+		anonymous[nested.enclosing.ID]++
+		name = fmt.Sprintf("func%d", anonymous[nested.enclosing.ID])
+	}
+
+	entity := entities.NewEntity(generateNestedID("nested_function", file.Path, node.StartByte()),
+		name, entities.EntityTypeFunction, file.Path, node)
+	entity.Body = node.Utf8Text(file.Content)
+	if body := node.ChildByFieldName("body"); body != nil {
+		entity.Signature = strings.TrimSpace(string(file.Content[node.StartByte():body.StartByte()]))
+		entity.Body = body.Utf8Text(file.Content)
+	}
+	entity.SetProperty("anonymous", !bound)
+	entity.SetProperty("language", file.Language)
+	return entity
+}
+
+// boundName returns the name a function literal is bound to: the name of a
+// function expression, or the variable of an assignment or declaration with
+// the function as its only value
+func boundName(node *ts.Node, content []byte) string {
+	if name := node.ChildByFieldName("name"); name != nil {
+		return name.Utf8Text(content)
+	}
+	parent := node.Parent()
+	if parent == nil {
+		return ""
+	}
+
+	var left *ts.Node
+	switch parent.Kind() {
+	case "variable_declarator": // const f = () => {}
+		left = parent.ChildByFieldName("name")
+	case "assignment", "assignment_expression": // f = lambda: ..., f = () => {}
+		left = parent.ChildByFieldName("left")
+	case "expression_list": // f := func() {}, var f = func() {}
+		if parent.NamedChildCount() != 1 || parent.Parent() == nil {
+			return ""
+		}
+		switch declaration := parent.Parent(); declaration.Kind() {
+		case "short_var_declaration", "assignment_statement":
+			left = declaration.ChildByFieldName("left")
+		case "var_spec":
+			left = declaration.ChildByFieldName("name")
+		}
+		if left != nil && left.Kind() == "expression_list" && left.NamedChildCount() == 1 {
+			left = left.NamedChild(0)
+		}
+	}
+	if left == nil || left.Kind() != "identifier" {
+		return ""
+	}
+	return left.Utf8Text(content)
+}
+
+// moveIntoNested attributes to a nested function what the analyzer
+// attributed to its enclosing function but is located inside the nested one:
+// relationships, symbols and entities such as log statements
+func moveIntoNested(file *entities.File, relationships []*entities.Relationship, enclosing, nested *entities.Entity) {
+	inside := func(start uint32) bool {
+		return start >= nested.StartByte && start < nested.EndByte
+	}
+
+	for _, rel := range relationships {
+		if rel.SourceID == enclosing.ID && rel.Location != nil && inside(rel.Location.StartByte) {
+			rel.Source = nested
+			rel.SourceID = nested.ID
+			rel.SourceType = nested.Type
+		}
+	}
+	for symbolType, nodes := range enclosing.Symbols {
+		kept := nodes[:0]
+		for _, node := range nodes {
+			if inside(uint32(node.StartByte())) {
+				nested.AddSymbol(symbolType, node)
+			} else {
+				kept = append(kept, node)
+			}
+		}
+		enclosing.Symbols[symbolType] = kept
+	}
+	for _, entity := range file.Entities {
+		if id, _ := entity.GetProperty("enclosing_function").(string); id == enclosing.ID && entity != nested && inside(entity.StartByte) {
+			entity.SetProperty("enclosing_function", nested.ID)
+			entity.SetProperty("enclosing_function_name", nested.GetFullName())
+		}
+	}
+}
+
+// generateNestedID derives a stable ID from a kind and a position in a file
+func generateNestedID(kind, filePath string, startByte uint) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", kind, filePath, startByte)))
+	return hex.EncodeToString(hash[:8])
+}
@@ -0,0 +1,247 @@
+package analyzer
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// PackageImport is an import from one package of the repository to another.
+// Packages are directories relative to the repository root, "." for the root
+// itself.
+type PackageImport struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Files []string `json:"files"` // files of From importing To
+}
+
+// tsImportExtensions are tried, in order, when resolving a relative
+// TypeScript or JavaScript import that omits the extension
+var tsImportExtensions = []string{".ts", ".tsx", ".js", ".jsx"}
+
+// recordGoModule remembers the module path declared by a go.mod file of the
+// repository
+func (gb *GraphBuilder) recordGoModule(rootPath, goModPath string) {
+	content, err := os.ReadFile(goModPath)
+	if err != nil {
+		return
+	}
+	dir, err := filepath.Rel(rootPath, filepath.Dir(goModPath))
+	if modulePath := goModulePath(content); modulePath != "" && err == nil {
+		gb.goModules[filepath.ToSlash(dir)] = modulePath
+	}
+}
+
+// recordEnclosingGoModule handles a repository root below its go.mod, such as
+// one module directory of a monorepo: the nearest go.mod above the root is
+// recorded as declaring the module path of the root itself
+func (gb *GraphBuilder) recordEnclosingGoModule(rootPath string) {
+	root, err := filepath.Abs(rootPath)
+	if err != nil {
+		return
+	}
+	for dir := filepath.Dir(root); ; dir = filepath.Dir(dir) {
+		content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			modulePath := goModulePath(content)
+			rel, err := filepath.Rel(dir, root)
+			if modulePath != "" && err == nil {
+				gb.goModules["."] = modulePath + "/" + filepath.ToSlash(rel)
+			}
+			return
+		}
+		if filepath.Dir(dir) == dir {
+			return
+		}
+	}
+}
+
+// goModulePath returns the module path declared by go.mod content
+func goModulePath(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// Packages returns the packages of the repository: every directory holding
+// an analyzed Go, Python, TypeScript or JavaScript file
+func (gb *GraphBuilder) Packages() []string {
+	seen := make(map[string]bool)
+	packages := make([]string, 0)
+	for filePath, file := range gb.files {
+		if file.Language == "hcl" || file.Language == "sql" {
+			continue
+		}
+		dir := path.Dir(filepath.ToSlash(filePath))
+		if !seen[dir] {
+			seen[dir] = true
+			packages = append(packages, dir)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// PackageImports resolves the imports of every analyzed file to the packages
+// of the repository they refer to and returns one PackageImport per pair of
+// packages, ordered by importing then imported package. Imports of a package
+// from its own files and of third-party packages are left out.
+//
+// Go imports are matched against the module paths of the repository's go.mod
+// files; Python absolute imports against module files and packages under the
+// repository root or any directory above the importing file, and relative
+// imports from the importing package; TypeScript and JavaScript relative
+// imports against files with or without their extension and index files.
+// Bare TypeScript module names, which depend on bundler configuration, are
+// not resolved.
+func (gb *GraphBuilder) PackageImports() []PackageImport {
+	known := make(map[string]bool, len(gb.files))
+	packages := make(map[string]bool)
+	for _, pkg := range gb.Packages() {
+		packages[pkg] = true
+	}
+	for filePath := range gb.files {
+		known[filepath.ToSlash(filePath)] = true
+	}
+
+	type edge struct{ from, to string }
+	importers := make(map[edge]map[string]bool)
+	for filePath, file := range gb.files {
+		filePath = filepath.ToSlash(filePath)
+		from := path.Dir(filePath)
+		for _, imp := range file.Imports {
+			for _, to := range gb.resolveImport(file, filePath, imp, known, packages) {
+				if to == from {
+					continue
+				}
+				key := edge{from, to}
+				if importers[key] == nil {
+					importers[key] = make(map[string]bool)
+				}
+				importers[key][filePath] = true
+			}
+		}
+	}
+
+	imports := make([]PackageImport, 0, len(importers))
+	for key, files := range importers {
+		imp := PackageImport{From: key.from, To: key.to, Files: make([]string, 0, len(files))}
+		for file := range files {
+			imp.Files = append(imp.Files, file)
+		}
+		sort.Strings(imp.Files)
+		imports = append(imports, imp)
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		if imports[i].From != imports[j].From {
+			return imports[i].From < imports[j].From
+		}
+		return imports[i].To < imports[j].To
+	})
+	return imports
+}
+
+// resolveImport returns the packages of the repository an import refers to
+func (gb *GraphBuilder) resolveImport(file *entities.File, filePath string, imp *entities.Entity, known, packages map[string]bool) []string {
+	dir := path.Dir(filePath)
+	switch file.Language {
+	case "go":
+		importPath, _ := imp.GetProperty("path").(string)
+		if importPath == "" {
+			importPath = imp.Name
+		}
+		for moduleDir, modulePath := range gb.goModules {
+			if importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/") {
+				continue
+			}
+			pkg := path.Join(moduleDir, strings.TrimPrefix(importPath, modulePath))
+			if packages[pkg] {
+				return []string{pkg}
+			}
+		}
+
+	case "python":
+		module := imp.Name
+		if strings.HasPrefix(module, ".") {
+			base := dir
+			trimmed := strings.TrimLeft(module, ".")
+			for i := 1; i < len(module)-len(trimmed); i++ {
+				base = path.Dir(base)
+			}
+			if trimmed == "" {
+				if packages[base] {
+					return []string{base}
+				}
+				return nil
+			}
+			if pkg := resolvePythonModule(base, trimmed, known); pkg != "" {
+				return []string{pkg}
+			}
+			return nil
+		}
+		// import a, b imports every listed module
+		modules := []string{module}
+		if full, _ := imp.GetProperty("full_import").(string); strings.HasPrefix(full, "import ") {
+			modules = modules[:0]
+			for _, name := range strings.Split(strings.TrimPrefix(full, "import "), ",") {
+				if fields := strings.Fields(name); len(fields) > 0 {
+					modules = append(modules, fields[0])
+				}
+			}
+		}
+		resolved := make([]string, 0, len(modules))
+		for _, module := range modules {
+			for root := dir; ; root = path.Dir(root) {
+				if pkg := resolvePythonModule(root, module, known); pkg != "" {
+					resolved = append(resolved, pkg)
+					break
+				}
+				if root == "." {
+					break
+				}
+			}
+		}
+		return resolved
+
+	case "typescript":
+		source := imp.Name
+		if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+			return nil
+		}
+		target := path.Join(dir, source)
+		candidates := []string{target}
+		for _, ext := range tsImportExtensions {
+			candidates = append(candidates, target+ext)
+		}
+		for _, ext := range tsImportExtensions {
+			candidates = append(candidates, path.Join(target, "index"+ext))
+		}
+		for _, candidate := range candidates {
+			if known[candidate] {
+				return []string{path.Dir(candidate)}
+			}
+		}
+	}
+	return nil
+}
+
+// resolvePythonModule returns the package holding a dotted module under root:
+// the directory of a/b/c.py, or a/b/c itself when it is a package
+func resolvePythonModule(root, module string, known map[string]bool) string {
+	target := path.Join(root, strings.ReplaceAll(module, ".", "/"))
+	if known[target+".py"] {
+		return path.Dir(target)
+	}
+	if known[path.Join(target, "__init__.py")] {
+		return target
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// indexPaths sorts the entities that belong to a file by path and position,
+// so that the entities under a directory form a contiguous run found by
+// binary search. It runs once phase 1 has registered every entity.
+func (gb *GraphBuilder) indexPaths() {
+	index := make([]*entities.Entity, 0, len(gb.allEntities))
+	for _, entity := range gb.allEntities {
+		if entity.FilePath != "" {
+			index = append(index, entity)
+		}
+	}
+	sort.Slice(index, func(i, j int) bool {
+		if index[i].FilePath != index[j].FilePath {
+			return index[i].FilePath < index[j].FilePath
+		}
+		if index[i].StartByte != index[j].StartByte {
+			return index[i].StartByte < index[j].StartByte
+		}
+		return index[i].ID < index[j].ID
+	})
+	gb.pathIndex = index
+}
+
+// GetEntitiesInPath returns the entities of the files under a directory, or
+// of a single file, ordered by file path and position. The prefix is relative
+// to the repository root or absolute within it, and matches whole path
+// elements: "internal/db" does not match "internal/dbutil". An empty prefix
+// or "." matches every file.
+func (gb *GraphBuilder) GetEntitiesInPath(prefix string) []*entities.Entity {
+	prefix = filepath.Clean(filepath.FromSlash(prefix))
+	if filepath.IsAbs(prefix) {
+		rel, err := filepath.Rel(gb.rootPath, prefix)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+		prefix = rel
+	}
+	if prefix == "." {
+		return append([]*entities.Entity(nil), gb.pathIndex...)
+	}
+
+	result := make([]*entities.Entity, 0)
+	start := sort.Search(len(gb.pathIndex), func(i int) bool {
+		return gb.pathIndex[i].FilePath >= prefix
+	})
+	for _, entity := range gb.pathIndex[start:] {
+		path := entity.FilePath
+		if !strings.HasPrefix(path, prefix) {
+			break
+		}
+		// Paths such as prefix-old/ and prefix.go sort among those under
+		// prefix/, so each is checked for a path element boundary
+		if len(path) == len(prefix) || path[len(prefix)] == filepath.Separator {
+			result = append(result, entity)
+		}
+	}
+	return result
+}
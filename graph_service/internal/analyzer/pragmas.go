@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Pragma comments let developers exclude a declaration from analysis without
+// external configuration, in the comment lines directly above it:
+//
+//	// onyx:ignore            -> "ignored": left out of coverage and lint checks
+//	# onyx:ignore-coverage   -> "ignore_coverage": only left out of coverage
+//
+// Both may be followed by a reason. Members of an ignored class, struct or
+// interface inherit the pragma, and so do Go methods of an ignored type
+// declared in the same file.
+var ignorePragma = regexp.MustCompile(`onyx:ignore(-coverage)?\b`)
+
+// markPragmas sets the "ignored" and "ignore_coverage" properties on the
+// entities of a file that are preceded by a pragma comment
+func markPragmas(file *entities.File) {
+	if !bytes.Contains(file.Content, []byte("onyx:ignore")) {
+		return
+	}
+	lines := strings.Split(string(file.Content), "\n")
+
+	all := file.GetAllEntities()
+	for _, entity := range all {
+		if entity.Node == nil || entity.Type == entities.EntityTypeImport {
+			continue
+		}
+		declaration := entity.Node
+		if parent := declaration.Parent(); parent != nil {
+			switch parent.Kind() {
+			case "decorated_definition", "export_statement":
+				declaration = parent
+			}
+		}
+
+		for row := int(declaration.StartPosition().Row) - 1; row >= 0 && row < len(lines); row-- {
+			line := strings.TrimSpace(lines[row])
+			if !isCommentLine(line) {
+				break
+			}
+			for _, match := range ignorePragma.FindAllStringSubmatch(line, -1) {
+				if match[1] == "" {
+					entity.SetProperty("ignored", true)
+				} else {
+					entity.SetProperty("ignore_coverage", true)
+				}
+			}
+		}
+	}
+
+	// Go methods are not children of their receiver type
+	types := make(map[string]*entities.Entity)
+	for _, entity := range all {
+		switch entity.Type {
+		case entities.EntityTypeStruct, entities.EntityTypeClass, entities.EntityTypeInterface:
+			types[entity.Name] = entity
+		}
+	}
+	for _, entity := range all {
+		owner := entity.Parent
+		if receiver, ok := entity.GetProperty("receiver").(string); ok && owner == nil {
+			owner = types[ReceiverTypeName(receiver)]
+		}
+		for ; owner != nil; owner = owner.Parent {
+			if owner.IsIgnored() {
+				entity.SetProperty("ignored", true)
+			}
+			if ignored, _ := owner.GetProperty("ignore_coverage").(bool); ignored {
+				entity.SetProperty("ignore_coverage", true)
+			}
+		}
+	}
+}
+
+// isCommentLine reports whether a trimmed source line is a comment in Go,
+// Python or TypeScript
+func isCommentLine(line string) bool {
+	for _, prefix := range []string{"//", "#", "/*", "*"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
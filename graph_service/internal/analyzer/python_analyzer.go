@@ -19,6 +19,9 @@ type PythonAnalyzer struct {
 	language      *ts.Language
 	currentFile   *entities.File
 	relationships []*entities.Relationship
+
+	// flagPatterns are extra function-name patterns that evaluate feature flags
+	flagPatterns []string
 }
 
 // NewPythonAnalyzer creates a new Python analyzer
@@ -102,6 +105,7 @@ func (pa *PythonAnalyzer) extractEntities(node *ts.Node, parent *entities.Entity
 		entity := pa.extractVariable(node, parent)
 		if entity != nil {
 			pa.currentFile.AddEntity(entity)
+			pa.extractDataClassField(node, entity, parent)
 		}
 	}
 
@@ -151,6 +155,8 @@ func (pa *PythonAnalyzer) extractFunction(node *ts.Node, parent *entities.Entity
 		entity.SetProperty("return_type", pa.getNodeText(returnTypeNode))
 		entity.AddSymbol("return_type", returnTypeNode)
 	}
+	params, results := pythonSignatureTypes(parametersNode, returnTypeNode, pa.currentFile.Content)
+	setSignatureTypes(entity, params, results)
 
 	// Extract decorators
 	pa.extractDecorators(node, entity)
@@ -294,6 +300,34 @@ func (pa *PythonAnalyzer) extractVariable(node *ts.Node, parent *entities.Entity
 	return entity
 }
 
+// extractDataClassField records field metadata for a class-level annotation in a
+// dataclass or pydantic model and attaches the variable to its class
+func (pa *PythonAnalyzer) extractDataClassField(node *ts.Node, variable, class *entities.Entity) {
+	if class == nil || class.Type != entities.EntityTypeClass || class.Node == nil || !isPythonDataClass(class) {
+		return
+	}
+
+	// Only direct members of the class body: assignment -> expression_statement -> block -> class
+	statement := node.Parent()
+	if statement == nil || statement.Kind() != "expression_statement" {
+		return
+	}
+	block := statement.Parent()
+	if block == nil || block.Parent() == nil || !block.Parent().Equals(*class.Node) {
+		return
+	}
+
+	annotation, _ := variable.GetProperty("type_annotation").(string)
+	if annotation == "" {
+		// Plain class attributes are not fields
+		return
+	}
+
+	jsonName, defaultValue, hasDefault, required := pythonFieldMetadata(variable.Name, annotation, node.ChildByFieldName("right"), pa.currentFile.Content)
+	setFieldMetadata(variable, jsonName, required, defaultValue, hasDefault)
+	class.AddChild(variable)
+}
+
 // extractParameterTypes extracts parameter type annotations
 func (pa *PythonAnalyzer) extractParameterTypes(parametersNode *ts.Node, entity *entities.Entity) {
 	pa.walkNode(parametersNode, func(n *ts.Node) {
@@ -349,8 +383,13 @@ func (pa *PythonAnalyzer) extractFunctionCalls(node *ts.Node, entity *entities.E
 func (pa *PythonAnalyzer) extractRelationships(node *ts.Node) {
 	// Extract function calls as relationships
 	pa.walkNode(node, func(n *ts.Node) {
-		if n.Kind() == "call" {
+		switch n.Kind() {
+		case "call":
 			pa.extractCallRelationship(n)
+			pa.extractLogStatement(n)
+			pa.extractFlagCheck(n)
+		case "try_statement":
+			pa.extractTryStatement(n)
 		}
 	})
 
@@ -372,6 +411,7 @@ func (pa *PythonAnalyzer) extractRelationships(node *ts.Node) {
 				entities.EntityTypeFile, // Source is a file
 				entity.Type,            // Target type depends on the actual entity
 			)
+			rel.SetProvenance(pa.currentFile.Path, entity.Node, pa.currentFile.Content)
 			pa.relationships = append(pa.relationships, rel)
 		}
 	}
@@ -386,6 +426,7 @@ func (pa *PythonAnalyzer) extractInheritanceRelationships(classEntity *entities.
 			relID := pa.generateRelationshipID("inherits", classEntity.ID, baseClassName)
 			rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeInherits, classEntity.ID, baseClassName, entities.EntityTypeClass, entities.EntityTypeClass)
 			rel.SetLocation(pa.currentFile.Path, uint32(baseClassNode.StartByte()), uint32(baseClassNode.EndByte()))
+			rel.SetProvenance(pa.currentFile.Path, baseClassNode, pa.currentFile.Content)
 			pa.relationships = append(pa.relationships, rel)
 		}
 	}
@@ -409,14 +450,169 @@ func (pa *PythonAnalyzer) extractCallRelationship(callNode *ts.Node) {
 		return
 	}
 
+	// Calls whose target depends on runtime state are recorded as UnresolvedCall
+	// entities instead of producing a (possibly false) CALLS relationship
+	if reason := pa.dynamicCallReason(functionNode, callingFunction); reason != "" {
+		pa.extractUnresolvedCall(callNode, calledFunction, reason, callingFunction)
+		return
+	}
+
 	// Create relationship
 	relID := pa.generateRelationshipID("calls", callingFunction.ID, calledFunction)
 	rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeCalls, callingFunction.ID, calledFunction, callingFunction.Type, entities.EntityTypeFunction)
 	rel.SetLocation(pa.currentFile.Path, uint32(callNode.StartByte()), uint32(callNode.EndByte()))
+	rel.SetProvenance(pa.currentFile.Path, callNode, pa.currentFile.Content)
 
 	pa.relationships = append(pa.relationships, rel)
 }
 
+// dynamicCallReason reports why a call target cannot be resolved statically, or
+// "" if the call is an ordinary name or attribute reference.
+//
+// Detected cases:
+//   - "call_result": the callee is itself a call, e.g. factory()() or getattr(o, n)()
+//   - "subscript": the callee is looked up in a container, e.g. handlers[key]()
+//   - "expression": the callee is a lambda or parenthesized expression
+//   - "local_binding": the callee is a parameter or local variable shadowing any definition
+//   - "dynamic_attribute": self.x() where x is assigned at runtime rather than defined as a method
+func (pa *PythonAnalyzer) dynamicCallReason(functionNode *ts.Node, caller *entities.Entity) string {
+	switch functionNode.Kind() {
+	case "call":
+		return "call_result"
+	case "subscript":
+		return "subscript"
+	case "lambda", "parenthesized_expression", "conditional_expression":
+		return "expression"
+	case "identifier":
+		if caller.Node != nil && pa.localBindings(caller.Node)[pa.getNodeText(functionNode)] {
+			return "local_binding"
+		}
+	case "attribute":
+		objectNode := functionNode.ChildByFieldName("object")
+		attrNode := functionNode.ChildByFieldName("attribute")
+		if objectNode == nil || attrNode == nil {
+			return ""
+		}
+		object := pa.getNodeText(objectNode)
+		if object != "self" && object != "cls" {
+			return ""
+		}
+		if pa.isRuntimeAttribute(caller.Parent, pa.getNodeText(attrNode)) {
+			return "dynamic_attribute"
+		}
+	}
+	return ""
+}
+
+// isRuntimeAttribute reports whether attribute name is not a method of class but
+// is assigned on self somewhere in its body
+func (pa *PythonAnalyzer) isRuntimeAttribute(class *entities.Entity, name string) bool {
+	if class == nil || class.Type != entities.EntityTypeClass || class.Node == nil {
+		return false
+	}
+	for _, child := range class.Children {
+		if child.Name == name && (child.Type == entities.EntityTypeMethod || child.Type == entities.EntityTypeFunction) {
+			return false
+		}
+	}
+
+	assigned := false
+	pa.walkNode(class.Node, func(n *ts.Node) {
+		if assigned || n.Kind() != "assignment" {
+			return
+		}
+		left := n.ChildByFieldName("left")
+		if left != nil && left.Kind() == "attribute" && pa.getNodeText(left) == "self."+name {
+			assigned = true
+		}
+	})
+	return assigned
+}
+
+// localBindings collects the parameter and local variable names bound inside a
+// function, excluding names bound only in nested functions or classes
+func (pa *PythonAnalyzer) localBindings(funcNode *ts.Node) map[string]bool {
+	bindings := make(map[string]bool)
+
+	if params := funcNode.ChildByFieldName("parameters"); params != nil {
+		for i := uint(0); i < params.NamedChildCount(); i++ {
+			param := params.NamedChild(i)
+			switch param.Kind() {
+			case "identifier":
+				bindings[pa.getNodeText(param)] = true
+			case "default_parameter", "typed_default_parameter":
+				if nameNode := param.ChildByFieldName("name"); nameNode != nil {
+					bindings[pa.getNodeText(nameNode)] = true
+				}
+			case "typed_parameter", "list_splat_pattern", "dictionary_splat_pattern":
+				for j := uint(0); j < param.NamedChildCount(); j++ {
+					if id := param.NamedChild(j); id.Kind() == "identifier" {
+						bindings[pa.getNodeText(id)] = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	var visit func(n *ts.Node)
+	visit = func(n *ts.Node) {
+		for i := uint(0); i < n.ChildCount(); i++ {
+			child := n.Child(i)
+			switch child.Kind() {
+			case "function_definition", "class_definition", "lambda":
+				continue
+			case "assignment", "augmented_assignment":
+				if left := child.ChildByFieldName("left"); left != nil && left.Kind() == "identifier" {
+					bindings[pa.getNodeText(left)] = true
+				}
+			}
+			visit(child)
+		}
+	}
+	if body := funcNode.ChildByFieldName("body"); body != nil {
+		visit(body)
+	}
+
+	delete(bindings, "self")
+	delete(bindings, "cls")
+	return bindings
+}
+
+// extractUnresolvedCall records a call with an unknown target as an UnresolvedCall entity
+// so that the call remains visible in the graph
+func (pa *PythonAnalyzer) extractUnresolvedCall(callNode *ts.Node, expression, reason string, caller *entities.Entity) {
+	id := pa.generateEntityID("unresolved_call", expression, callNode)
+	entity := entities.NewEntity(id, expression, entities.EntityTypeUnresolvedCall, pa.currentFile.Path, callNode)
+	entity.Signature = pa.getNodeText(callNode)
+	entity.SetProperty("expression", expression)
+	entity.SetProperty("reason", reason)
+	entity.SetProperty("caller_id", caller.ID)
+	entity.SetProperty("caller_name", caller.GetFullName())
+
+	pa.currentFile.AddEntity(entity)
+}
+
+// extractLogStatement records calls to the logging module and logger objects as
+// LogStatement entities
+func (pa *PythonAnalyzer) extractLogStatement(callNode *ts.Node) {
+	functionNode := callNode.ChildByFieldName("function")
+	if functionNode == nil {
+		return
+	}
+
+	callee := pa.getNodeText(functionNode)
+	logger, method, level, ok := classifyLogCall(callee)
+	if !ok {
+		return
+	}
+
+	message := logMessageSnippet(callNode.ChildByFieldName("arguments"), pa.currentFile.Content)
+	id := pa.generateEntityID("log_statement", callee, callNode)
+	entity := newLogStatement(id, pa.currentFile.Path, callNode, callee, logger, method, level, message, pa.findContainingFunction(callNode))
+	pa.currentFile.AddEntity(entity)
+}
+
 // findContainingFunction finds the function that contains the given node
 func (pa *PythonAnalyzer) findContainingFunction(node *ts.Node) *entities.Entity {
 	current := node.Parent()
@@ -947,12 +1143,14 @@ func (pa *PythonAnalyzer) extractPythonTestRelationships(testEntity *entities.En
 						relID := pa.generateRelationshipID("TESTS", testEntity.ID, targetEntity.ID)
 						rel := entities.NewRelationship(relID, entities.RelationshipTypeTests, testEntity, targetEntity)
 						rel.SetConfidenceScore(0.8) // High confidence for direct function calls
+						rel.SetProvenance(pa.currentFile.Path, n, pa.currentFile.Content)
 						pa.relationships = append(pa.relationships, rel)
 						
 						// Also create COVERS relationship
 						coverRelID := pa.generateRelationshipID("COVERS", testEntity.ID, targetEntity.ID)
 						coverRel := entities.NewRelationship(coverRelID, entities.RelationshipTypeCovers, testEntity, targetEntity)
 						coverRel.SetCoverageType("direct")
+						coverRel.SetProvenance(pa.currentFile.Path, n, pa.currentFile.Content)
 						pa.relationships = append(pa.relationships, coverRel)
 					}
 				}
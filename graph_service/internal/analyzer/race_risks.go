@@ -0,0 +1,521 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// goAccess is a use of a name that may be a package-level variable, or of a
+// field through the receiver of a method, inside a Go function. Which it
+// is, and how it is used, is only worked out by checkRaceRisks for the uses
+// of variables and fields.
+type goAccess struct {
+	file *entities.File
+	node *ts.Node
+	key  string // Package directory and name, or receiver type and field
+	init bool   // Inside an init function, which runs before any goroutine
+
+	write      bool             // Assigned, incremented, indexed into on the left or deleted from
+	locked     bool             // A Lock or RLock call comes before it in its function
+	concurrent bool             // Run as a goroutine
+	function   *entities.Entity // Innermost enclosing function or method
+}
+
+// goLaunch is a function or method started by name with a go statement
+type goLaunch struct {
+	dir    string
+	name   string
+	method bool
+}
+
+// goConcurrency is what collectGoConcurrency finds in a file
+type goConcurrency struct {
+	accesses []goAccess
+	launches []goLaunch
+}
+
+// raceSafeType reports whether values of a declared type synchronize
+// themselves, or are the locks themselves: sync and atomic types and channels
+func raceSafeType(typ string) bool {
+	typ = strings.TrimLeft(strings.TrimSpace(typ), "*")
+	return strings.HasPrefix(typ, "sync.") || strings.HasPrefix(typ, "atomic.") ||
+		strings.HasPrefix(typ, "chan ") || strings.HasPrefix(typ, "chan<-") || strings.HasPrefix(typ, "<-chan")
+}
+
+// collectGoConcurrency returns the accesses of a Go file to package-level
+// names and receiver fields, which checkRaceRisks matches against the
+// declarations of the package, and the functions it starts as goroutines.
+// Functions marked with an onyx:ignore comment are left out.
+func collectGoConcurrency(file *entities.File) goConcurrency {
+	var found goConcurrency
+	if file.Language != "go" || file.Tree == nil {
+		return found
+	}
+	content := file.Content
+	text := func(n *ts.Node) string { return n.Utf8Text(content) }
+	dir := path.Dir(file.Path)
+	goImports := goImportNames(file)
+
+	root := file.Tree.RootNode()
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		declaration := root.NamedChild(i)
+		if declaration.Kind() != "function_declaration" && declaration.Kind() != "method_declaration" {
+			continue
+		}
+		body := declaration.ChildByFieldName("body")
+		if body == nil {
+			continue
+		}
+		name := declaration.ChildByFieldName("name")
+		isInit := declaration.Kind() == "function_declaration" && name != nil && text(name) == "init"
+		locals := goLocalNames(declaration, content)
+
+		// The receiver of a method, whose fields are the shared state
+		receiverName, receiverType := "", ""
+		if receiver := declaration.ChildByFieldName("receiver"); receiver != nil {
+			receiverType = ReceiverTypeName(text(receiver))
+			if receiver.NamedChildCount() > 0 {
+				if n := receiver.NamedChild(0).ChildByFieldName("name"); n != nil {
+					receiverName = text(n)
+				}
+			}
+		}
+
+		walkTree(body, func(node *ts.Node) {
+			switch node.Kind() {
+			case "go_statement":
+				if launch, ok := goLaunched(node, content, goImports); ok {
+					launch.dir = dir
+					found.launches = append(found.launches, launch)
+				}
+				return
+			case "identifier":
+			case "selector_expression":
+				operand := node.ChildByFieldName("operand")
+				if receiverName == "" || operand == nil || operand.Kind() != "identifier" || text(operand) != receiverName {
+					return
+				}
+				field := node.ChildByFieldName("field")
+				if field == nil || isGoCallee(node) {
+					return
+				}
+				key := dir + "#" + receiverType + "." + text(field)
+				found.accesses = append(found.accesses, goAccess{file: file, node: node, key: key, init: isInit})
+				return
+			default:
+				return
+			}
+
+			// A package-level variable: an identifier not declared in the
+			// function, used as a value rather than as a field or label
+			name := text(node)
+			if _, imported := goImports[name]; imported || locals[name] || name == "_" || !goValueIdentifier(node) {
+				return
+			}
+			found.accesses = append(found.accesses, goAccess{file: file, node: node, key: dir + "#" + name, init: isInit})
+		})
+	}
+	return found
+}
+
+// goLaunched returns the function a go statement starts by name: go f(),
+// or go x.m() for a method of the package; false for function literals,
+// which are followed by their accesses, and functions of other packages
+func goLaunched(statement *ts.Node, content []byte, goImports map[string]string) (goLaunch, bool) {
+	if statement.NamedChildCount() == 0 {
+		return goLaunch{}, false
+	}
+	call := statement.NamedChild(0)
+	if call.Kind() != "call_expression" {
+		return goLaunch{}, false
+	}
+	function := call.ChildByFieldName("function")
+	switch function.Kind() {
+	case "identifier":
+		return goLaunch{name: function.Utf8Text(content)}, true
+	case "selector_expression":
+		operand, field := function.ChildByFieldName("operand"), function.ChildByFieldName("field")
+		if _, imported := goImports[operand.Utf8Text(content)]; imported || field == nil {
+			return goLaunch{}, false
+		}
+		return goLaunch{name: field.Utf8Text(content), method: true}, true
+	}
+	return goLaunch{}, false
+}
+
+// goLocalNames returns the names a function declares: its receiver,
+// parameters and results, and the variables and constants declared anywhere
+// in its body, including function literals. Names declared in a nested
+// scope only shadow there, so the set errs towards ignoring a use.
+func goLocalNames(function *ts.Node, content []byte) map[string]bool {
+	locals := make(map[string]bool)
+	addNames := func(node *ts.Node) {
+		if node == nil {
+			return
+		}
+		if node.Kind() == "identifier" {
+			locals[node.Utf8Text(content)] = true
+			return
+		}
+		for i := uint(0); i < node.NamedChildCount(); i++ {
+			if child := node.NamedChild(i); child.Kind() == "identifier" {
+				locals[child.Utf8Text(content)] = true
+			}
+		}
+	}
+	walkTree(function, func(node *ts.Node) {
+		switch node.Kind() {
+		case "parameter_declaration", "variadic_parameter_declaration", "var_spec", "const_spec":
+			for i := uint(0); i < node.ChildCount(); i++ {
+				if node.FieldNameForChild(uint32(i)) == "name" {
+					addNames(node.Child(i))
+				}
+			}
+		case "short_var_declaration":
+			addNames(node.ChildByFieldName("left"))
+		case "range_clause":
+			if strings.Contains(node.Utf8Text(content), ":=") {
+				addNames(node.ChildByFieldName("left"))
+			}
+		}
+	})
+	return locals
+}
+
+// goValueIdentifier reports whether an identifier is used as a value, rather
+// than being a label, a field name, a key of a struct literal, a package
+// qualifier or a declared name
+func goValueIdentifier(node *ts.Node) bool {
+	parent := node.Parent()
+	if parent == nil {
+		return false
+	}
+	switch parent.Kind() {
+	case "labeled_statement", "break_statement", "continue_statement", "goto_statement",
+		"parameter_declaration", "variadic_parameter_declaration", "var_spec", "const_spec":
+		return false
+	case "selector_expression":
+		// Only the operand is a value, and then only when it is not a package
+		return parent.ChildByFieldName("operand") != nil && parent.ChildByFieldName("operand").StartByte() == node.StartByte()
+	case "call_expression":
+		// A call of a function, not of a function-valued variable, is no access
+		return parent.ChildByFieldName("function") == nil || parent.ChildByFieldName("function").StartByte() != node.StartByte()
+	case "literal_element":
+		if keyed := parent.Parent(); keyed != nil && keyed.Kind() == "keyed_element" && keyed.NamedChild(0).StartByte() == parent.StartByte() {
+			return false
+		}
+	}
+	return true
+}
+
+// isGoCallee reports whether a selector is the function of a call, x.m() for
+// a method, rather than a field read
+func isGoCallee(selector *ts.Node) bool {
+	parent := selector.Parent()
+	return parent != nil && parent.Kind() == "call_expression" &&
+		parent.ChildByFieldName("function").StartByte() == selector.StartByte()
+}
+
+// isGoWrite reports whether an access writes: it is the target of an
+// assignment or of ++ or --, directly or through an index or field, or the
+// map passed to delete
+func isGoWrite(node *ts.Node, content []byte) bool {
+	target := node
+	for parent := target.Parent(); parent != nil; parent = target.Parent() {
+		switch parent.Kind() {
+		case "index_expression", "selector_expression":
+			if operand := parent.ChildByFieldName("operand"); operand == nil || operand.StartByte() != target.StartByte() {
+				return false
+			}
+		case "parenthesized_expression":
+		case "unary_expression":
+			if op := parent.ChildByFieldName("operator"); op == nil || op.Utf8Text(content) != "*" {
+				return false
+			}
+		case "expression_list":
+			statement := parent.Parent()
+			if statement == nil || statement.Kind() != "assignment_statement" {
+				return false
+			}
+			left := statement.ChildByFieldName("left")
+			return left != nil && left.StartByte() == parent.StartByte()
+		case "inc_statement", "dec_statement":
+			return true
+		case "argument_list":
+			call := parent.Parent()
+			function := call.ChildByFieldName("function")
+			return function != nil && function.Utf8Text(content) == "delete" && parent.NamedChild(0).StartByte() == target.StartByte()
+		default:
+			return false
+		}
+		target = parent
+	}
+	return false
+}
+
+// isGoLocked reports whether a Lock or RLock call comes before an access in
+// its function, or, outside goroutines, in a function enclosing it. An
+// access whose address is passed to a function of sync/atomic is treated as
+// locked.
+func isGoLocked(node *ts.Node, content []byte) bool {
+	if parent := node.Parent(); parent != nil && parent.Kind() == "unary_expression" {
+		if args := parent.Parent(); args != nil && args.Kind() == "argument_list" {
+			if function := args.Parent().ChildByFieldName("function"); function != nil &&
+				strings.HasPrefix(function.Utf8Text(content), "atomic.") {
+				return true
+			}
+		}
+	}
+	for scope := node.Parent(); scope != nil; scope = scope.Parent() {
+		kind := scope.Kind()
+		if kind != "func_literal" && kind != "function_declaration" && kind != "method_declaration" {
+			continue
+		}
+		locked := locksBefore(scope.ChildByFieldName("body"), node.StartByte(), content)
+		if locked || (kind == "func_literal" && startsGoroutine(scope, content)) {
+			return locked
+		}
+	}
+	return false
+}
+
+// locksBefore reports whether a Lock or RLock call starts before offset in
+// a function body, outside the function literals in it
+func locksBefore(body *ts.Node, offset uint, content []byte) bool {
+	if body == nil || body.StartByte() >= offset {
+		return false
+	}
+	for i := uint(0); i < body.NamedChildCount(); i++ {
+		child := body.NamedChild(i)
+		if child.StartByte() >= offset {
+			break
+		}
+		if child.Kind() == "func_literal" {
+			continue
+		}
+		if child.Kind() == "call_expression" {
+			if function := child.ChildByFieldName("function"); function != nil && function.Kind() == "selector_expression" {
+				switch function.ChildByFieldName("field").Utf8Text(content) {
+				case "Lock", "RLock":
+					return true
+				}
+			}
+		}
+		if locksBefore(child, offset, content) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGoConcurrent reports whether an access is inside a function literal run
+// as a goroutine
+func isGoConcurrent(node *ts.Node, content []byte) bool {
+	for scope := node.Parent(); scope != nil; scope = scope.Parent() {
+		if scope.Kind() == "func_literal" && startsGoroutine(scope, content) {
+			return true
+		}
+	}
+	return false
+}
+
+// startsGoroutine reports whether a function literal is started as a
+// goroutine: go func() {...}(), or passed to the Go method of an errgroup or
+// WaitGroup
+func startsGoroutine(literal *ts.Node, content []byte) bool {
+	parent := literal.Parent()
+	if parent == nil {
+		return false
+	}
+	if parent.Kind() == "call_expression" {
+		statement := parent.Parent()
+		return statement != nil && statement.Kind() == "go_statement"
+	}
+	if parent.Kind() == "argument_list" {
+		function := parent.Parent().ChildByFieldName("function")
+		return function != nil && function.Kind() == "selector_expression" &&
+			function.ChildByFieldName("field").Utf8Text(content) == "Go"
+	}
+	return false
+}
+
+// checkRaceRisks matches the accesses collected from every Go file to the
+// package-level variables and struct fields they use, recording READS and
+// WRITES relationships from the accessing function, and records a RaceRisk
+// entity for each variable or field accessed without a lock from code run
+// as a goroutine while it is also written without a lock. Code runs as a
+// goroutine when it is in a function literal started with go, or in a
+// function or method of the package started by name. Variables and fields
+// of sync and atomic types and channels are never at risk, and neither are
+// accesses in init functions.
+func (gb *GraphBuilder) checkRaceRisks() {
+	// Shared state by package directory and name, or receiver type and field
+	state := make(map[string]*entities.Entity)
+	for _, entity := range gb.allEntities {
+		file := gb.files[entity.FilePath]
+		if file == nil || file.Language != "go" {
+			continue
+		}
+		typ, _ := entity.GetProperty("type").(string)
+		if raceSafeType(typ) {
+			continue
+		}
+		switch entity.Type {
+		case entities.EntityTypeVariable:
+			kind, _ := entity.GetProperty("kind").(string)
+			if kind != "var" || entity.Parent != nil || (entity.Node != nil && hasAncestor(entity.Node, "function_declaration", "method_declaration", "func_literal")) {
+				continue
+			}
+			if value, _ := entity.GetProperty("value").(string); strings.HasPrefix(value, "make(chan") {
+				continue
+			}
+			state[path.Dir(entity.FilePath)+"#"+entity.Name] = entity
+		case entities.EntityTypeProperty:
+			if entity.Parent != nil && entity.Parent.Type == entities.EntityTypeStruct {
+				state[path.Dir(entity.FilePath)+"#"+entity.Parent.Name+"."+entity.Name] = entity
+			}
+		}
+	}
+
+	// Functions and methods started by name, by package directory and name
+	launched := make(map[string]bool)
+	for _, launch := range gb.goLaunches {
+		launched[launch.dir+"#"+launch.name+fmt.Sprint(launch.method)] = true
+	}
+	runsAsGoroutine := func(function *entities.Entity) bool {
+		if function == nil {
+			return false
+		}
+		_, method := function.GetProperty("receiver").(string)
+		return launched[path.Dir(function.FilePath)+"#"+function.Name+fmt.Sprint(method)]
+	}
+
+	// The functions and methods of each file, for finding where accesses are
+	functions := make(map[*entities.File][]*entities.Entity)
+	functionsOf := func(file *entities.File) []*entities.Entity {
+		if list, ok := functions[file]; ok {
+			return list
+		}
+		list := make([]*entities.Entity, 0)
+		for _, entity := range file.GetAllEntities() {
+			if entity.Type == entities.EntityTypeFunction || entity.Type == entities.EntityTypeMethod {
+				list = append(list, entity)
+			}
+		}
+		functions[file] = list
+		return list
+	}
+
+	byState := make(map[*entities.Entity][]goAccess)
+	seen := make(map[string]bool)
+	for _, access := range gb.goAccesses {
+		target := state[access.key]
+		if target == nil {
+			continue
+		}
+		access.function = innermostEntity(functionsOf(access.file), access.node)
+		ignored := false
+		for e := access.function; e != nil; e = e.Parent {
+			ignored = ignored || e.IsIgnored()
+		}
+		if ignored {
+			continue
+		}
+		content := access.file.Content
+		access.write = isGoWrite(access.node, content)
+		access.locked = isGoLocked(access.node, content)
+		access.concurrent = isGoConcurrent(access.node, content) || runsAsGoroutine(access.function)
+		byState[target] = append(byState[target], access)
+
+		// READS and WRITES from the function, once per kind
+		if access.function == nil {
+			continue
+		}
+		relType := entities.RelationshipTypeReads
+		if access.write {
+			relType = entities.RelationshipTypeWrites
+		}
+		relKey := fmt.Sprintf("%s:%s:%s", relType, access.function.ID, target.ID)
+		if seen[relKey] {
+			continue
+		}
+		seen[relKey] = true
+		hash := sha256.Sum256([]byte(strings.ToLower(string(relType)) + ":" + access.function.ID + ":" + target.ID))
+		rel := entities.NewRelationship(hex.EncodeToString(hash[:8]), relType, access.function, target)
+		rel.SetProperty("locked", access.locked)
+		rel.SetProperty("concurrent", access.concurrent)
+		rel.SetProvenance(access.file.Path, access.node, access.file.Content)
+		gb.unresolvedRelationships = append(gb.unresolvedRelationships, rel)
+	}
+
+	for target, accesses := range byState {
+		var concurrent []goAccess
+		writes := 0
+		for _, access := range accesses {
+			if access.locked || access.init {
+				continue
+			}
+			if access.concurrent {
+				concurrent = append(concurrent, access)
+			}
+			if access.write {
+				writes++
+			}
+		}
+		if len(concurrent) == 0 || writes == 0 {
+			continue
+		}
+		sort.SliceStable(concurrent, func(i, j int) bool {
+			if concurrent[i].file.Path != concurrent[j].file.Path {
+				return concurrent[i].file.Path < concurrent[j].file.Path
+			}
+			return concurrent[i].node.StartByte() < concurrent[j].node.StartByte()
+		})
+		risk := newRaceRisk(target, concurrent[0], len(concurrent), writes)
+		concurrent[0].file.AddEntity(risk)
+		gb.allEntities[risk.ID] = risk
+		gb.stats.EntitiesFound++
+	}
+	gb.goAccesses, gb.goLaunches = nil, nil
+}
+
+// newRaceRisk builds the RaceRisk entity of a variable or field, located at
+// the first access without a lock from code run as a goroutine
+func newRaceRisk(target *entities.Entity, first goAccess, accesses, writes int) *entities.Entity {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("race_risk:%s", target.ID)))
+	name := target.Name
+	kind := "variable"
+	if target.Type == entities.EntityTypeProperty {
+		name = target.Parent.Name + "." + target.Name
+		kind = "field"
+	}
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), name, entities.EntityTypeRaceRisk, first.file.Path, first.node)
+
+	goroutine := "a goroutine"
+	if first.function != nil {
+		goroutine = first.function.GetFullName()
+		entity.SetProperty("enclosing_function", first.function.ID)
+		entity.SetProperty("enclosing_function_name", goroutine)
+	}
+	entity.SetProperty("state", kind)
+	entity.SetProperty("variable", target.ID)
+	entity.SetProperty("goroutine", goroutine)
+	entity.SetProperty("accesses", accesses)
+	entity.SetProperty("writes", writes)
+	entity.SetProperty("line", int(first.node.StartPosition().Row)+1)
+	places := "1 place"
+	if writes != 1 {
+		places = fmt.Sprintf("%d places", writes)
+	}
+	entity.SetProperty("message", fmt.Sprintf("%s %s is accessed without a lock from code run as a goroutine in %s and written without a lock in %s",
+		kind, name, goroutine, places))
+	return entity
+}
@@ -0,0 +1,216 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// referenceSources are the declarations whose spans are searched for the
+// names they use
+var referenceSources = map[entities.EntityType]bool{
+	entities.EntityTypeFunction:     true,
+	entities.EntityTypeMethod:       true,
+	entities.EntityTypeTestFunction: true,
+	entities.EntityTypeStruct:       true,
+	entities.EntityTypeInterface:    true,
+	entities.EntityTypeClass:        true,
+	entities.EntityTypeType:         true,
+	entities.EntityTypeEnum:         true,
+	entities.EntityTypeVariable:     true,
+}
+
+// referenceUseKinds are the node kinds whose name field uses a name rather
+// than declaring one, as in pkg.Name or Box<T>
+var referenceUseKinds = map[string]bool{
+	"qualified_type":         true,
+	"generic_type":           true,
+	"nested_type_identifier": true,
+}
+
+// referenceParameterKinds hold parameter names, which are local to a function
+var referenceParameterKinds = map[string]bool{
+	"parameters":               true,
+	"lambda_parameters":        true,
+	"typed_parameter":          true,
+	"list_splat_pattern":       true,
+	"dictionary_splat_pattern": true,
+}
+
+// referenceDeclaringKinds are the statements whose left side declares local
+// names rather than assigning to existing ones: Go :=, Python assignments and
+// loop variables. Go and TypeScript assignments to existing names are kept.
+var referenceDeclaringKinds = map[string]bool{
+	"short_var_declaration": true,
+	"range_clause":          true,
+	"assignment":            true,
+	"for_statement":         true,
+	"for_in_statement":      true,
+}
+
+// goPredeclaredValues are the predeclared Go constants and functions, which
+// like goPredeclaredTypes never resolve to a declaration of the repository
+var goPredeclaredValues = map[string]bool{
+	"true": true, "false": true, "iota": true, "nil": true,
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true,
+	"delete": true, "imag": true, "len": true, "make": true, "max": true, "min": true,
+	"new": true, "panic": true, "print": true, "println": true, "real": true, "recover": true,
+}
+
+// nameUse is an identifier using a name, with the Go package qualifying it
+type nameUse struct {
+	node       *ts.Node
+	name       string
+	importPath string
+}
+
+// detectReferences records a REFERENCES relationship from each declaration to
+// every name it uses: types in signatures, fields, conversions and composite
+// literals, and variables and constants it reads or writes. Targets are names
+// here and are resolved to declarations in phase 2, see resolveReference.
+// Identifiers that declare a name, such as parameters and the left side of :=,
+// are skipped, and a declaration using a name several times gets one
+// relationship.
+func detectReferences(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+
+	sources := make([]*entities.Entity, 0)
+	for _, entity := range file.GetAllEntities() {
+		if referenceSources[entity.Type] && entity.Node != nil {
+			sources = append(sources, entity)
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	uses, imported := collectNameUses(file)
+
+	relationships := make([]*entities.Relationship, 0)
+	seen := make(map[string]bool)
+	for _, use := range uses {
+		source := innermostEntity(sources, use.node)
+		if source == nil {
+			continue
+		}
+		key := source.ID + ":" + use.importPath + "." + use.name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		targetType := entities.EntityTypeVariable
+		if strings.HasSuffix(use.node.Kind(), "type_identifier") {
+			targetType = entities.EntityTypeType
+		}
+		hash := sha256.Sum256([]byte(fmt.Sprintf("references:%s:%s", source.ID, key)))
+		rel := entities.NewRelationshipByID(hex.EncodeToString(hash[:8]), entities.RelationshipTypeReferences,
+			source.ID, use.name, source.Type, targetType)
+		if use.importPath != "" {
+			rel.SetProperty("import_path", use.importPath)
+		}
+		if imported[use.name] {
+			rel.SetProperty("imported", true)
+		}
+		rel.SetProvenance(file.Path, use.node, file.Content)
+		relationships = append(relationships, rel)
+	}
+	return relationships
+}
+
+// goImportNames maps the package names a Go file uses to the import paths
+// they refer to; for other files it is empty
+func goImportNames(file *entities.File) map[string]string {
+	goImports := make(map[string]string)
+	if file.Language != "go" {
+		return goImports
+	}
+	for _, imp := range file.Imports {
+		importPath, _ := imp.GetProperty("path").(string)
+		alias, _ := imp.GetProperty("alias").(string)
+		if alias == "" {
+			alias = path.Base(importPath)
+		}
+		goImports[alias] = importPath
+	}
+	return goImports
+}
+
+// collectNameUses returns the identifiers of a file that use a name, and the
+// names its import statements bring in. Go names qualified by an imported
+// package carry the import path, and attributes of an imported Python module
+// count as imported names; other selectors and member accesses are left out,
+// as their target depends on the type of the operand.
+func collectNameUses(file *entities.File) ([]nameUse, map[string]bool) {
+	goImports := goImportNames(file)
+	uses := make([]nameUse, 0)
+	imported := make(map[string]bool)
+	text := func(node *ts.Node) string { return node.Utf8Text(file.Content) }
+
+	var visit func(node *ts.Node, field string, inImport bool)
+	visit = func(node *ts.Node, field string, inImport bool) {
+		kind := node.Kind()
+		if strings.Contains(kind, "import") {
+			inImport = true
+		}
+
+		if node.ChildCount() > 0 {
+			for i := uint(0); i < node.ChildCount(); i++ {
+				childField := node.FieldNameForChild(uint32(i))
+				if childField == "left" && referenceDeclaringKinds[kind] {
+					continue
+				}
+				visit(node.Child(i), childField, inImport)
+			}
+			return
+		}
+
+		parent := node.Parent()
+		if parent == nil {
+			return
+		}
+		name := text(node)
+		switch {
+		case inImport:
+			if strings.HasSuffix(kind, "identifier") {
+				imported[name] = true
+			}
+		case kind == "field_identifier":
+			// pkg.Name: a package-level declaration of an imported Go package
+			operand := parent.ChildByFieldName("operand")
+			if parent.Kind() == "selector_expression" && field == "field" && operand != nil && operand.Kind() == "identifier" {
+				if importPath, ok := goImports[text(operand)]; ok {
+					uses = append(uses, nameUse{node: node, name: name, importPath: importPath})
+				}
+			}
+		case kind != "identifier" && kind != "type_identifier":
+		case field == "attribute":
+			// module.Name: a declaration of an imported Python module
+			object := parent.ChildByFieldName("object")
+			if object != nil && object.Kind() == "identifier" && imported[text(object)] {
+				imported[name] = true
+				uses = append(uses, nameUse{node: node, name: name})
+			}
+		case field == "name" && !referenceUseKinds[parent.Kind()]:
+		case field == "pattern" || referenceParameterKinds[parent.Kind()]:
+		case file.Language == "go" && (goPredeclaredTypes[name] || goPredeclaredValues[name]):
+		case file.Language == "go" && field == "operand" && parent.Kind() == "selector_expression" && goImports[name] != "":
+		case parent.Kind() == "qualified_type":
+			if importPath, ok := goImports[text(parent.ChildByFieldName("package"))]; ok {
+				uses = append(uses, nameUse{node: node, name: name, importPath: importPath})
+			}
+		default:
+			uses = append(uses, nameUse{node: node, name: name})
+		}
+	}
+	visit(file.Tree.RootNode(), "", false)
+	return uses, imported
+}
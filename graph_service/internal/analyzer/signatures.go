@@ -0,0 +1,153 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Function and method entities carry their parameter and result types as
+// ordered string slices so that callers can search by shape rather than name:
+//   - parameter_types: one entry per parameter, "" when the parameter is untyped
+//   - result_types: one entry per result; absent when no return type is declared
+//
+// Receivers (Go) and self/cls (Python) are not parameters. Variadic parameters
+// are recorded with a "..." prefix, e.g. "...string".
+
+// setSignatureTypes records the parameter and result types of a function entity
+func setSignatureTypes(entity *entities.Entity, params, results []string) {
+	if params == nil {
+		params = []string{}
+	}
+	entity.SetProperty("parameter_types", params)
+	if len(results) > 0 {
+		entity.SetProperty("result_types", results)
+	}
+}
+
+// goSignatureTypes reads the parameter and result types of a Go function or
+// method. Grouped parameters such as (a, b int) yield one entry per name.
+func goSignatureTypes(paramsNode, resultNode *ts.Node, content []byte) (params, results []string) {
+	params = goParameterListTypes(paramsNode, content)
+	if resultNode != nil {
+		if resultNode.Kind() == "parameter_list" {
+			results = goParameterListTypes(resultNode, content)
+		} else {
+			results = []string{resultNode.Utf8Text(content)}
+		}
+	}
+	return params, results
+}
+
+// goParameterListTypes expands a Go parameter_list into per-parameter types
+func goParameterListTypes(listNode *ts.Node, content []byte) []string {
+	types := make([]string, 0)
+	if listNode == nil {
+		return types
+	}
+
+	for i := uint(0); i < listNode.NamedChildCount(); i++ {
+		decl := listNode.NamedChild(i)
+		typeNode := decl.ChildByFieldName("type")
+		if typeNode == nil {
+			continue
+		}
+		typeText := typeNode.Utf8Text(content)
+
+		switch decl.Kind() {
+		case "variadic_parameter_declaration":
+			types = append(types, "..."+typeText)
+		case "parameter_declaration":
+			names := 0
+			for j := uint(0); j < decl.NamedChildCount(); j++ {
+				if decl.FieldNameForNamedChild(uint32(j)) == "name" {
+					names++
+				}
+			}
+			if names == 0 {
+				names = 1
+			}
+			for ; names > 0; names-- {
+				types = append(types, typeText)
+			}
+		}
+	}
+	return types
+}
+
+// pythonSignatureTypes reads the annotated parameter and return types of a
+// Python function. self and cls are skipped, as are *args and **kwargs.
+func pythonSignatureTypes(paramsNode, returnNode *ts.Node, content []byte) (params, results []string) {
+	params = make([]string, 0)
+	if paramsNode != nil {
+		for i := uint(0); i < paramsNode.NamedChildCount(); i++ {
+			param := paramsNode.NamedChild(i)
+
+			var nameNode, typeNode *ts.Node
+			switch param.Kind() {
+			case "identifier":
+				nameNode = param
+			case "default_parameter", "typed_default_parameter":
+				nameNode = param.ChildByFieldName("name")
+				typeNode = param.ChildByFieldName("type")
+			case "typed_parameter":
+				nameNode = param.NamedChild(0)
+				typeNode = param.ChildByFieldName("type")
+			default:
+				continue
+			}
+			if nameNode == nil || nameNode.Kind() != "identifier" {
+				continue
+			}
+			if name := nameNode.Utf8Text(content); len(params) == 0 && (name == "self" || name == "cls") {
+				continue
+			}
+
+			typeText := ""
+			if typeNode != nil {
+				typeText = typeNode.Utf8Text(content)
+			}
+			params = append(params, typeText)
+		}
+	}
+
+	if returnNode != nil {
+		results = []string{returnNode.Utf8Text(content)}
+	}
+	return params, results
+}
+
+// tsSignatureTypes reads the parameter and return type annotations of a
+// TypeScript function or method
+func tsSignatureTypes(paramsNode, returnNode *ts.Node, content []byte) (params, results []string) {
+	params = make([]string, 0)
+	if paramsNode != nil {
+		for i := uint(0); i < paramsNode.NamedChildCount(); i++ {
+			param := paramsNode.NamedChild(i)
+			if param.Kind() != "required_parameter" && param.Kind() != "optional_parameter" {
+				continue
+			}
+
+			typeText := ""
+			if typeNode := param.ChildByFieldName("type"); typeNode != nil {
+				typeText = tsTypeAnnotationText(typeNode, content)
+			}
+			if pattern := param.ChildByFieldName("pattern"); pattern != nil && pattern.Kind() == "rest_pattern" {
+				typeText = "..." + strings.TrimSuffix(typeText, "[]")
+			}
+			params = append(params, typeText)
+		}
+	}
+
+	if returnNode != nil {
+		results = []string{tsTypeAnnotationText(returnNode, content)}
+	}
+	return params, results
+}
+
+// tsTypeAnnotationText returns the type of a type_annotation node without its leading colon
+func tsTypeAnnotationText(node *ts.Node, content []byte) string {
+	return strings.TrimSpace(strings.TrimPrefix(node.Utf8Text(content), ":"))
+}
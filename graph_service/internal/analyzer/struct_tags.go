@@ -0,0 +1,214 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of StructTagIssue
+const (
+	StructTagDuplicateJSONName = "duplicate_json_name"     // Two fields serialize to the same JSON name
+	StructTagUnexportedTagged  = "unexported_field_tagged" // Unexported field has a json tag, which has no effect
+	StructTagMissingJSONTag    = "missing_json_tag"        // Exported field has no json tag although others do
+	StructTagMalformed         = "malformed_tag"           // Tag is not a list of key:"value" pairs
+)
+
+// detectStructTagIssues records StructTagIssue entities for the fields of Go
+// structs whose tags break or risk breaking JSON round-trips, in ways
+// encoding/json does not report:
+//
+//   - two exported fields serialize to the same JSON name, so encoding/json
+//     silently drops them, or all but the only tagged one
+//   - an unexported field has a json tag, which has no effect
+//   - an exported field has no json tag although other fields of the struct
+//     do, so it serializes under its Go name
+//   - a tag is not a list of key:"value" pairs and is ignored entirely
+//
+// Embedded fields are promoted rather than serialized by name and are only
+// checked for malformed tags. Each issue is linked to its field by a
+// HAS_ISSUE relationship. Structs and fields marked with an onyx:ignore
+// comment are skipped.
+func detectStructTagIssues(file *entities.File) []*entities.Relationship {
+	if file.Language != "go" {
+		return nil
+	}
+
+	relationships := make([]*entities.Relationship, 0)
+	for _, structEntity := range file.GetAllEntities() {
+		if structEntity.Type != entities.EntityTypeStruct || structEntity.IsIgnored() {
+			continue
+		}
+		for _, issue := range structTagIssues(structEntity) {
+			file.AddEntity(issue.entity)
+			rel := entities.NewRelationship(issue.entity.ID+":has_issue", entities.RelationshipTypeHasIssue, issue.field, issue.entity)
+			rel.SetProperty("issue", issue.kind)
+			rel.SetProvenance(file.Path, issue.field.Node, file.Content)
+			relationships = append(relationships, rel)
+		}
+	}
+	return relationships
+}
+
+// structTagIssue is an issue found on a field, before it is linked to it
+type structTagIssue struct {
+	kind   string
+	field  *entities.Entity
+	entity *entities.Entity
+}
+
+// structTagIssues checks the fields of one struct
+func structTagIssues(structEntity *entities.Entity) []structTagIssue {
+	fields := make([]*entities.Entity, 0, len(structEntity.Children))
+	serialized := false
+	for _, child := range structEntity.Children {
+		if child.Type != entities.EntityTypeProperty || child.IsIgnored() {
+			continue
+		}
+		fields = append(fields, child)
+		if _, ok := reflect.StructTag(fieldTag(child)).Lookup("json"); ok {
+			serialized = true
+		}
+	}
+
+	issues := make([]structTagIssue, 0)
+	report := func(kind string, field *entities.Entity, message string) {
+		issues = append(issues, structTagIssue{kind, field, newStructTagIssue(structEntity, field, kind, message)})
+	}
+
+	byJSONName := make(map[string][]*entities.Entity)
+	names := make([]string, 0)
+	for _, field := range fields {
+		tag := fieldTag(field)
+		if err := validateStructTag(tag); err != nil {
+			report(StructTagMalformed, field, fmt.Sprintf("malformed struct tag: %v", err))
+			continue
+		}
+		if embedded, _ := field.GetProperty("embedded").(bool); embedded {
+			continue
+		}
+
+		jsonTag, tagged := reflect.StructTag(tag).Lookup("json")
+		name := strings.Split(jsonTag, ",")[0]
+		exported := startsUpper(field.Name)
+		switch {
+		case !exported && tagged && name != "-":
+			report(StructTagUnexportedTagged, field, fmt.Sprintf("json tag on unexported field %s has no effect; encoding/json ignores unexported fields", field.Name))
+			continue
+		case !exported || name == "-":
+			continue
+		case !tagged && serialized:
+			report(StructTagMissingJSONTag, field, fmt.Sprintf("exported field %s has no json tag and serializes as %q", field.Name, field.Name))
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if byJSONName[name] == nil {
+			names = append(names, name)
+		}
+		byJSONName[name] = append(byJSONName[name], field)
+	}
+
+	for _, name := range names {
+		duplicates := byJSONName[name]
+		if len(duplicates) < 2 {
+			continue
+		}
+		described := make([]string, len(duplicates))
+		var tagged []*entities.Entity
+		for i, field := range duplicates {
+			if jsonTag, ok := reflect.StructTag(fieldTag(field)).Lookup("json"); ok {
+				described[i] = fmt.Sprintf("%s (json:%q)", field.Name, jsonTag)
+				tagged = append(tagged, field)
+			} else {
+				described[i] = fmt.Sprintf("%s (untagged)", field.Name)
+			}
+		}
+		// encoding/json keeps a field over untagged ones of the same name
+		// when it is the only tagged one, and otherwise drops them all
+		outcome := "encoding/json drops all of them"
+		if len(tagged) == 1 {
+			outcome = fmt.Sprintf("encoding/json keeps %s and drops the others", tagged[0].Name)
+		}
+		for _, field := range duplicates {
+			report(StructTagDuplicateJSONName, field, fmt.Sprintf("JSON name %q is used by fields %s; %s", name, strings.Join(described, ", "), outcome))
+		}
+	}
+	return issues
+}
+
+// newStructTagIssue builds the StructTagIssue entity of a field
+func newStructTagIssue(structEntity, field *entities.Entity, kind, message string) *entities.Entity {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("struct_tag_issue:%s:%s", field.ID, kind)))
+	entity := entities.NewEntity(hex.EncodeToString(hash[:8]), structEntity.Name+"."+field.Name,
+		entities.EntityTypeStructTagIssue, field.FilePath, field.Node)
+	entity.SetProperty("kind", kind)
+	entity.SetProperty("message", message)
+	entity.SetProperty("struct", structEntity.Name)
+	entity.SetProperty("field", field.Name)
+	entity.SetProperty("field_id", field.ID)
+	entity.SetProperty("tag", fieldTag(field))
+	entity.SetProperty("line", field.StartLine())
+	return entity
+}
+
+// fieldTag returns the tag of a field without its backquotes or double quotes
+func fieldTag(field *entities.Entity) string {
+	tag, _ := field.GetProperty("tag").(string)
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		return unquoted
+	}
+	return tag
+}
+
+// validateStructTag checks that a tag follows the conventional
+// key:"value" key:"value" format, as go vet's structtag check does
+func validateStructTag(tag string) error {
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 {
+			return fmt.Errorf("expected a key at %q", tag)
+		}
+		key := tag[:i]
+		if i+1 >= len(tag) || tag[i] != ':' {
+			return fmt.Errorf("key %q is not followed by :", key)
+		}
+		if tag[i+1] != '"' {
+			return fmt.Errorf("value of %q is not quoted", key)
+		}
+		tag = tag[i+1:]
+
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return fmt.Errorf("value of %q is not terminated", key)
+		}
+		if _, err := strconv.Unquote(tag[:i+1]); err != nil {
+			return fmt.Errorf("value of %q is not a valid string", key)
+		}
+		tag = tag[i+1:]
+		if tag != "" && tag[0] != ' ' {
+			return fmt.Errorf("missing space after the value of %q", key)
+		}
+	}
+	return nil
+}
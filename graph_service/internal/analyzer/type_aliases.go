@@ -0,0 +1,153 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
+
+// aliasCompositions maps the node kinds that combine several types to the
+// "alias_composition" of an alias made of them
+var aliasCompositions = map[string]string{
+	"union_type":        "union",
+	"intersection_type": "intersection",
+}
+
+// detectTypeAliases links each type alias to the types it is made of with
+// ALIASES relationships: TypeScript type aliases, and Go alias (type X = Y)
+// and defined (type X Y) types other than structs and interfaces. The members
+// of union and intersection types are linked one by one. Each alias gets
+// "alias_members", the members as written, and "alias_composition", "union",
+// "intersection" or "single"; Go types also carry "alias", which the Go
+// analyzer sets to tell aliases from defined types. Targets are names here,
+// resolved like references in phase 2; members that are not names, such as
+// string, literal types or object types, have no relationship.
+func detectTypeAliases(file *entities.File) []*entities.Relationship {
+	if file.Tree == nil {
+		return nil
+	}
+
+	var goImports map[string]string
+	var imported map[string]bool
+	relationships := make([]*entities.Relationship, 0)
+	for _, alias := range file.GetAllEntities() {
+		value := aliasValue(file, alias)
+		if value == nil {
+			continue
+		}
+		if goImports == nil {
+			goImports = goImportNames(file)
+			_, imported = collectNameUses(file)
+		}
+
+		composition := "single"
+		for value.Kind() == "parenthesized_type" && value.NamedChildCount() == 1 {
+			value = value.NamedChild(0)
+		}
+		if c, ok := aliasCompositions[value.Kind()]; ok {
+			composition = c
+		}
+
+		members := aliasMembers(value)
+		texts := make([]string, len(members))
+		for i, member := range members {
+			texts[i] = member.Utf8Text(file.Content)
+			name, importPath := aliasMemberName(file, member, goImports)
+			if name == "" {
+				continue
+			}
+
+			hash := sha256.Sum256([]byte(fmt.Sprintf("aliases:%s:%d", alias.ID, i)))
+			rel := entities.NewRelationshipByID(hex.EncodeToString(hash[:8]), entities.RelationshipTypeAliases,
+				alias.ID, name, alias.Type, entities.EntityTypeType)
+			rel.SetProperty("member", texts[i])
+			rel.SetProperty("composition", composition)
+			rel.SetProperty("position", i)
+			if importPath != "" {
+				rel.SetProperty("import_path", importPath)
+			}
+			if imported[name] {
+				rel.SetProperty("imported", true)
+			}
+			rel.SetProvenance(file.Path, member, file.Content)
+			relationships = append(relationships, rel)
+		}
+		alias.SetProperty("alias_members", texts)
+		alias.SetProperty("alias_composition", composition)
+	}
+	return relationships
+}
+
+// aliasValue returns the type an alias stands for, or nil when entity is
+// not an alias: a Go struct or interface declares a type of its own
+func aliasValue(file *entities.File, entity *entities.Entity) *ts.Node {
+	if entity.Node == nil {
+		return nil
+	}
+	switch entity.Node.Kind() {
+	case "type_alias_declaration":
+		return entity.Node.ChildByFieldName("value")
+	case "type_spec", "type_alias":
+		if file.Language != "go" {
+			return nil
+		}
+		value := entity.Node.ChildByFieldName("type")
+		if value == nil || value.Kind() == "struct_type" || value.Kind() == "interface_type" {
+			return nil
+		}
+		return value
+	}
+	return nil
+}
+
+// aliasMembers flattens the unions, intersections and parentheses of a type
+// into the types they combine
+func aliasMembers(node *ts.Node) []*ts.Node {
+	switch node.Kind() {
+	case "union_type", "intersection_type", "parenthesized_type":
+		members := make([]*ts.Node, 0)
+		for i := uint(0); i < node.NamedChildCount(); i++ {
+			members = append(members, aliasMembers(node.NamedChild(i))...)
+		}
+		return members
+	}
+	return []*ts.Node{node}
+}
+
+// aliasMemberName returns the declaration a member of an alias names, with
+// the import path of the Go package qualifying it, or "" for members that
+// name no declaration of the repository: predeclared types, literal, object,
+// function and array types
+func aliasMemberName(file *entities.File, member *ts.Node, goImports map[string]string) (string, string) {
+	text := func(node *ts.Node) string { return node.Utf8Text(file.Content) }
+	switch member.Kind() {
+	case "type_identifier":
+		if file.Language == "go" && goPredeclaredTypes[text(member)] {
+			return "", ""
+		}
+		return text(member), ""
+	case "nested_type_identifier":
+		if name := member.ChildByFieldName("name"); name != nil {
+			return text(name), ""
+		}
+	case "qualified_type":
+		pkg, name := member.ChildByFieldName("package"), member.ChildByFieldName("name")
+		if pkg != nil && name != nil && goImports[text(pkg)] != "" {
+			return text(name), goImports[text(pkg)]
+		}
+	case "generic_type":
+		// Box<T> and Box[T] name Box
+		base := member.ChildByFieldName("name")
+		if base == nil {
+			base = member.ChildByFieldName("type")
+		}
+		if base != nil {
+			return aliasMemberName(file, base, goImports)
+		}
+	}
+	return "", ""
+}
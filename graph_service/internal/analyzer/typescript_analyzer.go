@@ -20,6 +20,9 @@ type TypeScriptAnalyzer struct {
 	currentFile   *entities.File
 	relationships []*entities.Relationship
 
+	// flagPatterns are extra function-name patterns that evaluate feature flags
+	flagPatterns []string
+
 	// Phase 2: Advanced tracking
 	generics   map[string]*TypeScriptGenericInfo
 	decorators map[string]*TypeScriptDecoratorInfo
@@ -32,6 +35,7 @@ type TypeScriptAnalyzer struct {
 	apiCalls   map[string]*TypeScriptAPICallInfo
 	models     map[string]*TypeScriptModelInfo
 	middleware map[string]*TypeScriptMiddlewareInfo
+	apiURLs    *apiURLScope // constants and HTTP clients of the current file
 
 	// Test Coverage tracking
 	testFramework    string
@@ -110,7 +114,9 @@ type TypeScriptRouteInfo struct {
 
 // TypeScriptAPICallInfo represents API call information
 type TypeScriptAPICallInfo struct {
-	URL        string   // API URL or pattern
+	URL        string   // API URL, with unresolved parts as path parameters
+	Pattern    string   // URL path as endpoints declare it, e.g. /users/:id
+	BaseURL    string   // Base URL of the HTTP client making the call
 	Method     string   // HTTP method
 	CallSite   string   // Where the call is made
 	Parameters []string // Call parameters
@@ -272,6 +278,7 @@ func (ta *TypeScriptAnalyzer) AnalyzeFile(filePath string, content []byte) (*ent
 	file := entities.NewFile(filePath, "typescript", tree, content)
 	ta.currentFile = file
 	ta.relationships = make([]*entities.Relationship, 0)
+	ta.apiURLs = nil
 
 	// Extract entities from the parse tree
 	rootNode := tree.RootNode()
@@ -379,6 +386,14 @@ func (ta *TypeScriptAnalyzer) extractEntities(node *ts.Node, parent *entities.En
 			ta.currentFile.AddEntity(entity)
 		}
 
+	case "lexical_declaration":
+		// Module-level const and let; block-scoped locals are not extracted
+		if p := node.Parent(); p != nil && (p.Kind() == "program" || p.Kind() == "export_statement") {
+			for _, entity := range ta.extractVariables(node, parent) {
+				ta.currentFile.AddEntity(entity)
+			}
+		}
+
 	// Phase 2: Advanced TypeScript features
 	case "decorator":
 		entity := ta.extractDecorator(node, parent)
@@ -487,6 +502,8 @@ func (ta *TypeScriptAnalyzer) extractFunction(node *ts.Node, parent *entities.En
 		entity.SetProperty("return_type", ta.getNodeText(returnTypeNode))
 		entity.AddSymbol("return_type", returnTypeNode)
 	}
+	params, results := tsSignatureTypes(parametersNode, returnTypeNode, ta.currentFile.Content)
+	setSignatureTypes(entity, params, results)
 
 	// Extract body
 	bodyNode := node.ChildByFieldName("body")
@@ -534,6 +551,8 @@ func (ta *TypeScriptAnalyzer) extractMethod(node *ts.Node, parent *entities.Enti
 	if returnTypeNode != nil {
 		entity.SetProperty("return_type", ta.getNodeText(returnTypeNode))
 	}
+	params, results := tsSignatureTypes(parametersNode, returnTypeNode, ta.currentFile.Content)
+	setSignatureTypes(entity, params, results)
 
 	// Extract body
 	bodyNode := node.ChildByFieldName("body")
@@ -638,6 +657,7 @@ func (ta *TypeScriptAnalyzer) extractTypeAlias(node *ts.Node, parent *entities.E
 	id := ta.generateEntityID("type", name, node)
 
 	entity := entities.NewEntity(id, name, entities.EntityTypeType, ta.currentFile.Path, node)
+	entity.SetProperty("alias", true)
 
 	// Extract type parameters (generics)
 	typeParamsNode := node.ChildByFieldName("type_parameters")
@@ -843,7 +863,7 @@ func (ta *TypeScriptAnalyzer) extractHeritageClause(heritageNode *ts.Node, entit
 func (ta *TypeScriptAnalyzer) extractClassMembers(bodyNode *ts.Node, classEntity *entities.Entity) {
 	ta.walkNode(bodyNode, func(n *ts.Node) {
 		switch n.Kind() {
-		case "property_definition":
+		case "property_definition", "public_field_definition":
 			if nameNode := n.ChildByFieldName("name"); nameNode != nil {
 				propName := ta.getNodeText(nameNode)
 				id := ta.generateEntityID("property", propName, n)
@@ -854,8 +874,12 @@ func (ta *TypeScriptAnalyzer) extractClassMembers(bodyNode *ts.Node, classEntity
 					prop.SetProperty("type", ta.getNodeText(typeNode))
 				}
 
+				jsonName, defaultValue, hasDefault, required := tsMemberMetadata(propName, n, ta.currentFile.Content)
+				setFieldMetadata(prop, jsonName, required, defaultValue, hasDefault)
+
 				ta.currentFile.AddEntity(prop)
 				classEntity.AddChild(prop)
+				ta.relationships = append(ta.relationships, newFieldRelationship(ta.currentFile, classEntity, prop))
 			}
 		}
 	})
@@ -876,8 +900,12 @@ func (ta *TypeScriptAnalyzer) extractInterfaceMembers(bodyNode *ts.Node, interfa
 					prop.SetProperty("type", ta.getNodeText(typeNode))
 				}
 
+				jsonName, defaultValue, hasDefault, required := tsMemberMetadata(propName, n, ta.currentFile.Content)
+				setFieldMetadata(prop, jsonName, required, defaultValue, hasDefault)
+
 				ta.currentFile.AddEntity(prop)
 				interfaceEntity.AddChild(prop)
+				ta.relationships = append(ta.relationships, newFieldRelationship(ta.currentFile, interfaceEntity, prop))
 			}
 		case "method_signature":
 			if nameNode := n.ChildByFieldName("name"); nameNode != nil {
@@ -926,10 +954,14 @@ func (ta *TypeScriptAnalyzer) extractRelationships(node *ts.Node) {
 		switch n.Kind() {
 		case "call_expression":
 			ta.extractCallRelationship(n)
+			ta.extractLogStatement(n)
+			ta.extractFlagCheck(n)
 		case "class_declaration":
 			ta.extractInheritanceRelationships(n)
 		case "interface_declaration":
 			ta.extractInterfaceRelationships(n)
+		case "try_statement":
+			ta.extractTryStatement(n)
 		}
 	})
 }
@@ -961,6 +993,7 @@ func (ta *TypeScriptAnalyzer) extractInheritanceRelationships(classNode *ts.Node
 					if targetEntity != nil {
 						relID := ta.generateRelationshipID("extends", className, baseClassName)
 						rel := entities.NewRelationship(relID, entities.RelationshipTypeInherits, sourceEntity, targetEntity)
+						rel.SetProvenance(ta.currentFile.Path, typesNode, ta.currentFile.Content)
 						ta.relationships = append(ta.relationships, rel)
 					}
 				}
@@ -973,6 +1006,7 @@ func (ta *TypeScriptAnalyzer) extractInheritanceRelationships(classNode *ts.Node
 					if targetEntity != nil {
 						relID := ta.generateRelationshipID("implements", className, interfaceName)
 						rel := entities.NewRelationship(relID, entities.RelationshipTypeImplements, sourceEntity, targetEntity)
+						rel.SetProvenance(ta.currentFile.Path, typesNode, ta.currentFile.Content)
 						ta.relationships = append(ta.relationships, rel)
 					}
 				}
@@ -1008,6 +1042,7 @@ func (ta *TypeScriptAnalyzer) extractInterfaceRelationships(interfaceNode *ts.No
 					if targetEntity != nil {
 						relID := ta.generateRelationshipID("extends", interfaceName, baseInterfaceName)
 						rel := entities.NewRelationship(relID, entities.RelationshipTypeInherits, sourceEntity, targetEntity)
+						rel.SetProvenance(ta.currentFile.Path, typesNode, ta.currentFile.Content)
 						ta.relationships = append(ta.relationships, rel)
 					}
 				}
@@ -1038,10 +1073,31 @@ func (ta *TypeScriptAnalyzer) extractCallRelationship(callNode *ts.Node) {
 	if targetEntity != nil {
 		relID := ta.generateRelationshipID("calls", containingFunction.Name, calledFunctionName)
 		rel := entities.NewRelationship(relID, entities.RelationshipTypeCalls, containingFunction, targetEntity)
+		rel.SetProvenance(ta.currentFile.Path, callNode, ta.currentFile.Content)
 		ta.relationships = append(ta.relationships, rel)
 	}
 }
 
+// extractLogStatement records console, winston, pino and logger calls as
+// LogStatement entities
+func (ta *TypeScriptAnalyzer) extractLogStatement(callNode *ts.Node) {
+	functionNode := callNode.ChildByFieldName("function")
+	if functionNode == nil {
+		return
+	}
+
+	callee := ta.getNodeText(functionNode)
+	logger, method, level, ok := classifyLogCall(callee)
+	if !ok {
+		return
+	}
+
+	message := logMessageSnippet(callNode.ChildByFieldName("arguments"), ta.currentFile.Content)
+	id := ta.generateEntityID("log_statement", callee, callNode)
+	entity := newLogStatement(id, ta.currentFile.Path, callNode, callee, logger, method, level, message, ta.findContainingFunction(callNode))
+	ta.currentFile.AddEntity(entity)
+}
+
 // findContainingFunction finds the function that contains the given node
 func (ta *TypeScriptAnalyzer) findContainingFunction(node *ts.Node) *entities.Entity {
 	current := node.Parent()
@@ -1278,6 +1334,7 @@ func (ta *TypeScriptAnalyzer) extractDecoratorsFromNode(node *ts.Node, parent *e
 			// Create decorator relationship
 			relID := ta.generateRelationshipID("decorates", decoratorEntity.Name, targetEntity.Name)
 			rel := entities.NewRelationship(relID, entities.RelationshipTypeDecorates, decoratorEntity, targetEntity)
+			rel.SetProvenance(ta.currentFile.Path, current, ta.currentFile.Content)
 			ta.relationships = append(ta.relationships, rel)
 		}
 		current = current.PrevSibling()
@@ -1386,7 +1443,7 @@ func (ta *TypeScriptAnalyzer) analyzeAdvancedGenerics(node *ts.Node, parent *ent
 	ta.walkNode(node, func(n *ts.Node) {
 		switch n.Kind() {
 		case "type_parameters":
-			ta.extractAdvancedTypeParameters(n, parent)
+			ta.extractAdvancedTypeParameters(n)
 		case "conditional_type":
 			ta.extractConditionalType(n, parent)
 		case "mapped_type":
@@ -1397,8 +1454,24 @@ func (ta *TypeScriptAnalyzer) analyzeAdvancedGenerics(node *ts.Node, parent *ent
 	})
 }
 
-// extractAdvancedTypeParameters extracts generic type parameters with constraints
-func (ta *TypeScriptAnalyzer) extractAdvancedTypeParameters(node *ts.Node, parent *entities.Entity) {
+// extractAdvancedTypeParameters extracts generic type parameters with
+// constraints. The parameters of a declaration become Generic entities,
+// children of the declaration, with a CONSTRAINS relationship to each type
+// named in their constraint, as the Go analyzer records them.
+// analyzeAdvancedGenerics reaches a type parameter list once for every
+// enclosing node, so the owner is looked up from the list itself and a list
+// already recorded is skipped.
+func (ta *TypeScriptAnalyzer) extractAdvancedTypeParameters(node *ts.Node) {
+	owner := ta.declarationEntity(node.Parent())
+
+	paramNames := make(map[string]bool)
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		if nameNode := node.NamedChild(i).ChildByFieldName("name"); nameNode != nil {
+			paramNames[ta.getNodeText(nameNode)] = true
+		}
+	}
+
+	position := 0
 	ta.walkNode(node, func(n *ts.Node) {
 		if n.Kind() == "type_parameter" {
 			nameNode := n.ChildByFieldName("name")
@@ -1414,17 +1487,31 @@ func (ta *TypeScriptAnalyzer) extractAdvancedTypeParameters(node *ts.Node, paren
 			}
 
 			// Extract constraint
+			constraint := ""
 			constraintNode := n.ChildByFieldName("constraint")
 			if constraintNode != nil {
-				constraint := ta.getNodeText(constraintNode)
+				constraint = strings.TrimSpace(strings.TrimPrefix(ta.getNodeText(constraintNode), "extends"))
 				genericInfo.Constraints = []string{constraint}
+			}
 
-				// Create constraint relationship
-				if parent != nil {
-					relID := ta.generateRelationshipID("constrains", paramName, constraint)
-					rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeConstrains, parent.ID, constraint, parent.Type, entities.EntityTypeType)
-					ta.relationships = append(ta.relationships, rel)
+			if owner != nil {
+				id := ta.generateEntityID("generic", owner.Name+"."+paramName, n)
+				if _, recorded := ta.currentFile.Entities[id]; !recorded {
+					generic := entities.NewEntity(id, paramName, entities.EntityTypeGeneric, ta.currentFile.Path, n)
+					generic.Signature = ta.getNodeText(n)
+					generic.SetProperty("constraint", constraint)
+					generic.SetProperty("position", position)
+					generic.SetProperty("owner", owner.ID)
+					generic.SetProperty("owner_name", owner.Name)
+					generic.SetProperty("language", "typescript")
+					ta.currentFile.AddEntity(generic)
+					owner.AddChild(generic)
+
+					if constraintNode != nil {
+						ta.extractConstraintRelationships(generic, constraintNode, paramNames)
+					}
 				}
+				position++
 			}
 
 			// Extract default type
@@ -1439,6 +1526,48 @@ func (ta *TypeScriptAnalyzer) extractAdvancedTypeParameters(node *ts.Node, paren
 	})
 }
 
+// declarationEntity returns the entity of the file declared by node, such as
+// the class or function a type parameter list belongs to
+func (ta *TypeScriptAnalyzer) declarationEntity(node *ts.Node) *entities.Entity {
+	if node == nil {
+		return nil
+	}
+	for _, entity := range ta.currentFile.Entities {
+		if entity.Type == entities.EntityTypeGeneric || entity.Node == nil {
+			continue
+		}
+		if entity.Node.StartByte() == node.StartByte() && entity.Node.EndByte() == node.EndByte() {
+			return entity
+		}
+	}
+	return nil
+}
+
+// extractConstraintRelationships emits CONSTRAINS relationships from a type
+// parameter to the types named in its constraint, such as Entity in
+// <T extends Entity> or both terms of <T extends A | B>. Other type
+// parameters of the same list are left to the "constraint" property.
+func (ta *TypeScriptAnalyzer) extractConstraintRelationships(generic *entities.Entity, constraint *ts.Node, paramNames map[string]bool) {
+	seen := make(map[string]bool)
+	walkTree(constraint, func(n *ts.Node) {
+		if n.Kind() != "type_identifier" || n.Parent().Kind() == "nested_type_identifier" {
+			return
+		}
+		name := ta.getNodeText(n)
+		if paramNames[name] || seen[name] {
+			return
+		}
+		seen[name] = true
+
+		relID := ta.generateRelationshipID("constrains", generic.ID, name)
+		rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeConstrains, generic.ID, name,
+			entities.EntityTypeGeneric, entities.EntityTypeInterface)
+		rel.SetProperty("constraint_type", "extends")
+		rel.SetProvenance(ta.currentFile.Path, n, ta.currentFile.Content)
+		ta.relationships = append(ta.relationships, rel)
+	})
+}
+
 // extractConditionalType extracts conditional type information
 func (ta *TypeScriptAnalyzer) extractConditionalType(node *ts.Node, parent *entities.Entity) {
 	// Create entity for conditional type
@@ -1638,6 +1767,7 @@ func (ta *TypeScriptAnalyzer) enhanceExportAnalysis(node *ts.Node, entity *entit
 		// Create re-export relationship
 		relID := ta.generateRelationshipID("re_exports", entity.Name, source)
 		rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeReExports, entity.ID, source, entities.EntityTypeExport, entities.EntityTypeModule)
+		rel.SetProvenance(ta.currentFile.Path, sourceNode, ta.currentFile.Content)
 		ta.relationships = append(ta.relationships, rel)
 
 		// Store module info
@@ -1693,9 +1823,6 @@ func (ta *TypeScriptAnalyzer) buildAdvancedRelationships() {
 	// Phase 2: Build decorator relationships
 	ta.buildDecoratorRelationships()
 
-	// Phase 2: Build generic constraint relationships
-	ta.buildGenericConstraintRelationships()
-
 	// Phase 2: Build component relationships
 	ta.buildComponentRelationships()
 
@@ -1721,20 +1848,7 @@ func (ta *TypeScriptAnalyzer) buildDecoratorRelationships() {
 			if ta.entityHasDecorator(entity, decoratorInfo.Name) {
 				relID := ta.generateRelationshipID("decorates", decoratorInfo.Name, entity.Name)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeDecorates, decoratorEntity, entity)
-				ta.relationships = append(ta.relationships, rel)
-			}
-		}
-	}
-}
-
-// buildGenericConstraintRelationships creates constraint relationships
-func (ta *TypeScriptAnalyzer) buildGenericConstraintRelationships() {
-	for _, genericInfo := range ta.generics {
-		for _, constraint := range genericInfo.Constraints {
-			constraintEntity := ta.findEntityByName(constraint)
-			if constraintEntity != nil {
-				relID := ta.generateRelationshipID("constrains", genericInfo.Name, constraint)
-				rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeConstrains, genericInfo.Name, constraint, entities.EntityTypeGeneric, entities.EntityTypeType)
+				rel.SetProvenance(ta.currentFile.Path, entity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -1755,6 +1869,7 @@ func (ta *TypeScriptAnalyzer) buildComponentRelationships() {
 			if propEntity != nil {
 				relID := ta.generateRelationshipID("uses", componentInfo.Name, prop)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeUses, componentEntity, propEntity)
+				rel.SetProvenance(ta.currentFile.Path, componentEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -1765,6 +1880,7 @@ func (ta *TypeScriptAnalyzer) buildComponentRelationships() {
 			if serviceEntity != nil {
 				relID := ta.generateRelationshipID("injects", componentInfo.Name, service)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeInjects, componentEntity, serviceEntity)
+				rel.SetProvenance(ta.currentFile.Path, componentEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -1781,6 +1897,7 @@ func (ta *TypeScriptAnalyzer) buildModuleRelationships() {
 			if moduleEntity != nil && targetEntity != nil {
 				relID := ta.generateRelationshipID("re_exports", moduleInfo.Name, reExport)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeReExports, moduleEntity, targetEntity)
+				rel.SetProvenance(ta.currentFile.Path, moduleEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -1791,6 +1908,7 @@ func (ta *TypeScriptAnalyzer) buildModuleRelationships() {
 			if moduleEntity != nil {
 				relID := ta.generateRelationshipID("dynamic_import", moduleInfo.Name, dynamicImport)
 				rel := entities.NewRelationshipByID(relID, entities.RelationshipTypeDynamicImport, moduleEntity.ID, dynamicImport, entities.EntityTypeModule, entities.EntityTypeModule)
+				rel.SetProvenance(ta.currentFile.Path, moduleEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -1944,6 +2062,10 @@ func (ta *TypeScriptAnalyzer) extractAPICall(node *ts.Node, parent *entities.Ent
 	ta.extractAPICallArguments(node, apiCallInfo)
 
 	entity.SetProperty("url", apiCallInfo.URL)
+	entity.SetProperty("url_pattern", apiCallInfo.Pattern)
+	if apiCallInfo.BaseURL != "" {
+		entity.SetProperty("base_url", apiCallInfo.BaseURL)
+	}
 	entity.SetProperty("method", apiCallInfo.Method)
 	entity.SetProperty("library", apiCallInfo.Library)
 
@@ -1956,37 +2078,11 @@ func (ta *TypeScriptAnalyzer) extractAPICall(node *ts.Node, parent *entities.Ent
 	if parent != nil {
 		relID := ta.generateRelationshipID("calls_api", parent.Name, apiCallInfo.URL)
 		rel := entities.NewRelationship(relID, entities.RelationshipTypeCallsAPI, parent, entity)
+		rel.SetProvenance(ta.currentFile.Path, node, ta.currentFile.Content)
 		ta.relationships = append(ta.relationships, rel)
 	}
 }
 
-// extractAPICallArguments extracts URL and method from API call arguments
-func (ta *TypeScriptAnalyzer) extractAPICallArguments(node *ts.Node, apiCallInfo *TypeScriptAPICallInfo) {
-	argumentsNode := node.ChildByFieldName("arguments")
-	if argumentsNode == nil {
-		return
-	}
-
-	var args []string
-	ta.walkNode(argumentsNode, func(n *ts.Node) {
-		if n.Kind() == "string" || n.Kind() == "template_string" {
-			argValue := ta.getNodeText(n)
-			args = append(args, argValue)
-		}
-	})
-
-	if len(args) > 0 {
-		// First argument is usually the URL
-		apiCallInfo.URL = strings.Trim(args[0], "\"'`")
-
-		// Try to detect HTTP method
-		if strings.Contains(apiCallInfo.URL, "/api/") {
-			// Default to GET, but could be enhanced with more analysis
-			apiCallInfo.Method = "GET"
-		}
-	}
-}
-
 // getAPILibrary determines which API library is being used
 func (ta *TypeScriptAnalyzer) getAPILibrary(functionName string) string {
 	if strings.Contains(functionName, "fetch") {
@@ -2018,12 +2114,28 @@ func (ta *TypeScriptAnalyzer) isExpressPattern(functionName string) bool {
 	return false
 }
 
+// expressRouteMethods maps the Express router methods that register a route
+// to the HTTP method they serve
+var expressRouteMethods = map[string]string{
+	"get":     "GET",
+	"post":    "POST",
+	"put":     "PUT",
+	"delete":  "DELETE",
+	"patch":   "PATCH",
+	"options": "OPTIONS",
+	"head":    "HEAD",
+	"all":     "ANY",
+}
+
 // extractExpressPattern extracts Express.js route and endpoint patterns
 func (ta *TypeScriptAnalyzer) extractExpressPattern(node *ts.Node, parent *entities.Entity, functionName string) {
 	// Extract route information
-	if strings.Contains(functionName, ".get") || strings.Contains(functionName, ".post") ||
-		strings.Contains(functionName, ".put") || strings.Contains(functionName, ".delete") {
-		ta.extractExpressRoute(node, parent, functionName)
+	if functionNode := node.ChildByFieldName("function"); functionNode != nil && functionNode.Kind() == "member_expression" {
+		object := functionNode.ChildByFieldName("object")
+		property := functionNode.ChildByFieldName("property")
+		if method, ok := expressRouteMethods[ta.getNodeText(property)]; ok && object != nil && object.Kind() == "identifier" {
+			ta.extractExpressRoute(node, parent, method, ta.getNodeText(object))
+		}
 	}
 
 	// Extract middleware usage
@@ -2032,35 +2144,36 @@ func (ta *TypeScriptAnalyzer) extractExpressPattern(node *ts.Node, parent *entit
 	}
 }
 
-// extractExpressRoute extracts Express.js route definitions
-func (ta *TypeScriptAnalyzer) extractExpressRoute(node *ts.Node, parent *entities.Entity, functionName string) {
-	// Determine HTTP method
-	method := "GET"
-	if strings.Contains(functionName, ".post") {
-		method = "POST"
-	} else if strings.Contains(functionName, ".put") {
-		method = "PUT"
-	} else if strings.Contains(functionName, ".delete") {
-		method = "DELETE"
-	}
-
-	// Extract route path from arguments
+// extractExpressRoute extracts an Express.js route definition such as
+// router.put('/users/:id', auth, handler). The method comes from the router
+// method called, and router is the receiver, which routes of a file are
+// grouped by since it may be mounted under a prefix. A call is only a route
+// when a path is followed by a handler, so router.get('key') on a map-like
+// object is not one.
+func (ta *TypeScriptAnalyzer) extractExpressRoute(node *ts.Node, parent *entities.Entity, method, router string) {
 	argumentsNode := node.ChildByFieldName("arguments")
 	if argumentsNode == nil {
 		return
 	}
+	arguments := make([]*ts.Node, 0, argumentsNode.NamedChildCount())
+	for i := uint(0); i < argumentsNode.NamedChildCount(); i++ {
+		if argument := argumentsNode.NamedChild(i); argument.Kind() != "comment" {
+			arguments = append(arguments, argument)
+		}
+	}
+	if len(arguments) < 2 || (arguments[0].Kind() != "string" && arguments[0].Kind() != "template_string") {
+		return
+	}
 
-	var routePath string
 	var handlerName string
-
-	// Get first argument (route path)
-	ta.walkNode(argumentsNode, func(n *ts.Node) {
-		if n.Kind() == "string" && routePath == "" {
-			routePath = strings.Trim(ta.getNodeText(n), "\"'")
-		} else if n.Kind() == "identifier" || n.Kind() == "arrow_function" {
-			handlerName = ta.getNodeText(n)
-		}
-	})
+	switch handler := arguments[len(arguments)-1]; handler.Kind() {
+	case "identifier", "member_expression":
+		handlerName = ta.getNodeText(handler)
+	case "arrow_function", "function_expression", "function":
+	default:
+		return
+	}
+	routePath := strings.Trim(ta.getNodeText(arguments[0]), "\"'`")
 
 	if routePath != "" {
 		// Create endpoint entity
@@ -2070,6 +2183,7 @@ func (ta *TypeScriptAnalyzer) extractExpressRoute(node *ts.Node, parent *entitie
 		endpointEntity.SetProperty("method", method)
 		endpointEntity.SetProperty("path", routePath)
 		endpointEntity.SetProperty("handler", handlerName)
+		endpointEntity.SetProperty("router", router)
 
 		// Store endpoint info
 		endpointInfo := &TypeScriptEndpointInfo{
@@ -2077,6 +2191,9 @@ func (ta *TypeScriptAnalyzer) extractExpressRoute(node *ts.Node, parent *entitie
 			Method:  method,
 			Handler: handlerName,
 		}
+		for _, middleware := range arguments[1 : len(arguments)-1] {
+			endpointInfo.Middleware = append(endpointInfo.Middleware, ta.getNodeText(middleware))
+		}
 
 		key := fmt.Sprintf("endpoint_%s_%s", method, routePath)
 		ta.endpoints[key] = endpointInfo
@@ -2087,6 +2204,7 @@ func (ta *TypeScriptAnalyzer) extractExpressRoute(node *ts.Node, parent *entitie
 		if parent != nil {
 			relID := ta.generateRelationshipID("exposes_endpoint", parent.Name, routePath)
 			rel := entities.NewRelationship(relID, entities.RelationshipTypeExposesEndpoint, parent, endpointEntity)
+			rel.SetProvenance(ta.currentFile.Path, node, ta.currentFile.Content)
 			ta.relationships = append(ta.relationships, rel)
 		}
 	}
@@ -2205,6 +2323,7 @@ func (ta *TypeScriptAnalyzer) linkPropsToComponent(propsInterfaceName string, no
 		if propsEntity != nil && componentEntity != nil {
 			relID := ta.generateRelationshipID("has_props", componentName, propsInterfaceName)
 			rel := entities.NewRelationship(relID, entities.RelationshipTypeHasProps, componentEntity, propsEntity)
+			rel.SetProvenance(ta.currentFile.Path, componentEntity.Node, ta.currentFile.Content)
 			ta.relationships = append(ta.relationships, rel)
 		}
 	}
@@ -2456,6 +2575,7 @@ func (ta *TypeScriptAnalyzer) buildFrameworkRelationships() {
 			if propsEntity != nil {
 				relID := ta.generateRelationshipID("has_props", componentInfo.Name, componentInfo.PropsInterface)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeHasProps, componentEntity, propsEntity)
+				rel.SetProvenance(ta.currentFile.Path, componentEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -2466,6 +2586,7 @@ func (ta *TypeScriptAnalyzer) buildFrameworkRelationships() {
 			if jsxEntity != nil {
 				relID := ta.generateRelationshipID("renders_jsx", componentInfo.Name, jsxElement)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeRendersJSX, componentEntity, jsxEntity)
+				rel.SetProvenance(ta.currentFile.Path, componentEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -2476,6 +2597,7 @@ func (ta *TypeScriptAnalyzer) buildFrameworkRelationships() {
 			if serviceEntity != nil {
 				relID := ta.generateRelationshipID("consumes_service", componentInfo.Name, service)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeConsumesService, componentEntity, serviceEntity)
+				rel.SetProvenance(ta.currentFile.Path, componentEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -2489,6 +2611,7 @@ func (ta *TypeScriptAnalyzer) buildFrameworkRelationships() {
 		if routeEntity != nil && componentEntity != nil {
 			relID := ta.generateRelationshipID("handles_route", routeInfo.Component, routeInfo.Path)
 			rel := entities.NewRelationship(relID, entities.RelationshipTypeHandlesRoute, componentEntity, routeEntity)
+			rel.SetProvenance(ta.currentFile.Path, componentEntity.Node, ta.currentFile.Content)
 			ta.relationships = append(ta.relationships, rel)
 		}
 	}
@@ -2511,6 +2634,7 @@ func (ta *TypeScriptAnalyzer) buildAPIRelationships() {
 
 					relID := ta.generateRelationshipID("calls_api", potentialCaller.Name, entity.Name)
 					rel := entities.NewRelationship(relID, entities.RelationshipTypeCallsAPI, potentialCaller, entity)
+					rel.SetProvenance(ta.currentFile.Path, entity.Node, ta.currentFile.Content)
 					ta.relationships = append(ta.relationships, rel)
 				}
 			}
@@ -2525,6 +2649,7 @@ func (ta *TypeScriptAnalyzer) buildAPIRelationships() {
 		if endpointEntity != nil && handlerEntity != nil {
 			relID := ta.generateRelationshipID("handles_endpoint", endpointInfo.Handler, endpointInfo.Path)
 			rel := entities.NewRelationship(relID, entities.RelationshipTypeHandlesRoute, handlerEntity, endpointEntity)
+			rel.SetProvenance(ta.currentFile.Path, endpointEntity.Node, ta.currentFile.Content)
 			ta.relationships = append(ta.relationships, rel)
 		}
 
@@ -2534,6 +2659,7 @@ func (ta *TypeScriptAnalyzer) buildAPIRelationships() {
 			if endpointEntity != nil && middlewareEntity != nil {
 				relID := ta.generateRelationshipID("uses_middleware", endpointInfo.Path, middlewareName)
 				rel := entities.NewRelationship(relID, entities.RelationshipTypeUsesMiddleware, endpointEntity, middlewareEntity)
+				rel.SetProvenance(ta.currentFile.Path, endpointEntity.Node, ta.currentFile.Content)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -2554,6 +2680,7 @@ func (ta *TypeScriptAnalyzer) buildJSXRelationships() {
 							if jsxElement == entity.Name {
 								relID := ta.generateRelationshipID("renders_jsx", componentEntity.Name, entity.Name)
 								rel := entities.NewRelationship(relID, entities.RelationshipTypeRendersJSX, componentEntity, entity)
+								rel.SetProvenance(ta.currentFile.Path, entity.Node, ta.currentFile.Content)
 								ta.relationships = append(ta.relationships, rel)
 							}
 						}
@@ -2566,6 +2693,7 @@ func (ta *TypeScriptAnalyzer) buildJSXRelationships() {
 				if childEntity.Type == entities.EntityTypeProp {
 					relID := ta.generateRelationshipID("accepts_props", entity.Name, childEntity.Name)
 					rel := entities.NewRelationship(relID, entities.RelationshipTypeAcceptsProps, entity, childEntity)
+					rel.SetProvenance(ta.currentFile.Path, childEntity.Node, ta.currentFile.Content)
 					ta.relationships = append(ta.relationships, rel)
 				}
 			}
@@ -3277,6 +3405,7 @@ func (ta *TypeScriptAnalyzer) extractTestRelationships(node *ts.Node) {
 					targetEntity.Type,
 				)
 				rel.SetProperty("confidence_score", 0.8)
+				rel.Provenance = ta.testProvenance(testCase.Name, testCase.StartLine)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -3292,6 +3421,7 @@ func (ta *TypeScriptAnalyzer) extractTestRelationships(node *ts.Node) {
 				entities.EntityTypeTestFunction,
 				entities.EntityTypeAssertion,
 			)
+			rel.Provenance = ta.testProvenance(testCase.Name, testCase.StartLine)
 			ta.relationships = append(ta.relationships, rel)
 		}
 		
@@ -3309,6 +3439,7 @@ func (ta *TypeScriptAnalyzer) extractTestRelationships(node *ts.Node) {
 				)
 				rel.SetProperty("mock_type", mock.Type)
 				rel.SetProperty("mock_target", mock.Target)
+				rel.Provenance = ta.testProvenance(testCase.Name, testCase.StartLine)
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
@@ -3327,6 +3458,7 @@ func (ta *TypeScriptAnalyzer) extractTestRelationships(node *ts.Node) {
 				entities.EntityTypeTestSuite,
 				entities.EntityTypeTestFunction,
 			)
+			rel.Provenance = ta.testProvenance(suite.Name, suite.StartLine)
 			ta.relationships = append(ta.relationships, rel)
 		}
 		
@@ -3341,11 +3473,23 @@ func (ta *TypeScriptAnalyzer) extractTestRelationships(node *ts.Node) {
 				entities.EntityTypeTestSuite,
 				entities.EntityTypeTestSuite,
 			)
+			rel.Provenance = ta.testProvenance(suite.Name, suite.StartLine)
 			ta.relationships = append(ta.relationships, rel)
 		}
 	}
 }
 
+// testProvenance records the test or suite call a test relationship was read
+// from; test infos keep the 0-based row of the call rather than its node
+func (ta *TypeScriptAnalyzer) testProvenance(name string, row int) *entities.Provenance {
+	return &entities.Provenance{
+		NodeKind:    "call_expression",
+		MatchedText: name,
+		FilePath:    ta.currentFile.Path,
+		Line:        uint32(row + 1),
+	}
+}
+
 // buildTestRelationships builds additional test relationships after main analysis
 func (ta *TypeScriptAnalyzer) buildTestRelationships() {
 	// Build coverage relationships
@@ -3360,6 +3504,9 @@ func (ta *TypeScriptAnalyzer) buildTestRelationships() {
 				entities.EntityTypeFunction,
 			)
 			rel.SetProperty("coverage_type", "direct")
+			if testCase, ok := ta.testCases[testID]; ok {
+				rel.Provenance = ta.testProvenance(testCase.Name, testCase.StartLine)
+			}
 			ta.relationships = append(ta.relationships, rel)
 		}
 	}
@@ -3380,6 +3527,9 @@ func (ta *TypeScriptAnalyzer) buildTestRelationships() {
 				)
 				rel.SetProperty("render_method", componentTest.RenderMethod)
 				rel.SetProperty("framework", componentTest.Framework)
+				if testCase, ok := ta.testCases[componentTest.TestCaseID]; ok {
+					rel.Provenance = ta.testProvenance(testCase.Name, testCase.StartLine)
+				}
 				ta.relationships = append(ta.relationships, rel)
 			}
 		}
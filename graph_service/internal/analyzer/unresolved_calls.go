@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// pythonBuiltins are the functions and exception types Python provides
+// without an import. Calls to them never resolve to an entity of the
+// repository, but the analyzer knows what they are.
+var pythonBuiltins = map[string]bool{
+	"abs": true, "aiter": true, "all": true, "anext": true, "any": true, "ascii": true,
+	"bin": true, "bool": true, "breakpoint": true, "bytearray": true, "bytes": true,
+	"callable": true, "chr": true, "classmethod": true, "compile": true, "complex": true,
+	"delattr": true, "dict": true, "dir": true, "divmod": true, "enumerate": true,
+	"eval": true, "exec": true, "exit": true, "filter": true, "float": true, "format": true,
+	"frozenset": true, "getattr": true, "globals": true, "hasattr": true, "hash": true,
+	"help": true, "hex": true, "id": true, "input": true, "int": true, "isinstance": true,
+	"issubclass": true, "iter": true, "len": true, "list": true, "locals": true, "map": true,
+	"max": true, "memoryview": true, "min": true, "next": true, "object": true, "oct": true,
+	"open": true, "ord": true, "pow": true, "print": true, "property": true, "quit": true,
+	"range": true, "repr": true, "reversed": true, "round": true, "set": true, "setattr": true,
+	"slice": true, "sorted": true, "staticmethod": true, "str": true, "sum": true,
+	"super": true, "tuple": true, "type": true, "vars": true, "zip": true, "__import__": true,
+}
+
+// newPhase2UnresolvedCall returns the UnresolvedCall entity of a Python call
+// that Phase 2 could not resolve, or nil when the failure is not a blind
+// spot of the analyzer. Calls to builtins, to exception types and to names
+// bound by an import resolve outside the repository, methods may be
+// inherited from base classes, and calls on other objects depend on types
+// the analyzer does not track, so only two kinds of call are recorded:
+//   - "unresolved_name": a plain name that no definition of the repository
+//     and no import binds
+//   - "unresolved_attribute": self.x() or cls.x() where the class has no base
+//     class and defines no x
+//
+// imports caches the import bindings of each file, by path, across calls.
+func (gb *GraphBuilder) newPhase2UnresolvedCall(rel *entities.Relationship, imports map[string]map[string]string) *entities.Entity {
+	caller := gb.allEntities[rel.SourceID]
+	if rel.Type != entities.RelationshipTypeCalls || caller == nil || rel.Location == nil {
+		return nil
+	}
+	file := gb.files[caller.FilePath]
+	if file == nil || file.Language != "python" || file.Tree == nil {
+		return nil
+	}
+
+	expression := rel.TargetID
+	reason := ""
+	if object, attribute, found := strings.Cut(expression, "."); !found {
+		if pythonBuiltins[expression] || strings.HasSuffix(expression, "Error") || strings.HasSuffix(expression, "Exception") {
+			return nil
+		}
+		// Classes and other definitions that calls do not resolve to
+		if len(gb.registry.LookupName(expression)) > 0 {
+			return nil
+		}
+		bindings, ok := imports[file.Path]
+		if !ok {
+			bindings = pythonImportBindings(file.Tree.RootNode(), file.Content)
+			imports[file.Path] = bindings
+		}
+		if _, imported := bindings[expression]; imported {
+			return nil
+		}
+		reason = "unresolved_name"
+	} else if (object == "self" || object == "cls") && !mayDefineAttribute(caller.Parent, attribute) {
+		reason = "unresolved_attribute"
+	} else {
+		return nil
+	}
+
+	// The same ID the Python analyzer gives the UnresolvedCall of a call site
+	loc := rel.Location
+	base := fmt.Sprintf("unresolved_call:%s:%s:%d:%d", caller.FilePath, expression, loc.StartByte, loc.EndByte)
+	hash := sha256.Sum256([]byte(base))
+	line := 0
+	if rel.Provenance != nil {
+		line = int(rel.Provenance.Line)
+	}
+
+	entity := entities.NewSpanEntity(hex.EncodeToString(hash[:])[:16], expression, entities.EntityTypeUnresolvedCall,
+		caller.FilePath, loc.StartByte, loc.EndByte, line, line)
+	if int(loc.EndByte) <= len(file.Content) {
+		entity.Signature = string(file.Content[loc.StartByte:loc.EndByte])
+	}
+	entity.SetProperty("expression", expression)
+	entity.SetProperty("reason", reason)
+	entity.SetProperty("caller_id", caller.ID)
+	entity.SetProperty("caller_name", caller.GetFullName())
+	return entity
+}
+
+// mayDefineAttribute reports whether class, the class of a method calling
+// self.name(), may define name: it does not know a class it cannot see, a
+// class with base classes or one that defines a member called name.
+func mayDefineAttribute(class *entities.Entity, name string) bool {
+	if class == nil || class.Type != entities.EntityTypeClass {
+		return true
+	}
+	if superclasses, _ := class.GetProperty("superclasses").(string); superclasses != "" && superclasses != "()" && superclasses != "(object)" {
+		return true
+	}
+	for _, child := range class.Children {
+		if child.Name == name {
+			return true
+		}
+	}
+	return false
+}
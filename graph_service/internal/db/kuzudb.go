@@ -43,6 +43,7 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -82,6 +83,12 @@ type KuzuDatabase struct {
 	// executing queries and transactions. KuzuDB supports multiple
 	// concurrent connections to the same database.
 	Connection *kuzu.Connection
+
+	// RelationshipsMigrated is set by CreateSchema when a relationship table
+	// of an existing database gained FROM/TO pairs or was recreated, so the
+	// relationships stored before may be incomplete and are stored again by
+	// a resumed build.
+	RelationshipsMigrated bool
 }
 
 // NewKuzuDatabase creates and initializes a new KuzuDB embedded database instance
@@ -179,8 +186,9 @@ func (kdb *KuzuDatabase) CreateSchema() error {
 		`CREATE NODE TABLE IF NOT EXISTS Method(id STRING, name STRING, signature STRING, body STRING, receiver_type STRING, file_path STRING, PRIMARY KEY (id))`,
 		`CREATE NODE TABLE IF NOT EXISTS Struct(id STRING, name STRING, type_definition STRING, file_path STRING, PRIMARY KEY (id))`,
 		`CREATE NODE TABLE IF NOT EXISTS Interface(id STRING, name STRING, type_definition STRING, file_path STRING, PRIMARY KEY (id))`,
-		`CREATE NODE TABLE IF NOT EXISTS Import(id STRING, name STRING, path STRING, alias STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS Import(id STRING, name STRING, path STRING, alias STRING, file_path STRING, conditional BOOLEAN, condition STRING, PRIMARY KEY (id))`,
 		`CREATE NODE TABLE IF NOT EXISTS Variable(id STRING, name STRING, type STRING, value STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS Property(id STRING, name STRING, type STRING, json_name STRING, required BOOLEAN, default_value STRING, file_path STRING, PRIMARY KEY (id))`,
 
 		// Test-specific entity types
 		`CREATE NODE TABLE IF NOT EXISTS TestFunction(id STRING, name STRING, signature STRING, body STRING, file_path STRING, test_type STRING, test_target STRING, assertion_count INT64, test_framework STRING, PRIMARY KEY (id))`,
@@ -190,61 +198,138 @@ func (kdb *KuzuDatabase) CreateSchema() error {
 		`CREATE NODE TABLE IF NOT EXISTS Mock(id STRING, name STRING, mock_type STRING, target_entity STRING, file_path STRING, PRIMARY KEY (id))`,
 		`CREATE NODE TABLE IF NOT EXISTS Fixture(id STRING, name STRING, fixture_type STRING, data_content STRING, file_path STRING, PRIMARY KEY (id))`,
 
+		// Analysis diagnostics entity types
+		`CREATE NODE TABLE IF NOT EXISTS UnresolvedCall(id STRING, name STRING, expression STRING, reason STRING, caller_id STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS LogStatement(id STRING, name STRING, level STRING, message STRING, logger STRING, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS FeatureFlag(id STRING, name STRING, provider STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS NPlusOne(id STRING, name STRING, access_kind STRING, loop_kind STRING, loop_variables STRING, depends_on STRING, loop_start_line INT64, loop_end_line INT64, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS Generic(id STRING, name STRING, constraint STRING, position INT64, owner STRING, owner_name STRING, language STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS CommentedCode(id STRING, name STRING, text STRING, start_line INT64, end_line INT64, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS Binding(id STRING, name STRING, interface STRING, implementation STRING, framework STRING, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS ControlFlowIssue(id STRING, name STRING, issue STRING, line INT64, after STRING, message STRING, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS MissingDoc(id STRING, name STRING, entity STRING, entity_type STRING, line INT64, message STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS Type(id STRING, name STRING, type_definition STRING, is_alias BOOLEAN, alias_composition STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS Enum(id STRING, name STRING, members STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS CLIFlag(id STRING, name STRING, flag STRING, short STRING, flag_type STRING, default_value STRING, help STRING, library STRING, command STRING, positional BOOLEAN, value_read BOOLEAN, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS RaceRisk(id STRING, name STRING, state STRING, variable STRING, goroutine STRING, accesses INT64, writes INT64, message STRING, line INT64, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS BlockingInAsync(id STRING, name STRING, callee STRING, qualified_callee STRING, kind STRING, suggestion STRING, message STRING, line INT64, async_function STRING, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS StructTagIssue(id STRING, name STRING, kind STRING, struct_name STRING, field STRING, field_id STRING, tag STRING, message STRING, line INT64, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS ErrorMessageIssue(id STRING, name STRING, kind STRING, error_message STRING, constructor STRING, message STRING, suggestion STRING, line INT64, enclosing_function STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS EnumMember(id STRING, name STRING, enum STRING, value STRING, implicit BOOLEAN, position INT64, file_path STRING, PRIMARY KEY (id))`,
+
+		// Database migration entity types
+		`CREATE NODE TABLE IF NOT EXISTS Migration(id STRING, name STRING, tool STRING, version STRING, description STRING, direction STRING, revision STRING, down_revision STRING, migration_order INT64, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS SchemaChange(id STRING, name STRING, operation STRING, table_name STRING, column_name STRING, index_name STRING, columns STRING, statement STRING, migration STRING, direction STRING, position INT64, file_path STRING, PRIMARY KEY (id))`,
+
+		// Infrastructure-as-code entity types
+		`CREATE NODE TABLE IF NOT EXISTS Resource(id STRING, name STRING, resource_type STRING, provider STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS DataSource(id STRING, name STRING, data_type STRING, provider STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS ModuleCall(id STRING, name STRING, source STRING, file_path STRING, PRIMARY KEY (id))`,
+		`CREATE NODE TABLE IF NOT EXISTS Output(id STRING, name STRING, value STRING, file_path STRING, PRIMARY KEY (id))`,
+
 		// Basic relationships
-		`CREATE REL TABLE IF NOT EXISTS Contains(FROM File TO Function, FROM File TO Class, FROM File TO Method, FROM File TO Struct, FROM File TO Interface, FROM File TO Import, FROM File TO Variable, FROM File TO TestFunction, FROM File TO TestCase, FROM File TO TestSuite, FROM File TO Assertion, FROM File TO Mock, FROM File TO Fixture)`,
-		`CREATE REL TABLE IF NOT EXISTS CALLS(FROM Function TO Function, FROM Method TO Function, FROM Function TO Method, FROM Method TO Method, FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method)`,
-		`CREATE REL TABLE IF NOT EXISTS IMPORTS(FROM File TO File)`,
-		`CREATE REL TABLE IF NOT EXISTS INHERITS(FROM Class TO Class)`,
+		`CREATE REL TABLE IF NOT EXISTS Contains(FROM File TO Function, FROM File TO Class, FROM File TO Method, FROM File TO Struct, FROM File TO Interface, FROM File TO Import, FROM File TO Variable, FROM File TO TestFunction, FROM File TO TestCase, FROM File TO TestSuite, FROM File TO Assertion, FROM File TO Mock, FROM File TO Fixture, FROM File TO UnresolvedCall, FROM File TO LogStatement, FROM File TO Resource, FROM File TO DataSource, FROM File TO ModuleCall, FROM File TO Output, FROM File TO Migration, FROM File TO Type, FROM Migration TO SchemaChange, FROM Function TO Function, FROM Method TO Function, FROM TestFunction TO Function, FROM Struct TO Property, FROM Class TO Property, FROM Interface TO Property, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS CALLS(FROM Function TO Function, FROM Method TO Function, FROM Function TO Method, FROM Method TO Method, FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS IMPORTS(FROM File TO File, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS INHERITS(FROM Class TO Class, provenance STRING)`,
 
 		// Enhanced Go-specific relationships
-		`CREATE REL TABLE IF NOT EXISTS EMBEDS(FROM Struct TO Struct, source_id STRING, target_id STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS IMPLEMENTS(FROM Struct TO Interface, source_id STRING, target_id STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS DEFINES(FROM Struct TO Method, FROM Interface TO Method)`,
-		`CREATE REL TABLE IF NOT EXISTS USES(FROM Function TO Struct, FROM Method TO Struct, FROM Function TO Interface, FROM Method TO Interface)`,
+		`CREATE REL TABLE IF NOT EXISTS EMBEDS(FROM Struct TO Struct, source_id STRING, target_id STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS IMPLEMENTS(FROM Struct TO Interface, source_id STRING, target_id STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS DEFINES(FROM Struct TO Method, FROM Interface TO Method, FROM Enum TO EnumMember, FROM Class TO EnumMember, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS REFERENCES(FROM Function TO Struct, FROM Function TO Interface, FROM Function TO Class, FROM Function TO Variable, FROM Method TO Struct, FROM Method TO Interface, FROM Method TO Class, FROM Method TO Variable, FROM TestFunction TO Struct, FROM TestFunction TO Interface, FROM TestFunction TO Class, FROM TestFunction TO Variable, FROM Struct TO Struct, FROM Struct TO Interface, FROM Struct TO Class, FROM Struct TO Variable, FROM Interface TO Struct, FROM Interface TO Interface, FROM Interface TO Class, FROM Interface TO Variable, FROM Class TO Struct, FROM Class TO Interface, FROM Class TO Class, FROM Class TO Variable, FROM Variable TO Struct, FROM Variable TO Interface, FROM Variable TO Class, FROM Variable TO Variable, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS USES(FROM Function TO Struct, FROM Method TO Struct, FROM Function TO Interface, FROM Method TO Interface, provenance STRING)`,
 
 		// Test Coverage relationships
-		`CREATE REL TABLE IF NOT EXISTS TESTS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestFunction TO Class, FROM TestCase TO Function, FROM TestCase TO Method, FROM TestCase TO Class, confidence_score DOUBLE)`,
-		`CREATE REL TABLE IF NOT EXISTS COVERS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, coverage_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS MOCKS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, FROM Mock TO Function, FROM Mock TO Method, mock_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS SETUP_FOR(FROM TestFunction TO TestCase, FROM Fixture TO TestFunction, FROM Fixture TO TestCase)`,
-		`CREATE REL TABLE IF NOT EXISTS TEARDOWN_FOR(FROM TestFunction TO TestCase)`,
-		`CREATE REL TABLE IF NOT EXISTS ASSERTS(FROM TestFunction TO Assertion, FROM TestCase TO Assertion, assertion_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS VERIFIES(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, verification_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS SPIES(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, spy_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS STUBS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, stub_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS FIXTURES(FROM TestFunction TO Fixture, FROM TestCase TO Fixture, fixture_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS RUNS_TEST(FROM TestSuite TO TestFunction, FROM TestSuite TO TestCase)`,
-		`CREATE REL TABLE IF NOT EXISTS GROUPS_TESTS(FROM TestSuite TO TestFunction, FROM TestSuite TO TestCase, group_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS SKIPS(FROM TestFunction TO TestFunction, FROM TestCase TO TestCase, skip_condition STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS DEPENDS(FROM TestFunction TO TestFunction, FROM TestCase TO TestCase, FROM TestFunction TO Fixture, FROM TestCase TO Fixture, dependency_type STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS TESTS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestFunction TO Class, FROM TestCase TO Function, FROM TestCase TO Method, FROM TestCase TO Class, confidence_score DOUBLE, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS COVERS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, coverage_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS MOCKS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, FROM Mock TO Function, FROM Mock TO Method, mock_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS SETUP_FOR(FROM TestFunction TO TestCase, FROM Fixture TO TestFunction, FROM Fixture TO TestCase, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS TEARDOWN_FOR(FROM TestFunction TO TestCase, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS ASSERTS(FROM TestFunction TO Assertion, FROM TestCase TO Assertion, assertion_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS VERIFIES(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, verification_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS SPIES(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, spy_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS STUBS(FROM TestFunction TO Function, FROM TestFunction TO Method, FROM TestCase TO Function, FROM TestCase TO Method, stub_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS FIXTURES(FROM TestFunction TO Fixture, FROM TestCase TO Fixture, fixture_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS RUNS_TEST(FROM TestSuite TO TestFunction, FROM TestSuite TO TestCase, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS GROUPS_TESTS(FROM TestSuite TO TestFunction, FROM TestSuite TO TestCase, group_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS SKIPS(FROM TestFunction TO TestFunction, FROM TestCase TO TestCase, skip_condition STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS DEPENDS(FROM TestFunction TO TestFunction, FROM TestCase TO TestCase, FROM TestFunction TO Fixture, FROM TestCase TO Fixture, dependency_type STRING, provenance STRING)`,
 
 		// TypeScript-specific relationships
-		`CREATE REL TABLE IF NOT EXISTS RE_EXPORTS(FROM File TO File, FROM Function TO Function, FROM Class TO Class, export_name STRING, export_alias STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS DECORATES(FROM Function TO Class, FROM Function TO Method, FROM Function TO Function, decorator_name STRING, decorator_params STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS CONSTRAINS(FROM Interface TO Class, FROM Interface TO Function, constraint_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS DYNAMIC_IMPORT(FROM Function TO File, FROM Method TO File, import_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS HAS_PROPS(FROM Class TO Variable, FROM Interface TO Variable, prop_type STRING, is_required BOOLEAN)`,
-		`CREATE REL TABLE IF NOT EXISTS RENDERS_JSX(FROM Function TO Class, FROM Method TO Class, jsx_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS INJECTS(FROM Class TO Class, FROM Function TO Variable, injection_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS CONSUMES_SERVICE(FROM Class TO Class, FROM Function TO Class, service_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS HANDLES_ROUTE(FROM Function TO Variable, FROM Method TO Variable, route_path STRING, http_method STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS CALLS_API(FROM Function TO Function, FROM Method TO Function, api_endpoint STRING, http_method STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS EXPOSES_ENDPOINT(FROM Function TO Variable, FROM Method TO Variable, endpoint_path STRING, endpoint_type STRING)`,
-		`CREATE REL TABLE IF NOT EXISTS USES_MIDDLEWARE(FROM Function TO Function, FROM Class TO Function, middleware_type STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS RE_EXPORTS(FROM File TO File, FROM Function TO Function, FROM Class TO Class, export_name STRING, export_alias STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS DECORATES(FROM Function TO Class, FROM Function TO Method, FROM Function TO Function, decorator_name STRING, decorator_params STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS CONSTRAINS(FROM Interface TO Class, FROM Interface TO Function, FROM Generic TO Interface, FROM Generic TO Struct, FROM Generic TO Class, constraint_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS DYNAMIC_IMPORT(FROM Function TO File, FROM Method TO File, import_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS HAS_PROPS(FROM Class TO Variable, FROM Interface TO Variable, prop_type STRING, is_required BOOLEAN, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS RENDERS_JSX(FROM Function TO Class, FROM Method TO Class, jsx_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS INJECTS(FROM Class TO Class, FROM Function TO Variable, injection_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS PROVIDES(FROM Binding TO Interface, FROM Binding TO Class, FROM Binding TO Struct, framework STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS BOUND_TO(FROM Binding TO Struct, FROM Binding TO Class, FROM Binding TO Function, framework STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS CONSUMES_SERVICE(FROM Class TO Class, FROM Function TO Class, service_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS HANDLES_ROUTE(FROM Function TO Variable, FROM Method TO Variable, route_path STRING, http_method STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS CALLS_API(FROM Function TO Function, FROM Method TO Function, api_endpoint STRING, http_method STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS EXPOSES_ENDPOINT(FROM Function TO Variable, FROM Method TO Variable, endpoint_path STRING, endpoint_type STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS USES_MIDDLEWARE(FROM Function TO Function, FROM Class TO Function, middleware_type STRING, provenance STRING)`,
+
+		// Error-flow relationships
+		`CREATE REL TABLE IF NOT EXISTS PROPAGATES_ERROR(FROM Function TO Function, FROM Function TO Method, FROM Method TO Function, FROM Method TO Method, FROM TestFunction TO Function, FROM TestFunction TO Method, wrapped BOOLEAN, exception_types STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS HANDLES_ERROR(FROM Function TO Function, FROM Function TO Method, FROM Method TO Function, FROM Method TO Method, FROM TestFunction TO Function, FROM TestFunction TO Method, exception_types STRING, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS IGNORES_ERROR(FROM Function TO Function, FROM Function TO Method, FROM Method TO Function, FROM Method TO Method, FROM TestFunction TO Function, FROM TestFunction TO Method, reason STRING, exception_types STRING, provenance STRING)`,
+
+		// Feature-flag relationships
+		`CREATE REL TABLE IF NOT EXISTS CHECKS_FLAG(FROM Function TO FeatureFlag, FROM Method TO FeatureFlag, FROM TestFunction TO FeatureFlag, provider STRING, callee STRING, provenance STRING)`,
+
+		// Control-flow relationships
+		`CREATE REL TABLE IF NOT EXISTS HAS_ISSUE(FROM Function TO ControlFlowIssue, FROM Method TO ControlFlowIssue, FROM TestFunction TO ControlFlowIssue, FROM Function TO BlockingInAsync, FROM Method TO BlockingInAsync, FROM TestFunction TO BlockingInAsync, FROM Property TO StructTagIssue, issue STRING, provenance STRING)`,
+
+		// Infrastructure-as-code relationships
+		`CREATE REL TABLE IF NOT EXISTS DEPENDS_ON(FROM Resource TO Resource, FROM Resource TO DataSource, FROM Resource TO ModuleCall, FROM Resource TO Variable, FROM DataSource TO Resource, FROM DataSource TO DataSource, FROM DataSource TO ModuleCall, FROM DataSource TO Variable, FROM ModuleCall TO Resource, FROM ModuleCall TO DataSource, FROM ModuleCall TO ModuleCall, FROM ModuleCall TO Variable, FROM Output TO Resource, FROM Output TO DataSource, FROM Output TO ModuleCall, FROM Output TO Variable, reference STRING, explicit BOOLEAN, provenance STRING)`,
+
+		// Database migration relationships
+		`CREATE REL TABLE IF NOT EXISTS AFFECTS(FROM SchemaChange TO Class, FROM SchemaChange TO Struct, table_name STRING, operation STRING, provenance STRING)`,
+
+		// Type alias relationships
+		`CREATE REL TABLE IF NOT EXISTS ALIASES(FROM Type TO Type, FROM Type TO Interface, FROM Type TO Class, FROM Type TO Enum, FROM Type TO Struct, FROM Class TO Class, FROM Class TO Struct, FROM Class TO Interface, FROM Class TO Type, FROM Class TO Enum, member STRING, composition STRING, provenance STRING)`,
+
+		// Documentation relationships
+		`CREATE REL TABLE IF NOT EXISTS DOC_REFERENCES(FROM Function TO Function, FROM Function TO Method, FROM Function TO Class, FROM Function TO Struct, FROM Function TO Interface, FROM Function TO Type, FROM Function TO Enum, FROM Function TO Variable, FROM Function TO Property, FROM Function TO EnumMember, FROM Method TO Function, FROM Method TO Method, FROM Method TO Class, FROM Method TO Struct, FROM Method TO Interface, FROM Method TO Type, FROM Method TO Enum, FROM Method TO Variable, FROM Method TO Property, FROM Method TO EnumMember, FROM Class TO Function, FROM Class TO Method, FROM Class TO Class, FROM Class TO Struct, FROM Class TO Interface, FROM Class TO Type, FROM Class TO Enum, FROM Class TO Variable, FROM Class TO Property, FROM Class TO EnumMember, FROM Struct TO Function, FROM Struct TO Method, FROM Struct TO Class, FROM Struct TO Struct, FROM Struct TO Interface, FROM Struct TO Type, FROM Struct TO Enum, FROM Struct TO Variable, FROM Struct TO Property, FROM Struct TO EnumMember, FROM Interface TO Function, FROM Interface TO Method, FROM Interface TO Class, FROM Interface TO Struct, FROM Interface TO Interface, FROM Interface TO Type, FROM Interface TO Enum, FROM Interface TO Variable, FROM Interface TO Property, FROM Interface TO EnumMember, FROM Type TO Function, FROM Type TO Method, FROM Type TO Class, FROM Type TO Struct, FROM Type TO Interface, FROM Type TO Type, FROM Type TO Enum, FROM Type TO Variable, FROM Type TO Property, FROM Type TO EnumMember, FROM Enum TO Function, FROM Enum TO Method, FROM Enum TO Class, FROM Enum TO Struct, FROM Enum TO Interface, FROM Enum TO Type, FROM Enum TO Enum, FROM Enum TO Variable, FROM Enum TO Property, FROM Enum TO EnumMember, FROM Variable TO Function, FROM Variable TO Method, FROM Variable TO Class, FROM Variable TO Struct, FROM Variable TO Interface, FROM Variable TO Type, FROM Variable TO Enum, FROM Variable TO Variable, FROM Variable TO Property, FROM Variable TO EnumMember, text STRING, line INT64, provenance STRING)`,
+
+		// Command-line relationships
+		`CREATE REL TABLE IF NOT EXISTS DEFINES_FLAG(FROM Function TO CLIFlag, FROM Method TO CLIFlag, library STRING, provenance STRING)`,
+
+		// Shared state relationships
+		`CREATE REL TABLE IF NOT EXISTS READS(FROM Function TO Variable, FROM Method TO Variable, FROM Function TO Property, FROM Method TO Property, locked BOOLEAN, concurrent BOOLEAN, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS WRITES(FROM Function TO Variable, FROM Method TO Variable, FROM Function TO Property, FROM Method TO Property, locked BOOLEAN, concurrent BOOLEAN, provenance STRING)`,
+		`CREATE REL TABLE IF NOT EXISTS ALTERNATIVE_TO(FROM Import TO Import, FROM Function TO Function, FROM Method TO Method, FROM Struct TO Struct, FROM Interface TO Interface, FROM Type TO Type, FROM Variable TO Variable, condition STRING, provenance STRING)`,
 	}
 
 	fmt.Println("Initializing database schema...")
 	for _, query := range queries {
-		_, err := kdb.Connection.Query(query)
+		err := kdb.execute(query)
 		if err != nil {
 			return fmt.Errorf("failed to execute schema query '%s': %w", query, err)
 		}
 	}
+	if err := kdb.migrateSchema(queries); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
 
 	fmt.Println("Database schema initialized successfully.")
 	return nil
 }
 
+// execute runs a query whose result is not needed. The result is closed
+// right away rather than by its finalizer, which crashes if it runs after the
+// database is closed.
+func (kdb *KuzuDatabase) execute(query string) error {
+	result, err := kdb.Connection.Query(query)
+	if result != nil {
+		result.Close()
+	}
+	return err
+}
+
 // executePreparedStatement is a helper to prepare and execute a query with parameters.
 func (kdb *KuzuDatabase) executePreparedStatement(query string, params map[string]interface{}) error {
 	stmt, err := kdb.Connection.Prepare(query)
@@ -272,6 +357,33 @@ func (kdb *KuzuDatabase) AddFileNode(path, name, language string) error {
 	return kdb.executePreparedStatement(query, params)
 }
 
+// DeleteFileNodes removes a file's File node and every entity node whose
+// file_path is the file, together with their relationships. Checkpointed
+// builds use it to clear a partially stored batch before storing it again.
+func (kdb *KuzuDatabase) DeleteFileNodes(path string) error {
+	params := map[string]interface{}{"path": path}
+	if err := kdb.executePreparedStatement("MATCH (n) WHERE n.file_path = $path DETACH DELETE n", params); err != nil {
+		return fmt.Errorf("failed to delete entities of %s: %w", path, err)
+	}
+	if err := kdb.executePreparedStatement("MATCH (f:File {path: $path}) DETACH DELETE f", params); err != nil {
+		return fmt.Errorf("failed to delete file node %s: %w", path, err)
+	}
+	return nil
+}
+
+// DeleteFileRelationships removes the relationships whose source is a file's
+// File node or one of its entities, leaving the nodes in place
+func (kdb *KuzuDatabase) DeleteFileRelationships(path string) error {
+	params := map[string]interface{}{"path": path}
+	if err := kdb.executePreparedStatement("MATCH (n)-[r]->() WHERE n.file_path = $path DELETE r", params); err != nil {
+		return fmt.Errorf("failed to delete relationships from %s: %w", path, err)
+	}
+	if err := kdb.executePreparedStatement("MATCH (f:File {path: $path})-[r]->() DELETE r", params); err != nil {
+		return fmt.Errorf("failed to delete relationships from file node %s: %w", path, err)
+	}
+	return nil
+}
+
 // AddClassNode adds a class node to the graph.
 func (kdb *KuzuDatabase) AddClassNode(id, name, signature, filePath string) error {
 	query := `CREATE (c:Class {id: $id, name: $name, signature: $signature, file_path: $file_path})`
@@ -347,34 +459,39 @@ func (kdb *KuzuDatabase) AddInheritsRel(childId, parentName string) error {
 	return kdb.executePreparedStatement(query, params)
 }
 
-// ExecuteQuery executes a query and returns the result as a string.
+// ExecuteQuery executes a query and returns the result as a string, one
+// tab-pipe-delimited line per row. Use QueryRows for typed values or
+// ExecuteQueryFormat for other formats.
 func (kdb *KuzuDatabase) ExecuteQuery(query string) (string, error) {
-	result, err := kdb.Connection.Query(query)
+	rows, err := kdb.QueryRows(query)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute query: %w", err)
+		return "", err
 	}
-	defer result.Close()
+	return rows.text(), nil
+}
 
-	var resultBuilder strings.Builder
-	for result.HasNext() {
-		tuple, err := result.Next()
-		if err != nil {
-			return "", fmt.Errorf("failed to get next tuple: %w", err)
-		}
-		var rowItems []string
-		for i := uint64(0); ; i++ {
-			val, err := tuple.GetValue(i)
-			if err != nil {
-				// Assumes error means we are out of bounds.
-				break
-			}
-			rowItems = append(rowItems, fmt.Sprintf("%v", val))
-		}
-		resultBuilder.WriteString(strings.Join(rowItems, "\t|\t"))
-		resultBuilder.WriteString("\n")
+// ExecuteQueryContext executes a query like ExecuteQuery, interrupting it if ctx
+// is cancelled before the query completes.
+func (kdb *KuzuDatabase) ExecuteQueryContext(ctx context.Context, query string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("query cancelled: %w", err)
 	}
 
-	return resultBuilder.String(), nil
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			kdb.Connection.Interrupt()
+		case <-done:
+		}
+	}()
+
+	result, err := kdb.ExecuteQuery(query)
+	if err != nil && ctx.Err() != nil {
+		return "", fmt.Errorf("query cancelled: %w", ctx.Err())
+	}
+	return result, err
 }
 
 // GetSchema returns the database schema as a formatted string.
@@ -408,15 +525,34 @@ func (kdb *KuzuDatabase) GetSchema() (string, error) {
 	return schemaBuilder.String(), nil
 }
 
+// TableNames returns the names of the node and relationship tables of the
+// schema
+func (kdb *KuzuDatabase) TableNames() (nodeTables, relTables []string, err error) {
+	rows, err := kdb.QueryRows(`CALL SHOW_TABLES() RETURN name, type`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	for _, row := range rows.Rows {
+		name := fmt.Sprintf("%v", row[0])
+		switch fmt.Sprintf("%v", row[1]) {
+		case "NODE":
+			nodeTables = append(nodeTables, name)
+		case "REL":
+			relTables = append(relTables, name)
+		}
+	}
+	return nodeTables, relTables, nil
+}
+
 // StoreEntity stores an entity in the database
 func (kdb *KuzuDatabase) StoreEntity(entity *entities.Entity) error {
 	var query string
 
-	// Escape quotes in strings for safe SQL
-	safeName := strings.ReplaceAll(entity.Name, "\"", "\\\"")
-	safeSignature := strings.ReplaceAll(entity.Signature, "\"", "\\\"")
-	safeBody := strings.ReplaceAll(entity.Body, "\"", "\\\"")
-	safeFilePath := strings.ReplaceAll(entity.FilePath, "\"", "\\\"")
+	// Escape backslashes and quotes in strings for safe Cypher
+	safeName := escapeString(entity.Name)
+	safeSignature := escapeString(entity.Signature)
+	safeBody := escapeString(entity.Body)
+	safeFilePath := escapeString(entity.FilePath)
 
 	switch entity.Type {
 	case entities.EntityTypeFunction:
@@ -459,8 +595,11 @@ func (kdb *KuzuDatabase) StoreEntity(entity *entities.Entity) error {
 		}
 		safePath := strings.ReplaceAll(fmt.Sprintf("%v", path), "\"", "\\\"")
 		safeAlias := strings.ReplaceAll(fmt.Sprintf("%v", alias), "\"", "\\\"")
-		query = fmt.Sprintf(`CREATE (imp:Import {id: "%s", name: "%s", path: "%s", alias: "%s", file_path: "%s"})`,
-			entity.ID, safeName, safePath, safeAlias, safeFilePath)
+		conditional, _ := entity.GetProperty("conditional").(bool)
+		condition, _ := entity.GetProperty("condition").(string)
+		safeCondition := strings.ReplaceAll(strings.ReplaceAll(condition, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (imp:Import {id: "%s", name: "%s", path: "%s", alias: "%s", file_path: "%s", conditional: %t, condition: "%s"})`,
+			entity.ID, safeName, safePath, safeAlias, safeFilePath, conditional, safeCondition)
 	case entities.EntityTypeVariable:
 		varType := entity.GetProperty("type")
 		value := entity.GetProperty("value")
@@ -474,6 +613,15 @@ func (kdb *KuzuDatabase) StoreEntity(entity *entities.Entity) error {
 		safeValue := strings.ReplaceAll(fmt.Sprintf("%v", value), "\"", "\\\"")
 		query = fmt.Sprintf(`CREATE (v:Variable {id: "%s", name: "%s", type: "%s", value: "%s", file_path: "%s"})`,
 			entity.ID, safeName, safeType, safeValue, safeFilePath)
+	case entities.EntityTypeProperty:
+		propType, _ := entity.GetProperty("type").(string)
+		jsonName, _ := entity.GetProperty("json_name").(string)
+		required, _ := entity.GetProperty("required").(bool)
+		defaultValue, _ := entity.GetProperty("default").(string)
+		safeType := strings.ReplaceAll(strings.ReplaceAll(propType, "\\", "\\\\"), "\"", "\\\"")
+		safeDefault := strings.ReplaceAll(strings.ReplaceAll(defaultValue, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (p:Property {id: "%s", name: "%s", type: "%s", json_name: "%s", required: %t, default_value: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeType, escapeString(jsonName), required, safeDefault, safeFilePath)
 	
 	// Test entity types
 	case entities.EntityTypeTestFunction:
@@ -550,12 +698,256 @@ func (kdb *KuzuDatabase) StoreEntity(entity *entities.Entity) error {
 		safeDataContent := strings.ReplaceAll(dataContent, "\"", "\\\"")
 		query = fmt.Sprintf(`CREATE (f:Fixture {id: "%s", name: "%s", fixture_type: "%s", data_content: "%s", file_path: "%s"})`,
 			entity.ID, safeName, safeFixtureType, safeDataContent, safeFilePath)
-	
+
+	// Analysis diagnostics entity types
+	case entities.EntityTypeUnresolvedCall:
+		expression := ""
+		reason := ""
+		callerID := ""
+		if ex := entity.GetProperty("expression"); ex != nil {
+			expression = fmt.Sprintf("%v", ex)
+		}
+		if r := entity.GetProperty("reason"); r != nil {
+			reason = fmt.Sprintf("%v", r)
+		}
+		if c := entity.GetProperty("caller_id"); c != nil {
+			callerID = fmt.Sprintf("%v", c)
+		}
+		safeExpression := escapeString(expression)
+		query = fmt.Sprintf(`CREATE (u:UnresolvedCall {id: "%s", name: "%s", expression: "%s", reason: "%s", caller_id: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeExpression, reason, callerID, safeFilePath)
+
+	case entities.EntityTypeLogStatement:
+		level := ""
+		message := ""
+		logger := ""
+		enclosing := ""
+		if l := entity.GetProperty("level"); l != nil {
+			level = fmt.Sprintf("%v", l)
+		}
+		if m := entity.GetProperty("message"); m != nil {
+			message = fmt.Sprintf("%v", m)
+		}
+		if lg := entity.GetProperty("logger"); lg != nil {
+			logger = fmt.Sprintf("%v", lg)
+		}
+		if e := entity.GetProperty("enclosing_function"); e != nil {
+			enclosing = fmt.Sprintf("%v", e)
+		}
+		query = fmt.Sprintf(`CREATE (l:LogStatement {id: "%s", name: "%s", level: "%s", message: "%s", logger: "%s", enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, escapeString(level), escapeString(message), escapeString(logger), escapeString(enclosing), safeFilePath)
+	case entities.EntityTypeFeatureFlag:
+		provider := ""
+		if p := entity.GetProperty("provider"); p != nil {
+			provider = fmt.Sprintf("%v", p)
+		}
+		query = fmt.Sprintf(`CREATE (f:FeatureFlag {id: "%s", name: "%s", provider: "%s", file_path: "%s"})`,
+			entity.ID, safeName, escapeString(provider), safeFilePath)
+	case entities.EntityTypeNPlusOne:
+		accessKind, _ := entity.GetProperty("access_kind").(string)
+		loopKind, _ := entity.GetProperty("loop_kind").(string)
+		loopVariables, _ := entity.GetProperty("loop_variables").(string)
+		dependsOn, _ := entity.GetProperty("depends_on").(string)
+		loopStart, _ := entity.GetProperty("loop_start_line").(int)
+		loopEnd, _ := entity.GetProperty("loop_end_line").(int)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		query = fmt.Sprintf(`CREATE (n:NPlusOne {id: "%s", name: "%s", access_kind: "%s", loop_kind: "%s", loop_variables: "%s", depends_on: "%s", loop_start_line: %d, loop_end_line: %d, enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, accessKind, loopKind, escapeString(loopVariables), escapeString(dependsOn), loopStart, loopEnd, escapeString(enclosing), safeFilePath)
+	case entities.EntityTypeGeneric:
+		constraint, _ := entity.GetProperty("constraint").(string)
+		position, _ := entity.GetProperty("position").(int)
+		owner, _ := entity.GetProperty("owner").(string)
+		ownerName, _ := entity.GetProperty("owner_name").(string)
+		language, _ := entity.GetProperty("language").(string)
+		safeConstraint := strings.ReplaceAll(strings.ReplaceAll(constraint, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (g:Generic {id: "%s", name: "%s", constraint: "%s", position: %d, owner: "%s", owner_name: "%s", language: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeConstraint, position, owner, ownerName, language, safeFilePath)
+	case entities.EntityTypeCommentedCode:
+		startLine, _ := entity.GetProperty("start_line").(int)
+		endLine, _ := entity.GetProperty("end_line").(int)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		safeText := strings.ReplaceAll(strings.ReplaceAll(entity.Body, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (c:CommentedCode {id: "%s", name: "%s", text: "%s", start_line: %d, end_line: %d, enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeText, startLine, endLine, enclosing, safeFilePath)
+	case entities.EntityTypeBinding:
+		iface, _ := entity.GetProperty("interface").(string)
+		impl, _ := entity.GetProperty("implementation").(string)
+		framework, _ := entity.GetProperty("framework").(string)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		query = fmt.Sprintf(`CREATE (b:Binding {id: "%s", name: "%s", interface: "%s", implementation: "%s", framework: "%s", enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, iface, impl, framework, enclosing, safeFilePath)
+	case entities.EntityTypeControlFlowIssue:
+		issue, _ := entity.GetProperty("issue").(string)
+		line, _ := entity.GetProperty("line").(int)
+		after, _ := entity.GetProperty("after").(string)
+		message, _ := entity.GetProperty("message").(string)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		safeAfter := strings.ReplaceAll(strings.ReplaceAll(after, "\\", "\\\\"), "\"", "\\\"")
+		safeMessage := strings.ReplaceAll(strings.ReplaceAll(message, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (c:ControlFlowIssue {id: "%s", name: "%s", issue: "%s", line: %d, after: "%s", message: "%s", enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, issue, line, safeAfter, safeMessage, enclosing, safeFilePath)
+	case entities.EntityTypeMissingDoc:
+		target, _ := entity.GetProperty("entity").(string)
+		targetType, _ := entity.GetProperty("entity_type").(string)
+		line, _ := entity.GetProperty("line").(int)
+		message, _ := entity.GetProperty("message").(string)
+		safeMessage := strings.ReplaceAll(strings.ReplaceAll(message, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (m:MissingDoc {id: "%s", name: "%s", entity: "%s", entity_type: "%s", line: %d, message: "%s", file_path: "%s"})`,
+			entity.ID, safeName, target, targetType, line, safeMessage, safeFilePath)
+	case entities.EntityTypeType:
+		typeDefinition, _ := entity.GetProperty("type_definition").(string)
+		alias, _ := entity.GetProperty("alias").(bool)
+		composition, _ := entity.GetProperty("alias_composition").(string)
+		safeDefinition := strings.ReplaceAll(strings.ReplaceAll(typeDefinition, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (t:Type {id: "%s", name: "%s", type_definition: "%s", is_alias: %t, alias_composition: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeDefinition, alias, composition, safeFilePath)
+	case entities.EntityTypeCLIFlag:
+		flag, _ := entity.GetProperty("flag").(string)
+		short, _ := entity.GetProperty("short").(string)
+		flagType, _ := entity.GetProperty("flag_type").(string)
+		defaultValue, _ := entity.GetProperty("default").(string)
+		help, _ := entity.GetProperty("help").(string)
+		library, _ := entity.GetProperty("library").(string)
+		command, _ := entity.GetProperty("command").(string)
+		positional, _ := entity.GetProperty("positional").(bool)
+		read, known := entity.GetProperty("read").(bool)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		safeDefault := strings.ReplaceAll(strings.ReplaceAll(defaultValue, "\\", "\\\\"), "\"", "\\\"")
+		safeHelp := strings.ReplaceAll(strings.ReplaceAll(help, "\\", "\\\\"), "\"", "\\\"")
+		safeCommand := strings.ReplaceAll(strings.ReplaceAll(command, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (c:CLIFlag {id: "%s", name: "%s", flag: "%s", short: "%s", flag_type: "%s", default_value: "%s", help: "%s", library: "%s", command: "%s", positional: %t, value_read: %t, enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, flag, short, flagType, safeDefault, safeHelp, library, safeCommand, positional, read || !known, enclosing, safeFilePath)
+	case entities.EntityTypeRaceRisk:
+		state, _ := entity.GetProperty("state").(string)
+		variable, _ := entity.GetProperty("variable").(string)
+		goroutine, _ := entity.GetProperty("goroutine").(string)
+		accesses, _ := entity.GetProperty("accesses").(int)
+		writes, _ := entity.GetProperty("writes").(int)
+		message, _ := entity.GetProperty("message").(string)
+		line, _ := entity.GetProperty("line").(int)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		safeGoroutine := strings.ReplaceAll(strings.ReplaceAll(goroutine, "\\", "\\\\"), "\"", "\\\"")
+		safeMessage := strings.ReplaceAll(strings.ReplaceAll(message, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (r:RaceRisk {id: "%s", name: "%s", state: "%s", variable: "%s", goroutine: "%s", accesses: %d, writes: %d, message: "%s", line: %d, enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, state, variable, safeGoroutine, accesses, writes, safeMessage, line, enclosing, safeFilePath)
+	case entities.EntityTypeBlockingInAsync:
+		callee, _ := entity.GetProperty("callee").(string)
+		qualified, _ := entity.GetProperty("qualified_callee").(string)
+		kind, _ := entity.GetProperty("kind").(string)
+		suggestion, _ := entity.GetProperty("suggestion").(string)
+		message, _ := entity.GetProperty("message").(string)
+		line, _ := entity.GetProperty("line").(int)
+		asyncFunction, _ := entity.GetProperty("async_function").(string)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		safeCallee := strings.ReplaceAll(strings.ReplaceAll(callee, "\\", "\\\\"), "\"", "\\\"")
+		safeQualified := strings.ReplaceAll(strings.ReplaceAll(qualified, "\\", "\\\\"), "\"", "\\\"")
+		safeMessage := strings.ReplaceAll(strings.ReplaceAll(message, "\\", "\\\\"), "\"", "\\\"")
+		safeAsyncFunction := strings.ReplaceAll(strings.ReplaceAll(asyncFunction, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (b:BlockingInAsync {id: "%s", name: "%s", callee: "%s", qualified_callee: "%s", kind: "%s", suggestion: "%s", message: "%s", line: %d, async_function: "%s", enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeCallee, safeQualified, kind, escapeString(suggestion), safeMessage, line, safeAsyncFunction, escapeString(enclosing), safeFilePath)
+	case entities.EntityTypeStructTagIssue:
+		kind, _ := entity.GetProperty("kind").(string)
+		structName, _ := entity.GetProperty("struct").(string)
+		field, _ := entity.GetProperty("field").(string)
+		fieldID, _ := entity.GetProperty("field_id").(string)
+		tag, _ := entity.GetProperty("tag").(string)
+		message, _ := entity.GetProperty("message").(string)
+		line, _ := entity.GetProperty("line").(int)
+		safeTag := strings.ReplaceAll(strings.ReplaceAll(tag, "\\", "\\\\"), "\"", "\\\"")
+		safeMessage := strings.ReplaceAll(strings.ReplaceAll(message, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (s:StructTagIssue {id: "%s", name: "%s", kind: "%s", struct_name: "%s", field: "%s", field_id: "%s", tag: "%s", message: "%s", line: %d, file_path: "%s"})`,
+			entity.ID, safeName, kind, structName, field, fieldID, safeTag, safeMessage, line, safeFilePath)
+	case entities.EntityTypeErrorMessageIssue:
+		kind, _ := entity.GetProperty("kind").(string)
+		errorMessage, _ := entity.GetProperty("error_message").(string)
+		constructor, _ := entity.GetProperty("constructor").(string)
+		message, _ := entity.GetProperty("message").(string)
+		suggestion, _ := entity.GetProperty("suggestion").(string)
+		line, _ := entity.GetProperty("line").(int)
+		enclosing, _ := entity.GetProperty("enclosing_function").(string)
+		safeErrorMessage := strings.ReplaceAll(strings.ReplaceAll(errorMessage, "\\", "\\\\"), "\"", "\\\"")
+		safeConstructor := strings.ReplaceAll(strings.ReplaceAll(constructor, "\\", "\\\\"), "\"", "\\\"")
+		safeSuggestion := strings.ReplaceAll(strings.ReplaceAll(suggestion, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (e:ErrorMessageIssue {id: "%s", name: "%s", kind: "%s", error_message: "%s", constructor: "%s", message: "%s", suggestion: "%s", line: %d, enclosing_function: "%s", file_path: "%s"})`,
+			entity.ID, safeName, kind, safeErrorMessage, safeConstructor, escapeString(message), safeSuggestion, line, escapeString(enclosing), safeFilePath)
+	case entities.EntityTypeEnum:
+		members, _ := entity.GetProperty("members").(string)
+		safeMembers := strings.ReplaceAll(strings.ReplaceAll(members, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (e:Enum {id: "%s", name: "%s", members: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeMembers, safeFilePath)
+	case entities.EntityTypeEnumMember:
+		enum, _ := entity.GetProperty("enum").(string)
+		value, _ := entity.GetProperty("value").(string)
+		implicit, _ := entity.GetProperty("implicit").(bool)
+		position, _ := entity.GetProperty("position").(int)
+		safeValue := strings.ReplaceAll(strings.ReplaceAll(value, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (e:EnumMember {id: "%s", name: "%s", enum: "%s", value: "%s", implicit: %t, position: %d, file_path: "%s"})`,
+			entity.ID, safeName, enum, safeValue, implicit, position, safeFilePath)
+
+	// Database migration entity types
+	case entities.EntityTypeMigration:
+		tool, _ := entity.GetProperty("tool").(string)
+		version, _ := entity.GetProperty("version").(string)
+		description, _ := entity.GetProperty("description").(string)
+		direction, _ := entity.GetProperty("direction").(string)
+		revision, _ := entity.GetProperty("revision").(string)
+		downRevision, _ := entity.GetProperty("down_revision").(string)
+		order, _ := entity.GetProperty("order").(int)
+		safeDescription := strings.ReplaceAll(strings.ReplaceAll(description, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (m:Migration {id: "%s", name: "%s", tool: "%s", version: "%s", description: "%s", direction: "%s", revision: "%s", down_revision: "%s", migration_order: %d, file_path: "%s"})`,
+			entity.ID, safeName, tool, version, safeDescription, direction, revision, downRevision, order, safeFilePath)
+	case entities.EntityTypeSchemaChange:
+		operation, _ := entity.GetProperty("operation").(string)
+		table, _ := entity.GetProperty("table").(string)
+		column, _ := entity.GetProperty("column").(string)
+		index, _ := entity.GetProperty("index").(string)
+		columns, _ := entity.GetProperty("columns").(string)
+		migration, _ := entity.GetProperty("migration").(string)
+		direction, _ := entity.GetProperty("direction").(string)
+		position, _ := entity.GetProperty("position").(int)
+		safeStatement := strings.ReplaceAll(strings.ReplaceAll(entity.Signature, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (s:SchemaChange {id: "%s", name: "%s", operation: "%s", table_name: "%s", column_name: "%s", index_name: "%s", columns: "%s", statement: "%s", migration: "%s", direction: "%s", position: %d, file_path: "%s"})`,
+			entity.ID, safeName, operation, escapeString(table), escapeString(column), escapeString(index), escapeString(columns), safeStatement, escapeString(migration), direction, position, safeFilePath)
+
+	// Infrastructure-as-code entity types
+	case entities.EntityTypeResource, entities.EntityTypeDataSource:
+		blockType := ""
+		provider := ""
+		if t := entity.GetProperty("block_type"); t != nil {
+			blockType = fmt.Sprintf("%v", t)
+		}
+		if p := entity.GetProperty("provider"); p != nil {
+			provider = fmt.Sprintf("%v", p)
+		}
+		if entity.Type == entities.EntityTypeResource {
+			query = fmt.Sprintf(`CREATE (r:Resource {id: "%s", name: "%s", resource_type: "%s", provider: "%s", file_path: "%s"})`,
+				entity.ID, safeName, blockType, provider, safeFilePath)
+		} else {
+			query = fmt.Sprintf(`CREATE (d:DataSource {id: "%s", name: "%s", data_type: "%s", provider: "%s", file_path: "%s"})`,
+				entity.ID, safeName, blockType, provider, safeFilePath)
+		}
+	case entities.EntityTypeModuleCall:
+		source := ""
+		if src := entity.GetProperty("source"); src != nil {
+			source = fmt.Sprintf("%v", src)
+		}
+		safeSource := strings.ReplaceAll(source, "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (m:ModuleCall {id: "%s", name: "%s", source: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeSource, safeFilePath)
+	case entities.EntityTypeOutput:
+		value := ""
+		if v := entity.GetProperty("value"); v != nil {
+			value = fmt.Sprintf("%v", v)
+		}
+		safeValue := strings.ReplaceAll(strings.ReplaceAll(value, "\\", "\\\\"), "\"", "\\\"")
+		query = fmt.Sprintf(`CREATE (o:Output {id: "%s", name: "%s", value: "%s", file_path: "%s"})`,
+			entity.ID, safeName, safeValue, safeFilePath)
+
 	default:
 		return fmt.Errorf("unsupported entity type: %s", entity.Type)
 	}
 
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	return err
 }
 
@@ -590,6 +982,8 @@ func (kdb *KuzuDatabase) StoreRelationship(rel *entities.Relationship) error {
 		return kdb.storeDefinesRelationship(rel)
 	case entities.RelationshipTypeUses:
 		return kdb.storeUsesRelationship(rel)
+	case entities.RelationshipTypeReferences:
+		return kdb.storeReferencesRelationship(rel)
 	
 	// Test Coverage relationships
 	case entities.RelationshipTypeTests:
@@ -620,22 +1014,335 @@ func (kdb *KuzuDatabase) StoreRelationship(rel *entities.Relationship) error {
 		return kdb.storeSkipsRelationship(rel)
 	case entities.RelationshipTypeDepends:
 		return kdb.storeDependsRelationship(rel)
+
+	// Error-flow relationships
+	case entities.RelationshipTypePropagatesError, entities.RelationshipTypeHandlesError, entities.RelationshipTypeIgnoresError:
+		return kdb.storeErrorFlowRelationship(rel)
+
+	// Feature-flag relationships
+	case entities.RelationshipTypeChecksFlag:
+		return kdb.storeChecksFlagRelationship(rel)
+
+	// Control-flow relationships
+	case entities.RelationshipTypeHasIssue:
+		return kdb.storeHasIssueRelationship(rel)
+
+	// Generic constraint relationships
+	case entities.RelationshipTypeConstrains:
+		return kdb.storeConstrainsRelationship(rel)
+
+	// Dependency-injection bindings
+	case entities.RelationshipTypeProvides, entities.RelationshipTypeBoundTo:
+		return kdb.storeBindingRelationship(rel)
+
+	// Infrastructure-as-code relationships
+	case entities.RelationshipTypeDependsOn:
+		return kdb.storeDependsOnRelationship(rel)
+
+	// Database migration relationships
+	case entities.RelationshipTypeAffects:
+		return kdb.storeAffectsRelationship(rel)
+
+	// Type alias relationships
+	case entities.RelationshipTypeAliases:
+		return kdb.storeAliasesRelationship(rel)
+
+	// Documentation relationships
+	case entities.RelationshipTypeDocReferences:
+		return kdb.storeDocReferencesRelationship(rel)
+
+	// Command-line relationships
+	case entities.RelationshipTypeDefinesFlag:
+		return kdb.storeDefinesFlagRelationship(rel)
+
+	// Shared state relationships
+	case entities.RelationshipTypeReads, entities.RelationshipTypeWrites:
+		return kdb.storeAccessRelationship(rel)
+
+	// Conditional code relationships
+	case entities.RelationshipTypeAlternativeTo:
+		return kdb.storeAlternativeToRelationship(rel)
 	
 	default:
 		return fmt.Errorf("unsupported relationship type: %s", rel.Type)
 	}
 }
 
+// storeDependsOnRelationship stores DEPENDS_ON relationships between
+// infrastructure blocks with the reference expression that created them
+func (kdb *KuzuDatabase) storeDependsOnRelationship(rel *entities.Relationship) error {
+	reference := ""
+	if r := rel.GetProperty("reference"); r != nil {
+		reference = fmt.Sprintf("%v", r)
+	}
+	explicit := false
+	if e, ok := rel.GetProperty("explicit").(bool); ok {
+		explicit = e
+	}
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:DEPENDS_ON {reference: "%s", explicit: %t, provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID,
+		strings.ReplaceAll(reference, "\"", "\\\""), explicit, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store DEPENDS_ON relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeAffectsRelationship stores AFFECTS relationships from a schema change
+// to the model of the table it changes
+func (kdb *KuzuDatabase) storeAffectsRelationship(rel *entities.Relationship) error {
+	table, _ := rel.GetProperty("table").(string)
+	operation, _ := rel.GetProperty("operation").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:AFFECTS {table_name: "%s", operation: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID,
+		strings.ReplaceAll(table, "\"", "\\\""), operation, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store AFFECTS relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeAliasesRelationship stores ALIASES relationships from a type alias
+// to a type it names
+func (kdb *KuzuDatabase) storeAliasesRelationship(rel *entities.Relationship) error {
+	member, _ := rel.GetProperty("member").(string)
+	composition, _ := rel.GetProperty("composition").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:ALIASES {member: "%s", composition: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID,
+		strings.ReplaceAll(strings.ReplaceAll(member, "\\", "\\\\"), "\"", "\\\""), composition, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store ALIASES relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeDocReferencesRelationship stores DOC_REFERENCES relationships from a
+// documented declaration to a declaration its doc comment links to
+func (kdb *KuzuDatabase) storeDocReferencesRelationship(rel *entities.Relationship) error {
+	text, _ := rel.GetProperty("text").(string)
+	line, _ := rel.GetProperty("line").(int)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:DOC_REFERENCES {text: "%s", line: %d, provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID,
+		strings.ReplaceAll(strings.ReplaceAll(text, "\\", "\\\\"), "\"", "\\\""), line, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store DOC_REFERENCES relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeDefinesFlagRelationship stores DEFINES_FLAG relationships from a
+// function to a command-line flag it defines
+func (kdb *KuzuDatabase) storeDefinesFlagRelationship(rel *entities.Relationship) error {
+	library, _ := rel.GetProperty("library").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:DEFINES_FLAG {library: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, library, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store DEFINES_FLAG relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeAlternativeToRelationship stores ALTERNATIVE_TO relationships between
+// imports or declarations used instead of each other under different
+// conditions
+func (kdb *KuzuDatabase) storeAlternativeToRelationship(rel *entities.Relationship) error {
+	condition, _ := rel.GetProperty("condition").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:ALTERNATIVE_TO {condition: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID,
+		strings.ReplaceAll(strings.ReplaceAll(condition, "\\", "\\\\"), "\"", "\\\""), provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store ALTERNATIVE_TO relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeAccessRelationship stores READS and WRITES relationships from a
+// function to a package-level variable or field it uses
+func (kdb *KuzuDatabase) storeAccessRelationship(rel *entities.Relationship) error {
+	locked, _ := rel.GetProperty("locked").(bool)
+	concurrent, _ := rel.GetProperty("concurrent").(bool)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:%s {locked: %t, concurrent: %t, provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, rel.Type, locked, concurrent, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store %s relationship from %s:%s to %s:%s: %w",
+			rel.Type, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeErrorFlowRelationship stores PROPAGATES_ERROR, HANDLES_ERROR and
+// IGNORES_ERROR relationships. Each table only has the columns its type uses.
+func (kdb *KuzuDatabase) storeErrorFlowRelationship(rel *entities.Relationship) error {
+	exceptionTypes := ""
+	if t, ok := rel.GetProperty("exception_types").(string); ok {
+		exceptionTypes = strings.ReplaceAll(t, "\"", "\\\"")
+	}
+
+	props := fmt.Sprintf(`exception_types: "%s", provenance: "%s"`, exceptionTypes, provenanceString(rel))
+	switch rel.Type {
+	case entities.RelationshipTypePropagatesError:
+		wrapped, _ := rel.GetProperty("wrapped").(bool)
+		props = fmt.Sprintf("wrapped: %t, %s", wrapped, props)
+	case entities.RelationshipTypeIgnoresError:
+		reason, _ := rel.GetProperty("reason").(string)
+		props = fmt.Sprintf(`reason: "%s", %s`, reason, props)
+	}
+
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:%s {%s}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, rel.Type, props)
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store %s relationship from %s:%s to %s:%s: %w",
+			rel.Type, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeConstrainsRelationship stores CONSTRAINS relationships from a type
+// parameter to a type named in its constraint
+func (kdb *KuzuDatabase) storeConstrainsRelationship(rel *entities.Relationship) error {
+	constraintType, _ := rel.GetProperty("constraint_type").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:CONSTRAINS {constraint_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, constraintType, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store CONSTRAINS relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeBindingRelationship stores the PROVIDES and BOUND_TO relationships
+// from a dependency-injection binding to its interface and implementation
+func (kdb *KuzuDatabase) storeBindingRelationship(rel *entities.Relationship) error {
+	framework, _ := rel.GetProperty("framework").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:%s {framework: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, rel.Type, framework, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store %s relationship from %s:%s to %s:%s: %w",
+			rel.Type, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeHasIssueRelationship stores HAS_ISSUE relationships from a function
+// to a ControlFlowIssue or BlockingInAsync found in it, and from a struct
+// field to a StructTagIssue
+func (kdb *KuzuDatabase) storeHasIssueRelationship(rel *entities.Relationship) error {
+	issue, _ := rel.GetProperty("issue").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:HAS_ISSUE {issue: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, issue, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store HAS_ISSUE relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// storeChecksFlagRelationship stores CHECKS_FLAG relationships from a function
+// to the feature flag it evaluates
+func (kdb *KuzuDatabase) storeChecksFlagRelationship(rel *entities.Relationship) error {
+	provider, _ := rel.GetProperty("provider").(string)
+	callee, _ := rel.GetProperty("callee").(string)
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:CHECKS_FLAG {provider: "%s", callee: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID,
+		provider, strings.ReplaceAll(callee, "\"", "\\\""), provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store CHECKS_FLAG relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
+// provenanceString formats a relationship's provenance for storage as an escaped
+// string property, or "" when the analyzer recorded none
+func provenanceString(rel *entities.Relationship) string {
+	if rel.Provenance == nil {
+		return ""
+	}
+	return escapeString(rel.Provenance.String())
+}
+
+// escapeString escapes backslashes and double quotes so that s can be put
+// inside a double-quoted Cypher string literal
+func escapeString(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\\", "\\\\"), "\"", "\\\"")
+}
+
 // storeCAllsRelationship stores CALLS relationships with proper type-aware queries
 func (kdb *KuzuDatabase) storeCAllsRelationship(rel *entities.Relationship) error {
 	// Build type-aware query based on source and target types
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:CALLS]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:CALLS {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store CALLS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -645,14 +1352,14 @@ func (kdb *KuzuDatabase) storeCAllsRelationship(rel *entities.Relationship) erro
 
 // storeContainsRelationship stores Contains relationships with proper type-aware queries
 func (kdb *KuzuDatabase) storeContainsRelationship(rel *entities.Relationship) error {
-	// Contains relationships are typically File -> Entity
+	// Contains relationships are File -> Entity, or a function -> the functions nested in it
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:Contains]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:Contains {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store Contains relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -665,10 +1372,10 @@ func (kdb *KuzuDatabase) storeInheritsRelationship(rel *entities.Relationship) e
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:INHERITS]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:INHERITS {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store INHERITS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -681,10 +1388,10 @@ func (kdb *KuzuDatabase) storeEmbedsRelationship(rel *entities.Relationship) err
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:EMBEDS {source_id: "%s", target_id: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, rel.SourceID, rel.TargetID)
+		CREATE (source)-[:EMBEDS {source_id: "%s", target_id: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, rel.SourceID, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store EMBEDS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -697,10 +1404,10 @@ func (kdb *KuzuDatabase) storeImplementsRelationship(rel *entities.Relationship)
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:IMPLEMENTS {source_id: "%s", target_id: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, rel.SourceID, rel.TargetID)
+		CREATE (source)-[:IMPLEMENTS {source_id: "%s", target_id: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, rel.SourceID, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store IMPLEMENTS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -713,10 +1420,10 @@ func (kdb *KuzuDatabase) storeDefinesRelationship(rel *entities.Relationship) er
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:DEFINES]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:DEFINES {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store DEFINES relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -729,10 +1436,10 @@ func (kdb *KuzuDatabase) storeUsesRelationship(rel *entities.Relationship) error
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:USES]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:USES {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store USES relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -740,6 +1447,23 @@ func (kdb *KuzuDatabase) storeUsesRelationship(rel *entities.Relationship) error
 	return nil
 }
 
+// storeReferencesRelationship stores REFERENCES relationships from a
+// declaration to a type or variable it uses
+func (kdb *KuzuDatabase) storeReferencesRelationship(rel *entities.Relationship) error {
+	query := fmt.Sprintf(`
+		MATCH (source:%s {id: "%s"})
+		MATCH (target:%s {id: "%s"})
+		CREATE (source)-[:REFERENCES {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
+
+	err := kdb.execute(query)
+	if err != nil {
+		return fmt.Errorf("failed to store REFERENCES relationship from %s:%s to %s:%s: %w",
+			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
+	}
+	return nil
+}
+
 // Test Coverage relationship storage methods
 
 // storeTestsRelationship stores TESTS relationships with confidence score
@@ -748,10 +1472,10 @@ func (kdb *KuzuDatabase) storeTestsRelationship(rel *entities.Relationship) erro
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:TESTS {confidence_score: %f}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, confidenceScore)
+		CREATE (source)-[:TESTS {confidence_score: %f, provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, confidenceScore, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store TESTS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -765,10 +1489,10 @@ func (kdb *KuzuDatabase) storeCoversRelationship(rel *entities.Relationship) err
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:COVERS {coverage_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, coverageType)
+		CREATE (source)-[:COVERS {coverage_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, coverageType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store COVERS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -782,10 +1506,10 @@ func (kdb *KuzuDatabase) storeMocksRelationship(rel *entities.Relationship) erro
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:MOCKS {mock_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, mockType)
+		CREATE (source)-[:MOCKS {mock_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, mockType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store MOCKS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -800,10 +1524,10 @@ func (kdb *KuzuDatabase) storeSetupForRelationship(rel *entities.Relationship) e
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:SETUP_FOR]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:SETUP_FOR {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store SETUP_FOR relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -816,10 +1540,10 @@ func (kdb *KuzuDatabase) storeTeardownForRelationship(rel *entities.Relationship
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:TEARDOWN_FOR]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:TEARDOWN_FOR {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store TEARDOWN_FOR relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -833,10 +1557,10 @@ func (kdb *KuzuDatabase) storeAssertsRelationship(rel *entities.Relationship) er
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:ASSERTS {assertion_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, assertionType)
+		CREATE (source)-[:ASSERTS {assertion_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, assertionType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store ASSERTS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -853,10 +1577,10 @@ func (kdb *KuzuDatabase) storeVerifiesRelationship(rel *entities.Relationship) e
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:VERIFIES {verification_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, verificationType)
+		CREATE (source)-[:VERIFIES {verification_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, verificationType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store VERIFIES relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -873,10 +1597,10 @@ func (kdb *KuzuDatabase) storeSpiesRelationship(rel *entities.Relationship) erro
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:SPIES {spy_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, spyType)
+		CREATE (source)-[:SPIES {spy_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, spyType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store SPIES relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -893,10 +1617,10 @@ func (kdb *KuzuDatabase) storeStubsRelationship(rel *entities.Relationship) erro
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:STUBS {stub_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, stubType)
+		CREATE (source)-[:STUBS {stub_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, stubType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store STUBS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -913,10 +1637,10 @@ func (kdb *KuzuDatabase) storeFixturesRelationship(rel *entities.Relationship) e
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:FIXTURES {fixture_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, fixtureType)
+		CREATE (source)-[:FIXTURES {fixture_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, fixtureType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store FIXTURES relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -929,10 +1653,10 @@ func (kdb *KuzuDatabase) storeRunsTestRelationship(rel *entities.Relationship) e
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:RUNS_TEST]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID)
+		CREATE (source)-[:RUNS_TEST {provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store RUNS_TEST relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -949,10 +1673,10 @@ func (kdb *KuzuDatabase) storeGroupsTestsRelationship(rel *entities.Relationship
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:GROUPS_TESTS {group_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, groupType)
+		CREATE (source)-[:GROUPS_TESTS {group_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, groupType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store GROUPS_TESTS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -969,10 +1693,10 @@ func (kdb *KuzuDatabase) storeSkipsRelationship(rel *entities.Relationship) erro
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:SKIPS {skip_condition: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, skipCondition)
+		CREATE (source)-[:SKIPS {skip_condition: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, skipCondition, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store SKIPS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
@@ -989,13 +1713,14 @@ func (kdb *KuzuDatabase) storeDependsRelationship(rel *entities.Relationship) er
 	query := fmt.Sprintf(`
 		MATCH (source:%s {id: "%s"})
 		MATCH (target:%s {id: "%s"})
-		CREATE (source)-[:DEPENDS {dependency_type: "%s"}]->(target)
-	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, dependencyType)
+		CREATE (source)-[:DEPENDS {dependency_type: "%s", provenance: "%s"}]->(target)
+	`, rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, dependencyType, provenanceString(rel))
 	
-	_, err := kdb.Connection.Query(query)
+	err := kdb.execute(query)
 	if err != nil {
 		return fmt.Errorf("failed to store DEPENDS relationship from %s:%s to %s:%s: %w", 
 			rel.SourceType, rel.SourceID, rel.TargetType, rel.TargetID, err)
 	}
 	return nil
 }
+
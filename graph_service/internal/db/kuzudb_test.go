@@ -0,0 +1,104 @@
+package db
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// newTestDatabase opens a database with the code graph schema in a
+// temporary directory
+func newTestDatabase(t *testing.T) *KuzuDatabase {
+	t.Helper()
+	kdb, err := NewKuzuDatabase(filepath.Join(t.TempDir(), "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(kdb.Close)
+	if err := kdb.CreateSchema(); err != nil {
+		t.Fatal(err)
+	}
+	return kdb
+}
+
+func TestStoreEntityEscapesStrings(t *testing.T) {
+	kdb := newTestDatabase(t)
+
+	tests := []struct {
+		name       string
+		entityType entities.EntityType
+		table      string
+		property   string
+		value      string
+	}{
+		{`strings.Split("a\\b", "\\")`, entities.EntityTypeUnresolvedCall, "UnresolvedCall", "expression", `strings.Split("a\\b", "\\")`},
+		{`re.compile("\d+")`, entities.EntityTypeUnresolvedCall, "UnresolvedCall", "expression", `re.compile("\d+")`},
+		{"log.info", entities.EntityTypeLogStatement, "LogStatement", "logger", `loggers["a\\b"]`},
+		{"log.info", entities.EntityTypeLogStatement, "LogStatement", "message", `path C:\tmp\ not "found"\`},
+		{"ID", entities.EntityTypeProperty, "Property", "json_name", `a"b\`},
+		{"new-checkout", entities.EntityTypeFeatureFlag, "FeatureFlag", "provider", `flags["x\y"]`},
+		{"db.query", entities.EntityTypeNPlusOne, "NPlusOne", "loop_variables", `row, "key\"`},
+		{"db.query", entities.EntityTypeNPlusOne, "NPlusOne", "depends_on", `ids[strings.Trim(k, "\")]`},
+		{"time.Sleep", entities.EntityTypeBlockingInAsync, "BlockingInAsync", "suggestion", `use "await asyncio.sleep(...)" instead of C:\\sleep`},
+		{"errors.New", entities.EntityTypeErrorMessageIssue, "ErrorMessageIssue", "message", `error message "Failed\\n" should not end with punctuation`},
+	}
+	for i, tt := range tests {
+		id := fmt.Sprintf("escape-%d", i)
+		entity := entities.NewSpanEntity(id, tt.name, tt.entityType, `dir\file "x".py`, 0, 1, 1, 1)
+		entity.SetProperty(tt.property, tt.value)
+		if err := kdb.StoreEntity(entity); err != nil {
+			t.Errorf("StoreEntity(%s %q) = %v", tt.table, tt.value, err)
+			continue
+		}
+
+		rows, err := kdb.QueryRows(fmt.Sprintf(`MATCH (n:%s {id: "%s"}) RETURN n.name, n.%s, n.file_path`, tt.table, id, tt.property))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows.Rows) != 1 {
+			t.Errorf("%s %s: got %d rows, want 1", tt.table, id, len(rows.Rows))
+			continue
+		}
+		want := []any{tt.name, tt.value, `dir\file "x".py`}
+		for j, got := range rows.Rows[0] {
+			if got != want[j] {
+				t.Errorf("%s %s column %s = %q, want %q", tt.table, id, rows.Columns[j], got, want[j])
+			}
+		}
+	}
+}
+
+func TestStoreSchemaChangeEscapesIdentifiers(t *testing.T) {
+	kdb := newTestDatabase(t)
+
+	// Quoted SQL identifiers may contain quotes and backslashes
+	entity := entities.NewSpanEntity("schema-change", "add_column", entities.EntityTypeSchemaChange, "migrations/001.sql", 0, 1, 1, 1)
+	entity.Signature = `ALTER TABLE "we""ird\" ADD COLUMN "a\b" text`
+	want := map[string]string{
+		"table":   `we"ird\`,
+		"column":  `a\b`,
+		"index":   `idx_"x"`,
+		"columns": `"a\b", c`,
+	}
+	for property, value := range want {
+		entity.SetProperty(property, value)
+	}
+	if err := kdb.StoreEntity(entity); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := kdb.QueryRows(`MATCH (s:SchemaChange {id: "schema-change"}) RETURN s.table_name, s.column_name, s.index_name, s.columns, s.statement`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows.Rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows.Rows))
+	}
+	for i, value := range []string{want["table"], want["column"], want["index"], want["columns"], entity.Signature} {
+		if got := rows.Rows[0][i]; got != value {
+			t.Errorf("%s = %q, want %q", rows.Columns[i], got, value)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// createTablePattern matches the CREATE TABLE statements of CreateSchema
+var createTablePattern = regexp.MustCompile(`^CREATE (NODE|REL) TABLE IF NOT EXISTS (\w+)\((.*)\)$`)
+
+// tableDefinition is what an existing database can be migrated to from a
+// CREATE TABLE statement: the table's columns and, for relationship tables,
+// its FROM/TO pairs
+type tableDefinition struct {
+	name      string
+	statement string
+	columns   [][2]string // name and type
+	pairs     [][2]string // FROM and TO node tables
+}
+
+// parseTableDefinition parses a CREATE TABLE statement of CreateSchema
+func parseTableDefinition(statement string) (*tableDefinition, bool) {
+	match := createTablePattern.FindStringSubmatch(strings.TrimSpace(statement))
+	if match == nil {
+		return nil, false
+	}
+
+	def := &tableDefinition{name: match[2], statement: statement}
+	for _, item := range splitTopLevel(match[3]) {
+		fields := strings.Fields(item)
+		switch {
+		case len(fields) == 4 && fields[0] == "FROM" && fields[2] == "TO":
+			def.pairs = append(def.pairs, [2]string{fields[1], fields[3]})
+		case len(fields) >= 2 && fields[0] == "PRIMARY" && fields[1] == "KEY":
+		case len(fields) == 2:
+			def.columns = append(def.columns, [2]string{fields[0], fields[1]})
+		}
+	}
+	return def, true
+}
+
+// splitTopLevel splits a column list on the commas that are not inside
+// parentheses, as in "id STRING, PRIMARY KEY (id)"
+func splitTopLevel(list string) []string {
+	items := make([]string, 0)
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(items, strings.TrimSpace(list[start:]))
+}
+
+// migrateSchema brings the tables of a database created by an earlier
+// version up to date. CREATE TABLE IF NOT EXISTS leaves an existing table
+// untouched, so columns added to a definition since are added here with
+// ALTER TABLE; they read as NULL on the rows stored before. Columns removed
+// from a definition are dropped, and FROM/TO pairs added to a relationship
+// table are added like columns.
+func (kdb *KuzuDatabase) migrateSchema(statements []string) error {
+	nodeTables, relTables, err := kdb.TableNames()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for _, name := range append(nodeTables, relTables...) {
+		existing[name] = true
+	}
+
+	for _, statement := range statements {
+		def, ok := parseTableDefinition(statement)
+		if !ok || !existing[def.name] {
+			continue
+		}
+		if err := kdb.migrateColumns(def); err != nil {
+			return err
+		}
+		if err := kdb.addMissingPairs(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateColumns adds the columns of a definition that its table lacks and
+// drops those the definition no longer has. A column left behind could clash
+// with a column of the same name and another type in a different table,
+// which makes Kuzu reject every pattern with an unlabeled node.
+func (kdb *KuzuDatabase) migrateColumns(def *tableDefinition) error {
+	rows, err := kdb.QueryRows(fmt.Sprintf(`CALL TABLE_INFO('%s') RETURN name`, def.name))
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", def.name, err)
+	}
+	columns := make(map[string]bool)
+	for _, row := range rows.Rows {
+		columns[fmt.Sprintf("%v", row[0])] = true
+	}
+
+	defined := make(map[string]bool, len(def.columns))
+	for _, column := range def.columns {
+		defined[column[0]] = true
+	}
+	for _, row := range rows.Rows {
+		column := fmt.Sprintf("%v", row[0])
+		if defined[column] {
+			continue
+		}
+		if err := kdb.execute(fmt.Sprintf(`ALTER TABLE %s DROP %s`, def.name, column)); err != nil {
+			return fmt.Errorf("failed to drop column %s of %s: %w", column, def.name, err)
+		}
+	}
+
+	for _, column := range def.columns {
+		if columns[column[0]] {
+			continue
+		}
+		query := fmt.Sprintf(`ALTER TABLE %s ADD %s %s`, def.name, column[0], column[1])
+		if err := kdb.execute(query); err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", column[0], def.name, err)
+		}
+	}
+	return nil
+}
+
+// addMissingPairs adds the FROM/TO pairs of a relationship table definition
+// that its table lacks, such as the node tables a redefined table may now
+// connect. A Kuzu version that cannot add a pair to an existing table gets the
+// table dropped and created again instead. Either way the relationships of
+// the new pairs were never stored, so RelationshipsMigrated is set.
+func (kdb *KuzuDatabase) addMissingPairs(def *tableDefinition) error {
+	if len(def.pairs) == 0 {
+		return nil
+	}
+
+	rows, err := kdb.QueryRows(fmt.Sprintf(`CALL SHOW_CONNECTION('%s') RETURN *`, def.name))
+	if err != nil {
+		return fmt.Errorf("failed to read connections of %s: %w", def.name, err)
+	}
+	pairs := make(map[[2]string]bool)
+	for _, row := range rows.Rows {
+		if len(row) >= 2 {
+			pairs[[2]string{fmt.Sprintf("%v", row[0]), fmt.Sprintf("%v", row[1])}] = true
+		}
+	}
+
+	for _, pair := range def.pairs {
+		if pairs[pair] {
+			continue
+		}
+		kdb.RelationshipsMigrated = true
+		query := fmt.Sprintf(`ALTER TABLE %s ADD FROM %s TO %s`, def.name, pair[0], pair[1])
+		if err := kdb.execute(query); err != nil {
+			return kdb.recreateTable(def)
+		}
+	}
+	return nil
+}
+
+// recreateTable drops a relationship table and creates it from its current
+// definition, discarding the relationships stored in it
+func (kdb *KuzuDatabase) recreateTable(def *tableDefinition) error {
+	if err := kdb.execute(fmt.Sprintf(`DROP TABLE %s`, def.name)); err != nil {
+		return fmt.Errorf("failed to drop %s: %w", def.name, err)
+	}
+	if err := kdb.execute(def.statement); err != nil {
+		return fmt.Errorf("failed to recreate %s: %w", def.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateSchemaDropsRemovedColumns(t *testing.T) {
+	kdb, err := NewKuzuDatabase(filepath.Join(t.TempDir(), "graph.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(kdb.Close)
+
+	// Type as an earlier version created it, with an alias column whose type
+	// differs from Import's
+	if err := kdb.execute(`CREATE NODE TABLE Type(id STRING, name STRING, type_definition STRING, alias BOOLEAN, alias_composition STRING, file_path STRING, PRIMARY KEY (id))`); err != nil {
+		t.Fatal(err)
+	}
+	if err := kdb.CreateSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := kdb.QueryRows(`CALL TABLE_INFO('Type') RETURN name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := make(map[any]bool)
+	for _, row := range rows.Rows {
+		columns[row[0]] = true
+	}
+	if columns["alias"] || !columns["is_alias"] {
+		t.Errorf("Type columns = %v, want is_alias and no alias", rows.Rows)
+	}
+
+	// Unlabeled nodes bind the properties of every node table
+	if _, err := kdb.QueryRows(`MATCH (a)-[:CALLS]->(b) RETURN count(*)`); err != nil {
+		t.Errorf("query with unlabeled nodes: %v", err)
+	}
+}
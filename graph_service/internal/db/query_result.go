@@ -0,0 +1,269 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// QueryFormat selects how ExecuteQueryFormat renders query results
+type QueryFormat string
+
+const (
+	// QueryFormatText is the tab-pipe-delimited text returned by ExecuteQuery,
+	// one line per row and no header
+	QueryFormatText QueryFormat = "text"
+
+	// QueryFormatJSON is an array with one object per row, keyed by column
+	// name in column order
+	QueryFormatJSON QueryFormat = "json"
+
+	// QueryFormatCSV is RFC 4180 CSV with a header row
+	QueryFormatCSV QueryFormat = "csv"
+
+	// QueryFormatTable is an aligned plain-text table with a header row, meant
+	// for display in a terminal
+	QueryFormatTable QueryFormat = "table"
+)
+
+// ParseQueryFormat returns the QueryFormat named by s, ignoring case
+func ParseQueryFormat(s string) (QueryFormat, error) {
+	switch format := QueryFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case QueryFormatText, QueryFormatJSON, QueryFormatCSV, QueryFormatTable:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown query format %q (expected text, json, csv or table)", s)
+}
+
+// QueryRows holds the result of a query as typed values. Values are those
+// returned by the KuzuDB driver: strings, numbers, booleans, nil for NULL,
+// kuzu.Node and kuzu.Relationship for graph elements, slices for lists and
+// maps for structs.
+type QueryRows struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// QueryRows executes a query and returns its columns and rows
+func (kdb *KuzuDatabase) QueryRows(query string) (*QueryRows, error) {
+	result, err := kdb.Connection.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer result.Close()
+
+	rows := &QueryRows{
+		Columns: result.GetColumnNames(),
+		Rows:    make([][]any, 0),
+	}
+	for result.HasNext() {
+		tuple, err := result.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get next tuple: %w", err)
+		}
+		values, err := tuple.GetAsSlice()
+		tuple.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tuple values: %w", err)
+		}
+		rows.Rows = append(rows.Rows, values)
+	}
+	return rows, nil
+}
+
+// QueryRowsContext executes a query like QueryRows, interrupting it if ctx is
+// cancelled before the query completes.
+func (kdb *KuzuDatabase) QueryRowsContext(ctx context.Context, query string) (*QueryRows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("query cancelled: %w", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			kdb.Connection.Interrupt()
+		case <-done:
+		}
+	}()
+
+	rows, err := kdb.QueryRows(query)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+	}
+	return rows, err
+}
+
+// ExecuteQueryFormat executes a query and renders the result in the requested
+// format, interrupting the query if ctx is cancelled.
+//
+// Example:
+//
+//	table, err := database.ExecuteQueryFormat(ctx, "MATCH (f:Function) RETURN f.name, f.file_path LIMIT 10", db.QueryFormatTable)
+func (kdb *KuzuDatabase) ExecuteQueryFormat(ctx context.Context, query string, format QueryFormat) (string, error) {
+	rows, err := kdb.QueryRowsContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	return rows.Format(format)
+}
+
+// Format renders the rows in the given format
+func (r *QueryRows) Format(format QueryFormat) (string, error) {
+	switch format {
+	case QueryFormatText, "":
+		return r.text(), nil
+	case QueryFormatJSON:
+		return r.json()
+	case QueryFormatCSV:
+		return r.csv()
+	case QueryFormatTable:
+		return r.table(), nil
+	}
+	return "", fmt.Errorf("unknown query format %q", format)
+}
+
+// text renders the rows the way ExecuteQuery always has
+func (r *QueryRows) text() string {
+	var b strings.Builder
+	for _, row := range r.Rows {
+		b.WriteString(strings.Join(formatValues(row), "\t|\t"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// json renders each row as an object. Objects are written by hand because
+// encoding a map would sort the keys and lose the column order.
+func (r *QueryRows) json() (string, error) {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i, row := range r.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("{")
+		for j, value := range row {
+			if j > 0 {
+				b.WriteString(",")
+			}
+			key, err := json.Marshal(r.column(j))
+			if err != nil {
+				return "", fmt.Errorf("failed to encode column name: %w", err)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode value of column %s: %w", r.column(j), err)
+			}
+			b.Write(key)
+			b.WriteString(":")
+			b.Write(encoded)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("]")
+	return b.String(), nil
+}
+
+// csv renders the rows with a header row
+func (r *QueryRows) csv() (string, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write(r.Columns); err != nil {
+		return "", fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, row := range r.Rows {
+		if err := w.Write(formatValues(row)); err != nil {
+			return "", fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return b.String(), nil
+}
+
+// table renders the rows as left-aligned columns under a header. Line breaks
+// inside values, such as function bodies, are escaped so every row stays on
+// one line.
+func (r *QueryRows) table() string {
+	header := make([]string, len(r.Columns))
+	copy(header, r.Columns)
+	cells := make([][]string, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		line := formatValues(row)
+		for i, cell := range line {
+			line[i] = strings.NewReplacer("\r", `\r`, "\n", `\n`, "\t", " ").Replace(cell)
+		}
+		for len(header) < len(line) {
+			header = append(header, r.column(len(header)))
+		}
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(header))
+	for i, name := range header {
+		widths[i] = utf8.RuneCountInString(name)
+	}
+	for _, line := range cells {
+		for i, cell := range line {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var b strings.Builder
+	writeLine := func(line []string) {
+		for i, cell := range line {
+			if i > 0 {
+				b.WriteString(" | ")
+			}
+			b.WriteString(cell)
+			if i < len(line)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	writeLine(header)
+	for i, width := range widths {
+		if i > 0 {
+			b.WriteString("-+-")
+		}
+		b.WriteString(strings.Repeat("-", width))
+	}
+	b.WriteString("\n")
+	for _, line := range cells {
+		writeLine(line)
+	}
+	fmt.Fprintf(&b, "(%d row", len(r.Rows))
+	if len(r.Rows) != 1 {
+		b.WriteString("s")
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// column returns the name of column i, or a positional name when the driver
+// reported fewer names than values
+func (r *QueryRows) column(i int) string {
+	if i < len(r.Columns) {
+		return r.Columns[i]
+	}
+	return fmt.Sprintf("column_%d", i+1)
+}
+
+// formatValues renders row values as text
+func formatValues(row []any) []string {
+	formatted := make([]string, len(row))
+	for i, value := range row {
+		formatted[i] = fmt.Sprintf("%v", value)
+	}
+	return formatted
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/kuzudb/go-kuzu"
+)
+
+// RowIterator reads the rows of a query one at a time, so a large result is
+// never held as a whole on the Go side and the first row can be written out
+// before the last is read. The KuzuDB result stays open until the last row
+// has been read, reading fails, the context is cancelled or Close is
+// called; callers that may stop early must call Close. Cancelling the
+// context closes the result right away, even while Next is not called. An
+// iterator is not safe for concurrent use.
+type RowIterator struct {
+	// Columns holds the column names of the result, in column order
+	Columns []string
+
+	ctx context.Context
+
+	// mu serializes Next and Close with the goroutine that interrupts the
+	// query and closes the result when ctx is cancelled
+	mu      sync.Mutex
+	result  *kuzu.QueryResult
+	err     error
+	stop    chan struct{} // closed once the iterator is closed
+	stopped bool
+}
+
+// QueryStream executes a query and returns an iterator over its rows. The
+// query is interrupted if ctx is cancelled while it runs; once it has run,
+// cancelling ctx closes the result and ends the iteration.
+//
+// Example:
+//
+//	rows, err := database.QueryStream(ctx, "MATCH (f:Function) RETURN f.name, f.file_path")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer rows.Close()
+//	for row, ok := rows.Next(); ok; row, ok = rows.Next() {
+//		fmt.Println(row["f.name"], row["f.file_path"])
+//	}
+//	if err := rows.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+func (kdb *KuzuDatabase) QueryStream(ctx context.Context, query string) (*RowIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("query cancelled: %w", err)
+	}
+
+	it := &RowIterator{ctx: ctx, stop: make(chan struct{})}
+	queried := make(chan struct{})
+	go it.watch(kdb.Connection, queried)
+	result, err := kdb.Connection.Query(query)
+
+	// Once queried is closed, the watcher no longer interrupts the
+	// connection, which Next goes on to use
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	close(queried)
+	if err != nil || ctx.Err() != nil {
+		if err == nil {
+			result.Close()
+		}
+		it.closeLocked()
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("query cancelled: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	it.Columns = result.GetColumnNames()
+	it.result = result
+	return it, nil
+}
+
+// interruptInterval is how often a cancelled query is interrupted until it
+// returns
+const interruptInterval = 10 * time.Millisecond
+
+// watch interrupts the query while it runs and closes the result once it
+// has run, whichever is under way when ctx is cancelled. queried is closed
+// when the query has returned. KuzuDB drops an interrupt that arrives before
+// it starts executing the query, so the query is interrupted again until it
+// returns.
+func (it *RowIterator) watch(conn *kuzu.Connection, queried chan struct{}) {
+	select {
+	case <-it.ctx.Done():
+	case <-it.stop:
+		return
+	}
+
+	ticker := time.NewTicker(interruptInterval)
+	defer ticker.Stop()
+	for !it.cancel(conn, queried) {
+		select {
+		case <-queried:
+		case <-ticker.C:
+		}
+	}
+}
+
+// cancel interrupts the query if it is still running, or closes the result
+// if it has run, and reports whether the query had returned
+func (it *RowIterator) cancel(conn *kuzu.Connection, queried chan struct{}) bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	select {
+	case <-queried:
+		if it.result != nil {
+			it.err = fmt.Errorf("query cancelled: %w", it.ctx.Err())
+			it.closeLocked()
+		}
+		return true
+	default:
+		conn.Interrupt()
+		return false
+	}
+}
+
+// Next returns the next row, keyed by column name, and whether there was
+// one. It returns false at the end of the result and on error, closing the
+// result either way; Err tells the two apart.
+func (it *RowIterator) Next() (map[string]any, bool) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if it.result == nil {
+		return nil, false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = fmt.Errorf("query cancelled: %w", err)
+		it.closeLocked()
+		return nil, false
+	}
+	if !it.result.HasNext() {
+		it.closeLocked()
+		return nil, false
+	}
+
+	tuple, err := it.result.Next()
+	if err != nil {
+		it.err = fmt.Errorf("failed to get next tuple: %w", err)
+		it.closeLocked()
+		return nil, false
+	}
+	values, err := tuple.GetAsSlice()
+	tuple.Close()
+	if err != nil {
+		it.err = fmt.Errorf("failed to read tuple values: %w", err)
+		it.closeLocked()
+		return nil, false
+	}
+
+	row := make(map[string]any, len(values))
+	for i, value := range values {
+		row[it.column(i)] = value
+	}
+	return row, true
+}
+
+// Err returns the error that ended the iteration, or nil if it reached the
+// end of the result or has not ended
+func (it *RowIterator) Err() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.err
+}
+
+// Close releases the KuzuDB result. It may be called more than once, and
+// Next returns false after it.
+func (it *RowIterator) Close() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	it.closeLocked()
+	return nil
+}
+
+// closeLocked releases the result and stops the watcher; it.mu must be held
+func (it *RowIterator) closeLocked() {
+	if it.result != nil {
+		it.result.Close()
+		it.result = nil
+	}
+	if !it.stopped {
+		close(it.stop)
+		it.stopped = true
+	}
+}
+
+// WriteCSV writes the remaining rows to w as CSV with a header row, the way
+// QueryFormatCSV renders them, flushing after each row so a reader sees rows
+// as they are read. The iterator is closed when it returns.
+func (it *RowIterator) WriteCSV(w io.Writer) error {
+	defer it.Close()
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(it.Columns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	values := make([]any, len(it.Columns))
+	for row, ok := it.Next(); ok; row, ok = it.Next() {
+		for i := range values {
+			values[i] = row[it.column(i)]
+		}
+		if err := writer.Write(formatValues(values)); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// column returns the name of column i like QueryRows does
+func (it *RowIterator) column(i int) string {
+	if i < len(it.Columns) {
+		return it.Columns[i]
+	}
+	return fmt.Sprintf("column_%d", i+1)
+}
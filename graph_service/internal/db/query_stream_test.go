@@ -0,0 +1,165 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// newStreamDatabase returns a test database holding n functions named f1 to fn
+func newStreamDatabase(t *testing.T, n int) *KuzuDatabase {
+	t.Helper()
+	kdb := newTestDatabase(t)
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("f%d", i)
+		if err := kdb.StoreEntity(entities.NewSpanEntity(name, name, entities.EntityTypeFunction, "main.go", 0, 1, i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return kdb
+}
+
+func TestQueryStream(t *testing.T) {
+	kdb := newStreamDatabase(t, 3)
+
+	rows, err := kdb.QueryStream(context.Background(), "MATCH (f:Function) RETURN f.name, f.file_path ORDER BY f.name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []any
+	for row, ok := rows.Next(); ok; row, ok = rows.Next() {
+		names = append(names, row["f.name"])
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[f1 f2 f3]" {
+		t.Errorf("names = %v, want [f1 f2 f3]", names)
+	}
+	if _, ok := rows.Next(); ok {
+		t.Error("Next after the end of the result returned a row")
+	}
+
+	rows, err = kdb.QueryStream(context.Background(), "MATCH (f:Function) RETURN f.name, f.file_path ORDER BY f.name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := rows.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "f.name,f.file_path\nf1,main.go\nf2,main.go\nf3,main.go\n"; buf.String() != want {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestQueryStreamCancelled(t *testing.T) {
+	kdb := newStreamDatabase(t, 3)
+	const query = "MATCH (f:Function) RETURN f.name"
+
+	t.Run("before the query", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := kdb.QueryStream(ctx, query); !errors.Is(err, context.Canceled) {
+			t.Errorf("QueryStream = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("between rows", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rows, err := kdb.QueryStream(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		if _, ok := rows.Next(); !ok {
+			t.Fatalf("Next = false, %v; want a row", rows.Err())
+		}
+
+		// The result is closed on cancellation without waiting for Next
+		cancel()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			rows.mu.Lock()
+			closed := rows.result == nil
+			rows.mu.Unlock()
+			if closed {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("result still open after cancelling")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if _, ok := rows.Next(); ok {
+			t.Error("Next after cancelling returned a row")
+		}
+		if err := rows.Err(); !errors.Is(err, context.Canceled) {
+			t.Errorf("Err = %v, want context.Canceled", err)
+		}
+		if err := rows.Close(); err != nil {
+			t.Errorf("Close after cancelling = %v", err)
+		}
+	})
+
+	t.Run("while the query runs", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		rows, err := kdb.QueryStream(ctx, "UNWIND range(1, 100000) AS x UNWIND range(1, 100000) AS y RETURN sum(x * y)")
+		if err == nil {
+			rows.Close()
+			t.Fatal("QueryStream = nil, want the query to be interrupted")
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("QueryStream = %v, want context.DeadlineExceeded", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("interrupting the query took %v", elapsed)
+		}
+
+		// The connection is usable once the interrupted query has returned
+		rows, err = kdb.QueryStream(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		if _, ok := rows.Next(); !ok {
+			t.Errorf("Next after an interrupted query = false, %v", rows.Err())
+		}
+	})
+}
+
+func TestRowIteratorClose(t *testing.T) {
+	kdb := newStreamDatabase(t, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rows, err := kdb.QueryStream(ctx, "MATCH (f:Function) RETURN f.name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+	if _, ok := rows.Next(); ok {
+		t.Error("Next after Close returned a row")
+	}
+
+	// Cancelling after Close leaves a closed iteration without an error
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if err := rows.Err(); err != nil {
+		t.Errorf("Err after Close = %v, want nil", err)
+	}
+}
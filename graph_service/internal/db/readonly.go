@@ -0,0 +1,178 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrWriteQuery is returned by CheckReadOnly for queries that would modify
+// the database
+var ErrWriteQuery = errors.New("query modifies the graph")
+
+// readClauses are the Cypher clauses a read-only query may start a statement
+// with or chain; a statement starting with any other word is rejected
+var readClauses = map[string]bool{
+	"MATCH":    true,
+	"OPTIONAL": true,
+	"WITH":     true,
+	"UNWIND":   true,
+	"RETURN":   true,
+	"ORDER":    true,
+	"LIMIT":    true,
+	"SKIP":     true,
+	"UNION":    true,
+	"CALL":     true,
+}
+
+// readOnlyProcedures are the Kuzu functions CALL may run in a read-only
+// query. Others, such as CREATE_FTS_INDEX, and settings (CALL threads=4)
+// are rejected.
+var readOnlyProcedures = map[string]bool{
+	"SHOW_TABLES":             true,
+	"TABLE_INFO":              true,
+	"SHOW_CONNECTION":         true,
+	"SHOW_FUNCTIONS":          true,
+	"SHOW_INDEXES":            true,
+	"SHOW_SEQUENCES":          true,
+	"SHOW_MACROS":             true,
+	"SHOW_WARNINGS":           true,
+	"SHOW_ATTACHED_DATABASES": true,
+	"SHOW_LOADED_EXTENSIONS":  true,
+	"CURRENT_SETTING":         true,
+	"DB_VERSION":              true,
+	"QUERY_FTS_INDEX":         true,
+	"QUERY_VECTOR_INDEX":      true,
+}
+
+// writeClauses are the Cypher and Kuzu keywords that modify the database,
+// its schema, its settings or files outside it, rejected wherever they
+// appear in a statement
+var writeClauses = map[string]bool{
+	"CREATE":     true,
+	"MERGE":      true,
+	"SET":        true,
+	"DELETE":     true,
+	"DETACH":     true,
+	"REMOVE":     true,
+	"DROP":       true,
+	"ALTER":      true,
+	"COPY":       true,
+	"IMPORT":     true,
+	"EXPORT":     true,
+	"INSTALL":    true,
+	"UNINSTALL":  true,
+	"LOAD":       true,
+	"ATTACH":     true,
+	"USE":        true,
+	"FOREACH":    true,
+	"BEGIN":      true,
+	"COMMIT":     true,
+	"ROLLBACK":   true,
+	"CHECKPOINT": true,
+}
+
+// CheckReadOnly returns an error wrapping ErrWriteQuery unless a query only
+// reads the graph. Every statement must start with a clause of readClauses,
+// CALL must run a function of readOnlyProcedures, and no statement may
+// contain a clause that modifies the database, such as CREATE, SET or DELETE.
+//
+// The query is tokenized rather than searched, so keywords inside string
+// literals, backquoted names and comments are ignored, as are property names
+// (n.set), labels (:Create), parameters ($delete), map keys ({merge: 1}),
+// variables (set.name) and aliases (AS drop) that happen to spell one.
+func CheckReadOnly(query string) error {
+	statementStart, afterCall := true, false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			if afterCall {
+				return fmt.Errorf("%w: CALL of a quoted name is not allowed in read-only mode", ErrWriteQuery)
+			}
+			i = skipQuoted(query, i)
+		case strings.HasPrefix(query[i:], "//"):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(query)
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(query)
+			}
+		case c == ';':
+			statementStart = true
+			i++
+		case isWordByte(c):
+			start := i
+			for i < len(query) && isWordByte(query[i]) {
+				i++
+			}
+			word := strings.ToUpper(query[start:i])
+			switch {
+			case afterCall:
+				if !readOnlyProcedures[word] {
+					return fmt.Errorf("%w: CALL %s is not allowed in read-only mode", ErrWriteQuery, word)
+				}
+				afterCall = false
+			case isName(query, start, i):
+				continue
+			case statementStart && !readClauses[word], writeClauses[word]:
+				return fmt.Errorf("%w: %s is not allowed in read-only mode", ErrWriteQuery, word)
+			}
+			statementStart = false
+			afterCall = word == "CALL"
+		default:
+			if afterCall && !unicode.IsSpace(rune(c)) {
+				return fmt.Errorf("%w: CALL %c is not allowed in read-only mode", ErrWriteQuery, c)
+			}
+			i++
+		}
+	}
+	if afterCall {
+		return fmt.Errorf("%w: CALL without a function is not allowed in read-only mode", ErrWriteQuery)
+	}
+	return nil
+}
+
+// skipQuoted returns the offset just past the quoted string or name starting
+// at offset start, honouring backslash escapes in strings
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// isName reports whether the word between start and end is used as a name
+// rather than a keyword: preceded by '.', ':', '$' or AS, or followed by ':'
+// or '.'
+func isName(query string, start, end int) bool {
+	before := strings.TrimRight(query[:start], " \t\r\n")
+	if before != "" && strings.ContainsRune(".:$", rune(before[len(before)-1])) {
+		return true
+	}
+	if fields := strings.Fields(before); len(fields) > 0 && strings.EqualFold(fields[len(fields)-1], "AS") {
+		return true
+	}
+	after := strings.TrimLeft(query[end:], " \t\r\n")
+	return strings.HasPrefix(after, ":") || strings.HasPrefix(after, ".")
+}
+
+// isWordByte reports whether c can be part of an unquoted Cypher name or
+// keyword
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
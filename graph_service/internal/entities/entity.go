@@ -182,6 +182,10 @@ type Entity struct {
 	//   - "complexity": cyclomatic complexity score
 	//   - "parameters": detailed parameter information
 	Properties map[string]interface{}
+
+	// startLine and endLine are the 1-based lines of a restored entity,
+	// which has no syntax node; see NewRestoredEntity
+	startLine, endLine int
 }
 
 // EntityType categorizes code entities according to their programming language
@@ -240,6 +244,30 @@ const (
 	EntityTypeAssertion    EntityType = "Assertion"    // Individual assertions within tests
 	EntityTypeMock         EntityType = "Mock"         // Mock objects/functions used in tests
 	EntityTypeFixture      EntityType = "Fixture"      // Test fixtures and test data
+
+	// Analysis diagnostics entities
+	EntityTypeUnresolvedCall    EntityType = "UnresolvedCall"    // Call whose target cannot be determined statically
+	EntityTypeLogStatement      EntityType = "LogStatement"      // Call to a logging library with its level and message
+	EntityTypeFeatureFlag       EntityType = "FeatureFlag"       // Feature flag key, shared by every site that evaluates it
+	EntityTypeNPlusOne          EntityType = "NPlusOne"          // Query or API call made once per iteration of a loop
+	EntityTypeCommentedCode     EntityType = "CommentedCode"     // Comment block that parses as code in the file's language
+	EntityTypeBinding           EntityType = "Binding"           // Dependency-injection binding of an interface to its implementation
+	EntityTypeControlFlowIssue  EntityType = "ControlFlowIssue"  // Missing return or unreachable statement inside a function
+	EntityTypeMissingDoc        EntityType = "MissingDoc"        // Exported declaration without a doc comment or docstring
+	EntityTypeEnumMember        EntityType = "EnumMember"        // Member of an enum or Go const block with its explicit or implied value
+	EntityTypeMigration         EntityType = "Migration"         // Database migration file of Alembic, golang-migrate, Flyway or Prisma
+	EntityTypeSchemaChange      EntityType = "SchemaChange"      // Table, column or index operation of a migration
+	EntityTypeCLIFlag           EntityType = "CLIFlag"           // Command-line flag or positional argument with its type, default and help
+	EntityTypeErrorMessageIssue EntityType = "ErrorMessageIssue" // Error message breaking the capitalization or punctuation convention of its language
+	EntityTypeRaceRisk          EntityType = "RaceRisk"          // Variable or field accessed from a goroutine without a lock while also written without one
+	EntityTypeBlockingInAsync   EntityType = "BlockingInAsync"   // Synchronous call blocking the event loop inside an async function
+	EntityTypeStructTagIssue    EntityType = "StructTagIssue"    // Go struct field whose tag breaks or risks breaking JSON round-trips
+
+	// Infrastructure-as-code entities (Terraform/HCL)
+	EntityTypeResource   EntityType = "Resource"   // Terraform resource block, e.g. aws_s3_bucket.logs
+	EntityTypeDataSource EntityType = "DataSource" // Terraform data block, e.g. data.aws_ami.ubuntu
+	EntityTypeModuleCall EntityType = "ModuleCall" // Terraform module block, e.g. module.vpc
+	EntityTypeOutput     EntityType = "Output"     // Terraform output block
 )
 
 // NewEntity creates a new Entity instance
@@ -258,6 +286,45 @@ func NewEntity(id, name string, entityType EntityType, filePath string, node *ts
 	}
 }
 
+// NewSpanEntity creates an entity for a construct that was located without a
+// Tree-sitter node, such as a block found by the HCL scanner. Lines are 1-based
+// and are reported by StartLine and EndLine.
+func NewSpanEntity(id, name string, entityType EntityType, filePath string, startByte, endByte uint32, startLine, endLine int) *Entity {
+	entity := &Entity{
+		ID:         id,
+		Name:       name,
+		Type:       entityType,
+		FilePath:   filePath,
+		StartByte:  startByte,
+		EndByte:    endByte,
+		Symbols:    make(map[string][]*ts.Node),
+		Children:   make([]*Entity, 0),
+		Properties: make(map[string]interface{}),
+	}
+	entity.SetProperty("start_line", startLine)
+	entity.SetProperty("end_line", endLine)
+	return entity
+}
+
+// NewRestoredEntity creates an entity read back from a snapshot of a graph,
+// which has no Tree-sitter node. Unlike NewSpanEntity it keeps the lines out
+// of Properties, so that they hold exactly the properties that were saved.
+func NewRestoredEntity(id, name string, entityType EntityType, filePath string, startByte, endByte uint32, startLine, endLine int) *Entity {
+	return &Entity{
+		ID:         id,
+		Name:       name,
+		Type:       entityType,
+		FilePath:   filePath,
+		StartByte:  startByte,
+		EndByte:    endByte,
+		Symbols:    make(map[string][]*ts.Node),
+		Children:   make([]*Entity, 0),
+		Properties: make(map[string]interface{}),
+		startLine:  startLine,
+		endLine:    endLine,
+	}
+}
+
 // AddSymbol adds a symbol reference to this entity
 func (e *Entity) AddSymbol(symbolType string, node *ts.Node) {
 	if e.Symbols[symbolType] == nil {
@@ -293,6 +360,32 @@ func (e *Entity) GetProperty(key string) interface{} {
 	return e.Properties[key]
 }
 
+// StartLine returns the 1-based line on which this entity begins, or 0 if the
+// entity has neither a syntax node nor a recorded span
+func (e *Entity) StartLine() int {
+	if e.Node == nil {
+		if e.startLine != 0 {
+			return e.startLine
+		}
+		line, _ := e.Properties["start_line"].(int)
+		return line
+	}
+	return int(e.Node.StartPosition().Row) + 1
+}
+
+// EndLine returns the 1-based line on which this entity ends, or 0 if the
+// entity has neither a syntax node nor a recorded span
+func (e *Entity) EndLine() int {
+	if e.Node == nil {
+		if e.endLine != 0 {
+			return e.endLine
+		}
+		line, _ := e.Properties["end_line"].(int)
+		return line
+	}
+	return int(e.Node.EndPosition().Row) + 1
+}
+
 // IsMethod returns true if this entity is a method (function inside a class)
 func (e *Entity) IsMethod() bool {
 	return e.Type == EntityTypeMethod || (e.Type == EntityTypeFunction && e.Parent != nil && e.Parent.Type == EntityTypeClass)
@@ -306,6 +399,20 @@ func (e *Entity) GetFullName() string {
 	return e.Name
 }
 
+// IsIgnored returns true if the entity, or a type that declares it, is marked
+// with an onyx:ignore pragma comment
+func (e *Entity) IsIgnored() bool {
+	ignored, _ := e.GetProperty("ignored").(bool)
+	return ignored
+}
+
+// IgnoresCoverage returns true if the entity is excluded from test coverage,
+// by onyx:ignore or onyx:ignore-coverage
+func (e *Entity) IgnoresCoverage() bool {
+	ignored, _ := e.GetProperty("ignore_coverage").(bool)
+	return ignored || e.IsIgnored()
+}
+
 // Test-related methods and properties
 
 // IsTest returns true if this entity is a test-related entity
@@ -416,6 +523,12 @@ func (e *Entity) IsTestFile() bool {
 
 // matchesTestFilePattern checks if a file path matches common test file patterns
 func (e *Entity) matchesTestFilePattern(filePath string) bool {
+	return IsTestFilePath(filePath)
+}
+
+// IsTestFilePath reports whether a file path matches common Go, Python or
+// TypeScript/JavaScript test file patterns
+func IsTestFilePath(filePath string) bool {
 	// Go test patterns
 	if len(filePath) > 8 && filePath[len(filePath)-8:] == "_test.go" {
 		return true
@@ -66,6 +66,40 @@ func (f *File) AddEntity(entity *Entity) {
 	}
 }
 
+// RemoveEntity removes an entity from this file and its categories. Its
+// children are handed to its parent, if any.
+func (f *File) RemoveEntity(entity *Entity) {
+	delete(f.Entities, entity.ID)
+	f.Functions = removeEntity(f.Functions, entity)
+	f.Classes = removeEntity(f.Classes, entity)
+	f.Methods = removeEntity(f.Methods, entity)
+	f.Imports = removeEntity(f.Imports, entity)
+	f.Variables = removeEntity(f.Variables, entity)
+
+	parent := entity.Parent
+	if parent != nil {
+		parent.Children = removeEntity(parent.Children, entity)
+	}
+	for _, child := range entity.Children {
+		child.Parent = nil
+		if parent != nil {
+			parent.AddChild(child)
+		}
+	}
+	entity.Parent = nil
+	entity.Children = entity.Children[:0]
+}
+
+// removeEntity returns list without entity
+func removeEntity(list []*Entity, entity *Entity) []*Entity {
+	for i, e := range list {
+		if e == entity {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
 // GetEntity retrieves an entity by ID
 func (f *File) GetEntity(id string) *Entity {
 	return f.Entities[id]
@@ -370,6 +370,26 @@ func (r *EntityRegistry) ResolveEntity(name string, context *EntityResolutionCon
 	return nil
 }
 
+// ResolveInPackage resolves a name against the entities declared in a single
+// package directory, without falling back to file-scoped or global matches
+func (r *EntityRegistry) ResolveInPackage(name, packagePath string, context *EntityResolutionContext) *Entity {
+	if name == "" || context == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	r.stats.LookupCount++
+	if entity := r.resolveInPackage(name, packagePath, context); entity != nil {
+		r.stats.ResolvedCount++
+		return entity
+	}
+
+	r.stats.UnresolvedCount++
+	return nil
+}
+
 // ResolveFunction provides specialized function resolution with method handling
 // This method handles function calls including method calls with receiver types.
 func (r *EntityRegistry) ResolveFunction(name string, context *EntityResolutionContext) *Entity {
@@ -412,6 +432,25 @@ func (r *EntityRegistry) ResolveType(name string, context *EntityResolutionConte
 	return r.ResolveEntity(name, &typeContext)
 }
 
+// LookupName returns the entities named name of the given types, or of any
+// type when none are given, without regard to where they are declared
+func (r *EntityRegistry) LookupName(name string, types ...EntityType) []*Entity {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Entity, 0)
+	for entityType, byScope := range r.nameIndex[name] {
+		wanted := len(types) == 0
+		for _, t := range types {
+			wanted = wanted || t == entityType
+		}
+		if wanted {
+			result = append(result, byScope[""]...)
+		}
+	}
+	return result
+}
+
 // GetEntitiesByType returns all entities of the specified type
 func (r *EntityRegistry) GetEntitiesByType(entityType EntityType) []*Entity {
 	r.mu.RLock()
@@ -635,18 +674,16 @@ func (r *EntityRegistry) resolveInPackage(name string, packagePath string, conte
 	// Check name index for package-specific entities
 	if nameMap := r.nameIndex[name]; nameMap != nil {
 		for _, expectedType := range context.ExpectedTypes {
-			if typeMap := nameMap[expectedType]; typeMap != nil {
-				if entities := typeMap[packagePath]; len(entities) > 0 {
-					return entities[0]
-				}
+			if entity := firstInPackage(nameMap[expectedType], packagePath); entity != nil {
+				return entity
 			}
 		}
 
 		// If no expected types specified, check all types
 		if len(context.ExpectedTypes) == 0 {
 			for _, typeMap := range nameMap {
-				if entities := typeMap[packagePath]; len(entities) > 0 {
-					return entities[0]
+				if entity := firstInPackage(typeMap, packagePath); entity != nil {
+					return entity
 				}
 			}
 		}
@@ -655,6 +692,21 @@ func (r *EntityRegistry) resolveInPackage(name string, packagePath string, conte
 	return nil
 }
 
+// firstInPackage returns the first entity of a name index entry declared in a
+// file of the package directory. Only the entry keyed by the package itself is
+// searched: the file and global keys would otherwise let the root package "."
+// match subdirectories, as filepath.Dir of "" and of "sub" are both ".". Files
+// are compared by path to keep the choice stable.
+func firstInPackage(byFile map[string][]*Entity, packagePath string) *Entity {
+	var first *Entity
+	for _, entity := range byFile[packagePath] {
+		if filepath.Dir(entity.FilePath) == packagePath && (first == nil || entity.FilePath < first.FilePath) {
+			first = entity
+		}
+	}
+	return first
+}
+
 // resolveGlobal performs global entity resolution
 func (r *EntityRegistry) resolveGlobal(name string, context *EntityResolutionContext) *Entity {
 	// Check name index for global entities
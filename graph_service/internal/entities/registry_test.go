@@ -0,0 +1,44 @@
+package entities
+
+import "testing"
+
+func TestResolveInPackageKeepsRootPackageApart(t *testing.T) {
+	registry := NewEntityRegistry()
+	declare := func(id, name string, entityType EntityType, filePath string) *Entity {
+		entity := NewSpanEntity(id, name, entityType, filePath, 0, 1, 1, 1)
+		if err := registry.RegisterEntity(entity); err != nil {
+			t.Fatal(err)
+		}
+		return entity
+	}
+
+	// Registered subdirectory first, so registration order cannot pick the answer
+	subLimit := declare("sub-limit", "limit", EntityTypeVariable, "sub/sub.go")
+	subMsg := declare("sub-msg", "msg", EntityTypeStruct, "sub/sub.go")
+	rootLimit := declare("root-limit", "limit", EntityTypeVariable, "main.go")
+	rootMsg := declare("root-msg", "msg", EntityTypeStruct, "main.go")
+	declare("deep-msg", "msg", EntityTypeStruct, "sub/deep/deep.go")
+
+	tests := []struct {
+		name        string
+		packagePath string
+		want        *Entity
+	}{
+		{"limit", ".", rootLimit},
+		{"msg", ".", rootMsg},
+		{"limit", "sub", subLimit},
+		{"msg", "sub", subMsg},
+		{"limit", "other", nil},
+	}
+	for _, tt := range tests {
+		context := &EntityResolutionContext{
+			CurrentFile:    tt.packagePath + "/x.go",
+			ExpectedTypes:  []EntityType{EntityTypeStruct, EntityTypeVariable},
+			AllowCrossFile: true,
+		}
+		got := registry.ResolveInPackage(tt.name, tt.packagePath, context)
+		if got != tt.want {
+			t.Errorf("ResolveInPackage(%q, %q) = %v, want %v", tt.name, tt.packagePath, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package entities
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	ts "github.com/tree-sitter/go-tree-sitter"
+)
 
 // Relationship represents a connection between two entities with comprehensive
 // type metadata for proper database storage and query generation.
@@ -17,6 +23,7 @@ type Relationship struct {
 	TargetID   string                 // Target entity ID (for database storage)
 	Properties map[string]interface{} // Additional properties
 	Location   *Location              // Where the relationship occurs in code
+	Provenance *Provenance            // How the analyzer inferred this relationship
 	
 	// Enhanced type metadata for proper database storage
 	SourceType EntityType             // Type of the source entity
@@ -88,7 +95,8 @@ const (
 	RelationshipTypeReExports     RelationshipType = "RE_EXPORTS"     // Module re-exports another module
 	RelationshipTypeDynamicImport RelationshipType = "DYNAMIC_IMPORT" // Dynamic import relationships
 	RelationshipTypeTypeOnly      RelationshipType = "TYPE_ONLY"      // Type-only import relationships
-	RelationshipTypeProvides      RelationshipType = "PROVIDES"       // Service provides functionality
+	RelationshipTypeProvides      RelationshipType = "PROVIDES"       // Service provides functionality; binding provides an interface
+	RelationshipTypeBoundTo       RelationshipType = "BOUND_TO"       // Binding resolves its interface to an implementation
 	RelationshipTypeInjects       RelationshipType = "INJECTS"        // Dependency injection relationships
 
 	// Phase 3: Framework Integration relationships
@@ -116,8 +124,89 @@ const (
 	RelationshipTypeGroupsTests     RelationshipType = "GROUPS_TESTS"    // Test suite groups related test cases
 	RelationshipTypeSkips           RelationshipType = "SKIPS"           // Test conditionally skips other tests
 	RelationshipTypeDepends         RelationshipType = "DEPENDS"         // Test depends on another test or setup
+
+	// Error-flow relationships
+	RelationshipTypePropagatesError RelationshipType = "PROPAGATES_ERROR" // Function returns or re-raises the error of a call
+	RelationshipTypeHandlesError    RelationshipType = "HANDLES_ERROR"    // Function checks and handles the error of a call
+	RelationshipTypeIgnoresError    RelationshipType = "IGNORES_ERROR"    // Function discards the error of a call
+
+	// Feature-flag relationships
+	RelationshipTypeChecksFlag RelationshipType = "CHECKS_FLAG" // Function evaluates a feature flag
+
+	// Control-flow relationships
+	RelationshipTypeHasIssue RelationshipType = "HAS_ISSUE" // Function contains unreachable code, lacks a return or blocks in an async body; struct field has a bad tag
+
+	// Infrastructure-as-code relationships
+	RelationshipTypeDependsOn RelationshipType = "DEPENDS_ON" // Terraform block references another block
+
+	// Database migration relationships
+	RelationshipTypeAffects RelationshipType = "AFFECTS" // Schema change alters the table an ORM model maps to
+
+	// Type alias relationships
+	RelationshipTypeAliases RelationshipType = "ALIASES" // Type alias names a type, once per union or intersection member
+
+	// Documentation relationships
+	RelationshipTypeDocReferences RelationshipType = "DOC_REFERENCES" // Doc comment links to another declaration
+
+	// Command-line relationships
+	RelationshipTypeDefinesFlag RelationshipType = "DEFINES_FLAG" // Function defines a command-line flag or argument
+
+	// Shared state relationships
+	RelationshipTypeReads  RelationshipType = "READS"  // Function reads a package-level variable or a field of its receiver
+	RelationshipTypeWrites RelationshipType = "WRITES" // Function writes a package-level variable or a field of its receiver
+
+	// Conditional code relationships
+	RelationshipTypeAlternativeTo RelationshipType = "ALTERNATIVE_TO" // Import or declaration is used instead of another under a different condition
 )
 
+// Provenance records why a relationship exists: the syntax node an analyzer
+// matched and where it appears. When an edge turns out to be wrong, provenance
+// points a human at the exact line that produced it.
+type Provenance struct {
+	NodeKind    string `json:"node_kind"`    // Tree-sitter node kind, e.g. "call_expression"
+	MatchedText string `json:"matched_text"` // Source text of the node, condensed to one line
+	FilePath    string `json:"file_path"`
+	Line        uint32 `json:"line"`   // 1-based line of the reference site
+	Column      uint32 `json:"column"` // 1-based column of the reference site
+}
+
+// maxProvenanceTextLength bounds the matched text kept on a Provenance
+const maxProvenanceTextLength = 120
+
+// NewProvenance builds the provenance of a relationship inferred from node
+func NewProvenance(filePath string, node *ts.Node, content []byte) *Provenance {
+	if node == nil {
+		return &Provenance{FilePath: filePath}
+	}
+
+	text := strings.Join(strings.Fields(node.Utf8Text(content)), " ")
+	if len(text) > maxProvenanceTextLength {
+		// Cut on a rune boundary so multi-byte characters are not split
+		end := maxProvenanceTextLength
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text = text[:end] + "..."
+	}
+
+	start := node.StartPosition()
+	return &Provenance{
+		NodeKind:    node.Kind(),
+		MatchedText: text,
+		FilePath:    filePath,
+		Line:        uint32(start.Row) + 1,
+		Column:      uint32(start.Column) + 1,
+	}
+}
+
+// String formats the provenance as "file:line:column kind `text`"
+func (p *Provenance) String() string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d %s `%s`", p.FilePath, p.Line, p.Column, p.NodeKind, p.MatchedText)
+}
+
 // Location represents a position in source code
 type Location struct {
 	FilePath  string // Path to the file
@@ -233,6 +322,21 @@ func (r *Relationship) SetLocation(filePath string, startByte, endByte uint32) {
 	}
 }
 
+// SetProvenance records the syntax node the relationship was inferred from,
+// also filling in Location when it has not been set
+func (r *Relationship) SetProvenance(filePath string, node *ts.Node, content []byte) {
+	r.Provenance = NewProvenance(filePath, node, content)
+	if r.Location == nil && node != nil {
+		r.Location = &Location{
+			FilePath:  filePath,
+			StartByte: uint32(node.StartByte()),
+			EndByte:   uint32(node.EndByte()),
+			Line:      r.Provenance.Line,
+			Column:    r.Provenance.Column,
+		}
+	}
+}
+
 // IsValid checks if the relationship has valid source and target
 func (r *Relationship) IsValid() bool {
 	return r.SourceID != "" && r.TargetID != "" && r.Type != ""
@@ -410,6 +514,17 @@ func (r *Relationship) IsValidForSchema() bool {
 			{EntityTypeFile, EntityTypeAssertion},
 			{EntityTypeFile, EntityTypeMock},
 			{EntityTypeFile, EntityTypeFixture},
+			{EntityTypeFile, EntityTypeUnresolvedCall},
+			{EntityTypeFile, EntityTypeLogStatement},
+			{EntityTypeFile, EntityTypeMigration},
+			{EntityTypeFile, EntityTypeType},
+			{EntityTypeMigration, EntityTypeSchemaChange},
+			{EntityTypeFunction, EntityTypeFunction},
+			{EntityTypeMethod, EntityTypeFunction},
+			{EntityTypeTestFunction, EntityTypeFunction},
+			{EntityTypeStruct, EntityTypeProperty},
+			{EntityTypeClass, EntityTypeProperty},
+			{EntityTypeInterface, EntityTypeProperty},
 		},
 		RelationshipTypeImports: {
 			{EntityTypeFile, EntityTypeFile},
@@ -426,6 +541,8 @@ func (r *Relationship) IsValidForSchema() bool {
 		RelationshipTypeDefines: {
 			{EntityTypeStruct, EntityTypeMethod},
 			{EntityTypeInterface, EntityTypeMethod},
+			{EntityTypeEnum, EntityTypeEnumMember},
+			{EntityTypeClass, EntityTypeEnumMember},
 		},
 		RelationshipTypeUses: {
 			{EntityTypeFunction, EntityTypeStruct},
@@ -433,6 +550,21 @@ func (r *Relationship) IsValidForSchema() bool {
 			{EntityTypeFunction, EntityTypeInterface},
 			{EntityTypeMethod, EntityTypeInterface},
 		},
+		RelationshipTypeConstrains: {
+			{EntityTypeGeneric, EntityTypeInterface},
+			{EntityTypeGeneric, EntityTypeStruct},
+			{EntityTypeGeneric, EntityTypeClass},
+		},
+		RelationshipTypeProvides: {
+			{EntityTypeBinding, EntityTypeInterface},
+			{EntityTypeBinding, EntityTypeClass},
+			{EntityTypeBinding, EntityTypeStruct},
+		},
+		RelationshipTypeBoundTo: {
+			{EntityTypeBinding, EntityTypeStruct},
+			{EntityTypeBinding, EntityTypeClass},
+			{EntityTypeBinding, EntityTypeFunction},
+		},
 		// Test coverage relationships
 		RelationshipTypeTests: {
 			{EntityTypeTestFunction, EntityTypeFunction},
@@ -456,6 +588,121 @@ func (r *Relationship) IsValidForSchema() bool {
 			{EntityTypeMock, EntityTypeFunction},
 			{EntityTypeMock, EntityTypeMethod},
 		},
+		RelationshipTypeAsserts: {
+			{EntityTypeTestFunction, EntityTypeAssertion},
+			{EntityTypeTestCase, EntityTypeAssertion},
+		},
+		// Error-flow relationships
+		RelationshipTypePropagatesError: {
+			{EntityTypeFunction, EntityTypeFunction},
+			{EntityTypeMethod, EntityTypeFunction},
+			{EntityTypeFunction, EntityTypeMethod},
+			{EntityTypeMethod, EntityTypeMethod},
+			{EntityTypeTestFunction, EntityTypeFunction},
+			{EntityTypeTestFunction, EntityTypeMethod},
+		},
+		RelationshipTypeHandlesError: {
+			{EntityTypeFunction, EntityTypeFunction},
+			{EntityTypeMethod, EntityTypeFunction},
+			{EntityTypeFunction, EntityTypeMethod},
+			{EntityTypeMethod, EntityTypeMethod},
+			{EntityTypeTestFunction, EntityTypeFunction},
+			{EntityTypeTestFunction, EntityTypeMethod},
+		},
+		RelationshipTypeIgnoresError: {
+			{EntityTypeFunction, EntityTypeFunction},
+			{EntityTypeMethod, EntityTypeFunction},
+			{EntityTypeFunction, EntityTypeMethod},
+			{EntityTypeMethod, EntityTypeMethod},
+			{EntityTypeTestFunction, EntityTypeFunction},
+			{EntityTypeTestFunction, EntityTypeMethod},
+		},
+		// Feature-flag relationships
+		RelationshipTypeChecksFlag: {
+			{EntityTypeFunction, EntityTypeFeatureFlag},
+			{EntityTypeMethod, EntityTypeFeatureFlag},
+			{EntityTypeTestFunction, EntityTypeFeatureFlag},
+		},
+		// Control-flow relationships
+		RelationshipTypeHasIssue: {
+			{EntityTypeFunction, EntityTypeControlFlowIssue},
+			{EntityTypeMethod, EntityTypeControlFlowIssue},
+			{EntityTypeTestFunction, EntityTypeControlFlowIssue},
+			{EntityTypeFunction, EntityTypeBlockingInAsync},
+			{EntityTypeMethod, EntityTypeBlockingInAsync},
+			{EntityTypeTestFunction, EntityTypeBlockingInAsync},
+			{EntityTypeProperty, EntityTypeStructTagIssue},
+		},
+		// Infrastructure-as-code relationships
+		RelationshipTypeDependsOn: {
+			{EntityTypeResource, EntityTypeResource},
+			{EntityTypeResource, EntityTypeDataSource},
+			{EntityTypeResource, EntityTypeModuleCall},
+			{EntityTypeResource, EntityTypeVariable},
+			{EntityTypeDataSource, EntityTypeResource},
+			{EntityTypeDataSource, EntityTypeDataSource},
+			{EntityTypeDataSource, EntityTypeModuleCall},
+			{EntityTypeDataSource, EntityTypeVariable},
+			{EntityTypeModuleCall, EntityTypeResource},
+			{EntityTypeModuleCall, EntityTypeDataSource},
+			{EntityTypeModuleCall, EntityTypeModuleCall},
+			{EntityTypeModuleCall, EntityTypeVariable},
+			{EntityTypeOutput, EntityTypeResource},
+			{EntityTypeOutput, EntityTypeDataSource},
+			{EntityTypeOutput, EntityTypeModuleCall},
+			{EntityTypeOutput, EntityTypeVariable},
+		},
+
+		// Database migration relationships
+		RelationshipTypeAffects: {
+			{EntityTypeSchemaChange, EntityTypeClass},
+			{EntityTypeSchemaChange, EntityTypeStruct},
+		},
+
+		// Type alias relationships
+		RelationshipTypeAliases: {
+			{EntityTypeType, EntityTypeType},
+			{EntityTypeType, EntityTypeInterface},
+			{EntityTypeType, EntityTypeClass},
+			{EntityTypeType, EntityTypeEnum},
+			{EntityTypeType, EntityTypeStruct},
+			{EntityTypeClass, EntityTypeClass},
+			{EntityTypeClass, EntityTypeStruct},
+			{EntityTypeClass, EntityTypeInterface},
+			{EntityTypeClass, EntityTypeType},
+			{EntityTypeClass, EntityTypeEnum},
+		},
+
+		// Command-line relationships
+		RelationshipTypeDefinesFlag: {
+			{EntityTypeFunction, EntityTypeCLIFlag},
+			{EntityTypeMethod, EntityTypeCLIFlag},
+		},
+
+		// Shared state relationships
+		RelationshipTypeReads: {
+			{EntityTypeFunction, EntityTypeVariable},
+			{EntityTypeMethod, EntityTypeVariable},
+			{EntityTypeFunction, EntityTypeProperty},
+			{EntityTypeMethod, EntityTypeProperty},
+		},
+		RelationshipTypeWrites: {
+			{EntityTypeFunction, EntityTypeVariable},
+			{EntityTypeMethod, EntityTypeVariable},
+			{EntityTypeFunction, EntityTypeProperty},
+			{EntityTypeMethod, EntityTypeProperty},
+		},
+
+		// Conditional code relationships
+		RelationshipTypeAlternativeTo: {
+			{EntityTypeImport, EntityTypeImport},
+			{EntityTypeFunction, EntityTypeFunction},
+			{EntityTypeMethod, EntityTypeMethod},
+			{EntityTypeStruct, EntityTypeStruct},
+			{EntityTypeInterface, EntityTypeInterface},
+			{EntityTypeType, EntityTypeType},
+			{EntityTypeVariable, EntityTypeVariable},
+		},
 	}
 
 	constraints, exists := validConstraints[r.Type]
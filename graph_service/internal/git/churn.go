@@ -0,0 +1,281 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// FileHistory is how often a file changed over the history walked by
+// ChangeFrequency. Lines are those of the file at HEAD.
+type FileHistory struct {
+	// Commits is the number of commits that changed the file
+	Commits int
+
+	changes [][]int     // Lines at HEAD touched by each commit, ascending
+	authors []string    // Author of each commit, newest first as changes
+	times   []time.Time // Author time of each commit
+}
+
+// ChangesBetween returns how many commits touched a line from start to end,
+// inclusive, of the file as it is at HEAD
+func (h *FileHistory) ChangesBetween(start, end int) int {
+	count := 0
+	for _, lines := range h.changes {
+		i := sort.SearchInts(lines, start)
+		if i < len(lines) && lines[i] <= end {
+			count++
+		}
+	}
+	return count
+}
+
+// LastChange returns the author and time of the latest commit that touched a
+// line from start to end, inclusive, of the file as it is at HEAD; ok is
+// false when none did
+func (h *FileHistory) LastChange(start, end int) (author string, when time.Time, ok bool) {
+	for c, lines := range h.changes {
+		i := sort.SearchInts(lines, start)
+		if i < len(lines) && lines[i] <= end {
+			return h.authors[c], h.times[c], true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// ChangeFrequency walks the first-parent history of the repository
+// containing repoPath back from HEAD, stopping at the first commit older than
+// since unless it is zero, and returns the history of every file still
+// present at HEAD, keyed by its slash-separated path relative to repoPath.
+//
+// The lines each commit added or removed are mapped through the later
+// commits to the lines they became at HEAD, so code that moved since is
+// still attributed; lines that no longer exist are dropped. A removal that
+// is not a replacement counts against the line before it. Renames are
+// followed. A merge counts as one commit, with the changes it brought in
+// against its first parent.
+func ChangeFrequency(repoPath string, since time.Time) (map[string]*FileHistory, error) {
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	prefix, err := repoPrefix(repo, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+
+	histories := make(map[string]*FileHistory)
+	// The HEAD path and HEAD lines of each line of the version being walked,
+	// by path in that version. Files not in it are unchanged since.
+	lineMaps := make(map[string]*lineMap)
+	mapFor := func(path string) *lineMap {
+		if m, ok := lineMaps[path]; ok {
+			return m
+		}
+		return &lineMap{path: path}
+	}
+
+	for commit != nil {
+		if !since.IsZero() && commit.Committer.When.Before(since) {
+			break
+		}
+		var parent *object.Commit
+		if commit.NumParents() > 0 {
+			if parent, err = commit.Parent(0); err != nil {
+				return nil, fmt.Errorf("failed to read parent of %s: %w", commit.Hash, err)
+			}
+		}
+
+		patch, err := commitPatch(parent, commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w", commit.Hash, err)
+		}
+		// Paths the parent version holds other content under, or none at all
+		parentMaps := make(map[string]*lineMap)
+		for _, filePatch := range patch.FilePatches() {
+			if from, to := filePatch.Files(); to != nil && (from == nil || from.Path() != to.Path()) {
+				parentMaps[to.Path()] = &lineMap{}
+			}
+		}
+		for _, filePatch := range patch.FilePatches() {
+			from, to := filePatch.Files()
+			if to == nil {
+				// Deleted here, so not present at HEAD through this path
+				parentMaps[from.Path()] = &lineMap{}
+				continue
+			}
+			current := mapFor(to.Path())
+			delete(lineMaps, to.Path())
+			if current.path == "" || filePatch.IsBinary() {
+				if from != nil {
+					parentMaps[from.Path()] = &lineMap{path: current.path}
+				}
+				continue
+			}
+
+			touched, previous := current.apply(filePatch.Chunks())
+			if len(touched) > 0 {
+				history := histories[current.path]
+				if history == nil {
+					history = &FileHistory{}
+					histories[current.path] = history
+				}
+				history.Commits++
+				history.changes = append(history.changes, touched)
+				history.authors = append(history.authors, commit.Author.Name)
+				history.times = append(history.times, commit.Author.When)
+			}
+			if from != nil {
+				parentMaps[from.Path()] = previous
+			}
+		}
+		for path, m := range parentMaps {
+			lineMaps[path] = m
+		}
+		commit = parent
+	}
+
+	result := make(map[string]*FileHistory, len(histories))
+	for path, history := range histories {
+		rel, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			continue
+		}
+		for i := range history.changes {
+			sort.Ints(history.changes[i])
+		}
+		result[rel] = history
+	}
+	return result, nil
+}
+
+// repoPrefix returns the slash-separated path of repoPath within the work
+// tree of repo, with a trailing slash, or "" when it is the root
+func repoPrefix(repo *git.Repository, repoPath string) (string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to open work tree: %w", err)
+	}
+	root, err := filepath.EvalSymlinks(worktree.Filesystem.Root())
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve work tree: %w", err)
+	}
+	abs, err := filepath.Abs(repoPath)
+	if err == nil {
+		abs, err = filepath.EvalSymlinks(abs)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", repoPath, err)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." {
+		return "", err
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// commitPatch returns the changes commit made to parent, or to an empty tree
+// for a root commit, with renames detected
+func commitPatch(parent, commit *object.Commit) (*object.Patch, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if parent != nil {
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+	return changes.Patch()
+}
+
+// lineMap maps the lines of one version of a file to the file at HEAD. A
+// nil lines maps each line to itself: the file has not changed since. An
+// empty path means the file is not present at HEAD.
+type lineMap struct {
+	path  string
+	lines []int // HEAD line of each line, from line 1; 0 when it is gone
+}
+
+func (m *lineMap) head(line int) int {
+	if m.lines == nil {
+		return line
+	}
+	if line < 1 || line > len(m.lines) {
+		return 0
+	}
+	return m.lines[line-1]
+}
+
+// apply reads the chunks of a commit's diff of the version m maps. It
+// returns the HEAD lines the commit touched and the map of the version
+// before it.
+func (m *lineMap) apply(chunks []diff.Chunk) ([]int, *lineMap) {
+	touched := make(map[int]bool)
+	previous := &lineMap{path: m.path, lines: make([]int, 0)}
+	line := 1 // Next line of the new version
+	for i, chunk := range chunks {
+		n := chunkLines(chunk.Content())
+		switch chunk.Type() {
+		case diff.Equal:
+			for j := 0; j < n; j++ {
+				previous.lines = append(previous.lines, m.head(line+j))
+			}
+			line += n
+		case diff.Add:
+			for j := 0; j < n; j++ {
+				if head := m.head(line + j); head > 0 {
+					touched[head] = true
+				}
+			}
+			line += n
+		case diff.Delete:
+			for j := 0; j < n; j++ {
+				previous.lines = append(previous.lines, 0)
+			}
+			// Lines replaced by others count as the lines added instead
+			if i+1 < len(chunks) && chunks[i+1].Type() == diff.Add {
+				continue
+			}
+			if head := m.head(max(line-1, 1)); head > 0 {
+				touched[head] = true
+			}
+		}
+	}
+
+	lines := make([]int, 0, len(touched))
+	for head := range touched {
+		lines = append(lines, head)
+	}
+	return lines, previous
+}
+
+// chunkLines counts the lines of a chunk, the last of which may lack a
+// newline
+func chunkLines(content string) int {
+	n := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetLargeFunctions returns the functions and methods of Go, Python and
+// TypeScript files that span more than maxLines lines, largest first, as
+// candidates for breaking up. Each carries its "line_count", from the
+// declaration to the closing brace or last statement, its "statement_count"
+// and its cyclomatic "complexity", so a long but flat function can be told
+// from a long and tangled one; functions of the same length are ordered by
+// complexity, highest first, then by file and position. Closures and nested
+// functions count toward the function they are written in. Functions marked
+// with an onyx:ignore comment are not reported. It returns nil when the
+// builder is not available.
+//
+// Example:
+//
+//	for _, fn := range result.GetLargeFunctions(80) {
+//		fmt.Printf("%s:%d %s %v lines, complexity %v\n", fn.FilePath, fn.StartLine(), fn.Name,
+//			fn.GetProperty("line_count"), fn.GetProperty("complexity"))
+//	}
+func (r *BuildGraphResult) GetLargeFunctions(maxLines int) []*entities.Entity {
+	if r.Builder == nil {
+		return nil
+	}
+
+	large := make([]*entities.Entity, 0)
+	for _, function := range r.entitiesOfType(entities.EntityTypeFunction, entities.EntityTypeMethod) {
+		lines, ok := function.GetProperty("line_count").(int)
+		if ok && lines > maxLines && !function.IsIgnored() {
+			large = append(large, function)
+		}
+	}
+
+	sort.SliceStable(large, func(i, j int) bool {
+		linesI, _ := large[i].GetProperty("line_count").(int)
+		linesJ, _ := large[j].GetProperty("line_count").(int)
+		if linesI != linesJ {
+			return linesI > linesJ
+		}
+		complexityI, _ := large[i].GetProperty("complexity").(int)
+		complexityJ, _ := large[j].GetProperty("complexity").(int)
+		return complexityI > complexityJ
+	})
+	return large
+}
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetLogStatements returns the logging call sites found in the repository whose
+// level is at least minLevel. Levels are normalized across languages and
+// libraries to "trace", "debug", "info", "warn", "error" and "fatal"; an empty
+// minLevel returns every log statement.
+//
+// Each entity carries the normalized "level", a "message" snippet taken from the
+// first argument, the "logger" receiver and the "enclosing_function" ID and
+// "enclosing_function_name". Results are ordered by file and position.
+//
+// Example:
+//
+//	errorLogs, err := result.GetLogStatements("error")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, stmt := range errorLogs {
+//		fmt.Printf("%s:%d [%v] %v\n", stmt.FilePath, stmt.StartLine(),
+//			stmt.GetProperty("level"), stmt.GetProperty("message"))
+//	}
+func (r *BuildGraphResult) GetLogStatements(minLevel string) ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	minRank := 0
+	if minLevel != "" {
+		rank, ok := analyzer.LogLevelRank(minLevel)
+		if !ok {
+			return nil, fmt.Errorf("unknown log level: %s", minLevel)
+		}
+		minRank = rank
+	}
+
+	statements := make([]*entities.Entity, 0)
+	for _, entity := range r.entitiesOfType(entities.EntityTypeLogStatement) {
+		level, _ := entity.GetProperty("level").(string)
+		if rank, ok := analyzer.LogLevelRank(level); ok && rank >= minRank {
+			statements = append(statements, entity)
+		}
+	}
+	return statements, nil
+}
@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetMigrations returns the database migrations of the repository, one
+// Migration entity per file: Alembic revisions, golang-migrate and Flyway
+// SQL files, Prisma migration directories, and other .sql files under a
+// migrations directory. Each carries its "tool", "version", "description",
+// "direction" ("up", "down" or, for Flyway, "repeatable"; Alembic files hold
+// both) and "order", from 1 for the first to run in its directory; Alembic
+// revisions also carry their "revision" and "down_revision". The schema
+// changes of a migration are its children. Results are ordered by directory,
+// then order, with an up migration before its down.
+//
+// Example:
+//
+//	migrations, err := result.GetMigrations()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, m := range migrations {
+//		fmt.Printf("%v %s (%d changes)\n", m.GetProperty("order"), m.Name, len(m.Children))
+//	}
+func (r *BuildGraphResult) GetMigrations() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	migrations := r.entitiesOfType(entities.EntityTypeMigration)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrationLess(migrations[i], migrations[j])
+	})
+	return migrations, nil
+}
+
+// GetSchemaChanges returns the table, column and index operations of the
+// migrations, one SchemaChange entity per operation, or only those on table
+// when it is not empty, including renames to or from it. Table names are
+// compared without quotes, schema or case. Each change carries its
+// "operation" (create_table, drop_table, rename_table, add_column,
+// drop_column, alter_column, rename_column, create_index or drop_index), its
+// "table" and, as they apply, "new_table", "column", "new_column", "index"
+// and "columns", the columns of a created table or index. It also carries
+// the "direction" it runs in and its "position" in the migration, whose ID
+// is its "migration". The statement or op call is the Signature.
+//
+// In the graph, a change AFFECTS the class or struct of the ORM model mapped
+// to its table: a SQLAlchemy class with __tablename__, a Django model with
+// db_table, a TypeORM or Sequelize class decorated with @Entity or @Table, or
+// a Go struct with a TableName method, a bun table tag or gorm tags. Checking
+// the add_column changes of a model's table tells whether a new field has a
+// migration. Results are in migration order, then position.
+//
+// Example:
+//
+//	changes, err := result.GetSchemaChanges("users")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, c := range changes {
+//		fmt.Printf("%s:%d %s\n", c.FilePath, c.StartLine(), c.Name)
+//	}
+func (r *BuildGraphResult) GetSchemaChanges(table string) ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	table = strings.ToLower(strings.Trim(table, "\"`[]"))
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+
+	changes := make([]*entities.Entity, 0)
+	for _, change := range r.entitiesOfType(entities.EntityTypeSchemaChange) {
+		if table != "" {
+			name, _ := change.GetProperty("table").(string)
+			renamed, _ := change.GetProperty("new_table").(string)
+			if name != table && renamed != table {
+				continue
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i].Parent, changes[j].Parent
+		if a != b && a != nil && b != nil {
+			return migrationLess(a, b)
+		}
+		position := func(e *entities.Entity) int {
+			n, _ := e.GetProperty("position").(int)
+			return n
+		}
+		return position(changes[i]) < position(changes[j])
+	})
+	return changes, nil
+}
+
+// migrationLess orders migrations by directory, then order, then up before
+// down
+func migrationLess(a, b *entities.Entity) bool {
+	dirA, dirB := path.Dir(filepath.ToSlash(a.FilePath)), path.Dir(filepath.ToSlash(b.FilePath))
+	if a.GetProperty("tool") == "prisma" {
+		dirA = path.Dir(dirA)
+	}
+	if b.GetProperty("tool") == "prisma" {
+		dirB = path.Dir(dirB)
+	}
+	if dirA != dirB {
+		return dirA < dirB
+	}
+	orderA, _ := a.GetProperty("order").(int)
+	orderB, _ := b.GetProperty("order").(int)
+	if orderA != orderB {
+		return orderA < orderB
+	}
+	directionA, _ := a.GetProperty("direction").(string)
+	directionB, _ := b.GetProperty("direction").(string)
+	if directionA != directionB {
+		return directionA == "up"
+	}
+	return a.FilePath < b.FilePath
+}
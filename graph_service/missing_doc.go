@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetUndocumentedAPI returns a MissingDoc finding for every exported
+// function, method, class, type, interface or enum (and, in Go, package
+// variable) that has no documentation: no comment directly above it, or at
+// the end of its line, in Go, no docstring in Python, no /** JSDoc */ comment
+// in TypeScript. It is the worklist for documenting a public API.
+//
+// Python magic methods, TypeScript constructors, test files, generated Go
+// files and declarations marked with an onyx:ignore comment are not
+// reported. Each finding carries the "entity" ID and "entity_name" of the
+// declaration, its "entity_type", "line" and "language", and a "message"
+// such as "exported function Parse should have comment or be unexported".
+// Results are ordered by file and position.
+//
+// Example:
+//
+//	missing, err := result.GetUndocumentedAPI()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, m := range missing {
+//		fmt.Printf("%s:%v %v\n", m.FilePath, m.GetProperty("line"), m.GetProperty("message"))
+//	}
+func (r *BuildGraphResult) GetUndocumentedAPI() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeMissingDoc), nil
+}
+
+// GetDocumentationCoverage returns the percentage (0-100) of the
+// declarations checked by GetUndocumentedAPI that are documented. A
+// repository without any public API counts as fully documented.
+func (r *BuildGraphResult) GetDocumentationCoverage() (float64, error) {
+	if r.Builder == nil {
+		return 0, fmt.Errorf("builder not available")
+	}
+	checked, documented := 0, 0
+	for _, entity := range r.Builder.GetAllEntities() {
+		if value, ok := entity.GetProperty("documented").(bool); ok {
+			checked++
+			if value {
+				documented++
+			}
+		}
+	}
+	if checked == 0 {
+		return 100, nil
+	}
+	return float64(documented) / float64(checked) * 100, nil
+}
@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+)
+
+// Mutation is one entry of the audit log kept with BuildGraphOptions.AuditLog.
+// Operation is "build", "clear_file", "add_file", "update_file" or
+// "remove_file" and Trigger is "build", "resume", "scan", "watch" or "manual".
+type Mutation = analyzer.Mutation
+
+// GetMutationHistory returns the changes recorded in the database's audit log,
+// oldest first. The log spans every build into the same DBPath, so comparing
+// entries shows when and why the graph drifted from the source: for example a
+// resumed build that cleared files, or files re-analyzed by a live session
+// with the entities they gained and lost.
+//
+// It fails unless the graph was built with BuildGraphOptions.AuditLog.
+//
+// Example:
+//
+//	history, err := result.GetMutationHistory()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, m := range history {
+//		fmt.Printf("%s %s %s (+%d -%d)\n", m.Time.Format(time.RFC3339), m.Operation,
+//			m.FilePath, m.EntitiesAdded, m.EntitiesRemoved)
+//	}
+func (r *BuildGraphResult) GetMutationHistory() ([]Mutation, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	log := r.Builder.MutationLog()
+	if log == nil {
+		return nil, fmt.Errorf("audit log not enabled; build with AuditLog set")
+	}
+	return analyzer.ReadMutationLog(log.Path())
+}
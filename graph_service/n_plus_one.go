@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetNPlusOneQueries returns the N+1 query patterns found in the repository:
+// database queries and HTTP requests made inside a loop with arguments derived
+// from the loop variable, which issue one round trip per element where a
+// single batched call would do. Go, Python and TypeScript for loops, Python
+// comprehensions and TypeScript forEach/map callbacks are checked; test files
+// and functions marked with an onyx:ignore comment are not.
+//
+// Each entity spans the call and carries its "callee", the "access_kind"
+// ("query" or "api"), the "loop_kind", the "loop_variables" bound by the loop
+// and the names the call "depends_on", the "loop_start_line" and
+// "loop_end_line", and the "enclosing_function" ID and
+// "enclosing_function_name". TypeScript calls also recognized by the analyzer
+// as API calls carry the APICall entity's ID in "api_call".
+//
+// Calls are recognized by receiver and method names such as db.QueryRow,
+// User.objects.get, prisma.user.findUnique and axios.get, so wrappers with
+// other names are missed. Results are ordered by file and position.
+//
+// Example:
+//
+//	issues, err := result.GetNPlusOneQueries()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, issue := range issues {
+//		fmt.Printf("%s:%d %v in %v loop at line %v\n", issue.FilePath, issue.StartLine(),
+//			issue.GetProperty("callee"), issue.GetProperty("loop_kind"), issue.GetProperty("loop_start_line"))
+//	}
+func (r *BuildGraphResult) GetNPlusOneQueries() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeNPlusOne), nil
+}
@@ -0,0 +1,271 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of OrphanedTest
+const (
+	TestTargetOrphaned = "orphaned_test"
+	TestTargetMisnamed = "misnamed_test"
+)
+
+// testTargetTypes are the production declarations a test can be named after
+var testTargetTypes = map[entities.EntityType]bool{
+	entities.EntityTypeFunction:  true,
+	entities.EntityTypeMethod:    true,
+	entities.EntityTypeClass:     true,
+	entities.EntityTypeStruct:    true,
+	entities.EntityTypeInterface: true,
+	entities.EntityTypeType:      true,
+	entities.EntityTypeEnum:      true,
+}
+
+// OrphanedTest is a test whose name points at a declaration that does not
+// exist
+type OrphanedTest struct {
+	// Kind is "orphaned_test" when the test exercises no production code, or
+	// "misnamed_test" when it does, under another name
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+
+	Test string `json:"test"`
+	// Target is the declaration inferred from the test name, e.g.
+	// Calculator.Add for TestCalculator_Add
+	Target string `json:"target"`
+	// Calls are the production declarations the test calls or covers
+	Calls    []string `json:"calls,omitempty"`
+	FilePath string   `json:"file_path"`
+	Line     int      `json:"line"`
+
+	// Entity is the TestFunction
+	Entity *entities.Entity `json:"-"`
+}
+
+// GetOrphanedTests finds the Go and Python tests whose inferred target does
+// not match any declaration of the repository, typically tests left behind
+// when the code they were written for was renamed or removed. The target is
+// the test_target of the test, the name without its Test or test_ prefix:
+// TestCalculator_Add targets the method Add of Calculator when Calculator is
+// a type, and test_parse_config_missing_file targets parse_config_missing_file
+// or any shorter prefix of it such as parse_config. Names are compared
+// ignoring case and underscores, and the tests of a Python TestCalculator
+// class target methods of Calculator. Declarations in test files do not
+// count.
+//
+// A test whose target is missing is reported as "misnamed_test" when it still
+// calls or covers production code, listing those declarations, and as
+// "orphaned_test" when it does not. TypeScript tests are named by strings and
+// their target is taken from the calls they make, so they are not checked,
+// nor are TestMain and tests marked with an onyx:ignore comment. Results are
+// ordered by file and line.
+//
+// Example:
+//
+//	tests, err := result.GetOrphanedTests()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, test := range tests {
+//		fmt.Printf("%s:%d %s: %s\n", test.FilePath, test.Line, test.Kind, test.Message)
+//	}
+func (r *BuildGraphResult) GetOrphanedTests() ([]*OrphanedTest, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	all := r.Builder.GetAllEntities()
+
+	// Production declarations by normalized name, and methods also by
+	// normalized owner and name
+	declared := &testTargets{
+		callables: make(map[string]bool),
+		functions: make(map[string]bool),
+		members:   make(map[string]bool),
+		types:     make(map[string]bool),
+	}
+	for _, entity := range all {
+		if !testTargetTypes[entity.Type] || entity.IsTest() || entity.IsTestFile() {
+			continue
+		}
+		name := testTargetKey(entity.Name)
+		switch entity.Type {
+		case entities.EntityTypeFunction:
+			declared.callables[name] = true
+			declared.functions[name] = true
+		case entities.EntityTypeMethod:
+			declared.callables[name] = true
+			owner := ""
+			if entity.Parent != nil {
+				owner = entity.Parent.Name
+			} else if receiver, ok := entity.GetProperty("receiver").(string); ok {
+				owner = analyzer.ReceiverTypeName(receiver)
+			}
+			if owner != "" {
+				declared.members[testTargetKey(owner)+"."+name] = true
+			}
+		default:
+			declared.types[name] = true
+		}
+	}
+
+	// Production declarations each test calls or covers
+	calls := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, rel := range r.Builder.GetAllRelationships() {
+		switch rel.Type {
+		case entities.RelationshipTypeCalls, entities.RelationshipTypeTests, entities.RelationshipTypeCovers:
+		default:
+			continue
+		}
+		target := all[rel.TargetID]
+		if target == nil || !testTargetTypes[target.Type] || target.IsTest() || target.IsTestFile() {
+			continue
+		}
+		if key := rel.SourceID + ":" + target.ID; !seen[key] {
+			seen[key] = true
+			calls[rel.SourceID] = append(calls[rel.SourceID], target.Name)
+		}
+	}
+
+	tests := make([]*OrphanedTest, 0)
+	for _, test := range r.entitiesOfType(entities.EntityTypeTestFunction) {
+		if test.IsIgnored() || test.Name == "TestMain" {
+			continue
+		}
+		var target string
+		var matched bool
+		file := r.Builder.GetFiles()[test.FilePath]
+		if file == nil {
+			continue
+		}
+		switch file.Language {
+		case "go":
+			target, matched = goTestTargetExists(test.GetTestTarget(), declared)
+		case "python":
+			target, matched = pythonTestTargetExists(test, declared)
+		default:
+			continue
+		}
+		if target == "" || matched {
+			continue
+		}
+
+		orphan := &OrphanedTest{
+			Kind:     TestTargetOrphaned,
+			Test:     test.Name,
+			Target:   target,
+			Calls:    calls[test.ID],
+			FilePath: test.FilePath,
+			Line:     test.StartLine(),
+			Entity:   test,
+		}
+		if len(orphan.Calls) > 0 {
+			sort.Strings(orphan.Calls)
+			orphan.Kind = TestTargetMisnamed
+			orphan.Message = fmt.Sprintf("%s is named for %s, which does not exist, but tests %s",
+				test.Name, target, strings.Join(orphan.Calls, ", "))
+		} else {
+			orphan.Message = fmt.Sprintf("%s is named for %s, which does not exist, and tests no other code",
+				test.Name, target)
+		}
+		tests = append(tests, orphan)
+	}
+
+	sort.SliceStable(tests, func(i, j int) bool {
+		if tests[i].FilePath != tests[j].FilePath {
+			return tests[i].FilePath < tests[j].FilePath
+		}
+		return tests[i].Line < tests[j].Line
+	})
+	return tests, nil
+}
+
+// testTargets are the production declarations a test name can match, by
+// testTargetKey
+type testTargets struct {
+	// callables are functions and methods, functions are functions only
+	callables map[string]bool
+	functions map[string]bool
+	// members are methods as owner.method
+	members map[string]bool
+	types   map[string]bool
+}
+
+// matches reports whether the words of a test name, or a prefix of them
+// followed by a scenario such as _Overflow, name a declaration: a method of
+// owner when one is given, otherwise a function, a method of any type or,
+// only for the whole name, a type. A type or a method of another type never
+// matches a name with an owner, so that TestCalculator_Add is not satisfied
+// by Calculator alone once Add is gone from it.
+func (t *testTargets) matches(owner string, words []string) bool {
+	for n := len(words); n >= 1; n-- {
+		name := testTargetKey(strings.Join(words[:n], "_"))
+		if owner != "" {
+			if t.members[testTargetKey(owner)+"."+name] {
+				return true
+			}
+			continue
+		}
+		if t.callables[name] || (n == len(words) && t.types[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+// goTestTargetExists resolves the target of a Go test, the test name without
+// Test: Calculator_Add_Overflow matches the method Calculator.Add, Parse_Empty
+// the function Parse and Calculator the type. It returns the target as
+// written, with the owner separated by a dot.
+func goTestTargetExists(target string, declared *testTargets) (string, bool) {
+	words := strings.Split(strings.Trim(target, "_"), "_")
+	if words[0] == "" {
+		return "", false
+	}
+	if len(words) >= 2 && declared.types[testTargetKey(words[0])] {
+		if declared.matches(words[0], words[1:]) {
+			return target, true
+		}
+		return words[0] + "." + strings.Join(words[1:], "_"), false
+	}
+	return target, declared.matches("", words)
+}
+
+// pythonTestTargetExists resolves the target of a Python test, the name
+// without test_, as a function or, when the test is a method of a TestX class
+// and X is a class, as a method of X or a module-level function
+func pythonTestTargetExists(test *entities.Entity, declared *testTargets) (string, bool) {
+	target := test.GetTestTarget()
+	words := strings.Split(strings.Trim(target, "_"), "_")
+	if words[0] == "" {
+		return "", false
+	}
+	owner := ""
+	if test.Parent != nil && strings.HasPrefix(test.Parent.Name, "Test") {
+		owner = strings.TrimPrefix(test.Parent.Name, "Test")
+	}
+	if owner == "" || !declared.types[testTargetKey(owner)] {
+		return target, declared.matches("", words)
+	}
+	if declared.matches(owner, words) {
+		return target, true
+	}
+	for n := len(words); n >= 1; n-- {
+		if declared.functions[testTargetKey(strings.Join(words[:n], "_"))] {
+			return target, true
+		}
+	}
+	return owner + "." + target, false
+}
+
+// testTargetKey normalizes a name for matching test names, which spell the
+// same declaration as ParseConfig, parseConfig or parse_config
+func testTargetKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
@@ -0,0 +1,215 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// FileOutline is a compact, ordered summary of a single source file. It lets an
+// agent understand a file's structure without reading its full contents.
+//
+// Sections are always emitted in the same order (imports, then types, then
+// top-level functions) and items inside each section follow source order.
+type FileOutline struct {
+	Path      string         `json:"path"`
+	Language  string         `json:"language"`
+	Imports   []*OutlineItem `json:"imports"`
+	Types     []*OutlineItem `json:"types"`
+	Functions []*OutlineItem `json:"functions"`
+}
+
+// OutlineItem is a single entry in a FileOutline. Types carry their methods and
+// fields as Children, nested in source order.
+type OutlineItem struct {
+	Name      string         `json:"name"`
+	Kind      string         `json:"kind"`
+	Signature string         `json:"signature,omitempty"`
+	Line      int            `json:"line"`
+	EndLine   int            `json:"end_line"`
+	Children  []*OutlineItem `json:"children,omitempty"`
+}
+
+// GetFileOutline returns a nested outline of a file: its imports, its types
+// with their methods and fields, and its top-level functions, each with a
+// signature and line number.
+//
+// Parameters:
+//   - filePath: Path to the source file (exact or suffix match, as in GetFileEntities)
+//
+// Returns:
+//   - *FileOutline: Ordered outline of the file
+//   - error: Non-nil if the builder is unavailable or the file was not analyzed
+//
+// Example:
+//
+//	outline, err := result.GetFileOutline("internal/db/kuzudb.go")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, t := range outline.Types {
+//		fmt.Printf("%d: %s\n", t.Line, t.Name)
+//		for _, m := range t.Children {
+//			fmt.Printf("  %d: %s\n", m.Line, m.Signature)
+//		}
+//	}
+func (r *BuildGraphResult) GetFileOutline(filePath string) (*FileOutline, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	file := r.findFile(filePath)
+	if file == nil {
+		return nil, fmt.Errorf("file not found: %s", filePath)
+	}
+
+	outline := &FileOutline{
+		Path:      file.Path,
+		Language:  file.Language,
+		Imports:   make([]*OutlineItem, 0),
+		Types:     make([]*OutlineItem, 0),
+		Functions: make([]*OutlineItem, 0),
+	}
+
+	all := file.GetAllEntities()
+	sortEntitiesBySource(all)
+
+	// Types are collected first so that methods can be attached to them
+	typeItems := make(map[string]*OutlineItem)
+	typeEntities := make(map[*entities.Entity]*OutlineItem)
+	for _, entity := range all {
+		if !isOutlineType(entity) || isNestedInType(entity) {
+			continue
+		}
+		item := newOutlineItem(entity)
+		outline.Types = append(outline.Types, item)
+		typeEntities[entity] = item
+		if _, exists := typeItems[entity.Name]; !exists {
+			typeItems[entity.Name] = item
+		}
+	}
+
+	goFields := make(map[uint32]bool)
+	for _, entity := range all {
+		switch entity.Type {
+		case entities.EntityTypeImport:
+			outline.Imports = append(outline.Imports, newOutlineItem(entity))
+
+		case entities.EntityTypeFunction, entities.EntityTypeMethod:
+			if owner := outlineOwner(entity, typeItems, typeEntities); owner != nil {
+				owner.Children = append(owner.Children, newOutlineItem(entity))
+			} else if entity.Parent == nil {
+				outline.Functions = append(outline.Functions, newOutlineItem(entity))
+			}
+
+		case entities.EntityTypeProperty:
+			owner := outlineOwner(entity, typeItems, typeEntities)
+			if owner == nil {
+				continue
+			}
+			item := newOutlineItem(entity)
+			if file.Language == "go" {
+				// Go fields are listed once per declaration, so "X, Y int"
+				// is a single item named after its first field
+				if goFields[entity.StartByte] {
+					continue
+				}
+				goFields[entity.StartByte] = true
+				item.Kind = "Field"
+			}
+			owner.Children = append(owner.Children, item)
+		}
+	}
+
+	for _, item := range outline.Types {
+		sort.SliceStable(item.Children, func(i, j int) bool {
+			return item.Children[i].Line < item.Children[j].Line
+		})
+	}
+
+	return outline, nil
+}
+
+// findFile looks up an analyzed file by exact path, falling back to a suffix match
+func (r *BuildGraphResult) findFile(filePath string) *entities.File {
+	if file := r.Builder.GetFile(filePath); file != nil {
+		return file
+	}
+
+	var paths []string
+	files := r.Builder.GetFiles()
+	for path := range files {
+		if strings.HasSuffix(path, filePath) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+	return files[paths[0]]
+}
+
+// sortEntitiesBySource orders entities by their position in the source file
+func sortEntitiesBySource(list []*entities.Entity) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].StartByte != list[j].StartByte {
+			return list[i].StartByte < list[j].StartByte
+		}
+		return list[i].Name < list[j].Name
+	})
+}
+
+// isOutlineType reports whether an entity is shown in the types section
+func isOutlineType(entity *entities.Entity) bool {
+	switch entity.Type {
+	case entities.EntityTypeClass, entities.EntityTypeStruct, entities.EntityTypeInterface,
+		entities.EntityTypeType, entities.EntityTypeEnum:
+		return true
+	}
+	return false
+}
+
+// isNestedInType reports whether an entity is declared inside another type
+func isNestedInType(entity *entities.Entity) bool {
+	for p := entity.Parent; p != nil; p = p.Parent {
+		if isOutlineType(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// newOutlineItem converts an entity into an outline item
+func newOutlineItem(entity *entities.Entity) *OutlineItem {
+	signature := entity.Signature
+	if signature == "" {
+		signature = entity.Name
+	}
+	return &OutlineItem{
+		Name:      entity.Name,
+		Kind:      string(entity.Type),
+		Signature: strings.TrimSpace(signature),
+		Line:      entity.StartLine(),
+		EndLine:   entity.EndLine(),
+	}
+}
+
+// outlineOwner finds the type outline item a method or field belongs to, either
+// through the entity hierarchy (Python, TypeScript) or a Go receiver
+func outlineOwner(entity *entities.Entity, byName map[string]*OutlineItem, byEntity map[*entities.Entity]*OutlineItem) *OutlineItem {
+	if entity.Parent != nil {
+		if item, ok := byEntity[entity.Parent]; ok {
+			return item
+		}
+	}
+	if receiver, ok := entity.GetProperty("receiver").(string); ok {
+		if item, ok := byName[analyzer.ReceiverTypeName(receiver)]; ok {
+			return item
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+)
+
+// PackageImport is an import from one package of the repository to another,
+// with the files of the importing package that make it. Packages are
+// directories relative to the repository root, "." for the root itself.
+type PackageImport = analyzer.PackageImport
+
+// GetPackageLayers sorts the packages of the repository into dependency
+// layers: the first layer holds the packages that import no other package of
+// the repository, and every later layer only imports packages of the layers
+// before it. A package that imports one of its own or a later layer breaks the
+// architecture, which is how an agent can check whether a proposed import
+// would be allowed.
+//
+// Packages that import each other in a cycle cannot be ordered and share a
+// layer; GetPackageCycles reports the imports that close those cycles. Imports
+// are resolved from the Import entities of Go (through the repository's
+// go.mod files), Python and TypeScript files; third-party packages are left
+// out. Packages within a layer are sorted by path.
+//
+// Example:
+//
+//	layers, err := result.GetPackageLayers()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for i, layer := range layers {
+//		fmt.Printf("layer %d: %v\n", i, layer)
+//	}
+func (r *BuildGraphResult) GetPackageLayers() ([][]string, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	layers, _ := packageLayers(r.Builder.Packages(), r.Builder.PackageImports())
+	return layers, nil
+}
+
+// GetPackageCycles returns the package imports that take part in an import
+// cycle: each one goes from a package to another of the same layer of
+// GetPackageLayers. Removing enough of them, such as by moving shared code to
+// a new package, lets the packages be layered. Results are ordered by
+// importing then imported package; an empty result means the package graph
+// is acyclic.
+//
+// Example:
+//
+//	cycles, err := result.GetPackageCycles()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, imp := range cycles {
+//		fmt.Printf("%s -> %s (%v)\n", imp.From, imp.To, imp.Files)
+//	}
+func (r *BuildGraphResult) GetPackageCycles() ([]PackageImport, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	_, cycles := packageLayers(r.Builder.Packages(), r.Builder.PackageImports())
+	return cycles, nil
+}
+
+// packageLayers groups the packages into strongly connected components, so
+// each import cycle becomes a single node, then layers the resulting acyclic
+// graph with Kahn's algorithm. Imports within a component are the cycles.
+func packageLayers(packages []string, imports []PackageImport) ([][]string, []PackageImport) {
+	deps := make(map[string][]string, len(packages))
+	for _, imp := range imports {
+		deps[imp.From] = append(deps[imp.From], imp.To)
+	}
+	component := stronglyConnected(packages, deps)
+
+	// Each component waits for the components it imports
+	members := make(map[int][]string)
+	waiting := make(map[int]map[int]bool)
+	dependents := make(map[int][]int)
+	for _, pkg := range packages {
+		c := component[pkg]
+		members[c] = append(members[c], pkg)
+		if waiting[c] == nil {
+			waiting[c] = make(map[int]bool)
+		}
+	}
+	cycles := make([]PackageImport, 0)
+	for _, imp := range imports {
+		from, to := component[imp.From], component[imp.To]
+		if from == to {
+			cycles = append(cycles, imp)
+			continue
+		}
+		if !waiting[from][to] {
+			waiting[from][to] = true
+			dependents[to] = append(dependents[to], from)
+		}
+	}
+
+	layers := make([][]string, 0)
+	ready := make([]int, 0)
+	for c := range members {
+		if len(waiting[c]) == 0 {
+			ready = append(ready, c)
+		}
+	}
+	for len(ready) > 0 {
+		layer := make([]string, 0)
+		next := make([]int, 0)
+		for _, c := range ready {
+			layer = append(layer, members[c]...)
+			for _, dependent := range dependents[c] {
+				delete(waiting[dependent], c)
+				if len(waiting[dependent]) == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		sort.Strings(layer)
+		layers = append(layers, layer)
+		ready = next
+	}
+	return layers, cycles
+}
+
+// stronglyConnected numbers the strongly connected components of the import
+// graph using Tarjan's algorithm, returning the component of each package
+func stronglyConnected(packages []string, deps map[string][]string) map[string]int {
+	index := make(map[string]int, len(packages))
+	lowLink := make(map[string]int, len(packages))
+	onStack := make(map[string]bool)
+	component := make(map[string]int, len(packages))
+	stack := make([]string, 0)
+	next, components := 0, 0
+
+	var visit func(pkg string)
+	visit = func(pkg string) {
+		index[pkg], lowLink[pkg] = next, next
+		next++
+		stack = append(stack, pkg)
+		onStack[pkg] = true
+
+		for _, dep := range deps[pkg] {
+			if _, seen := index[dep]; !seen {
+				visit(dep)
+				lowLink[pkg] = min(lowLink[pkg], lowLink[dep])
+			} else if onStack[dep] {
+				lowLink[pkg] = min(lowLink[pkg], index[dep])
+			}
+		}
+
+		if lowLink[pkg] == index[pkg] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = components
+				if top == pkg {
+					break
+				}
+			}
+			components++
+		}
+	}
+
+	for _, pkg := range packages {
+		if _, seen := index[pkg]; !seen {
+			visit(pkg)
+		}
+	}
+	return component
+}
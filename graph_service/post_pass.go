@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"fmt"
+	"time"
+)
+
+// PostPass is an analysis run over the graph once it is built, given with
+// BuildGraphOptions.PostPasses. Passes see every entity and relationship
+// through the result and typically record what they find as entity
+// properties, which later passes and queries can use. An error fails the
+// build.
+//
+// Besides PostPassFunc for passes written as a function, the passes built in
+// are CoverageReportPass and ValidatePass; WithChurn and ValidateAfterBuild
+// add theirs to the pipeline too.
+//
+// Example:
+//
+//	countTODOs := graph.PostPassFunc(func(r *graph.BuildGraphResult) error {
+//		for _, e := range r.Builder.GetAllEntities() {
+//			e.SetProperty("todos", strings.Count(e.Body, "TODO"))
+//		}
+//		return nil
+//	})
+//	result, err := graph.BuildGraph(graph.BuildGraphOptions{
+//		RepoPath:   ".",
+//		PostPasses: []graph.PostPass{countTODOs},
+//	})
+type PostPass interface {
+	Run(result *BuildGraphResult) error
+}
+
+// PostPassFunc adapts a function to PostPass
+type PostPassFunc func(result *BuildGraphResult) error
+
+// Run calls f(result)
+func (f PostPassFunc) Run(result *BuildGraphResult) error {
+	return f(result)
+}
+
+// CoverageReportPass imports a coverage report with ImportCoverageReport as
+// part of the build, so passes after it and the result see runtime coverage
+func CoverageReportPass(path, format string) PostPass {
+	return PostPassFunc(func(r *BuildGraphResult) error {
+		return r.ImportCoverageReport(path, format)
+	})
+}
+
+// ValidatePass runs Validate and stores what it finds in
+// BuildGraphResult.ConsistencyIssues, as ValidateAfterBuild does.
+// Inconsistencies do not fail the build.
+func ValidatePass() PostPass {
+	return PostPassFunc(func(r *BuildGraphResult) error {
+		r.ConsistencyIssues = r.Validate()
+		return nil
+	})
+}
+
+// runPostPasses runs passes in order, stopping at the first that fails
+func (r *BuildGraphResult) runPostPasses(passes []PostPass) error {
+	start := time.Now()
+	defer func() { r.postPassTime += time.Since(start) }()
+
+	for i, pass := range passes {
+		if err := pass.Run(r); err != nil {
+			return fmt.Errorf("post-analysis pass %d failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/db"
+)
+
+// QueryFormat selects how RunQuery renders Cypher query results
+type QueryFormat = db.QueryFormat
+
+// QueryRows is the typed result of a Cypher query: the column names and one
+// slice of values per row
+type QueryRows = db.QueryRows
+
+// RowIterator reads the rows of a Cypher query one at a time, each keyed by
+// column name
+type RowIterator = db.RowIterator
+
+// Query result formats
+const (
+	// QueryFormatText is tab-pipe-delimited text without a header, as
+	// returned by Database.ExecuteQuery
+	QueryFormatText = db.QueryFormatText
+
+	// QueryFormatJSON is an array of objects keyed by column name, suited to
+	// tools and LLM agents
+	QueryFormatJSON = db.QueryFormatJSON
+
+	// QueryFormatCSV is CSV with a header row
+	QueryFormatCSV = db.QueryFormatCSV
+
+	// QueryFormatTable is an aligned table for display in a terminal
+	QueryFormatTable = db.QueryFormatTable
+)
+
+// ErrWriteQuery is wrapped by the error returned for a query that would
+// modify the graph: by QueryRows, QueryStream, RunQuery and QueryGraph when
+// ReadOnly is set, and by the package-level QueryGraph always
+var ErrWriteQuery = db.ErrWriteQuery
+
+// ParseQueryFormat returns the QueryFormat named by s ("text", "json", "csv"
+// or "table"), ignoring case
+func ParseQueryFormat(s string) (QueryFormat, error) {
+	return db.ParseQueryFormat(s)
+}
+
+// QueryRows executes a Cypher query and returns its typed rows. The query is
+// interrupted if ctx is cancelled.
+func (r *BuildGraphResult) QueryRows(ctx context.Context, query string) (*QueryRows, error) {
+	if r.Database == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	if err := r.checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	return r.Database.QueryRowsContext(ctx, query)
+}
+
+// QueryStream executes a Cypher query and returns an iterator over its rows,
+// for results too large to hold in memory at once or that should be written
+// out as they are read, such as a CSV export. The iterator holds the KuzuDB
+// result open until its last row is read, ctx is cancelled or it is closed,
+// so close it when stopping early. The query is interrupted if ctx is
+// cancelled while it runs.
+//
+// Example:
+//
+//	rows, err := result.QueryStream(ctx, "MATCH (f:Function) RETURN f.name, f.file_path")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := rows.WriteCSV(os.Stdout); err != nil {
+//		log.Fatal(err)
+//	}
+func (r *BuildGraphResult) QueryStream(ctx context.Context, query string) (*RowIterator, error) {
+	if r.Database == nil {
+		return nil, fmt.Errorf("database not available")
+	}
+	if err := r.checkReadOnly(query); err != nil {
+		return nil, err
+	}
+	return r.Database.QueryStream(ctx, query)
+}
+
+// RunQuery executes a Cypher query and renders the result in the given format.
+// The query is interrupted if ctx is cancelled.
+//
+// Example:
+//
+//	table, err := result.RunQuery(ctx, "MATCH (s:Struct) RETURN s.name, s.file_path", graph.QueryFormatTable)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Print(table)
+func (r *BuildGraphResult) RunQuery(ctx context.Context, query string, format QueryFormat) (string, error) {
+	if r.Database == nil {
+		return "", fmt.Errorf("database not available")
+	}
+	if err := r.checkReadOnly(query); err != nil {
+		return "", err
+	}
+	return r.Database.ExecuteQueryFormat(ctx, query, format)
+}
+
+// checkReadOnly rejects a query that would modify the graph when ReadOnly is
+// set
+func (r *BuildGraphResult) checkReadOnly(query string) error {
+	if !r.ReadOnly {
+		return nil
+	}
+	return checkWriteQuery(query)
+}
+
+// checkWriteQuery rejects a query that would modify the graph
+func checkWriteQuery(query string) error {
+	return db.CheckReadOnly(query)
+}
@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetRaceRisks returns the Go package-level variables and struct fields that
+// may be part of a data race: code run as a goroutine accesses them without
+// an obvious lock while they are also written without one. It is a static
+// heuristic to point an investigation, or a go test -race run, at shared
+// state, not a proof of a race.
+//
+// Code runs as a goroutine when it is in a function literal started with go
+// or passed to the Go method of an errgroup or WaitGroup, or in a function or
+// method of the same package started by name with go; functions those call
+// are not followed. Fields count when used through the receiver of a method.
+// An access is locked when a Lock or RLock call comes before it in its
+// function, or when its address is passed to sync/atomic. Variables and
+// fields of sync and atomic types and channels, and accesses in init
+// functions, are never at risk.
+//
+// Each RaceRisk entity is named after the variable, or Type.field, and is
+// located at the first unlocked access from a goroutine. It carries the
+// "state" ("variable" or "field"), the ID of the "variable" entity, the
+// "goroutine" function, the number of unlocked goroutine "accesses" and
+// unlocked "writes", and a "message". Every access recorded also links its
+// function to the variable or field with a READS or WRITES relationship,
+// whose "locked" and "concurrent" properties tell how it was made. Results
+// are ordered by file and position.
+//
+// Example:
+//
+//	risks, err := result.GetRaceRisks()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, risk := range risks {
+//		fmt.Printf("%s:%d %v\n", risk.FilePath, risk.StartLine(), risk.GetProperty("message"))
+//	}
+func (r *BuildGraphResult) GetRaceRisks() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+	return r.entitiesOfType(entities.EntityTypeRaceRisk), nil
+}
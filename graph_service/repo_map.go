@@ -0,0 +1,422 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// repoMapCharsPerToken approximates how many characters of code or English
+// text a tokenizer packs into one token, to fit ExportRepoMap to a budget
+const repoMapCharsPerToken = 4.0
+
+// repoMapMaxDeclarations bounds the key declarations listed by ExportRepoMap,
+// which would otherwise list every type and function without a budget
+const repoMapMaxDeclarations = 40
+
+// repoMapSection is a titled part of the repository map. Items are in the
+// order they are shown; importance decides which are dropped first.
+type repoMapSection struct {
+	title   string
+	items   []repoMapItem
+	grouped bool // Items are listed on one line per group, as packages by layer
+}
+
+type repoMapItem struct {
+	text       string
+	group      int
+	importance int
+}
+
+// ExportRepoMap returns a compact textual overview of the repository, meant
+// to be handed to an LLM once, as part of its system prompt, so it starts out
+// knowing how the code is laid out: a summary of the files by language and
+// the declarations found, the package layers of GetPackageLayers, the entry
+// points (Go main functions, Python __main__ guards and the commands of
+// GetCLIFlags), the HTTP endpoints and the most referenced types and
+// functions.
+//
+// The map is kept within maxTokens, estimated at four characters per token.
+// To fit, detail is dropped from the least important section first (key
+// declarations, then endpoints, entry points and packages), and within a
+// section from its least important items: the declarations referenced the
+// least, the endpoints with the simplest handlers, the commands with the
+// fewest flags and the packages with the fewest files. Every section keeps
+// its heading and says how many items were left out, and every layer keeps
+// its line, so the structure survives even a small budget. The summary and
+// headings are never dropped, so a budget too small for them is exceeded. A
+// maxTokens of zero or less means no budget.
+//
+// Example:
+//
+//	repoMap := result.ExportRepoMap(2000)
+//	system := basePrompt + "\n\n" + repoMap
+func (r *BuildGraphResult) ExportRepoMap(maxTokens int) string {
+	if r.Builder == nil {
+		return ""
+	}
+	summary := r.repoMapSummary()
+	sections := []repoMapSection{
+		r.repoMapLayers(),
+		r.repoMapEntryPoints(),
+		r.repoMapEndpoints(),
+		r.repoMapDeclarations(),
+	}
+
+	// Items in the order they are dropped: the last section first, and the
+	// least important items of a section first
+	type ref struct{ section, item int }
+	order := make([]ref, 0)
+	for s := len(sections) - 1; s >= 0; s-- {
+		refs := make([]ref, len(sections[s].items))
+		for i := range refs {
+			refs[i] = ref{s, len(refs) - 1 - i}
+		}
+		items := sections[s].items
+		sort.SliceStable(refs, func(i, j int) bool {
+			return items[refs[i].item].importance < items[refs[j].item].importance
+		})
+		order = append(order, refs...)
+	}
+
+	render := func(dropped int) string {
+		drop := make(map[ref]bool, dropped)
+		for _, d := range order[:dropped] {
+			drop[d] = true
+		}
+		var b strings.Builder
+		b.WriteString(summary)
+		for s, section := range sections {
+			kept := make([]bool, len(section.items))
+			for i := range kept {
+				kept[i] = !drop[ref{s, i}]
+			}
+			section.render(&b, kept)
+		}
+		return b.String()
+	}
+
+	fits := func(text string) bool {
+		return maxTokens <= 0 ||
+			int(math.Ceil(float64(utf8.RuneCountInString(text))/repoMapCharsPerToken)) <= maxTokens
+	}
+
+	// Dropping items only shortens the map, but for the odd "+N more" count
+	// growing a digit, so search for the fewest items to drop then step past
+	// any such bump
+	dropped := sort.Search(len(order), func(k int) bool { return fits(render(k)) })
+	text := render(dropped)
+	for !fits(text) && dropped < len(order) {
+		dropped++
+		text = render(dropped)
+	}
+	return text
+}
+
+// render writes the section with the items kept, noting how many were left
+// out
+func (s repoMapSection) render(b *strings.Builder, kept []bool) {
+	if len(s.items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n", s.title)
+
+	if s.grouped {
+		for start := 0; start < len(s.items); {
+			group := s.items[start].group
+			names := make([]string, 0)
+			omitted := 0
+			end := start
+			for ; end < len(s.items) && s.items[end].group == group; end++ {
+				if kept[end] {
+					names = append(names, s.items[end].text)
+				} else {
+					omitted++
+				}
+			}
+			switch {
+			case len(names) == 0:
+				fmt.Fprintf(b, "%d: %d packages\n", group, omitted)
+			case omitted > 0:
+				fmt.Fprintf(b, "%d: %s, +%d more\n", group, strings.Join(names, ", "), omitted)
+			default:
+				fmt.Fprintf(b, "%d: %s\n", group, strings.Join(names, ", "))
+			}
+			start = end
+		}
+		return
+	}
+
+	omitted := 0
+	for i, item := range s.items {
+		if kept[i] {
+			fmt.Fprintf(b, "- %s\n", item.text)
+		} else {
+			omitted++
+		}
+	}
+	if omitted > 0 {
+		fmt.Fprintf(b, "- ... %d more\n", omitted)
+	}
+}
+
+// repoMapSummary counts the files by language and the declarations found
+func (r *BuildGraphResult) repoMapSummary() string {
+	languages := make(map[string]int)
+	for _, file := range r.Builder.GetFiles() {
+		languages[file.Language]++
+	}
+	names := make([]string, 0, len(languages))
+	for language := range languages {
+		names = append(names, language)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if languages[names[i]] != languages[names[j]] {
+			return languages[names[i]] > languages[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	counts := make([]string, len(names))
+	for i, language := range names {
+		counts[i] = fmt.Sprintf("%s %d", language, languages[language])
+	}
+
+	var b strings.Builder
+	b.WriteString("# Repository map\n")
+	fmt.Fprintf(&b, "%d files (%s), %d functions, %d methods, %d classes, structs and interfaces\n",
+		r.Stats.FilesCount, strings.Join(counts, ", "),
+		r.Stats.FunctionsCount, r.Stats.MethodsCount, r.Stats.ClassesCount)
+	if r.Partial {
+		fmt.Fprintf(&b, "Partial: %d files were not analyzed before the time limit\n", len(r.UnanalyzedFiles))
+	}
+	return b.String()
+}
+
+// repoMapLayers lists the packages of each layer, the larger packages
+// counting as more important
+func (r *BuildGraphResult) repoMapLayers() repoMapSection {
+	section := repoMapSection{
+		title:   "Package layers (each imports only from the layers before it)",
+		grouped: true,
+	}
+	layers, err := r.GetPackageLayers()
+	if err != nil {
+		return section
+	}
+	files := make(map[string]int)
+	for filePath := range r.Builder.GetFiles() {
+		files[path.Dir(strings.ReplaceAll(filePath, "\\", "/"))]++
+	}
+	for i, layer := range layers {
+		for _, pkg := range layer {
+			section.items = append(section.items, repoMapItem{text: pkg, group: i, importance: files[pkg]})
+		}
+	}
+	return section
+}
+
+// repoMapEntryPoints lists the main functions, Python scripts and CLI
+// commands; mains come first, then commands by their number of flags
+func (r *BuildGraphResult) repoMapEntryPoints() repoMapSection {
+	section := repoMapSection{title: "Entry points"}
+
+	for _, function := range r.entitiesOfType(entities.EntityTypeFunction) {
+		if function.Name == "main" && !function.IsTestFile() && strings.HasSuffix(function.FilePath, ".go") {
+			section.items = append(section.items, repoMapItem{
+				text:       fmt.Sprintf("main (%s:%d)", function.FilePath, function.StartLine()),
+				importance: math.MaxInt,
+			})
+		}
+	}
+
+	scripts := make([]string, 0)
+	for filePath, file := range r.Builder.GetFiles() {
+		if file.Language != "python" {
+			continue
+		}
+		if i := bytes.Index(file.Content, []byte("__main__")); i >= 0 && bytes.Contains(file.Content, []byte("__name__")) {
+			line := bytes.Count(file.Content[:i], []byte("\n")) + 1
+			scripts = append(scripts, fmt.Sprintf("python script (%s:%d)", filePath, line))
+		}
+	}
+	sort.Strings(scripts)
+	for _, script := range scripts {
+		section.items = append(section.items, repoMapItem{text: script, importance: math.MaxInt})
+	}
+
+	// Commands by file and name, in the order their first flag is defined
+	type command struct {
+		name, file string
+		line       int
+		flags      int
+	}
+	commands := make([]*command, 0)
+	byKey := make(map[string]*command)
+	flags, _ := r.GetCLIFlags()
+	for _, flag := range flags {
+		name, _ := flag.GetProperty("command").(string)
+		key := flag.FilePath + "\x00" + name
+		if byKey[key] == nil {
+			byKey[key] = &command{name: name, file: flag.FilePath, line: flag.StartLine()}
+			commands = append(commands, byKey[key])
+		}
+		byKey[key].flags++
+	}
+	sort.SliceStable(commands, func(i, j int) bool { return commands[i].flags > commands[j].flags })
+	for _, c := range commands {
+		name := "root command"
+		if c.name != "" {
+			name = fmt.Sprintf("command %q", c.name)
+		}
+		flags := "1 flag"
+		if c.flags != 1 {
+			flags = fmt.Sprintf("%d flags", c.flags)
+		}
+		section.items = append(section.items, repoMapItem{
+			text:       fmt.Sprintf("%s, %s (%s:%d)", name, flags, c.file, c.line),
+			importance: c.flags,
+		})
+	}
+	return section
+}
+
+// repoMapEndpoints lists the HTTP endpoints by path, those whose handler is
+// the most complex counting as the most important
+func (r *BuildGraphResult) repoMapEndpoints() repoMapSection {
+	section := repoMapSection{title: "HTTP endpoints"}
+
+	type endpoint struct {
+		method, path string
+		item         repoMapItem
+	}
+	endpoints := make([]endpoint, 0)
+	for _, e := range r.entitiesOfType(entities.EntityTypeEndpoint) {
+		if e.IsIgnored() {
+			continue
+		}
+		route, _ := e.GetProperty("path").(string)
+		if route == "" {
+			route = e.Name
+		}
+		method, _ := e.GetProperty("method").(string)
+		method = strings.ToUpper(method)
+		if method == "" {
+			method = "ANY"
+		}
+		handler, _ := e.GetProperty("handler").(string)
+
+		text := fmt.Sprintf("%s %s (%s:%d)", method, route, e.FilePath, e.StartLine())
+		complexity := 0
+		if handler != "" {
+			text = fmt.Sprintf("%s %s -> %s (%s:%d)", method, route, handler, e.FilePath, e.StartLine())
+			for _, candidate := range r.Builder.GetEntitiesByName(handler) {
+				if c, ok := candidate.GetProperty("complexity").(int); ok && c > complexity {
+					complexity = c
+				}
+			}
+		}
+		endpoints = append(endpoints, endpoint{method, route, repoMapItem{text: text, importance: complexity}})
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].path != endpoints[j].path {
+			return endpoints[i].path < endpoints[j].path
+		}
+		return endpoints[i].method < endpoints[j].method
+	})
+	for _, e := range endpoints {
+		section.items = append(section.items, e.item)
+	}
+	return section
+}
+
+// repoMapDeclarations lists the exported types and functions referenced
+// from the most other places, most referenced first, with the first
+// sentence of their documentation
+func (r *BuildGraphResult) repoMapDeclarations() repoMapSection {
+	section := repoMapSection{title: "Key declarations (most referenced first)"}
+
+	candidates := make(map[string]*entities.Entity)
+	for _, e := range r.entitiesOfType(entities.EntityTypeStruct, entities.EntityTypeInterface,
+		entities.EntityTypeClass, entities.EntityTypeType, entities.EntityTypeEnum, entities.EntityTypeFunction) {
+		if exported, _ := e.GetProperty("exported").(bool); exported && !e.IsTestFile() && !e.IsIgnored() {
+			candidates[e.ID] = e
+		}
+	}
+
+	// Distinct referencing entities outside each declaration
+	referrers := make(map[string]map[string]bool)
+	for _, rel := range r.Builder.GetAllRelationships() {
+		target := candidates[rel.TargetID]
+		if target == nil || !rel.IsResolved || rel.SourceID == rel.TargetID ||
+			rel.Type == entities.RelationshipTypeContains || rel.Type == entities.RelationshipTypeDefines {
+			continue
+		}
+		source := r.Builder.GetEntity(rel.SourceID)
+		if source == nil || withinAny(source, []*entities.Entity{target}) {
+			continue
+		}
+		if referrers[target.ID] == nil {
+			referrers[target.ID] = make(map[string]bool)
+		}
+		referrers[target.ID][source.ID] = true
+	}
+
+	ranked := make([]*entities.Entity, 0, len(referrers))
+	for id := range referrers {
+		ranked = append(ranked, candidates[id])
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if len(referrers[ranked[i].ID]) != len(referrers[ranked[j].ID]) {
+			return len(referrers[ranked[i].ID]) > len(referrers[ranked[j].ID])
+		}
+		if ranked[i].FilePath != ranked[j].FilePath {
+			return ranked[i].FilePath < ranked[j].FilePath
+		}
+		return ranked[i].StartByte < ranked[j].StartByte
+	})
+	if len(ranked) > repoMapMaxDeclarations {
+		ranked = ranked[:repoMapMaxDeclarations]
+	}
+
+	for _, e := range ranked {
+		references := "1 reference"
+		if n := len(referrers[e.ID]); n != 1 {
+			references = fmt.Sprintf("%d references", n)
+		}
+		text := fmt.Sprintf("%s %s (%s:%d), %s", e.Type, e.GetFullName(), e.FilePath, e.StartLine(), references)
+		if doc := firstSentence(e.DocString); doc != "" {
+			text += ": " + doc
+		}
+		section.items = append(section.items, repoMapItem{text: text, importance: len(referrers[e.ID])})
+	}
+	return section
+}
+
+// firstSentence returns the first sentence of a doc comment or docstring on
+// one line, without comment markers
+func firstSentence(doc string) string {
+	words := make([]string, 0)
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "/*#")
+		line = strings.Trim(strings.TrimSpace(line), `"'`)
+		if line == "" {
+			if len(words) > 0 {
+				break
+			}
+			continue
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	sentence := strings.Join(words, " ")
+	if i := strings.Index(sentence, ". "); i >= 0 {
+		sentence = sentence[:i+1]
+	}
+	return sentence
+}
@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of RouteConflict
+const (
+	RouteConflictDuplicate   = "duplicate_route"
+	RouteConflictOverlapping = "overlapping_route"
+)
+
+// RouteSite is one registration of a conflicting route
+type RouteSite struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler,omitempty"`
+
+	// Router is the object the route was registered on, such as app or
+	// userRouter
+	Router   string `json:"router,omitempty"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+
+	// Entity is the Endpoint
+	Entity *entities.Entity `json:"-"`
+}
+
+// RouteConflict is a set of endpoints that claim the same requests
+type RouteConflict struct {
+	// Kind is "duplicate_route" when the endpoints have the same method and
+	// path, or "overlapping_route" when the path patterns of two endpoints
+	// match some of the same paths, as /users/:id and /users/me do
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+
+	Method string      `json:"method"`
+	Sites  []RouteSite `json:"sites"`
+}
+
+// GetRouteConflicts finds endpoints registered more than once, and endpoints
+// whose path patterns overlap so that which one serves a request depends on
+// the order they were registered in. Paths are compared segment by segment
+// after dropping a trailing slash; path parameters (:id, {id}, <int:id>)
+// match any segment and are equal whatever their name. Methods must be the
+// same, except that an endpoint without a method, or with ANY, conflicts
+// with every method.
+//
+// Only the routes of one router in one file are compared: a router may be
+// mounted under a prefix, so router.get('/') in two route files usually
+// serves two different paths. A route seen twice at the same file and line
+// is one registration.
+//
+// Every site of a conflict is reported: all the registrations of a duplicate,
+// and those of both patterns of an overlap. Endpoints marked with an
+// onyx:ignore comment are skipped. Without a builder the result is nil.
+// Results are ordered by the file and line of their first site.
+//
+// Example:
+//
+//	for _, conflict := range result.GetRouteConflicts() {
+//		fmt.Println(conflict.Message)
+//		for _, site := range conflict.Sites {
+//			fmt.Printf("  %s:%d %s %s\n", site.FilePath, site.Line, site.Method, site.Path)
+//		}
+//	}
+func (r *BuildGraphResult) GetRouteConflicts() []RouteConflict {
+	if r.Builder == nil {
+		return nil
+	}
+
+	// Endpoints by router, method and normalized path, in file and line order
+	type route struct {
+		scope    string
+		method   string
+		segments []string
+		sites    []RouteSite
+	}
+	routes := make([]*route, 0)
+	byKey := make(map[string]*route)
+	registered := make(map[string]bool)
+	for _, endpoint := range r.entitiesOfType(entities.EntityTypeEndpoint) {
+		if endpoint.IsIgnored() {
+			continue
+		}
+		path, _ := endpoint.GetProperty("path").(string)
+		if path == "" {
+			path = endpoint.Name
+		}
+		method, _ := endpoint.GetProperty("method").(string)
+		method = strings.ToUpper(method)
+		if method == "" {
+			method = "ANY"
+		}
+		handler, _ := endpoint.GetProperty("handler").(string)
+		router, _ := endpoint.GetProperty("router").(string)
+
+		segments := routeSegments(path)
+		scope := endpoint.FilePath + "#" + router
+		key := scope + " " + method + " /" + strings.Join(segments, "/")
+		site := fmt.Sprintf("%s:%d", key, endpoint.StartLine())
+		if registered[site] {
+			continue
+		}
+		registered[site] = true
+		if byKey[key] == nil {
+			byKey[key] = &route{scope: scope, method: method, segments: segments}
+			routes = append(routes, byKey[key])
+		}
+		byKey[key].sites = append(byKey[key].sites, RouteSite{
+			Method:   method,
+			Path:     path,
+			Handler:  handler,
+			Router:   router,
+			FilePath: endpoint.FilePath,
+			Line:     endpoint.StartLine(),
+			Entity:   endpoint,
+		})
+	}
+
+	conflicts := make([]RouteConflict, 0)
+	for _, rt := range routes {
+		if len(rt.sites) < 2 {
+			continue
+		}
+		paths := make([]string, len(rt.sites))
+		for i, site := range rt.sites {
+			paths[i] = fmt.Sprintf("%s:%d", site.FilePath, site.Line)
+		}
+		conflicts = append(conflicts, RouteConflict{
+			Kind: RouteConflictDuplicate,
+			Message: fmt.Sprintf("%s %s is registered %d times, at %s",
+				rt.method, rt.sites[0].Path, len(rt.sites), strings.Join(paths, ", ")),
+			Method: rt.method,
+			Sites:  rt.sites,
+		})
+	}
+
+	for i, a := range routes {
+		for _, b := range routes[i+1:] {
+			if a.scope != b.scope || !routeMethodsOverlap(a.method, b.method) || !routeSegmentsOverlap(a.segments, b.segments) {
+				continue
+			}
+			// The same pattern under ANY and a method: a duplicate for
+			// that method rather than an overlap of patterns
+			kind := RouteConflictOverlapping
+			if strings.Join(a.segments, "/") == strings.Join(b.segments, "/") {
+				kind = RouteConflictDuplicate
+			}
+			method := a.method
+			if method == "ANY" {
+				method = b.method
+			}
+			sites := append(append([]RouteSite{}, a.sites...), b.sites...)
+			conflicts = append(conflicts, RouteConflict{
+				Kind: kind,
+				Message: fmt.Sprintf("%s %s (%s:%d) and %s %s (%s:%d) match the same requests",
+					a.method, a.sites[0].Path, a.sites[0].FilePath, a.sites[0].Line,
+					b.method, b.sites[0].Path, b.sites[0].FilePath, b.sites[0].Line),
+				Method: method,
+				Sites:  sites,
+			})
+		}
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		a, b := conflicts[i].Sites[0], conflicts[j].Sites[0]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		return a.Line < b.Line
+	})
+	return conflicts
+}
+
+// routeSegments splits a route path into its segments, replacing each path
+// parameter with {}
+func routeSegments(path string) []string {
+	if idx := strings.IndexAny(path, "?#"); idx >= 0 {
+		path = path[:idx]
+	}
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") ||
+			strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") ||
+			strings.HasPrefix(segment, "<") && strings.HasSuffix(segment, ">") {
+			segments[i] = "{}"
+		}
+	}
+	return segments
+}
+
+// routeMethodsOverlap reports whether two endpoints serve a common method
+func routeMethodsOverlap(a, b string) bool {
+	return a == b || a == "ANY" || b == "ANY"
+}
+
+// routeSegmentsOverlap reports whether some path matches both patterns: they
+// have as many segments and each pair is equal or contains a parameter
+func routeSegmentsOverlap(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && a[i] != "{}" && b[i] != "{}" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// analyzeTypeScript builds a result holding the given TypeScript files, by path
+func analyzeTypeScript(t *testing.T, sources map[string]string) *BuildGraphResult {
+	t.Helper()
+	files := make(map[string]*entities.File)
+	all := make(map[string]*entities.Entity)
+	unresolved := make([]*entities.Relationship, 0)
+	for path, source := range sources {
+		file, relationships, err := analyzer.NewTypeScriptAnalyzer().AnalyzeFile(path, []byte(source))
+		if err != nil {
+			t.Fatalf("analyze %s: %v", path, err)
+		}
+		files[path] = file
+		for _, entity := range file.GetAllEntities() {
+			all[entity.ID] = entity
+		}
+		unresolved = append(unresolved, relationships...)
+	}
+	builder, err := analyzer.RestoreGraphBuilder(files, all, nil, unresolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &BuildGraphResult{Builder: builder}
+}
+
+func TestGetRouteConflicts(t *testing.T) {
+	result := analyzeTypeScript(t, map[string]string{
+		"server.ts": `
+app.get('/api/users', auth, listUsers);
+app.post('/api/users', auth, createUser);
+app.put('/api/users/:id', auth, async (req, res) => { res.json({}); });
+app.delete('/api/users/:id', auth, async (req, res) => { res.status(204).send(); });
+app.get('/api/users/:id', getUser);
+app.get('/api/users/me', getMe);
+app.post('/api/users', createUserAgain);
+const cache = new Map(); cache.get('/api/users');
+`,
+		"routes/users.ts":  "router.get('/', listUsers);\n",
+		"routes/orders.ts": "router.get('/', listOrders);\n",
+	})
+
+	conflicts := result.GetRouteConflicts()
+	if len(conflicts) != 2 {
+		for _, conflict := range conflicts {
+			t.Log(conflict.Message)
+		}
+		t.Fatalf("got %d conflicts, want 2", len(conflicts))
+	}
+
+	duplicate := conflicts[0]
+	if duplicate.Kind != RouteConflictDuplicate || duplicate.Method != "POST" || len(duplicate.Sites) != 2 {
+		t.Errorf("first conflict = %s %s with %d sites, want a POST duplicate with 2", duplicate.Kind, duplicate.Method, len(duplicate.Sites))
+	}
+	if duplicate.Sites[0].Line != 3 || duplicate.Sites[1].Line != 8 {
+		t.Errorf("duplicate sites at lines %d and %d, want 3 and 8", duplicate.Sites[0].Line, duplicate.Sites[1].Line)
+	}
+
+	overlap := conflicts[1]
+	if overlap.Kind != RouteConflictOverlapping || overlap.Method != "GET" {
+		t.Errorf("second conflict = %s %s, want a GET overlap", overlap.Kind, overlap.Method)
+	}
+	for _, site := range overlap.Sites {
+		if site.Path != "/api/users/:id" && site.Path != "/api/users/me" {
+			t.Errorf("overlap site %s %s, want /api/users/:id and /api/users/me", site.Method, site.Path)
+		}
+	}
+
+	methods := make(map[string]string)
+	for _, endpoint := range result.entitiesOfType(entities.EntityTypeEndpoint) {
+		if endpoint.FilePath == "server.ts" && endpoint.StartLine() <= 5 {
+			methods[endpoint.GetProperty("method").(string)] = endpoint.Name
+		}
+	}
+	for method, path := range map[string]string{"GET": "/api/users", "POST": "/api/users", "PUT": "/api/users/:id", "DELETE": "/api/users/:id"} {
+		if methods[method] != path {
+			t.Errorf("%s endpoint = %q, want %q", method, methods[method], path)
+		}
+	}
+}
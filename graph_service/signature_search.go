@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// SignaturePattern describes the shape of a function to search for.
+//
+// Types are compared after normalization: whitespace is ignored, interface{}
+// and any are equivalent, Python typing generics (List[int], Optional[str])
+// match their builtin spellings, TypeScript Array<T> matches T[], and Go import
+// aliases are replaced by the imported package name. A pattern type without a
+// package qualifier also matches qualified types, so "*Request" matches
+// "*http.Request".
+type SignaturePattern struct {
+	// ParamTypes are the parameter types in order. "*" matches any single
+	// parameter and a trailing "..." matches any remaining parameters. A nil
+	// slice matches any parameter list; an empty slice requires none.
+	ParamTypes []string
+
+	// ReturnTypes are the result types in order, with the same wildcards.
+	// A nil slice matches any results; an empty slice requires that the
+	// function declares none.
+	ReturnTypes []string
+
+	// Language restricts matches to one language ("go", "python", "typescript");
+	// empty matches all languages
+	Language string
+}
+
+var (
+	signaturePunctuation   = regexp.MustCompile(`\s*([\[\](){}<>,|*&:?=])\s*`)
+	signatureQualifier     = regexp.MustCompile(`\b([A-Za-z_]\w*)\.`)
+	pythonTypingGenerics   = regexp.MustCompile(`\b(List|Dict|Set|FrozenSet|Tuple|Type)\[`)
+	pythonOptionalType     = regexp.MustCompile(`^Optional\[(.*)\]$`)
+	typescriptArrayGeneric = regexp.MustCompile(`^Array<(.*)>$`)
+)
+
+// FindBySignature returns the functions and methods whose parameter and return
+// types match the pattern, regardless of their names. This finds handler- or
+// callback-shaped functions, such as every net/http handler:
+//
+//	handlers := result.FindBySignature(graph.SignaturePattern{
+//		ParamTypes:  []string{"http.ResponseWriter", "*http.Request"},
+//		ReturnTypes: []string{},
+//		Language:    "go",
+//	})
+//
+// Untyped parameters only match "*". Functions without recorded parameter
+// types (interface method declarations, for example) never match. Results are
+// ordered by file and position.
+func (r *BuildGraphResult) FindBySignature(pattern SignaturePattern) []*entities.Entity {
+	if r.Builder == nil {
+		return nil
+	}
+
+	matches := make([]*entities.Entity, 0)
+	aliasesByFile := make(map[string]map[string]string)
+	for _, entity := range r.entitiesOfType(entities.EntityTypeFunction, entities.EntityTypeMethod) {
+		params, ok := entity.GetProperty("parameter_types").([]string)
+		if !ok {
+			continue
+		}
+		results, _ := entity.GetProperty("result_types").([]string)
+
+		file := r.Builder.GetFile(entity.FilePath)
+		if pattern.Language != "" && (file == nil || !strings.EqualFold(file.Language, pattern.Language)) {
+			continue
+		}
+
+		aliases, seen := aliasesByFile[entity.FilePath]
+		if !seen {
+			aliases = importAliases(file)
+			aliasesByFile[entity.FilePath] = aliases
+		}
+
+		if signatureTypesMatch(pattern.ParamTypes, params, aliases) &&
+			signatureTypesMatch(pattern.ReturnTypes, results, aliases) {
+			matches = append(matches, entity)
+		}
+	}
+
+	return matches
+}
+
+// importAliases maps the aliases of renamed Go imports to the package name
+// they stand for, e.g. nethttp -> http for `nethttp "net/http"`
+func importAliases(file *entities.File) map[string]string {
+	aliases := make(map[string]string)
+	if file == nil {
+		return aliases
+	}
+
+	for _, imp := range file.GetEntitiesByType(entities.EntityTypeImport) {
+		alias, _ := imp.GetProperty("alias").(string)
+		path, _ := imp.GetProperty("path").(string)
+		if alias == "" || alias == "_" || alias == "." || path == "" {
+			continue
+		}
+		aliases[alias] = filepath.Base(path)
+	}
+	return aliases
+}
+
+// signatureTypesMatch compares a pattern type list against declared types
+func signatureTypesMatch(pattern, actual []string, aliases map[string]string) bool {
+	if pattern == nil {
+		return true
+	}
+
+	for i, want := range pattern {
+		if want == "..." && i == len(pattern)-1 {
+			return true
+		}
+		if i >= len(actual) {
+			return false
+		}
+		if want == "*" {
+			continue
+		}
+		if actual[i] == "" || !signatureTypeMatches(want, actual[i], aliases) {
+			return false
+		}
+	}
+	return len(actual) == len(pattern)
+}
+
+// signatureTypeMatches compares a single pattern type against a declared type
+func signatureTypeMatches(want, actual string, aliases map[string]string) bool {
+	want = normalizeSignatureType(want, nil)
+	actual = normalizeSignatureType(actual, aliases)
+	if want == actual {
+		return true
+	}
+	if !strings.Contains(want, ".") {
+		return want == signatureQualifier.ReplaceAllString(actual, "")
+	}
+	return false
+}
+
+// normalizeSignatureType rewrites a type into a canonical spelling so that
+// equivalent types written differently compare equal
+func normalizeSignatureType(t string, aliases map[string]string) string {
+	t = strings.Join(strings.Fields(t), " ")
+	t = signaturePunctuation.ReplaceAllString(t, "$1")
+	t = strings.ReplaceAll(t, "typing.", "")
+
+	if m := pythonOptionalType.FindStringSubmatch(t); m != nil {
+		t = m[1] + "|None"
+	}
+	if m := typescriptArrayGeneric.FindStringSubmatch(t); m != nil {
+		t = m[1] + "[]"
+	}
+	t = pythonTypingGenerics.ReplaceAllStringFunc(t, strings.ToLower)
+
+	if len(aliases) > 0 {
+		t = signatureQualifier.ReplaceAllStringFunc(t, func(qualifier string) string {
+			if pkg, ok := aliases[strings.TrimSuffix(qualifier, ".")]; ok {
+				return pkg + "."
+			}
+			return qualifier
+		})
+	}
+
+	switch t {
+	case "interface{}", "Any":
+		return "any"
+	}
+	return t
+}
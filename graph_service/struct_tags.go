@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of StructTagIssue
+const (
+	TagIssueDuplicateJSONName = analyzer.StructTagDuplicateJSONName
+	TagIssueUnexportedTagged  = analyzer.StructTagUnexportedTagged
+	TagIssueMissingJSONTag    = analyzer.StructTagMissingJSONTag
+	TagIssueMalformedTag      = analyzer.StructTagMalformed
+)
+
+// StructTagIssue is a Go struct field whose tag breaks or risks breaking JSON
+// round-trips
+type StructTagIssue struct {
+	// Kind is one of "duplicate_json_name", "unexported_field_tagged",
+	// "missing_json_tag" or "malformed_tag"
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+
+	Struct   string `json:"struct"`
+	Field    string `json:"field"`
+	FieldID  string `json:"field_id"`
+	Tag      string `json:"tag,omitempty"`
+	FilePath string `json:"file_path"`
+	Line     int    `json:"line"`
+
+	// Entity is the field's Property entity, which links to Issue with a
+	// HAS_ISSUE relationship
+	Entity *entities.Entity `json:"-"`
+
+	// Issue is the StructTagIssue entity stored in the graph
+	Issue *entities.Entity `json:"-"`
+}
+
+// GetStructTagIssues checks the struct tags of Go structs for mistakes that
+// encoding/json does not report:
+//   - two exported fields serialize to the same JSON name, so encoding/json
+//     silently drops them, or all but the only tagged one
+//   - an unexported field has a json tag, which has no effect
+//   - an exported field has no json tag although other fields of the struct
+//     do, so it serializes under its Go name
+//   - a tag is not a list of key:"value" pairs and is ignored entirely
+//
+// Embedded fields are promoted rather than serialized by name and are only
+// checked for malformed tags. Structs and fields marked with an onyx:ignore
+// comment are skipped. Each issue is a StructTagIssue entity of the graph,
+// linked to the field's Property entity by a HAS_ISSUE relationship, so the
+// issues can also be queried:
+//
+//	MATCH (f:Property)-[:HAS_ISSUE]->(i:StructTagIssue) RETURN f.name, i.message
+//
+// Results are ordered by file and line.
+//
+// Example:
+//
+//	issues, err := result.GetStructTagIssues()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, issue := range issues {
+//		fmt.Printf("%s:%d %s.%s: %s\n", issue.FilePath, issue.Line, issue.Struct, issue.Field, issue.Message)
+//	}
+func (r *BuildGraphResult) GetStructTagIssues() ([]*StructTagIssue, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	all := r.Builder.GetAllEntities()
+	issues := make([]*StructTagIssue, 0)
+	for _, entity := range r.entitiesOfType(entities.EntityTypeStructTagIssue) {
+		issue := &StructTagIssue{FilePath: entity.FilePath, Issue: entity}
+		issue.Kind, _ = entity.GetProperty("kind").(string)
+		issue.Message, _ = entity.GetProperty("message").(string)
+		issue.Struct, _ = entity.GetProperty("struct").(string)
+		issue.Field, _ = entity.GetProperty("field").(string)
+		issue.FieldID, _ = entity.GetProperty("field_id").(string)
+		issue.Tag, _ = entity.GetProperty("tag").(string)
+		issue.Line, _ = entity.GetProperty("line").(int)
+		issue.Entity = all[issue.FieldID]
+		issues = append(issues, issue)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].FilePath != issues[j].FilePath {
+			return issues[i].FilePath < issues[j].FilePath
+		}
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Field < issues[j].Field
+	})
+	return issues, nil
+}
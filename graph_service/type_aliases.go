@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// TypeAliasResolution is what a type alias ultimately stands for
+type TypeAliasResolution struct {
+	Alias    string   `json:"alias"`
+	FilePath string   `json:"file_path"`
+	Line     int      `json:"line"`
+	Chain    []string `json:"chain"` // The alias and the aliases it names one after the other, up to its types
+	Types    []string `json:"types"` // The types at the end of every chain, union and intersection members included
+	Cycle    bool     `json:"cycle"` // Whether the alias names itself, directly or through other aliases
+
+	Entity *entities.Entity `json:"-"`
+}
+
+// ResolveTypeAlias follows the ALIASES relationships of the type aliases
+// named name, or of every alias when name is empty, to the types they
+// ultimately stand for. Aliases are TypeScript type aliases and Go types that
+// are neither structs nor interfaces; the Go ones carry "alias", true for
+// type X = Y and false for a defined type X Y.
+//
+// With type UserID = string and type AdminID = UserID, AdminID resolves to
+// the Chain [AdminID UserID string] and the Types [string]. The Chain stops
+// at the first union or intersection, whose members are each resolved in
+// turn and listed in Types. Members that name no type of the repository,
+// such as string, literal types or types of other modules, are listed as
+// written. Results are ordered by file and position.
+//
+// Example:
+//
+//	resolutions, err := result.ResolveTypeAlias("AdminID")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, res := range resolutions {
+//		fmt.Printf("%s: %s\n", strings.Join(res.Chain, " -> "), strings.Join(res.Types, " | "))
+//	}
+func (r *BuildGraphResult) ResolveTypeAlias(name string) ([]*TypeAliasResolution, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	// The resolved ALIASES relationships of each alias, by member position
+	targets := make(map[string]map[int]*entities.Entity)
+	for _, rel := range r.Builder.GetAllRelationships() {
+		if rel.Type != entities.RelationshipTypeAliases || !rel.IsResolved {
+			continue
+		}
+		target := r.Builder.GetEntity(rel.TargetID)
+		position, ok := rel.GetProperty("position").(int)
+		if target == nil || !ok {
+			continue
+		}
+		if targets[rel.SourceID] == nil {
+			targets[rel.SourceID] = make(map[int]*entities.Entity)
+		}
+		targets[rel.SourceID][position] = target
+	}
+
+	resolutions := make([]*TypeAliasResolution, 0)
+	for _, alias := range r.entitiesOfType(entities.EntityTypeType, entities.EntityTypeClass) {
+		if _, ok := alias.GetProperty("alias_members").([]string); !ok || (name != "" && alias.Name != name) {
+			continue
+		}
+		res := &TypeAliasResolution{
+			Alias:    alias.Name,
+			FilePath: alias.FilePath,
+			Line:     alias.StartLine(),
+			Chain:    []string{alias.Name},
+			Types:    make([]string, 0),
+			Entity:   alias,
+		}
+		resolveAliasMembers(alias, targets, res, map[string]bool{alias.ID: true}, true)
+		resolutions = append(resolutions, res)
+	}
+	return resolutions, nil
+}
+
+// resolveAliasMembers adds the types alias stands for to res. While chained
+// is true the alias was reached through single-member aliases only, so the
+// aliases it names extend the Chain.
+func resolveAliasMembers(alias *entities.Entity, targets map[string]map[int]*entities.Entity,
+	res *TypeAliasResolution, visited map[string]bool, chained bool) {
+	members, _ := alias.GetProperty("alias_members").([]string)
+	chained = chained && len(members) == 1
+	for i, member := range members {
+		target := targets[alias.ID][i]
+		if target == nil {
+			addAliasType(res, member, chained)
+			continue
+		}
+		if _, ok := target.GetProperty("alias_members").([]string); !ok {
+			addAliasType(res, target.Name, chained)
+			continue
+		}
+		if visited[target.ID] {
+			res.Cycle = true
+			continue
+		}
+		if chained {
+			res.Chain = append(res.Chain, target.Name)
+		}
+		visited[target.ID] = true
+		resolveAliasMembers(target, targets, res, visited, chained)
+		delete(visited, target.ID)
+	}
+}
+
+// addAliasType records a type an alias ends at, once, ending the Chain with
+// it when the alias is a chain of single-member aliases
+func addAliasType(res *TypeAliasResolution, name string, chained bool) {
+	if chained {
+		res.Chain = append(res.Chain, name)
+	}
+	for _, t := range res.Types {
+		if t == name {
+			return
+		}
+	}
+	res.Types = append(res.Types, name)
+}
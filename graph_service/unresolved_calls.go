@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetUnresolvedCalls returns the call sites whose target could not be determined
+// statically, such as calls through runtime-assigned attributes (self.handler()),
+// container lookups (handlers[key]()) or local variables shadowing a definition.
+// Python calls that fail to resolve after all files are analyzed are included
+// too when they are plain names no definition or import binds, or self.x()
+// calls on a class without base classes that defines no x.
+//
+// Each entity carries the original call expression in the "expression" property,
+// the reason it could not be resolved in "reason", and the enclosing function in
+// "caller_id"/"caller_name". Results are ordered by file and position.
+//
+// Example:
+//
+//	calls, err := result.GetUnresolvedCalls()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, call := range calls {
+//		fmt.Printf("%s:%d %s (%v)\n", call.FilePath, call.StartLine(), call.Name, call.GetProperty("reason"))
+//	}
+func (r *BuildGraphResult) GetUnresolvedCalls() ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	return r.entitiesOfType(entities.EntityTypeUnresolvedCall), nil
+}
@@ -0,0 +1,155 @@
+package graph
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/analyzer"
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// GetUnusedTypes returns the structs, interfaces, classes, type aliases and
+// enums that are never referenced: no resolved relationship other than
+// CONTAINS or DEFINES points at them from outside their own declaration.
+// References from a Go type's own methods, including its receivers, do not
+// count.
+//
+// Exported types are part of the public API and may be used by other
+// repositories, so they are only reported when includeExported is true, which
+// audits for public API that is dead within the repository. Declarations in
+// test files and those marked with an onyx:ignore comment are never reported.
+//
+// Uses are the REFERENCES relationships recorded for the types and variables
+// each declaration names, resolved in the declaration's own package, through
+// the Go package qualifying them, or through a Python or TypeScript import, as
+// well as CALLS, USES, INHERITS, IMPLEMENTS, EMBEDS and the other relationships
+// resolved to the entity. A type that shares its name with a declaration of
+// another package is therefore not kept alive by it. Results are ordered by
+// file and position.
+//
+// Example:
+//
+//	unused, err := result.GetUnusedTypes(false)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, t := range unused {
+//		fmt.Printf("%s:%d %s %s\n", t.FilePath, t.StartLine(), t.Type, t.Name)
+//	}
+func (r *BuildGraphResult) GetUnusedTypes(includeExported bool) ([]*entities.Entity, error) {
+	return r.unusedEntities(includeExported, entities.EntityTypeStruct, entities.EntityTypeInterface,
+		entities.EntityTypeClass, entities.EntityTypeType, entities.EntityTypeEnum)
+}
+
+// GetUnusedVariables returns the package- and module-level variables and
+// constants that are never referenced outside their declaration. Go vars and
+// consts, Python module-level assignments and top-level TypeScript variables
+// are considered; class attributes and local variables are not. Python dunder
+// names such as __version__ are never reported.
+//
+// includeExported and the matching rules are the same as for GetUnusedTypes.
+func (r *BuildGraphResult) GetUnusedVariables(includeExported bool) ([]*entities.Entity, error) {
+	return r.unusedEntities(includeExported, entities.EntityTypeVariable)
+}
+
+// unusedEntities finds the declarations of the given types that no resolved
+// relationship points at from elsewhere
+func (r *BuildGraphResult) unusedEntities(includeExported bool, types ...entities.EntityType) ([]*entities.Entity, error) {
+	if r.Builder == nil {
+		return nil, fmt.Errorf("builder not available")
+	}
+
+	users := inboundSources(r.Builder.GetAllRelationships(), r.Builder.GetEntity)
+	methodsByType := goMethodsByType(r.entitiesOfType(entities.EntityTypeMethod))
+
+	unused := make([]*entities.Entity, 0)
+	for _, entity := range r.entitiesOfType(types...) {
+		exported, known := entity.GetProperty("exported").(bool)
+		if !known || (exported && !includeExported) || entity.IsIgnored() || entities.IsTestFilePath(entity.FilePath) {
+			continue
+		}
+		if entity.Type == entities.EntityTypeVariable && !isTopLevelVariable(entity) {
+			continue
+		}
+
+		// Spans whose references are the declaration itself
+		own := []*entities.Entity{entity}
+		if entity.Type != entities.EntityTypeVariable {
+			own = append(own, methodsByType[path.Dir(entity.FilePath)+"#"+entity.Name]...)
+		}
+
+		used := false
+		for _, user := range users[entity.ID] {
+			if !withinAny(user, own) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			unused = append(unused, entity)
+		}
+	}
+	return unused, nil
+}
+
+// inboundSources indexes the sources of resolved relationships by target ID.
+// CONTAINS and DEFINES only record where a declaration lives, so they are not
+// uses.
+func inboundSources(relationships []*entities.Relationship, lookup func(string) *entities.Entity) map[string][]*entities.Entity {
+	sources := make(map[string][]*entities.Entity)
+	for _, rel := range relationships {
+		if !rel.IsResolved || rel.Type == entities.RelationshipTypeContains || rel.Type == entities.RelationshipTypeDefines {
+			continue
+		}
+		if source := lookup(rel.SourceID); source != nil {
+			sources[rel.TargetID] = append(sources[rel.TargetID], source)
+		}
+	}
+	return sources
+}
+
+// goMethodsByType groups Go methods by package directory and receiver type
+func goMethodsByType(methods []*entities.Entity) map[string][]*entities.Entity {
+	byType := make(map[string][]*entities.Entity)
+	for _, method := range methods {
+		if receiver, ok := method.GetProperty("receiver").(string); ok {
+			key := path.Dir(method.FilePath) + "#" + analyzer.ReceiverTypeName(receiver)
+			byType[key] = append(byType[key], method)
+		}
+	}
+	return byType
+}
+
+// isTopLevelVariable reports whether a variable is declared at package or
+// module level rather than inside a function or class
+func isTopLevelVariable(entity *entities.Entity) bool {
+	if entity.Parent != nil {
+		return false
+	}
+	if strings.HasPrefix(entity.Name, "__") && strings.HasSuffix(entity.Name, "__") {
+		return false
+	}
+	if entity.Node == nil {
+		return true
+	}
+	for parent := entity.Node.Parent(); parent != nil; parent = parent.Parent() {
+		switch parent.Kind() {
+		case "function_declaration", "function_definition", "method_declaration", "method_definition",
+			"function_expression", "arrow_function", "func_literal", "class_definition", "class_body", "lambda":
+			return false
+		}
+	}
+	return true
+}
+
+// withinAny reports whether an entity lies inside one of the entities' source
+// spans
+func withinAny(entity *entities.Entity, spans []*entities.Entity) bool {
+	for _, span := range spans {
+		if entity.FilePath == span.FilePath && entity.StartByte >= span.StartByte && entity.StartByte < span.EndByte {
+			return true
+		}
+	}
+	return false
+}
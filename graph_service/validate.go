@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/onyx/onyx-tui/graph_service/internal/entities"
+)
+
+// Kinds of ConsistencyIssue
+const (
+	ConsistencyDanglingRelationship = "dangling_relationship"
+	ConsistencyMissingProperty      = "missing_property"
+	ConsistencyDuplicateID          = "duplicate_id"
+	ConsistencyOrphanedTest         = "orphaned_test_entity"
+)
+
+// orphanableTestTypes are the test entities that only make sense as part of
+// a test or suite
+var orphanableTestTypes = map[entities.EntityType]bool{
+	entities.EntityTypeTestCase:  true,
+	entities.EntityTypeAssertion: true,
+	entities.EntityTypeMock:      true,
+	entities.EntityTypeFixture:   true,
+}
+
+// testOwnerTypes are the entities a test entity can belong to
+var testOwnerTypes = map[entities.EntityType]bool{
+	entities.EntityTypeTestFunction: true,
+	entities.EntityTypeTestCase:     true,
+	entities.EntityTypeTestSuite:    true,
+}
+
+// ConsistencyIssue is a defect of the analyzed graph itself rather than of
+// the code: an edge or entity that a correct build would not produce
+type ConsistencyIssue struct {
+	// Kind is one of "dangling_relationship", "missing_property",
+	// "duplicate_id" or "orphaned_test_entity"
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+
+	EntityID       string `json:"entity_id,omitempty"`
+	RelationshipID string `json:"relationship_id,omitempty"`
+	FilePath       string `json:"file_path,omitempty"`
+}
+
+// Validate checks the graph held by the builder for inconsistencies, the
+// kind stale incremental updates and merges leave behind:
+//   - resolved relationships whose source or target is neither an entity
+//     nor a file; unresolved ones point at a name by design
+//   - entities without an ID, a name or a file_path, or whose file_path is
+//     not an analyzed file
+//   - two different entities of the analyzed files sharing an ID, of which
+//     the graph keeps only one
+//   - test cases, assertions, mocks and fixtures that belong to no test
+//     function, test case or suite, by parent or by relationship
+//
+// An empty result means the graph is consistent; without a builder there is
+// nothing to check and the result is nil. Results are ordered by file, then
+// kind. BuildGraphOptions.ValidateAfterBuild runs it after every build.
+//
+// Example:
+//
+//	for _, issue := range result.Validate() {
+//		fmt.Printf("%s: %s\n", issue.Kind, issue.Message)
+//	}
+func (r *BuildGraphResult) Validate() []ConsistencyIssue {
+	if r.Builder == nil {
+		return nil
+	}
+
+	files := r.Builder.GetFiles()
+	all := r.Builder.GetAllEntities()
+	issues := make([]ConsistencyIssue, 0)
+
+	// Duplicate IDs: the graph keeps one entity per ID, so look for the
+	// others in the files they were found in
+	seen := make(map[string]*entities.Entity)
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, entity := range files[path].GetAllEntities() {
+			if first, ok := seen[entity.ID]; ok && first != entity {
+				issues = append(issues, ConsistencyIssue{
+					Kind: ConsistencyDuplicateID,
+					Message: fmt.Sprintf("%s %s in %s and %s %s in %s share the ID %s",
+						first.Type, first.Name, first.FilePath, entity.Type, entity.Name, entity.FilePath, entity.ID),
+					EntityID: entity.ID,
+					FilePath: entity.FilePath,
+				})
+				continue
+			}
+			seen[entity.ID] = entity
+		}
+	}
+
+	for _, entity := range all {
+		// Feature flags are shared by the files checking them and need
+		// not have a file of their own
+		shared := entity.Type == entities.EntityTypeFeatureFlag
+		missing := ""
+		switch {
+		case entity.ID == "":
+			missing = "id"
+		case entity.Name == "":
+			missing = "name"
+		case entity.FilePath == "" && !shared:
+			missing = "file_path"
+		}
+		if missing != "" {
+			issues = append(issues, ConsistencyIssue{
+				Kind:     ConsistencyMissingProperty,
+				Message:  fmt.Sprintf("%s %s (%s) has no %s", entity.Type, entity.Name, entity.ID, missing),
+				EntityID: entity.ID,
+				FilePath: entity.FilePath,
+			})
+		} else if files[entity.FilePath] == nil && !shared {
+			issues = append(issues, ConsistencyIssue{
+				Kind:     ConsistencyMissingProperty,
+				Message:  fmt.Sprintf("%s %s (%s) has file_path %s, which is not an analyzed file", entity.Type, entity.Name, entity.ID, entity.FilePath),
+				EntityID: entity.ID,
+				FilePath: entity.FilePath,
+			})
+		}
+	}
+
+	exists := func(id string) bool {
+		return all[id] != nil || files[id] != nil
+	}
+	owned := make(map[string]bool)
+	for _, rel := range r.Builder.GetAllRelationships() {
+		for _, end := range []struct{ role, id string }{{"source", rel.SourceID}, {"target", rel.TargetID}} {
+			// The target of an unresolved relationship is a name, such as
+			// a call into the standard library, by design
+			if !rel.IsResolved || exists(end.id) {
+				continue
+			}
+			filePath := ""
+			if rel.Location != nil {
+				filePath = rel.Location.FilePath
+			}
+			issues = append(issues, ConsistencyIssue{
+				Kind:           ConsistencyDanglingRelationship,
+				Message:        fmt.Sprintf("%s relationship %s has %s %s, which is not in the graph", rel.Type, rel.ID, end.role, end.id),
+				RelationshipID: rel.ID,
+				FilePath:       filePath,
+			})
+		}
+		if source, target := all[rel.SourceID], all[rel.TargetID]; source != nil && target != nil {
+			if testOwnerTypes[source.Type] {
+				owned[target.ID] = true
+			}
+			if testOwnerTypes[target.Type] {
+				owned[source.ID] = true
+			}
+		}
+	}
+
+	for _, entity := range all {
+		if !orphanableTestTypes[entity.Type] || owned[entity.ID] {
+			continue
+		}
+		if entity.Parent != nil && testOwnerTypes[entity.Parent.Type] && all[entity.Parent.ID] == entity.Parent {
+			continue
+		}
+		if owner, ok := entity.GetProperty("test_function").(string); ok && all[owner] != nil {
+			continue
+		}
+		issues = append(issues, ConsistencyIssue{
+			Kind:     ConsistencyOrphanedTest,
+			Message:  fmt.Sprintf("%s %s (%s) belongs to no test function, test case or suite", entity.Type, entity.Name, entity.ID),
+			EntityID: entity.ID,
+			FilePath: entity.FilePath,
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].FilePath != issues[j].FilePath {
+			return issues[i].FilePath < issues[j].FilePath
+		}
+		if issues[i].Kind != issues[j].Kind {
+			return issues[i].Kind < issues[j].Kind
+		}
+		return issues[i].Message < issues[j].Message
+	})
+	return issues
+}
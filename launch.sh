@@ -97,7 +97,7 @@ echo -e "${GREEN}✓${NC} TUI built successfully"
 echo ""
 
 # Clean up old log files
-rm -f onyx-tui.log agent-error.log
+rm -f onyx-tui.log agent-error.log "$HOME/.local/lib/onyx-tui/agent-error.log"
 
 # Check for API key in environment
 if [ -z "$OPENAI_API_KEY" ]; then
@@ -120,7 +120,7 @@ echo "  • Use Ctrl+C to quit anytime"
 echo ""
 echo -e "${YELLOW}Debug logs:${NC}"
 echo "  • TUI logs: onyx-tui.log"
-echo "  • Agent errors: agent-error.log"
+echo "  • Agent errors: ~/.local/lib/onyx-tui/agent-error.log (Ctrl+L in the TUI)"
 echo ""
 
 # Run the TUI
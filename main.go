@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,6 +33,8 @@ const (
 	MsgStreamChunk  MessageType = "stream_chunk"
 	MsgRunCypher    MessageType = "run_cypher"
 	MsgCypherResult MessageType = "cypher_result"
+	MsgCancel       MessageType = "cancel"
+	MsgContext      MessageType = "context"
 )
 
 type AgentMessage struct {
@@ -49,8 +52,9 @@ const (
 
 // Chat message for display
 type ChatMessage struct {
-	Role      string // "user", "assistant", "system", "tool"
+	Role      string // "user", "assistant", "system", "tool", "result"
 	Content   string
+	Detail    string // Shown instead of Content while results are expanded (Ctrl+O)
 	Timestamp time.Time
 	IsError   bool
 }
@@ -78,6 +82,18 @@ type Model struct {
 	isProcessing bool
 	graphResult  *graph.BuildGraphResult // Store the graph database connection
 	workDir      string                  // Store the working directory
+	turnCtx      context.Context         // Cancelled when the user aborts the current request
+	cancelTurn   context.CancelFunc
+	usage        tokenUsage                  // Estimated tokens and cost for the session
+	showStats    bool                        // Whether the graph stats panel is shown (Ctrl+G)
+	lastQuery    string                      // Last Cypher query run for the agent
+	expanded     bool                        // Whether query results show all rows (Ctrl+O)
+	coverage     float64                     // Test coverage percentage of the graph, -1 if unknown
+	agentLog     *agentLog                   // Recent stderr of the agent
+	showLog      bool                        // Whether the agent log panel is shown (Ctrl+L)
+	streams      map[string]*assistantStream // Streamed assistant messages by turn id
+	queries      map[int]bool                // IDs of the Cypher queries of the current request still running
+	lastQueryID  int                         // ID given to the most recent Cypher query
 }
 
 // Styles
@@ -147,6 +163,33 @@ func initialModel() Model {
 	vp := viewport.New(80, 20)
 	vp.SetContent("Welcome to Onyx AI TUI!\n\nPlease enter your OpenAI API key to begin.")
 
+	return Model{
+		state:       StateAPIKey,
+		apiKeyInput: ti,
+		chatInput:   ta,
+		viewport:    vp,
+		messages:    []ChatMessage{},
+		agentReady:  false,
+		workDir:     resolveWorkDir(),
+		coverage:    -1,
+		agentLog:    newAgentLog(),
+	}
+}
+
+// onyxDir returns the directory onyx-tui writes its logs to
+func onyxDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".local", "lib", "onyx-tui")
+}
+
+// agentErrorLogPath returns the file the agent's stderr is written to
+func agentErrorLogPath() string {
+	return filepath.Join(onyxDir(), "agent-error.log")
+}
+
+// resolveWorkDir returns the repository the TUI works on: $ONYX_WORK_DIR, or
+// the current directory
+func resolveWorkDir() string {
 	workDir := os.Getenv("ONYX_WORK_DIR")
 	if workDir == "" {
 		if wd, err := os.Getwd(); err == nil {
@@ -155,16 +198,18 @@ func initialModel() Model {
 			workDir = "."
 		}
 	}
+	return workDir
+}
 
-	return Model{
-		state:       StateAPIKey,
-		apiKeyInput: ti,
-		chatInput:   ta,
-		viewport:    vp,
-		messages:    []ChatMessage{},
-		agentReady:  false,
-		workDir:     workDir,
+// graphTimeLimit returns the time the graph build may take before the TUI
+// settles for partial results: $ONYX_GRAPH_TIME_LIMIT as a duration such as
+// "30s", or no limit when unset or invalid
+func graphTimeLimit() time.Duration {
+	limit, err := time.ParseDuration(os.Getenv("ONYX_GRAPH_TIME_LIMIT"))
+	if err != nil || limit < 0 {
+		return 0
 	}
+	return limit
 }
 
 func (m Model) Init() tea.Cmd {
@@ -178,26 +223,29 @@ type agentStartedMsg struct {
 }
 
 type graphBuiltMsg struct {
-	result *graph.BuildGraphResult
-	err    error
+	result   *graph.BuildGraphResult
+	coverage float64 // -1 if it could not be computed
+	err      error
 }
 
 type cypherResultMsg struct {
+	queryID   int // Matches Model.queries while the result is still wanted
 	requestID string
 	result    string
+	rows      *graph.QueryRows // Shown in the chat; nil on error
 	err       error
 }
 
 func (m Model) startAgent(apiKey string) tea.Cmd {
 	return func() tea.Msg {
 		// Check if agent directory exists
-		if _, err := os.Stat("./agent"); os.IsNotExist(err) {
+		if _, err := os.Stat(agentDir); os.IsNotExist(err) {
 			return errMsg{err: fmt.Errorf("agent directory not found. Please run from the onyx-tui directory")}
 		}
 
 		// Start the TypeScript agent process
 		cmd := exec.Command("npm", "start")
-		cmd.Dir = "./agent"
+		cmd.Dir = agentDir
 
 		// Set up pipes
 		stdin, err := cmd.StdinPipe()
@@ -210,15 +258,18 @@ func (m Model) startAgent(apiKey string) tea.Cmd {
 			return errMsg{err: fmt.Errorf("failed to create stdout pipe: %w", err)}
 		}
 
-		// Redirect stderr to a file for debugging
-		errFile, err := os.Create("agent-error.log")
+		// Keep stderr in a file for debugging and in memory for the log
+		// panel
+		cmd.Stderr = m.agentLog
+		os.MkdirAll(onyxDir(), 0o755)
+		errFile, err := os.Create(agentErrorLogPath())
 		if err == nil {
-			cmd.Stderr = errFile
+			cmd.Stderr = io.MultiWriter(errFile, m.agentLog)
 		}
 
 		// Start the process
 		if err := cmd.Start(); err != nil {
-			return errMsg{err: fmt.Errorf("failed to start agent: %w", err)}
+			return errMsg{err: fmt.Errorf("failed to start agent (run `onyx-tui doctor` to check your setup): %w", err)}
 		}
 
 		// Send initialization message
@@ -297,6 +348,73 @@ func (m Model) sendChatMessage(message string) tea.Cmd {
 	}
 }
 
+// sendCancel asks the agent to abort the request it is currently processing
+func (m Model) sendCancel() tea.Cmd {
+	return func() tea.Msg {
+		if m.agentStdin == nil {
+			return nil
+		}
+
+		msgBytes, _ := json.Marshal(AgentMessage{Type: MsgCancel})
+		m.agentStdin.Write(msgBytes)
+		m.agentStdin.Write([]byte("\n"))
+
+		return nil
+	}
+}
+
+// repoMapTokens is the budget of the repository map added to the agent's
+// system prompt, small next to the model's context window
+const repoMapTokens = 2000
+
+// sendRepoMap gives the agent an overview of the repository for its system
+// prompt, once the graph is built
+func (m Model) sendRepoMap(repoMap string) tea.Cmd {
+	return func() tea.Msg {
+		if m.agentStdin == nil {
+			return nil
+		}
+
+		data, err := json.Marshal(map[string]string{"repo_map": repoMap})
+		if err != nil {
+			log.Printf("Failed to marshal repository map: %v", err)
+			return nil
+		}
+		msgBytes, _ := json.Marshal(AgentMessage{Type: MsgContext, Data: json.RawMessage(data)})
+		m.agentStdin.Write(msgBytes)
+		m.agentStdin.Write([]byte("\n"))
+
+		return nil
+	}
+}
+
+// beginTurn starts a new cancellable agent request
+func (m *Model) beginTurn() {
+	m.turnCtx, m.cancelTurn = context.WithCancel(context.Background())
+	m.isProcessing = true
+	m.queries = nil // Results of an earlier request's queries are stale
+	m.usage.recordPrompt(m.messages)
+}
+
+// cancelCurrentTurn aborts the in-flight agent request and any Cypher queries it started
+func (m *Model) cancelCurrentTurn() tea.Cmd {
+	if m.cancelTurn != nil {
+		m.cancelTurn()
+		m.cancelTurn = nil
+	}
+	m.isProcessing = false
+	m.usage.finishResponse(nil, "")
+	m.streams = nil
+	m.queries = nil // Results of the cancelled queries are dropped
+	m.messages = append(m.messages, ChatMessage{
+		Role:      "system",
+		Content:   "⏹ Request cancelled",
+		Timestamp: time.Now(),
+	})
+	m.updateViewport()
+	return m.sendCancel()
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
@@ -304,7 +422,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
+			// Esc cancels an in-flight request instead of quitting
+			if msg.Type == tea.KeyEsc && m.state == StateChat && m.isProcessing {
+				cmds = append(cmds, m.cancelCurrentTurn())
+				break
+			}
+
 			// Clean shutdown
+			if m.cancelTurn != nil {
+				m.cancelTurn()
+			}
 			if m.agentProcess != nil {
 				m.agentProcess.Process.Kill()
 			}
@@ -330,12 +457,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						Timestamp: time.Now(),
 					})
 					m.chatInput.Reset()
-					m.isProcessing = true
+					m.beginTurn()
 					m.updateViewport()
 					cmds = append(cmds, m.sendChatMessage(message))
 				}
 			}
 
+		case tea.KeyCtrlG:
+			// Toggle the graph stats panel
+			if m.state == StateChat {
+				m.showStats = !m.showStats
+				m.layout()
+				m.updateViewport()
+			}
+
+		case tea.KeyCtrlL:
+			// Toggle the panel tailing the agent's stderr
+			if m.state == StateChat {
+				m.showLog = !m.showLog
+				m.layout()
+				m.updateViewport()
+			}
+
+		case tea.KeyCtrlO:
+			// Expand or collapse the query results shown in the chat
+			if m.state == StateChat {
+				m.expanded = !m.expanded
+				m.updateViewport()
+			}
+
 		case tea.KeyCtrlS:
 			// Send message with Ctrl+S in chat mode
 			if m.state == StateChat {
@@ -347,7 +497,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						Timestamp: time.Now(),
 					})
 					m.chatInput.Reset()
-					m.isProcessing = true
+					m.beginTurn()
 					m.updateViewport()
 					cmds = append(cmds, m.sendChatMessage(message))
 				}
@@ -358,29 +508,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		// Update viewport size
-		headerHeight := 6
-		footerHeight := 8
-		m.viewport.Width = m.width - 4
-		m.viewport.Height = m.height - headerHeight - footerHeight
-
-		// Update chat input width
-		m.chatInput.SetWidth(m.width - 8)
-
+		m.layout()
 		m.updateViewport()
 
 	case agentStartedMsg:
 		// Agent process started successfully
+		if prompt, err := os.ReadFile(filepath.Join(agentDir, "SYSTEM_MAIN.md")); err == nil {
+			m.usage.setSystemPrompt(string(prompt))
+		}
 		m.agentProcess = msg.process
 		m.agentStdin = msg.stdin
 		m.agentStdout = msg.stdout
 
-		// Start listening to agent output
-		cmds = append(cmds, m.listenToAgent())
+		// Start listening to agent output, and following its stderr
+		cmds = append(cmds, m.listenToAgent(), m.agentLog.wait())
 
 		// Start building the graph database
 		cmds = append(cmds, m.buildGraph())
 
+	case agentLogMsg:
+		// The log panel reads the lines when rendering; keep following
+		cmds = append(cmds, m.agentLog.wait())
+
 	case agentResponseMsg:
 		// Continue listening
 		cmds = append(cmds, m.listenToAgent())
@@ -388,9 +537,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.message.Type {
 		case MsgResponse:
 			var respData struct {
-				Status  string `json:"status"`
-				Message string `json:"message"`
-				Content string `json:"content"`
+				Status    string         `json:"status"`
+				Message   string         `json:"message"`
+				Content   string         `json:"content"`
+				MessageID string         `json:"message_id"`
+				Usage     *reportedUsage `json:"usage"` // Of every step of the request
 			}
 			json.Unmarshal(msg.message.Data, &respData)
 
@@ -405,25 +556,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Timestamp: time.Now(),
 				})
 			} else if respData.Content != "" {
-				// Check if we already have this content from streaming
-				// If the last message is from assistant with the same content, don't duplicate
-				if len(m.messages) > 0 &&
-					m.messages[len(m.messages)-1].Role == "assistant" &&
-					m.messages[len(m.messages)-1].Content == respData.Content {
-					// Already have this content from streaming, just update processing state
-					m.isProcessing = false
-				} else if len(m.messages) == 0 || m.messages[len(m.messages)-1].Role != "assistant" {
-					// No assistant message yet, add it
-					m.messages = append(m.messages, ChatMessage{
-						Role:      "assistant",
-						Content:   respData.Content,
-						Timestamp: time.Now(),
-					})
-					m.isProcessing = false
-				} else {
-					// Just mark as done
-					m.isProcessing = false
+				// Add the response unless it already streamed
+				unstreamed := ""
+				if m.finishStream(respData.MessageID, respData.Content) {
+					unstreamed = respData.Content
 				}
+				m.usage.finishResponse(respData.Usage, unstreamed)
+				m.isProcessing = false
 			}
 			m.updateViewport()
 
@@ -547,10 +686,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case MsgStreamChunk:
 			var chunkData struct {
-				Content string `json:"content"`
-				Status  string `json:"status"`
+				Content   string         `json:"content"`
+				Status    string         `json:"status"`
+				MessageID string         `json:"message_id"`
+				Step      int            `json:"step"`
+				Usage     *reportedUsage `json:"usage"` // Of the step that just finished
 			}
 			json.Unmarshal(msg.message.Data, &chunkData)
+			m.usage.recordStep(chunkData.Usage)
 
 			if chunkData.Status == "thinking" {
 				// Show thinking indicator
@@ -560,16 +703,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Timestamp: time.Now(),
 				})
 			} else if chunkData.Content != "" {
-				// Update or append assistant message
-				if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" {
-					m.messages[len(m.messages)-1].Content = chunkData.Content
-				} else {
-					m.messages = append(m.messages, ChatMessage{
-						Role:      "assistant",
-						Content:   chunkData.Content,
-						Timestamp: time.Now(),
-					})
-				}
+				// Update the step's assistant message, or add it
+				m.appendChunk(chunkData.MessageID, chunkData.Step, chunkData.Content)
 			}
 			m.updateViewport()
 
@@ -580,9 +715,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				RequestID string `json:"request_id"`
 			}
 			json.Unmarshal(msg.message.Data, &queryData)
+			m.lastQuery = queryData.Query
 
 			if m.graphResult != nil && m.graphResult.Database != nil {
-				cmds = append(cmds, m.executeCypher(queryData.Query, queryData.RequestID))
+				m.lastQueryID++
+				if m.queries == nil {
+					m.queries = make(map[int]bool)
+				}
+				m.queries[m.lastQueryID] = true
+				cmds = append(cmds, m.executeCypher(m.lastQueryID, queryData.Query, queryData.RequestID))
 			} else {
 				// Send error response if graph is not ready
 				response := AgentMessage{
@@ -605,11 +746,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			m.messages = append(m.messages, ChatMessage{
 				Role:      "system",
-				Content:   fmt.Sprintf("❌ Error: %s", errData.Message),
+				Content:   fmt.Sprintf("❌ Error: %s%s", errData.Message, m.agentErrorSummary()),
 				Timestamp: time.Now(),
 				IsError:   true,
 			})
 			m.isProcessing = false
+			m.usage.finishResponse(nil, "")
+			m.streams = nil
 			m.updateViewport()
 		}
 
@@ -623,16 +766,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			})
 		} else {
 			m.graphResult = msg.result
+			m.coverage = msg.coverage
+			files := fmt.Sprintf("%d files", msg.result.Stats.FilesCount)
+			if msg.result.Partial {
+				files = fmt.Sprintf("%d/%d files (time limit)",
+					msg.result.Stats.FilesCount, msg.result.Stats.FilesCount+len(msg.result.UnanalyzedFiles))
+			}
 			m.messages = append(m.messages, ChatMessage{
 				Role: "system",
-				Content: fmt.Sprintf("✓ Graph database initialized with %d files, %d functions, %d classes",
-					msg.result.Stats.FilesCount, msg.result.Stats.FunctionsCount, msg.result.Stats.ClassesCount),
+				Content: fmt.Sprintf("✓ Graph database initialized with %s, %d functions, %d classes",
+					files, msg.result.Stats.FunctionsCount, msg.result.Stats.ClassesCount),
 				Timestamp: time.Now(),
 			})
+			m.updateViewport()
+			repoMap := msg.result.ExportRepoMap(repoMapTokens)
+			m.usage.setRepoMap(repoMap)
+			return m, m.sendRepoMap(repoMap)
 		}
 		m.updateViewport()
 
 	case cypherResultMsg:
+		// Drop results of queries from a cancelled or earlier request
+		if !m.queries[msg.queryID] {
+			log.Printf("Dropped result of cancelled cypher query %d", msg.queryID)
+			return m, nil
+		}
+		delete(m.queries, msg.queryID)
+
 		// Send the Cypher result back to the agent
 		var responseData map[string]interface{}
 		if msg.err != nil {
@@ -670,12 +830,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			log.Printf("Sent cypher_result to agent: %s", string(msgBytes))
 		}
 
+		// Show the user what the agent learned, below its run_cypher call
+		result := cypherResultMessage(msg.rows, msg.err)
+		result.Timestamp = time.Now()
+		m.messages = append(m.messages, result)
+		m.updateViewport()
+
 	case errMsg:
 		m.err = msg.err
 		m.isProcessing = false
 		m.messages = append(m.messages, ChatMessage{
 			Role:      "system",
-			Content:   fmt.Sprintf("❌ System Error: %s", msg.err.Error()),
+			Content:   fmt.Sprintf("❌ System Error: %s%s", msg.err.Error(), m.agentErrorSummary()),
 			Timestamp: time.Now(),
 			IsError:   true,
 		})
@@ -699,6 +865,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// Indentation of message text, and of tool calls and results, which line up
+// under the text after the "[15:04:05] " timestamp
+const (
+	messageIndent = "  "
+	resultIndent  = "           "
+)
+
+// minWrapWidth is the narrowest a wrapped line is made to keep its
+// indentation; below it, continuation lines start at the margin
+const minWrapWidth = 20
+
+// wrapLine wraps one line of message text to width cells, at spaces where it
+// can. Continuation lines keep the indentation of the line. Widths are
+// measured in visible cells and ANSI escape sequences are never split: a
+// style open at a break is closed there and reopened on the next line.
+func wrapLine(line string, width int) []string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	if width <= 0 || lipgloss.Width(line) <= width {
+		return []string{line}
+	}
+
+	body := strings.TrimLeft(line, " ")
+	lead := line[:len(line)-len(body)]
+	if width-len(lead) < minWrapWidth {
+		lead = ""
+	}
+	wrapped := strings.Split(lipgloss.NewStyle().Width(width-len(lead)).Render(body), "\n")
+	for i := range wrapped {
+		// Render pads every line to the width
+		wrapped[i] = lead + strings.TrimRight(wrapped[i], " ")
+	}
+	return wrapped
+}
+
 func (m *Model) updateViewport() {
 	var content strings.Builder
 
@@ -729,8 +929,29 @@ func (m *Model) updateViewport() {
 
 		// Format based on role
 		if msg.Role == "tool" {
-			// Tool messages get a compact single-line format
-			content.WriteString(fmt.Sprintf("[%s] %s\n", timestamp, toolMsgStyle.Render(msg.Content)))
+			// Tool messages get a compact format, continued under the text
+			for i, line := range wrapLine(msg.Content, m.viewport.Width-len(resultIndent)) {
+				lead := resultIndent
+				if i == 0 {
+					lead = fmt.Sprintf("[%s] ", timestamp)
+				}
+				content.WriteString(lead + toolMsgStyle.Render(line) + "\n")
+			}
+		} else if msg.Role == "result" {
+			// Query results sit under the tool call that produced them
+			text := msg.Content
+			if m.expanded && msg.Detail != "" {
+				text = msg.Detail
+			}
+			style := statusStyle
+			if msg.IsError {
+				style = errorStyle
+			}
+			for _, line := range strings.Split(text, "\n") {
+				for _, wrapped := range wrapLine(line, m.viewport.Width-len(resultIndent)) {
+					content.WriteString(resultIndent + style.Render(wrapped) + "\n")
+				}
+			}
 		} else {
 			// Regular messages with prefix and indentation
 			content.WriteString(fmt.Sprintf("[%s] %s:\n", timestamp, style.Render(prefix)))
@@ -738,7 +959,9 @@ func (m *Model) updateViewport() {
 			// Wrap and indent message content
 			lines := strings.Split(msg.Content, "\n")
 			for _, line := range lines {
-				content.WriteString(fmt.Sprintf("  %s\n", line))
+				for _, wrapped := range wrapLine(line, m.viewport.Width-len(messageIndent)) {
+					content.WriteString(messageIndent + wrapped + "\n")
+				}
 			}
 		}
 		content.WriteString("\n")
@@ -766,6 +989,7 @@ func (m Model) buildGraph() tea.Cmd {
 			DBPath:      dbPath,
 			CleanupDB:   false, // Keep the database for reuse
 			LoadEnvFile: false, // Don't load .env file
+			MaxDuration: graphTimeLimit(),
 		})
 
 		// Restore original stderr
@@ -776,24 +1000,46 @@ func (m Model) buildGraph() tea.Cmd {
 			return graphBuiltMsg{err: err}
 		}
 
-		return graphBuiltMsg{result: result}
+		// The agent's Cypher is model output; it may only read the graph
+		result.ReadOnly = true
+
+		// Coverage is computed once here for the stats panel rather than on
+		// every render
+		coverage := -1.0
+		if metrics, err := result.GetCoverageMetrics(); err == nil {
+			coverage = metrics.CoveragePercentage
+		}
+
+		return graphBuiltMsg{result: result, coverage: coverage}
 	}
 }
 
-func (m Model) executeCypher(query string, requestID string) tea.Cmd {
+func (m Model) executeCypher(queryID int, query string, requestID string) tea.Cmd {
 	return func() tea.Msg {
 		// Log the query for debugging
 		log.Printf("Cypher query: %s", query)
 
 		if m.graphResult == nil || m.graphResult.Database == nil {
 			return cypherResultMsg{
+				queryID:   queryID,
 				requestID: requestID,
 				err:       fmt.Errorf("Graph database not initialized"),
 			}
 		}
 
-		// Execute the Cypher query
-		result, err := m.graphResult.Database.ExecuteQuery(query)
+		ctx := m.turnCtx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		// Execute the Cypher query, aborting it if the user cancels the request.
+		// The agent gets JSON so it can tell columns and values apart; the
+		// rows themselves are shown in the chat.
+		var result string
+		rows, err := m.graphResult.QueryRows(ctx, query)
+		if err == nil {
+			result, err = rows.Format(graph.QueryFormatJSON)
+		}
 
 		// Log the result for debugging
 		if err != nil {
@@ -802,9 +1048,14 @@ func (m Model) executeCypher(query string, requestID string) tea.Cmd {
 			log.Printf("Cypher result: %s", result)
 		}
 
+		if err != nil {
+			rows = nil
+		}
 		return cypherResultMsg{
+			queryID:   queryID,
 			requestID: requestID,
 			result:    result,
+			rows:      rows,
 			err:       err,
 		}
 	}
@@ -846,9 +1097,9 @@ func (m Model) View() string {
 	case StateChat:
 		title := titleStyle.Render("💬 Onyx AI Assistant")
 
-		status := statusStyle.Render(fmt.Sprintf("Connected • %d messages", len(m.messages)))
+		status := statusStyle.Render(fmt.Sprintf("Connected • %d messages • %s", len(m.messages), m.usage))
 		if m.isProcessing {
-			status = statusStyle.Render("Processing... 🔄")
+			status = statusStyle.Render(fmt.Sprintf("Processing... 🔄 • %s", m.usage))
 		}
 
 		header := lipgloss.JoinHorizontal(
@@ -859,6 +1110,16 @@ func (m Model) View() string {
 		)
 
 		chatHistory := m.viewport.View()
+		if m.showStats {
+			if m.statsBeside() {
+				chatHistory = lipgloss.JoinHorizontal(lipgloss.Top, chatHistory, " ", m.statsPanelView())
+			} else {
+				chatHistory = lipgloss.JoinVertical(lipgloss.Left, chatHistory, m.statsPanelView())
+			}
+		}
+		if m.showLog {
+			chatHistory = lipgloss.JoinVertical(lipgloss.Left, chatHistory, m.agentLogView())
+		}
 
 		inputLabel := "Message:"
 		if m.isProcessing {
@@ -871,7 +1132,10 @@ func (m Model) View() string {
 			inputStyle.Render(m.chatInput.View()),
 		)
 
-		help := helpStyle.Render("Ctrl+S to send • Ctrl+C to quit")
+		help := helpStyle.Render("Ctrl+S to send • Ctrl+O to expand results • Ctrl+G for stats • Ctrl+L for agent log • Ctrl+C to quit")
+		if m.isProcessing {
+			help = helpStyle.Render("Esc to cancel • Ctrl+G for stats • Ctrl+L for agent log • Ctrl+C to quit")
+		}
 
 		content = lipgloss.JoinVertical(
 			lipgloss.Left,
@@ -886,9 +1150,12 @@ func (m Model) View() string {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctor(os.Stdout, resolveWorkDir()))
+	}
+
 	// Set up logging - try to create log file but don't fail if we can't
-	homeDir, _ := os.UserHomeDir()
-	logPath := filepath.Join(homeDir, ".local", "lib", "onyx-tui", "onyx-tui.log")
+	logPath := filepath.Join(onyxDir(), "onyx-tui.log")
 	logFile, err := os.Create(logPath)
 	if err == nil {
 		log.SetOutput(logFile)
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Dimensions of the stats panel. It sits beside the chat when the terminal
+// is at least statsSideMinWidth columns wide, and below it otherwise.
+const (
+	statsPanelWidth   = 34 // columns of the side panel, border included
+	statsPanelHeight  = 4  // rows of the bottom panel, border included
+	statsSideMinWidth = 100
+)
+
+var (
+	statsPanelStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#626262")).
+			Padding(0, 1)
+
+	statsHeadingStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#7D56F4"))
+)
+
+// statsBeside reports whether the stats panel is laid out beside the chat
+// rather than below it
+func (m Model) statsBeside() bool {
+	return m.width >= statsSideMinWidth
+}
+
+// layout sizes the chat history and input to the window, leaving room for
+// the stats and agent log panels when they are shown
+func (m *Model) layout() {
+	headerHeight := 6
+	footerHeight := 8
+	m.viewport.Width = m.width - 4
+	m.viewport.Height = m.height - headerHeight - footerHeight
+	if m.showStats {
+		if m.statsBeside() {
+			m.viewport.Width -= statsPanelWidth + 1
+		} else {
+			m.viewport.Height -= statsPanelHeight
+		}
+	}
+	if m.showLog {
+		m.viewport.Height -= agentLogPanelHeight
+	}
+
+	m.chatInput.SetWidth(m.width - 8)
+}
+
+// statsLines returns the figures shown in the stats panel as label, value
+// pairs
+func (m Model) statsLines() [][2]string {
+	lines := make([][2]string, 0, 8)
+	if m.graphResult == nil {
+		lines = append(lines, [2]string{"Graph", "building..."})
+	} else {
+		stats := m.graphResult.Stats
+		lines = append(lines,
+			[2]string{"Files", fmt.Sprintf("%d", stats.FilesCount)},
+			[2]string{"Functions", fmt.Sprintf("%d", stats.FunctionsCount)},
+			[2]string{"Methods", fmt.Sprintf("%d", stats.MethodsCount)},
+			[2]string{"Classes", fmt.Sprintf("%d", stats.ClassesCount)},
+			[2]string{"Calls", fmt.Sprintf("%d", stats.CallsCount)},
+			[2]string{"Errors", fmt.Sprintf("%d", stats.ErrorsCount)},
+		)
+		coverage := "n/a"
+		if m.coverage >= 0 {
+			coverage = fmt.Sprintf("%.1f%%", m.coverage)
+		}
+		lines = append(lines, [2]string{"Coverage", coverage})
+	}
+	return append(lines, [2]string{"Messages", fmt.Sprintf("%d", len(m.messages))})
+}
+
+// statsPanelView renders the stats panel: a column of figures and the last
+// query beside the chat, or a few summary rows below it
+func (m Model) statsPanelView() string {
+	lastQuery := m.lastQuery
+	if lastQuery == "" {
+		lastQuery = "none yet"
+	}
+	lastQuery = strings.Join(strings.Fields(lastQuery), " ")
+
+	if m.statsBeside() {
+		inner := statsPanelWidth - 4 // border and padding
+		var b strings.Builder
+		b.WriteString(statsHeadingStyle.Render("Graph stats") + "\n")
+		for _, line := range m.statsLines() {
+			b.WriteString(fmt.Sprintf("%-10s %s\n", line[0], line[1]))
+		}
+		b.WriteString("\n" + statsHeadingStyle.Render("Last query") + "\n")
+		b.WriteString(lipgloss.NewStyle().Width(inner).Render(lastQuery))
+		return statsPanelStyle.
+			Width(statsPanelWidth - 2).
+			Height(m.viewport.Height - 2).
+			MaxHeight(m.viewport.Height).
+			Render(b.String())
+	}
+
+	// The graph counts take the first row; coverage and the message count,
+	// the last figures, lead the second so they are not cut off
+	inner := m.width - 8
+	figures := make([]string, 0, 8)
+	for _, line := range m.statsLines() {
+		figures = append(figures, line[0]+" "+line[1])
+	}
+	split := max(len(figures)-2, 1)
+	heading := "Graph stats "
+	rows := []string{
+		statsHeadingStyle.Render(heading) + truncate(strings.Join(figures[:split], " • "), inner-len(heading)),
+		truncate(strings.Join(append(figures[split:], "Last query: "+lastQuery), " • "), inner),
+	}
+	return statsPanelStyle.Width(m.width - 6).Render(strings.Join(rows, "\n"))
+}
+
+// truncate shortens unstyled text to at most width columns, marking the cut
+// with an ellipsis
+func truncate(s string, width int) string {
+	if lipgloss.Width(s) <= width || width < 1 {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
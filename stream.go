@@ -0,0 +1,81 @@
+package main
+
+import "time"
+
+// assistantStream assembles the assistant messages of one turn of the agent
+// from its stream chunks. Each chunk carries the full text of one step of the
+// turn, so a chunk sent again after the agent reconnects replaces the step's
+// message instead of adding to it, and steps separated by tool calls stay in
+// the order they were made.
+type assistantStream struct {
+	steps map[int]int // Index in Model.messages of the message of each step
+	last  int         // Highest step received, -1 before the first
+}
+
+// streamFor returns the stream of a turn, starting it if needed. Agents that
+// do not send a message id share the "" stream, whose chunks all have step 0
+// and so replace each other.
+func (m *Model) streamFor(id string) *assistantStream {
+	if m.streams == nil {
+		m.streams = make(map[string]*assistantStream)
+	}
+	s, ok := m.streams[id]
+	if !ok {
+		s = &assistantStream{steps: make(map[int]int), last: -1}
+		m.streams[id] = s
+	}
+	return s
+}
+
+// appendChunk records the text of a step of a turn, updating the step's
+// message when it has one and adding it otherwise
+func (m *Model) appendChunk(id string, step int, content string) {
+	s := m.streamFor(id)
+	if i, ok := s.steps[step]; ok && i < len(m.messages) && m.messages[i].Role == "assistant" {
+		m.messages[i].Content = content
+	} else {
+		s.steps[step] = len(m.messages)
+		m.messages = append(m.messages, ChatMessage{
+			Role:      "assistant",
+			Content:   content,
+			Timestamp: time.Now(),
+		})
+	}
+	s.last = max(s.last, step)
+	m.usage.recordStreaming(m.streamedText(s))
+}
+
+// finishStream reconciles the final response of a turn with what streamed:
+// content that a step already shows is not added again, otherwise it is
+// added as the turn's last message, as for a wrap-up written after the last
+// step, and finishStream reports true. The turn's stream is dropped either
+// way.
+func (m *Model) finishStream(id, content string) bool {
+	s := m.streamFor(id)
+	delete(m.streams, id)
+	for _, i := range s.steps {
+		if i < len(m.messages) && m.messages[i].Role == "assistant" && m.messages[i].Content == content {
+			return false
+		}
+	}
+	m.messages = append(m.messages, ChatMessage{
+		Role:      "assistant",
+		Content:   content,
+		Timestamp: time.Now(),
+	})
+	return true
+}
+
+// streamedText returns the text of every step of a stream, in step order
+func (m *Model) streamedText(s *assistantStream) string {
+	text := ""
+	for step := 0; step <= s.last; step++ {
+		if i, ok := s.steps[step]; ok && i < len(m.messages) {
+			if text != "" {
+				text += "\n\n"
+			}
+			text += m.messages[i].Content
+		}
+	}
+	return text
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pricing used for the session cost estimate, in US dollars per million
+// tokens. The figures match the agent's default model (gpt-5); the estimate
+// is only meant to give an order of magnitude.
+const (
+	inputPricePerMillion  = 1.25
+	outputPricePerMillion = 10.0
+)
+
+// charsPerToken approximates how many characters of English text or code an
+// OpenAI tokenizer packs into one token
+const charsPerToken = 4.0
+
+// tokenUsage tallies the tokens exchanged with the agent during a session.
+// The agent reports the tokens of each step of a request: every step sends
+// the system prompt, the repository map, the conversation so far and the
+// tool calls and results of the earlier steps. Until a step reports, or when
+// none does, a request is estimated from what the TUI sees: the system
+// prompt, repository map and conversation once, and the streamed text.
+type tokenUsage struct {
+	sent     int // prompt tokens of finished requests
+	received int // completion tokens of finished requests
+
+	systemPrompt int // estimated tokens of the agent's system prompt
+	repoMap      int // estimated tokens of the repository map in the system prompt
+
+	// The request in progress: the estimated prompt and streamed text, and
+	// the tokens reported by its steps so far
+	prompt    int
+	streamed  int
+	reported  bool
+	reportIn  int
+	reportOut int
+}
+
+// reportedUsage is the token usage the agent sends for a step or a whole
+// request
+type reportedUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// estimateTokens approximates the token count of text without a tokenizer:
+// roughly four characters per token, but never fewer tokens than words
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	byChars := int(math.Ceil(float64(utf8.RuneCountInString(text)) / charsPerToken))
+	if words := len(strings.Fields(text)); words > byChars {
+		return words
+	}
+	return byChars
+}
+
+// setSystemPrompt records the agent's system prompt, sent with every request
+func (u *tokenUsage) setSystemPrompt(text string) {
+	u.systemPrompt = estimateTokens(text)
+}
+
+// setRepoMap records the repository map the agent adds to its system prompt
+func (u *tokenUsage) setRepoMap(text string) {
+	u.repoMap = estimateTokens(text)
+}
+
+// recordPrompt starts a new request: its prompt is the system prompt, the
+// repository map and the user and assistant messages of the conversation,
+// including the message just sent
+func (u *tokenUsage) recordPrompt(history []ChatMessage) {
+	u.prompt = u.systemPrompt + u.repoMap
+	for _, msg := range history {
+		if msg.Role == "user" || msg.Role == "assistant" {
+			u.prompt += estimateTokens(msg.Content)
+		}
+	}
+	u.streamed = 0
+	u.reported, u.reportIn, u.reportOut = false, 0, 0
+}
+
+// recordStreaming updates the estimate for a response that is still arriving;
+// content is the full text of every step received so far
+func (u *tokenUsage) recordStreaming(content string) {
+	u.streamed = estimateTokens(content)
+}
+
+// recordStep adds the tokens the agent reported for one step of the request
+func (u *tokenUsage) recordStep(usage *reportedUsage) {
+	if usage == nil {
+		return
+	}
+	u.reported = true
+	u.reportIn += usage.InputTokens
+	u.reportOut += usage.OutputTokens
+}
+
+// finishResponse moves the request into the totals. total is the usage the
+// agent reported for all of its steps, if any, and replaces what they
+// reported one by one; unstreamed is final text that did not stream, such
+// as a wrap-up written after the last step. A request that ends without a
+// response keeps what its steps had reported or streamed.
+func (u *tokenUsage) finishResponse(total *reportedUsage, unstreamed string) {
+	if total != nil {
+		u.reported, u.reportIn, u.reportOut = true, total.InputTokens, total.OutputTokens
+	}
+	u.streamed += estimateTokens(unstreamed)
+	in, out := u.current()
+	u.sent += in
+	u.received += out
+	u.prompt, u.streamed = 0, 0
+	u.reported, u.reportIn, u.reportOut = false, 0, 0
+}
+
+// current returns the prompt and completion tokens of the request in
+// progress, as reported by the agent or else estimated
+func (u tokenUsage) current() (int, int) {
+	if u.reported {
+		return u.reportIn, u.reportOut
+	}
+	return u.prompt, u.streamed
+}
+
+// cost returns the estimated cost of the session in US dollars
+func (u tokenUsage) cost() float64 {
+	in, out := u.current()
+	return float64(u.sent+in)*inputPricePerMillion/1e6 + float64(u.received+out)*outputPricePerMillion/1e6
+}
+
+// String formats the usage for the status line, e.g. "~1.2k tokens (↑900 ↓310) • ~$0.0042"
+func (u tokenUsage) String() string {
+	in, out := u.current()
+	sent, received := u.sent+in, u.received+out
+	return fmt.Sprintf("~%s tokens (↑%s ↓%s) • ~$%.4f",
+		formatTokenCount(sent+received), formatTokenCount(sent), formatTokenCount(received), u.cost())
+}
+
+// formatTokenCount abbreviates large counts: 950, 1.2k, 3.4M
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}